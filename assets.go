@@ -0,0 +1,80 @@
+package mytasks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// immutable is the Cache-Control for fingerprinted static files: their
+// name changes with their contents, so a copy never goes stale.
+const immutable = "public, max-age=31536000, immutable"
+
+// assetIndex fingerprints the static files. Each is also served under a
+// name with a hash of its contents in it, e.g. css/styles.3f2a9c1b0d.css,
+// and pages link to that name through the asset template func, so a changed
+// file is fetched again while an unchanged one is never asked for.
+type assetIndex struct {
+	hashed map[string]string // "css/styles.css" -> "css/styles.3f2a9c1b0d.css"
+	files  map[string]string // the reverse
+	etags  map[string]string // "css/styles.css" -> `"3f2a9c1b0d"`
+}
+
+// newAssetIndex hashes every file in fsys.
+func newAssetIndex(fsys fs.FS) (*assetIndex, error) {
+	a := &assetIndex{hashed: map[string]string{}, files: map[string]string{}, etags: map[string]string{}}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:5])
+		ext := path.Ext(name)
+		hashed := strings.TrimSuffix(name, ext) + "." + hash + ext
+		a.hashed[name], a.files[hashed], a.etags[name] = hashed, name, `"`+hash+`"`
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// path returns the fingerprinted name of a static file, relative to
+// /static/. Unknown files, and every file when a is nil as in DEV_MODE,
+// keep their name.
+func (a *assetIndex) path(name string) string {
+	if a == nil {
+		return name
+	}
+	if hashed, ok := a.hashed[name]; ok {
+		return hashed
+	}
+	return name
+}
+
+// handler serves fsys with /static/ stripped. Fingerprinted names get the
+// file cached for good; plain names, still used by the service worker and
+// links saved elsewhere, are revalidated against an ETag of their contents.
+func (a *assetIndex) handler(fsys fs.FS) http.Handler {
+	files := http.FileServer(http.FS(fsys))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		if file, ok := a.files[name]; ok {
+			w.Header().Set("Cache-Control", immutable)
+			r = r.Clone(r.Context())
+			r.URL.Path, r.URL.RawPath = "/"+file, ""
+		} else if etag, ok := a.etags[name]; ok {
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", etag)
+		}
+		files.ServeHTTP(w, r)
+	})
+}
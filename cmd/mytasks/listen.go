@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listen opens the address given in LISTEN: "unix:/path/to.sock" for a Unix
+// socket, otherwise a TCP address such as ":8080" or "127.0.0.1:8080". A
+// stale socket file left by a previous run is removed, and the new one gets
+// the permissions in mode (e.g. "0660") so a proxy in the same group can
+// connect.
+func listen(addr, mode string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, "unix:")
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if path == "" {
+		return nil, errors.New("unix socket path is empty")
+	}
+
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || perm > 0777 {
+		return nil, fmt.Errorf("invalid socket mode %q", mode)
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, fs.FileMode(perm)); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// systemdListener returns the socket passed by systemd socket activation, or
+// nil when the process was started normally. Only the first socket is used.
+// The activation variables are cleared so child processes don't see them.
+func systemdListener() (net.Listener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds < 1 {
+		return nil, nil
+	}
+
+	// Passed sockets start at file descriptor 3, after stdin, stdout and stderr.
+	f := os.NewFile(3, "systemd-socket")
+	defer f.Close()
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("inherited socket: %w", err)
+	}
+	return l, nil
+}
+
+// listenURL describes where the server can be reached, for the startup log.
+func listenURL(addr, basePath string) string {
+	if strings.HasPrefix(addr, "unix:") {
+		return addr
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port) + basePath + "/"
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"mytasks/internal/accesslog"
+	"mytasks/internal/config"
+)
+
+// newLogger builds the process logger. format is "text" or "json"; level is
+// debug, info, warn or error.
+func newLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("invalid log format %q, want text or json", format)
+}
+
+// openAccessLog opens the ACCESS_LOG file with its rotation settings. "-"
+// writes to stdout instead, for containers that collect it.
+func openAccessLog(path string, cfg *config.Config) (io.WriteCloser, error) {
+	if path == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+	maxSize, err := strconv.ParseInt(cfg.Get("ACCESS_LOG_MAX_SIZE", "100"), 10, 64)
+	if err != nil || maxSize < 0 {
+		return nil, fmt.Errorf("invalid ACCESS_LOG_MAX_SIZE %q, want megabytes", cfg.Get("ACCESS_LOG_MAX_SIZE", ""))
+	}
+	keep, err := strconv.Atoi(cfg.Get("ACCESS_LOG_KEEP", "7"))
+	if err != nil || keep < 0 {
+		return nil, fmt.Errorf("invalid ACCESS_LOG_KEEP %q", cfg.Get("ACCESS_LOG_KEEP", ""))
+	}
+	var daily bool
+	switch rotate := cfg.Get("ACCESS_LOG_ROTATE", "daily"); rotate {
+	case "daily":
+		daily = true
+	case "off":
+	default:
+		return nil, fmt.Errorf("invalid ACCESS_LOG_ROTATE %q, want daily or off", rotate)
+	}
+	return accesslog.Open(path, accesslog.Rotation{MaxSize: maxSize << 20, Daily: daily, Keep: keep})
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// fatal logs msg at error level and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
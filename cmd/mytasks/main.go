@@ -0,0 +1,275 @@
+// Command mytasks runs the task manager as a standalone web server.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	// Zone data for the time zone setting, missing from the Alpine image.
+	_ "time/tzdata"
+
+	"mytasks"
+	"mytasks/internal/config"
+)
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "seed":
+			seedMain(os.Args[2:])
+			return
+		case "tui":
+			tuiMain(os.Args[2:])
+			return
+		}
+	}
+
+	// Configuration: flags, then environment, then the -config file
+	cfg, err := config.Load(os.Args[0], os.Args[1:], os.LookupEnv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("invalid configuration", "err", err)
+	}
+	logger, err := newLogger(os.Stderr, cfg.Get("LOG_FORMAT", "text"), cfg.Get("LOG_LEVEL", "info"))
+	if err != nil {
+		fatal("invalid configuration", "err", err)
+	}
+	slog.SetDefault(logger)
+	port := cfg.Get("PORT", "8080")
+	dbPath := cfg.Get("DB_PATH", "./data/mytasks.db")
+	autoCert := autoTLSConfig{
+		Domains:      splitList(cfg.Get("TLS_DOMAINS", "")),
+		CacheDir:     cfg.Get("TLS_CACHE_DIR", filepath.Join(filepath.Dir(dbPath), "certs")),
+		Email:        cfg.Get("TLS_EMAIL", ""),
+		RedirectAddr: cfg.Get("TLS_REDIRECT_ADDR", ":80"),
+	}
+	if len(autoCert.Domains) > 0 && !cfg.IsSet("PORT") {
+		port = "443"
+	}
+	listenAddr := cfg.Get("LISTEN", ":"+port)
+	socketMode := cfg.Get("SOCKET_MODE", "0660")
+	grpcPort := cfg.Get("GRPC_PORT", "")
+
+	app := mytasks.DefaultConfig()
+	app.BasePath = cfg.Get("BASE_PATH", "")
+	app.DBPath = dbPath
+	app.DevMode, _ = strconv.ParseBool(cfg.Get("DEV_MODE", "false"))
+	app.TemplatesOverrideDir = cfg.Get("TEMPLATES_OVERRIDE_DIR", "")
+	app.StaticOverrideDir = cfg.Get("STATIC_OVERRIDE_DIR", "")
+	app.CSP = headerSetting(cfg, "CSP", app.CSP)
+	app.FrameAncestors = headerSetting(cfg, "CSP_FRAME_ANCESTORS", app.FrameAncestors)
+	app.ReferrerPolicy = headerSetting(cfg, "REFERRER_POLICY", app.ReferrerPolicy)
+	app.CORSAllowedOrigins = splitList(cfg.Get("CORS_ALLOWED_ORIGINS", ""))
+	app.TrustedProxies = splitList(cfg.Get("TRUSTED_PROXIES", ""))
+	app.ExternalHosts = splitList(cfg.Get("EXTERNAL_HOSTS", ""))
+	app.DebugToken = cfg.Get("DEBUG_TOKEN", "")
+	app.AdminToken = cfg.Get("ADMIN_TOKEN", "")
+	app.Maintenance, _ = strconv.ParseBool(cfg.Get("MAINTENANCE", "false"))
+	if path := cfg.Get("ACCESS_LOG", ""); path != "" {
+		accessLog, err := openAccessLog(path, cfg)
+		if err != nil {
+			fatal("failed to open access log", "err", err)
+		}
+		defer accessLog.Close()
+		app.AccessLog = accessLog
+		app.AccessLogFormat = cfg.Get("ACCESS_LOG_FORMAT", app.AccessLogFormat)
+	}
+	app.SlackSigningSecret = cfg.Get("SLACK_SIGNING_SECRET", "")
+	app.InboundEmailSigningKey = cfg.Get("INBOUND_EMAIL_SIGNING_KEY", "")
+	app.InboundEmailAllowedSenders = splitList(cfg.Get("INBOUND_EMAIL_ALLOWED_SENDERS", ""))
+	app.GoogleClientID = cfg.Get("GOOGLE_CLIENT_ID", "")
+	app.GoogleClientSecret = cfg.Get("GOOGLE_CLIENT_SECRET", "")
+	app.GoogleRedirectURL = cfg.Get("GOOGLE_REDIRECT_URL", "")
+	app.GoogleCalendarRedirectURL = cfg.Get("GOOGLE_CALENDAR_REDIRECT_URL", "")
+	app.SMTPHost = cfg.Get("SMTP_HOST", "")
+	app.SMTPPort = cfg.Get("SMTP_PORT", app.SMTPPort)
+	app.SMTPUsername = cfg.Get("SMTP_USERNAME", "")
+	app.SMTPPassword = cfg.Get("SMTP_PASSWORD", "")
+	app.SMTPFrom = cfg.Get("SMTP_FROM", "")
+	app.OverdueAlertEmail = splitList(cfg.Get("OVERDUE_ALERT_EMAIL", ""))
+	app.DigestEmail = splitList(cfg.Get("DIGEST_EMAIL", ""))
+	app.BackupDir = cfg.Get("BACKUP_DIR", "")
+	app.BackupKeep, _ = strconv.Atoi(cfg.Get("BACKUP_KEEP", strconv.Itoa(app.BackupKeep)))
+	app.AutoArchiveDays, _ = strconv.Atoi(cfg.Get("AUTO_ARCHIVE_DAYS", "0"))
+	app.JobSchedules, err = parseJobSchedules(cfg.Get("JOB_SCHEDULES", ""))
+	if err != nil {
+		fatal("invalid JOB_SCHEDULES", "err", err)
+	}
+	workspaces, err := parseWorkspaces(cfg.Get("WORKSPACES", ""), dbPath)
+	if err != nil {
+		fatal("invalid WORKSPACES", "err", err)
+	}
+	app.Tenancy = cfg.Get("TENANCY", "")
+	app.TenantDomain = cfg.Get("TENANT_DOMAIN", "")
+	app.DemoMode, _ = strconv.ParseBool(cfg.Get("DEMO_MODE", "false"))
+	if app.DemoMode {
+		if app.DemoReset, err = time.ParseDuration(cfg.Get("DEMO_RESET", app.DemoReset.String())); err != nil {
+			fatal("invalid DEMO_RESET", "err", err)
+		}
+		app.DemoWriteLimit, _ = strconv.Atoi(cfg.Get("DEMO_WRITE_LIMIT", strconv.Itoa(app.DemoWriteLimit)))
+		// The demo keeps nothing: its database lives in memory.
+		dbPath = ":memory:"
+		app.DBPath = dbPath
+	}
+
+	// Initialize store
+	tuning, err := storeTuning(cfg)
+	if err != nil {
+		fatal("invalid configuration", "err", err)
+	}
+	s, err := mytasks.OpenTunedStore(dbPath, tuning)
+	if err != nil {
+		fatal("failed to initialize store", "err", err)
+	}
+	defer s.Close()
+
+	for _, ws := range workspaces {
+		wsStore, err := mytasks.OpenTunedStore(ws.DBPath, tuning)
+		if err != nil {
+			fatal("failed to initialize store", "workspace", ws.Slug, "err", err)
+		}
+		defer wsStore.Close()
+		ws.Store = wsStore
+		app.Workspaces = append(app.Workspaces, ws)
+	}
+
+	srv, err := mytasks.NewServer(app, s)
+	if err != nil {
+		fatal("failed to start", "err", err)
+	}
+	srv.Start(context.Background())
+
+	// Optional gRPC API on a second port
+	if grpcPort != "" {
+		grpcAddr := fmt.Sprintf(":%s", grpcPort)
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			fatal("failed to listen for gRPC", "err", err)
+		}
+		grpcServer := srv.GRPCServer()
+		go func() {
+			slog.Info("starting gRPC server", "addr", grpcAddr)
+			if err := grpcServer.Serve(lis); err != nil {
+				fatal("gRPC server failed", "err", err)
+			}
+		}()
+	}
+
+	// Start server, on the socket systemd passed in if there is one
+	lis, err := systemdListener()
+	if err != nil {
+		fatal("failed to use systemd socket", "err", err)
+	}
+	if lis != nil {
+		listenAddr = lis.Addr().String()
+		if lis.Addr().Network() == "unix" {
+			listenAddr = "unix:" + listenAddr
+		}
+	} else if lis, err = listen(listenAddr, socketMode); err != nil {
+		fatal("failed to listen", "addr", listenAddr, "err", err)
+	}
+	basePath := srv.BasePath()
+	if len(autoCert.Domains) > 0 {
+		lis = autoTLS(lis, autoCert)
+		slog.Info("starting server", "url", "https://"+autoCert.Domains[0]+basePath+"/")
+	} else {
+		slog.Info("starting server", "url", listenURL(listenAddr, basePath))
+	}
+	httpServer := &http.Server{
+		Handler:  srv,
+		ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+	if err := httpServer.Serve(lis); err != nil {
+		fatal("server failed", "err", err)
+	}
+}
+
+// headerSetting reads a header value from the configuration, where "off"
+// leaves the header out.
+func headerSetting(cfg *config.Config, key, defaultValue string) string {
+	if value := cfg.Get(key, defaultValue); value != "off" {
+		return value
+	}
+	return ""
+}
+
+// splitList reads a comma-separated list, ignoring blanks.
+func splitList(list string) []string {
+	var values []string
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// storeTuning reads the SQLITE_* settings. Unset ones keep the defaults.
+func storeTuning(cfg *config.Config) (mytasks.StoreTuning, error) {
+	t := mytasks.DefaultStoreTuning()
+	var err error
+	if v := cfg.Get("SQLITE_CACHE_SIZE", ""); v != "" {
+		if t.CacheSize, err = strconv.Atoi(v); err != nil {
+			return t, fmt.Errorf("invalid SQLITE_CACHE_SIZE %q", v)
+		}
+	}
+	t.Synchronous = cfg.Get("SQLITE_SYNCHRONOUS", t.Synchronous)
+	t.TempStore = cfg.Get("SQLITE_TEMP_STORE", t.TempStore)
+	if v := cfg.Get("SQLITE_MMAP_SIZE", ""); v != "" {
+		if t.MmapSize, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return t, fmt.Errorf("invalid SQLITE_MMAP_SIZE %q", v)
+		}
+	}
+	if v := cfg.Get("SQLITE_BUSY_TIMEOUT", ""); v != "" {
+		if t.BusyTimeout, err = time.ParseDuration(v); err != nil {
+			return t, fmt.Errorf("invalid SQLITE_BUSY_TIMEOUT %q, want a duration such as 5s", v)
+		}
+	}
+	return t, t.Validate()
+}
+
+// parseJobSchedules reads "name=schedule;name=schedule", e.g.
+// "backup=0 4 * * *;email-digest=off".
+func parseJobSchedules(list string) (map[string]string, error) {
+	schedules := make(map[string]string)
+	for _, override := range strings.Split(list, ";") {
+		if strings.TrimSpace(override) == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(override, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected name=schedule, got %q", override)
+		}
+		schedules[strings.TrimSpace(name)] = spec
+	}
+	return schedules, nil
+}
+
+// parseWorkspaces reads "name,name=path", e.g. "personal,side=/data/side.db".
+// A workspace without a path gets name.db next to the main database.
+func parseWorkspaces(list, dbPath string) ([]mytasks.Workspace, error) {
+	var workspaces []mytasks.Workspace
+	for _, entry := range splitList(list) {
+		slug, path, _ := strings.Cut(entry, "=")
+		slug, path = strings.TrimSpace(slug), strings.TrimSpace(path)
+		if slug == "" {
+			return nil, fmt.Errorf("expected name or name=path, got %q", entry)
+		}
+		if path == "" {
+			path = filepath.Join(filepath.Dir(dbPath), slug+".db")
+		}
+		workspaces = append(workspaces, mytasks.Workspace{Slug: slug, DBPath: path})
+	}
+	return workspaces, nil
+}
@@ -0,0 +1,8 @@
+package main
+
+// Plugins are compiled in by importing them here for their side effects, one
+// blank import per plugin:
+//
+//	import _ "example.com/mytasks-ntfy"
+//
+// Each registers itself with plugin.Register from its init function.
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"mytasks"
+	"mytasks/internal/config"
+	"mytasks/internal/seed"
+)
+
+// seedMain runs "mytasks seed [-force] [settings]", which fills the database
+// named by the usual settings with demo projects and tasks.
+func seedMain(args []string) {
+	var force bool
+	var rest []string
+	for _, arg := range args {
+		switch arg {
+		case "-force", "--force":
+			force = true
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	cfg, err := config.Load(os.Args[0]+" seed", rest, os.LookupEnv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, "  -force\n    \tadd the demo data even if the database has projects")
+		return
+	}
+	if err != nil {
+		fatal("invalid configuration", "err", err)
+	}
+	logger, err := newLogger(os.Stderr, cfg.Get("LOG_FORMAT", "text"), cfg.Get("LOG_LEVEL", "info"))
+	if err != nil {
+		fatal("invalid configuration", "err", err)
+	}
+	slog.SetDefault(logger)
+	dbPath := cfg.Get("DB_PATH", "./data/mytasks.db")
+	tuning, err := storeTuning(cfg)
+	if err != nil {
+		fatal("invalid configuration", "err", err)
+	}
+	s, err := mytasks.OpenTunedStore(dbPath, tuning)
+	if err != nil {
+		fatal("failed to initialize store", "err", err)
+	}
+	defer s.Close()
+
+	result, err := seed.Run(context.Background(), s, time.Now(), force)
+	if errors.Is(err, seed.ErrNotEmpty) {
+		s.Close()
+		fatal("not seeding: the database already has projects; use -force to add the demo data anyway", "db", dbPath)
+	}
+	if err != nil {
+		s.Close()
+		fatal("failed to seed", "err", err)
+	}
+	slog.Info("seeded demo data", "db", dbPath, "projects", result.Projects, "tasks", result.Tasks)
+}
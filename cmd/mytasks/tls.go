@@ -0,0 +1,47 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// autoTLSConfig configures HTTPS with certificates from Let's Encrypt.
+type autoTLSConfig struct {
+	Domains      []string
+	CacheDir     string
+	Email        string
+	RedirectAddr string // plain HTTP listener for redirects and ACME challenges; "off" disables it
+}
+
+// autoTLS wraps lis so it serves HTTPS for cfg.Domains, obtaining and
+// renewing certificates on demand, and starts the HTTP listener that answers
+// ACME challenges and redirects everything else to HTTPS.
+func autoTLS(lis net.Listener, cfg autoTLSConfig) net.Listener {
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		Email:      cfg.Email,
+	}
+
+	if cfg.RedirectAddr != "off" {
+		go func() {
+			srv := &http.Server{
+				Addr:              cfg.RedirectAddr,
+				Handler:           m.HTTPHandler(nil),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			if err := srv.ListenAndServe(); err != nil {
+				fatal("HTTP redirect server failed", "err", err)
+			}
+		}()
+	}
+
+	tlsConfig := m.TLSConfig()
+	tlsConfig.MinVersion = tls.VersionTLS12
+	return tls.NewListener(lis, tlsConfig)
+}
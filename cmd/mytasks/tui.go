@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+
+	"mytasks"
+	"mytasks/internal/config"
+	"mytasks/internal/tui"
+)
+
+// tuiMain runs "mytasks tui [settings]", a terminal client working on the
+// database named by the usual settings. It can run alongside the server;
+// SQLite's busy timeout covers the two writing at once.
+func tuiMain(args []string) {
+	cfg, err := config.Load(os.Args[0]+" tui", args, os.LookupEnv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("invalid configuration", "err", err)
+	}
+	// Log lines would scroll the screen away, so only warnings get through.
+	logger, err := newLogger(os.Stderr, cfg.Get("LOG_FORMAT", "text"), cfg.Get("LOG_LEVEL", "warn"))
+	if err != nil {
+		fatal("invalid configuration", "err", err)
+	}
+	slog.SetDefault(logger)
+	tuning, err := storeTuning(cfg)
+	if err != nil {
+		fatal("invalid configuration", "err", err)
+	}
+	s, err := mytasks.OpenTunedStore(cfg.Get("DB_PATH", "./data/mytasks.db"), tuning)
+	if err != nil {
+		fatal("failed to initialize store", "err", err)
+	}
+	defer s.Close()
+
+	ui := tui.New(s, os.Stdin, os.Stdout)
+	ui.Clear = isTerminal(os.Stdout)
+	if err := ui.Run(context.Background()); err != nil {
+		s.Close()
+		fatal("tui failed", "err", err)
+	}
+}
+
+// isTerminal reports whether f is a character device, such as a terminal
+// rather than a pipe or file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,107 @@
+package mytasks
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mytasks/internal/store"
+)
+
+// startedAt is reported as the process uptime in diagnostics.
+var startedAt = time.Now()
+
+// debugRoutes mounts the pprof profiles under /debug/pprof/ and a JSON
+// summary of the process and database at /debug/diagnostics. Every request
+// must present token.
+func debugRoutes(r chi.Router, token, basePath string, s *store.SQLiteStore, dbPath string) {
+	r.Route("/debug", func(r chi.Router) {
+		r.Use(tokenAuth(token, "debug_token", basePath+"/debug"))
+		r.HandleFunc("/pprof/cmdline", pprof.Cmdline)
+		r.HandleFunc("/pprof/profile", pprof.Profile)
+		r.HandleFunc("/pprof/symbol", pprof.Symbol)
+		r.HandleFunc("/pprof/trace", pprof.Trace)
+		r.HandleFunc("/pprof/*", pprof.Index)
+		r.Get("/diagnostics", diagnostics(s, dbPath))
+	})
+}
+
+// Diagnostics is the JSON body of GET /debug/diagnostics.
+type Diagnostics struct {
+	GoVersion  string              `json:"go_version"`
+	Uptime     string              `json:"uptime"`
+	Goroutines int                 `json:"goroutines"`
+	Memory     MemoryDiagnostics   `json:"memory"`
+	Database   DatabaseDiagnostics `json:"database"`
+}
+
+// MemoryDiagnostics summarizes runtime.MemStats, in bytes.
+type MemoryDiagnostics struct {
+	HeapAlloc   uint64 `json:"heap_alloc"`
+	HeapObjects uint64 `json:"heap_objects"`
+	TotalAlloc  uint64 `json:"total_alloc"`
+	Sys         uint64 `json:"sys"`
+	NumGC       uint32 `json:"num_gc"`
+	PauseTotal  string `json:"gc_pause_total"`
+}
+
+// DatabaseDiagnostics reports file sizes in bytes and connection pool
+// statistics. A growing WAL means checkpoints are not keeping up, usually
+// because a reader holds the database open.
+type DatabaseDiagnostics struct {
+	Size            int64  `json:"size"`
+	WALSize         int64  `json:"wal_size"`
+	OpenConnections int    `json:"open_connections"`
+	InUse           int    `json:"in_use"`
+	WaitCount       int64  `json:"wait_count"`
+	WaitDuration    string `json:"wait_duration"`
+}
+
+func diagnostics(s *store.SQLiteStore, dbPath string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		db := s.Stats()
+
+		d := Diagnostics{
+			GoVersion:  runtime.Version(),
+			Uptime:     time.Since(startedAt).Round(time.Second).String(),
+			Goroutines: runtime.NumGoroutine(),
+			Memory: MemoryDiagnostics{
+				HeapAlloc:   mem.HeapAlloc,
+				HeapObjects: mem.HeapObjects,
+				TotalAlloc:  mem.TotalAlloc,
+				Sys:         mem.Sys,
+				NumGC:       mem.NumGC,
+				PauseTotal:  time.Duration(mem.PauseTotalNs).String(),
+			},
+			Database: DatabaseDiagnostics{
+				Size:            fileSize(dbPath),
+				WALSize:         fileSize(dbPath + "-wal"),
+				OpenConnections: db.OpenConnections,
+				InUse:           db.InUse,
+				WaitCount:       db.WaitCount,
+				WaitDuration:    db.WaitDuration.String(),
+			},
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(d)
+	}
+}
+
+// fileSize returns the size of path, or 0 if it does not exist.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
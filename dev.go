@@ -0,0 +1,90 @@
+package mytasks
+
+import (
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// templateReloader re-parses the templates whenever a file in one of the
+// template directories has changed, been added or been removed since the
+// last parse. It backs DEV_MODE, where the built-in templates are read from
+// the working directory rather than the embedded files.
+type templateReloader struct {
+	dirs     []fs.FS
+	basePath string
+	funcs    template.FuncMap
+
+	mu      sync.Mutex
+	tmpl    *template.Template
+	modTime time.Time
+	files   int
+}
+
+func newTemplateReloader(basePath string, funcs template.FuncMap, dirs ...fs.FS) *templateReloader {
+	return &templateReloader{dirs: dirs, basePath: basePath, funcs: funcs}
+}
+
+// Load returns the current templates, parsing them again if they changed. A
+// template that fails to parse is reported on every render until it is
+// fixed.
+func (t *templateReloader) Load() (*template.Template, error) {
+	modTime, files, err := t.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tmpl != nil && modTime.Equal(t.modTime) && files == t.files {
+		return t.tmpl, nil
+	}
+	tmpl, err := parseTemplates(t.basePath, nil, t.funcs, t.dirs...)
+	if err != nil {
+		return nil, err
+	}
+	if t.tmpl != nil {
+		slog.Info("dev: reloaded templates")
+	}
+	t.tmpl, t.modTime, t.files = tmpl, modTime, files
+	return tmpl, nil
+}
+
+// scan returns the latest modification time and number of files across the
+// template directories.
+func (t *templateReloader) scan() (time.Time, int, error) {
+	var latest time.Time
+	files := 0
+	for _, dir := range t.dirs {
+		err := fs.WalkDir(dir, ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			files++
+			if info.ModTime().After(latest) {
+				latest = info.ModTime()
+			}
+			return nil
+		})
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+	}
+	return latest, files, nil
+}
+
+// noStore stops browsers caching responses, so edited static files are
+// fetched again on reload.
+func noStore(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		next.ServeHTTP(w, r)
+	})
+}
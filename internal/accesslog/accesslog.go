@@ -0,0 +1,151 @@
+// Package accesslog writes one line per HTTP request in a format log
+// analyzers understand, to a file that rotates by size and by day.
+package accesslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// entry is what is logged about one request.
+type entry struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id,omitempty"`
+	Remote    string    `json:"remote"`
+	Method    string    `json:"method"`
+	URI       string    `json:"uri"`
+	Proto     string    `json:"proto"`
+	Status    int       `json:"status"`
+	Bytes     int       `json:"bytes"`
+	Duration  float64   `json:"duration_ms"`
+	Referer   string    `json:"referer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+}
+
+// Middleware returns middleware writing a line to w for every request, in
+// format "combined" or "json". Put it after any middleware that sets the
+// client address from proxy headers.
+func Middleware(w io.Writer, format string) (func(http.Handler) http.Handler, error) {
+	var encode func(entry) []byte
+	switch format {
+	case "combined":
+		encode = combined
+	case "json":
+		encode = func(e entry) []byte {
+			line, _ := json.Marshal(e)
+			return append(line, '\n')
+		}
+	default:
+		return nil, fmt.Errorf("invalid access log format %q, want combined or json", format)
+	}
+
+	var mu sync.Mutex
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(rw, r.ProtoMajor)
+			start := time.Now()
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				line := encode(entry{
+					Time:      start,
+					RequestID: rw.Header().Get("X-Request-ID"),
+					Remote:    remoteHost(r.RemoteAddr),
+					Method:    r.Method,
+					URI:       redact(r.RequestURI),
+					Proto:     r.Proto,
+					Status:    status,
+					Bytes:     ww.BytesWritten(),
+					Duration:  float64(time.Since(start).Microseconds()) / 1000,
+					Referer:   redact(r.Referer()),
+					UserAgent: r.UserAgent(),
+				})
+				mu.Lock()
+				w.Write(line)
+				mu.Unlock()
+			}()
+			next.ServeHTTP(ww, r)
+		})
+	}, nil
+}
+
+// secretParams are the query parameters that carry credentials, such as the
+// capture token and OAuth codes. Their values are not logged.
+var secretParams = map[string]bool{
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"code":          true,
+}
+
+// redact replaces the values of secretParams in the query of uri, leaving
+// the rest of it as sent.
+func redact(uri string) string {
+	path, query, ok := strings.Cut(uri, "?")
+	if !ok {
+		return uri
+	}
+	pairs := strings.Split(query, "&")
+	for i, pair := range pairs {
+		name, _, _ := strings.Cut(pair, "=")
+		if secretParams[strings.ToLower(name)] {
+			pairs[i] = name + "=REDACTED"
+		}
+	}
+	return path + "?" + strings.Join(pairs, "&")
+}
+
+// combined formats e like Apache's combined log format:
+//
+//	203.0.113.7 - - [16/Oct/2026:14:03:12 +0200] "GET /tasks HTTP/1.1" 200 5120 "-" "Mozilla/5.0"
+func combined(e entry) []byte {
+	b := make([]byte, 0, 256)
+	b = append(b, e.Remote...)
+	b = append(b, " - - ["...)
+	b = e.Time.AppendFormat(b, "02/Jan/2006:15:04:05 -0700")
+	b = append(b, "] "...)
+	b = strconv.AppendQuote(b, e.Method+" "+e.URI+" "+e.Proto)
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, int64(e.Status), 10)
+	b = append(b, ' ')
+	if e.Bytes == 0 {
+		b = append(b, '-')
+	} else {
+		b = strconv.AppendInt(b, int64(e.Bytes), 10)
+	}
+	b = append(b, ' ')
+	b = strconv.AppendQuote(b, orDash(e.Referer))
+	b = append(b, ' ')
+	b = strconv.AppendQuote(b, orDash(e.UserAgent))
+	return append(b, '\n')
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// remoteHost drops the port from addr, leaving Unix socket and other
+// portless addresses as they are.
+func remoteHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	if addr == "" {
+		return "-"
+	}
+	return addr
+}
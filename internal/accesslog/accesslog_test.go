@@ -0,0 +1,146 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func serve(t *testing.T, format string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	mw, err := Middleware(&buf, format)
+	if err != nil {
+		t.Fatalf("Middleware failed: %v", err)
+	}
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "abc123")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	}))
+	req := httptest.NewRequest("POST", "/api/tasks?project=2", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set("Referer", "http://example.com/")
+	req.Header.Set("User-Agent", `curl "8"`)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	return buf.String()
+}
+
+func TestMiddleware_Combined(t *testing.T) {
+	line := serve(t, "combined")
+	want := regexp.MustCompile(`^203\.0\.113\.7 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "POST /api/tasks\?project=2 HTTP/1\.1" 201 5 "http://example\.com/" "curl \\"8\\""\n$`)
+	if !want.MatchString(line) {
+		t.Errorf("unexpected combined line: %q", line)
+	}
+}
+
+func TestMiddleware_JSON(t *testing.T) {
+	var e entry
+	if err := json.Unmarshal([]byte(serve(t, "json")), &e); err != nil {
+		t.Fatalf("expected one JSON object: %v", err)
+	}
+	if e.Remote != "203.0.113.7" || e.Method != "POST" || e.URI != "/api/tasks?project=2" ||
+		e.Status != 201 || e.Bytes != 5 || e.RequestID != "abc123" || e.UserAgent != `curl "8"` {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+}
+
+func TestMiddleware_RedactsCredentials(t *testing.T) {
+	var buf bytes.Buffer
+	mw, err := Middleware(&buf, "combined")
+	if err != nil {
+		t.Fatalf("Middleware failed: %v", err)
+	}
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest("GET", "/capture?title=Milk&token=s3cret&code=abc", nil)
+	req.Header.Set("Referer", "http://example.com/capture?token=s3cret")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := buf.String()
+	if strings.Contains(line, "s3cret") || strings.Contains(line, "code=abc") {
+		t.Errorf("credentials logged: %q", line)
+	}
+	if !strings.Contains(line, "/capture?title=Milk&token=REDACTED&code=REDACTED") {
+		t.Errorf("unexpected request line: %q", line)
+	}
+}
+
+func TestMiddleware_RejectsUnknownFormat(t *testing.T) {
+	if _, err := Middleware(&bytes.Buffer{}, "common"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func logFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestFile_RotatesBySizeAndKeepsNewest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", "access.log")
+	l, err := Open(path, Rotation{MaxSize: 10, Keep: 2})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer l.Close()
+
+	start := time.Date(2026, time.October, 16, 9, 0, 0, 0, time.Local)
+	for i := 0; i < 4; i++ {
+		l.now = func() time.Time { return start.Add(time.Duration(i) * time.Minute) }
+		if _, err := l.Write([]byte("line " + string(rune('a'+i)) + "\n")); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	want := []string{"access.log", "access.log.20261016-090200", "access.log.20261016-090300"}
+	if got := logFiles(t, filepath.Dir(path)); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	current, _ := os.ReadFile(path)
+	if string(current) != "line d\n" {
+		t.Errorf("expected the newest line in the current file, got %q", current)
+	}
+}
+
+func TestFile_RotatesDaily(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	l, err := Open(path, Rotation{Daily: true})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer l.Close()
+
+	l.Write([]byte("today\n"))
+	l.Write([]byte("still today\n"))
+	if got := logFiles(t, dir); len(got) != 1 {
+		t.Fatalf("expected no rotation within a day, got %v", got)
+	}
+
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	l.now = func() time.Time { return tomorrow }
+	l.Write([]byte("tomorrow\n"))
+	if got := logFiles(t, dir); len(got) != 2 {
+		t.Fatalf("expected a rotated file, got %v", got)
+	}
+	current, _ := os.ReadFile(path)
+	if string(current) != "tomorrow\n" {
+		t.Errorf("expected only the new day's line, got %q", current)
+	}
+}
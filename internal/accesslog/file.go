@@ -0,0 +1,170 @@
+package accesslog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rotation says when a File starts a new file. The old file is renamed
+// with the time it was rotated, e.g. access.log.20261016-000000.
+type Rotation struct {
+	// MaxSize rotates before a write would take the file past this many
+	// bytes. Zero means no size limit.
+	MaxSize int64
+	// Daily rotates on the first write of each local day.
+	Daily bool
+	// Keep is the number of rotated files to keep; zero keeps them all.
+	Keep int
+}
+
+// File is an append-only log file that rotates itself. It is safe for
+// concurrent use.
+type File struct {
+	path     string
+	rotation Rotation
+	now      func() time.Time
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+	day  string
+}
+
+// Open opens the log file at path for appending, creating it and its
+// directory if needed.
+func Open(path string, rotation Rotation) (*File, error) {
+	l := &File{path: path, rotation: rotation, now: time.Now}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("access log: %w", err)
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *File) open() error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("access log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("access log: %w", err)
+	}
+	l.f = f
+	l.size = info.Size()
+	// A file left over from an earlier day is rotated on the first write.
+	l.day = info.ModTime().Format(time.DateOnly)
+	if info.Size() == 0 {
+		l.day = l.now().Format(time.DateOnly)
+	}
+	return nil
+}
+
+// Write appends p, rotating first if the file is too big or from an
+// earlier day.
+func (l *File) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return 0, os.ErrClosed
+	}
+	var rotateErr error
+	if l.dueForRotation(len(p)) {
+		// A failed rotation still leaves a file open to write to.
+		if rotateErr = l.rotate(); l.f == nil {
+			return 0, rotateErr
+		}
+	}
+	n, err := l.f.Write(p)
+	l.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+func (l *File) dueForRotation(n int) bool {
+	if l.size == 0 {
+		return false
+	}
+	if l.rotation.MaxSize > 0 && l.size+int64(n) > l.rotation.MaxSize {
+		return true
+	}
+	return l.rotation.Daily && l.now().Format(time.DateOnly) != l.day
+}
+
+func (l *File) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return fmt.Errorf("access log: %w", err)
+	}
+	l.f = nil
+	stamp := l.now().Format("20060102-150405")
+	rotated := l.path + "." + stamp
+	for i := 1; fileExists(rotated); i++ {
+		rotated = fmt.Sprintf("%s.%s.%d", l.path, stamp, i)
+	}
+	renameErr := os.Rename(l.path, rotated)
+	// Keep logging to the old file if it couldn't be moved aside.
+	if err := l.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return fmt.Errorf("access log: %w", renameErr)
+	}
+	return l.prune()
+}
+
+// prune deletes the oldest rotated files beyond the ones to keep.
+func (l *File) prune() error {
+	if l.rotation.Keep < 1 {
+		return nil
+	}
+	dir, base := filepath.Split(l.path)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("access log: %w", err)
+	}
+	var rotated []string
+	for _, e := range entries {
+		if name := e.Name(); !e.IsDir() && strings.HasPrefix(name, base+".") {
+			rotated = append(rotated, name)
+		}
+	}
+	// The timestamped names sort oldest first.
+	sort.Strings(rotated)
+	for len(rotated) > l.rotation.Keep {
+		if err := os.Remove(filepath.Join(dir, rotated[0])); err != nil {
+			return fmt.Errorf("access log: %w", err)
+		}
+		rotated = rotated[1:]
+	}
+	return nil
+}
+
+// Close closes the current file.
+func (l *File) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
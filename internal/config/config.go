@@ -0,0 +1,175 @@
+// Package config gathers the server's settings from command-line flags,
+// environment variables and an optional config file, in that order of
+// precedence.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Setting is one configurable value. Key is its environment variable; the
+// flag and config file names derive from it.
+type Setting struct {
+	Key   string
+	Usage string
+}
+
+// Settings lists everything the server reads. Keys that are not listed here
+// are rejected in config files.
+var Settings = []Setting{
+	{"PORT", "TCP port to listen on (default 8080, or 443 with TLS_DOMAINS)"},
+	{"LISTEN", "listen address such as 127.0.0.1:8080 or unix:/run/mytasks.sock; overrides PORT"},
+	{"SOCKET_MODE", "permissions of the Unix socket (default 0660)"},
+	{"BASE_PATH", "path prefix to serve under, such as /mytasks"},
+	{"DB_PATH", "SQLite database file (default ./data/mytasks.db)"},
+	{"WORKSPACES", "comma-separated further workspaces, each name or name=database file, served under /w/name"},
+	{"TENANCY", "host the workspaces as isolated tenants: path (under /w/name) or subdomain"},
+	{"TENANT_DOMAIN", "domain whose subdomains name tenants with TENANCY=subdomain, e.g. tasks.example.org"},
+	{"SQLITE_CACHE_SIZE", "SQLite page cache: pages if positive, KiB if negative, e.g. -64000"},
+	{"SQLITE_SYNCHRONOUS", "SQLite synchronous mode: off, normal, full or extra"},
+	{"SQLITE_TEMP_STORE", "where SQLite keeps temporary tables: default, file or memory"},
+	{"SQLITE_MMAP_SIZE", "bytes of the database SQLite memory-maps"},
+	{"SQLITE_BUSY_TIMEOUT", "how long SQLite waits for a lock (default 5s)"},
+	{"GRPC_PORT", "port for the gRPC API; disabled when empty"},
+	{"TLS_DOMAINS", "comma-separated domains to serve HTTPS for with Let's Encrypt"},
+	{"TLS_EMAIL", "contact address for Let's Encrypt"},
+	{"TLS_CACHE_DIR", "directory for certificates (default certs next to the database)"},
+	{"TLS_REDIRECT_ADDR", "plain HTTP listener redirecting to HTTPS (default :80, or off)"},
+	{"TRUSTED_PROXIES", "comma-separated proxy IPs or CIDRs whose X-Forwarded-* headers are believed"},
+	{"EXTERNAL_HOSTS", "comma-separated public hosts accepted by the origin check"},
+	{"CORS_ALLOWED_ORIGINS", "comma-separated origins allowed to call /api/* from the browser"},
+	{"CSP", "Content-Security-Policy header, or off"},
+	{"CSP_FRAME_ANCESTORS", "frame-ancestors sources (default 'none'), or off"},
+	{"REFERRER_POLICY", "Referrer-Policy header (default same-origin), or off"},
+	{"SMTP_HOST", "outgoing mail server"},
+	{"SMTP_PORT", "outgoing mail port (default 587)"},
+	{"SMTP_USERNAME", "outgoing mail username"},
+	{"SMTP_PASSWORD", "outgoing mail password"},
+	{"SMTP_FROM", "sender address for outgoing mail"},
+	{"OVERDUE_ALERT_EMAIL", "comma-separated recipients of overdue alerts"},
+	{"DIGEST_EMAIL", "comma-separated recipients of the daily digest"},
+	{"INBOUND_EMAIL_SIGNING_KEY", "signing key for the inbound email webhook"},
+	{"INBOUND_EMAIL_ALLOWED_SENDERS", "comma-separated senders allowed to create tasks by email"},
+	{"SLACK_SIGNING_SECRET", "signing secret for the Slack slash command"},
+	{"GOOGLE_CLIENT_ID", "OAuth client ID for Google Tasks and Calendar sync"},
+	{"GOOGLE_CLIENT_SECRET", "OAuth client secret for Google Tasks and Calendar sync"},
+	{"GOOGLE_REDIRECT_URL", "OAuth redirect URL for Google Tasks sync"},
+	{"GOOGLE_CALENDAR_REDIRECT_URL", "OAuth redirect URL for Google Calendar sync"},
+	{"BACKUP_DIR", "directory for nightly database backups"},
+	{"BACKUP_KEEP", "number of backups to keep (default 7)"},
+	{"AUTO_ARCHIVE_DAYS", "complete projects whose tasks have all been done this many days"},
+	{"LOG_FORMAT", "log output format: text (default) or json"},
+	{"LOG_LEVEL", "minimum log level: debug, info (default), warn or error"},
+	{"ACCESS_LOG", "file to write an access log line per request to, or - for stdout"},
+	{"ACCESS_LOG_FORMAT", "access log format: combined (default) or json"},
+	{"ACCESS_LOG_MAX_SIZE", "rotate the access log at this many megabytes (default 100, 0 for no limit)"},
+	{"ACCESS_LOG_ROTATE", "rotate the access log daily (default) or off"},
+	{"ACCESS_LOG_KEEP", "number of rotated access logs to keep (default 7, 0 for all)"},
+	{"TEMPLATES_OVERRIDE_DIR", "directory of templates replacing the built-in ones of the same name"},
+	{"STATIC_OVERRIDE_DIR", "directory of static files served instead of, or in addition to, the built-in ones"},
+	{"DEV_MODE", "read templates and static files from the working directory, reloading on change"},
+	{"ADMIN_TOKEN", "enables the admin panel at /admin for requests bearing this token"},
+	{"MAINTENANCE", "start in read-only maintenance mode (true or false)"},
+	{"DEMO_MODE", "run a public demo on an in-memory database reset to demo data (true or false)"},
+	{"DEMO_RESET", "how often demo mode starts over, e.g. 30m (default 1h)"},
+	{"DEMO_WRITE_LIMIT", "changes each client may make per hour in demo mode (default 120)"},
+	{"DEBUG_TOKEN", "enables /debug/pprof/ and /debug/diagnostics for requests bearing this token"},
+	{"JOB_SCHEDULES", "per-job schedule overrides, e.g. backup=0 4 * * *;digest=off"},
+}
+
+// FileEnv names the environment variable that can point at a config file
+// instead of the -config flag.
+const FileEnv = "MYTASKS_CONFIG"
+
+// Config holds the loaded values.
+type Config struct {
+	flags map[string]string
+	env   func(string) (string, bool)
+	file  map[string]string
+}
+
+// Load parses args (without the program name), then reads the config file
+// named by -config or MYTASKS_CONFIG, if any. env looks up environment
+// variables; pass os.LookupEnv.
+func Load(name string, args []string, env func(string) (string, bool), output io.Writer) (*Config, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	configFile := fs.String("config", "", "config file (TOML); also $"+FileEnv)
+	keys := make(map[string]string, len(Settings))
+	for _, s := range Settings {
+		fs.String(FlagName(s.Key), "", s.Usage)
+		keys[FlagName(s.Key)] = s.Key
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	c := &Config{flags: make(map[string]string), env: env, file: make(map[string]string)}
+	fs.Visit(func(f *flag.Flag) {
+		if key, ok := keys[f.Name]; ok {
+			c.flags[key] = f.Value.String()
+		}
+	})
+
+	path := *configFile
+	if path == "" {
+		path, _ = env(FileEnv)
+	}
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if c.file, err = parseFile(f); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return c, nil
+}
+
+// Get returns the value of key from the flags, the environment or the config
+// file, or defaultValue when none of them sets it to something non-empty.
+func (c *Config) Get(key, defaultValue string) string {
+	if v := c.lookup(key); v != "" {
+		return v
+	}
+	return defaultValue
+}
+
+// IsSet reports whether key has a non-empty value from any source.
+func (c *Config) IsSet(key string) bool {
+	return c.lookup(key) != ""
+}
+
+func (c *Config) lookup(key string) string {
+	if v := c.flags[key]; v != "" {
+		return v
+	}
+	if v, _ := c.env(key); v != "" {
+		return v
+	}
+	return c.file[key]
+}
+
+// FlagName returns the command-line flag for a setting, e.g. "db-path" for
+// DB_PATH.
+func FlagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+func known(key string) bool {
+	for _, s := range Settings {
+		if s.Key == key {
+			return true
+		}
+	}
+	return false
+}
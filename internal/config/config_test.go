@@ -0,0 +1,130 @@
+package config
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func envOf(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mytasks.toml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, `
+port = 9000
+db_path = "/from/file.db"
+backup_dir = "/from/file"
+`)
+	env := envOf(map[string]string{"PORT": "9100", "DB_PATH": "/from/env.db", "BACKUP_DIR": ""})
+	c, err := Load("mytasks", []string{"-config", path, "-port", "9200"}, env, io.Discard)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	tests := []struct{ key, want string }{
+		{"PORT", "9200"},             // flag beats env and file
+		{"DB_PATH", "/from/env.db"},  // env beats file
+		{"BACKUP_DIR", "/from/file"}, // empty env counts as unset
+		{"SMTP_HOST", "default"},
+	}
+	for _, tt := range tests {
+		if got := c.Get(tt.key, "default"); got != tt.want {
+			t.Errorf("Get(%s) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+	if c.IsSet("SMTP_HOST") || !c.IsSet("PORT") {
+		t.Error("IsSet disagrees with Get")
+	}
+}
+
+func TestLoadFileFromEnv(t *testing.T) {
+	path := writeFile(t, `base_path = "/mytasks"`)
+	c, err := Load("mytasks", nil, envOf(map[string]string{FileEnv: path}), io.Discard)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := c.Get("BASE_PATH", ""); got != "/mytasks" {
+		t.Errorf("expected the file named by %s to be read, got %q", FileEnv, got)
+	}
+}
+
+func TestLoadRejectsUnknownFlags(t *testing.T) {
+	if _, err := Load("mytasks", []string{"-prot", "1"}, envOf(nil), io.Discard); err == nil {
+		t.Error("expected an unknown flag to fail")
+	}
+	if _, err := Load("mytasks", []string{"serve"}, envOf(nil), io.Discard); err == nil {
+		t.Error("expected a stray argument to fail")
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	values, err := parseFile(strings.NewReader(`
+# Server
+port = 8_080
+csp = 'off'                 # literal string
+referrer_policy = "no-referrer # not a comment"
+
+[smtp]
+host = "smtp.example.com"
+password = "p\"ss"
+
+[tls]
+domains = ["a.example.com", 'b.example.com',]
+
+[job_schedules]
+backup = "0 4 * * *"
+digest = "off"
+`))
+	if err != nil {
+		t.Fatalf("parseFile: %v", err)
+	}
+	want := map[string]string{
+		"PORT":            "8080",
+		"CSP":             "off",
+		"REFERRER_POLICY": "no-referrer # not a comment",
+		"SMTP_HOST":       "smtp.example.com",
+		"SMTP_PASSWORD":   `p"ss`,
+		"TLS_DOMAINS":     "a.example.com,b.example.com",
+		"JOB_SCHEDULES":   "backup=0 4 * * *;digest=off",
+	}
+	for key, v := range want {
+		if values[key] != v {
+			t.Errorf("%s = %q, want %q", key, values[key], v)
+		}
+	}
+	if len(values) != len(want) {
+		t.Errorf("got %d values, want %d: %v", len(values), len(want), values)
+	}
+}
+
+func TestParseFileErrors(t *testing.T) {
+	for _, content := range []string{
+		`prot = 8080`,
+		"[smtp]\nhots = \"x\"",
+		`port`,
+		`port =`,
+		`db_path = /unquoted`,
+		`db_path = "unterminated`,
+		`tls_domains = ["a",`,
+		`[smtp`,
+	} {
+		if _, err := parseFile(strings.NewReader(content)); err == nil {
+			t.Errorf("expected %q to fail", content)
+		}
+	}
+}
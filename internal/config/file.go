@@ -0,0 +1,165 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// parseFile reads the subset of TOML the settings need:
+//
+//	port = 8080
+//	db_path = "/var/lib/mytasks/mytasks.db"
+//
+//	[smtp]
+//	host = "smtp.example.com"   # SMTP_HOST
+//
+//	[tls]
+//	domains = ["tasks.example.com", "www.tasks.example.com"]
+//
+//	[job_schedules]
+//	backup = "0 4 * * *"
+//
+// A key inside a [section] is the setting named SECTION_KEY. Arrays become
+// comma-separated values. A section named after a setting, like
+// job_schedules, collects its entries as "name=value" pairs separated by
+// semicolons. Values are returned keyed by setting.
+func parseFile(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	section := ""
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: malformed section %q", lineNo, line)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == "" {
+				return nil, fmt.Errorf("line %d: empty section name", lineNo)
+			}
+			continue
+		}
+
+		name, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		name = strings.TrimSpace(name)
+		value, err := parseValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", lineNo, name, err)
+		}
+
+		if mapKey := settingKey(section); section != "" && known(mapKey) {
+			if values[mapKey] != "" {
+				values[mapKey] += ";"
+			}
+			values[mapKey] += name + "=" + value
+			continue
+		}
+		key := settingKey(name)
+		if section != "" {
+			key = settingKey(section + "_" + name)
+		}
+		if !known(key) {
+			return nil, fmt.Errorf("line %d: unknown setting %q", lineNo, strings.ToLower(key))
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// settingKey turns a config file name such as "db_path" into its setting.
+func settingKey(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// stripComment drops a trailing # comment that is not inside quotes.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseValue reads a string, number, boolean or single-line array of those.
+func parseValue(raw string) (string, error) {
+	switch {
+	case raw == "":
+		return "", fmt.Errorf("missing value")
+	case strings.HasPrefix(raw, "["):
+		if !strings.HasSuffix(raw, "]") {
+			return "", fmt.Errorf("arrays must be on one line")
+		}
+		var items []string
+		for _, item := range splitArray(raw[1 : len(raw)-1]) {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			v, err := parseValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, v)
+		}
+		return strings.Join(items, ","), nil
+	case strings.HasPrefix(raw, `"`):
+		v, err := strconv.Unquote(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", raw)
+		}
+		return v, nil
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") || strings.Contains(raw[1:len(raw)-1], "'") {
+			return "", fmt.Errorf("invalid string %s", raw)
+		}
+		return raw[1 : len(raw)-1], nil
+	case raw == "true" || raw == "false":
+		return raw, nil
+	}
+	if _, err := strconv.ParseInt(strings.ReplaceAll(raw, "_", ""), 0, 64); err != nil {
+		return "", fmt.Errorf("unquoted value %s must be a number or boolean", raw)
+	}
+	return strings.ReplaceAll(raw, "_", ""), nil
+}
+
+// splitArray splits array items on commas outside quotes.
+func splitArray(s string) []string {
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == ',':
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, s[start:])
+}
@@ -0,0 +1,41 @@
+// Package escalation raises the priority of open tasks as their due date
+// nears, following the escalation window in the settings.
+package escalation
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/store"
+)
+
+// Escalator recomputes every task's escalated priority. It runs on a schedule
+// so escalations follow the calendar, and after task changes so they follow
+// edited due dates and priorities.
+type Escalator struct {
+	store store.Store
+	now   func() time.Time
+}
+
+// NewEscalator creates an Escalator.
+func NewEscalator(s store.Store) *Escalator {
+	return &Escalator{store: s, now: time.Now}
+}
+
+// Run applies the current escalation window to every task.
+func (e *Escalator) Run(ctx context.Context) error {
+	settings, err := e.store.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	return e.store.EscalatePriorities(ctx, settings.EscalateDays, e.now())
+}
+
+// HandleEvent is an events.Handler for task changes.
+func (e *Escalator) HandleEvent(ctx context.Context, _ events.Event) {
+	if err := e.Run(ctx); err != nil {
+		slog.Error("escalation: failed to raise priorities", "err", err)
+	}
+}
@@ -0,0 +1,130 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"mytasks/internal/models"
+)
+
+// Type identifies a domain event.
+type Type string
+
+const (
+	TaskCreated    Type = "task.created"
+	TaskUpdated    Type = "task.updated"
+	TaskCompleted  Type = "task.completed"
+	TaskReopened   Type = "task.reopened"
+	TaskMoved      Type = "task.moved" // moved to a different project
+	TaskDeleted    Type = "task.deleted"
+	TasksReordered Type = "task.reordered"
+
+	ProjectCreated    Type = "project.created"
+	ProjectUpdated    Type = "project.updated"
+	ProjectCompleted  Type = "project.completed"
+	ProjectReopened   Type = "project.reopened"
+	ProjectDeleted    Type = "project.deleted"
+	ProjectsReordered Type = "project.reordered"
+
+	// Focus events carry the task the session is timing.
+	FocusStarted Type = "focus.started"
+	FocusStopped Type = "focus.stopped"
+
+	// TaskOverdue is published once per due date when a task becomes overdue.
+	TaskOverdue Type = "task.overdue"
+	// ImportFinished is published after an import stored its records.
+	ImportFinished Type = "import.finished"
+)
+
+// Event is a domain event published after a successful mutation.
+type Event struct {
+	Type      Type
+	ProjectID int64
+	TaskID    int64
+	Task      *models.Task
+	Project   *models.Project
+
+	// PreviousProjectID is set on TaskMoved.
+	PreviousProjectID int64
+	// IDs and Status describe reorder events.
+	IDs    []int64
+	Status string
+	// Source names the import format and Count the tasks it created or
+	// updated, on ImportFinished.
+	Source string
+	Count  int
+
+	// Origin identifies the client that caused the event, when known.
+	Origin string
+	At     time.Time
+}
+
+// Handler receives published events.
+type Handler func(ctx context.Context, e Event)
+
+type subscription struct {
+	types   map[Type]bool
+	handler Handler
+}
+
+// Bus is an in-process, synchronous publish/subscribe dispatcher.
+// Handlers run on the publisher's goroutine and should hand off slow work.
+type Bus struct {
+	mu   sync.RWMutex
+	subs []subscription
+}
+
+// NewBus creates an empty Bus.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers handler for the given event types, or for every event
+// when no types are given.
+func (b *Bus) Subscribe(handler Handler, types ...Type) {
+	sub := subscription{handler: handler}
+	if len(types) > 0 {
+		sub.types = make(map[Type]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+}
+
+// Publish delivers e to every matching subscriber. A panicking subscriber is
+// logged and skipped so one faulty side effect cannot fail the request.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if b == nil {
+		return
+	}
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+
+	b.mu.RLock()
+	subs := make([]subscription, len(b.subs))
+	copy(subs, b.subs)
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		if sub.types != nil && !sub.types[e.Type] {
+			continue
+		}
+		dispatch(ctx, sub.handler, e)
+	}
+}
+
+func dispatch(ctx context.Context, handler Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("events: subscriber panicked", "event", e.Type, "panic", r)
+		}
+	}()
+	handler(ctx, e)
+}
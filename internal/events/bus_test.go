@@ -0,0 +1,55 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBus_DeliversToMatchingSubscribers(t *testing.T) {
+	bus := NewBus()
+	var all, completions []Type
+
+	bus.Subscribe(func(_ context.Context, e Event) { all = append(all, e.Type) })
+	bus.Subscribe(func(_ context.Context, e Event) { completions = append(completions, e.Type) }, TaskCompleted)
+
+	bus.Publish(context.Background(), Event{Type: TaskCreated, TaskID: 1})
+	bus.Publish(context.Background(), Event{Type: TaskCompleted, TaskID: 1})
+
+	if len(all) != 2 {
+		t.Errorf("expected catch-all subscriber to see 2 events, got %v", all)
+	}
+	if len(completions) != 1 || completions[0] != TaskCompleted {
+		t.Errorf("expected filtered subscriber to see only TaskCompleted, got %v", completions)
+	}
+}
+
+func TestBus_RecoversFromPanickingSubscriber(t *testing.T) {
+	bus := NewBus()
+	delivered := false
+
+	bus.Subscribe(func(context.Context, Event) { panic("boom") })
+	bus.Subscribe(func(context.Context, Event) { delivered = true })
+
+	bus.Publish(context.Background(), Event{Type: ProjectCreated})
+
+	if !delivered {
+		t.Error("expected later subscribers to run after a panic")
+	}
+}
+
+func TestBus_NilIsNoop(t *testing.T) {
+	var bus *Bus
+	bus.Publish(context.Background(), Event{Type: TaskDeleted})
+}
+
+func TestBus_SetsTimestamp(t *testing.T) {
+	bus := NewBus()
+	var got Event
+	bus.Subscribe(func(_ context.Context, e Event) { got = e })
+
+	bus.Publish(context.Background(), Event{Type: TaskUpdated})
+
+	if got.At.IsZero() {
+		t.Error("expected event timestamp to be set")
+	}
+}
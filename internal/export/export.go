@@ -0,0 +1,226 @@
+// Package export writes chosen projects to a zip archive as JSON, Markdown
+// and CSV, for keeping a copy of a project or handing it to someone else.
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// Export formats, each written as one file per project.
+const (
+	FormatJSON     = "json"     // project.json: the project with its tasks
+	FormatMarkdown = "markdown" // tasks.md: a checklist by status
+	FormatCSV      = "csv"      // tasks.csv: one row per task
+)
+
+// Formats lists the export formats in the order they are offered.
+var Formats = []string{FormatJSON, FormatMarkdown, FormatCSV}
+
+// ValidFormat reports whether name is one of Formats.
+func ValidFormat(name string) bool {
+	for _, f := range Formats {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Exporter reads projects for export.
+type Exporter struct {
+	store store.Store
+}
+
+// New creates an Exporter.
+func New(s store.Store) *Exporter {
+	return &Exporter{store: s}
+}
+
+// Projects loads the projects with the given IDs, in that order, each with
+// its tasks and their tags. Deleted tasks are left out. A missing project
+// is store.ErrNotFound.
+func (e *Exporter) Projects(ctx context.Context, ids []int64) ([]models.Project, error) {
+	tags, err := e.store.ListTaskTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	projects := make([]models.Project, 0, len(ids))
+	for _, id := range ids {
+		project, err := e.store.GetProject(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if project.Tasks, err = e.store.ListTasksByProject(ctx, id, 0); err != nil {
+			return nil, err
+		}
+		for i := range project.Tasks {
+			project.Tasks[i].Tags = tags[project.Tasks[i].ID]
+		}
+		projects = append(projects, *project)
+	}
+	return projects, nil
+}
+
+// WriteZip writes projects to w as a zip archive with a folder per project,
+// named after its ID and name, holding a file in each of formats.
+func WriteZip(w io.Writer, projects []models.Project, formats []string) error {
+	zw := zip.NewWriter(w)
+	for i := range projects {
+		project := &projects[i]
+		dir := Folder(project)
+		for _, format := range formats {
+			var name string
+			var write func(io.Writer, *models.Project) error
+			switch format {
+			case FormatJSON:
+				name, write = "project.json", writeJSON
+			case FormatMarkdown:
+				name, write = "tasks.md", writeMarkdown
+			case FormatCSV:
+				name, write = "tasks.csv", writeCSV
+			default:
+				return fmt.Errorf("unknown export format %q", format)
+			}
+
+			f, err := zw.CreateHeader(&zip.FileHeader{Name: dir + "/" + name, Method: zip.Deflate, Modified: project.UpdatedAt})
+			if err != nil {
+				return err
+			}
+			if err := write(f, project); err != nil {
+				return fmt.Errorf("failed to write %s/%s: %w", dir, name, err)
+			}
+		}
+	}
+	return zw.Close()
+}
+
+// Folder names a project's folder in the archive: its ID and its name in
+// lower case, with runs of anything but letters and digits turned into
+// dashes, such as "12-home-renovation".
+func Folder(project *models.Project) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(project.Name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		return strconv.FormatInt(project.ID, 10)
+	}
+	return strconv.FormatInt(project.ID, 10) + "-" + b.String()
+}
+
+func writeJSON(w io.Writer, project *models.Project) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(project)
+}
+
+// markdownSections orders the statuses in tasks.md.
+var markdownSections = []struct{ status, title string }{
+	{"todo", "To Do"},
+	{"in_progress", "In Progress"},
+	{"done", "Done"},
+}
+
+// writeMarkdown writes the project as a checklist by status, for reading or
+// handing over; due dates, priorities and tags follow each task, and notes
+// are indented below it.
+func writeMarkdown(w io.Writer, project *models.Project) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", project.Name)
+	if project.Description != "" {
+		fmt.Fprintf(&b, "\n%s\n", project.Description)
+	}
+	for _, section := range markdownSections {
+		var tasks []*models.Task
+		for i := range project.Tasks {
+			if project.Tasks[i].Status == section.status {
+				tasks = append(tasks, &project.Tasks[i])
+			}
+		}
+		if len(tasks) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", section.title)
+		for _, task := range tasks {
+			box := " "
+			if task.Completed {
+				box = "x"
+			}
+			fmt.Fprintf(&b, "- [%s] %s", box, strings.TrimSpace(task.Description))
+			var details []string
+			if task.DueDate != nil {
+				details = append(details, "due "+task.DueDate.Format(time.DateOnly))
+			}
+			if task.Priority != "" {
+				details = append(details, task.Priority)
+			}
+			for _, tag := range task.Tags {
+				details = append(details, "#"+tag)
+			}
+			if len(details) > 0 {
+				fmt.Fprintf(&b, " (%s)", strings.Join(details, ", "))
+			}
+			b.WriteByte('\n')
+			if notes := strings.TrimSpace(task.Notes); notes != "" {
+				for _, line := range strings.Split(notes, "\n") {
+					fmt.Fprintf(&b, "  %s\n", strings.TrimRight(line, "\r"))
+				}
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// csvHeader names the columns of tasks.csv.
+var csvHeader = []string{"id", "description", "notes", "priority", "status", "due_date", "tags", "completed_at", "created_at", "updated_at"}
+
+func writeCSV(w io.Writer, project *models.Project) error {
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+	for _, task := range project.Tasks {
+		cw.Write([]string{
+			strconv.FormatInt(task.ID, 10),
+			task.Description,
+			task.Notes,
+			task.Priority,
+			task.Status,
+			formatDate(task.DueDate, time.DateOnly),
+			strings.Join(task.Tags, " "),
+			formatDate(task.CompletedAt, time.RFC3339),
+			task.CreatedAt.Format(time.RFC3339),
+			task.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatDate(t *time.Time, layout string) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(layout)
+}
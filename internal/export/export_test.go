@@ -0,0 +1,136 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+func setupStore(t *testing.T) *store.SQLiteStore {
+	t.Helper()
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestWriteZip(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	home := &models.Project{Name: "Home Renovation", Type: "project"}
+	s.CreateProject(ctx, home)
+	work := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, work)
+	due := time.Date(2026, time.November, 2, 0, 0, 0, 0, time.UTC)
+	tasks := []*models.Task{
+		{ProjectID: home.ID, Description: "Paint fence", Notes: "White\ntwo coats", Priority: "high", Status: "todo", DueDate: &due, Tags: []string{"outside"}},
+		{ProjectID: home.ID, Description: "Buy brushes", Priority: "low", Status: "done", Completed: true},
+		{ProjectID: work.ID, Description: "File report", Priority: "medium", Status: "todo"},
+	}
+	if err := s.CreateTasks(ctx, tasks); err != nil {
+		t.Fatalf("CreateTasks failed: %v", err)
+	}
+
+	projects, err := New(s).Projects(ctx, []int64{home.ID})
+	if err != nil {
+		t.Fatalf("Projects failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteZip(&buf, projects, Formats); err != nil {
+		t.Fatalf("WriteZip failed: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("expected a zip archive: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+	if len(files) != 3 {
+		t.Fatalf("expected a file per format for the chosen project only, got %v", zr.File)
+	}
+
+	var project models.Project
+	if err := json.Unmarshal([]byte(files["1-home-renovation/project.json"]), &project); err != nil {
+		t.Fatalf("expected project.json, got %q (%v)", files["1-home-renovation/project.json"], err)
+	}
+	if project.Name != "Home Renovation" || len(project.Tasks) != 2 {
+		t.Errorf("expected the project with its 2 tasks, got %+v", project)
+	}
+
+	md := files["1-home-renovation/tasks.md"]
+	for _, want := range []string{
+		"# Home Renovation\n",
+		"## To Do\n\n- [ ] Paint fence (due 2026-11-02, high, #outside)\n  White\n  two coats\n",
+		"## Done\n\n- [x] Buy brushes (low)\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected tasks.md to contain %q, got:\n%s", want, md)
+		}
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(files["1-home-renovation/tasks.csv"])).ReadAll()
+	if err != nil {
+		t.Fatalf("expected tasks.csv: %v", err)
+	}
+	if len(rows) != 3 || rows[0][1] != "description" {
+		t.Fatalf("expected a header and 2 rows, got %v", rows)
+	}
+	if row := rows[1]; row[1] != "Paint fence" || row[2] != "White\ntwo coats" || row[5] != "2026-11-02" || row[6] != "outside" {
+		t.Errorf("unexpected row %q", row)
+	}
+
+	buf.Reset()
+	if err := WriteZip(&buf, projects, []string{FormatCSV}); err != nil {
+		t.Fatalf("WriteZip failed: %v", err)
+	}
+	zr, _ = zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if len(zr.File) != 1 || zr.File[0].Name != "1-home-renovation/tasks.csv" {
+		t.Errorf("expected only the CSV, got %v", zr.File)
+	}
+}
+
+func TestProjectsNotFound(t *testing.T) {
+	s := setupStore(t)
+	if _, err := New(s).Projects(context.Background(), []int64{42}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFolder(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Home Renovation", "7-home-renovation"},
+		{"  Q3 / Launch!! ", "7-q3-launch"},
+		{"Küche", "7-küche"},
+		{"***", "7"},
+	}
+	for _, tt := range tests {
+		if got := Folder(&models.Project{ID: 7, Name: tt.name}); got != tt.want {
+			t.Errorf("Folder(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Package fuzzy scores how well a short typed query matches a name, for the
+// quick switcher: "gh sync" finds "GitHub issue sync".
+package fuzzy
+
+import "unicode"
+
+const (
+	matchScore       = 1
+	startBonus       = 4 // the query starts the name
+	wordStartBonus   = 8 // a match at the start of a word
+	consecutiveBonus = 5 // a match right after the previous one
+	// maxGapPenalty caps the one-per-rune cost of runes skipped before and
+	// between matches, and of runes left over after, so shorter names win ties.
+	maxGapPenalty = 5
+)
+
+// Score reports whether every non-space rune of query appears in target in
+// order, ignoring case, and how good the best such match is: higher is
+// better. An empty query matches everything with a score of 0.
+func Score(query, target string) (int, bool) {
+	var q []rune
+	for _, r := range query {
+		if !unicode.IsSpace(r) {
+			q = append(q, unicode.ToLower(r))
+		}
+	}
+	if len(q) == 0 {
+		return 0, true
+	}
+
+	t := []rune(target)
+	if len(q) > len(t) {
+		return 0, false
+	}
+
+	// best[j] is the best score for the query so far with its last rune
+	// matched at t[j]; ok[j] says whether such a match exists.
+	best := make([]int, len(t))
+	ok := make([]bool, len(t))
+	for j := range t {
+		if unicode.ToLower(t[j]) == q[0] {
+			best[j] = runeScore(t, j) - min(j, maxGapPenalty)
+			ok[j] = true
+			if j == 0 {
+				best[j] += startBonus
+			}
+		}
+	}
+
+	for i := 1; i < len(q); i++ {
+		next := make([]int, len(t))
+		nextOK := make([]bool, len(t))
+		for j := i; j < len(t); j++ {
+			if unicode.ToLower(t[j]) != q[i] {
+				continue
+			}
+			for k := j - 1; k >= i-1; k-- {
+				if !ok[k] {
+					continue
+				}
+				s := best[k] + runeScore(t, j)
+				if k == j-1 {
+					s += consecutiveBonus
+				} else {
+					s -= min(j-k-1, maxGapPenalty)
+				}
+				if !nextOK[j] || s > next[j] {
+					next[j], nextOK[j] = s, true
+				}
+			}
+		}
+		best, ok = next, nextOK
+	}
+
+	score, found := 0, false
+	for j := range t {
+		s := best[j] - min(len(t)-j-1, maxGapPenalty)
+		if ok[j] && (!found || s > score) {
+			score, found = s, true
+		}
+	}
+	return score, found
+}
+
+// runeScore is what matching t[j] is worth on its own.
+func runeScore(t []rune, j int) int {
+	if isWordStart(t, j) {
+		return matchScore + wordStartBonus
+	}
+	return matchScore
+}
+
+func isWordStart(t []rune, j int) bool {
+	if j == 0 {
+		return true
+	}
+	prev := t[j-1]
+	return !unicode.IsLetter(prev) && !unicode.IsDigit(prev) ||
+		unicode.IsLower(prev) && unicode.IsUpper(t[j])
+}
@@ -0,0 +1,50 @@
+package fuzzy
+
+import "testing"
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		query, target string
+		ok            bool
+	}{
+		{"", "anything", true},
+		{"gh sync", "GitHub issue sync", true},
+		{"GHIS", "GitHub issue sync", true},
+		{"hshld", "Household", true},
+		{"syncgh", "GitHub issue sync", false},
+		{"householder", "Household", false},
+		{"ü", "Über", true},
+	}
+	for _, tt := range tests {
+		if _, ok := Score(tt.query, tt.target); ok != tt.ok {
+			t.Errorf("Score(%q, %q) matched = %v, want %v", tt.query, tt.target, ok, tt.ok)
+		}
+	}
+}
+
+func TestScore_Ranking(t *testing.T) {
+	// Each query should rank the targets in the order given.
+	tests := []struct {
+		query   string
+		targets []string
+	}{
+		{"home", []string{"Home", "Home Office", "Household maintenance"}},
+		{"gs", []string{"GitHub sync", "Buy glass shelves", "Edit blogs"}},
+		{"rent", []string{"Pay rent", "Parent teacher meeting"}},
+		{"wr", []string{"Weekly report", "Draw rectangles"}},
+	}
+	for _, tt := range tests {
+		prev, _ := Score(tt.query, tt.targets[0])
+		for _, target := range tt.targets[1:] {
+			score, ok := Score(tt.query, target)
+			if !ok {
+				t.Errorf("Score(%q, %q) did not match", tt.query, target)
+				continue
+			}
+			if score >= prev {
+				t.Errorf("Score(%q, %q) = %d, want less than %d", tt.query, target, score, prev)
+			}
+			prev = score
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package gcal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultBaseURL is the Google Calendar API root.
+const DefaultBaseURL = "https://www.googleapis.com/calendar/v3"
+
+// errGone is returned for an event that no longer exists.
+var errGone = errors.New("gcal: event is gone")
+
+// Event is the subset of a Google Calendar event that sync uses.
+type Event struct {
+	ID      string    `json:"id,omitempty"`
+	ETag    string    `json:"etag,omitempty"`
+	Status  string    `json:"status,omitempty"` // "cancelled" once deleted
+	Summary string    `json:"summary"`
+	Start   EventTime `json:"start"`
+	End     EventTime `json:"end"`
+	// Transparency is "transparent" for events that don't block time.
+	Transparency       string              `json:"transparency,omitempty"`
+	Updated            string              `json:"updated,omitempty"`
+	ExtendedProperties *ExtendedProperties `json:"extendedProperties,omitempty"`
+}
+
+// EventTime is the start or end of an event: Date for all-day events,
+// DateTime (RFC 3339) for timed ones.
+type EventTime struct {
+	Date     string `json:"date,omitempty"`
+	DateTime string `json:"dateTime,omitempty"`
+}
+
+// ExtendedProperties are values stored on an event by the app that wrote it.
+type ExtendedProperties struct {
+	Private map[string]string `json:"private,omitempty"`
+}
+
+// Day returns the date the event starts on, in its own time zone.
+func (e *Event) Day() (time.Time, bool) {
+	if e.Start.Date != "" {
+		d, err := time.Parse("2006-01-02", e.Start.Date)
+		return d, err == nil
+	}
+	t, err := time.Parse(time.RFC3339, e.Start.DateTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+	d, _ := time.Parse("2006-01-02", t.Format("2006-01-02"))
+	return d, true
+}
+
+// taskID returns the task an event was created for, 0 if none.
+func (e *Event) taskID() int64 {
+	if e.ExtendedProperties == nil {
+		return 0
+	}
+	id, _ := strconv.ParseInt(e.ExtendedProperties.Private[propTaskID], 10, 64)
+	return id
+}
+
+// Client calls the Google Calendar REST API with a bearer token.
+type Client struct {
+	HTTPClient  *http.Client
+	BaseURL     string
+	AccessToken string
+}
+
+// ListEvents returns the events sync created in a calendar, including
+// deleted ones, limited to those updated after updatedMin when it is
+// non-zero.
+func (c *Client) ListEvents(ctx context.Context, calendarID string, updatedMin time.Time) ([]Event, error) {
+	var events []Event
+	pageToken := ""
+	for {
+		q := url.Values{
+			"maxResults":              {"250"},
+			"showDeleted":             {"true"},
+			"privateExtendedProperty": {propSource + "=" + sourceValue},
+		}
+		if !updatedMin.IsZero() {
+			q.Set("updatedMin", updatedMin.UTC().Format(time.RFC3339))
+		}
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+		var page struct {
+			Items         []Event `json:"items"`
+			NextPageToken string  `json:"nextPageToken"`
+		}
+		if err := c.do(ctx, http.MethodGet, eventsPath(calendarID)+"?"+q.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		events = append(events, page.Items...)
+		if page.NextPageToken == "" {
+			return events, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// InsertEvent creates an event and returns it as stored.
+func (c *Client) InsertEvent(ctx context.Context, calendarID string, event *Event) (*Event, error) {
+	var created Event
+	if err := c.do(ctx, http.MethodPost, eventsPath(calendarID), event, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// PatchEvent changes the given fields of an event and returns it as stored.
+func (c *Client) PatchEvent(ctx context.Context, calendarID, eventID string, fields map[string]interface{}) (*Event, error) {
+	var patched Event
+	if err := c.do(ctx, http.MethodPatch, eventsPath(calendarID)+"/"+url.PathEscape(eventID), fields, &patched); err != nil {
+		return nil, err
+	}
+	return &patched, nil
+}
+
+// DeleteEvent deletes an event. An event that is already gone is not an
+// error.
+func (c *Client) DeleteEvent(ctx context.Context, calendarID, eventID string) error {
+	err := c.do(ctx, http.MethodDelete, eventsPath(calendarID)+"/"+url.PathEscape(eventID), nil, nil)
+	if errors.Is(err, errGone) {
+		return nil
+	}
+	return err
+}
+
+func eventsPath(calendarID string) string {
+	return "/calendars/" + url.PathEscape(calendarID) + "/events"
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	var body io.Reader
+	if in != nil {
+		buf, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("gcal: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return fmt.Errorf("gcal: %s %s returned %s: %w", method, path, resp.Status, errGone)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("gcal: %s %s returned %s", method, path, resp.Status)
+	case out == nil:
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("gcal: invalid response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,371 @@
+// Package gcal keeps Google Calendar in step with the tasks that have due
+// dates: each becomes an all-day event, and moving or completing the event
+// in the calendar moves or completes the task.
+package gcal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/gtasks"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+const (
+	// Scope grants access to the events of the user's calendars, and to
+	// nothing else in them.
+	Scope = "https://www.googleapis.com/auth/calendar.events"
+	// Origin tags events caused by sync.
+	Origin = "google_calendar"
+
+	// propSource marks the events sync created, and propTaskID names their
+	// task, in the events' private extended properties.
+	propSource  = "mytasks"
+	sourceValue = "1"
+	propTaskID  = "mytasksTaskId"
+
+	dateLayout = "2006-01-02"
+)
+
+// doneMarks start the title of an event whose task is done. The first is
+// the one written; adding any of them in the calendar completes the task.
+var doneMarks = []string{"✓", "✔", "[x]", "[X]"}
+
+// Syncer syncs tasks with due dates to a Google calendar, both ways. The
+// state both sides agreed on at the last sync is kept with each link, so a
+// change on one side is applied to the other, and a date changed on both
+// sides is a conflict settled by the later change.
+type Syncer struct {
+	store      store.Store
+	bus        *events.Bus
+	oauth      gtasks.OAuthConfig
+	httpClient *http.Client
+	baseURL    string
+	now        func() time.Time
+}
+
+// NewSyncer creates a Syncer. bus may be nil.
+func NewSyncer(s store.Store, bus *events.Bus, oauth gtasks.OAuthConfig) *Syncer {
+	return &Syncer{
+		store:      s,
+		bus:        bus,
+		oauth:      oauth,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    DefaultBaseURL,
+		now:        time.Now,
+	}
+}
+
+// Run performs one sync pass. It does nothing until an account is connected.
+func (s *Syncer) Run(ctx context.Context) error {
+	account, err := s.store.GetGoogleCalendarAccount(ctx)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.ensureAccessToken(ctx, account); err != nil {
+		return err
+	}
+	client := &Client{HTTPClient: s.httpClient, BaseURL: s.baseURL, AccessToken: account.AccessToken}
+
+	started := s.now()
+	var updatedMin time.Time
+	if account.LastSyncedAt != nil {
+		// Overlap slightly so clock skew cannot drop edits.
+		updatedMin = account.LastSyncedAt.Add(-5 * time.Minute)
+	}
+
+	changed, err := client.ListEvents(ctx, account.CalendarID, updatedMin)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*Event, len(changed))
+	for i := range changed {
+		byID[changed[i].ID] = &changed[i]
+	}
+
+	links, err := s.store.ListCalendarEvents(ctx)
+	if err != nil {
+		return err
+	}
+	linked := make(map[int64]bool, len(links))
+	for i := range links {
+		link := &links[i]
+		linked[link.TaskID] = true
+		ev := byID[link.EventID]
+		delete(byID, link.EventID)
+		if err := s.reconcile(ctx, client, account.CalendarID, link, ev); err != nil {
+			return err
+		}
+	}
+
+	// Events no link points at were made before the links were forgotten,
+	// by disconnecting; their tasks take them back rather than get another.
+	orphans := make(map[int64]*Event)
+	for _, ev := range byID {
+		if id := ev.taskID(); id != 0 && ev.Status != "cancelled" {
+			orphans[id] = ev
+		}
+	}
+
+	tasks, err := s.store.ListOpenTasks(ctx, store.TaskFilter{})
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		task := &tasks[i]
+		if task.DueDate == nil || linked[task.ID] {
+			continue
+		}
+		if err := s.create(ctx, client, account.CalendarID, task, orphans[task.ID]); err != nil {
+			return err
+		}
+	}
+
+	account.LastSyncedAt = &started
+	return s.store.SaveGoogleCalendarAccount(ctx, account)
+}
+
+func (s *Syncer) ensureAccessToken(ctx context.Context, account *models.GoogleCalendarAccount) error {
+	if account.AccessToken != "" && account.TokenExpiry != nil && s.now().Before(account.TokenExpiry.Add(-time.Minute)) {
+		return nil
+	}
+
+	tok, err := s.oauth.Refresh(ctx, s.httpClient, account.RefreshToken)
+	if err != nil {
+		return err
+	}
+	account.AccessToken = tok.AccessToken
+	account.RefreshToken = tok.RefreshToken
+	account.TokenExpiry = &tok.Expiry
+	return s.store.SaveGoogleCalendarAccount(ctx, account)
+}
+
+// create adds the event of a task, or takes over orphan, the event the task
+// had before, when there is one.
+func (s *Syncer) create(ctx context.Context, client *Client, calendarID string, task *models.Task, orphan *Event) error {
+	var ev *Event
+	if orphan != nil {
+		patched, err := client.PatchEvent(ctx, calendarID, orphan.ID, eventFields(task.Description, *task.DueDate, false))
+		if err != nil && !errors.Is(err, errGone) {
+			return err
+		}
+		ev = patched
+	}
+	if ev == nil {
+		created, err := client.InsertEvent(ctx, calendarID, &Event{
+			Summary:      task.Description,
+			Start:        EventTime{Date: task.DueDate.Format(dateLayout)},
+			End:          EventTime{Date: task.DueDate.AddDate(0, 0, 1).Format(dateLayout)},
+			Transparency: "transparent",
+			ExtendedProperties: &ExtendedProperties{Private: map[string]string{
+				propSource: sourceValue,
+				propTaskID: strconv.FormatInt(task.ID, 10),
+			}},
+		})
+		if err != nil {
+			return err
+		}
+		ev = created
+	}
+
+	return s.store.SaveCalendarEvent(ctx, &models.CalendarEvent{
+		TaskID:   task.ID,
+		EventID:  ev.ID,
+		ETag:     ev.ETag,
+		Title:    task.Description,
+		DueDate:  *task.DueDate,
+		SyncedAt: s.now(),
+	})
+}
+
+// reconcile brings a linked task and its event back in step. ev is the event
+// as listed if it may have changed in the calendar since the last sync, and
+// nil otherwise.
+func (s *Syncer) reconcile(ctx context.Context, client *Client, calendarID string, link *models.CalendarEvent, ev *Event) error {
+	if ev != nil && ev.ETag == link.ETag {
+		// Listed because of our own last write.
+		ev = nil
+	}
+
+	task, err := s.store.GetTask(ctx, link.TaskID)
+	if errors.Is(err, store.ErrNotFound) {
+		// Deleted here, so it goes from the calendar too.
+		if err := client.DeleteEvent(ctx, calendarID, link.EventID); err != nil {
+			return err
+		}
+		return s.store.DeleteCalendarEvent(ctx, link.TaskID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if ev == nil && sameDay(task.DueDate, link.DueDate) && task.IsDone() == link.Done && task.Description == link.Title {
+		return nil
+	}
+	if ev != nil && ev.Status == "cancelled" {
+		return s.applyDeletion(ctx, link, task)
+	}
+
+	// What the calendar has now: the agreed state unless it changed.
+	remoteDue, remoteTitle, remoteDone := link.DueDate, link.Title, link.Done
+	if ev != nil {
+		if day, ok := ev.Day(); ok {
+			remoteDue = day
+		}
+		remoteTitle, remoteDone = parseSummary(ev.Summary)
+	}
+
+	due, done, conflict := task.DueDate, task.IsDone(), ""
+	localMoved := !sameDay(due, link.DueDate)
+	remoteMoved := !sameDay(&remoteDue, link.DueDate)
+	switch {
+	case localMoved && remoteMoved && !sameDay(due, remoteDue):
+		kept := due
+		if eventUpdated(ev).After(task.UpdatedAt) {
+			kept = &remoteDue
+		}
+		conflict = fmt.Sprintf("Changed to %s here and to %s in Google Calendar; kept %s.", dayLabel(due), dayLabel(&remoteDue), dayLabel(kept))
+		due = kept
+	case remoteMoved:
+		due = &remoteDue
+	}
+	// Completions are pulled; reopening happens here, and a mark removed
+	// in the calendar from a done task is put back.
+	completed := remoteDone && !link.Done && !done
+	if completed {
+		done = true
+	}
+
+	if completed || !equalDue(due, task.DueDate) {
+		task.DueDate = due
+		eventType := events.TaskUpdated
+		if completed {
+			task.Status = "done"
+			eventType = events.TaskCompleted
+		}
+		if err := s.store.UpdateTask(ctx, task); err != nil {
+			return err
+		}
+		s.bus.Publish(ctx, events.Event{Type: eventType, TaskID: task.ID, ProjectID: task.ProjectID, Task: task, Origin: Origin})
+	}
+
+	if due == nil {
+		// The date was removed here.
+		if err := client.DeleteEvent(ctx, calendarID, link.EventID); err != nil {
+			return err
+		}
+		return s.store.DeleteCalendarEvent(ctx, link.TaskID)
+	}
+
+	// A title changed here replaces the event's; one changed in the
+	// calendar is left alone.
+	title := remoteTitle
+	if task.Description != link.Title {
+		title = task.Description
+	}
+	if ev != nil {
+		link.ETag = ev.ETag
+	}
+	if !sameDay(due, remoteDue) || done != remoteDone || title != remoteTitle {
+		patched, err := client.PatchEvent(ctx, calendarID, link.EventID, eventFields(title, *due, done))
+		if errors.Is(err, errGone) {
+			// Deleted after it was listed; the next sync creates it again.
+			return s.store.DeleteCalendarEvent(ctx, link.TaskID)
+		}
+		if err != nil {
+			return err
+		}
+		link.ETag = patched.ETag
+	}
+
+	link.Title = task.Description
+	link.DueDate = *due
+	link.Done = done
+	link.SyncedAt = s.now()
+	if conflict != "" {
+		link.Conflict = conflict
+		link.ConflictAt = &link.SyncedAt
+	}
+	return s.store.SaveCalendarEvent(ctx, link)
+}
+
+// applyDeletion handles an event deleted in the calendar: an open task
+// loses its due date, unless it was moved here since, in which case the
+// next sync gives it a new event.
+func (s *Syncer) applyDeletion(ctx context.Context, link *models.CalendarEvent, task *models.Task) error {
+	if !task.IsDone() && sameDay(task.DueDate, link.DueDate) {
+		task.DueDate = nil
+		if err := s.store.UpdateTask(ctx, task); err != nil {
+			return err
+		}
+		s.bus.Publish(ctx, events.Event{Type: events.TaskUpdated, TaskID: task.ID, ProjectID: task.ProjectID, Task: task, Origin: Origin})
+	}
+	return s.store.DeleteCalendarEvent(ctx, link.TaskID)
+}
+
+// eventFields returns the fields sync writes to an event: an all-day event
+// on due, titled with title and marked when done.
+func eventFields(title string, due time.Time, done bool) map[string]interface{} {
+	summary := title
+	if done {
+		summary = doneMarks[0] + " " + title
+	}
+	// Nulls turn an event moved to a time of day back into an all-day one.
+	return map[string]interface{}{
+		"summary": summary,
+		"start":   map[string]interface{}{"date": due.Format(dateLayout), "dateTime": nil},
+		"end":     map[string]interface{}{"date": due.AddDate(0, 0, 1).Format(dateLayout), "dateTime": nil},
+	}
+}
+
+// parseSummary splits an event title into the task's and whether it has a
+// done mark.
+func parseSummary(summary string) (string, bool) {
+	summary = strings.TrimSpace(summary)
+	for _, mark := range doneMarks {
+		if strings.HasPrefix(summary, mark) {
+			return strings.TrimSpace(strings.TrimPrefix(summary, mark)), true
+		}
+	}
+	return summary, false
+}
+
+// eventUpdated returns when an event was last changed, zero if unknown.
+func eventUpdated(ev *Event) time.Time {
+	if ev == nil {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, ev.Updated)
+	return t
+}
+
+// sameDay reports whether a due date is set and falls on day.
+func sameDay(due *time.Time, day time.Time) bool {
+	return due != nil && due.Format(dateLayout) == day.Format(dateLayout)
+}
+
+// equalDue reports whether two due dates are the same day, or both unset.
+func equalDue(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return sameDay(a, *b)
+}
+
+func dayLabel(due *time.Time) string {
+	if due == nil {
+		return "no date"
+	}
+	return due.Format("Mon, Jan 2")
+}
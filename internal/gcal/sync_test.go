@@ -0,0 +1,358 @@
+package gcal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mytasks/internal/gtasks"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// fakeCalendar serves the token endpoint and the events of the primary
+// calendar. Deleted events stay listed as cancelled, as in Google's API.
+type fakeCalendar struct {
+	events    map[string]*Event
+	order     []string
+	version   int
+	refreshes int
+	patches   int
+}
+
+func newFakeCalendar() *fakeCalendar {
+	return &fakeCalendar{events: make(map[string]*Event)}
+}
+
+// touch gives an event a new version, as any change in the calendar does.
+func (f *fakeCalendar) touch(ev *Event, at time.Time) {
+	f.version++
+	ev.ETag = fmt.Sprintf(`"%d"`, f.version)
+	ev.Updated = at.UTC().Format(time.RFC3339)
+}
+
+// edit changes an event as if in the calendar at the given time.
+func (f *fakeCalendar) edit(id string, at time.Time, change func(ev *Event)) {
+	change(f.events[id])
+	f.touch(f.events[id], at)
+}
+
+func (f *fakeCalendar) only(t *testing.T) *Event {
+	t.Helper()
+	if len(f.order) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(f.order))
+	}
+	return f.events[f.order[0]]
+}
+
+func (f *fakeCalendar) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		r.ParseForm()
+		if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") != "refresh-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.refreshes++
+		w.Write([]byte(`{"access_token":"access-1","expires_in":3600}`))
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer access-1" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	const prefix = "/calendars/primary/events"
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	switch {
+	case !strings.HasPrefix(r.URL.Path, prefix):
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodGet && id == "":
+		if r.URL.Query().Get("privateExtendedProperty") != "mytasks=1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		items := []Event{}
+		for _, id := range f.order {
+			items = append(items, *f.events[id])
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+	case r.Method == http.MethodPost && id == "":
+		ev := &Event{}
+		json.NewDecoder(r.Body).Decode(ev)
+		ev.ID = fmt.Sprintf("ev%d", len(f.order)+1)
+		ev.Status = "confirmed"
+		f.touch(ev, time.Now())
+		f.events[ev.ID] = ev
+		f.order = append(f.order, ev.ID)
+		json.NewEncoder(w).Encode(ev)
+	case f.events[id] == nil || f.events[id].Status == "cancelled":
+		w.WriteHeader(http.StatusGone)
+	case r.Method == http.MethodPatch:
+		var fields struct {
+			Summary *string    `json:"summary"`
+			Start   *EventTime `json:"start"`
+			End     *EventTime `json:"end"`
+		}
+		json.NewDecoder(r.Body).Decode(&fields)
+		ev := f.events[id]
+		if fields.Summary != nil {
+			ev.Summary = *fields.Summary
+		}
+		if fields.Start != nil {
+			ev.Start = *fields.Start
+		}
+		if fields.End != nil {
+			ev.End = *fields.End
+		}
+		f.patches++
+		f.touch(ev, time.Now())
+		json.NewEncoder(w).Encode(ev)
+	case r.Method == http.MethodDelete:
+		f.events[id].Status = "cancelled"
+		f.touch(f.events[id], time.Now())
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func setupSyncer(t *testing.T, f *fakeCalendar) (*Syncer, store.Store, *models.Project) {
+	t.Helper()
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	if err := s.SaveGoogleCalendarAccount(ctx, &models.GoogleCalendarAccount{RefreshToken: "refresh-1"}); err != nil {
+		t.Fatalf("SaveGoogleCalendarAccount failed: %v", err)
+	}
+
+	syncer := NewSyncer(s, nil, gtasks.OAuthConfig{ClientID: "id", ClientSecret: "secret", TokenURL: srv.URL + "/token"})
+	syncer.httpClient = srv.Client()
+	syncer.baseURL = srv.URL
+	return syncer, s, project
+}
+
+func date(value string) *time.Time {
+	d, _ := time.Parse("2006-01-02", value)
+	return &d
+}
+
+func run(t *testing.T, syncer *Syncer) {
+	t.Helper()
+	if err := syncer.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+}
+
+func TestSyncer_CreatesAllDayEventsOnce(t *testing.T) {
+	f := newFakeCalendar()
+	syncer, s, project := setupSyncer(t, f)
+	ctx := context.Background()
+
+	dated := &models.Task{ProjectID: project.ID, Description: "Pay rent", Priority: "high", Status: "todo", DueDate: date("2026-03-01")}
+	s.CreateTask(ctx, dated)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Someday", Priority: "low", Status: "todo"})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Done already", Priority: "low", Status: "done", DueDate: date("2026-03-02")})
+
+	run(t, syncer)
+	run(t, syncer)
+
+	ev := f.only(t)
+	if ev.Summary != "Pay rent" || ev.Start.Date != "2026-03-01" || ev.End.Date != "2026-03-02" || ev.Transparency != "transparent" {
+		t.Errorf("unexpected event %+v", ev)
+	}
+	if ev.taskID() != dated.ID {
+		t.Errorf("event names task %d, want %d", ev.taskID(), dated.ID)
+	}
+	if f.refreshes != 1 || f.patches != 0 {
+		t.Errorf("expected one token refresh and no patches, got %d and %d", f.refreshes, f.patches)
+	}
+
+	links, _ := s.ListCalendarEvents(ctx)
+	if len(links) != 1 || links[0].EventID != ev.ID || links[0].ETag != ev.ETag || links[0].DueDate.Format("2006-01-02") != "2026-03-01" {
+		t.Errorf("unexpected links %+v", links)
+	}
+	account, _ := s.GetGoogleCalendarAccount(ctx)
+	if account.LastSyncedAt == nil {
+		t.Error("expected last sync time to be recorded")
+	}
+}
+
+func TestSyncer_PullsMovesAndCompletions(t *testing.T) {
+	f := newFakeCalendar()
+	syncer, s, project := setupSyncer(t, f)
+	ctx := context.Background()
+
+	task := &models.Task{ProjectID: project.ID, Description: "Dentist", Priority: "medium", Status: "todo", DueDate: date("2026-03-01")}
+	s.CreateTask(ctx, task)
+	run(t, syncer)
+	ev := f.only(t)
+
+	// Moving the event to a time of day still moves the task to that day.
+	f.edit(ev.ID, time.Now(), func(ev *Event) {
+		ev.Start = EventTime{DateTime: "2026-03-04T15:00:00+01:00"}
+	})
+	run(t, syncer)
+	got, _ := s.GetTask(ctx, task.ID)
+	if got.DueDate == nil || got.DueDate.Format("2006-01-02") != "2026-03-04" || got.IsDone() {
+		t.Fatalf("expected the task moved to March 4, got %+v", got)
+	}
+	if f.patches != 0 {
+		t.Errorf("a change pulled from the calendar was pushed back: %d patches", f.patches)
+	}
+
+	f.edit(ev.ID, time.Now(), func(ev *Event) { ev.Summary = "[x] Dentist" })
+	run(t, syncer)
+	got, _ = s.GetTask(ctx, task.ID)
+	if !got.IsDone() {
+		t.Fatalf("expected the task done, got %+v", got)
+	}
+
+	// The event of a done task keeps its mark.
+	f.edit(ev.ID, time.Now(), func(ev *Event) { ev.Summary = "Dentist" })
+	run(t, syncer)
+	if got, _ = s.GetTask(ctx, task.ID); !got.IsDone() || f.events[ev.ID].Summary != "✓ Dentist" {
+		t.Errorf("expected the mark put back on a done task, got %q", f.events[ev.ID].Summary)
+	}
+}
+
+func TestSyncer_PushesLocalChanges(t *testing.T) {
+	f := newFakeCalendar()
+	syncer, s, project := setupSyncer(t, f)
+	ctx := context.Background()
+
+	moved := &models.Task{ProjectID: project.ID, Description: "Call bank", Priority: "medium", Status: "todo", DueDate: date("2026-03-01")}
+	undated := &models.Task{ProjectID: project.ID, Description: "Book trip", Priority: "medium", Status: "todo", DueDate: date("2026-03-02")}
+	deleted := &models.Task{ProjectID: project.ID, Description: "Old errand", Priority: "medium", Status: "todo", DueDate: date("2026-03-03")}
+	for _, task := range []*models.Task{moved, undated, deleted} {
+		s.CreateTask(ctx, task)
+	}
+	run(t, syncer)
+
+	moved.Description = "Call the bank"
+	moved.DueDate = date("2026-03-05")
+	moved.Status = "done"
+	s.UpdateTask(ctx, moved)
+	undated.DueDate = nil
+	s.UpdateTask(ctx, undated)
+	s.DeleteTask(ctx, deleted.ID)
+	run(t, syncer)
+
+	ev := f.events["ev1"]
+	if ev.Summary != "✓ Call the bank" || ev.Start.Date != "2026-03-05" || ev.End.Date != "2026-03-06" {
+		t.Errorf("unexpected event %+v", ev)
+	}
+	if f.events["ev2"].Status != "cancelled" || f.events["ev3"].Status != "cancelled" {
+		t.Errorf("expected the events of the undated and deleted tasks deleted, got %q and %q", f.events["ev2"].Status, f.events["ev3"].Status)
+	}
+	links, _ := s.ListCalendarEvents(ctx)
+	if len(links) != 1 || links[0].TaskID != moved.ID || !links[0].Done {
+		t.Errorf("unexpected links %+v", links)
+	}
+
+	// Reopening here takes the mark off.
+	moved.Status = "todo"
+	s.UpdateTask(ctx, moved)
+	run(t, syncer)
+	if ev.Summary != "Call the bank" {
+		t.Errorf("expected the mark removed, got %q", ev.Summary)
+	}
+}
+
+func TestSyncer_ResolvesConflictsByLaterChange(t *testing.T) {
+	f := newFakeCalendar()
+	syncer, s, project := setupSyncer(t, f)
+	ctx := context.Background()
+
+	task := &models.Task{ProjectID: project.ID, Description: "Renew passport", Priority: "medium", Status: "todo", DueDate: date("2026-03-01")}
+	s.CreateTask(ctx, task)
+	run(t, syncer)
+	ev := f.only(t)
+
+	// Moved in the calendar after it was moved here: the calendar wins.
+	task.DueDate = date("2026-03-02")
+	s.UpdateTask(ctx, task)
+	f.edit(ev.ID, time.Now().Add(time.Hour), func(ev *Event) { ev.Start.Date, ev.End.Date = "2026-03-03", "2026-03-04" })
+	run(t, syncer)
+
+	got, _ := s.GetTask(ctx, task.ID)
+	if got.DueDate.Format("2006-01-02") != "2026-03-03" {
+		t.Errorf("expected the calendar's date kept, got %s", got.DueDate.Format("2006-01-02"))
+	}
+	links, _ := s.ListCalendarEvents(ctx)
+	if links[0].Conflict != "Changed to Mon, Mar 2 here and to Tue, Mar 3 in Google Calendar; kept Tue, Mar 3." || links[0].ConflictAt == nil {
+		t.Errorf("unexpected conflict %q at %v", links[0].Conflict, links[0].ConflictAt)
+	}
+
+	// Moved here after it was moved in the calendar: this side wins.
+	f.edit(ev.ID, time.Now().Add(-time.Hour), func(ev *Event) { ev.Start.Date, ev.End.Date = "2026-03-06", "2026-03-07" })
+	got.DueDate = date("2026-03-05")
+	s.UpdateTask(ctx, got)
+	run(t, syncer)
+
+	got, _ = s.GetTask(ctx, task.ID)
+	if got.DueDate.Format("2006-01-02") != "2026-03-05" || ev.Start.Date != "2026-03-05" {
+		t.Errorf("expected March 5 on both sides, got %s here and %s in the calendar", got.DueDate.Format("2006-01-02"), ev.Start.Date)
+	}
+	links, _ = s.ListCalendarEvents(ctx)
+	if !strings.HasSuffix(links[0].Conflict, "kept Thu, Mar 5.") {
+		t.Errorf("unexpected conflict %q", links[0].Conflict)
+	}
+}
+
+func TestSyncer_RemoteDeletionClearsDueDate(t *testing.T) {
+	f := newFakeCalendar()
+	syncer, s, project := setupSyncer(t, f)
+	ctx := context.Background()
+
+	task := &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "low", Status: "todo", DueDate: date("2026-03-01")}
+	s.CreateTask(ctx, task)
+	run(t, syncer)
+
+	f.edit(f.only(t).ID, time.Now(), func(ev *Event) { ev.Status = "cancelled" })
+	run(t, syncer)
+	run(t, syncer)
+
+	got, _ := s.GetTask(ctx, task.ID)
+	if got.DueDate != nil {
+		t.Errorf("expected the due date cleared, got %v", got.DueDate)
+	}
+	if links, _ := s.ListCalendarEvents(ctx); len(links) != 0 || len(f.order) != 1 {
+		t.Errorf("expected the link forgotten and no new event, got %+v and %d events", links, len(f.order))
+	}
+}
+
+func TestSyncer_TakesBackEventsAfterReconnecting(t *testing.T) {
+	f := newFakeCalendar()
+	syncer, s, project := setupSyncer(t, f)
+	ctx := context.Background()
+
+	task := &models.Task{ProjectID: project.ID, Description: "File taxes", Priority: "high", Status: "todo", DueDate: date("2026-04-15")}
+	s.CreateTask(ctx, task)
+	run(t, syncer)
+
+	if err := s.DeleteGoogleCalendarAccount(ctx); err != nil {
+		t.Fatalf("DeleteGoogleCalendarAccount failed: %v", err)
+	}
+	s.SaveGoogleCalendarAccount(ctx, &models.GoogleCalendarAccount{RefreshToken: "refresh-1"})
+	run(t, syncer)
+
+	ev := f.only(t)
+	links, _ := s.ListCalendarEvents(ctx)
+	if len(links) != 1 || links[0].EventID != ev.ID || links[0].ETag != ev.ETag {
+		t.Errorf("expected the old event linked again, got %+v", links)
+	}
+}
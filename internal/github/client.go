@@ -0,0 +1,143 @@
+// Package github syncs issues assigned to the user into linked projects.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultBaseURL is the GitHub REST API root.
+const DefaultBaseURL = "https://api.github.com"
+
+// Issue is the subset of a GitHub issue that sync uses.
+type Issue struct {
+	Number    int    `json:"number"`
+	Title     string `json:"title"`
+	State     string `json:"state"` // "open" or "closed"
+	HTMLURL   string `json:"html_url"`
+	Assignees []User `json:"assignees"`
+	// PullRequest is set when the issue is a pull request.
+	PullRequest *json.RawMessage `json:"pull_request,omitempty"`
+}
+
+// User is a GitHub account.
+type User struct {
+	Login string `json:"login"`
+}
+
+// AssignedTo reports whether login is among the issue's assignees.
+func (i *Issue) AssignedTo(login string) bool {
+	for _, a := range i.Assignees {
+		if a.Login == login {
+			return true
+		}
+	}
+	return false
+}
+
+// Client calls the GitHub REST API with a personal access token.
+type Client struct {
+	HTTPClient *http.Client
+	BaseURL    string
+	Token      string
+}
+
+// CurrentUser returns the login the token belongs to.
+func (c *Client) CurrentUser(ctx context.Context) (string, error) {
+	var user User
+	if err := c.do(ctx, http.MethodGet, "/user", nil, &user); err != nil {
+		return "", err
+	}
+	return user.Login, nil
+}
+
+// ListAssignedIssues returns open and closed issues in repo assigned to
+// login, limited to those updated since the given time when it is non-zero.
+// Pull requests are skipped.
+func (c *Client) ListAssignedIssues(ctx context.Context, repo, login string, since time.Time) ([]Issue, error) {
+	const perPage = 100
+
+	var issues []Issue
+	for page := 1; ; page++ {
+		q := url.Values{
+			"assignee": {login},
+			"state":    {"all"},
+			"per_page": {strconv.Itoa(perPage)},
+			"page":     {strconv.Itoa(page)},
+		}
+		if !since.IsZero() {
+			q.Set("since", since.UTC().Format(time.RFC3339))
+		}
+
+		var batch []Issue
+		if err := c.do(ctx, http.MethodGet, "/repos/"+repo+"/issues?"+q.Encode(), nil, &batch); err != nil {
+			return nil, err
+		}
+		for _, issue := range batch {
+			if issue.PullRequest == nil {
+				issues = append(issues, issue)
+			}
+		}
+		if len(batch) < perPage {
+			return issues, nil
+		}
+	}
+}
+
+// SetIssueState opens or closes an issue.
+func (c *Client) SetIssueState(ctx context.Context, repo string, number int, state string) error {
+	body := map[string]string{"state": state}
+	return c.do(ctx, http.MethodPatch, "/repos/"+repo+"/issues/"+strconv.Itoa(number), body, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	var body io.Reader
+	if in != nil {
+		buf, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("github: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("github: %s %s returned %d: %s", method, path, resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("github: invalid response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+const (
+	// Source is the task_external_refs source for issue references.
+	Source = "github"
+	// Origin tags events caused by sync so they are not pushed back to GitHub.
+	Origin = "github"
+)
+
+// IssueRef returns the external ID stored for an issue, e.g. "octo/app#12".
+func IssueRef(repo string, number int) string {
+	return repo + "#" + strconv.Itoa(number)
+}
+
+// ParseIssueRef splits an external ID created by IssueRef.
+func ParseIssueRef(ref string) (repo string, number int, ok bool) {
+	i := strings.LastIndexByte(ref, '#')
+	if i <= 0 {
+		return "", 0, false
+	}
+	number, err := strconv.Atoi(ref[i+1:])
+	if err != nil || number <= 0 {
+		return "", 0, false
+	}
+	return ref[:i], number, true
+}
+
+// IssueURL returns the web URL for an issue reference, or "" if it is invalid.
+func IssueURL(ref string) string {
+	repo, number, ok := ParseIssueRef(ref)
+	if !ok {
+		return ""
+	}
+	return "https://github.com/" + repo + "/issues/" + strconv.Itoa(number)
+}
+
+// Syncer keeps linked projects in step with GitHub. Open issues assigned to
+// the link's owner become tasks; closing or reopening either side mirrors
+// to the other. Titles and other fields are only copied on creation.
+type Syncer struct {
+	store      store.Store
+	bus        *events.Bus
+	httpClient *http.Client
+	baseURL    string
+	now        func() time.Time
+}
+
+// NewSyncer creates a Syncer. bus may be nil.
+func NewSyncer(s store.Store, bus *events.Bus) *Syncer {
+	return &Syncer{
+		store:      s,
+		bus:        bus,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    DefaultBaseURL,
+		now:        time.Now,
+	}
+}
+
+func (s *Syncer) client(link *models.GitHubLink) *Client {
+	return &Client{HTTPClient: s.httpClient, BaseURL: s.baseURL, Token: link.Token}
+}
+
+// Run polls every linked repository. A failing repository does not stop
+// the others.
+func (s *Syncer) Run(ctx context.Context) error {
+	links, err := s.store.ListGitHubLinks(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range links {
+		if err := s.SyncLink(ctx, &links[i]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", links[i].Repo, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SyncLink pulls issues updated since the link's last sync.
+func (s *Syncer) SyncLink(ctx context.Context, link *models.GitHubLink) error {
+	started := s.now()
+	var since time.Time
+	if link.LastSyncedAt != nil {
+		// Overlap slightly so clock skew cannot drop edits.
+		since = link.LastSyncedAt.Add(-5 * time.Minute)
+	}
+
+	issues, err := s.client(link).ListAssignedIssues(ctx, link.Repo, link.Login, since)
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		if err := s.ApplyIssue(ctx, link, issue); err != nil {
+			return err
+		}
+	}
+
+	link.LastSyncedAt = &started
+	return s.store.SaveGitHubLink(ctx, link)
+}
+
+// ApplyIssue creates a task for a newly seen open issue assigned to the
+// link's owner, or mirrors the issue's state onto the task already linked.
+func (s *Syncer) ApplyIssue(ctx context.Context, link *models.GitHubLink, issue Issue) error {
+	if issue.PullRequest != nil {
+		return nil
+	}
+
+	ref := IssueRef(link.Repo, issue.Number)
+	taskID, err := s.store.GetTaskIDByExternalRef(ctx, Source, ref)
+	switch {
+	case err == nil:
+		return s.applyState(ctx, taskID, issue)
+	case !errors.Is(err, store.ErrNotFound):
+		return err
+	}
+
+	if issue.State != "open" || !issue.AssignedTo(link.Login) {
+		return nil
+	}
+
+	task := &models.Task{
+		ProjectID:   link.ProjectID,
+		Description: strings.TrimSpace(issue.Title),
+		Priority:    "medium",
+		Status:      "todo",
+	}
+	if err := task.Validate(); err != nil {
+		return nil
+	}
+	if err := s.store.CreateTask(ctx, task); err != nil {
+		return err
+	}
+	if err := s.store.SetTaskExternalRef(ctx, Source, ref, task.ID); err != nil {
+		return err
+	}
+
+	s.bus.Publish(ctx, events.Event{Type: events.TaskCreated, TaskID: task.ID, ProjectID: task.ProjectID, Task: task, Origin: Origin})
+	return nil
+}
+
+func (s *Syncer) applyState(ctx context.Context, taskID int64, issue Issue) error {
+	task, err := s.store.GetTask(ctx, taskID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	closed := issue.State == "closed"
+	if closed == task.IsDone() {
+		return nil
+	}
+
+	eventType := events.TaskCompleted
+	task.Status = "done"
+	if !closed {
+		eventType = events.TaskReopened
+		task.Status = "todo"
+	}
+	if err := s.store.UpdateTask(ctx, task); err != nil {
+		return err
+	}
+
+	s.bus.Publish(ctx, events.Event{Type: eventType, TaskID: task.ID, ProjectID: task.ProjectID, Task: task, Origin: Origin})
+	return nil
+}
+
+// HandleEvent closes or reopens the linked issue when a synced task is
+// completed or reopened here. Subscribe it to TaskCompleted and TaskReopened.
+// The API call runs in the background so the originating request is not
+// held up by GitHub.
+func (s *Syncer) HandleEvent(_ context.Context, e events.Event) {
+	if e.Origin == Origin {
+		return
+	}
+
+	var state string
+	switch e.Type {
+	case events.TaskCompleted:
+		state = "closed"
+	case events.TaskReopened:
+		state = "open"
+	default:
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := s.pushState(ctx, e.TaskID, state); err != nil {
+			slog.Error("github: failed to update issue", "task", e.TaskID, "err", err)
+		}
+	}()
+}
+
+func (s *Syncer) pushState(ctx context.Context, taskID int64, state string) error {
+	ref, err := s.store.GetTaskExternalRef(ctx, Source, taskID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	repo, number, ok := ParseIssueRef(ref)
+	if !ok {
+		return nil
+	}
+	link, err := s.store.GetGitHubLinkByRepo(ctx, repo)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.client(link).SetIssueState(ctx, link.Repo, number, state)
+}
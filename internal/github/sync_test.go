@@ -0,0 +1,185 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// fakeGitHub serves assigned issues for octo/app and records state changes.
+type fakeGitHub struct {
+	issues  []Issue
+	patches chan string
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer tok" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/octo/app/issues":
+		if r.URL.Query().Get("assignee") != "me" || r.URL.Query().Get("state") != "all" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(f.issues)
+	case r.Method == http.MethodPatch:
+		body, _ := io.ReadAll(r.Body)
+		f.patches <- r.URL.Path + " " + string(body)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func setupSyncer(t *testing.T, f *fakeGitHub) (*Syncer, store.Store, *models.GitHubLink) {
+	t.Helper()
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	f.patches = make(chan string, 4)
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	project := &models.Project{Name: "App", Type: "project"}
+	s.CreateProject(ctx, project)
+	link := &models.GitHubLink{ProjectID: project.ID, Repo: "octo/app", Token: "tok", Login: "me", WebhookSecret: "shh"}
+	if err := s.SaveGitHubLink(ctx, link); err != nil {
+		t.Fatalf("SaveGitHubLink failed: %v", err)
+	}
+
+	syncer := NewSyncer(s, nil)
+	syncer.httpClient = srv.Client()
+	syncer.baseURL = srv.URL
+	return syncer, s, link
+}
+
+func TestSyncer_CreatesAssignedOpenIssuesOnce(t *testing.T) {
+	me := []User{{Login: "me"}}
+	pr := json.RawMessage(`{}`)
+	f := &fakeGitHub{issues: []Issue{
+		{Number: 1, Title: "Fix login", State: "open", Assignees: me},
+		{Number: 2, Title: "Old bug", State: "closed", Assignees: me},
+		{Number: 3, Title: "A pull request", State: "open", Assignees: me, PullRequest: &pr},
+	}}
+	syncer, s, link := setupSyncer(t, f)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := syncer.Run(ctx); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+	}
+
+	tasks, _ := s.ListTasksByProject(ctx, link.ProjectID, 0)
+	if len(tasks) != 1 || tasks[0].Description != "Fix login" {
+		t.Fatalf("expected one task for the open issue, got %+v", tasks)
+	}
+	ref, err := s.GetTaskExternalRef(ctx, Source, tasks[0].ID)
+	if err != nil || IssueURL(ref) != "https://github.com/octo/app/issues/1" {
+		t.Errorf("expected issue ref, got %q (%v)", ref, err)
+	}
+	saved, _ := s.GetGitHubLink(ctx, link.ProjectID)
+	if saved.LastSyncedAt == nil {
+		t.Error("expected last sync time to be recorded")
+	}
+}
+
+func TestSyncer_MirrorsIssueState(t *testing.T) {
+	f := &fakeGitHub{issues: []Issue{{Number: 7, Title: "Ship it", State: "open", Assignees: []User{{Login: "me"}}}}}
+	syncer, s, link := setupSyncer(t, f)
+	ctx := context.Background()
+
+	states := []struct {
+		issueState string
+		wantStatus string
+	}{
+		{"open", "todo"},
+		{"closed", "done"},
+		{"open", "todo"},
+	}
+	for _, tt := range states {
+		f.issues[0].State = tt.issueState
+		if err := syncer.SyncLink(ctx, link); err != nil {
+			t.Fatalf("SyncLink failed: %v", err)
+		}
+		tasks, _ := s.ListTasksByProject(ctx, link.ProjectID, 0)
+		if len(tasks) != 1 || tasks[0].Status != tt.wantStatus {
+			t.Fatalf("issue %s: expected status %s, got %+v", tt.issueState, tt.wantStatus, tasks)
+		}
+	}
+}
+
+func TestSyncer_HandleEventClosesIssue(t *testing.T) {
+	f := &fakeGitHub{issues: []Issue{{Number: 4, Title: "Write docs", State: "open", Assignees: []User{{Login: "me"}}}}}
+	syncer, s, link := setupSyncer(t, f)
+	ctx := context.Background()
+
+	if err := syncer.SyncLink(ctx, link); err != nil {
+		t.Fatalf("SyncLink failed: %v", err)
+	}
+	tasks, _ := s.ListTasksByProject(ctx, link.ProjectID, 0)
+
+	// Events caused by sync itself must not be echoed back.
+	syncer.HandleEvent(ctx, events.Event{Type: events.TaskCompleted, TaskID: tasks[0].ID, Origin: Origin})
+	syncer.HandleEvent(ctx, events.Event{Type: events.TaskCompleted, TaskID: tasks[0].ID})
+
+	select {
+	case got := <-f.patches:
+		if got != `/repos/octo/app/issues/4 {"state":"closed"}` {
+			t.Errorf("unexpected request %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the issue to be closed")
+	}
+	select {
+	case got := <-f.patches:
+		t.Errorf("unexpected extra request %q", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestParseIssueRef(t *testing.T) {
+	tests := []struct {
+		ref    string
+		repo   string
+		number int
+		ok     bool
+	}{
+		{"octo/app#12", "octo/app", 12, true},
+		{"octo/app", "", 0, false},
+		{"#3", "", 0, false},
+		{"octo/app#x", "", 0, false},
+	}
+	for _, tt := range tests {
+		repo, number, ok := ParseIssueRef(tt.ref)
+		if repo != tt.repo || number != tt.number || ok != tt.ok {
+			t.Errorf("ParseIssueRef(%q) = %q, %d, %v", tt.ref, repo, number, ok)
+		}
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"action":"closed"}`)
+	if err := VerifySignature("shh", Sign("shh", body), body); err != nil {
+		t.Errorf("expected valid signature, got %v", err)
+	}
+	if err := VerifySignature("shh", Sign("other", body), body); err != ErrBadSignature {
+		t.Errorf("expected ErrBadSignature, got %v", err)
+	}
+	if err := VerifySignature("", Sign("", body), body); err != ErrBadSignature {
+		t.Errorf("expected empty secret to be rejected, got %v", err)
+	}
+}
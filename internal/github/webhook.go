@@ -0,0 +1,26 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+var ErrBadSignature = errors.New("github: webhook signature mismatch")
+
+// VerifySignature checks a delivery's X-Hub-Signature-256 header against the
+// webhook secret. body must be the raw request body.
+func VerifySignature(secret, signature string, body []byte) error {
+	if secret == "" || !hmac.Equal([]byte(signature), []byte(Sign(secret, body))) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Sign returns the X-Hub-Signature-256 value GitHub sends for body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,555 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxDepth bounds how deeply a query may nest selections when
+// Schema.MaxDepth is zero.
+const defaultMaxDepth = 10
+
+// Schema is what requests can ask for: the fields of Query and, if set,
+// Mutation.
+type Schema struct {
+	Query    *Object
+	Mutation *Object
+	// MaxDepth bounds how deeply selections nest, so a query can't walk
+	// relations back and forth without end.
+	MaxDepth int
+}
+
+// Object is a type with fields.
+type Object struct {
+	Name   string
+	Fields map[string]*Field
+}
+
+// Field is a field of an Object.
+type Field struct {
+	// Type is the object the field returns, alone or in a slice; nil for
+	// scalars, which are returned as they marshal to JSON.
+	Type *Object
+	// Args declares the arguments the field takes, by name, with a type of
+	// ID, Int, Float, String or Boolean, followed by ! when required.
+	Args map[string]string
+	// Resolve returns the field's value on source, the value of the object
+	// it belongs to, or nil for Query and Mutation. Structs reach it as
+	// pointers. When Resolve is nil, the field is read from source's JSON,
+	// under the field's name.
+	Resolve func(ctx context.Context, source interface{}, args Args) (interface{}, error)
+}
+
+// ScalarFields returns a field for each property of v's JSON encoding, by
+// its json tag, to fill in an Object for a model.
+func ScalarFields(v interface{}) map[string]*Field {
+	fields := map[string]*Field{}
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if !sf.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields[name] = &Field{}
+	}
+	return fields
+}
+
+// Args are a field's arguments, coerced to the declared types: int64 for
+// ID and Int, float64, string and bool. Arguments not given, or given as
+// null, are missing.
+type Args map[string]interface{}
+
+// Has reports whether the argument was given.
+func (a Args) Has(name string) bool {
+	_, ok := a[name]
+	return ok
+}
+
+// Int returns an ID or Int argument, or 0.
+func (a Args) Int(name string) int64 {
+	n, _ := a[name].(int64)
+	return n
+}
+
+// String returns a String argument, or "".
+func (a Args) String(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+// Bool returns a Boolean argument, or false.
+func (a Args) Bool(name string) bool {
+	b, _ := a[name].(bool)
+	return b
+}
+
+// Location is a line and column in a document, counted from 1.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is a GraphQL error, for the errors of a Response.
+type Error struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	// Path is the response key and list index of each field down to the
+	// one that failed.
+	Path []interface{} `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Response is the result of a request. Data is nil when the request could
+// not be run at all.
+type Response struct {
+	Data   *OrderedMap `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// OrderedMap is a JSON object that keeps its keys in the order the query
+// asked for them.
+type OrderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *OrderedMap) set(key string, v interface{}) {
+	if m.values == nil {
+		m.values = map[string]interface{}{}
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+// Get returns the value at key.
+func (m *OrderedMap) Get(key string) interface{} {
+	return m.values[key]
+}
+
+// MarshalJSON writes the keys in order.
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		b.Write(key)
+		b.WriteByte(':')
+		v, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// Execute runs an operation of doc, picked as by Document.Operation, with
+// variables decoded from JSON. Resolver errors leave their field null and
+// are listed in the response; mistakes in the request, such as unknown
+// fields, fail it as a whole.
+func (s *Schema) Execute(ctx context.Context, doc *Document, operationName string, variables map[string]interface{}) *Response {
+	op, err := doc.Operation(operationName)
+	if err != nil {
+		return &Response{Errors: []*Error{asError(err)}}
+	}
+	root := s.Query
+	switch op.Type {
+	case "mutation":
+		root = s.Mutation
+	case "subscription":
+		root = nil
+	}
+	if root == nil {
+		return &Response{Errors: []*Error{{Message: fmt.Sprintf("The schema does not support %ss.", op.Type)}}}
+	}
+
+	e := &executor{ctx: ctx, schema: s, doc: doc, maxDepth: s.MaxDepth}
+	if e.maxDepth == 0 {
+		e.maxDepth = defaultMaxDepth
+	}
+	if e.vars, err = variableValues(op, variables); err != nil {
+		return &Response{Errors: []*Error{asError(err)}}
+	}
+	if err := e.validate(root, op.sel, 1); err != nil {
+		return &Response{Errors: []*Error{asError(err)}}
+	}
+	data := e.selectionSet(root, nil, op.sel, nil)
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func asError(err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+	return &Error{Message: err.Error()}
+}
+
+// variableValues reads the operation's variables from input, taking
+// defaults for those left out.
+func variableValues(op *Operation, input map[string]interface{}) (map[string]interface{}, error) {
+	vars := map[string]interface{}{}
+	for _, def := range op.vars {
+		v, ok := input[def.name]
+		if !ok && def.def != nil {
+			v, ok = literal(def.def, nil), true
+		}
+		if (!ok || v == nil) && strings.HasSuffix(def.typ, "!") {
+			return nil, &Error{Message: fmt.Sprintf("Variable $%s of type %s is required.", def.name, def.typ), Locations: []Location{def.loc}}
+		}
+		if ok {
+			vars[def.name] = v
+		}
+	}
+	return vars, nil
+}
+
+// literal turns a value into Go, taking variables from vars.
+func literal(v value, vars map[string]interface{}) interface{} {
+	switch v := v.(type) {
+	case varRef:
+		return vars[string(v)]
+	case enumValue:
+		return string(v)
+	case []value:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = literal(item, vars)
+		}
+		return list
+	case []objectField:
+		obj := map[string]interface{}{}
+		for _, f := range v {
+			obj[f.name] = literal(f.val, vars)
+		}
+		return obj
+	}
+	return v
+}
+
+type executor struct {
+	ctx      context.Context
+	schema   *Schema
+	doc      *Document
+	vars     map[string]interface{}
+	maxDepth int
+	errors   []*Error
+}
+
+// collected is a response key and the fields asked for under it, whose
+// selections are merged.
+type collected struct {
+	key    string
+	fields []*fieldNode
+}
+
+// collect lists the fields of sel that apply to obj, expanding fragments
+// and dropping fields skipped by @skip or @include.
+func (e *executor) collect(obj *Object, sel []selection, out []collected, visited map[string]bool) ([]collected, error) {
+	for _, s := range sel {
+		switch s := s.(type) {
+		case *fieldNode:
+			include, err := e.included(s.directives)
+			if err != nil {
+				return nil, err
+			}
+			if !include {
+				continue
+			}
+			found := false
+			for i := range out {
+				if out[i].key == s.key() {
+					if out[i].fields[0].name != s.name {
+						return nil, &Error{Message: fmt.Sprintf("Fields %q conflict: they ask for %s and %s.", s.key(), out[i].fields[0].name, s.name), Locations: []Location{s.loc}}
+					}
+					out[i].fields = append(out[i].fields, s)
+					found = true
+					break
+				}
+			}
+			if !found {
+				out = append(out, collected{key: s.key(), fields: []*fieldNode{s}})
+			}
+		case *fragmentSpread:
+			include, err := e.included(s.directives)
+			if err != nil {
+				return nil, err
+			}
+			if !include || visited[s.name] {
+				continue
+			}
+			f, ok := e.doc.fragments[s.name]
+			if !ok {
+				return nil, &Error{Message: fmt.Sprintf("Unknown fragment %q.", s.name), Locations: []Location{s.loc}}
+			}
+			visited[s.name] = true
+			if f.typeCond != obj.Name {
+				continue
+			}
+			if out, err = e.collect(obj, f.sel, out, visited); err != nil {
+				return nil, err
+			}
+		case *inlineFragment:
+			include, err := e.included(s.directives)
+			if err != nil {
+				return nil, err
+			}
+			if !include || (s.typeCond != "" && s.typeCond != obj.Name) {
+				continue
+			}
+			if out, err = e.collect(obj, s.sel, out, visited); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
+// included applies @skip(if:) and @include(if:).
+func (e *executor) included(dirs []directive) (bool, error) {
+	for _, d := range dirs {
+		if d.name != "skip" && d.name != "include" {
+			return false, &Error{Message: fmt.Sprintf("Unknown directive @%s.", d.name), Locations: []Location{d.loc}}
+		}
+		var cond interface{}
+		for _, a := range d.args {
+			if a.name == "if" {
+				cond = literal(a.val, e.vars)
+			}
+		}
+		b, ok := cond.(bool)
+		if !ok {
+			return false, &Error{Message: fmt.Sprintf("Directive @%s needs a Boolean if argument.", d.name), Locations: []Location{d.loc}}
+		}
+		if b == (d.name == "skip") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// validate checks the selections on obj against the schema before anything
+// is resolved, so mistakes are found whatever the data.
+func (e *executor) validate(obj *Object, sel []selection, depth int) error {
+	if depth > e.maxDepth {
+		return &Error{Message: fmt.Sprintf("The query nests more than %d levels deep.", e.maxDepth)}
+	}
+	fields, err := e.collect(obj, sel, nil, map[string]bool{})
+	if err != nil {
+		return err
+	}
+	for _, c := range fields {
+		f := c.fields[0]
+		if f.name == "__typename" {
+			continue
+		}
+		def, ok := obj.Fields[f.name]
+		if !ok {
+			return &Error{Message: fmt.Sprintf("Cannot query field %q on type %q.", f.name, obj.Name), Locations: []Location{f.loc}}
+		}
+		subsel := c.subselection()
+		if def.Type == nil && subsel != nil {
+			return &Error{Message: fmt.Sprintf("Field %q of type %q is a scalar and can't have a selection.", f.name, obj.Name), Locations: []Location{f.loc}}
+		}
+		if def.Type != nil && subsel == nil {
+			return &Error{Message: fmt.Sprintf("Field %q of type %q must have a selection of subfields.", f.name, obj.Name), Locations: []Location{f.loc}}
+		}
+		for _, f := range c.fields {
+			if _, err := e.args(def, f); err != nil {
+				return err
+			}
+		}
+		if def.Type != nil {
+			if err := e.validate(def.Type, subsel, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// subselection merges the selections of the fields.
+func (c collected) subselection() []selection {
+	var sel []selection
+	for _, f := range c.fields {
+		sel = append(sel, f.sel...)
+	}
+	return sel
+}
+
+// selectionSet resolves the selected fields of obj on source, which
+// validate has checked. Resolver errors leave their field null.
+func (e *executor) selectionSet(obj *Object, source interface{}, sel []selection, path []interface{}) *OrderedMap {
+	fields, _ := e.collect(obj, sel, nil, map[string]bool{})
+	result := &OrderedMap{}
+	var sourceJSON map[string]json.RawMessage
+	for _, c := range fields {
+		f := c.fields[0]
+		fieldPath := append(path[:len(path):len(path)], c.key)
+		fail := func(err error) {
+			e.errors = append(e.errors, &Error{Message: err.Error(), Locations: []Location{f.loc}, Path: fieldPath})
+			result.set(c.key, nil)
+		}
+		if f.name == "__typename" {
+			result.set(c.key, obj.Name)
+			continue
+		}
+		def := obj.Fields[f.name]
+
+		if def.Resolve == nil {
+			if sourceJSON == nil {
+				raw, err := json.Marshal(source)
+				if err == nil {
+					err = json.Unmarshal(raw, &sourceJSON)
+				}
+				if err != nil {
+					fail(err)
+					continue
+				}
+			}
+			var v interface{}
+			if raw, ok := sourceJSON[f.name]; ok {
+				v = raw
+			}
+			result.set(c.key, v)
+			continue
+		}
+		args, err := e.args(def, f)
+		if err != nil {
+			fail(err)
+			continue
+		}
+		v, err := def.Resolve(e.ctx, source, args)
+		if err != nil {
+			fail(err)
+			continue
+		}
+		if def.Type == nil {
+			result.set(c.key, v)
+			continue
+		}
+		result.set(c.key, e.complete(def.Type, v, c.subselection(), fieldPath))
+	}
+	return result
+}
+
+// complete resolves the selection on an object value: nil, a pointer or
+// struct, or a slice of them.
+func (e *executor) complete(obj *Object, v interface{}, sel []selection, path []interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Slice) && rv.IsNil() {
+		return nil
+	}
+	if rv.Kind() == reflect.Slice {
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			item := rv.Index(i)
+			if item.Kind() == reflect.Struct {
+				item = item.Addr()
+			}
+			list[i] = e.complete(obj, item.Interface(), sel, append(path[:len(path):len(path)], i))
+		}
+		return list
+	}
+	if rv.Kind() == reflect.Struct {
+		// Resolvers are handed structs by pointer.
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		v = ptr.Interface()
+	}
+	return e.selectionSet(obj, v, sel, path)
+}
+
+// args coerces the arguments of f to those def declares.
+func (e *executor) args(def *Field, f *fieldNode) (Args, error) {
+	args := Args{}
+	for _, a := range f.args {
+		typ, ok := def.Args[a.name]
+		if !ok {
+			return nil, &Error{Message: fmt.Sprintf("Unknown argument %q on field %q.", a.name, f.name), Locations: []Location{a.loc}}
+		}
+		v := literal(a.val, e.vars)
+		if v == nil {
+			continue
+		}
+		coerced, err := coerce(strings.TrimSuffix(typ, "!"), v)
+		if err != nil {
+			return nil, &Error{Message: fmt.Sprintf("Argument %q of field %q: %v.", a.name, f.name, err), Locations: []Location{a.loc}}
+		}
+		args[a.name] = coerced
+	}
+	for name, typ := range def.Args {
+		if strings.HasSuffix(typ, "!") && !args.Has(name) {
+			return nil, &Error{Message: fmt.Sprintf("Argument %q of type %q is required on field %q.", name, typ, f.name), Locations: []Location{f.loc}}
+		}
+	}
+	return args, nil
+}
+
+// coerce converts an argument, from a literal or a JSON variable, to typ.
+func coerce(typ string, v interface{}) (interface{}, error) {
+	switch typ {
+	case "ID":
+		switch v := v.(type) {
+		case string:
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return n, nil
+			}
+		case int64:
+			return v, nil
+		case float64:
+			if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+				return int64(v), nil
+			}
+		}
+		return nil, fmt.Errorf("expected an ID, got %v", v)
+	case "Int":
+		switch v := v.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+				return int64(v), nil
+			}
+		}
+		return nil, fmt.Errorf("expected an Int, got %v", v)
+	case "Float":
+		switch v := v.(type) {
+		case int64:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		}
+		return nil, fmt.Errorf("expected a Float, got %v", v)
+	case "String":
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("expected a String, got %v", v)
+	case "Boolean":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("expected a Boolean, got %v", v)
+	}
+	return nil, fmt.Errorf("unsupported type %s", typ)
+}
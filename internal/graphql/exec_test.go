@@ -0,0 +1,175 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type book struct {
+	ID       int64  `json:"id"`
+	Title    string `json:"title"`
+	AuthorID int64  `json:"author_id"`
+	Secret   string `json:"-"`
+}
+
+type author struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// testSchema has authors with books, and books with their author.
+func testSchema() *Schema {
+	authors := []author{{1, "Le Guin"}, {2, "Pratchett"}}
+	books := []book{{10, "The Dispossessed", 1, "x"}, {11, "Mort", 2, "x"}, {12, "Small Gods", 2, "x"}}
+
+	authorObj := &Object{Name: "Author", Fields: ScalarFields(author{})}
+	bookObj := &Object{Name: "Book", Fields: ScalarFields(book{})}
+	authorObj.Fields["books"] = &Field{Type: bookObj, Resolve: func(_ context.Context, source interface{}, _ Args) (interface{}, error) {
+		var out []book
+		for _, b := range books {
+			if b.AuthorID == source.(*author).ID {
+				out = append(out, b)
+			}
+		}
+		return out, nil
+	}}
+	bookObj.Fields["author"] = &Field{Type: authorObj, Resolve: func(_ context.Context, source interface{}, _ Args) (interface{}, error) {
+		for i := range authors {
+			if authors[i].ID == source.(*book).AuthorID {
+				return &authors[i], nil
+			}
+		}
+		return nil, nil
+	}}
+	bookObj.Fields["blurb"] = &Field{Resolve: func(_ context.Context, source interface{}, _ Args) (interface{}, error) {
+		if source.(*book).ID == 11 {
+			return nil, errors.New("no blurb")
+		}
+		return "A good read", nil
+	}}
+
+	return &Schema{
+		Query: &Object{Name: "Query", Fields: map[string]*Field{
+			"authors": {Type: authorObj, Resolve: func(context.Context, interface{}, Args) (interface{}, error) {
+				return authors, nil
+			}},
+			"book": {Type: bookObj, Args: map[string]string{"id": "ID!"}, Resolve: func(_ context.Context, _ interface{}, args Args) (interface{}, error) {
+				for i := range books {
+					if books[i].ID == args.Int("id") {
+						return &books[i], nil
+					}
+				}
+				return nil, nil
+			}},
+			"echo": {Args: map[string]string{"s": "String", "n": "Int", "f": "Float", "b": "Boolean"}, Resolve: func(_ context.Context, _ interface{}, args Args) (interface{}, error) {
+				return args, nil
+			}},
+		}},
+		MaxDepth: 4,
+	}
+}
+
+func execute(t *testing.T, s *Schema, query string, vars map[string]interface{}) string {
+	t.Helper()
+	doc, err := Parse(query)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	body, err := json.Marshal(s.Execute(context.Background(), doc, "", vars))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	return string(body)
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]interface{}
+		want  string
+	}{
+		{
+			"nested lists keep the query's order",
+			`{ authors { name books { title id } } }`,
+			nil,
+			`{"data":{"authors":[{"name":"Le Guin","books":[{"title":"The Dispossessed","id":10}]},{"name":"Pratchett","books":[{"title":"Mort","id":11},{"title":"Small Gods","id":12}]}]}}`,
+		},
+		{
+			"aliases, arguments and __typename",
+			`{ a: book(id: 10) { __typename title } b: book(id: "12") { title author { name } } none: book(id: 99) { title } }`,
+			nil,
+			`{"data":{"a":{"__typename":"Book","title":"The Dispossessed"},"b":{"title":"Small Gods","author":{"name":"Pratchett"}},"none":null}}`,
+		},
+		{
+			"fragments merge into one field",
+			`query { book(id: 10) { id ...F ... on Book { author { id } } ... on Author { name } } } fragment F on Book { title author { name } }`,
+			nil,
+			`{"data":{"book":{"id":10,"title":"The Dispossessed","author":{"name":"Le Guin","id":1}}}}`,
+		},
+		{
+			"skip and include",
+			`query($no: Boolean!) { book(id: 10) { id @skip(if: true) title @include(if: $no) author_id } }`,
+			map[string]interface{}{"no": false},
+			`{"data":{"book":{"author_id":1}}}`,
+		},
+		{
+			"variables and defaults",
+			`query($s: String = "hi", $n: Int) { echo(s: $s, n: $n, f: 2, b: true) }`,
+			map[string]interface{}{"n": 3.0},
+			`{"data":{"echo":{"b":true,"f":2,"n":3,"s":"hi"}}}`,
+		},
+		{
+			"resolver errors null the field",
+			`{ authors { books { id blurb } } }`,
+			nil,
+			`{"data":{"authors":[{"books":[{"id":10,"blurb":"A good read"}]},{"books":[{"id":11,"blurb":null},{"id":12,"blurb":"A good read"}]}]},"errors":[{"message":"no blurb","locations":[{"line":1,"column":24}],"path":["authors",1,"books",0,"blurb"]}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := execute(t, testSchema(), tt.query, tt.vars); got != tt.want {
+				t.Errorf("expected\n  %s\ngot\n  %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExecute_Errors(t *testing.T) {
+	tests := []struct {
+		query string
+		vars  map[string]interface{}
+		msg   string
+	}{
+		{`{ nope }`, nil, `Cannot query field "nope" on type "Query".`},
+		{`{ authors }`, nil, `Field "authors" of type "Query" must have a selection of subfields.`},
+		{`{ book(id: 1) { title { x } } }`, nil, `Field "title" of type "Book" is a scalar and can't have a selection.`},
+		{`{ book { title } }`, nil, `Argument "id" of type "ID!" is required on field "book".`},
+		{`{ book(id: 1, page: 2) { title } }`, nil, `Unknown argument "page" on field "book".`},
+		{`{ echo(n: "three") }`, nil, `Argument "n" of field "echo": expected an Int, got three.`},
+		{`query($id: ID!) { book(id: $id) { title } }`, nil, `Variable $id of type ID! is required.`},
+		{`{ book(id: 10) { secret } }`, nil, `Cannot query field "secret" on type "Book".`},
+		{`{ book(id: 10) { ...Missing } }`, nil, `Unknown fragment "Missing".`},
+		{`{ book(id: 10) { title @defer } }`, nil, `Unknown directive @defer.`},
+		{`{ book(id: 10) { x: title x: id } }`, nil, `Fields "x" conflict: they ask for title and id.`},
+		{`{ authors { books { author { books { id } } } } }`, nil, `The query nests more than 4 levels deep.`},
+		{`mutation { x }`, nil, `The schema does not support mutations.`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			doc, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			resp := testSchema().Execute(context.Background(), doc, "", tt.vars)
+			if resp.Data != nil {
+				t.Errorf("expected no data, got %v", resp.Data)
+			}
+			if len(resp.Errors) != 1 || resp.Errors[0].Message != tt.msg {
+				t.Errorf("expected %q, got %+v", tt.msg, resp.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"mytasks/internal/events"
+	"mytasks/internal/store"
+)
+
+// maxBody caps the size of a POSTed request.
+const maxBody = 1 << 20
+
+// params are a request's query, operation name and variables.
+type params struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// NewHandler serves the mytasks schema over HTTP: queries by GET or POST,
+// mutations by POST only. Mutations are refused with 503 while readOnly
+// returns true, for maintenance mode. bus may be nil.
+func NewHandler(s store.Store, bus *events.Bus, readOnly func() bool) http.Handler {
+	return &handler{schema: NewSchema(s, bus), readOnly: readOnly}
+}
+
+type handler struct {
+	schema   *Schema
+	readOnly func() bool
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var p params
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		p.Query = q.Get("query")
+		p.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			dec := json.NewDecoder(strings.NewReader(v))
+			dec.UseNumber()
+			if err := dec.Decode(&p.Variables); err != nil {
+				writeErrors(w, http.StatusBadRequest, "variables must be a JSON object")
+				return
+			}
+		}
+	case http.MethodPost:
+		if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+			writeErrors(w, http.StatusUnsupportedMediaType, "requests must be sent as application/json")
+			return
+		}
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody))
+		dec.UseNumber()
+		if err := dec.Decode(&p); err != nil {
+			writeErrors(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeErrors(w, http.StatusMethodNotAllowed, "use GET or POST")
+		return
+	}
+	if p.Query == "" {
+		writeErrors(w, http.StatusBadRequest, "query is required")
+		return
+	}
+	p.Variables = numbers(p.Variables).(map[string]interface{})
+
+	doc, err := Parse(p.Query)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &Response{Errors: []*Error{asError(err)}})
+		return
+	}
+	op, err := doc.Operation(p.OperationName)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &Response{Errors: []*Error{asError(err)}})
+		return
+	}
+	if op.Type == "mutation" {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			writeErrors(w, http.StatusMethodNotAllowed, "mutations must be sent by POST")
+			return
+		}
+		if h.readOnly != nil && h.readOnly() {
+			w.Header().Set("Retry-After", "120")
+			writeErrors(w, http.StatusServiceUnavailable, "My Tasks is read-only for maintenance; try again in a few minutes.")
+			return
+		}
+	}
+
+	ctx := context.WithValue(r.Context(), requestKey{}, &request{origin: r.Header.Get("X-Client-ID")})
+	resp := h.schema.Execute(ctx, doc, p.OperationName, p.Variables)
+	code := http.StatusOK
+	if resp.Data == nil {
+		code = http.StatusBadRequest
+	}
+	writeJSON(w, code, resp)
+}
+
+// numbers turns the json.Numbers of decoded variables into int64 where
+// they are integers and float64 otherwise, as literals are parsed.
+func numbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = numbers(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = numbers(item)
+		}
+		return v
+	}
+	return v
+}
+
+func writeErrors(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, &Response{Errors: []*Error{{Message: message}}})
+}
+
+func writeJSON(w http.ResponseWriter, code int, resp *Response) {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		slog.Error("graphql response failed", "err", err)
+		code = http.StatusInternalServerError
+		body, _ = json.Marshal(&Response{Errors: []*Error{{Message: "internal error"}}})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(body)
+}
@@ -0,0 +1,263 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"mytasks/internal/events"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+type testServer struct {
+	handler  http.Handler
+	store    *store.SQLiteStore
+	readOnly bool
+	events   []events.Event
+}
+
+func setupTestServer(t *testing.T) *testServer {
+	t.Helper()
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	ts := &testServer{store: s}
+	bus := events.NewBus()
+	bus.Subscribe(func(_ context.Context, e events.Event) { ts.events = append(ts.events, e) })
+	ts.handler = NewHandler(s, bus, func() bool { return ts.readOnly })
+	return ts
+}
+
+// post sends query with variables and decodes the response.
+func (ts *testServer) post(t *testing.T, query string, vars map[string]interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": vars})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Client-ID", "tab-1")
+	return ts.serve(t, req)
+}
+
+func (ts *testServer) serve(t *testing.T, req *http.Request) (int, map[string]interface{}) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	ts.handler.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected JSON, got %q", ct)
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, resp
+}
+
+func firstError(resp map[string]interface{}) string {
+	errs, _ := resp["errors"].([]interface{})
+	if len(errs) == 0 {
+		return ""
+	}
+	return errs[0].(map[string]interface{})["message"].(string)
+}
+
+func TestHandler_Query(t *testing.T) {
+	ts := setupTestServer(t)
+	ctx := context.Background()
+
+	home := &models.Project{Name: "Home", Type: "project"}
+	ts.store.CreateProject(ctx, home)
+	done := &models.Project{Name: "Done", Type: "project"}
+	ts.store.CreateProject(ctx, done)
+	ts.store.MarkProjectComplete(ctx, done.ID)
+	paint := &models.Task{ProjectID: home.ID, Description: "Paint the fence", Priority: "high", Status: "todo"}
+	ts.store.CreateTask(ctx, paint)
+	ts.store.CreateTask(ctx, &models.Task{ProjectID: home.ID, Description: "Fix the tap", Priority: "low", Status: "done"})
+	ts.store.CreateTaskComment(ctx, &models.TaskComment{TaskID: paint.ID, Body: "Green, probably"})
+	ts.store.CreateSubtask(ctx, &models.Subtask{TaskID: paint.ID, Title: "Buy paint"})
+
+	query := url.Values{"query": {`{
+		projects { name tasks(status: "todo") { description comments { body } subtasks { title done } } }
+		high: tasks(filter: "priority = high") { id project { name } }
+	}`}}
+	code, resp := ts.serve(t, httptest.NewRequest(http.MethodGet, "/graphql?"+query.Encode(), nil))
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %v", code, resp)
+	}
+	got, _ := json.Marshal(resp["data"])
+	want := `{"high":[{"id":1,"project":{"name":"Home"}}],"projects":[{"name":"Home","tasks":[{"comments":[{"body":"Green, probably"}],"description":"Paint the fence","subtasks":[{"done":false,"title":"Buy paint"}]}]}]}`
+	if string(got) != want {
+		t.Errorf("expected\n  %s\ngot\n  %s", want, got)
+	}
+
+	code, resp = ts.post(t, `query($id: ID!) { project(id: $id) { completed } all: projects(include_completed: true) { name } missing: task(id: 99) { id } }`,
+		map[string]interface{}{"id": done.ID})
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %v", code, resp)
+	}
+	if got, _ := json.Marshal(resp["data"]); string(got) != `{"all":[{"name":"Home"},{"name":"Done"}],"missing":null,"project":{"completed":true}}` {
+		t.Errorf("unexpected data %s", got)
+	}
+
+	code, resp = ts.post(t, `{ tasks(filter: "prio = high") { id } }`, nil)
+	if code != http.StatusOK || firstError(resp) != `invalid filter: unknown field "prio" at position 1` {
+		t.Errorf("expected a filter error, got %d: %v", code, resp)
+	}
+
+	code, resp = ts.post(t, `{ tasks { nope } }`, nil)
+	if code != http.StatusBadRequest || resp["data"] != nil || firstError(resp) != `Cannot query field "nope" on type "Task".` {
+		t.Errorf("expected a validation error, got %d: %v", code, resp)
+	}
+}
+
+func TestHandler_Mutations(t *testing.T) {
+	ts := setupTestServer(t)
+
+	code, resp := ts.post(t, `mutation { createProject(name: "Garden", target_date: "2030-05-01") { id name } }`, nil)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %v", code, resp)
+	}
+	projectID := resp["data"].(map[string]interface{})["createProject"].(map[string]interface{})["id"]
+
+	code, resp = ts.post(t, `mutation($p: ID!) { createTask(project_id: $p, description: "Plant tulips", priority: "high", due_date: "2030-04-01") { id priority status due_date } }`,
+		map[string]interface{}{"p": projectID})
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %v", code, resp)
+	}
+	created := resp["data"].(map[string]interface{})["createTask"].(map[string]interface{})
+	if created["priority"] != "high" || created["status"] != "todo" || !strings.HasPrefix(created["due_date"].(string), "2030-04-01") {
+		t.Errorf("unexpected task %v", created)
+	}
+	id := created["id"]
+
+	code, resp = ts.post(t, `mutation($id: ID!) { updateTask(id: $id, notes: "By the gate", due_date: "") { description notes due_date } }`,
+		map[string]interface{}{"id": id})
+	updated := resp["data"].(map[string]interface{})["updateTask"].(map[string]interface{})
+	if code != http.StatusOK || updated["description"] != "Plant tulips" || updated["notes"] != "By the gate" || updated["due_date"] != nil {
+		t.Errorf("expected a partial update, got %d: %v", code, resp)
+	}
+
+	code, resp = ts.post(t, `mutation($id: ID!) { setTaskStatus(id: $id, status: "done") { completed } }`, map[string]interface{}{"id": id})
+	if code != http.StatusOK || resp["data"].(map[string]interface{})["setTaskStatus"].(map[string]interface{})["completed"] != true {
+		t.Errorf("expected the task to be completed, got %d: %v", code, resp)
+	}
+
+	code, resp = ts.post(t, `mutation($id: ID!) { setTaskStatus(id: $id, status: "later") { id } }`, map[string]interface{}{"id": id})
+	if code != http.StatusOK || firstError(resp) != "status must be 'todo', 'in_progress', or 'done'" {
+		t.Errorf("expected a validation error, got %d: %v", code, resp)
+	}
+
+	code, resp = ts.post(t, `mutation($id: ID!) { deleteTask(id: $id) }`, map[string]interface{}{"id": id})
+	if code != http.StatusOK || resp["data"].(map[string]interface{})["deleteTask"] != true {
+		t.Errorf("expected the task to be deleted, got %d: %v", code, resp)
+	}
+
+	code, resp = ts.post(t, `mutation { deleteTask(id: 99) }`, nil)
+	if code != http.StatusOK || !strings.Contains(firstError(resp), "not found") {
+		t.Errorf("expected not found, got %d: %v", code, resp)
+	}
+
+	var types []events.Type
+	for _, e := range ts.events {
+		if e.Origin != "tab-1" {
+			t.Errorf("expected events from tab-1, got %q", e.Origin)
+		}
+		types = append(types, e.Type)
+	}
+	want := []events.Type{events.ProjectCreated, events.TaskCreated, events.TaskUpdated, events.TaskCompleted, events.TaskDeleted}
+	if len(types) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, types)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Errorf("expected events %v, got %v", want, types)
+			break
+		}
+	}
+}
+
+func TestHandler_UpdateTaskMovesToEndOfProject(t *testing.T) {
+	ts := setupTestServer(t)
+	ctx := context.Background()
+
+	home := &models.Project{Name: "Home", Type: "project"}
+	ts.store.CreateProject(ctx, home)
+	garden := &models.Project{Name: "Garden", Type: "project"}
+	ts.store.CreateProject(ctx, garden)
+	var homeTasks []*models.Task
+	for _, d := range []string{"Paint the fence", "Fix the tap", "Clean the gutters"} {
+		task := &models.Task{ProjectID: home.ID, Description: d, Priority: "low", Status: "todo"}
+		ts.store.CreateTask(ctx, task)
+		homeTasks = append(homeTasks, task)
+	}
+	ts.store.CreateTask(ctx, &models.Task{ProjectID: garden.ID, Description: "Plant tulips", Priority: "low", Status: "todo"})
+
+	code, resp := ts.post(t, `mutation($id: ID!, $p: ID!) { updateTask(id: $id, project_id: $p, notes: "Moved") { notes project { name } } }`,
+		map[string]interface{}{"id": homeTasks[0].ID, "p": garden.ID})
+	if code != http.StatusOK || firstError(resp) != "" {
+		t.Fatalf("expected 200, got %d: %v", code, resp)
+	}
+	if got, _ := json.Marshal(resp["data"]); string(got) != `{"updateTask":{"notes":"Moved","project":{"name":"Garden"}}}` {
+		t.Errorf("unexpected data %s", got)
+	}
+
+	moved, _ := ts.store.GetTask(ctx, homeTasks[0].ID)
+	if moved.SortOrder != 2 {
+		t.Errorf("expected the task at the end of Garden, got sort order %d", moved.SortOrder)
+	}
+	for i, task := range homeTasks[1:] {
+		got, _ := ts.store.GetTask(ctx, task.ID)
+		if got.SortOrder != i+1 {
+			t.Errorf("%s: expected Home renumbered to %d, got %d", got.Description, i+1, got.SortOrder)
+		}
+	}
+	if len(ts.events) == 0 || ts.events[len(ts.events)-1].Type != events.TaskMoved {
+		t.Errorf("expected a move event, got %v", ts.events)
+	}
+}
+
+func TestHandler_Refusals(t *testing.T) {
+	ts := setupTestServer(t)
+
+	mutation := url.Values{"query": {`mutation { deleteTask(id: 1) }`}}
+	code, _ := ts.serve(t, httptest.NewRequest(http.MethodGet, "/graphql?"+mutation.Encode(), nil))
+	if code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for a mutation by GET, got %d", code)
+	}
+
+	ts.readOnly = true
+	code, resp := ts.post(t, `mutation { deleteTask(id: 1) }`, nil)
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 in maintenance, got %d: %v", code, resp)
+	}
+	if code, _ := ts.post(t, `{ projects { id } }`, nil); code != http.StatusOK {
+		t.Errorf("expected queries to work in maintenance, got %d", code)
+	}
+
+	code, resp = ts.post(t, `subscription { tasks { id } }`, nil)
+	if code != http.StatusBadRequest || firstError(resp) != "The schema does not support subscriptions." {
+		t.Errorf("expected subscriptions to be refused, got %d: %v", code, resp)
+	}
+
+	code, resp = ts.post(t, `{ projects { id }`, nil)
+	if code != http.StatusBadRequest || firstError(resp) != "Syntax error: unexpected end of document" {
+		t.Errorf("expected a syntax error, got %d: %v", code, resp)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader("query={projects{id}}"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if code, _ := ts.serve(t, req); code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415 for a form body, got %d", code)
+	}
+
+	if code, _ := ts.serve(t, httptest.NewRequest(http.MethodDelete, "/graphql", nil)); code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for DELETE, got %d", code)
+	}
+}
@@ -0,0 +1,768 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Document is a parsed GraphQL request document.
+type Document struct {
+	operations []*Operation
+	fragments  map[string]*fragment
+}
+
+// Operation is a query or mutation in a Document.
+type Operation struct {
+	// Type is "query", "mutation" or "subscription".
+	Type string
+	Name string
+	vars []*varDef
+	sel  []selection
+}
+
+type varDef struct {
+	name string
+	typ  string // e.g. "ID!"
+	def  value  // default, or nil
+	loc  Location
+}
+
+// selection is a *fieldNode, *fragmentSpread or *inlineFragment.
+type selection interface{}
+
+type fieldNode struct {
+	alias, name string
+	args        []argNode
+	directives  []directive
+	sel         []selection
+	loc         Location
+}
+
+// key is the field's name in the response.
+func (f *fieldNode) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type argNode struct {
+	name string
+	val  value
+	loc  Location
+}
+
+type directive struct {
+	name string
+	args []argNode
+	loc  Location
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []directive
+	loc        Location
+}
+
+type inlineFragment struct {
+	typeCond   string
+	directives []directive
+	sel        []selection
+}
+
+type fragment struct {
+	name, typeCond string
+	sel            []selection
+}
+
+// value is a literal in a document: nil, bool, int64, float64, string,
+// enumValue, varRef, []value or []objectField.
+type value interface{}
+
+type enumValue string
+
+type varRef string
+
+type objectField struct {
+	name string
+	val  value
+}
+
+// Parse reads a request document.
+func Parse(src string) (*Document, error) {
+	p := &parser{lex: lexer{src: src, line: 1, col: 1}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc := &Document{fragments: map[string]*fragment{}}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.tok.is(tokPunct, "{"):
+			sel, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &Operation{Type: "query", sel: sel})
+		case p.tok.is(tokName, "fragment"):
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := doc.fragments[f.name]; dup {
+				return nil, p.errorf("There can be only one fragment named %q.", f.name)
+			}
+			doc.fragments[f.name] = f
+		case p.tok.is(tokName, "query"), p.tok.is(tokName, "mutation"), p.tok.is(tokName, "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, &Error{Message: "The document has no operation."}
+	}
+	return doc, nil
+}
+
+// Operation picks the operation to run: the one called name, or the only
+// one when name is empty.
+func (d *Document) Operation(name string) (*Operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, &Error{Message: "The document has several operations; operationName must name one."}
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("Unknown operation named %q.", name)}
+}
+
+type parser struct {
+	lex lexer
+	tok token
+}
+
+func (p *parser) advance() error {
+	t, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = t
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &Error{Message: "Syntax error: " + fmt.Sprintf(format, args...), Locations: []Location{p.tok.loc}}
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokEOF {
+		return p.errorf("unexpected end of document")
+	}
+	return p.errorf("unexpected %q", p.tok.text)
+}
+
+// punct takes the punctuator s, or fails.
+func (p *parser) punct(s string) error {
+	if !p.tok.is(tokPunct, s) {
+		return p.errorf("expected %q, found %s", s, p.tok.describe())
+	}
+	return p.advance()
+}
+
+// skip takes the punctuator s if it is next.
+func (p *parser) skip(s string) (bool, error) {
+	if !p.tok.is(tokPunct, s) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokName {
+		return "", p.errorf("expected a name, found %s", p.tok.describe())
+	}
+	name := p.tok.text
+	return name, p.advance()
+}
+
+func (p *parser) operation() (*Operation, error) {
+	op := &Operation{Type: p.tok.text}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokName {
+		op.Name = p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if ok, err := p.skip("("); err != nil {
+		return nil, err
+	} else if ok {
+		for !p.tok.is(tokPunct, ")") {
+			v, err := p.varDef()
+			if err != nil {
+				return nil, err
+			}
+			op.vars = append(op.vars, v)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sel, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.sel = sel
+	return op, nil
+}
+
+func (p *parser) varDef() (*varDef, error) {
+	v := &varDef{loc: p.tok.loc}
+	if err := p.punct("$"); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	v.name = name
+	if err := p.punct(":"); err != nil {
+		return nil, err
+	}
+	if v.typ, err = p.typeRef(); err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip("="); err != nil {
+		return nil, err
+	} else if ok {
+		if v.def, err = p.value(true); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// typeRef reads a type such as ID!, [String] or [Int!]!, as written.
+func (p *parser) typeRef() (string, error) {
+	var typ string
+	if ok, err := p.skip("["); err != nil {
+		return "", err
+	} else if ok {
+		inner, err := p.typeRef()
+		if err != nil {
+			return "", err
+		}
+		if err := p.punct("]"); err != nil {
+			return "", err
+		}
+		typ = "[" + inner + "]"
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return "", err
+		}
+		typ = name
+	}
+	if ok, err := p.skip("!"); err != nil {
+		return "", err
+	} else if ok {
+		typ += "!"
+	}
+	return typ, nil
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, p.errorf("a fragment can't be named \"on\"")
+	}
+	if !p.tok.is(tokName, "on") {
+		return nil, p.errorf("expected \"on\", found %s", p.tok.describe())
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	typeCond, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sel, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragment{name: name, typeCond: typeCond, sel: sel}, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.punct("{"); err != nil {
+		return nil, err
+	}
+	var sel []selection
+	for !p.tok.is(tokPunct, "}") {
+		if p.tok.kind == tokEOF {
+			return nil, p.unexpected()
+		}
+		s, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sel = append(sel, s)
+	}
+	if len(sel) == 0 {
+		return nil, p.errorf("a selection set can't be empty")
+	}
+	return sel, p.advance()
+}
+
+func (p *parser) selection() (selection, error) {
+	loc := p.tok.loc
+	if ok, err := p.skip("..."); err != nil {
+		return nil, err
+	} else if ok {
+		if p.tok.kind == tokName && p.tok.text != "on" {
+			name := p.tok.text
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			dirs, err := p.directives()
+			if err != nil {
+				return nil, err
+			}
+			return &fragmentSpread{name: name, directives: dirs, loc: loc}, nil
+		}
+		inline := &inlineFragment{}
+		if p.tok.is(tokName, "on") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if inline.typeCond, err = p.name(); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		if inline.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		if inline.sel, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+		return inline, nil
+	}
+
+	f := &fieldNode{loc: loc}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f.name = name
+	if ok, err := p.skip(":"); err != nil {
+		return nil, err
+	} else if ok {
+		f.alias = name
+		if f.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if f.args, err = p.arguments(false); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.tok.is(tokPunct, "{") {
+		if f.sel, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) arguments(constant bool) ([]argNode, error) {
+	if ok, err := p.skip("("); err != nil || !ok {
+		return nil, err
+	}
+	var args []argNode
+	for !p.tok.is(tokPunct, ")") {
+		a := argNode{loc: p.tok.loc}
+		var err error
+		if a.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if err := p.punct(":"); err != nil {
+			return nil, err
+		}
+		if a.val, err = p.value(constant); err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+	}
+	if len(args) == 0 {
+		return nil, p.errorf("an argument list can't be empty")
+	}
+	return args, p.advance()
+}
+
+func (p *parser) directives() ([]directive, error) {
+	var dirs []directive
+	for p.tok.is(tokPunct, "@") {
+		d := directive{loc: p.tok.loc}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		var err error
+		if d.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if d.args, err = p.arguments(false); err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, d)
+	}
+	return dirs, nil
+}
+
+// value reads a literal. Variables are not allowed in constants, such as
+// variable defaults.
+func (p *parser) value(constant bool) (value, error) {
+	t := p.tok
+	switch t.kind {
+	case tokInt:
+		n, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return nil, p.errorf("%s is out of range", t.text)
+		}
+		return n, p.advance()
+	case tokFloat:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, p.errorf("%s is out of range", t.text)
+		}
+		return f, p.advance()
+	case tokString:
+		return t.text, p.advance()
+	case tokName:
+		var v value
+		switch t.text {
+		case "true":
+			v = true
+		case "false":
+			v = false
+		case "null":
+			v = nil
+		default:
+			v = enumValue(t.text)
+		}
+		return v, p.advance()
+	}
+
+	switch {
+	case t.is(tokPunct, "$") && !constant:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		return varRef(name), nil
+	case t.is(tokPunct, "["):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := []value{}
+		for !p.tok.is(tokPunct, "]") {
+			v, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, p.advance()
+	case t.is(tokPunct, "{"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		obj := []objectField{}
+		for !p.tok.is(tokPunct, "}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.punct(":"); err != nil {
+				return nil, err
+			}
+			v, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, objectField{name: name, val: v})
+		}
+		return obj, p.advance()
+	}
+	return nil, p.errorf("expected a value, found %s", t.describe())
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind tokKind
+	text string // a string's text is unescaped
+	loc  Location
+}
+
+func (t token) is(kind tokKind, text string) bool {
+	return t.kind == kind && t.text == text
+}
+
+func (t token) describe() string {
+	if t.kind == tokEOF {
+		return "end of document"
+	}
+	return strconv.Quote(t.text)
+}
+
+// lexer splits a document into tokens, skipping white space, commas and
+// comments.
+type lexer struct {
+	src       string
+	pos       int
+	line, col int
+}
+
+func (l *lexer) errorf(loc Location, format string, args ...interface{}) error {
+	return &Error{Message: "Syntax error: " + fmt.Sprintf(format, args...), Locations: []Location{loc}}
+}
+
+// step moves past n bytes of the current line.
+func (l *lexer) step(n int) {
+	l.pos += n
+	l.col += n
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			l.pos++
+			l.line++
+			l.col = 1
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			l.step(1)
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.src[l.pos:], "\ufeff"):
+			l.pos += len("\ufeff")
+		default:
+			return l.token()
+		}
+	}
+	return token{kind: tokEOF, loc: Location{Line: l.line, Column: l.col}}, nil
+}
+
+func (l *lexer) token() (token, error) {
+	loc := Location{Line: l.line, Column: l.col}
+	rest := l.src[l.pos:]
+	c := rest[0]
+	switch {
+	case strings.HasPrefix(rest, "..."):
+		l.step(3)
+		return token{kind: tokPunct, text: "...", loc: loc}, nil
+	case strings.IndexByte("!$()[]{}:=@|&", c) >= 0:
+		l.step(1)
+		return token{kind: tokPunct, text: string(c), loc: loc}, nil
+	case c == '_' || isLetter(c):
+		n := 1
+		for n < len(rest) && (rest[n] == '_' || isLetter(rest[n]) || isDigit(rest[n])) {
+			n++
+		}
+		l.step(n)
+		return token{kind: tokName, text: rest[:n], loc: loc}, nil
+	case c == '-' || isDigit(c):
+		return l.number(loc)
+	case c == '"':
+		return l.string(loc)
+	}
+	return token{}, l.errorf(loc, "unexpected character %q", c)
+}
+
+func (l *lexer) number(loc Location) (token, error) {
+	rest := l.src[l.pos:]
+	n := 0
+	if rest[n] == '-' {
+		n++
+	}
+	digits := func() int {
+		start := n
+		for n < len(rest) && isDigit(rest[n]) {
+			n++
+		}
+		return n - start
+	}
+	if digits() == 0 {
+		return token{}, l.errorf(loc, "expected a digit after %q", rest[:n])
+	}
+	kind := tokInt
+	if n < len(rest) && rest[n] == '.' {
+		n++
+		if digits() == 0 {
+			return token{}, l.errorf(loc, "expected a digit after %q", rest[:n])
+		}
+		kind = tokFloat
+	}
+	if n < len(rest) && (rest[n] == 'e' || rest[n] == 'E') {
+		n++
+		if n < len(rest) && (rest[n] == '+' || rest[n] == '-') {
+			n++
+		}
+		if digits() == 0 {
+			return token{}, l.errorf(loc, "expected a digit after %q", rest[:n])
+		}
+		kind = tokFloat
+	}
+	if n < len(rest) && (rest[n] == '_' || isLetter(rest[n]) || rest[n] == '.') {
+		return token{}, l.errorf(loc, "invalid number %q", rest[:n+1])
+	}
+	l.step(n)
+	return token{kind: kind, text: rest[:n], loc: loc}, nil
+}
+
+// string reads a quoted or block string.
+func (l *lexer) string(loc Location) (token, error) {
+	rest := l.src[l.pos:]
+	if strings.HasPrefix(rest, `"""`) {
+		end := strings.Index(rest[3:], `"""`)
+		if end < 0 {
+			return token{}, l.errorf(loc, "unterminated string")
+		}
+		raw := rest[3 : 3+end]
+		l.pos += 6 + end
+		l.line += strings.Count(raw, "\n")
+		if i := strings.LastIndexByte(raw, '\n'); i >= 0 {
+			l.col = len(raw) - i + 3
+		} else {
+			l.col += 6 + end
+		}
+		return token{kind: tokString, text: blockString(raw), loc: loc}, nil
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(rest); i++ {
+		switch c := rest[i]; c {
+		case '"':
+			l.step(i + 1)
+			return token{kind: tokString, text: b.String(), loc: loc}, nil
+		case '\n', '\r':
+			return token{}, l.errorf(loc, "unterminated string")
+		case '\\':
+			if i+1 >= len(rest) {
+				return token{}, l.errorf(loc, "unterminated string")
+			}
+			i++
+			switch e := rest[i]; e {
+			case '"', '\\', '/':
+				b.WriteByte(e)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if i+4 >= len(rest) {
+					return token{}, l.errorf(loc, "invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(rest[i+1:i+5], 16, 32)
+				if err != nil {
+					return token{}, l.errorf(loc, "invalid unicode escape %q", rest[i-1:i+5])
+				}
+				b.WriteRune(rune(r))
+				i += 4
+			default:
+				return token{}, l.errorf(loc, "invalid escape %q", rest[i-1:i+1])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return token{}, l.errorf(loc, "unterminated string")
+}
+
+// blockString removes the common indentation and blank first and last
+// lines of a block string, as the spec asks.
+func blockString(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = strings.TrimLeft(lines[i], " \t")
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
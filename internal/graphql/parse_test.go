@@ -0,0 +1,109 @@
+package graphql
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(`
+		# Tasks for the dashboard
+		query Dashboard($days: Int = 7, $filter: String!) {
+			due: tasks(filter: $filter, completed_within_days: $days) {
+				...taskFields
+				project @include(if: true) { name }
+			}
+		}
+		mutation Done { setTaskStatus(id: "1", status: done) { id } }
+		fragment taskFields on Task { id, description, tags }
+	`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	op, err := doc.Operation("Dashboard")
+	if err != nil {
+		t.Fatalf("Operation: %v", err)
+	}
+	if op.Type != "query" || len(op.vars) != 2 || op.vars[0].def != int64(7) || op.vars[1].typ != "String!" {
+		t.Errorf("unexpected operation %+v", op)
+	}
+	f := op.sel[0].(*fieldNode)
+	if f.key() != "due" || f.name != "tasks" || len(f.args) != 2 || f.args[0].val != varRef("filter") {
+		t.Errorf("unexpected field %+v", f)
+	}
+	if spread, ok := f.sel[0].(*fragmentSpread); !ok || spread.name != "taskFields" {
+		t.Errorf("expected a spread of taskFields, got %+v", f.sel[0])
+	}
+	if doc.fragments["taskFields"].typeCond != "Task" {
+		t.Errorf("unexpected fragments %+v", doc.fragments)
+	}
+
+	if op, err := doc.Operation("Done"); err != nil || op.Type != "mutation" {
+		t.Errorf("expected the Done mutation, got %+v, %v", op, err)
+	}
+	if _, err := doc.Operation(""); err == nil {
+		t.Error("expected an operation name to be required with several operations")
+	}
+}
+
+func TestParse_Values(t *testing.T) {
+	doc, err := Parse(`{ f(a: -12, b: 1.5e2, c: "tab\there é", d: [1 "x" null], e: {k: true}, g: """
+		block
+		  string
+	""") }`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	args := doc.operations[0].sel[0].(*fieldNode).args
+	got := map[string]interface{}{}
+	for _, a := range args {
+		got[a.name] = literal(a.val, nil)
+	}
+	if got["a"] != int64(-12) || got["b"] != 150.0 || got["c"] != "tab\there é" {
+		t.Errorf("unexpected scalars %v", got)
+	}
+	if list := got["d"].([]interface{}); len(list) != 3 || list[0] != int64(1) || list[1] != "x" || list[2] != nil {
+		t.Errorf("unexpected list %v", got["d"])
+	}
+	if obj := got["e"].(map[string]interface{}); obj["k"] != true {
+		t.Errorf("unexpected object %v", got["e"])
+	}
+	if got["g"] != "block\n  string" {
+		t.Errorf("unexpected block string %q", got["g"])
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		src string
+		msg string
+		loc Location
+	}{
+		{"", "The document has no operation.", Location{}},
+		{"{", "Syntax error: unexpected end of document", Location{1, 2}},
+		{"{}", "Syntax error: a selection set can't be empty", Location{1, 2}},
+		{"{ a(b: ) }", `Syntax error: expected a value, found ")"`, Location{1, 8}},
+		{"query Q($x: Int = $y) { a }", `Syntax error: expected a value, found "$"`, Location{1, 19}},
+		{"{ a }\n{ b(c: \"open\n) }", "Syntax error: unterminated string", Location{2, 8}},
+		{"{ a(b: 01x) }", `Syntax error: invalid number "01x"`, Location{1, 8}},
+		{"{ a ? }", `Syntax error: unexpected character '?'`, Location{1, 5}},
+		{"fragment on on T { a }", `Syntax error: a fragment can't be named "on"`, Location{1, 13}},
+		{"type Query { a }", `Syntax error: unexpected "type"`, Location{1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := Parse(tt.src)
+			var gerr *Error
+			if !errors.As(err, &gerr) {
+				t.Fatalf("expected an *Error, got %v", err)
+			}
+			if gerr.Message != tt.msg {
+				t.Errorf("expected %q, got %q", tt.msg, gerr.Message)
+			}
+			if tt.loc != (Location{}) && (len(gerr.Locations) != 1 || gerr.Locations[0] != tt.loc) {
+				t.Errorf("expected location %v, got %v", tt.loc, gerr.Locations)
+			}
+		})
+	}
+}
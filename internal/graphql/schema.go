@@ -0,0 +1,394 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+	"mytasks/internal/taskql"
+)
+
+// request is what resolvers share during one request.
+type request struct {
+	// origin is the X-Client-ID of the request, for the events it
+	// publishes.
+	origin string
+	// projects caches projects by ID, since many tasks share one.
+	projects map[int64]*models.Project
+}
+
+type requestKey struct{}
+
+func requestFrom(ctx context.Context) *request {
+	if req, ok := ctx.Value(requestKey{}).(*request); ok {
+		return req
+	}
+	return &request{}
+}
+
+// resolvers holds what the mytasks schema resolves fields from.
+type resolvers struct {
+	store store.Store
+	bus   *events.Bus
+}
+
+// NewSchema returns the schema of mytasks: projects and tasks with their
+// comments and subtasks, and mutations to create and change them. bus may be
+// nil.
+func NewSchema(s store.Store, bus *events.Bus) *Schema {
+	r := &resolvers{store: s, bus: bus}
+
+	project := &Object{Name: "Project", Fields: ScalarFields(models.Project{})}
+	task := &Object{Name: "Task", Fields: ScalarFields(models.Task{})}
+	comment := &Object{Name: "Comment", Fields: ScalarFields(models.TaskComment{})}
+	subtask := &Object{Name: "Subtask", Fields: ScalarFields(models.Subtask{})}
+
+	project.Fields["tasks"] = &Field{Type: task, Args: map[string]string{"status": "String"}, Resolve: r.projectTasks}
+	task.Fields["project"] = &Field{Type: project, Resolve: r.taskProject}
+	task.Fields["comments"] = &Field{Type: comment, Resolve: r.taskComments}
+	task.Fields["subtasks"] = &Field{Type: subtask, Resolve: r.taskSubtasks}
+
+	return &Schema{
+		Query: &Object{Name: "Query", Fields: map[string]*Field{
+			"projects": {Type: project, Args: map[string]string{"include_completed": "Boolean"}, Resolve: r.projects},
+			"project":  {Type: project, Args: map[string]string{"id": "ID!"}, Resolve: r.project},
+			"tasks":    {Type: task, Args: map[string]string{"filter": "String", "completed_within_days": "Int"}, Resolve: r.tasks},
+			"task":     {Type: task, Args: map[string]string{"id": "ID!"}, Resolve: r.task},
+		}},
+		Mutation: &Object{Name: "Mutation", Fields: map[string]*Field{
+			"createProject": {Type: project, Args: map[string]string{
+				"name": "String!", "description": "String", "target_date": "String",
+			}, Resolve: r.createProject},
+			"createTask": {Type: task, Args: map[string]string{
+				"project_id": "ID!", "description": "String!", "notes": "String",
+				"priority": "String", "status": "String", "due_date": "String",
+			}, Resolve: r.createTask},
+			"updateTask": {Type: task, Args: map[string]string{
+				"id": "ID!", "description": "String", "notes": "String",
+				"priority": "String", "due_date": "String", "project_id": "ID",
+			}, Resolve: r.updateTask},
+			"setTaskStatus": {Type: task, Args: map[string]string{"id": "ID!", "status": "String!"}, Resolve: r.setTaskStatus},
+			"deleteTask":    {Args: map[string]string{"id": "ID!"}, Resolve: r.deleteTask},
+		}},
+	}
+}
+
+// userError returns err as resolvers report it: validation and not-found
+// errors as they are, anything else logged and hidden.
+func userError(err error) error {
+	var ve *models.ValidationError
+	if errors.As(err, &ve) || errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	slog.Error("graphql resolver failed", "err", err)
+	return errors.New("internal error")
+}
+
+func (r *resolvers) publish(ctx context.Context, e events.Event) {
+	e.Origin = requestFrom(ctx).origin
+	r.bus.Publish(ctx, e)
+}
+
+func (r *resolvers) projects(ctx context.Context, _ interface{}, args Args) (interface{}, error) {
+	var projects []models.Project
+	var err error
+	if args.Bool("include_completed") {
+		projects, err = r.store.ListProjects(ctx)
+	} else {
+		projects, err = r.store.ListActiveProjects(ctx)
+	}
+	if err != nil {
+		return nil, userError(err)
+	}
+	if projects == nil {
+		projects = []models.Project{}
+	}
+	return projects, nil
+}
+
+// project returns the project, or nil when there is none.
+func (r *resolvers) project(ctx context.Context, _ interface{}, args Args) (interface{}, error) {
+	p, err := r.cachedProject(ctx, args.Int("id"))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, userError(err)
+	}
+	return p, nil
+}
+
+func (r *resolvers) cachedProject(ctx context.Context, id int64) (*models.Project, error) {
+	req := requestFrom(ctx)
+	if p, ok := req.projects[id]; ok {
+		return p, nil
+	}
+	p, err := r.store.GetProject(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.projects == nil {
+		req.projects = map[int64]*models.Project{}
+	}
+	req.projects[id] = p
+	return p, nil
+}
+
+func (r *resolvers) tasks(ctx context.Context, _ interface{}, args Args) (interface{}, error) {
+	var match *taskql.Query
+	if args.Has("filter") {
+		var err error
+		if match, err = taskql.Parse(args.String("filter"), time.Now()); err != nil {
+			return nil, fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+	var completedSince *time.Time
+	if args.Has("completed_within_days") {
+		days := args.Int("completed_within_days")
+		if days < 0 {
+			return nil, errors.New("invalid completed_within_days")
+		}
+		since := time.Now().AddDate(0, 0, -int(days))
+		completedSince = &since
+	}
+	tasks, err := r.store.ListTasks(ctx, completedSince, match)
+	if err != nil {
+		return nil, userError(err)
+	}
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+	return tasks, nil
+}
+
+// task returns the task, or nil when there is none.
+func (r *resolvers) task(ctx context.Context, _ interface{}, args Args) (interface{}, error) {
+	t, err := r.store.GetTask(ctx, args.Int("id"))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, userError(err)
+	}
+	return t, nil
+}
+
+func (r *resolvers) projectTasks(ctx context.Context, source interface{}, args Args) (interface{}, error) {
+	p := source.(*models.Project)
+	var tasks []models.Task
+	var err error
+	switch status := args.String("status"); status {
+	case "":
+		tasks, err = r.store.ListTasksByProject(ctx, p.ID, 0)
+	case "todo", "in_progress", "done":
+		tasks, err = r.store.ListTasksByProjectAndStatus(ctx, p.ID, status)
+	default:
+		return nil, fmt.Errorf("invalid status %q", status)
+	}
+	if err != nil {
+		return nil, userError(err)
+	}
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+	return tasks, nil
+}
+
+func (r *resolvers) taskProject(ctx context.Context, source interface{}, _ Args) (interface{}, error) {
+	p, err := r.cachedProject(ctx, source.(*models.Task).ProjectID)
+	if err != nil {
+		return nil, userError(err)
+	}
+	return p, nil
+}
+
+func (r *resolvers) taskComments(ctx context.Context, source interface{}, _ Args) (interface{}, error) {
+	comments, err := r.store.ListTaskComments(ctx, source.(*models.Task).ID)
+	if err != nil {
+		return nil, userError(err)
+	}
+	if comments == nil {
+		comments = []models.TaskComment{}
+	}
+	return comments, nil
+}
+
+func (r *resolvers) taskSubtasks(ctx context.Context, source interface{}, _ Args) (interface{}, error) {
+	subtasks, err := r.store.ListSubtasks(ctx, source.(*models.Task).ID)
+	if err != nil {
+		return nil, userError(err)
+	}
+	if subtasks == nil {
+		subtasks = []models.Subtask{}
+	}
+	return subtasks, nil
+}
+
+// parseDate reads an optional YYYY-MM-DD date.
+func parseDate(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: expected YYYY-MM-DD", s)
+	}
+	return &t, nil
+}
+
+func (r *resolvers) createProject(ctx context.Context, _ interface{}, args Args) (interface{}, error) {
+	targetDate, err := parseDate(args.String("target_date"))
+	if err != nil {
+		return nil, err
+	}
+	project := &models.Project{
+		Name:        args.String("name"),
+		Description: args.String("description"),
+		TargetDate:  targetDate,
+	}
+	if err := project.Validate(); err != nil {
+		return nil, err
+	}
+	if err := r.store.CreateProject(ctx, project); err != nil {
+		return nil, userError(err)
+	}
+
+	r.publish(ctx, events.Event{Type: events.ProjectCreated, ProjectID: project.ID, Project: project})
+	return project, nil
+}
+
+func (r *resolvers) createTask(ctx context.Context, _ interface{}, args Args) (interface{}, error) {
+	if _, err := r.store.GetProject(ctx, args.Int("project_id")); err != nil {
+		return nil, userError(err)
+	}
+	dueDate, err := parseDate(args.String("due_date"))
+	if err != nil {
+		return nil, err
+	}
+
+	task := &models.Task{
+		ProjectID:   args.Int("project_id"),
+		Description: args.String("description"),
+		Notes:       args.String("notes"),
+		Priority:    args.String("priority"),
+		Status:      args.String("status"),
+		DueDate:     dueDate,
+	}
+	if task.Priority == "" {
+		task.Priority = "medium"
+	}
+	if task.Status == "" {
+		task.Status = "todo"
+	}
+	if err := task.Validate(); err != nil {
+		return nil, err
+	}
+	if err := r.store.CreateTask(ctx, task); err != nil {
+		return nil, userError(err)
+	}
+
+	r.publish(ctx, events.Event{Type: events.TaskCreated, TaskID: task.ID, ProjectID: task.ProjectID, Task: task})
+	return task, nil
+}
+
+// updateTask changes the fields given, leaving the rest as they are. An
+// empty due_date clears it, and a new project_id moves the task to the end
+// of that project, as dropping it there in the sidebar does.
+func (r *resolvers) updateTask(ctx context.Context, _ interface{}, args Args) (interface{}, error) {
+	task, err := r.store.GetTask(ctx, args.Int("id"))
+	if err != nil {
+		return nil, userError(err)
+	}
+	previousProjectID := task.ProjectID
+
+	if args.Has("description") {
+		task.Description = args.String("description")
+	}
+	if args.Has("notes") {
+		task.Notes = args.String("notes")
+	}
+	if args.Has("priority") {
+		task.Priority = args.String("priority")
+	}
+	if args.Has("due_date") {
+		if task.DueDate, err = parseDate(args.String("due_date")); err != nil {
+			return nil, err
+		}
+	}
+	destID := task.ProjectID
+	if args.Has("project_id") && args.Int("project_id") != task.ProjectID {
+		destID = args.Int("project_id")
+		dest, err := r.store.GetProject(ctx, destID)
+		if err != nil || dest.Completed {
+			return nil, errors.New("invalid destination project")
+		}
+	}
+
+	if err := task.Validate(); err != nil {
+		return nil, err
+	}
+	if err := r.store.UpdateTask(ctx, task); err != nil {
+		return nil, userError(err)
+	}
+	if destID != previousProjectID {
+		// Moved, not just relabeled, so both projects' order stays dense.
+		if err := r.store.MoveTaskToProject(ctx, task.ID, destID); err != nil {
+			return nil, userError(err)
+		}
+		if task, err = r.store.GetTask(ctx, task.ID); err != nil {
+			return nil, userError(err)
+		}
+	}
+
+	if previousProjectID != task.ProjectID {
+		r.publish(ctx, events.Event{Type: events.TaskMoved, TaskID: task.ID, ProjectID: task.ProjectID, PreviousProjectID: previousProjectID, Task: task})
+	} else {
+		r.publish(ctx, events.Event{Type: events.TaskUpdated, TaskID: task.ID, ProjectID: task.ProjectID, Task: task})
+	}
+	return task, nil
+}
+
+func (r *resolvers) setTaskStatus(ctx context.Context, _ interface{}, args Args) (interface{}, error) {
+	task, err := r.store.GetTask(ctx, args.Int("id"))
+	if err != nil {
+		return nil, userError(err)
+	}
+	wasDone := task.IsDone()
+
+	task.Status = args.String("status")
+	if err := task.Validate(); err != nil {
+		return nil, err
+	}
+	if err := r.store.UpdateTask(ctx, task); err != nil {
+		return nil, userError(err)
+	}
+
+	eventType := events.TaskUpdated
+	switch {
+	case !wasDone && task.IsDone():
+		eventType = events.TaskCompleted
+	case wasDone && !task.IsDone():
+		eventType = events.TaskReopened
+	}
+	r.publish(ctx, events.Event{Type: eventType, TaskID: task.ID, ProjectID: task.ProjectID, Task: task})
+	return task, nil
+}
+
+// deleteTask removes a task for good and returns true.
+func (r *resolvers) deleteTask(ctx context.Context, _ interface{}, args Args) (interface{}, error) {
+	task, err := r.store.GetTask(ctx, args.Int("id"))
+	if err != nil {
+		return nil, userError(err)
+	}
+	if err := r.store.DeleteTask(ctx, task.ID); err != nil {
+		return nil, userError(err)
+	}
+
+	r.publish(ctx, events.Event{Type: events.TaskDeleted, TaskID: task.ID, ProjectID: task.ProjectID})
+	return true, nil
+}
@@ -0,0 +1,122 @@
+package gtasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultBaseURL is the Google Tasks API root.
+const DefaultBaseURL = "https://tasks.googleapis.com/tasks/v1"
+
+// TaskList is a Google Tasks list.
+type TaskList struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// RemoteTask is the subset of a Google Tasks task that sync uses.
+type RemoteTask struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Notes     string `json:"notes"`
+	Status    string `json:"status"` // "needsAction" or "completed"
+	Due       string `json:"due"`    // RFC 3339; only the date is meaningful
+	Completed string `json:"completed"`
+	Deleted   bool   `json:"deleted"`
+	Updated   string `json:"updated"`
+}
+
+// Client calls the Google Tasks REST API with a bearer token.
+type Client struct {
+	HTTPClient  *http.Client
+	BaseURL     string
+	AccessToken string
+}
+
+// ListTaskLists returns every task list.
+func (c *Client) ListTaskLists(ctx context.Context) ([]TaskList, error) {
+	var lists []TaskList
+	pageToken := ""
+	for {
+		q := url.Values{"maxResults": {"100"}}
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+		var page struct {
+			Items         []TaskList `json:"items"`
+			NextPageToken string     `json:"nextPageToken"`
+		}
+		if err := c.get(ctx, "/users/@me/lists?"+q.Encode(), &page); err != nil {
+			return nil, err
+		}
+		lists = append(lists, page.Items...)
+		if page.NextPageToken == "" {
+			return lists, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// ListTasks returns tasks in a list, including completed and deleted ones,
+// limited to those updated after updatedMin when it is non-zero.
+func (c *Client) ListTasks(ctx context.Context, listID string, updatedMin time.Time) ([]RemoteTask, error) {
+	var tasks []RemoteTask
+	pageToken := ""
+	for {
+		q := url.Values{
+			"maxResults":    {"100"},
+			"showCompleted": {"true"},
+			"showHidden":    {"true"},
+			"showDeleted":   {"true"},
+		}
+		if !updatedMin.IsZero() {
+			q.Set("updatedMin", updatedMin.UTC().Format(time.RFC3339))
+		}
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+		var page struct {
+			Items         []RemoteTask `json:"items"`
+			NextPageToken string       `json:"nextPageToken"`
+		}
+		if err := c.get(ctx, "/lists/"+url.PathEscape(listID)+"/tasks?"+q.Encode(), &page); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, page.Items...)
+		if page.NextPageToken == "" {
+			return tasks, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("gtasks: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gtasks: %s returned %s", path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("gtasks: invalid response: %w", err)
+	}
+	return nil
+}
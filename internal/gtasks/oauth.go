@@ -0,0 +1,137 @@
+// Package gtasks pulls tasks from Google Tasks into a designated project.
+package gtasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Scope grants read-only access to the user's Google Tasks.
+	Scope = "https://www.googleapis.com/auth/tasks.readonly"
+
+	defaultAuthURL  = "https://accounts.google.com/o/oauth2/v2/auth"
+	defaultTokenURL = "https://oauth2.googleapis.com/token"
+)
+
+// OAuthConfig holds the Google OAuth client credentials.
+type OAuthConfig struct {
+	ClientID     string
+	ClientSecret string
+	// RedirectURL overrides the callback URL derived from the request.
+	RedirectURL string
+	// Scope is the access requested on the consent page, Scope when empty.
+	Scope string
+
+	AuthURL  string
+	TokenURL string
+}
+
+// Enabled reports whether client credentials are configured.
+func (c OAuthConfig) Enabled() bool {
+	return c.ClientID != "" && c.ClientSecret != ""
+}
+
+// Token is an OAuth token response.
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time
+}
+
+// AuthCodeURL returns the consent page URL. Offline access with forced consent
+// makes Google return a refresh token on every connect.
+func (c OAuthConfig) AuthCodeURL(state, redirectURL string) string {
+	authURL := c.AuthURL
+	if authURL == "" {
+		authURL = defaultAuthURL
+	}
+	scope := c.Scope
+	if scope == "" {
+		scope = Scope
+	}
+	v := url.Values{
+		"client_id":     {c.ClientID},
+		"redirect_uri":  {redirectURL},
+		"response_type": {"code"},
+		"scope":         {scope},
+		"state":         {state},
+		"access_type":   {"offline"},
+		"prompt":        {"consent"},
+	}
+	return authURL + "?" + v.Encode()
+}
+
+// Exchange trades an authorization code for tokens.
+func (c OAuthConfig) Exchange(ctx context.Context, client *http.Client, code, redirectURL string) (*Token, error) {
+	return c.token(ctx, client, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {redirectURL},
+	})
+}
+
+// Refresh obtains a new access token from a refresh token.
+func (c OAuthConfig) Refresh(ctx context.Context, client *http.Client, refreshToken string) (*Token, error) {
+	tok, err := c.token(ctx, client, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tok.RefreshToken == "" {
+		tok.RefreshToken = refreshToken
+	}
+	return tok, nil
+}
+
+func (c OAuthConfig) token(ctx context.Context, client *http.Client, form url.Values) (*Token, error) {
+	tokenURL := c.TokenURL
+	if tokenURL == "" {
+		tokenURL = defaultTokenURL
+	}
+	form.Set("client_id", c.ClientID)
+	form.Set("client_secret", c.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("gtasks: token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("gtasks: token endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var body struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("gtasks: invalid token response: %w", err)
+	}
+	if body.AccessToken == "" {
+		return nil, fmt.Errorf("gtasks: token response missing access_token")
+	}
+
+	return &Token{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(body.ExpiresIn) * time.Second),
+	}, nil
+}
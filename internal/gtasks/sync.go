@@ -0,0 +1,183 @@
+package gtasks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+const (
+	// Source is the task_external_refs source for Google Tasks IDs.
+	Source = "google_tasks"
+	// Origin tags events caused by sync.
+	Origin = "google_tasks"
+)
+
+// Syncer pulls Google Tasks into the designated project. Sync is one-way:
+// new remote tasks are created locally and remote completion marks the
+// linked task done; local edits are never pushed back or overwritten.
+type Syncer struct {
+	store      store.Store
+	bus        *events.Bus
+	oauth      OAuthConfig
+	httpClient *http.Client
+	baseURL    string
+	now        func() time.Time
+}
+
+// NewSyncer creates a Syncer. bus may be nil.
+func NewSyncer(s store.Store, bus *events.Bus, oauth OAuthConfig) *Syncer {
+	return &Syncer{
+		store:      s,
+		bus:        bus,
+		oauth:      oauth,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    DefaultBaseURL,
+		now:        time.Now,
+	}
+}
+
+// Run performs one sync pass. It does nothing until an account is connected
+// and a target project is chosen.
+func (s *Syncer) Run(ctx context.Context) error {
+	account, err := s.store.GetGoogleTasksAccount(ctx)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if account.ProjectID == nil {
+		return nil
+	}
+
+	project, err := s.store.GetProject(ctx, *account.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ensureAccessToken(ctx, account); err != nil {
+		return err
+	}
+	client := &Client{HTTPClient: s.httpClient, BaseURL: s.baseURL, AccessToken: account.AccessToken}
+
+	started := s.now()
+	var updatedMin time.Time
+	if account.LastSyncedAt != nil {
+		// Overlap slightly so clock skew cannot drop edits.
+		updatedMin = account.LastSyncedAt.Add(-5 * time.Minute)
+	}
+
+	lists, err := client.ListTaskLists(ctx)
+	if err != nil {
+		return err
+	}
+	for _, list := range lists {
+		remote, err := client.ListTasks(ctx, list.ID, updatedMin)
+		if err != nil {
+			return err
+		}
+		for _, rt := range remote {
+			if err := s.apply(ctx, project.ID, rt); err != nil {
+				return err
+			}
+		}
+	}
+
+	account.LastSyncedAt = &started
+	return s.store.SaveGoogleTasksAccount(ctx, account)
+}
+
+func (s *Syncer) ensureAccessToken(ctx context.Context, account *models.GoogleTasksAccount) error {
+	if account.AccessToken != "" && account.TokenExpiry != nil && s.now().Before(account.TokenExpiry.Add(-time.Minute)) {
+		return nil
+	}
+
+	tok, err := s.oauth.Refresh(ctx, s.httpClient, account.RefreshToken)
+	if err != nil {
+		return err
+	}
+	account.AccessToken = tok.AccessToken
+	account.RefreshToken = tok.RefreshToken
+	account.TokenExpiry = &tok.Expiry
+	return s.store.SaveGoogleTasksAccount(ctx, account)
+}
+
+func (s *Syncer) apply(ctx context.Context, projectID int64, rt RemoteTask) error {
+	taskID, err := s.store.GetTaskIDByExternalRef(ctx, Source, rt.ID)
+	switch {
+	case err == nil:
+		return s.applyCompletion(ctx, taskID, rt)
+	case !errors.Is(err, store.ErrNotFound):
+		return err
+	}
+
+	title := strings.TrimSpace(rt.Title)
+	if rt.Deleted || rt.Status == "completed" || title == "" {
+		return nil
+	}
+
+	task := &models.Task{
+		ProjectID:   projectID,
+		Description: title,
+		Notes:       models.TruncateNotes(strings.TrimSpace(rt.Notes)),
+		Priority:    "medium",
+		Status:      "todo",
+		DueDate:     parseDue(rt.Due),
+	}
+	if err := task.Validate(); err != nil {
+		return nil
+	}
+	if err := s.store.CreateTask(ctx, task); err != nil {
+		return err
+	}
+	if err := s.store.SetTaskExternalRef(ctx, Source, rt.ID, task.ID); err != nil {
+		return err
+	}
+
+	s.bus.Publish(ctx, events.Event{Type: events.TaskCreated, TaskID: task.ID, ProjectID: task.ProjectID, Task: task, Origin: Origin})
+	return nil
+}
+
+func (s *Syncer) applyCompletion(ctx context.Context, taskID int64, rt RemoteTask) error {
+	if rt.Status != "completed" || rt.Deleted {
+		return nil
+	}
+
+	task, err := s.store.GetTask(ctx, taskID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if task.IsDone() {
+		return nil
+	}
+
+	task.Status = "done"
+	if err := s.store.UpdateTask(ctx, task); err != nil {
+		return err
+	}
+
+	s.bus.Publish(ctx, events.Event{Type: events.TaskCompleted, TaskID: task.ID, ProjectID: task.ProjectID, Task: task, Origin: Origin})
+	return nil
+}
+
+// parseDue reads the date part of a Google Tasks due timestamp.
+func parseDue(value string) *time.Time {
+	if len(value) < len("2006-01-02") {
+		return nil
+	}
+	t, err := time.Parse("2006-01-02", value[:len("2006-01-02")])
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,149 @@
+package gtasks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// fakeGoogle serves the token endpoint and a single task list.
+type fakeGoogle struct {
+	tasks      []RemoteTask
+	refreshes  int
+	updatedMin []string
+}
+
+func (f *fakeGoogle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/token":
+		r.ParseForm()
+		if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") != "refresh-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.refreshes++
+		w.Write([]byte(`{"access_token":"access-1","expires_in":3600}`))
+	case "/users/@me/lists":
+		if r.Header.Get("Authorization") != "Bearer access-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"items":[{"id":"L1","title":"My Tasks"}]}`))
+	case "/lists/L1/tasks":
+		f.updatedMin = append(f.updatedMin, r.URL.Query().Get("updatedMin"))
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": f.tasks})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func setupSyncer(t *testing.T, f *fakeGoogle) (*Syncer, store.Store, *models.Project) {
+	t.Helper()
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	project := &models.Project{Name: "Phone", Type: "project"}
+	s.CreateProject(ctx, project)
+	if err := s.SaveGoogleTasksAccount(ctx, &models.GoogleTasksAccount{RefreshToken: "refresh-1", ProjectID: &project.ID}); err != nil {
+		t.Fatalf("SaveGoogleTasksAccount failed: %v", err)
+	}
+
+	syncer := NewSyncer(s, nil, OAuthConfig{ClientID: "id", ClientSecret: "secret", TokenURL: srv.URL + "/token"})
+	syncer.httpClient = srv.Client()
+	syncer.baseURL = srv.URL
+	return syncer, s, project
+}
+
+func TestSyncer_CreatesTasksOnce(t *testing.T) {
+	f := &fakeGoogle{tasks: []RemoteTask{
+		{ID: "a", Title: "Buy milk", Notes: "2%", Status: "needsAction", Due: "2026-03-01T00:00:00.000Z"},
+		{ID: "b", Title: "Already done", Status: "completed"},
+		{ID: "c", Title: "Removed", Status: "needsAction", Deleted: true},
+	}}
+	syncer, s, project := setupSyncer(t, f)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := syncer.Run(ctx); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+	}
+
+	tasks, _ := s.ListTasksByProject(ctx, project.ID, 0)
+	if len(tasks) != 1 {
+		t.Fatalf("expected 1 task, got %+v", tasks)
+	}
+	got := tasks[0]
+	if got.Description != "Buy milk" || got.Notes != "2%" || got.DueDate == nil || got.DueDate.Format("2006-01-02") != "2026-03-01" {
+		t.Errorf("unexpected task %+v", got)
+	}
+
+	if f.refreshes != 1 {
+		t.Errorf("expected access token to be reused, got %d refreshes", f.refreshes)
+	}
+	if len(f.updatedMin) != 2 || f.updatedMin[0] != "" || f.updatedMin[1] == "" {
+		t.Errorf("expected incremental second pull, got updatedMin %q", f.updatedMin)
+	}
+	account, _ := s.GetGoogleTasksAccount(ctx)
+	if account.LastSyncedAt == nil {
+		t.Error("expected last sync time to be recorded")
+	}
+}
+
+func TestSyncer_RemoteCompletionMarksDone(t *testing.T) {
+	f := &fakeGoogle{tasks: []RemoteTask{{ID: "a", Title: "Call mum", Status: "needsAction"}}}
+	syncer, s, project := setupSyncer(t, f)
+	ctx := context.Background()
+
+	if err := syncer.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	f.tasks[0].Status = "completed"
+	if err := syncer.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	tasks, _ := s.ListTasksByProject(ctx, project.ID, 0)
+	if len(tasks) != 1 || tasks[0].Status != "done" {
+		t.Errorf("expected the linked task to be done, got %+v", tasks)
+	}
+}
+
+func TestSyncer_IdleWithoutProject(t *testing.T) {
+	f := &fakeGoogle{tasks: []RemoteTask{{ID: "a", Title: "Ignored", Status: "needsAction"}}}
+	syncer, s, _ := setupSyncer(t, f)
+	ctx := context.Background()
+
+	account, _ := s.GetGoogleTasksAccount(ctx)
+	account.ProjectID = nil
+	s.SaveGoogleTasksAccount(ctx, account)
+
+	if err := syncer.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if f.refreshes != 0 || len(f.updatedMin) != 0 {
+		t.Error("expected no requests before a project is chosen")
+	}
+}
+
+func TestParseDue(t *testing.T) {
+	if got := parseDue("2026-05-04T00:00:00.000Z"); got == nil || !got.Equal(time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected due date %v", got)
+	}
+	if parseDue("") != nil {
+		t.Error("expected nil for empty due date")
+	}
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"mytasks/internal/housekeeping"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+	"mytasks/internal/webhook"
+)
+
+// AdminConfig locates the files the admin panel reports on.
+type AdminConfig struct {
+	DBPath     string
+	BackupDir  string
+	BackupKeep int
+	// Workspaces are the further workspaces served next to this one, listed
+	// for the operator; Tenants reports they are hosted as tenants.
+	Workspaces []AdminWorkspace
+	Tenants    bool
+}
+
+// AdminWorkspace is a further workspace, or tenant, to list in the admin
+// panel.
+type AdminWorkspace struct {
+	Name string
+	// URL is the workspace's own admin panel.
+	URL    string
+	DBPath string
+	Store  store.Store
+}
+
+// AdminWorkspaceStatus is a workspace as listed in the admin panel.
+type AdminWorkspaceStatus struct {
+	Name   string
+	URL    string
+	DBPath string
+	DBSize ByteSize
+	// Pending counts migrations not applied yet.
+	Pending int
+	// LastChange is when a task or project last changed, nil if never.
+	LastChange *time.Time
+}
+
+// SetAdmin enables the admin panel.
+func (h *Handlers) SetAdmin(cfg AdminConfig) {
+	h.admin = cfg
+}
+
+// ByteSize is a size in bytes, printed as "1.5 MB".
+type ByteSize int64
+
+func (b ByteSize) String() string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := int64(b) / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// AdminBackup is a backup listed in the admin panel.
+type AdminBackup struct {
+	Name      string
+	Size      ByteSize
+	CreatedAt time.Time
+}
+
+// AdminData holds data for the admin panel.
+type AdminData struct {
+	PageData
+	// Notice reports the result of the action that redirected here.
+	Notice     string
+	DBPath     string
+	DBSize     ByteSize
+	WALSize    ByteSize
+	Migrations []models.Migration
+	// Pending counts migrations not applied yet; Unknown those applied by a
+	// newer build.
+	Pending, Unknown int
+	BackupDir        string
+	Backups          []AdminBackup
+	Jobs             []models.JobStatus
+	Webhooks         []models.WebhookDelivery
+	Workspaces       []AdminWorkspaceStatus
+	Tenants          bool
+	Maintenance      bool
+}
+
+// adminWebhookCount is how many recent webhook deliveries the admin panel
+// lists.
+const adminWebhookCount = 50
+
+// Admin shows the database, migrations, backups, background jobs and webhook
+// deliveries, with buttons for the maintenance actions.
+func (h *Handlers) Admin(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	migrations, err := h.store.ListMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	jobs, err := h.store.ListJobStatuses(ctx)
+	if err != nil {
+		return err
+	}
+	webhooks, err := h.store.ListWebhookDeliveries(ctx, adminWebhookCount)
+	if err != nil {
+		return err
+	}
+	activeProjects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	data := AdminData{
+		PageData: PageData{
+			Title:          "Admin",
+			ActiveProjects: activeProjects,
+			CurrentView:    "admin",
+			Prefs:          h.prefs(ctx),
+			Lang:           h.localizer(r),
+		},
+		Notice:      h.adminNotice(r),
+		DBPath:      h.admin.DBPath,
+		DBSize:      fileSize(h.admin.DBPath),
+		WALSize:     fileSize(h.admin.DBPath + "-wal"),
+		Migrations:  migrations,
+		BackupDir:   h.admin.BackupDir,
+		Jobs:        jobs,
+		Webhooks:    webhooks,
+		Tenants:     h.admin.Tenants,
+		Maintenance: h.inMaintenance(),
+	}
+	for _, m := range migrations {
+		if m.AppliedAt == nil {
+			data.Pending++
+		}
+		if m.Unknown {
+			data.Unknown++
+		}
+	}
+	if h.admin.BackupDir != "" {
+		backups, err := housekeeping.ListBackups(h.admin.BackupDir)
+		if err != nil {
+			return err
+		}
+		for _, b := range backups {
+			data.Backups = append(data.Backups, AdminBackup{Name: b.Name, Size: ByteSize(b.Size), CreatedAt: b.CreatedAt})
+		}
+	}
+	for _, ws := range h.admin.Workspaces {
+		status, err := adminWorkspaceStatus(ctx, ws)
+		if err != nil {
+			return fmt.Errorf("workspace %s: %w", ws.Name, err)
+		}
+		data.Workspaces = append(data.Workspaces, status)
+	}
+	return h.renderTemplate(w, "admin.html", data)
+}
+
+func adminWorkspaceStatus(ctx context.Context, ws AdminWorkspace) (AdminWorkspaceStatus, error) {
+	status := AdminWorkspaceStatus{Name: ws.Name, URL: ws.URL, DBPath: ws.DBPath, DBSize: fileSize(ws.DBPath)}
+	migrations, err := ws.Store.ListMigrations(ctx)
+	if err != nil {
+		return status, err
+	}
+	for _, m := range migrations {
+		if m.AppliedAt == nil {
+			status.Pending++
+		}
+	}
+	recent, err := ws.Store.ListRecentlyChanged(ctx, 1)
+	if err != nil {
+		return status, err
+	}
+	if len(recent) > 0 {
+		status.LastChange = &recent[0].UpdatedAt
+	}
+	return status, nil
+}
+
+// adminNotice describes the action named by the done query parameter.
+func (h *Handlers) adminNotice(r *http.Request) string {
+	lang := h.localizer(r)
+	q := r.URL.Query()
+	switch q.Get("done") {
+	case "backup":
+		return lang.T("Backup written.")
+	case "maintenance-on":
+		return lang.T("Maintenance mode is on: the app is read-only until you turn it off.")
+	case "maintenance-off":
+		return lang.T("Maintenance mode is off.")
+	case "checkpoint":
+		return lang.T("The write-ahead log was copied into the database.")
+	case "redelivered":
+		return lang.T("The webhook was redelivered.")
+	case "redeliver-failed":
+		return lang.T("The redelivery failed; its attempt is listed under Webhook Deliveries.")
+	case "cleanup":
+		removed, _ := strconv.Atoi(q.Get("removed"))
+		freed, _ := strconv.ParseInt(q.Get("freed"), 10, 64)
+		return lang.T("Cleanup removed %d expired token lockouts and freed %s.", removed, ByteSize(freed).String())
+	}
+	return ""
+}
+
+// AdminBackup writes a backup now, as the nightly job would.
+func (h *Handlers) AdminBackup(w http.ResponseWriter, r *http.Request) error {
+	if h.admin.BackupDir == "" {
+		return badRequest("set BACKUP_DIR to enable backups")
+	}
+	if err := housekeeping.NewBackup(h.store, h.admin.BackupDir, h.admin.BackupKeep).Run(r.Context()); err != nil {
+		return err
+	}
+	h.adminDone(w, r, url.Values{"done": {"backup"}})
+	return nil
+}
+
+// AdminCheckpoint copies the write-ahead log into the database file.
+func (h *Handlers) AdminCheckpoint(w http.ResponseWriter, r *http.Request) error {
+	if err := h.store.Checkpoint(r.Context()); err != nil {
+		return err
+	}
+	h.adminDone(w, r, url.Values{"done": {"checkpoint"}})
+	return nil
+}
+
+// AdminCleanup deletes expired records and compacts the database.
+func (h *Handlers) AdminCleanup(w http.ResponseWriter, r *http.Request) error {
+	result, err := h.store.Cleanup(r.Context(), time.Now())
+	if err != nil {
+		return err
+	}
+	h.adminDone(w, r, url.Values{
+		"done":    {"cleanup"},
+		"removed": {strconv.FormatInt(result.ExpiredAuthFailures, 10)},
+		"freed":   {strconv.FormatInt(max(result.SizeBefore-result.SizeAfter, 0), 10)},
+	})
+	return nil
+}
+
+// AdminRedeliverWebhook sends a logged webhook delivery again.
+func (h *Handlers) AdminRedeliverWebhook(w http.ResponseWriter, r *http.Request) error {
+	if h.webhooks == nil {
+		return notFound("webhooks are not enabled")
+	}
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid delivery ID")
+	}
+	done := "redelivered"
+	if _, err := h.webhooks.Redeliver(r.Context(), id); webhook.IsDeliveryError(err) {
+		done = "redeliver-failed"
+	} else if err != nil {
+		return err
+	}
+	h.adminDone(w, r, url.Values{"done": {done}})
+	return nil
+}
+
+func (h *Handlers) adminDone(w http.ResponseWriter, r *http.Request, q url.Values) {
+	http.Redirect(w, r, h.url("/admin?"+q.Encode()), http.StatusSeeOther)
+}
+
+// fileSize returns the size of path, or 0 if it does not exist.
+func fileSize(path string) ByteSize {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return ByteSize(info.Size())
+}
+
+// AdminDiagnostic downloads an anonymized copy of the database, with every
+// text scrambled and credentials removed, to attach to a bug report.
+func (h *Handlers) AdminDiagnostic(w http.ResponseWriter, r *http.Request) error {
+	diagnostic, err := housekeeping.OpenDiagnostic(r.Context(), h.store)
+	if err != nil {
+		return err
+	}
+	defer diagnostic.Close()
+
+	now := time.Now()
+	name := "mytasks-diagnostic-" + now.Format("20060102") + ".db"
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	http.ServeContent(w, r, name, now, diagnostic)
+	return nil
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"mytasks/internal/models"
+)
+
+var isoWeekPattern = regexp.MustCompile(`^(\d{4})-W(\d{2})$`)
+
+// AgendaData holds data for the printable weekly agenda.
+type AgendaData struct {
+	PageData
+	Week     string // ISO week, e.g. "2025-W07"
+	Days     []AgendaDay
+	PrevWeek string
+	NextWeek string
+}
+
+// AgendaDay is one day of the agenda with the tasks due on it.
+type AgendaDay struct {
+	Date  time.Time
+	Today bool
+	Tasks []models.Task
+}
+
+// Agenda renders tasks due in an ISO week, grouped by day and laid out for
+// printing. The week comes from ?week=YYYY-Www and defaults to the current one.
+// With a Sunday week start the agenda begins the day before the ISO week.
+func (h *Handlers) Agenda(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	prefs := h.prefs(ctx)
+
+	today := dateOnly(time.Now())
+	start := prefs.StartOfWeek(today)
+	if v := r.URL.Query().Get("week"); v != "" {
+		monday, err := parseISOWeek(v)
+		if err != nil {
+			return badRequest(err.Error())
+		}
+		start = prefs.StartOfWeek(monday)
+	}
+	end := start.AddDate(0, 0, 7)
+	// The Monday in the agenda names its ISO week.
+	monday := startOfISOWeek(end.AddDate(0, 0, -1))
+
+	tasks, err := h.store.ListTasksDueBetween(ctx, start, end)
+	if err != nil {
+		return err
+	}
+
+	activeProjects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	days := make([]AgendaDay, 7)
+	for i := range days {
+		days[i].Date = start.AddDate(0, 0, i)
+		days[i].Today = days[i].Date.Equal(today)
+	}
+	for _, task := range tasks {
+		i := int(dateOnly(*task.DueDate).Sub(start).Hours() / 24)
+		if i >= 0 && i < len(days) {
+			days[i].Tasks = append(days[i].Tasks, task)
+		}
+	}
+
+	data := AgendaData{
+		PageData: PageData{
+			Title:          "Agenda",
+			ActiveProjects: activeProjects,
+			CurrentView:    "agenda",
+			Prefs:          prefs,
+			Lang:           h.localizer(r),
+		},
+		Week:     formatISOWeek(monday),
+		Days:     days,
+		PrevWeek: formatISOWeek(monday.AddDate(0, 0, -7)),
+		NextWeek: formatISOWeek(monday.AddDate(0, 0, 7)),
+	}
+
+	return h.renderTemplate(w, "agenda.html", data)
+}
+
+// parseISOWeek returns the Monday (UTC midnight) starting an ISO week
+// written as YYYY-Www.
+func parseISOWeek(s string) (time.Time, error) {
+	errInvalid := errors.New("invalid week: expected YYYY-Www")
+
+	m := isoWeekPattern.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, errInvalid
+	}
+	year, _ := strconv.Atoi(m[1])
+	week, _ := strconv.Atoi(m[2])
+
+	// January 4th always falls in week 1.
+	start := startOfISOWeek(time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)).AddDate(0, 0, 7*(week-1))
+	if y, wk := start.ISOWeek(); y != year || wk != week {
+		return time.Time{}, errInvalid
+	}
+	return start, nil
+}
+
+// startOfISOWeek returns the Monday on or before t.
+func startOfISOWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+func formatISOWeek(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%04d-W%02d", year, week)
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+	"mytasks/internal/taskql"
+)
+
+// AllTasksData holds data for the flat list of open tasks.
+type AllTasksData struct {
+	PageData
+	Tasks  []models.Task
+	Filter store.TaskFilter
+	// FilterExpr is the filter expression as typed, and FilterError what is
+	// wrong with it, if anything; the list is then shown without it.
+	FilterExpr  string
+	FilterError string
+	// SavedFilters are the expressions saved under a name, to pick from.
+	SavedFilters []models.SavedFilter
+}
+
+// AllTasks renders every open task across active projects as one list.
+// Filters and sort order come from the query string so views can be
+// bookmarked.
+func (h *Handlers) AllTasks(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	filter := parseTaskFilter(r)
+	expr := strings.TrimSpace(r.URL.Query().Get("filter"))
+	var filterError string
+	if match, err := parseFilterExpr(expr); err != nil {
+		filterError = err.Error()
+	} else {
+		filter.Match = match
+	}
+	tasks, err := h.store.ListOpenTasks(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	prefs := h.prefs(ctx)
+	flagStale(prefs.StaleDays, tasks)
+
+	activeProjects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+	saved, err := h.store.ListSavedFilters(ctx)
+	if err != nil {
+		return err
+	}
+
+	data := AllTasksData{
+		PageData: PageData{
+			Title:          "All Tasks",
+			ActiveProjects: activeProjects,
+			CurrentView:    "all_tasks",
+			Prefs:          prefs,
+			Lang:           h.localizer(r),
+		},
+		Tasks:        tasks,
+		Filter:       filter,
+		FilterExpr:   expr,
+		FilterError:  filterError,
+		SavedFilters: saved,
+	}
+
+	return h.renderTemplate(w, "all_tasks.html", data)
+}
+
+// SaveFilter saves the filter expression shown under a name, replacing the
+// one saved under that name before, and shows the list through it.
+func (h *Handlers) SaveFilter(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	f := &models.SavedFilter{Name: r.FormValue("name"), Query: r.FormValue("filter")}
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	if _, err := parseFilterExpr(f.Query); err != nil {
+		return err
+	}
+	if err := h.store.SaveFilter(r.Context(), f); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, h.url("/tasks?"+url.Values{"filter": {f.Query}}.Encode()), http.StatusSeeOther)
+	return nil
+}
+
+// DeleteSavedFilter forgets a saved filter.
+func (h *Handlers) DeleteSavedFilter(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid saved filter id")
+	}
+	if err := h.store.DeleteSavedFilter(r.Context(), id); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, h.url("/tasks"), http.StatusSeeOther)
+	return nil
+}
+
+// parseTaskFilter reads list filters from the query string, dropping values
+// it does not recognize.
+func parseTaskFilter(r *http.Request) store.TaskFilter {
+	q := r.URL.Query()
+	filter := store.TaskFilter{
+		Search: strings.TrimSpace(q.Get("q")),
+		Sort:   store.SortDueDate,
+	}
+
+	if id, err := strconv.ParseInt(q.Get("project_id"), 10, 64); err == nil && id > 0 {
+		filter.ProjectID = id
+	}
+	switch v := q.Get("priority"); v {
+	case "high", "medium", "low":
+		filter.Priority = v
+	}
+	switch v := q.Get("status"); v {
+	case "todo", "in_progress":
+		filter.Status = v
+	}
+	switch v := q.Get("due"); v {
+	case store.DueOverdue, store.DueToday, store.DueThisWeek, store.DueNone:
+		filter.Due = v
+	}
+	switch v := q.Get("sort"); v {
+	case store.SortPriority, store.SortProject:
+		filter.Sort = v
+	}
+
+	return filter
+}
+
+// parseFilterExpr parses a filter expression, answering 400 when it is not
+// valid. An empty one filters nothing, and gives nil.
+func parseFilterExpr(raw string) (*taskql.Query, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	match, err := taskql.Parse(raw, time.Now())
+	if err != nil {
+		return nil, badRequest("invalid filter: " + err.Error())
+	}
+	return match, nil
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"strconv"
 	"time"
 
 	"mytasks/internal/models"
@@ -14,45 +16,55 @@ type ArchivedProjectEntry struct {
 	TodoTasks          []models.Task
 	InProgressTasks    []models.Task
 	DoneTasks          []models.Task
+
+	// DoneCount and DonePage are used by the paginated Completed Tasks view.
+	DoneCount int
+	DonePage  *CompletedTasksPage
 }
 
 // ArchiveData holds data for archive templates.
 type ArchiveData struct {
 	PageData
 	ArchivedProjects []ArchivedProjectEntry
+	From             string
+	To               string
 }
 
 // Archive redirects to the completed tasks view.
-func (h *Handlers) Archive(w http.ResponseWriter, r *http.Request) {
-	http.Redirect(w, r, "/archive/tasks", http.StatusFound)
+func (h *Handlers) Archive(w http.ResponseWriter, r *http.Request) error {
+	http.Redirect(w, r, h.url("/archive/tasks"), http.StatusFound)
+	return nil
+}
+
+// ArchiveProjects redirects the completed projects page's old address.
+func (h *Handlers) ArchiveProjects(w http.ResponseWriter, r *http.Request) error {
+	http.Redirect(w, r, h.url("/projects/completed"), http.StatusMovedPermanently)
+	return nil
 }
 
-// CompletedProjects renders completed projects and all of their tasks.
-func (h *Handlers) CompletedProjects(w http.ResponseWriter, r *http.Request) {
+// CompletedProjects renders completed projects, most recently completed
+// first, with how many of their tasks got done and all of the tasks.
+func (h *Handlers) CompletedProjects(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
-	completedProjects, err := h.store.ListCompletedProjects(ctx)
+	completedProjects, err := h.store.ListProjectsFiltered(ctx, true, 0)
 	if err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 
 	entries := make([]ArchivedProjectEntry, 0, len(completedProjects))
 	for _, p := range completedProjects {
 		todo, err := h.store.ListTasksByProjectAndStatus(ctx, p.ID, "todo")
 		if err != nil {
-			respondServerError(w, err)
-			return
+			return err
 		}
 		inProgress, err := h.store.ListTasksByProjectAndStatus(ctx, p.ID, "in_progress")
 		if err != nil {
-			respondServerError(w, err)
-			return
+			return err
 		}
 		done, err := h.store.ListTasksByProjectAndStatus(ctx, p.ID, "done")
 		if err != nil {
-			respondServerError(w, err)
-			return
+			return err
 		}
 		entries = append(entries, ArchivedProjectEntry{
 			Project:            p,
@@ -65,8 +77,7 @@ func (h *Handlers) CompletedProjects(w http.ResponseWriter, r *http.Request) {
 
 	activeProjects, err := h.loadActiveProjects(ctx)
 	if err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 
 	data := ArchiveData{
@@ -74,51 +85,140 @@ func (h *Handlers) CompletedProjects(w http.ResponseWriter, r *http.Request) {
 			Title:          "Completed Projects",
 			ActiveProjects: activeProjects,
 			CurrentView:    "completed_projects",
+			Prefs:          h.prefs(r.Context()),
+			Lang:           h.localizer(r),
 		},
 		ArchivedProjects: entries,
 	}
 
-	h.renderTemplate(w, "archive_projects.html", data)
+	return h.renderTemplate(w, "archive_projects.html", data)
+}
+
+// completedPageSize is how many done tasks the Completed Tasks view loads per
+// project at a time.
+const completedPageSize = 50
+
+// CompletedTasksPage is one page of a project's done tasks plus what is
+// needed to request the next one.
+type CompletedTasksPage struct {
+	ProjectID  int64
+	Tasks      []models.Task
+	From       string
+	To         string
+	NextOffset int
+	HasMore    bool
+	Prefs      models.Settings
 }
 
-// CompletedTasks renders old completed tasks for active projects, grouped by project.
-func (h *Handlers) CompletedTasks(w http.ResponseWriter, r *http.Request) {
+// CompletedTasks renders done tasks for active projects, grouped by project.
+// By default it shows tasks that have dropped off the board; ?from= and ?to=
+// (YYYY-MM-DD, inclusive) choose another range. Each project loads one page
+// and fetches more on demand.
+func (h *Handlers) CompletedTasks(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
-	before := time.Now().AddDate(0, 0, -donePruneWindowDays)
+	from, to := completedRange(r)
 
-	activeWithOld, err := h.store.ListActiveProjectsWithOldDoneTasks(ctx, before)
+	projects, err := h.loadActiveProjects(ctx)
 	if err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 
-	entries := make([]ArchivedProjectEntry, 0, len(activeWithOld))
-	for _, p := range activeWithOld {
-		oldDone, err := h.store.ListOldDoneTasks(ctx, p.ID, before)
+	entries := make([]ArchivedProjectEntry, 0, len(projects))
+	for _, p := range projects {
+		count, err := h.store.CountTasksByProjectCompletedBetween(ctx, p.ID, from, to)
 		if err != nil {
-			respondServerError(w, err)
-			return
+			return err
+		}
+		if count == 0 {
+			continue
+		}
+
+		page, err := h.completedTasksPage(ctx, p.ID, from, to, 0)
+		if err != nil {
+			return err
 		}
 		entries = append(entries, ArchivedProjectEntry{
 			Project:   p,
-			DoneTasks: oldDone,
+			DoneCount: count,
+			DonePage:  page,
 		})
 	}
 
-	activeProjects, err := h.loadActiveProjects(ctx)
-	if err != nil {
-		respondServerError(w, err)
-		return
-	}
-
 	data := ArchiveData{
 		PageData: PageData{
 			Title:          "Completed Tasks",
-			ActiveProjects: activeProjects,
+			ActiveProjects: projects,
 			CurrentView:    "completed_tasks",
+			Prefs:          h.prefs(r.Context()),
+			Lang:           h.localizer(r),
 		},
 		ArchivedProjects: entries,
+		From:             formatDateParam(from),
+		To:               formatDateParam(to),
+	}
+
+	return h.renderTemplate(w, "archive_tasks.html", data)
+}
+
+// CompletedTasksMore returns the next page of a project's done tasks for the
+// Completed Tasks view's "Load more" button.
+func (h *Handlers) CompletedTasksMore(w http.ResponseWriter, r *http.Request) error {
+	projectID, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid project id")
 	}
 
-	h.renderTemplate(w, "archive_tasks.html", data)
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		return badRequest("invalid offset")
+	}
+
+	from, to := completedRange(r)
+	page, err := h.completedTasksPage(r.Context(), projectID, from, to, offset)
+	if err != nil {
+		return err
+	}
+
+	return h.renderPartial(w, "completed_tasks_page.html", page)
+}
+
+func (h *Handlers) completedTasksPage(ctx context.Context, projectID int64, from, to *time.Time, offset int) (*CompletedTasksPage, error) {
+	// Fetch one extra row to learn whether another page exists.
+	tasks, err := h.store.ListTasksByProjectCompletedBetween(ctx, projectID, from, to, completedPageSize+1, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &CompletedTasksPage{
+		ProjectID:  projectID,
+		Tasks:      tasks,
+		From:       formatDateParam(from),
+		To:         formatDateParam(to),
+		NextOffset: offset + completedPageSize,
+		Prefs:      h.prefs(ctx),
+	}
+	if len(tasks) > completedPageSize {
+		page.Tasks = tasks[:completedPageSize]
+		page.HasMore = true
+	}
+	return page, nil
+}
+
+// completedRange reads the Completed Tasks date range. Without ?to= the range
+// ends the day before tasks stop showing in the board's Done column.
+func completedRange(r *http.Request) (from, to *time.Time) {
+	from = parseDate(r.URL.Query().Get("from"))
+	to = parseDate(r.URL.Query().Get("to"))
+	if to == nil {
+		cutoff := time.Now().AddDate(0, 0, -donePruneWindowDays-1)
+		to = &cutoff
+	}
+	return from, to
+}
+
+func formatDateParam(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
 }
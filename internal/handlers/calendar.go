@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// calendarStateCookie carries the OAuth state between connecting Google
+// Calendar and the callback.
+const calendarStateCookie = "google_calendar_oauth_state"
+
+// maxCalendarConflicts caps the conflicts listed on the settings page.
+const maxCalendarConflicts = 10
+
+// CalendarSettingsData holds data for the Google Calendar settings page.
+type CalendarSettingsData struct {
+	PageData
+	Configured bool
+	Account    *models.GoogleCalendarAccount
+	// Linked counts the tasks with an event in the calendar.
+	Linked    int
+	Conflicts []CalendarConflict
+	Error     string
+}
+
+// CalendarConflict is a task whose date was changed on both sides at once,
+// with how it was resolved.
+type CalendarConflict struct {
+	models.CalendarEvent
+	Task *models.Task
+}
+
+// CalendarSettings renders the Google Calendar connection and the latest
+// conflicts.
+func (h *Handlers) CalendarSettings(w http.ResponseWriter, r *http.Request) error {
+	return h.renderCalendarSettings(w, r, "")
+}
+
+func (h *Handlers) renderCalendarSettings(w http.ResponseWriter, r *http.Request, formError string) error {
+	ctx := r.Context()
+
+	account, err := h.store.GetGoogleCalendarAccount(ctx)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	links, err := h.store.ListCalendarEvents(ctx)
+	if err != nil {
+		return err
+	}
+
+	activeProjects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if formError != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	data := CalendarSettingsData{
+		PageData: PageData{
+			Title:          "Google Calendar",
+			ActiveProjects: activeProjects,
+			CurrentView:    "calendar",
+			Prefs:          h.prefs(ctx),
+			Lang:           h.localizer(r),
+		},
+		Configured: h.calendar.Enabled(),
+		Account:    account,
+		Linked:     len(links),
+		Error:      formError,
+	}
+	// Links come most recent conflict first.
+	for _, link := range links {
+		if link.Conflict == "" || len(data.Conflicts) == maxCalendarConflicts {
+			break
+		}
+		task, err := h.store.GetTask(ctx, link.TaskID)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		data.Conflicts = append(data.Conflicts, CalendarConflict{CalendarEvent: link, Task: task})
+	}
+
+	return h.renderTemplate(w, "calendar_settings.html", data)
+}
+
+// ConnectGoogleCalendar starts the OAuth flow by redirecting to Google's
+// consent page.
+func (h *Handlers) ConnectGoogleCalendar(w http.ResponseWriter, r *http.Request) error {
+	if !h.calendar.Enabled() {
+		return notFound("google calendar is not configured")
+	}
+
+	state, err := h.setOAuthState(w, r, calendarStateCookie, "/integrations/google/calendar")
+	if err != nil {
+		return err
+	}
+	http.Redirect(w, r, h.calendar.AuthCodeURL(state, h.calendarRedirectURL(r)), http.StatusFound)
+	return nil
+}
+
+// GoogleCalendarCallback completes the OAuth flow and stores the refresh
+// token. The first sync then adds the events.
+func (h *Handlers) GoogleCalendarCallback(w http.ResponseWriter, r *http.Request) error {
+	if !h.calendar.Enabled() {
+		return notFound("google calendar is not configured")
+	}
+	if !h.checkOAuthState(w, r, calendarStateCookie, "/integrations/google/calendar") {
+		return badRequest("invalid oauth state")
+	}
+
+	if reason := r.URL.Query().Get("error"); reason != "" {
+		return h.renderCalendarSettings(w, r, "Google declined the connection: "+reason)
+	}
+
+	ctx := r.Context()
+	tok, err := h.calendar.Exchange(ctx, h.httpClient(), r.URL.Query().Get("code"), h.calendarRedirectURL(r))
+	if err != nil {
+		return h.renderCalendarSettings(w, r, "Could not connect to Google: "+err.Error())
+	}
+	if tok.RefreshToken == "" {
+		return h.renderCalendarSettings(w, r, "Google did not return a refresh token. Remove mytasks from your Google account's third-party access and connect again.")
+	}
+
+	account, err := h.store.GetGoogleCalendarAccount(ctx)
+	if errors.Is(err, store.ErrNotFound) {
+		account = &models.GoogleCalendarAccount{}
+	} else if err != nil {
+		return err
+	}
+	account.RefreshToken = tok.RefreshToken
+	account.AccessToken = tok.AccessToken
+	account.TokenExpiry = &tok.Expiry
+
+	if err := h.store.SaveGoogleCalendarAccount(ctx, account); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, h.url("/settings/calendar"), http.StatusSeeOther)
+	return nil
+}
+
+// DisconnectGoogleCalendar forgets the stored tokens and which event belongs
+// to which task. The events stay in the calendar; connecting again links
+// them back up.
+func (h *Handlers) DisconnectGoogleCalendar(w http.ResponseWriter, r *http.Request) error {
+	if err := h.store.DeleteGoogleCalendarAccount(r.Context()); err != nil {
+		return err
+	}
+	http.Redirect(w, r, h.url("/settings/calendar"), http.StatusSeeOther)
+	return nil
+}
+
+// calendarRedirectURL returns the configured callback URL or derives one
+// from the request.
+func (h *Handlers) calendarRedirectURL(r *http.Request) string {
+	if h.calendar.RedirectURL != "" {
+		return h.calendar.RedirectURL
+	}
+	return requestBaseURL(r) + h.url("/integrations/google/calendar/callback")
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/i18n"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// captureTokenSetting is the settings key for the /capture token.
+const captureTokenSetting = "capture_token"
+
+// CaptureData holds data for the page a bookmarklet capture opens.
+type CaptureData struct {
+	Task        *models.Task
+	ProjectName string
+	Prefs       models.Settings
+	Lang        *i18n.Localizer
+}
+
+// CaptureSettingsData holds data for the capture settings page.
+type CaptureSettingsData struct {
+	PageData
+	Token       string
+	CaptureURL  string
+	Bookmarklet template.URL
+}
+
+// Capture saves a page for later as an Inbox task: title becomes the
+// description and url goes in the notes. It authenticates with the capture
+// token rather than same-origin checks, so a bookmarklet or browser
+// extension can call it from any page. GET renders a confirmation page for
+// bookmarklet pop-ups; POST answers 201 with the task as JSON.
+func (h *Handlers) Capture(w http.ResponseWriter, r *http.Request) error {
+	if err := h.authorizeToken(w, r, captureTokenSetting, bearerToken(r, r.FormValue("token"))); err != nil {
+		return err
+	}
+
+	title := strings.TrimSpace(r.FormValue("title"))
+	pageURL := strings.TrimSpace(r.FormValue("url"))
+	if title == "" {
+		title = pageURL
+	}
+	if title == "" {
+		return badRequest("title or url is required")
+	}
+
+	project, err := h.inboxProject(r)
+	if err != nil {
+		return err
+	}
+
+	priority, err := h.defaultPriority(r.Context())
+	if err != nil {
+		return err
+	}
+
+	task := &models.Task{
+		ProjectID:   project.ID,
+		Description: title,
+		Notes:       models.TruncateNotes(pageURL),
+		Priority:    priority,
+		Status:      "todo",
+	}
+	if err := h.store.CreateTask(r.Context(), task); err != nil {
+		return err
+	}
+
+	h.publish(r, events.Event{Type: events.TaskCreated, TaskID: task.ID, ProjectID: task.ProjectID, Task: task})
+
+	if r.Method == http.MethodPost {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(task)
+		return nil
+	}
+	return h.renderTemplate(w, "capture.html", CaptureData{Task: task, ProjectName: project.Name, Prefs: h.prefs(r.Context()), Lang: h.localizer(r)})
+}
+
+// CaptureSettings shows the capture token and a bookmarklet that uses it.
+func (h *Handlers) CaptureSettings(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	token, err := h.store.GetSetting(ctx, captureTokenSetting)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+
+	activeProjects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	data := CaptureSettingsData{
+		PageData: PageData{
+			Title:          "Capture",
+			ActiveProjects: activeProjects,
+			CurrentView:    "capture",
+			Prefs:          h.prefs(r.Context()),
+			Lang:           h.localizer(r),
+		},
+		Token:      token,
+		CaptureURL: requestBaseURL(r) + h.url("/capture"),
+	}
+	if token != "" {
+		data.Bookmarklet = template.URL(fmt.Sprintf(
+			"javascript:(function(){window.open('%s?token=%s&title='+encodeURIComponent(document.title)+'&url='+encodeURIComponent(location.href),'mytasks-capture','width=420,height=220');})();",
+			data.CaptureURL, token))
+	}
+
+	return h.renderTemplate(w, "capture_settings.html", data)
+}
+
+// ResetCaptureToken issues a new capture token, invalidating bookmarklets
+// that use the old one.
+func (h *Handlers) ResetCaptureToken(w http.ResponseWriter, r *http.Request) error {
+	return h.setToken(w, r, captureTokenSetting, true, "/settings/capture")
+}
+
+// DisableCapture removes the capture token, turning /capture off.
+func (h *Handlers) DisableCapture(w http.ResponseWriter, r *http.Request) error {
+	return h.setToken(w, r, captureTokenSetting, false, "/settings/capture")
+}
+
+// setToken saves a new random token under key, or clears it when on is
+// false, then redirects back to the settings page.
+func (h *Handlers) setToken(w http.ResponseWriter, r *http.Request, key string, on bool, redirect string) error {
+	var token string
+	if on {
+		var err error
+		if token, err = newSecret(); err != nil {
+			return err
+		}
+	}
+	if err := h.store.SetSetting(r.Context(), key, token); err != nil {
+		return err
+	}
+	http.Redirect(w, r, h.url(redirect), http.StatusSeeOther)
+	return nil
+}
+
+// authorizeToken checks a presented token against the one saved under key.
+// It returns an error when the token is wrong or no token has been issued,
+// which turns the feature off. Clients that keep presenting wrong tokens are
+// locked out with a growing delay (see models.AuthFailure).
+func (h *Handlers) authorizeToken(w http.ResponseWriter, r *http.Request, key, presented string) error {
+	ctx := r.Context()
+	want, err := h.store.GetSetting(ctx, key)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	if want == "" {
+		return notFound("token access is disabled")
+	}
+
+	client := clientIP(r)
+	failure, err := h.store.GetAuthFailure(ctx, key, client)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if wait := failure.LockedFor(now); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Round(time.Second).Seconds())+1))
+		return withStatus(http.StatusTooManyRequests, "too many invalid tokens, try again later")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(presented)), []byte(want)) != 1 {
+		failure.Record(now)
+		if err := h.store.SaveAuthFailure(ctx, failure); err != nil {
+			return err
+		}
+		if failure.LockedUntil != nil {
+			slog.Warn("auth: locked out after invalid tokens", "client", client, "feature", key, "until", failure.LockedUntil.Format(time.RFC3339), "failures", failure.Failures)
+		}
+		return withStatus(http.StatusUnauthorized, "invalid token")
+	}
+	if failure.Failures > 0 {
+		if err := h.store.ClearAuthFailures(ctx, key, client); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clientIP returns the IP address the request came from; behind a trusted
+// proxy the middleware has already replaced RemoteAddr with the client's.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// bearerToken returns the token from an "Authorization: Bearer" header, or
+// fallback when there is none.
+func bearerToken(r *http.Request, fallback string) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token
+	}
+	return fallback
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// Page sizes for ListChanges.
+const (
+	defaultChangesLimit = 200
+	maxChangesLimit     = 1000
+)
+
+// ChangeItem is a change with the project or task as it is now. Deleted
+// entities have neither.
+type ChangeItem struct {
+	models.Change
+	Project *models.Project `json:"project,omitempty"`
+	Task    *models.Task    `json:"task,omitempty"`
+}
+
+// ChangesPage is a page of the change log.
+type ChangesPage struct {
+	Changes []ChangeItem `json:"changes"`
+	// Next is the since to ask with next time: the seq of the last change
+	// here, or the since asked with when there were none.
+	Next int64 `json:"next"`
+	// More is set when there are changes after this page.
+	More bool `json:"more"`
+}
+
+// ListChanges returns the projects and tasks changed after ?since, with
+// their current contents, so clients can keep a copy up to date without
+// fetching everything. Starting from since=0 lists every project and task.
+func (h *Handlers) ListChanges(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	var since int64
+	if raw := q.Get("since"); raw != "" {
+		var err error
+		if since, err = strconv.ParseInt(raw, 10, 64); err != nil || since < 0 {
+			return badRequest("invalid since")
+		}
+	}
+	limit := defaultChangesLimit
+	if raw := q.Get("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > maxChangesLimit {
+			return badRequest("invalid limit")
+		}
+	}
+
+	changes, err := h.store.ListChanges(ctx, since, limit+1)
+	if err != nil {
+		return err
+	}
+	page := ChangesPage{Changes: []ChangeItem{}, Next: since}
+	if len(changes) > limit {
+		changes, page.More = changes[:limit], true
+	}
+	for _, c := range changes {
+		item, err := h.changeItem(r, c)
+		if err != nil {
+			return err
+		}
+		page.Changes = append(page.Changes, item)
+		page.Next = c.Seq
+	}
+
+	return writeJSON(w, r, page)
+}
+
+// changeItem loads the entity c refers to. One deleted since the log was
+// read is reported as deleted.
+func (h *Handlers) changeItem(r *http.Request, c models.Change) (ChangeItem, error) {
+	item := ChangeItem{Change: c}
+	if c.Op == models.ChangeDelete {
+		return item, nil
+	}
+
+	var err error
+	switch c.Entity {
+	case models.ChangeProject:
+		item.Project, err = h.store.GetProject(r.Context(), c.EntityID)
+	case models.ChangeTask:
+		item.Task, err = h.store.GetTask(r.Context(), c.EntityID)
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		item.Op, item.Project, item.Task = models.ChangeDelete, nil, nil
+		return item, nil
+	}
+	return item, err
+}
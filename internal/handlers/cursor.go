@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"mytasks/internal/store"
+)
+
+// cursor is the JSON behind an opaque page cursor. Clients only pass it back
+// as is, so its fields may change.
+type cursor struct {
+	Key string `json:"k"`
+	ID  int64  `json:"i"`
+}
+
+// encodeCursor makes the opaque cursor for the page after next, or "" when
+// there is none.
+func encodeCursor(next *store.Keyset) string {
+	if next == nil {
+		return ""
+	}
+	data, _ := json.Marshal(cursor{Key: next.Key, ID: next.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads a cursor made by encodeCursor. An empty one starts at
+// the first page.
+func decodeCursor(raw string) (*store.Keyset, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, badRequest("invalid cursor")
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 {
+		return nil, badRequest("invalid cursor")
+	}
+	return &store.Keyset{Key: c.Key, ID: c.ID}, nil
+}
+
+// pageParams reads the ?cursor and ?limit of a paged list. limit defaults to
+// def and may not exceed maxLimit.
+func pageParams(r *http.Request, def, maxLimit int) (after *store.Keyset, limit int, err error) {
+	q := r.URL.Query()
+	if after, err = decodeCursor(q.Get("cursor")); err != nil {
+		return nil, 0, err
+	}
+	limit = def
+	if raw := q.Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > maxLimit {
+			return nil, 0, badRequest("invalid limit")
+		}
+	}
+	return after, limit, nil
+}
+
+// keysetError answers a cursor that does not fit the list it was passed to,
+// such as one from another list, with 400.
+func keysetError(err error) error {
+	if errors.Is(err, store.ErrInvalidKeyset) {
+		return badRequest("invalid cursor")
+	}
+	return err
+}
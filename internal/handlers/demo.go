@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxDemoBody caps the body of a change in demo mode, well above any form
+// but too small to fill the in-memory database.
+const maxDemoBody = 64 << 10
+
+// demoWindow is the period DemoConfig.WriteLimit counts changes over.
+const demoWindow = time.Hour
+
+// DemoConfig enables demo mode, for a public instance anyone may change.
+type DemoConfig struct {
+	// WriteLimit caps the changes a client may make per hour.
+	WriteLimit int
+}
+
+// SetDemo enables demo mode.
+func (h *Handlers) SetDemo(cfg DemoConfig) {
+	h.demo = &demoLimiter{limit: cfg.WriteLimit, clients: map[string]*demoClient{}, now: time.Now}
+}
+
+type demoClient struct {
+	writes int
+	since  time.Time
+}
+
+// demoLimiter counts each client's changes in fixed one-hour windows.
+type demoLimiter struct {
+	limit   int
+	mu      sync.Mutex
+	clients map[string]*demoClient
+	now     func() time.Time
+}
+
+// allow counts a change by client, and returns how long it must wait if it
+// is over the limit.
+func (l *demoLimiter) allow(client string) (wait time.Duration, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	for key, c := range l.clients {
+		if now.Sub(c.since) >= demoWindow {
+			delete(l.clients, key)
+		}
+	}
+	c := l.clients[client]
+	if c == nil {
+		c = &demoClient{since: now}
+		l.clients[client] = c
+	}
+	if c.writes >= l.limit {
+		return c.since.Add(demoWindow).Sub(now), false
+	}
+	c.writes++
+	return 0, true
+}
+
+// DemoLimits caps the size of changes in demo mode, and how many a client
+// may make per hour, answering 429 past the limit. Reads are not limited.
+func (h *Handlers) DemoLimits(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if h.demo == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		client, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			client = r.RemoteAddr
+		}
+		if wait, ok := h.demo.allow(client); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Round(time.Second).Seconds())+1))
+			h.writeError(w, r, http.StatusTooManyRequests, "That's a lot of changes for the demo. Take a break and try again later.")
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxDemoBody)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NotInDemo turns f off in demo mode, for features that store files, hold
+// credentials or call other services on a visitor's behalf.
+func (h *Handlers) NotInDemo(f HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if h.demo != nil {
+			return withStatus(http.StatusForbidden, "This is not available in the demo.")
+		}
+		return f(w, r)
+	}
+}
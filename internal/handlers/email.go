@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/mail"
+	"mytasks/internal/models"
+)
+
+// maxInboundEmailBody bounds inbound webhook payloads, attachments included.
+const maxInboundEmailBody = 10 << 20
+
+// InboundEmail turns a Mailgun "store and notify"/forward webhook into an
+// Inbox task: subject becomes the description and the body becomes notes.
+// Senders must be on the configured allow list.
+func (h *Handlers) InboundEmail(w http.ResponseWriter, r *http.Request) error {
+	if !h.inboundEmail.Enabled() {
+		return notFound("inbound email is disabled")
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxInboundEmailBody)
+	if err := r.ParseMultipartForm(maxInboundEmailBody); err != nil {
+		if !errors.Is(err, http.ErrNotMultipart) {
+			return badRequest("invalid form data")
+		}
+		if err := r.ParseForm(); err != nil {
+			return badRequest("invalid form data")
+		}
+	}
+
+	if err := mail.VerifyMailgun(h.inboundEmail.SigningKey, r.FormValue("timestamp"), r.FormValue("token"), r.FormValue("signature"), time.Now()); err != nil {
+		return withStatus(http.StatusUnauthorized, "invalid signature")
+	}
+
+	sender := r.FormValue("sender")
+	if sender == "" {
+		sender = r.FormValue("from")
+	}
+	if !h.inboundEmail.Allowed(sender) {
+		// 406 tells Mailgun to drop the message instead of retrying.
+		slog.Warn("inbound email: rejected message", "sender", sender)
+		return withStatus(http.StatusNotAcceptable, "sender not allowed")
+	}
+
+	description := strings.TrimSpace(r.FormValue("subject"))
+	if description == "" {
+		description = "(no subject)"
+	}
+	body := r.FormValue("stripped-text")
+	if strings.TrimSpace(body) == "" {
+		body = r.FormValue("body-plain")
+	}
+
+	project, err := h.inboxProject(r)
+	if err != nil {
+		return err
+	}
+
+	priority, err := h.defaultPriority(r.Context())
+	if err != nil {
+		return err
+	}
+
+	task := &models.Task{
+		ProjectID:   project.ID,
+		Description: description,
+		Notes:       models.TruncateNotes(strings.TrimSpace(body)),
+		Priority:    priority,
+		Status:      "todo",
+	}
+	if err := task.Validate(); err != nil {
+		return err
+	}
+	if err := h.store.CreateTask(r.Context(), task); err != nil {
+		return err
+	}
+
+	h.publish(r, events.Event{Type: events.TaskCreated, TaskID: task.ID, ProjectID: task.ProjectID, Task: task})
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// HandlerFunc is an HTTP handler that returns its failure rather than
+// writing it. Handle turns the error into a response:
+//
+//   - errors from withStatus, badRequest and notFound send their status and
+//     message
+//   - store.ErrNotFound sends 404
+//   - a *models.ValidationError sends 400 with its message
+//   - anything else is logged and sends 500
+//
+// A handler that has already written a response returns nil.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Handle adapts f for the router, sending its errors as described on
+// HandlerFunc.
+func (h *Handlers) Handle(f HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := f(w, r); err != nil {
+			h.respondError(w, r, err)
+		}
+	}
+}
+
+// NotFound answers requests that match no route.
+func (h *Handlers) NotFound(w http.ResponseWriter, r *http.Request) error {
+	return notFound("There is nothing at this address.")
+}
+
+// statusError is an error with the status and message to send for it.
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+// withStatus returns an error that sends code with message.
+func withStatus(code int, message string) error {
+	return &statusError{code: code, message: message}
+}
+
+// badRequest returns an error that sends 400 with message.
+func badRequest(message string) error {
+	return withStatus(http.StatusBadRequest, message)
+}
+
+// notFound returns an error that sends 404 with message, for things that are
+// missing without the store saying so, such as a disabled integration.
+func notFound(message string) error {
+	return withStatus(http.StatusNotFound, message)
+}
+
+// ErrorData is the data for the error page and the error fragment sent to
+// htmx requests.
+type ErrorData struct {
+	PageData
+	Code    int
+	Message string
+	// RequestID is set for server errors, so they can be matched to the log.
+	RequestID string
+}
+
+// respondError sends the response for err.
+func (h *Handlers) respondError(w http.ResponseWriter, r *http.Request, err error) {
+	var se *statusError
+	var ve *models.ValidationError
+	switch {
+	case errors.As(err, &se):
+		h.writeError(w, r, se.code, se.message)
+	case errors.Is(err, store.ErrNotFound):
+		h.writeError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, store.ErrStale):
+		h.writeError(w, r, http.StatusPreconditionFailed, staleMessage)
+	case errors.As(err, &ve):
+		h.writeError(w, r, http.StatusBadRequest, ve.Message)
+	default:
+		// Server errors name the request ID, so a reported error can be
+		// matched to its log line.
+		slog.Error("internal server error", "request_id", w.Header().Get("X-Request-ID"), "err", err)
+		h.writeError(w, r, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// writeError sends the error as a page to browsers navigating here, as a
+// fragment to htmx, and as plain text to everything else.
+func (h *Handlers) writeError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	var id string
+	if code >= 500 {
+		id = w.Header().Get("X-Request-ID")
+	}
+
+	name := ""
+	switch {
+	case h.templates == nil && h.reload == nil:
+	case r.Header.Get("HX-Request") == "true":
+		name = "error_message.html"
+	case strings.Contains(r.Header.Get("Accept"), "text/html"):
+		name = "error.html"
+	}
+	if name == "" {
+		if id != "" {
+			message += " (request " + id + ")"
+		}
+		writeError(w, code, message)
+		return
+	}
+
+	ctx := r.Context()
+	data := ErrorData{
+		PageData: PageData{
+			Title: errorTitle(code),
+			Prefs: h.prefs(ctx),
+			Lang:  h.localizer(r),
+		},
+		Code:      code,
+		Message:   message,
+		RequestID: id,
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	if err := h.render(w, name, data); err != nil {
+		slog.Error("failed to render error page", "request_id", w.Header().Get("X-Request-ID"), "err", err)
+	}
+}
+
+// errorTitle heads the error page. Not-found and server errors get a
+// friendlier title than the status text; the message says what went wrong.
+func errorTitle(code int) string {
+	switch code {
+	case http.StatusNotFound:
+		return "Page not found"
+	case http.StatusInternalServerError:
+		return "Something went wrong"
+	}
+	return http.StatusText(code)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write([]byte(message))
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// revisionETag is the strong ETag of a task or project. It changes exactly
+// when the revision does, so it can guard a PUT with If-Match.
+func revisionETag(kind string, id, revision int64) string {
+	return fmt.Sprintf(`"%s-%d-%d"`, kind, id, revision)
+}
+
+// etagListed reports whether an If-Match or If-None-Match value lists etag.
+// If-None-Match compares weakly, ignoring a W/ prefix; If-Match only
+// accepts strong tags.
+func etagListed(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if weak {
+			if strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if tag == etag && !strings.HasPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// staleMessage answers 412 Precondition Failed, to an If-Match or an update
+// the store refused with store.ErrStale.
+const staleMessage = "it has changed since it was read; fetch it again"
+
+// checkIfMatch fails with 412 Precondition Failed when the request has an
+// If-Match that doesn't list etag, meaning someone else changed the
+// resource since the client read it.
+func checkIfMatch(r *http.Request, etag string) error {
+	header := r.Header.Get("If-Match")
+	if header == "" || etagListed(header, etag, false) {
+		return nil
+	}
+	return withStatus(http.StatusPreconditionFailed, staleMessage)
+}
+
+// ifMatchRevision returns the revision an update must still find for the
+// If-Match that checkIfMatch let through to hold, or 0 without one.
+func ifMatchRevision(r *http.Request, revision int64) int64 {
+	if r.Header.Get("If-Match") == "" {
+		return 0
+	}
+	return revision
+}
+
+// notModified sets the ETag header and, if the client's If-None-Match
+// already lists it, answers 304 Not Modified and returns true.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if header := r.Header.Get("If-None-Match"); header != "" && etagListed(header, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// writeJSON encodes v with a weak ETag taken from the encoded body, for
+// responses with no single revision to derive one from.
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(body)
+	if notModified(w, r, `W/"`+hex.EncodeToString(sum[:12])+`"`) {
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(append(body, '\n'))
+	return err
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"mytasks/internal/export"
+)
+
+// ExportProjects downloads the projects picked by ?project_id, each in the
+// formats picked by ?format (json, markdown, csv; all three by default), as
+// a zip archive with a folder per project.
+func (h *Handlers) ExportProjects(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query()
+
+	var ids []int64
+	for _, raw := range q["project_id"] {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id < 1 {
+			return badRequest("invalid project id")
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return badRequest("choose at least one project")
+	}
+	formats := q["format"]
+	if len(formats) == 0 {
+		formats = export.Formats
+	}
+	for _, format := range formats {
+		if !export.ValidFormat(format) {
+			return badRequest(fmt.Sprintf("unknown format %q", format))
+		}
+	}
+
+	projects, err := export.New(h.store).Projects(r.Context(), ids)
+	if err != nil {
+		return err
+	}
+
+	name := "mytasks-projects.zip"
+	if len(projects) == 1 {
+		name = "mytasks-" + export.Folder(&projects[0]) + ".zip"
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	if err := export.WriteZip(w, projects, formats); err != nil {
+		// The archive is partly sent: cut the connection so the client
+		// doesn't take a truncated zip for a whole one.
+		if r.Context().Err() == nil {
+			slog.Error("export failed part way", "path", r.URL.Path, "err", err)
+		}
+		panic(http.ErrAbortHandler)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/i18n"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// FocusTimerData holds data for the focus timer in the sidebar.
+type FocusTimerData struct {
+	Session *models.FocusSession // nil when no session is running
+	Lang    *i18n.Localizer
+}
+
+// FocusReport is the JSON answer of GET /api/focus.
+type FocusReport struct {
+	From    string               `json:"from"`
+	To      string               `json:"to"`
+	Running *models.FocusSession `json:"running"`
+	Totals  []models.FocusTotal  `json:"totals"`
+}
+
+// FocusTimer renders the sidebar's focus timer. The running session is kept
+// by the server, so the timer survives reloads and shows on every device.
+func (h *Handlers) FocusTimer(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.runningFocusSession(r)
+	if err != nil {
+		return err
+	}
+	return h.renderFocus(w, r, session)
+}
+
+// StartFocus starts a focus session on a task, stopping any other.
+func (h *Handlers) StartFocus(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid task id")
+	}
+
+	session, err := h.store.StartFocusSession(r.Context(), id, time.Now())
+	if err != nil {
+		return err
+	}
+	h.publish(r, events.Event{Type: events.FocusStarted, TaskID: session.TaskID})
+	return h.renderFocus(w, r, session)
+}
+
+// StopFocus stops the running focus session. API clients get the stopped
+// session back.
+func (h *Handlers) StopFocus(w http.ResponseWriter, r *http.Request) error {
+	session, err := h.store.StopFocusSession(r.Context(), time.Now())
+	if errors.Is(err, store.ErrNotFound) {
+		return withStatus(http.StatusConflict, "no focus session is running")
+	}
+	if err != nil {
+		return err
+	}
+	h.publish(r, events.Event{Type: events.FocusStopped, TaskID: session.TaskID})
+	if r.Header.Get("HX-Request") != "true" {
+		return writeJSON(w, r, session)
+	}
+	return h.renderFocus(w, r, nil)
+}
+
+// FocusTotals returns the running session and the time spent focusing per
+// task and day, as JSON. Query params:
+//   - from, to: YYYY-MM-DD, both included; default today.
+//   - task_id: optional; limits the totals to one task.
+func (h *Handlers) FocusTotals(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	today := time.Now()
+	from, to := dateOnly(today), dateOnly(today)
+	if raw := q.Get("from"); raw != "" {
+		d := parseDate(raw)
+		if d == nil {
+			return badRequest("from must be YYYY-MM-DD")
+		}
+		from = *d
+	}
+	if raw := q.Get("to"); raw != "" {
+		d := parseDate(raw)
+		if d == nil {
+			return badRequest("to must be YYYY-MM-DD")
+		}
+		to = *d
+	}
+	if to.Before(from) {
+		return badRequest("to must not be before from")
+	}
+	var taskID int64
+	if raw := q.Get("task_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			return badRequest("invalid task_id")
+		}
+		taskID = id
+	}
+
+	// Sessions belong to the local day they started on.
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.Local)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+	sessions, err := h.store.ListFocusSessions(ctx, taskID, start, end)
+	if err != nil {
+		return err
+	}
+	running, err := h.runningFocusSession(r)
+	if err != nil {
+		return err
+	}
+
+	report := FocusReport{
+		From:    from.Format("2006-01-02"),
+		To:      to.Format("2006-01-02"),
+		Running: running,
+		Totals:  models.SumFocusSessions(sessions, today),
+	}
+	if report.Totals == nil {
+		report.Totals = []models.FocusTotal{}
+	}
+	return writeJSON(w, r, report)
+}
+
+// runningFocusSession returns the running focus session, or nil.
+func (h *Handlers) runningFocusSession(r *http.Request) (*models.FocusSession, error) {
+	session, err := h.store.GetRunningFocusSession(r.Context())
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	return session, err
+}
+
+// renderFocus answers htmx with the sidebar timer and other clients with
+// the running session as JSON, null when there is none.
+func (h *Handlers) renderFocus(w http.ResponseWriter, r *http.Request, session *models.FocusSession) error {
+	if r.Header.Get("HX-Request") != "true" {
+		return writeJSON(w, r, session)
+	}
+	return h.renderPartial(w, "focus_timer.html", FocusTimerData{Session: session, Lang: h.localizer(r)})
+}
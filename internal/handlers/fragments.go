@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+
+	"mytasks/internal/i18n"
+	"mytasks/internal/models"
+)
+
+// TaskOOBData holds what a change to a task refreshes out of band: the
+// sidebar entries and board headers of the projects it touched, and the
+// sidebar's Upcoming badge.
+type TaskOOBData struct {
+	Projects []models.Project
+	Total    models.ProjectCounts // across all active projects
+	Prefs    models.Settings
+	Lang     *i18n.Localizer
+}
+
+// UndoToastData holds data for the toast offering to undo a task delete.
+type UndoToastData struct {
+	Token   string
+	Seconds int // how long the toast stays up
+	Lang    *i18n.Localizer
+}
+
+// renderTaskChange renders the partial name with data, then, for htmx
+// requests, the sidebar entries and counts a change to tasks in projectIDs,
+// or to the projects themselves, makes stale as hx-swap-oob fragments. An
+// empty name sends only the fragments.
+//
+// The fragments are loaded before anything is written, so a failure still
+// reaches the client as an error rather than half a response.
+func (h *Handlers) renderTaskChange(w http.ResponseWriter, r *http.Request, name string, data interface{}, projectIDs ...int64) error {
+	var oob *TaskOOBData
+	if r.Header.Get("HX-Request") == "true" {
+		ctx := r.Context()
+		projects, err := h.loadActiveProjects(ctx)
+		if err != nil {
+			return err
+		}
+		oob = &TaskOOBData{Total: models.TotalCounts(projects), Prefs: h.prefs(ctx), Lang: h.localizer(r)}
+		for _, p := range projects {
+			if slices.Contains(projectIDs, p.ID) {
+				oob.Projects = append(oob.Projects, p)
+			}
+		}
+	}
+
+	if name != "" {
+		if err := h.renderPartial(w, name, data); err != nil {
+			return err
+		}
+	}
+	if oob == nil {
+		return nil
+	}
+	return h.renderPartial(w, "task_oob.html", oob)
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mytasks/internal/github"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// maxGitHubWebhookSize bounds webhook deliveries; issue payloads are small.
+const maxGitHubWebhookSize = 1 << 20
+
+// GitHubSettingsData holds data for the GitHub settings page.
+type GitHubSettingsData struct {
+	PageData
+	Links      []GitHubLinkView
+	WebhookURL string
+	Error      string
+}
+
+// GitHubLinkView pairs a link with its project's name.
+type GitHubLinkView struct {
+	models.GitHubLink
+	ProjectName string
+}
+
+// GitHubSettings renders linked repositories and the form to link another.
+func (h *Handlers) GitHubSettings(w http.ResponseWriter, r *http.Request) error {
+	return h.renderGitHubSettings(w, r, "")
+}
+
+func (h *Handlers) renderGitHubSettings(w http.ResponseWriter, r *http.Request, formError string) error {
+	ctx := r.Context()
+
+	links, err := h.store.ListGitHubLinks(ctx)
+	if err != nil {
+		return err
+	}
+
+	projects, err := h.store.ListProjects(ctx)
+	if err != nil {
+		return err
+	}
+	names := make(map[int64]string, len(projects))
+	for _, p := range projects {
+		names[p.ID] = p.Name
+	}
+
+	activeProjects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if formError != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	data := GitHubSettingsData{
+		PageData: PageData{
+			Title:          "GitHub",
+			ActiveProjects: activeProjects,
+			CurrentView:    "github",
+			Prefs:          h.prefs(r.Context()),
+			Lang:           h.localizer(r),
+		},
+		WebhookURL: requestBaseURL(r) + h.url("/integrations/github/webhook"),
+		Error:      formError,
+	}
+	for _, link := range links {
+		data.Links = append(data.Links, GitHubLinkView{GitHubLink: link, ProjectName: names[link.ProjectID]})
+	}
+
+	return h.renderTemplate(w, "github_settings.html", data)
+}
+
+// SaveGitHubLink links a project to a repository, or updates an existing
+// link. The token is checked against GitHub to learn whose issues to sync.
+func (h *Handlers) SaveGitHubLink(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	projectID, err := strconv.ParseInt(r.FormValue("project_id"), 10, 64)
+	if err != nil || projectID <= 0 {
+		return badRequest("invalid project id")
+	}
+	project, err := h.store.GetProject(ctx, projectID)
+	if err != nil || project.Completed {
+		return h.renderGitHubSettings(w, r, "Choose an active project.")
+	}
+
+	link, err := h.store.GetGitHubLink(ctx, projectID)
+	if errors.Is(err, store.ErrNotFound) {
+		link = &models.GitHubLink{ProjectID: projectID}
+	} else if err != nil {
+		return err
+	}
+
+	repo := strings.TrimSpace(r.FormValue("repo"))
+	repo = strings.TrimSuffix(strings.TrimPrefix(repo, "https://github.com/"), "/")
+	if repo != link.Repo {
+		// Issue refs are per repository; a new repo starts a fresh sync.
+		link.LastSyncedAt = nil
+	}
+	link.Repo = repo
+	if token := strings.TrimSpace(r.FormValue("token")); token != "" {
+		link.Token = token
+	}
+
+	if err := link.Validate(); err != nil {
+		return h.renderGitHubSettings(w, r, err.Error())
+	}
+	if other, err := h.store.GetGitHubLinkByRepo(ctx, link.Repo); err == nil && other.ProjectID != projectID {
+		return h.renderGitHubSettings(w, r, link.Repo+" is already linked to another project.")
+	}
+
+	client := &github.Client{HTTPClient: h.httpClient(), BaseURL: h.githubBaseURL, Token: link.Token}
+	login, err := client.CurrentUser(ctx)
+	if err != nil {
+		return h.renderGitHubSettings(w, r, "Could not verify token: "+err.Error())
+	}
+	link.Login = login
+
+	if link.WebhookSecret == "" {
+		link.WebhookSecret, err = newSecret()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := h.store.SaveGitHubLink(ctx, link); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, h.url("/settings/github"), http.StatusSeeOther)
+	return nil
+}
+
+// DeleteGitHubLink unlinks a project. Tasks created from issues are kept.
+func (h *Handlers) DeleteGitHubLink(w http.ResponseWriter, r *http.Request) error {
+	projectID, err := parseID(r, "project_id")
+	if err != nil {
+		return badRequest("invalid project id")
+	}
+
+	if err := h.store.DeleteGitHubLink(r.Context(), projectID); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, h.url("/settings/github"), http.StatusSeeOther)
+	return nil
+}
+
+// GitHubWebhook applies "issues" deliveries so changes show up without
+// waiting for the next poll. Each linked repository has its own secret.
+func (h *Handlers) GitHubWebhook(w http.ResponseWriter, r *http.Request) error {
+	if h.github == nil {
+		return notFound("github sync is not enabled")
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxGitHubWebhookSize))
+	if err != nil {
+		return badRequest("invalid body")
+	}
+
+	var payload struct {
+		Issue      *github.Issue `json:"issue"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return badRequest("invalid payload")
+	}
+
+	ctx := r.Context()
+	link, err := h.store.GetGitHubLinkByRepo(ctx, payload.Repository.FullName)
+	if errors.Is(err, store.ErrNotFound) {
+		return notFound("repository is not linked")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := github.VerifySignature(link.WebhookSecret, r.Header.Get("X-Hub-Signature-256"), body); err != nil {
+		return withStatus(http.StatusUnauthorized, "invalid signature")
+	}
+
+	if r.Header.Get("X-GitHub-Event") == "issues" && payload.Issue != nil {
+		if err := h.github.ApplyIssue(ctx, link, *payload.Issue); err != nil {
+			return err
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// linkGitHubIssues sets URL on tasks that were created from GitHub issues.
+func (h *Handlers) linkGitHubIssues(ctx context.Context, lists ...[]models.Task) error {
+	refs, err := h.store.ListTaskExternalRefs(ctx, github.Source)
+	if err != nil || len(refs) == 0 {
+		return err
+	}
+	for _, tasks := range lists {
+		for i := range tasks {
+			if ref, ok := refs[tasks[i].ID]; ok {
+				tasks[i].URL = github.IssueURL(ref)
+			}
+		}
+	}
+	return nil
+}
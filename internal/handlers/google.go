@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// googleStateCookie carries the OAuth state between connect and callback.
+const googleStateCookie = "google_oauth_state"
+
+// GoogleSettingsData holds data for the Google Tasks settings page.
+type GoogleSettingsData struct {
+	PageData
+	Configured bool
+	Account    *models.GoogleTasksAccount
+	Error      string
+}
+
+// GoogleSettings renders the Google Tasks connection and target project.
+func (h *Handlers) GoogleSettings(w http.ResponseWriter, r *http.Request) error {
+	return h.renderGoogleSettings(w, r, "")
+}
+
+func (h *Handlers) renderGoogleSettings(w http.ResponseWriter, r *http.Request, formError string) error {
+	ctx := r.Context()
+
+	account, err := h.store.GetGoogleTasksAccount(ctx)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+
+	activeProjects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if formError != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	data := GoogleSettingsData{
+		PageData: PageData{
+			Title:          "Google Tasks",
+			ActiveProjects: activeProjects,
+			CurrentView:    "google",
+			Prefs:          h.prefs(r.Context()),
+			Lang:           h.localizer(r),
+		},
+		Configured: h.google.Enabled(),
+		Account:    account,
+		Error:      formError,
+	}
+
+	return h.renderTemplate(w, "google_settings.html", data)
+}
+
+// ConnectGoogle starts the OAuth flow by redirecting to Google's consent page.
+func (h *Handlers) ConnectGoogle(w http.ResponseWriter, r *http.Request) error {
+	if !h.google.Enabled() {
+		return notFound("google tasks is not configured")
+	}
+
+	state, err := h.setOAuthState(w, r, googleStateCookie, "/integrations/google")
+	if err != nil {
+		return err
+	}
+	http.Redirect(w, r, h.google.AuthCodeURL(state, h.googleRedirectURL(r)), http.StatusFound)
+	return nil
+}
+
+// GoogleCallback completes the OAuth flow and stores the refresh token.
+func (h *Handlers) GoogleCallback(w http.ResponseWriter, r *http.Request) error {
+	if !h.google.Enabled() {
+		return notFound("google tasks is not configured")
+	}
+
+	if !h.checkOAuthState(w, r, googleStateCookie, "/integrations/google") {
+		return badRequest("invalid oauth state")
+	}
+
+	if reason := r.URL.Query().Get("error"); reason != "" {
+		return h.renderGoogleSettings(w, r, "Google declined the connection: "+reason)
+	}
+
+	ctx := r.Context()
+	tok, err := h.google.Exchange(ctx, h.httpClient(), r.URL.Query().Get("code"), h.googleRedirectURL(r))
+	if err != nil {
+		return h.renderGoogleSettings(w, r, "Could not connect to Google: "+err.Error())
+	}
+	if tok.RefreshToken == "" {
+		return h.renderGoogleSettings(w, r, "Google did not return a refresh token. Remove mytasks from your Google account's third-party access and connect again.")
+	}
+
+	account, err := h.store.GetGoogleTasksAccount(ctx)
+	if errors.Is(err, store.ErrNotFound) {
+		account = &models.GoogleTasksAccount{}
+	} else if err != nil {
+		return err
+	}
+	account.RefreshToken = tok.RefreshToken
+	account.AccessToken = tok.AccessToken
+	account.TokenExpiry = &tok.Expiry
+
+	if err := h.store.SaveGoogleTasksAccount(ctx, account); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, h.url("/settings/google"), http.StatusSeeOther)
+	return nil
+}
+
+// UpdateGoogleSettings sets the project that synced tasks land in.
+func (h *Handlers) UpdateGoogleSettings(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	account, err := h.store.GetGoogleTasksAccount(ctx)
+	if err != nil {
+		return notFound("google tasks is not connected")
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	account.ProjectID = nil
+	if raw := r.FormValue("project_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			return badRequest("invalid project id")
+		}
+		project, err := h.store.GetProject(ctx, id)
+		if err != nil || project.Completed {
+			return h.renderGoogleSettings(w, r, "Choose an active project.")
+		}
+		account.ProjectID = &id
+	}
+
+	if err := h.store.SaveGoogleTasksAccount(ctx, account); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, h.url("/settings/google"), http.StatusSeeOther)
+	return nil
+}
+
+// DisconnectGoogle forgets the stored tokens. Tasks already synced are kept.
+func (h *Handlers) DisconnectGoogle(w http.ResponseWriter, r *http.Request) error {
+	if err := h.store.DeleteGoogleTasksAccount(r.Context()); err != nil {
+		return err
+	}
+	http.Redirect(w, r, h.url("/settings/google"), http.StatusSeeOther)
+	return nil
+}
+
+// googleRedirectURL returns the configured callback URL or derives one from
+// the request.
+func (h *Handlers) googleRedirectURL(r *http.Request) string {
+	if h.google.RedirectURL != "" {
+		return h.google.RedirectURL
+	}
+	return requestBaseURL(r) + h.url("/integrations/google/callback")
+}
+
+// setOAuthState starts an OAuth flow: it returns a random state for the
+// consent page URL and sets it in the named cookie, scoped to path, for the
+// callback to check.
+func (h *Handlers) setOAuthState(w http.ResponseWriter, r *http.Request, name, path string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	state := hex.EncodeToString(buf)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    state,
+		Path:     h.url(path),
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return state, nil
+}
+
+// checkOAuthState reports whether the callback carries the state set by
+// setOAuthState, and clears the cookie.
+func (h *Handlers) checkOAuthState(w http.ResponseWriter, r *http.Request, name, path string) bool {
+	cookie, err := r.Cookie(name)
+	state := r.URL.Query().Get("state")
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		return false
+	}
+	http.SetCookie(w, &http.Cookie{Name: name, Path: h.url(path), MaxAge: -1})
+	return true
+}
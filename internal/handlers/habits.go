@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"mytasks/internal/i18n"
+	"mytasks/internal/models"
+)
+
+// HabitGridData holds data for the habit grid in the sidebar.
+type HabitGridData struct {
+	// Days are the columns, the last streakHistoryDays days oldest first, as
+	// in the streak chart.
+	Days   []time.Time
+	Habits []HabitRow
+	Lang   *i18n.Localizer
+}
+
+// HabitRow is one habit in the grid.
+type HabitRow struct {
+	models.Habit
+	Streak int
+	// Done has one entry per day in HabitGridData.Days.
+	Done []bool
+}
+
+// Habits renders the habit grid.
+func (h *Handlers) Habits(w http.ResponseWriter, r *http.Request) error {
+	return h.renderHabits(w, r)
+}
+
+// CreateHabit adds a habit and re-renders the grid.
+func (h *Handlers) CreateHabit(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+	habit := &models.Habit{Name: r.FormValue("name"), Cadence: r.FormValue("cadence")}
+	if err := habit.Validate(); err != nil {
+		return err
+	}
+	if err := h.store.CreateHabit(r.Context(), habit); err != nil {
+		return err
+	}
+	return h.renderHabits(w, r)
+}
+
+// CheckInHabit checks a habit in for today, or for the day given as
+// YYYY-MM-DD, taking the check-in back if there already was one, and
+// re-renders the grid.
+func (h *Handlers) CheckInHabit(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid habit id")
+	}
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	today := dateOnly(time.Now())
+	day := today
+	if raw := r.FormValue("day"); raw != "" {
+		parsed := parseDate(raw)
+		if parsed == nil {
+			return badRequest("day must be YYYY-MM-DD")
+		}
+		if parsed.After(today) {
+			return badRequest("habits can't be checked in ahead of time")
+		}
+		day = *parsed
+	}
+
+	if _, err := h.store.ToggleHabitCheckIn(r.Context(), id, day); err != nil {
+		return err
+	}
+	return h.renderHabits(w, r)
+}
+
+// DeleteHabit removes a habit with its check-ins and re-renders the grid.
+func (h *Handlers) DeleteHabit(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid habit id")
+	}
+	if err := h.store.DeleteHabit(r.Context(), id); err != nil {
+		return err
+	}
+	return h.renderHabits(w, r)
+}
+
+func (h *Handlers) renderHabits(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	habits, err := h.store.ListHabits(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	today := dateOnly(now)
+	data := HabitGridData{Lang: h.localizer(r)}
+	for i := streakHistoryDays - 1; i >= 0; i-- {
+		data.Days = append(data.Days, today.AddDate(0, 0, -i))
+	}
+	weekStart := h.prefs(ctx).WeekStart
+	for _, habit := range habits {
+		row := HabitRow{Habit: habit, Streak: habit.Streak(now, weekStart)}
+		for _, day := range data.Days {
+			row.Done = append(row.Done, habit.CheckedIn(day))
+		}
+		data.Habits = append(data.Habits, row)
+	}
+
+	return h.renderPartial(w, "habits.html", data)
+}
@@ -2,22 +2,55 @@ package handlers
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"html/template"
-	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"mytasks/internal/events"
+	"mytasks/internal/github"
+	"mytasks/internal/gtasks"
+	"mytasks/internal/i18n"
+	"mytasks/internal/importer"
+	"mytasks/internal/mail"
 	"mytasks/internal/models"
+	"mytasks/internal/realtime"
 	"mytasks/internal/store"
+	"mytasks/internal/webhook"
 )
 
 // Handlers holds the HTTP handlers and their dependencies.
 type Handlers struct {
 	store     store.Store
 	templates *template.Template
+	reload    func() (*template.Template, error)
+	hub       *realtime.Hub
+	bus       *events.Bus
+
+	slackSigningSecret string
+	inboundEmail       mail.InboundConfig
+	google             gtasks.OAuthConfig
+	calendar           gtasks.OAuthConfig
+	github             *github.Syncer
+	webhooks           *webhook.Sender
+	reports            *mail.Reports
+	admin              AdminConfig
+	maintenance        *atomic.Bool
+	demo               *demoLimiter
+	basePath           string
+	palettes           []string
+
+	// client and the base URLs are used by handlers that call remote APIs;
+	// tests point them at an httptest server.
+	client        *http.Client
+	graphBaseURL  string
+	githubBaseURL string
 }
 
 // PageData is the base data structure for all page templates.
@@ -26,15 +59,122 @@ type PageData struct {
 	Title            string
 	ActiveProjects   []models.Project
 	CurrentProjectID int64
-	CurrentView      string // "kanban", "upcoming", "completed_projects", "completed_tasks"
+	CurrentView      string // "kanban", "all_tasks", "upcoming", "agenda", "review", "completed_projects", "completed_tasks", "import", "slack", "google", "github", "capture", "shortcuts", "reports", "settings", "admin", "recent"
+	// Prefs style the page: the theme is set on <html> so it renders
+	// without a flash of the wrong one, and dates use the chosen format.
+	Prefs models.Settings
+	// Lang translates the page's text.
+	Lang *i18n.Localizer
 }
 
 // New creates a new Handlers instance.
 func New(s store.Store, tmpl *template.Template) *Handlers {
 	return &Handlers{
-		store:     s,
-		templates: tmpl,
+		store:         s,
+		templates:     tmpl,
+		graphBaseURL:  importer.GraphBaseURL,
+		githubBaseURL: github.DefaultBaseURL,
+	}
+}
+
+// SetHub attaches the realtime hub served by the /ws endpoint.
+func (h *Handlers) SetHub(hub *realtime.Hub) {
+	h.hub = hub
+}
+
+// SetTemplateReloader makes every render fetch its templates from load
+// instead of using the ones given to New, so edits show up without a restart.
+func (h *Handlers) SetTemplateReloader(load func() (*template.Template, error)) {
+	h.reload = load
+}
+
+// SetPalettes lists the color palettes offered in the settings, by the names
+// of their stylesheets in static/css/themes.
+func (h *Handlers) SetPalettes(names []string) {
+	h.palettes = names
+}
+
+// SetBus attaches the event bus that mutation handlers publish domain events to.
+func (h *Handlers) SetBus(bus *events.Bus) {
+	h.bus = bus
+}
+
+// SetBasePath sets the path prefix the app is served under, e.g. "/mytasks"
+// behind a reverse proxy. Redirects and generated links include it.
+func (h *Handlers) SetBasePath(basePath string) {
+	h.basePath = basePath
+}
+
+// url prefixes an absolute app path with the base path.
+func (h *Handlers) url(path string) string {
+	return h.basePath + path
+}
+
+// SetSlackSigningSecret enables the Slack slash command endpoint.
+func (h *Handlers) SetSlackSigningSecret(secret string) {
+	h.slackSigningSecret = secret
+}
+
+// SetInboundEmail enables the inbound email webhook.
+func (h *Handlers) SetInboundEmail(cfg mail.InboundConfig) {
+	h.inboundEmail = cfg
+}
+
+// SetGoogleTasks enables connecting a Google account for Tasks sync.
+func (h *Handlers) SetGoogleTasks(cfg gtasks.OAuthConfig) {
+	h.google = cfg
+}
+
+// SetGoogleCalendar enables connecting a Google account for Calendar sync.
+// cfg is the Google OAuth client, asking for the Calendar scope.
+func (h *Handlers) SetGoogleCalendar(cfg gtasks.OAuthConfig) {
+	h.calendar = cfg
+}
+
+// SetGitHub enables the GitHub webhook, which applies issue changes through
+// the given syncer.
+func (h *Handlers) SetGitHub(syncer *github.Syncer) {
+	h.github = syncer
+}
+
+// SetWebhooks enables redelivering webhooks from the admin panel.
+func (h *Handlers) SetWebhooks(sender *webhook.Sender) {
+	h.webhooks = sender
+}
+
+func (h *Handlers) httpClient() *http.Client {
+	if h.client != nil {
+		return h.client
 	}
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+// requestBaseURL returns the scheme and host the request was made to, for
+// building absolute callback URLs. X-Forwarded-Proto only survives the
+// middleware when a trusted proxy sent it.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// newSecret returns a random hex string for webhook secrets, API tokens and
+// undo tokens.
+func newSecret() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// publish emits a domain event, tagging it with the originating client so
+// realtime subscribers can skip re-applying their own edit.
+func (h *Handlers) publish(r *http.Request, e events.Event) {
+	e.Origin = r.Header.Get("X-Client-ID")
+	h.bus.Publish(r.Context(), e)
 }
 
 // parseID extracts and parses an integer ID from URL parameters.
@@ -55,39 +195,69 @@ func parseDate(s string) *time.Time {
 	return &t
 }
 
-// respondError sends an error response.
-func respondError(w http.ResponseWriter, code int, message string) {
-	w.WriteHeader(code)
-	w.Write([]byte(message))
-}
-
-func respondServerError(w http.ResponseWriter, err error) {
-	log.Printf("internal server error: %v", err)
-	respondError(w, http.StatusInternalServerError, "internal server error")
-}
-
-func (h *Handlers) render(w http.ResponseWriter, name string, data interface{}) {
-	if h.templates == nil {
+func (h *Handlers) render(w http.ResponseWriter, name string, data interface{}) error {
+	tmpl := h.templates
+	if h.reload != nil {
+		var err error
+		if tmpl, err = h.reload(); err != nil {
+			return err
+		}
+	}
+	if tmpl == nil {
 		// For testing without templates
 		w.WriteHeader(http.StatusOK)
-		return
-	}
-	if err := h.templates.ExecuteTemplate(w, name, data); err != nil {
-		respondServerError(w, err)
+		return nil
 	}
+	return tmpl.ExecuteTemplate(w, name, data)
 }
 
 // renderTemplate renders a template with the given data.
-func (h *Handlers) renderTemplate(w http.ResponseWriter, name string, data interface{}) {
-	h.render(w, name, data)
+func (h *Handlers) renderTemplate(w http.ResponseWriter, name string, data interface{}) error {
+	return h.render(w, name, data)
 }
 
 // renderPartial renders a partial template (for htmx responses).
-func (h *Handlers) renderPartial(w http.ResponseWriter, name string, data interface{}) {
-	h.render(w, name, data)
+func (h *Handlers) renderPartial(w http.ResponseWriter, name string, data interface{}) error {
+	return h.render(w, name, data)
 }
 
-// loadActiveProjects loads all active projects for the sidebar.
+// loadActiveProjects loads all active projects for the sidebar, with the
+// counts of their open tasks for its badges.
 func (h *Handlers) loadActiveProjects(ctx context.Context) ([]models.Project, error) {
-	return h.store.ListActiveProjects(ctx)
+	projects, err := h.store.ListActiveProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := h.store.ProjectTaskCounts(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range projects {
+		projects[i].Counts = counts[projects[i].ID]
+	}
+	return projects, nil
+}
+
+// inboxProject returns the active project named "Inbox", creating it when
+// missing. Integrations that capture tasks without a project file them here.
+func (h *Handlers) inboxProject(r *http.Request) (*models.Project, error) {
+	ctx := r.Context()
+
+	projects, err := h.store.ListActiveProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range projects {
+		if strings.EqualFold(projects[i].Name, "Inbox") {
+			return &projects[i], nil
+		}
+	}
+
+	project := &models.Project{Name: "Inbox", Type: "project"}
+	if err := h.store.CreateProject(ctx, project); err != nil {
+		return nil, err
+	}
+
+	h.publish(r, events.Event{Type: events.ProjectCreated, ProjectID: project.ID, Project: project})
+	return project, nil
 }
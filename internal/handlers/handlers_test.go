@@ -1,24 +1,46 @@
 package handlers
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"mytasks/internal/events"
+	"mytasks/internal/gcal"
+	"mytasks/internal/github"
+	"mytasks/internal/gtasks"
+	"mytasks/internal/i18n"
+	"mytasks/internal/mail"
+	"mytasks/internal/markdown"
 	"mytasks/internal/models"
+	"mytasks/internal/realtime"
+	"mytasks/internal/slack"
 	"mytasks/internal/store"
+	"mytasks/internal/webhook"
+	"mytasks/plugin"
 )
 
 func setupTestHandlers(t *testing.T) (*Handlers, *store.SQLiteStore) {
@@ -39,6 +61,27 @@ func setupTestHandlersWithTemplates(t *testing.T) (*Handlers, *store.SQLiteStore
 
 	funcMap := template.FuncMap{
 		"add": func(a, b int) int { return a + b },
+		// App URLs start with the base path, e.g. href="{{base}}/settings".
+		"base": func() string { return "" },
+		// Static files are linked by their fingerprinted URL, e.g.
+		// href="{{asset "css/styles.css"}}".
+		"asset": func(name string) string { return "/static/" + name },
+		// Dates follow the date format in PageData.Prefs, e.g. {{formatDate $.Prefs .DueDate}}.
+		"formatDate": models.Settings.FormatDate,
+		"shortDate":  models.Settings.FormatShortDate,
+		// Text is translated with PageData.Lang, e.g. {{t $.Lang "Upcoming"}}.
+		"t": (*i18n.Localizer).T,
+		// Notes and comments are written in Markdown, e.g. {{markdown .Notes}}.
+		"markdown": markdown.Render,
+		// The sidebar sums its projects' counts, e.g. {{totalCounts .ActiveProjects}}.
+		"totalCounts": models.TotalCounts,
+		// Focus time reads as "25m" or "1h 05m", e.g. {{duration .Total}}.
+		"duration": models.FormatDuration,
+		// Sidebar links added by plugins; see plugins.go in package mytasks.
+		"pluginNav":   func() []plugin.NavItem { return nil },
+		"workspaces":  func() []models.WorkspaceLink { return nil },
+		"maintenance": func() bool { return false },
+		"demo":        func() time.Duration { return 0 },
 		"dict": func(values ...interface{}) map[string]interface{} {
 			if len(values)%2 != 0 {
 				return nil
@@ -85,7 +128,7 @@ func TestHomeHandler_RedirectsToFirstProject(t *testing.T) {
 	req := httptest.NewRequest("GET", "/", nil)
 	rec := httptest.NewRecorder()
 
-	h.Home(rec, req)
+	h.Handle(h.Home)(rec, req)
 
 	if rec.Code != http.StatusFound {
 		t.Errorf("expected status %d, got %d", http.StatusFound, rec.Code)
@@ -102,7 +145,7 @@ func TestHomeHandler_EmptyStateWhenNoProjects(t *testing.T) {
 	req := httptest.NewRequest("GET", "/", nil)
 	rec := httptest.NewRecorder()
 
-	h.Home(rec, req)
+	h.Handle(h.Home)(rec, req)
 
 	// With nil templates, renders as 200
 	if rec.Code != http.StatusOK {
@@ -110,6 +153,32 @@ func TestHomeHandler_EmptyStateWhenNoProjects(t *testing.T) {
 	}
 }
 
+func TestRender_UsesTemplateReloader(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+	version := "first"
+	h.SetTemplateReloader(func() (*template.Template, error) {
+		return template.New("empty.html").Parse(version)
+	})
+
+	for _, want := range []string{"first", "second"} {
+		version = want
+		rec := httptest.NewRecorder()
+		h.Handle(h.Home)(rec, httptest.NewRequest("GET", "/", nil))
+		if rec.Body.String() != want {
+			t.Errorf("expected the reloaded template %q, got %q", want, rec.Body.String())
+		}
+	}
+
+	h.SetTemplateReloader(func() (*template.Template, error) {
+		return nil, fmt.Errorf("empty.html: unexpected EOF")
+	})
+	rec := httptest.NewRecorder()
+	h.Handle(h.Home)(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected a parse error to be a 500, got %d", rec.Code)
+	}
+}
+
 func TestKanbanBoardHandler_ShowsAllTasks(t *testing.T) {
 	h, s := setupTestHandlers(t)
 	ctx := context.Background()
@@ -133,13 +202,113 @@ func TestKanbanBoardHandler_ShowsAllTasks(t *testing.T) {
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.KanbanBoard(rec, req)
+	h.Handle(h.KanbanBoard)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
 	}
 }
 
+func TestKanbanBoardHandler_AppliesFilters(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Test", Type: "project"}
+	s.CreateProject(ctx, project)
+	yesterday := time.Now().AddDate(0, 0, -1)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Late report", Notes: "Ask for data", Priority: "high", Status: "todo", DueDate: &yesterday})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Tidy desk", Priority: "low", Status: "todo"})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Urgent fix", Priority: "high", Status: "in_progress"})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Shipped", Priority: "high", Status: "done", CompletedAt: &yesterday})
+
+	get := func(query string) string {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/projects/1?"+query, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", fmt.Sprint(project.ID))
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		h.Handle(h.KanbanBoard)(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		return rec.Body.String()
+	}
+	shows := func(body string, want ...string) {
+		t.Helper()
+		for _, d := range []string{"Late report", "Tidy desk", "Urgent fix", "Shipped"} {
+			if strings.Contains(body, d) != slices.Contains(want, d) {
+				t.Errorf("expected %v on the board, got %q shown: %v", want, d, strings.Contains(body, d))
+			}
+		}
+	}
+
+	body := get("")
+	shows(body, "Late report", "Tidy desk", "Urgent fix", "Shipped")
+	if !strings.Contains(body, "data-can-reorder") {
+		t.Error("expected an unfiltered board to allow dragging")
+	}
+
+	body = get("priority=high")
+	shows(body, "Late report", "Urgent fix", "Shipped")
+	if strings.Contains(body, "data-can-reorder") {
+		t.Error("expected a filtered board not to allow dragging")
+	}
+	shows(get("due=overdue"), "Late report")
+	shows(get("notes=1"), "Late report")
+	shows(get("priority=bogus&due=today"), "Late report", "Tidy desk", "Urgent fix", "Shipped")
+}
+
+func TestKanbanBoardHandler_SortParam(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Test", Type: "project"}
+	s.CreateProject(ctx, project)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Low first", Priority: "low", Status: "todo", SortOrder: 1})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "High second", Priority: "high", Status: "todo", SortOrder: 2})
+
+	get := func(query string) string {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/projects/1?"+query, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", fmt.Sprint(project.ID))
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		h.Handle(h.KanbanBoard)(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		return rec.Body.String()
+	}
+	highFirst := func(body string) bool {
+		return strings.Index(body, "High second") < strings.Index(body, "Low first")
+	}
+
+	body := get("")
+	if highFirst(body) || !strings.Contains(body, "data-can-reorder") {
+		t.Error("expected the manual order with dragging by default")
+	}
+	body = get("sort=priority")
+	if !highFirst(body) || strings.Contains(body, "data-can-reorder") {
+		t.Error("expected priority order without dragging")
+	}
+	if p, _ := s.GetProject(ctx, project.ID); p.SortMode != models.SortManual {
+		t.Errorf("expected viewing a sort not to change the project's, got %q", p.SortMode)
+	}
+
+	s.SetProjectSortMode(ctx, project.ID, models.SortPriority)
+	if body = get(""); !highFirst(body) || strings.Contains(body, "data-can-reorder") {
+		t.Error("expected the project's sort mode without dragging")
+	}
+	if body = get("sort=manual"); highFirst(body) || !strings.Contains(body, "data-can-reorder") {
+		t.Error("expected the manual order with dragging")
+	}
+	if body = get("sort=bogus"); !highFirst(body) {
+		t.Error("expected an unknown sort to fall back to the project's")
+	}
+}
+
 func TestCreateProjectHandler_Success(t *testing.T) {
 	h, _ := setupTestHandlers(t)
 
@@ -152,7 +321,7 @@ func TestCreateProjectHandler_Success(t *testing.T) {
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	rec := httptest.NewRecorder()
 
-	h.CreateProject(rec, req)
+	h.Handle(h.CreateProject)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
@@ -170,7 +339,7 @@ func TestCreateProjectHandler_ValidationError(t *testing.T) {
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	rec := httptest.NewRecorder()
 
-	h.CreateProject(rec, req)
+	h.Handle(h.CreateProject)(rec, req)
 
 	if rec.Code != http.StatusBadRequest {
 		t.Errorf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
@@ -197,13 +366,50 @@ func TestUpdateProjectHandler_Success(t *testing.T) {
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.UpdateProject(rec, req)
+	h.Handle(h.UpdateProject)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
 	}
 }
 
+func TestUpdateProjectHandler_Checklist(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Morning", Type: "project"}
+	s.CreateProject(ctx, project)
+
+	for _, tt := range []struct {
+		reset, hour string
+		want        int
+	}{
+		{"daily", "6", http.StatusOK},
+		{"daily", "24", http.StatusBadRequest},
+		{"hourly", "6", http.StatusBadRequest},
+	} {
+		form := url.Values{}
+		form.Set("name", "Morning")
+		form.Set("type", "project")
+		form.Set("checklist_reset", tt.reset)
+		form.Set("checklist_hour", tt.hour)
+
+		req := httptest.NewRequest("PUT", "/api/projects/1", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.Handle(h.UpdateProject)(rec, withIDParam(req, project.ID))
+
+		if rec.Code != tt.want {
+			t.Errorf("%s at %s: expected status %d, got %d", tt.reset, tt.hour, tt.want, rec.Code)
+		}
+	}
+
+	updated, _ := s.GetProject(ctx, project.ID)
+	if updated.ChecklistReset != models.ChecklistDaily || updated.ChecklistHour != 6 {
+		t.Errorf("expected a daily checklist at 6, got %q at %d", updated.ChecklistReset, updated.ChecklistHour)
+	}
+}
+
 func TestUpdateProjectHandler_CanChangeToCategoryAndSetDescription(t *testing.T) {
 	h, s := setupTestHandlers(t)
 	ctx := context.Background()
@@ -226,7 +432,7 @@ func TestUpdateProjectHandler_CanChangeToCategoryAndSetDescription(t *testing.T)
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.UpdateProject(rec, req)
+	h.Handle(h.UpdateProject)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
@@ -248,6 +454,58 @@ func TestUpdateProjectHandler_CanChangeToCategoryAndSetDescription(t *testing.T)
 	}
 }
 
+func TestUpdateProjectHeaderHandler(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Garden", Type: "project", Description: "Veg patch", TargetDate: parseDate("2026-03-01")}
+	s.CreateProject(ctx, project)
+
+	req := withIDParam(httptest.NewRequest("GET", "/", nil), project.ID)
+	rec := httptest.NewRecorder()
+	h.Handle(h.GetProjectHeaderForm)(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `value="Garden"`) {
+		t.Fatalf("expected the inline form, got %d: %s", rec.Code, rec.Body)
+	}
+
+	send := func(form url.Values) *httptest.ResponseRecorder {
+		t.Helper()
+		req := withIDParam(httptest.NewRequest("PUT", "/", strings.NewReader(form.Encode())), project.ID)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("HX-Request", "true")
+		rec := httptest.NewRecorder()
+		h.Handle(h.UpdateProjectHeader)(rec, req)
+		return rec
+	}
+
+	if rec := send(url.Values{"name": {"  "}}); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a blank name, got %d", rec.Code)
+	}
+
+	rec = send(url.Values{"name": {" Allotment "}, "description": {"Beans and leeks"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`id="project-header-` + strconv.FormatInt(project.ID, 10) + `"`,
+		"Beans and leeks",
+		`id="sidebar-project-` + strconv.FormatInt(project.ID, 10) + `" hx-swap-oob="innerHTML"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected the response to contain %q, got %s", want, body)
+		}
+	}
+
+	updated, _ := s.GetProject(ctx, project.ID)
+	if updated.Name != "Allotment" || updated.Description != "Beans and leeks" {
+		t.Errorf("expected the name and description to change, got %q, %q", updated.Name, updated.Description)
+	}
+	if updated.TargetDate == nil {
+		t.Error("expected the target date to be left alone")
+	}
+}
+
 func TestDeleteProjectHandler_Success(t *testing.T) {
 	h, s := setupTestHandlers(t)
 	ctx := context.Background()
@@ -262,7 +520,7 @@ func TestDeleteProjectHandler_Success(t *testing.T) {
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.DeleteProject(rec, req)
+	h.Handle(h.DeleteProject)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
@@ -288,7 +546,7 @@ func TestCompleteProjectHandler_Success(t *testing.T) {
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.CompleteProject(rec, req)
+	h.Handle(h.CompleteProject)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
@@ -318,7 +576,7 @@ func TestReopenProjectHandler_Success(t *testing.T) {
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.ReopenProject(rec, req)
+	h.Handle(h.ReopenProject)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
@@ -333,6 +591,51 @@ func TestReopenProjectHandler_Success(t *testing.T) {
 	}
 }
 
+func TestSetProjectSortModeHandler(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Sorted", Type: "project"}
+	s.CreateProject(ctx, project)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Low first", Priority: "low", Status: "todo"})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "High second", Priority: "high", Status: "todo"})
+
+	setMode := func(mode string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/projects/1/sort", strings.NewReader("sort_mode="+mode))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "1")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		h.Handle(h.SetProjectSortMode)(rec, req)
+		return rec
+	}
+
+	if rec := setMode("shuffle"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for an unknown mode, got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	rec := setMode(models.SortPriority)
+	if rec.Code != http.StatusOK || rec.Header().Get("HX-Refresh") != "true" {
+		t.Fatalf("expected a refresh, got %d %v", rec.Code, rec.Header())
+	}
+
+	req := httptest.NewRequest("GET", "/projects/1", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec = httptest.NewRecorder()
+	h.Handle(h.KanbanBoard)(rec, req)
+
+	body := rec.Body.String()
+	if !strings.Contains(body, `<option value="priority" selected>`) {
+		t.Error("expected the selector to show the priority mode")
+	}
+	if strings.Index(body, "High second") > strings.Index(body, "Low first") {
+		t.Error("expected the high-priority task first")
+	}
+}
+
 func TestReorderProjectsHandler_Success(t *testing.T) {
 	h, s := setupTestHandlers(t)
 	ctx := context.Background()
@@ -347,7 +650,7 @@ func TestReorderProjectsHandler_Success(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	h.ReorderProjects(rec, req)
+	h.Handle(h.ReorderProjects)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
@@ -378,7 +681,7 @@ func TestCreateTaskHandler_Success(t *testing.T) {
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.CreateTask(rec, req)
+	h.Handle(h.CreateTask)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
@@ -401,7 +704,7 @@ func TestCreateTaskHandler_SuccessWithProjectFromForm(t *testing.T) {
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	rec := httptest.NewRecorder()
 
-	h.CreateTask(rec, req)
+	h.Handle(h.CreateTask)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
@@ -429,7 +732,7 @@ func TestUpdateTaskHandler_Success(t *testing.T) {
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.UpdateTask(rec, req)
+	h.Handle(h.UpdateTask)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
@@ -452,7 +755,7 @@ func TestDeleteTaskHandler_Success(t *testing.T) {
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.DeleteTask(rec, req)
+	h.Handle(h.DeleteTask)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
@@ -464,6 +767,64 @@ func TestDeleteTaskHandler_Success(t *testing.T) {
 	}
 }
 
+func TestDeleteTaskCanBeUndone(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Call plumber", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, task)
+
+	undo := func(token string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/api/tasks/undo", strings.NewReader("token="+token))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("HX-Request", "true")
+		rec := httptest.NewRecorder()
+		h.Handle(h.UndoDeleteTask)(rec, req)
+		return rec
+	}
+
+	req := withIDParam(httptest.NewRequest("DELETE", "/", nil), task.ID)
+	req.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	h.Handle(h.DeleteTask)(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	m := regexp.MustCompile(`name="token" value="([0-9a-f]+)"`).FindStringSubmatch(rec.Body.String())
+	if m == nil || !strings.Contains(rec.Body.String(), `class="undo-toast"`) {
+		t.Fatalf("expected an undo toast with a token, got %s", rec.Body)
+	}
+	if _, err := s.GetTask(ctx, task.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected the deleted task to be hidden, got %v", err)
+	}
+
+	if rec := undo("not-a-token"); rec.Code != http.StatusGone {
+		t.Errorf("expected 410 for an unknown token, got %d", rec.Code)
+	}
+	rec = undo(m[1])
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), `title="1 open">1</span>`) {
+		t.Errorf("expected the sidebar count to be refreshed, got %s", rec.Body)
+	}
+	if _, err := s.GetTask(ctx, task.ID); err != nil {
+		t.Errorf("expected the task to be back, got %v", err)
+	}
+	if rec := undo(m[1]); rec.Code != http.StatusGone {
+		t.Errorf("expected 410 undoing twice, got %d", rec.Code)
+	}
+
+	// Once the window has passed, the undo is refused.
+	s.SoftDeleteTask(ctx, task.ID, "late", time.Now().Add(-models.TaskUndoWindow-time.Second))
+	if rec := undo("late"); rec.Code != http.StatusGone {
+		t.Errorf("expected 410 after the undo window, got %d", rec.Code)
+	}
+}
+
 func TestToggleTaskHandler_Success(t *testing.T) {
 	h, s := setupTestHandlers(t)
 	ctx := context.Background()
@@ -480,7 +841,7 @@ func TestToggleTaskHandler_Success(t *testing.T) {
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.ToggleTask(rec, req)
+	h.Handle(h.ToggleTask)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
@@ -513,7 +874,7 @@ func TestReorderTasksHandler_Success(t *testing.T) {
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.ReorderTasks(rec, req)
+	h.Handle(h.ReorderTasks)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
@@ -532,7 +893,7 @@ func TestReorderProjectsHandler_InvalidJSON(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	h.ReorderProjects(rec, req)
+	h.Handle(h.ReorderProjects)(rec, req)
 
 	if rec.Code != http.StatusBadRequest {
 		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
@@ -554,7 +915,7 @@ func TestUpdateTaskHandler_NotFound(t *testing.T) {
 	rctx.URLParams.Add("id", "999")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.UpdateTask(rec, req)
+	h.Handle(h.UpdateTask)(rec, req)
 
 	if rec.Code != http.StatusNotFound {
 		t.Fatalf("expected status %d, got %d", http.StatusNotFound, rec.Code)
@@ -571,7 +932,7 @@ func TestDeleteProjectHandler_NotFound(t *testing.T) {
 	rctx.URLParams.Add("id", "999")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.DeleteProject(rec, req)
+	h.Handle(h.DeleteProject)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected status %d for idempotent delete, got %d", http.StatusOK, rec.Code)
@@ -596,7 +957,7 @@ func TestMoveTaskHandler_Success(t *testing.T) {
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.MoveTask(rec, req)
+	h.Handle(h.MoveTask)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
@@ -629,7 +990,7 @@ func TestMoveTaskHandler_ToDone(t *testing.T) {
 	rctx.URLParams.Add("id", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.MoveTask(rec, req)
+	h.Handle(h.MoveTask)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
@@ -653,7 +1014,7 @@ func TestArchiveHandler_RedirectsToCompletedTasks(t *testing.T) {
 	req := httptest.NewRequest("GET", "/archive", nil)
 	rec := httptest.NewRecorder()
 
-	h.Archive(rec, req)
+	h.Handle(h.Archive)(rec, req)
 
 	if rec.Code != http.StatusFound {
 		t.Fatalf("expected %d, got %d", http.StatusFound, rec.Code)
@@ -705,7 +1066,7 @@ func TestCompletedTasksHandler_IncludesOnlyActiveProjectsWithOldDoneTasks(t *tes
 
 	req := httptest.NewRequest("GET", "/archive/tasks", nil)
 	rec := httptest.NewRecorder()
-	h.CompletedTasks(rec, req)
+	h.Handle(h.CompletedTasks)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
@@ -723,6 +1084,85 @@ func TestCompletedTasksHandler_IncludesOnlyActiveProjectsWithOldDoneTasks(t *tes
 	}
 }
 
+func TestCompletedTasksHandler_LoadsMoreInPages(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Admin", Type: "project"}
+	if err := s.CreateProject(ctx, project); err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	total := completedPageSize + 5
+	for i := 0; i < total; i++ {
+		task := &models.Task{ProjectID: project.ID, Description: fmt.Sprintf("Done %d", i), Priority: "medium", Status: "todo"}
+		if err := s.CreateTask(ctx, task); err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		if err := s.MoveTaskToStatus(ctx, task.ID, "done", i); err != nil {
+			t.Fatalf("MoveTaskToStatus: %v", err)
+		}
+		completed := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i).Format("2006-01-02")
+		if _, err := s.DB().ExecContext(ctx, `UPDATE tasks SET completed_at = ? WHERE id = ?`, completed, task.ID); err != nil {
+			t.Fatalf("set completed_at: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/archive/tasks?from=2025-01-01&to=2025-12-31", nil)
+	rec := httptest.NewRecorder()
+	h.Handle(h.CompletedTasks)(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	body := rec.Body.String()
+	if got := strings.Count(body, `class="archive-task-item`); got != completedPageSize {
+		t.Fatalf("expected %d tasks on the first page, got %d", completedPageSize, got)
+	}
+	if !strings.Contains(body, fmt.Sprintf("%d tasks", total)) {
+		t.Fatalf("expected project summary to count all %d tasks", total)
+	}
+	moreURL := fmt.Sprintf("/archive/tasks/%d/more?offset=%d&from=2025-01-01&to=2025-12-31", project.ID, completedPageSize)
+	if !strings.Contains(body, moreURL) {
+		t.Fatalf("expected load more link %q in body", moreURL)
+	}
+
+	req = httptest.NewRequest("GET", moreURL, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", strconv.FormatInt(project.ID, 10))
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec = httptest.NewRecorder()
+	h.Handle(h.CompletedTasksMore)(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	body = rec.Body.String()
+	if got := strings.Count(body, `class="archive-task-item`); got != 5 {
+		t.Fatalf("expected 5 tasks on the last page, got %d", got)
+	}
+	if !strings.Contains(body, "Done 0") {
+		t.Fatalf("expected the oldest task on the last page")
+	}
+	if strings.Contains(body, "Load more") {
+		t.Fatalf("did not expect a load more button on the last page")
+	}
+}
+
+func TestCompletedTasksMoreHandler_RejectsBadOffset(t *testing.T) {
+	h, _ := setupTestHandlersWithTemplates(t)
+
+	req := httptest.NewRequest("GET", "/archive/tasks/1/more?offset=-1", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec := httptest.NewRecorder()
+	h.Handle(h.CompletedTasksMore)(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
 func TestCompletedProjectsHandler_ShowsOnlyCompletedProjects(t *testing.T) {
 	h, s := setupTestHandlersWithTemplates(t)
 	ctx := context.Background()
@@ -757,7 +1197,7 @@ func TestCompletedProjectsHandler_ShowsOnlyCompletedProjects(t *testing.T) {
 
 	req := httptest.NewRequest("GET", "/archive/projects", nil)
 	rec := httptest.NewRecorder()
-	h.CompletedProjects(rec, req)
+	h.Handle(h.CompletedProjects)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
@@ -770,6 +1210,9 @@ func TestCompletedProjectsHandler_ShowsOnlyCompletedProjects(t *testing.T) {
 	if strings.Contains(body, fmt.Sprintf(`id="project-%d"`, activeProject.ID)) {
 		t.Fatalf("did not expect active project %q in completed projects cards", activeProject.Name)
 	}
+	if !strings.Contains(body, "0 of 1 tasks done") {
+		t.Errorf("expected the completed project's task counts, got %s", body)
+	}
 }
 
 func TestUpdateTaskHandler_MovesToAnotherProject(t *testing.T) {
@@ -803,7 +1246,7 @@ func TestUpdateTaskHandler_MovesToAnotherProject(t *testing.T) {
 	rctx.URLParams.Add("id", strconv.FormatInt(task.ID, 10))
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.UpdateTask(rec, req)
+	h.Handle(h.UpdateTask)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
@@ -849,7 +1292,7 @@ func TestUpdateTaskHandler_RejectsCompletedProject(t *testing.T) {
 	rctx.URLParams.Add("id", strconv.FormatInt(task.ID, 10))
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
-	h.UpdateTask(rec, req)
+	h.Handle(h.UpdateTask)(rec, req)
 
 	if rec.Code != http.StatusBadRequest {
 		t.Fatalf("expected 400, got %d", rec.Code)
@@ -879,7 +1322,7 @@ func TestListTasksHandler_ReturnsAllTasks(t *testing.T) {
 	req := httptest.NewRequest("GET", "/api/tasks", nil)
 	rec := httptest.NewRecorder()
 
-	h.ListTasks(rec, req)
+	h.Handle(h.ListTasks)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
@@ -930,7 +1373,7 @@ func TestListTasksHandler_FiltersCompletedWithinDays(t *testing.T) {
 	req := httptest.NewRequest("GET", "/api/tasks?completed_within_days=7", nil)
 	rec := httptest.NewRecorder()
 
-	h.ListTasks(rec, req)
+	h.Handle(h.ListTasks)(rec, req)
 
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
@@ -954,9 +1397,3856 @@ func TestListTasksHandler_InvalidCompletedWithinDays(t *testing.T) {
 	req := httptest.NewRequest("GET", "/api/tasks?completed_within_days=abc", nil)
 	rec := httptest.NewRecorder()
 
-	h.ListTasks(rec, req)
+	h.Handle(h.ListTasks)(rec, req)
 
 	if rec.Code != http.StatusBadRequest {
 		t.Fatalf("expected 400, got %d", rec.Code)
 	}
 }
+
+func TestListTasksHandler_Filter(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	p := &models.Project{Name: "Project", Type: "project"}
+	if err := s.CreateProject(ctx, p); err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	due := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	for _, task := range []*models.Task{
+		{ProjectID: p.ID, Description: "Match", Priority: "high", Status: "todo", DueDate: &due},
+		{ProjectID: p.ID, Description: "Low", Priority: "low", Status: "todo", DueDate: &due},
+		{ProjectID: p.ID, Description: "Done", Priority: "high", Status: "done", DueDate: &due},
+	} {
+		if err := s.CreateTask(ctx, task); err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+	}
+
+	filter := url.QueryEscape("completed=false AND priority IN (high,medium) AND due<=2025-07-01")
+	rec := httptest.NewRecorder()
+	h.Handle(h.ListTasks)(rec, httptest.NewRequest("GET", "/api/tasks?filter="+filter, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got []models.Task
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(got) != 1 || got[0].Description != "Match" {
+		t.Fatalf("expected only Match, got %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.ListTasks)(rec, httptest.NewRequest("GET", "/api/tasks?limit=10&filter="+filter, nil))
+	var page TasksPage
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("decode page: %v", err)
+	}
+	if len(page.Tasks) != 1 || page.Tasks[0].Description != "Match" {
+		t.Fatalf("expected only Match on the page, got %+v", page)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.ListTasks)(rec, httptest.NewRequest("GET", "/api/tasks?filter="+url.QueryEscape("prio = high"), nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `unknown field "prio"`) {
+		t.Errorf("expected 400 naming the unknown field, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestListTasksHandler_PagesWithCursor(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	p := &models.Project{Name: "Project", Type: "project"}
+	if err := s.CreateProject(ctx, p); err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := s.CreateTask(ctx, &models.Task{ProjectID: p.ID, Description: fmt.Sprintf("Task %d", i), Priority: "medium", Status: "todo"}); err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+	}
+
+	seen := map[int64]bool{}
+	target := "/api/tasks?limit=2"
+	for pages := 1; ; pages++ {
+		rec := httptest.NewRecorder()
+		h.Handle(h.ListTasks)(rec, httptest.NewRequest("GET", target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var page TasksPage
+		if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		for _, task := range page.Tasks {
+			if seen[task.ID] {
+				t.Fatalf("task %d listed twice", task.ID)
+			}
+			seen[task.ID] = true
+		}
+		if page.NextCursor == "" {
+			if pages != 3 {
+				t.Errorf("expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		if pages == 3 {
+			t.Fatalf("expected the third page to be the last, got cursor %q", page.NextCursor)
+		}
+		target = "/api/tasks?limit=2&cursor=" + page.NextCursor
+	}
+	if len(seen) != 5 {
+		t.Errorf("expected all 5 tasks across pages, got %d", len(seen))
+	}
+
+	for _, target := range []string{
+		"/api/tasks?cursor=not-a-cursor",
+		"/api/tasks?limit=0",
+		"/api/tasks?limit=501",
+		// A sort order cursor doesn't fit the list of completed tasks.
+		"/api/tasks?completed_within_days=7&cursor=" + encodeCursor(&store.Keyset{Key: "3", ID: 1}),
+	} {
+		rec := httptest.NewRecorder()
+		h.Handle(h.ListTasks)(rec, httptest.NewRequest("GET", target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, rec.Code)
+		}
+	}
+}
+
+func TestCreateTaskHandler_BroadcastsRealtimeEvent(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	bus := events.NewBus()
+	hub := realtime.NewHub()
+	bus.Subscribe(hub.HandleEvent)
+	h.SetBus(bus)
+	sub := hub.Subscribe(0)
+
+	project := &models.Project{Name: "Shared", Type: "project"}
+	s.CreateProject(ctx, project)
+
+	form := url.Values{}
+	form.Set("description", "Pair on review")
+	form.Set("priority", "high")
+
+	req := httptest.NewRequest("POST", "/api/projects/1/tasks", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Client-ID", "client-a")
+	rec := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", fmt.Sprintf("%d", project.ID))
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	h.Handle(h.CreateTask)(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	select {
+	case msg := <-sub.C:
+		var ev realtime.Event
+		if err := json.Unmarshal(msg, &ev); err != nil {
+			t.Fatalf("failed to decode event: %v", err)
+		}
+		if ev.Type != "created" || ev.Entity != "task" || ev.ProjectID != project.ID {
+			t.Errorf("unexpected event %+v", ev)
+		}
+		if ev.Origin != "client-a" {
+			t.Errorf("expected origin client-a, got %q", ev.Origin)
+		}
+	default:
+		t.Fatal("expected a realtime event to be broadcast")
+	}
+}
+
+func TestToggleTaskHandler_PublishesCompletedAndReopened(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	bus := events.NewBus()
+	var got []events.Type
+	bus.Subscribe(func(_ context.Context, e events.Event) {
+		got = append(got, e.Type)
+	})
+	h.SetBus(bus)
+
+	project := &models.Project{Name: "Test", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Task", Priority: "medium"}
+	s.CreateTask(ctx, task)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", "/api/tasks/1/toggle", nil)
+		rec := httptest.NewRecorder()
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", fmt.Sprintf("%d", task.ID))
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		h.Handle(h.ToggleTask)(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+	}
+
+	want := []events.Type{events.TaskCompleted, events.TaskReopened}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("expected events %v, got %v", want, got)
+	}
+}
+
+func slackCommandRequest(secret string, form url.Values) *http.Request {
+	body := form.Encode()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req := httptest.NewRequest("POST", "/integrations/slack/command", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", slack.Sign(secret, timestamp, []byte(body)))
+	return req
+}
+
+func TestSlackCommandHandler_AddCreatesInboxTask(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	h.SetSlackSigningSecret("secret")
+	ctx := context.Background()
+
+	req := slackCommandRequest("secret", url.Values{
+		"team_id":     {"T1"},
+		"team_domain": {"acme"},
+		"command":     {"/mytasks"},
+		"text":        {"add Buy milk"},
+	})
+	rec := httptest.NewRecorder()
+
+	h.Handle(h.SlackCommand)(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var msg slack.Message
+	if err := json.NewDecoder(rec.Body).Decode(&msg); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if msg.ResponseType != "ephemeral" || !strings.Contains(msg.Text, "Buy milk") {
+		t.Errorf("unexpected response %+v", msg)
+	}
+
+	projects, _ := s.ListActiveProjects(ctx)
+	if len(projects) != 1 || projects[0].Name != "Inbox" {
+		t.Fatalf("expected an Inbox project to be created, got %+v", projects)
+	}
+	tasks, _ := s.ListTasksByProject(ctx, projects[0].ID, 0)
+	if len(tasks) != 1 || tasks[0].Description != "Buy milk" {
+		t.Errorf("expected task in Inbox, got %+v", tasks)
+	}
+
+	if _, err := s.GetSlackWorkspace(ctx, "T1"); err != nil {
+		t.Errorf("expected workspace to be registered: %v", err)
+	}
+}
+
+func TestSlackCommandHandler_AddUsesWorkspaceProject(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	h.SetSlackSigningSecret("secret")
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, project)
+	s.RegisterSlackWorkspace(ctx, "T1", "acme")
+	ws, _ := s.GetSlackWorkspace(ctx, "T1")
+	ws.ProjectID = &project.ID
+	s.UpdateSlackWorkspace(ctx, ws)
+
+	req := slackCommandRequest("secret", url.Values{"team_id": {"T1"}, "text": {"add Ship release"}})
+	rec := httptest.NewRecorder()
+
+	h.Handle(h.SlackCommand)(rec, req)
+
+	tasks, _ := s.ListTasksByProject(ctx, project.ID, 0)
+	if len(tasks) != 1 {
+		t.Fatalf("expected task in configured project, got %d", len(tasks))
+	}
+}
+
+func TestSlackCommandHandler_TodayListsDueTasks(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	h.SetSlackSigningSecret("secret")
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	today := time.Now()
+	nextWeek := today.AddDate(0, 0, 7)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "low", Status: "todo", DueDate: &today})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Clean garage", Priority: "low", Status: "todo", DueDate: &nextWeek})
+
+	req := slackCommandRequest("secret", url.Values{"team_id": {"T1"}, "text": {"today"}})
+	rec := httptest.NewRecorder()
+
+	h.Handle(h.SlackCommand)(rec, req)
+
+	var msg slack.Message
+	json.NewDecoder(rec.Body).Decode(&msg)
+	if !strings.Contains(msg.Text, "Water plants") {
+		t.Errorf("expected due task in reply, got %q", msg.Text)
+	}
+	if strings.Contains(msg.Text, "Clean garage") {
+		t.Errorf("expected future task to be excluded, got %q", msg.Text)
+	}
+}
+
+func TestSlackCommandHandler_RejectsBadSignature(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	h.SetSlackSigningSecret("secret")
+
+	req := slackCommandRequest("wrong", url.Values{"team_id": {"T1"}, "text": {"add Sneaky"}})
+	rec := httptest.NewRecorder()
+
+	h.Handle(h.SlackCommand)(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", rec.Code)
+	}
+	projects, _ := s.ListProjects(context.Background())
+	if len(projects) != 0 {
+		t.Errorf("expected no projects to be created, got %d", len(projects))
+	}
+}
+
+func TestSlackCommandHandler_DisabledWithoutSecret(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+
+	req := slackCommandRequest("", url.Values{"team_id": {"T1"}, "text": {"today"}})
+	rec := httptest.NewRecorder()
+
+	h.Handle(h.SlackCommand)(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", rec.Code)
+	}
+}
+
+func TestSlackSettingsHandler_Renders(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	s.RegisterSlackWorkspace(context.Background(), "T1", "acme")
+
+	req := httptest.NewRequest("GET", "/settings/slack", nil)
+	rec := httptest.NewRecorder()
+
+	h.Handle(h.SlackSettings)(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "acme") {
+		t.Error("expected workspace name in settings page")
+	}
+}
+
+func inboundEmailRequest(key string, form url.Values) *http.Request {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	token := "abc123"
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp + token))
+	form.Set("timestamp", timestamp)
+	form.Set("token", token)
+	form.Set("signature", hex.EncodeToString(mac.Sum(nil)))
+
+	req := httptest.NewRequest("POST", "/integrations/email/inbound", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestInboundEmailHandler_CreatesInboxTask(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	h.SetInboundEmail(mail.InboundConfig{SigningKey: "key", AllowedSenders: []string{"me@example.com"}})
+	ctx := context.Background()
+
+	req := inboundEmailRequest("key", url.Values{
+		"sender":        {"me@example.com"},
+		"subject":       {"Book dentist"},
+		"body-plain":    {"Call before Friday\n\n-- \nSent from my phone"},
+		"stripped-text": {"Call before Friday"},
+	})
+	rec := httptest.NewRecorder()
+
+	h.Handle(h.InboundEmail)(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	projects, _ := s.ListActiveProjects(ctx)
+	if len(projects) != 1 || projects[0].Name != "Inbox" {
+		t.Fatalf("expected an Inbox project, got %+v", projects)
+	}
+	tasks, _ := s.ListTasksByProject(ctx, projects[0].ID, 0)
+	if len(tasks) != 1 {
+		t.Fatalf("expected 1 task, got %d", len(tasks))
+	}
+	if tasks[0].Description != "Book dentist" || tasks[0].Notes != "Call before Friday" {
+		t.Errorf("unexpected task %+v", tasks[0])
+	}
+}
+
+func TestInboundEmailHandler_RejectsUnknownSender(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	h.SetInboundEmail(mail.InboundConfig{SigningKey: "key", AllowedSenders: []string{"me@example.com"}})
+
+	req := inboundEmailRequest("key", url.Values{"sender": {"spam@example.net"}, "subject": {"Buy now"}})
+	rec := httptest.NewRecorder()
+
+	h.Handle(h.InboundEmail)(rec, req)
+
+	if rec.Code != http.StatusNotAcceptable {
+		t.Errorf("expected status 406, got %d", rec.Code)
+	}
+	projects, _ := s.ListProjects(context.Background())
+	if len(projects) != 0 {
+		t.Errorf("expected no projects to be created, got %d", len(projects))
+	}
+}
+
+func TestInboundEmailHandler_RejectsBadSignature(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+	h.SetInboundEmail(mail.InboundConfig{SigningKey: "key", AllowedSenders: []string{"me@example.com"}})
+
+	req := inboundEmailRequest("wrong", url.Values{"sender": {"me@example.com"}, "subject": {"Hi"}})
+	rec := httptest.NewRecorder()
+
+	h.Handle(h.InboundEmail)(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", rec.Code)
+	}
+}
+
+func TestImportHandler_TaskPaperText(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	form := url.Values{
+		"format": {"taskpaper"},
+		"text":   {"Garden:\n\t- Plant bulbs @due(2025-10-01) @high\n\t- Rake leaves\n"},
+	}
+	req := httptest.NewRequest("POST", "/import", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+
+	h.Handle(h.Import)(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "Imported 2 tasks into 1 new project") {
+		t.Errorf("expected import summary in response")
+	}
+
+	projects, _ := s.ListActiveProjects(ctx)
+	if len(projects) != 1 || projects[0].Name != "Garden" {
+		t.Fatalf("expected Garden project, got %+v", projects)
+	}
+	tasks, _ := s.ListTasksByProject(ctx, projects[0].ID, 0)
+	if len(tasks) != 2 {
+		t.Errorf("expected 2 tasks, got %d", len(tasks))
+	}
+}
+
+func TestImportHandler_RequiresInput(t *testing.T) {
+	h, _ := setupTestHandlersWithTemplates(t)
+
+	form := url.Values{"format": {"taskpaper"}}
+	req := httptest.NewRequest("POST", "/import", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+
+	h.Handle(h.Import)(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func TestExportTaskwarriorHandler(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, project)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Ship it", Priority: "high", Status: "todo", Tags: []string{"release"}})
+
+	req := httptest.NewRequest("GET", "/export/taskwarrior.json", nil)
+	rec := httptest.NewRecorder()
+
+	h.Handle(h.ExportTaskwarrior)(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var exported []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &exported); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(exported) != 1 || exported[0]["description"] != "Ship it" || exported[0]["priority"] != "H" {
+		t.Errorf("unexpected export %v", exported)
+	}
+}
+
+func TestExportTaskwarriorHandler_StreamsPages(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.ExportTaskwarrior)(rec, httptest.NewRequest("GET", "/export/taskwarrior.json", nil))
+	if strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("expected an empty array, got %q", rec.Body.String())
+	}
+
+	// More than one page from the store, and more than one flush.
+	project := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, project)
+	tasks := make([]*models.Task, 1201)
+	for i := range tasks {
+		tasks[i] = &models.Task{ProjectID: project.ID, Description: fmt.Sprintf("Task %d", i), Priority: "medium", Status: "todo"}
+	}
+	if err := s.CreateTasks(ctx, tasks); err != nil {
+		t.Fatalf("CreateTasks failed: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.ExportTaskwarrior)(rec, httptest.NewRequest("GET", "/export/taskwarrior.json", nil))
+	if !rec.Flushed {
+		t.Error("expected the export to be flushed as it was written")
+	}
+	var exported []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &exported); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(exported) != len(tasks) || exported[1200]["description"] != "Task 1200" {
+		t.Errorf("expected all %d tasks in order, got %d", len(tasks), len(exported))
+	}
+}
+
+func TestImportMicrosoftToDoHandler_UsesGraph(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	graph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/me/todo/lists":
+			w.Write([]byte(`{"value":[{"id":"L1","displayName":"Chores"}]}`))
+		case "/me/todo/lists/L1/tasks":
+			w.Write([]byte(`{"value":[{"id":"T1","title":"Vacuum","status":"notStarted","importance":"low"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer graph.Close()
+	h.client = graph.Client()
+	h.graphBaseURL = graph.URL
+
+	form := url.Values{"token": {"abc"}}
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", "/import/mstodo", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+
+		h.Handle(h.ImportMicrosoftToDo)(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	projects, _ := s.ListActiveProjects(ctx)
+	if len(projects) != 1 || projects[0].Name != "Chores" {
+		t.Fatalf("expected Chores project, got %+v", projects)
+	}
+	tasks, _ := s.ListTasksByProject(ctx, projects[0].ID, 0)
+	if len(tasks) != 1 || tasks[0].Priority != "low" {
+		t.Errorf("expected one deduplicated task, got %+v", tasks)
+	}
+}
+
+func TestGoogleSettingsHandler_Renders(t *testing.T) {
+	h, _ := setupTestHandlersWithTemplates(t)
+	h.SetGoogleTasks(gtasks.OAuthConfig{ClientID: "id", ClientSecret: "secret"})
+
+	req := httptest.NewRequest("GET", "/settings/google", nil)
+	rec := httptest.NewRecorder()
+
+	h.Handle(h.GoogleSettings)(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "/integrations/google/connect") {
+		t.Error("expected connect link on settings page")
+	}
+}
+
+func TestGoogleOAuthFlow(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+
+	google := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.FormValue("code") != "good-code" || r.FormValue("redirect_uri") != "http://example.com/integrations/google/callback" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token":"a1","refresh_token":"r1","expires_in":3600}`))
+	}))
+	defer google.Close()
+	h.client = google.Client()
+	h.SetGoogleTasks(gtasks.OAuthConfig{ClientID: "id", ClientSecret: "secret", TokenURL: google.URL})
+
+	req := httptest.NewRequest("GET", "/integrations/google/connect", nil)
+	rec := httptest.NewRecorder()
+	h.Handle(h.ConnectGoogle)(rec, req)
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected redirect, got %d", rec.Code)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != googleStateCookie {
+		t.Fatalf("expected state cookie, got %+v", cookies)
+	}
+	location, _ := url.Parse(rec.Header().Get("Location"))
+	state := location.Query().Get("state")
+	if state != cookies[0].Value {
+		t.Fatalf("expected state %q in redirect, got %q", cookies[0].Value, state)
+	}
+
+	t.Run("rejects mismatched state", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/integrations/google/callback?code=good-code&state=forged", nil)
+		req.AddCookie(cookies[0])
+		rec := httptest.NewRecorder()
+		h.Handle(h.GoogleCallback)(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rec.Code)
+		}
+	})
+
+	t.Run("stores tokens", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/integrations/google/callback?code=good-code&state="+state, nil)
+		req.AddCookie(cookies[0])
+		rec := httptest.NewRecorder()
+		h.Handle(h.GoogleCallback)(rec, req)
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("expected status 303, got %d: %s", rec.Code, rec.Body.String())
+		}
+		account, err := s.GetGoogleTasksAccount(context.Background())
+		if err != nil || account.RefreshToken != "r1" {
+			t.Errorf("expected stored refresh token, got %+v (%v)", account, err)
+		}
+	})
+}
+
+func TestGoogleCalendarOAuthFlow(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	google := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.FormValue("code") != "good-code" || r.FormValue("redirect_uri") != "http://example.com/integrations/google/calendar/callback" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token":"a1","refresh_token":"r1","expires_in":3600}`))
+	}))
+	defer google.Close()
+	h.client = google.Client()
+	h.SetGoogleCalendar(gtasks.OAuthConfig{ClientID: "id", ClientSecret: "secret", TokenURL: google.URL, Scope: gcal.Scope})
+
+	req := httptest.NewRequest("GET", "/integrations/google/calendar/connect", nil)
+	rec := httptest.NewRecorder()
+	h.Handle(h.ConnectGoogleCalendar)(rec, req)
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected redirect, got %d", rec.Code)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != calendarStateCookie {
+		t.Fatalf("expected state cookie, got %+v", cookies)
+	}
+	location, _ := url.Parse(rec.Header().Get("Location"))
+	if location.Query().Get("scope") != gcal.Scope {
+		t.Errorf("expected the calendar scope, got %q", location.Query().Get("scope"))
+	}
+
+	req = httptest.NewRequest("GET", "/integrations/google/calendar/callback?code=good-code&state="+location.Query().Get("state"), nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	h.Handle(h.GoogleCalendarCallback)(rec, req)
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected status 303, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if account, err := s.GetGoogleCalendarAccount(ctx); err != nil || account.RefreshToken != "r1" {
+		t.Fatalf("expected stored refresh token, got %+v (%v)", account, err)
+	}
+	if _, err := s.GetGoogleTasksAccount(ctx); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("connecting the calendar connected Google Tasks: %v", err)
+	}
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Renew passport", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, task)
+	now := time.Now()
+	s.SaveCalendarEvent(ctx, &models.CalendarEvent{TaskID: task.ID, EventID: "ev1", DueDate: now, Conflict: "Changed to Mon, Mar 2 here and to Tue, Mar 3 in Google Calendar; kept Tue, Mar 3.", ConflictAt: &now})
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.CalendarSettings)(rec, httptest.NewRequest("GET", "/settings/calendar", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "Renew passport") || !strings.Contains(body, "kept Tue, Mar 3.") {
+		t.Errorf("expected the conflict listed, got %d: %s", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.DisconnectGoogleCalendar)(rec, httptest.NewRequest("POST", "/settings/calendar/disconnect", nil))
+	if _, err := s.GetGoogleCalendarAccount(ctx); rec.Code != http.StatusSeeOther || !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected the account removed, got %d (%v)", rec.Code, err)
+	}
+}
+
+func TestSaveGitHubLinkHandler_VerifiesToken(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+	project := &models.Project{Name: "App", Type: "project"}
+	s.CreateProject(ctx, project)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user" || r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	defer api.Close()
+	h.client = api.Client()
+	h.githubBaseURL = api.URL
+
+	tests := []struct {
+		name       string
+		repo       string
+		token      string
+		wantStatus int
+	}{
+		{"bad repo", "not a repo", "good", http.StatusBadRequest},
+		{"bad token", "octo/app", "bad", http.StatusBadRequest},
+		{"linked", "https://github.com/octo/app", "good", http.StatusSeeOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"project_id": {strconv.FormatInt(project.ID, 10)}, "repo": {tt.repo}, "token": {tt.token}}
+			req := httptest.NewRequest("POST", "/settings/github", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+
+			h.Handle(h.SaveGitHubLink)(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	link, err := s.GetGitHubLink(ctx, project.ID)
+	if err != nil || link.Repo != "octo/app" || link.Login != "octocat" || link.WebhookSecret == "" {
+		t.Errorf("unexpected link %+v (%v)", link, err)
+	}
+}
+
+func TestGitHubWebhookHandler(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+	h.SetGitHub(github.NewSyncer(s, nil))
+
+	project := &models.Project{Name: "App", Type: "project"}
+	s.CreateProject(ctx, project)
+	s.SaveGitHubLink(ctx, &models.GitHubLink{ProjectID: project.ID, Repo: "octo/app", Token: "t", Login: "me", WebhookSecret: "shh"})
+
+	body := `{"action":"assigned","issue":{"number":9,"title":"From webhook","state":"open","assignees":[{"login":"me"}]},"repository":{"full_name":"octo/app"}}`
+	tests := []struct {
+		name       string
+		secret     string
+		wantStatus int
+	}{
+		{"bad signature", "wrong", http.StatusUnauthorized},
+		{"valid", "shh", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/integrations/github/webhook", strings.NewReader(body))
+			req.Header.Set("X-GitHub-Event", "issues")
+			req.Header.Set("X-Hub-Signature-256", github.Sign(tt.secret, []byte(body)))
+			rec := httptest.NewRecorder()
+
+			h.Handle(h.GitHubWebhook)(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+		})
+	}
+
+	tasks, _ := s.ListTasksByProject(ctx, project.ID, 0)
+	if len(tasks) != 1 || tasks[0].Description != "From webhook" {
+		t.Fatalf("expected task from webhook, got %+v", tasks)
+	}
+}
+
+func TestKanbanBoardHandler_LinksGitHubIssues(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "App", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Fix login", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, task)
+	s.SetTaskExternalRef(ctx, github.Source, github.IssueRef("octo/app", 3), task.ID)
+
+	req := httptest.NewRequest("GET", "/projects/1", nil)
+	rec := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", strconv.FormatInt(project.ID, 10))
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	h.Handle(h.KanbanBoard)(rec, req)
+
+	if !strings.Contains(rec.Body.String(), `href="https://github.com/octo/app/issues/3"`) {
+		t.Error("expected a link back to the issue")
+	}
+}
+
+func TestHandle_ServerErrorNamesRequestID(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+	rec := httptest.NewRecorder()
+	rec.Header().Set("X-Request-ID", "abc123")
+
+	h.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		return fmt.Errorf("database is locked")
+	})(rec, httptest.NewRequest("GET", "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); body != "internal server error (request abc123)" {
+		t.Errorf("expected the request ID in the message, got %q", body)
+	}
+}
+
+func TestHandle_MapsErrorsToStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+		body string
+	}{
+		{badRequest("invalid task id"), http.StatusBadRequest, "invalid task id"},
+		{withStatus(http.StatusUnauthorized, "invalid token"), http.StatusUnauthorized, "invalid token"},
+		{fmt.Errorf("task 7: %w", store.ErrNotFound), http.StatusNotFound, "task 7: not found"},
+		{(&models.Task{}).Validate(), http.StatusBadRequest, "description is required"},
+		{fmt.Errorf("database is locked"), http.StatusInternalServerError, "internal server error"},
+	}
+	h, _ := setupTestHandlers(t)
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.Handle(func(w http.ResponseWriter, r *http.Request) error {
+			return tt.err
+		})(rec, httptest.NewRequest("GET", "/", nil))
+
+		if rec.Code != tt.code || rec.Body.String() != tt.body {
+			t.Errorf("%v: got %d %q, want %d %q", tt.err, rec.Code, rec.Body.String(), tt.code, tt.body)
+		}
+	}
+}
+
+func TestHandle_RendersErrorPageForBrowsers(t *testing.T) {
+	h, _ := setupTestHandlersWithTemplates(t)
+
+	req := httptest.NewRequest("GET", "/no/such/page", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	rec := httptest.NewRecorder()
+	h.Handle(h.NotFound)(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("expected an HTML page, got %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"<title>Page not found - My Tasks</title>", `href="/"`, "Back to My Tasks"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in error page:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "Request ID") {
+		t.Error("a 404 should not name a request ID")
+	}
+}
+
+func TestHandle_ServerErrorPageNamesRequestID(t *testing.T) {
+	h, _ := setupTestHandlersWithTemplates(t)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html")
+	rec := httptest.NewRecorder()
+	rec.Header().Set("X-Request-ID", "abc123")
+	h.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		return fmt.Errorf("database is locked")
+	})(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "Something went wrong") || !strings.Contains(body, "<code>abc123</code>") {
+		t.Errorf("expected the request ID on the error page:\n%s", body)
+	}
+	if strings.Contains(body, "database is locked") {
+		t.Error("the error page should not show the underlying error")
+	}
+}
+
+func TestHandle_SendsErrorFragmentToHTMX(t *testing.T) {
+	h, _ := setupTestHandlersWithTemplates(t)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	h.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		return badRequest("invalid task id")
+	})(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `class="error-toast"`) || !strings.Contains(body, "invalid task id") {
+		t.Errorf("expected an error fragment, got:\n%s", body)
+	}
+	if strings.Contains(body, "<html") {
+		t.Error("htmx requests should get a fragment, not a page")
+	}
+}
+
+func TestParseISOWeek(t *testing.T) {
+	tests := []struct {
+		week    string
+		want    string
+		wantErr bool
+	}{
+		{"2025-W01", "2024-12-30", false},
+		{"2026-W53", "2026-12-28", false},
+		{"2025-W53", "", true},
+		{"2025-W00", "", true},
+		{"2025-7", "", true},
+	}
+	for _, tt := range tests {
+		got, err := parseISOWeek(tt.week)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseISOWeek(%q) error = %v", tt.week, err)
+			continue
+		}
+		if err == nil && got.Format("2006-01-02") != tt.want {
+			t.Errorf("parseISOWeek(%q) = %s, want %s", tt.week, got.Format("2006-01-02"), tt.want)
+		}
+	}
+}
+
+func TestAgendaHandler_GroupsByDay(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	wednesday := time.Date(2025, time.March, 5, 0, 0, 0, 0, time.UTC)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "low", Status: "todo", DueDate: &wednesday})
+
+	req := httptest.NewRequest("GET", "/agenda?week=2025-W10", nil)
+	rec := httptest.NewRecorder()
+
+	h.Handle(h.Agenda)(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	wed := strings.Index(body, "Wednesday")
+	task := strings.Index(body, "Water plants")
+	thu := strings.Index(body, "Thursday")
+	if wed < 0 || task < wed || thu < task {
+		t.Error("expected task to be listed under Wednesday")
+	}
+	if !strings.Contains(body, "week=2025-W09") || !strings.Contains(body, "week=2025-W11") {
+		t.Error("expected links to the previous and next weeks")
+	}
+
+	req = httptest.NewRequest("GET", "/agenda?week=2025-13", nil)
+	rec = httptest.NewRecorder()
+	h.Handle(h.Agenda)(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid week, got %d", rec.Code)
+	}
+}
+
+func TestAgendaHandler_WeekStartAndDateFormat(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	settings := models.DefaultSettings()
+	settings.WeekStart = time.Sunday
+	settings.DateFormat = "2006-01-02"
+	s.SaveSettings(ctx, &settings)
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	sunday := time.Date(2025, time.March, 2, 0, 0, 0, 0, time.UTC)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "low", Status: "todo", DueDate: &sunday})
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.Agenda)(rec, httptest.NewRequest("GET", "/agenda?week=2025-W10", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "Week of 2025-03-02") {
+		t.Error("expected the week to start on Sunday in the chosen date format")
+	}
+	sun := strings.Index(body, "Sunday")
+	task := strings.Index(body, "Water plants")
+	mon := strings.Index(body, "Monday")
+	if sun < 0 || task < sun || mon < task {
+		t.Error("expected the Sunday task to open the week")
+	}
+	if !strings.Contains(body, "week=2025-W09") || !strings.Contains(body, "week=2025-W11") {
+		t.Error("expected links to the previous and next ISO weeks")
+	}
+}
+
+func TestAllTasksHandler_AppliesFilters(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Errands", Type: "project"}
+	s.CreateProject(ctx, project)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Buy stamps", Priority: "high", Status: "todo"})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Return books", Priority: "low", Status: "todo"})
+
+	req := httptest.NewRequest("GET", "/tasks?priority=high&sort=bogus", nil)
+	rec := httptest.NewRecorder()
+
+	h.Handle(h.AllTasks)(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "Buy stamps") || strings.Contains(body, "Return books") {
+		t.Error("expected only high priority tasks")
+	}
+	if !strings.Contains(body, "Errands") {
+		t.Error("expected project name in list")
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.AllTasks)(rec, httptest.NewRequest("GET", "/tasks?filter="+url.QueryEscape("description ~ books"), nil))
+	if body := rec.Body.String(); !strings.Contains(body, "Return books") || strings.Contains(body, "Buy stamps") {
+		t.Error("expected the filter expression to keep only Return books")
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.AllTasks)(rec, httptest.NewRequest("GET", "/tasks?filter="+url.QueryEscape("priority = urgent"), nil))
+	body = rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "invalid filter") || !strings.Contains(body, "Buy stamps") {
+		t.Errorf("expected the list unfiltered with the filter's error, got %d", rec.Code)
+	}
+}
+
+func TestSavedFilters(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Errands", Type: "project"}
+	s.CreateProject(ctx, project)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Buy stamps", Priority: "high", Status: "todo"})
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/tasks/filters", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.Handle(h.SaveFilter)(rec, req)
+		return rec
+	}
+	if rec := post(url.Values{"name": {"Urgent"}, "filter": {"priority = urgent"}}); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid filter, got %d", rec.Code)
+	}
+	if rec := post(url.Values{"name": {" "}, "filter": {"priority = high"}}); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a name, got %d", rec.Code)
+	}
+	rec := post(url.Values{"name": {"Urgent"}, "filter": {"priority = high"}})
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/tasks?filter=priority+%3D+high" {
+		t.Fatalf("expected a redirect to the filtered list, got %d to %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.AllTasks)(rec, httptest.NewRequest("GET", "/tasks?filter="+url.QueryEscape("priority = high"), nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `href="/tasks?filter=priority%20%3d%20high"`) || !strings.Contains(body, "Urgent") {
+		t.Errorf("expected the saved filter in the picker")
+	}
+
+	filters, _ := s.ListSavedFilters(ctx)
+	if len(filters) != 1 {
+		t.Fatalf("expected one saved filter, got %+v", filters)
+	}
+	id := strconv.FormatInt(filters[0].ID, 10)
+	req := httptest.NewRequest("POST", "/tasks/filters/"+id+"/delete", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec = httptest.NewRecorder()
+	h.Handle(h.DeleteSavedFilter)(rec, req)
+	if filters, _ := s.ListSavedFilters(ctx); rec.Code != http.StatusSeeOther || len(filters) != 0 {
+		t.Errorf("expected the filter deleted, got %d and %+v", rec.Code, filters)
+	}
+}
+
+func TestGroupByDueDay(t *testing.T) {
+	today := time.Date(2024, time.June, 19, 0, 0, 0, 0, time.UTC)
+	due := func(days int) models.Task {
+		d := today.AddDate(0, 0, days)
+		return models.Task{DueDate: &d}
+	}
+
+	groups := groupByDueDay([]models.Task{due(-3), due(-1), due(0), due(1), due(2), due(2), due(200)}, today, nil)
+
+	var labels []string
+	for _, g := range groups {
+		labels = append(labels, fmt.Sprintf("%s:%d", g.Label, len(g.Tasks)))
+	}
+	want := "Overdue:2,Today:1,Tomorrow:1,Fri 21 Jun:2,Sun 5 Jan 2025:1"
+	if strings.Join(labels, ",") != want {
+		t.Errorf("expected %s, got %s", want, strings.Join(labels, ","))
+	}
+	if !groups[0].Overdue || groups[1].Overdue {
+		t.Error("expected only the first group to be flagged overdue")
+	}
+}
+
+func TestUpcomingHandler_RangeAndUndated(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Test", Type: "project"}
+	s.CreateProject(ctx, project)
+	later := time.Now().AddDate(0, 0, 60)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Renew lease", Priority: "medium", Status: "todo", DueDate: &later})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Call bank", Priority: "high", Status: "todo"})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Someday maybe", Priority: "low", Status: "todo"})
+
+	tests := []struct {
+		url      string
+		contains []string
+		excludes []string
+	}{
+		{"/upcoming", nil, []string{"Renew lease", "Call bank"}},
+		{"/upcoming?days=90", []string{"Renew lease"}, []string{"Call bank"}},
+		{"/upcoming?days=90&undated=1", []string{"Renew lease", "No due date", "Call bank"}, []string{"Someday maybe"}},
+		{"/upcoming?days=9999", nil, []string{"Renew lease"}},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.url, nil)
+		rec := httptest.NewRecorder()
+
+		h.Handle(h.Upcoming)(rec, req)
+
+		body := rec.Body.String()
+		for _, s := range tt.contains {
+			if !strings.Contains(body, s) {
+				t.Errorf("%s: expected %q", tt.url, s)
+			}
+		}
+		for _, s := range tt.excludes {
+			if strings.Contains(body, s) {
+				t.Errorf("%s: did not expect %q", tt.url, s)
+			}
+		}
+	}
+}
+
+func TestUpcomingHandler_UrgentFirst(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Test", Type: "project"}
+	s.CreateProject(ctx, project)
+	today := time.Now()
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "low", Status: "todo", DueDate: &today})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Book flights", Priority: "medium", Status: "todo", DueDate: &today})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "File taxes", Priority: "high", Status: "todo", DueDate: &today})
+
+	settings := models.DefaultSettings()
+	settings.EscalateDays = 1
+	s.SaveSettings(ctx, &settings)
+	s.EscalatePriorities(ctx, settings.EscalateDays, today)
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.Upcoming)(rec, httptest.NewRequest("GET", "/upcoming", nil))
+	body := rec.Body.String()
+
+	// Book flights is escalated to high; ties keep the stored order.
+	taxes, flights, plants := strings.Index(body, "File taxes"), strings.Index(body, "Book flights"), strings.Index(body, "Water plants")
+	if taxes < 0 || !(taxes < flights && flights < plants) {
+		t.Errorf("expected tasks due today ordered by urgency, got positions %d, %d, %d", taxes, flights, plants)
+	}
+	if !strings.Contains(body, "Raised from medium") {
+		t.Errorf("expected the escalation to be shown")
+	}
+}
+
+func TestReviewHandler_FindsStaleUndatedAndEmpty(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	work := &models.Project{Name: "Work", Type: "project"}
+	idle := &models.Project{Name: "Idle", Type: "project"}
+	s.CreateProject(ctx, work)
+	s.CreateProject(ctx, idle)
+
+	due := time.Now().AddDate(0, 0, 3)
+	stale := &models.Task{ProjectID: work.ID, Description: "Dusty report", Priority: "low", Status: "todo", DueDate: &due}
+	undated := &models.Task{ProjectID: work.ID, Description: "Plan offsite", Priority: "medium", Status: "todo"}
+	fresh := &models.Task{ProjectID: work.ID, Description: "Ship release", Priority: "high", Status: "todo", DueDate: &due}
+	for _, task := range []*models.Task{stale, undated, fresh} {
+		s.CreateTask(ctx, task)
+	}
+	old := time.Now().AddDate(0, 0, -30)
+	if _, err := s.DB().ExecContext(ctx, `UPDATE tasks SET updated_at = ? WHERE id = ?`, old, stale.ID); err != nil {
+		t.Fatalf("set updated_at: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/review/%d", work.ID), nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", strconv.FormatInt(work.ID, 10))
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec := httptest.NewRecorder()
+	h.Handle(h.ReviewProject)(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"Dusty report", "Plan offsite", "Not reviewed yet", "continue to Idle"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in review page", want)
+		}
+	}
+	if strings.Contains(body, "Ship release") {
+		t.Errorf("did not expect a fresh, dated task in the review")
+	}
+
+	req = httptest.NewRequest("GET", "/review", nil)
+	rec = httptest.NewRecorder()
+	h.Handle(h.Review)(rec, req)
+
+	body = rec.Body.String()
+	if !strings.Contains(body, "review-flag") || !strings.Contains(body, "Idle") {
+		t.Errorf("expected the empty project to be flagged")
+	}
+}
+
+func TestStaleTasksFlagged(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	settings := models.DefaultSettings()
+	settings.StaleDays = 5
+	s.SaveSettings(ctx, &settings)
+
+	project := &models.Project{Name: "Chores", Type: "project"}
+	s.CreateProject(ctx, project)
+	forgotten := &models.Task{ProjectID: project.ID, Description: "Fix gutter", Priority: "low", Status: "todo"}
+	recent := &models.Task{ProjectID: project.ID, Description: "Buy milk", Priority: "low", Status: "todo"}
+	s.CreateTask(ctx, forgotten)
+	s.CreateTask(ctx, recent)
+	s.DB().Exec(`UPDATE tasks SET updated_at = ? WHERE id = ?`, time.Now().AddDate(0, 0, -8), forgotten.ID)
+	s.DB().Exec(`UPDATE tasks SET updated_at = ? WHERE id = ?`, time.Now().AddDate(0, 0, -5), recent.ID)
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.AllTasks)(rec, httptest.NewRequest("GET", "/tasks", nil))
+	body := rec.Body.String()
+	if strings.Count(body, `class="stale-flag"`) != 1 || !strings.Contains(body, "8d old") {
+		t.Errorf("expected only the task untouched for more than 5 days to be flagged")
+	}
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/projects/%d", project.ID), nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", strconv.FormatInt(project.ID, 10))
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec = httptest.NewRecorder()
+	h.Handle(h.KanbanBoard)(rec, req)
+	if body := rec.Body.String(); !strings.Contains(body, "Untouched for 8 days") {
+		t.Errorf("expected the stale flag on the board")
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.Review)(rec, httptest.NewRequest("GET", "/review", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `name="stale" min="1" max="365" value="5"`) {
+		t.Errorf("expected the review to default to the stale setting")
+	}
+}
+
+func TestCompleteProjectReview_RecordsAndAdvances(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	first := &models.Project{Name: "First", Type: "project"}
+	second := &models.Project{Name: "Second", Type: "project"}
+	s.CreateProject(ctx, first)
+	s.CreateProject(ctx, second)
+
+	tests := []struct {
+		project *models.Project
+		url     string
+		want    string
+	}{
+		{first, fmt.Sprintf("/review/%d?stale=30", first.ID), fmt.Sprintf("/review/%d?stale=30", second.ID)},
+		{second, fmt.Sprintf("/review/%d", second.ID), "/review"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", tt.url, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", strconv.FormatInt(tt.project.ID, 10))
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		h.Handle(h.CompleteProjectReview)(rec, req)
+
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("%s: expected %d, got %d", tt.project.Name, http.StatusSeeOther, rec.Code)
+		}
+		if got := rec.Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: expected redirect to %q, got %q", tt.project.Name, tt.want, got)
+		}
+	}
+
+	reviews, err := s.ListLastProjectReviews(ctx)
+	if err != nil {
+		t.Fatalf("ListLastProjectReviews: %v", err)
+	}
+	if len(reviews) != 2 {
+		t.Errorf("expected both projects to be recorded as reviewed, got %v", reviews)
+	}
+}
+
+func TestReviewTaskActions(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Dusty report", Priority: "low", Status: "todo"}
+	s.CreateTask(ctx, task)
+
+	call := func(handler HandlerFunc, form string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", strconv.FormatInt(task.ID, 10))
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		h.Handle(handler)(rec, req)
+		return rec
+	}
+
+	if rec := call(h.SnoozeReviewTask, ""); rec.Code != http.StatusOK {
+		t.Fatalf("snooze: expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	if rec := call(h.ReprioritizeReviewTask, "priority=high"); rec.Code != http.StatusOK {
+		t.Fatalf("reprioritize: expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	if rec := call(h.ReprioritizeReviewTask, "priority=urgent"); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid priority: expected %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	got, err := s.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	wantDue := time.Now().AddDate(0, 0, reviewSnoozeDays).Format("2006-01-02")
+	if got.DueDate == nil || got.DueDate.Format("2006-01-02") != wantDue {
+		t.Errorf("expected due date %s, got %v", wantDue, got.DueDate)
+	}
+	if got.Priority != "high" {
+		t.Errorf("expected priority high, got %q", got.Priority)
+	}
+}
+
+func TestStreakHandler(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "P", Type: "project"}
+	s.CreateProject(ctx, project)
+	for i := 0; i < 2; i++ {
+		task := &models.Task{ProjectID: project.ID, Description: fmt.Sprintf("Task %d", i), Priority: "medium", Status: "todo"}
+		s.CreateTask(ctx, task)
+		if err := s.ToggleTaskComplete(ctx, task.ID); err != nil {
+			t.Fatalf("ToggleTaskComplete: %v", err)
+		}
+	}
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	earlier := &models.Task{ProjectID: project.ID, Description: "Earlier", Priority: "low", Status: "todo"}
+	s.CreateTask(ctx, earlier)
+	s.ToggleTaskComplete(ctx, earlier.ID)
+	if _, err := s.DB().ExecContext(ctx, `UPDATE tasks SET completed_at = ? WHERE id = ?`, yesterday, earlier.ID); err != nil {
+		t.Fatalf("set completed_at: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.Streak)(rec, httptest.NewRequest("GET", "/streak", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"2 days", "Today 2", fmt.Sprintf(`value="%d"`, defaultDailyGoal)} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in streak widget", want)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/streak/goal", strings.NewReader("goal=2"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	h.Handle(h.UpdateDailyGoal)(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "streak-goal met") {
+		t.Errorf("expected the lowered goal to be met")
+	}
+
+	for _, goal := range []string{"0", "abc", "101"} {
+		req := httptest.NewRequest("POST", "/streak/goal", strings.NewReader("goal="+goal))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.Handle(h.UpdateDailyGoal)(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("goal=%s: expected %d, got %d", goal, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestHabitHandlers(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	req := httptest.NewRequest("POST", "/habits", strings.NewReader("name=Floss&cadence=daily"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.Handle(h.CreateHabit)(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	habits, _ := s.ListHabits(ctx)
+	if len(habits) != 1 {
+		t.Fatalf("expected 1 habit, got %d", len(habits))
+	}
+	id := habits[0].ID
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	for _, target := range []string{"/habits/1/checkin", "/habits/1/checkin?day=" + yesterday} {
+		rec := httptest.NewRecorder()
+		h.Handle(h.CheckInHabit)(rec, withIDParam(httptest.NewRequest("POST", target, nil), id))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected %d, got %d: %s", target, http.StatusOK, rec.Code, rec.Body.String())
+		}
+	}
+	rec = httptest.NewRecorder()
+	h.Handle(h.Habits)(rec, httptest.NewRequest("GET", "/habits", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "Floss") || !strings.Contains(body, "2d") {
+		t.Errorf("expected Floss on a 2-day streak, got %s", body)
+	}
+	if n := strings.Count(body, "habit-cell done"); n != 2 {
+		t.Errorf("expected 2 checked cells, got %d", n)
+	}
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	for target, want := range map[string]int{
+		"/habits/1/checkin?day=" + tomorrow: http.StatusBadRequest,
+		"/habits/1/checkin?day=soon":        http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		h.Handle(h.CheckInHabit)(rec, withIDParam(httptest.NewRequest("POST", target, nil), id))
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", target, want, rec.Code)
+		}
+	}
+	rec = httptest.NewRecorder()
+	h.Handle(h.CheckInHabit)(rec, withIDParam(httptest.NewRequest("POST", "/habits/99/checkin", nil), 99))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected %d for a missing habit, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.DeleteHabit)(rec, withIDParam(httptest.NewRequest("DELETE", "/habits/1", nil), id))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	if habits, _ := s.ListHabits(ctx); len(habits) != 0 {
+		t.Errorf("expected the habit to be deleted, got %d", len(habits))
+	}
+}
+
+func TestFocusHandlers(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Write report", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, task)
+
+	req := withIDParam(httptest.NewRequest("POST", "/api/tasks/1/focus", nil), task.ID)
+	req.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	h.Handle(h.StartFocus)(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "data-focus-started") || !strings.Contains(body, "Write report") {
+		t.Errorf("expected the running timer, got %s", body)
+	}
+
+	// Another device loading the page sees the same timer.
+	rec = httptest.NewRecorder()
+	h.Handle(h.FocusTimer)(rec, httptest.NewRequest("GET", "/focus", nil))
+	if !strings.Contains(rec.Body.String(), "Write report") {
+		t.Errorf("expected the timer to survive a reload, got %s", rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	h.Handle(h.TaskDetail)(rec, withIDParam(httptest.NewRequest("GET", "/tasks/1", nil), task.ID))
+	if !strings.Contains(rec.Body.String(), "Stop focusing") {
+		t.Error("expected the task page to offer to stop focusing")
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.StopFocus)(rec, httptest.NewRequest("POST", "/api/focus/stop", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var stopped models.FocusSession
+	if err := json.Unmarshal(rec.Body.Bytes(), &stopped); err != nil || stopped.EndedAt == nil || stopped.TaskID != task.ID {
+		t.Errorf("expected the stopped session as JSON, got %s (%v)", rec.Body.String(), err)
+	}
+	rec = httptest.NewRecorder()
+	h.Handle(h.StopFocus)(rec, httptest.NewRequest("POST", "/api/focus/stop", nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected %d with nothing running, got %d", http.StatusConflict, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.FocusTotals)(rec, httptest.NewRequest("GET", "/api/focus?task_id=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var report FocusReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.Running != nil || len(report.Totals) != 1 || report.Totals[0].TaskID != task.ID {
+		t.Errorf("expected one total for today and nothing running, got %+v", report)
+	}
+
+	for _, query := range []string{"from=soon", "from=2026-03-02&to=2026-03-01", "task_id=x"} {
+		rec := httptest.NewRecorder()
+		h.Handle(h.FocusTotals)(rec, httptest.NewRequest("GET", "/api/focus?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected %d, got %d", query, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestMyDayHandlers(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, project)
+	report := &models.Task{ProjectID: project.ID, Description: "Write report", Priority: "medium", Status: "todo"}
+	inbox := &models.Task{ProjectID: project.ID, Description: "Inbox zero", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, report)
+	s.CreateTask(ctx, inbox)
+
+	// Yesterday's plan left Inbox zero open.
+	today := dateOnly(time.Now())
+	s.AddToDayPlan(ctx, today.AddDate(0, 0, -1), inbox.ID)
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.AddToMyDay)(rec, withIDParam(httptest.NewRequest("POST", "/api/my-day/1", nil), report.ID))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "In My Day") {
+		t.Fatalf("expected the planned button, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	h.Handle(h.AddToMyDay)(rec, withIDParam(httptest.NewRequest("POST", "/api/my-day/999", nil), 999))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected %d for a missing task, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.MyDay)(rec, httptest.NewRequest("GET", "/today", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "Write report") || !strings.Contains(body, "0 of 1 done") {
+		t.Errorf("expected today's plan, got %s", body)
+	}
+	if !strings.Contains(body, "my-day-rollover") || !strings.Contains(body, "Inbox zero") {
+		t.Errorf("expected yesterday's open task to be offered, got %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.RolloverMyDay)(rec, httptest.NewRequest("POST", "/api/my-day/rollover", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("HX-Refresh") != "true" {
+		t.Fatalf("expected a refresh, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	h.Handle(h.ListMyDay)(rec, httptest.NewRequest("GET", "/api/my-day", nil))
+	var plan MyDayPlan
+	if err := json.Unmarshal(rec.Body.Bytes(), &plan); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if plan.Day != today.Format("2006-01-02") || len(plan.Tasks) != 2 || plan.Tasks[1].ID != inbox.ID {
+		t.Errorf("expected report then the carried-over inbox, got %+v", plan)
+	}
+	rec = httptest.NewRecorder()
+	h.Handle(h.MyDay)(rec, httptest.NewRequest("GET", "/today", nil))
+	if strings.Contains(rec.Body.String(), "my-day-rollover") {
+		t.Error("expected the prompt to be answered for today")
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.RemoveFromMyDay)(rec, withIDParam(httptest.NewRequest("DELETE", "/api/my-day/1", nil), report.ID))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Add to My Day") {
+		t.Errorf("expected the unplanned button, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	h.Handle(h.TaskDetail)(rec, withIDParam(httptest.NewRequest("GET", "/tasks/2", nil), inbox.ID))
+	if !strings.Contains(rec.Body.String(), "In My Day") {
+		t.Error("expected the task page to show the task is planned")
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.MyDay)(rec, httptest.NewRequest("GET", "/today?day=soon", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for a bad day, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestSomedayHandlers(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	fence := &models.Task{ProjectID: project.ID, Description: "Paint fence", Priority: "high", Status: "todo"}
+	s.CreateTask(ctx, fence)
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.ParkTask)(rec, withIDParam(httptest.NewRequest("POST", "/api/tasks/1/someday", nil), fence.ID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var parked models.Task
+	if err := json.Unmarshal(rec.Body.Bytes(), &parked); err != nil || !parked.Someday {
+		t.Errorf("expected the parked task as JSON, got %s (%v)", rec.Body.String(), err)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.AllTasks)(rec, httptest.NewRequest("GET", "/tasks", nil))
+	if strings.Contains(rec.Body.String(), "Paint fence") {
+		t.Error("expected the parked task to leave All Tasks")
+	}
+	for _, page := range []struct {
+		name    string
+		handler func(http.ResponseWriter, *http.Request) error
+		path    string
+	}{
+		{"someday", h.Someday, "/someday"},
+		{"review", h.Review, "/review"},
+	} {
+		rec := httptest.NewRecorder()
+		h.Handle(page.handler)(rec, httptest.NewRequest("GET", page.path, nil))
+		if body := rec.Body.String(); !strings.Contains(body, "Paint fence") || !strings.Contains(body, "/api/tasks/1/someday") {
+			t.Errorf("%s: expected the parked task with a way back, got %s", page.name, body)
+		}
+	}
+
+	req := withIDParam(httptest.NewRequest("DELETE", "/api/tasks/1/someday", nil), fence.ID)
+	req.Header.Set("HX-Request", "true")
+	rec = httptest.NewRecorder()
+	h.Handle(h.PromoteTask)(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Errorf("expected an empty answer for htmx, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	h.Handle(h.AllTasks)(rec, httptest.NewRequest("GET", "/tasks", nil))
+	if !strings.Contains(rec.Body.String(), "Paint fence") {
+		t.Error("expected the promoted task back in All Tasks")
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.ParkTask)(rec, withIDParam(httptest.NewRequest("POST", "/api/tasks/999/someday", nil), 999))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestWaitingHandlers(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, project)
+	quote := &models.Task{ProjectID: project.ID, Description: "Get a quote", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, quote)
+
+	form := url.Values{"description": {"Get a quote"}, "priority": {"medium"}, "waiting_on": {" Acme "}}
+	req := withIDParam(httptest.NewRequest("PUT", "/api/tasks/1", strings.NewReader(form.Encode())), quote.ID)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.Handle(h.UpdateTask)(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if got, _ := s.GetTask(ctx, quote.ID); got.WaitingOn != "Acme" || got.WaitingSince == nil {
+		t.Fatalf("expected the task to wait on Acme, got %+v", got)
+	}
+
+	// Edits that leave out waiting_on keep it.
+	form.Del("waiting_on")
+	req = withIDParam(httptest.NewRequest("PUT", "/api/tasks/1", strings.NewReader(form.Encode())), quote.ID)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	h.Handle(h.UpdateTask)(httptest.NewRecorder(), req)
+	if got, _ := s.GetTask(ctx, quote.ID); got.WaitingOn != "Acme" {
+		t.Errorf("expected the wait to survive an edit without it, got %q", got.WaitingOn)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.Waiting)(rec, httptest.NewRequest("GET", "/waiting", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "Get a quote") || !strings.Contains(body, "Acme") || !strings.Contains(body, "since today") {
+		t.Errorf("expected the waiting task with its days, got %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.StopWaiting)(rec, withIDParam(httptest.NewRequest("DELETE", "/api/tasks/1/waiting", nil), quote.ID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var task models.Task
+	if err := json.Unmarshal(rec.Body.Bytes(), &task); err != nil || task.WaitingOn != "" || task.WaitingSince != nil {
+		t.Errorf("expected the task back on my plate, got %s (%v)", rec.Body.String(), err)
+	}
+}
+
+func TestTaskLinkHandlers(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, project)
+	login := &models.Task{ProjectID: project.ID, Description: "Fix login", Priority: "high", Status: "todo"}
+	release := &models.Task{ProjectID: project.ID, Description: "Release 2.0", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, login)
+	s.CreateTask(ctx, release)
+
+	link := func(id int64, form url.Values) *httptest.ResponseRecorder {
+		req := withIDParam(httptest.NewRequest("POST", "/api/tasks/1/links", strings.NewReader(form.Encode())), id)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.Handle(h.CreateTaskLink)(rec, req)
+		return rec
+	}
+
+	rec := link(login.ID, url.Values{"related_task_id": {"2"}, "relation": {"blocks"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "Blocks") || !strings.Contains(body, "Release 2.0") {
+		t.Errorf("expected the new link, got %s", body)
+	}
+	for _, tc := range []struct {
+		form url.Values
+		want int
+	}{
+		{url.Values{"related_task_id": {"1"}}, http.StatusBadRequest},
+		{url.Values{"related_task_id": {"2"}, "relation": {"causes"}}, http.StatusBadRequest},
+		{url.Values{"related_task_id": {"x"}}, http.StatusBadRequest},
+		{url.Values{"related_task_id": {"999"}}, http.StatusNotFound},
+	} {
+		if rec := link(login.ID, tc.form); rec.Code != tc.want {
+			t.Errorf("%v: expected %d, got %d", tc.form, tc.want, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.TaskDetail)(rec, withIDParam(httptest.NewRequest("GET", "/tasks/2", nil), release.ID))
+	if body := rec.Body.String(); !strings.Contains(body, "Blocked by") || !strings.Contains(body, "Fix login") {
+		t.Errorf("expected the related task's page to show the link, got %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.ListTaskLinks)(rec, withIDParam(httptest.NewRequest("GET", "/api/tasks/2/links", nil), release.ID))
+	var links []models.TaskLink
+	if err := json.Unmarshal(rec.Body.Bytes(), &links); err != nil || len(links) != 1 || !links[0].Inverse {
+		t.Fatalf("expected one inverse link, got %s (%v)", rec.Body.String(), err)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.DeleteTaskLink)(rec, withIDParam(httptest.NewRequest("DELETE", "/api/task-links/1", nil), links[0].ID))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	if links, _ := s.ListTaskLinks(ctx, login.ID); len(links) != 0 {
+		t.Errorf("expected the link to be gone, got %+v", links)
+	}
+}
+
+func TestReportsHandler(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work, Inc", Type: "project"}
+	s.CreateProject(ctx, project)
+	done := &models.Task{ProjectID: project.ID, Description: "Ship", Priority: "high", Status: "todo"}
+	s.CreateTask(ctx, done)
+	s.ToggleTaskComplete(ctx, done.ID)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Plan", Priority: "low", Status: "todo"})
+
+	today := time.Now().Format("2006-01-02")
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.Reports)(rec, httptest.NewRequest("GET", "/api/reports?from="+today+"&to="+today, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.GroupBy != "project" || len(report.Rows) != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if report.Totals.Created != 2 || report.Totals.Completed != 1 {
+		t.Errorf("unexpected totals %+v", report.Totals)
+	}
+
+	req := httptest.NewRequest("GET", "/api/reports?group_by=priority&from="+today, nil)
+	req.Header.Set("Accept", "text/csv")
+	rec = httptest.NewRecorder()
+	h.Handle(h.Reports)(rec, req)
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/csv") {
+		t.Fatalf("expected CSV, got %q", got)
+	}
+	want := "priority,created,completed\nhigh,1,1\nlow,1,0\ntotal,2,1\n"
+	if rec.Body.String() != want {
+		t.Errorf("expected CSV %q, got %q", want, rec.Body.String())
+	}
+
+	for _, query := range []string{"group_by=status", "from=yesterday", "from=2025-02-01&to=2025-01-01"} {
+		rec := httptest.NewRecorder()
+		h.Handle(h.Reports)(rec, httptest.NewRequest("GET", "/api/reports?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected %d, got %d", query, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestCreateTask_ParsesNaturalLanguageDates(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	tests := []struct {
+		form        string
+		description string
+		due         string
+		recurrence  string
+	}{
+		{"description=pay+rent+tomorrow&priority=high", "pay rent", tomorrow, ""},
+		{"description=pay+rent+tomorrow&priority=high&due_date=2030-01-02", "pay rent", "2030-01-02", ""},
+		{"description=call+mom+every+monday&priority=medium", "call mom", "", "every monday"},
+		{"description=buy+a+new+monitor&priority=low", "buy a new monitor", "", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/api/tasks", strings.NewReader(tt.form+"&project_id="+strconv.FormatInt(project.ID, 10)))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.Handle(h.CreateTask)(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected %d, got %d: %s", tt.form, http.StatusOK, rec.Code, rec.Body.String())
+		}
+
+		tasks, _ := s.ListTasksByProject(ctx, project.ID, 0)
+		var got *models.Task
+		for i := range tasks {
+			if tasks[i].Description == tt.description {
+				got, _ = s.GetTask(ctx, tasks[i].ID)
+			}
+		}
+		if got == nil {
+			t.Fatalf("%s: no task with description %q", tt.form, tt.description)
+		}
+		if tt.due != "" && (got.DueDate == nil || got.DueDate.Format("2006-01-02") != tt.due) {
+			t.Errorf("%s: expected due %s, got %v", tt.form, tt.due, got.DueDate)
+		}
+		if got.Recurrence != tt.recurrence {
+			t.Errorf("%s: expected recurrence %q, got %q", tt.form, tt.recurrence, got.Recurrence)
+		}
+		s.DeleteTask(ctx, got.ID)
+	}
+}
+
+func TestUpdateTask_Recurrence(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "low", Status: "todo", Recurrence: "every day"}
+	s.CreateTask(ctx, task)
+
+	update := func(form string) int {
+		req := httptest.NewRequest("PUT", "/api/tasks/1", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", strconv.FormatInt(task.ID, 10))
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		h.Handle(h.UpdateTask)(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		form string
+		code int
+		want string
+	}{
+		{"description=Water+plants&priority=low", http.StatusOK, "every day"},
+		{"description=Water+plants&priority=low&recurrence=Every+Sat", http.StatusOK, "every saturday"},
+		{"description=Water+plants&priority=low&recurrence=now+and+then", http.StatusBadRequest, "every saturday"},
+		{"description=Water+plants&priority=low&recurrence=", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		if code := update(tt.form); code != tt.code {
+			t.Errorf("%s: expected %d, got %d", tt.form, tt.code, code)
+		}
+		got, _ := s.GetTask(ctx, task.ID)
+		if got.Recurrence != tt.want {
+			t.Errorf("%s: expected recurrence %q, got %q", tt.form, tt.want, got.Recurrence)
+		}
+	}
+}
+
+func TestQuickAdd(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	household := &models.Project{Name: "Household", Type: "project"}
+	s.CreateProject(ctx, household)
+	work := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, work)
+
+	quickAdd := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/tasks/quick", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.Handle(h.QuickAdd)(rec, req)
+		return rec
+	}
+
+	rec := quickAdd(url.Values{"text": {"#Household !high @errands buy filters"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "to Household") {
+		t.Errorf("expected confirmation, got %s", rec.Body.String())
+	}
+	tasks, _ := s.ListTasksByProject(ctx, household.ID, 0)
+	if len(tasks) != 1 {
+		t.Fatalf("expected 1 task in Household, got %d", len(tasks))
+	}
+	got, _ := s.GetTask(ctx, tasks[0].ID)
+	if got.Description != "buy filters" || got.Priority != "high" || len(got.Tags) != 1 || got.Tags[0] != "errands" {
+		t.Errorf("unexpected task %+v", got)
+	}
+
+	// Without a marker the task goes to the given project, then the Inbox.
+	quickAdd(url.Values{"text": {"write report"}, "project_id": {strconv.FormatInt(work.ID, 10)}})
+	if tasks, _ := s.ListTasksByProject(ctx, work.ID, 0); len(tasks) != 1 || tasks[0].Priority != "medium" {
+		t.Errorf("expected a medium task in Work, got %+v", tasks)
+	}
+	quickAdd(url.Values{"text": {"call the plumber"}})
+	projects, _ := s.ListActiveProjects(ctx)
+	if len(projects) != 3 || projects[2].Name != "Inbox" {
+		t.Errorf("expected an Inbox project to be created, got %+v", projects)
+	}
+
+	rec = quickAdd(url.Values{"text": {"#Garden plant bulbs"}})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for unknown project, got %d", http.StatusBadRequest, rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `No active project named &#34;Garden&#34;`) || !strings.Contains(rec.Body.String(), `value="#Garden plant bulbs"`) {
+		t.Errorf("expected error with text echoed back, got %s", rec.Body.String())
+	}
+
+	rec = quickAdd(url.Values{"text": {"#Work !low"}})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for missing description, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestListChanges(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "medium", Status: "todo", Tags: []string{"garden"}}
+	s.CreateTask(ctx, task)
+
+	changes := func(query string) (int, ChangesPage) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.Handle(h.ListChanges)(rec, httptest.NewRequest("GET", "/api/changes"+query, nil))
+		var page ChangesPage
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+		}
+		return rec.Code, page
+	}
+
+	_, page := changes("")
+	if len(page.Changes) != 2 || page.More {
+		t.Fatalf("expected the project and the task, got %+v", page)
+	}
+	if page.Changes[0].Project == nil || page.Changes[0].Project.Name != "Home" {
+		t.Errorf("expected the project's contents, got %+v", page.Changes[0])
+	}
+	if got := page.Changes[1].Task; got == nil || got.Description != "Water plants" || len(got.Tags) != 1 {
+		t.Errorf("expected the task's contents, got %+v", page.Changes[1])
+	}
+
+	_, first := changes("?limit=1")
+	if len(first.Changes) != 1 || !first.More || first.Next != page.Changes[0].Seq {
+		t.Errorf("expected one change and more to come, got %+v", first)
+	}
+
+	since := strconv.FormatInt(page.Next, 10)
+	if _, idle := changes("?since=" + since); len(idle.Changes) != 0 || idle.Next != page.Next {
+		t.Errorf("expected no changes and the same cursor, got %+v", idle)
+	}
+
+	s.DeleteTask(ctx, task.ID)
+	_, page = changes("?since=" + since)
+	if len(page.Changes) != 1 || page.Changes[0].Op != models.ChangeDelete || page.Changes[0].Task != nil ||
+		page.Changes[0].EntityID != task.ID {
+		t.Errorf("expected the deletion, got %+v", page)
+	}
+
+	for _, query := range []string{"?since=x", "?since=-1", "?limit=0", "?limit=5000"} {
+		if code, _ := changes(query); code != http.StatusBadRequest {
+			t.Errorf("%s: expected %d, got %d", query, http.StatusBadRequest, code)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	home := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, home)
+	done := &models.Project{Name: "Done", Type: "project"}
+	s.CreateProject(ctx, done)
+	s.MarkProjectComplete(ctx, done.ID)
+	task := &models.Task{ProjectID: home.ID, Description: "Water plants", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, task)
+
+	sync := func(mutations ...Mutation) []MutationResult {
+		t.Helper()
+		body, _ := json.Marshal(map[string]interface{}{"mutations": mutations})
+		rec := httptest.NewRecorder()
+		h.Handle(h.Sync)(rec, httptest.NewRequest("POST", "/api/sync", bytes.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var payload struct {
+			Results []MutationResult `json:"results"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		return payload.Results
+	}
+
+	// Written on a Friday, "tomorrow" is the Saturday.
+	friday := time.Date(2026, time.October, 16, 8, 0, 0, 0, time.Local)
+	mutations := []Mutation{
+		{ID: "a", Type: MutationComplete, TaskID: task.ID, Completed: true},
+		{ID: "b", Type: MutationAdd, Text: "buy soil tomorrow", ProjectID: home.ID, At: friday},
+		{ID: "c", Type: MutationAdd, Text: "file the report", ProjectID: done.ID},
+		{ID: "d", Type: MutationComplete, TaskID: 9999, Completed: true},
+		{ID: "e", Type: "delete", TaskID: task.ID},
+		{Type: MutationComplete, TaskID: task.ID},
+	}
+	results := sync(mutations...)
+	statuses := make([]string, len(results))
+	for i, r := range results {
+		statuses[i] = r.Status
+	}
+	want := []string{SyncApplied, SyncApplied, SyncApplied, SyncRejected, SyncRejected, SyncRejected}
+	if strings.Join(statuses, " ") != strings.Join(want, " ") {
+		t.Fatalf("expected statuses %v, got %+v", want, results)
+	}
+
+	if got, _ := s.GetTask(ctx, task.ID); !got.Completed {
+		t.Error("expected the task to be completed")
+	}
+	soil, _ := s.GetTask(ctx, results[1].TaskID)
+	if soil.ProjectID != home.ID || soil.DueDate == nil || soil.DueDate.Format(time.DateOnly) != "2026-10-17" {
+		t.Errorf("expected soil in Home due the day after it was written, got %+v", soil)
+	}
+	report, _ := s.GetTask(ctx, results[2].TaskID)
+	if inbox, _ := s.GetProject(ctx, report.ProjectID); inbox.Name != "Inbox" {
+		t.Errorf("expected a task for a completed project to go to the Inbox, got %q", inbox.Name)
+	}
+
+	// Replaying the same queue changes nothing, even after the task was
+	// reopened online.
+	s.ToggleTaskComplete(ctx, task.ID)
+	for i, r := range sync(mutations[:3]...) {
+		if r.Status != SyncDuplicate {
+			t.Errorf("mutation %d: expected a duplicate, got %+v", i, r)
+		}
+	}
+	if got, _ := s.GetTask(ctx, task.ID); got.Completed {
+		t.Error("expected a replayed completion to be skipped")
+	}
+	if tasks, _ := s.ListTasksByProject(ctx, home.ID, 0); len(tasks) != 2 {
+		t.Errorf("expected no duplicate tasks, got %d", len(tasks))
+	}
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.Sync)(rec, httptest.NewRequest("POST", "/api/sync", strings.NewReader("{")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for invalid json, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestSync_Conflicts(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	home := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, home)
+	task := &models.Task{ProjectID: home.ID, Description: "Water plants", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, task)
+	base := task.Revision
+
+	sync := func(m Mutation) MutationResult {
+		t.Helper()
+		body, _ := json.Marshal(map[string]interface{}{"mutations": []Mutation{m}})
+		rec := httptest.NewRecorder()
+		h.Handle(h.Sync)(rec, httptest.NewRequest("POST", "/api/sync", bytes.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var payload struct {
+			Results []MutationResult `json:"results"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &payload)
+		return payload.Results[0]
+	}
+	str := func(s string) *string { return &s }
+
+	// Edited online while the device was offline.
+	task.Description = "Water the plants"
+	s.UpdateTask(ctx, task)
+
+	r := sync(Mutation{ID: "a", Type: MutationUpdate, TaskID: task.ID, BaseRevision: base, Edit: &TaskEdit{Notes: str("the ferns too")}})
+	if r.Status != SyncConflict || r.Conflict == nil {
+		t.Fatalf("expected a conflict, got %+v", r)
+	}
+	if r.Conflict.Server.Description != "Water the plants" || r.Conflict.Server.Notes != "" {
+		t.Errorf("expected the server's version, got %+v", r.Conflict.Server)
+	}
+	if r.Conflict.Client.Notes != "the ferns too" || r.Conflict.Client.Revision != base {
+		t.Errorf("expected the offline version, got %+v", r.Conflict.Client)
+	}
+	if got, _ := s.GetTask(ctx, task.ID); got.Notes != "" {
+		t.Error("expected a conflicting edit not to be applied")
+	}
+
+	// Keeping the offline edit resends it against the server's revision.
+	r = sync(Mutation{ID: "b", Type: MutationUpdate, TaskID: task.ID, BaseRevision: r.Conflict.Server.Revision, Edit: &TaskEdit{Notes: str("the ferns too"), DueDate: str("2026-10-20")}})
+	if r.Status != SyncApplied {
+		t.Fatalf("expected the edit to be applied, got %+v", r)
+	}
+	got, _ := s.GetTask(ctx, task.ID)
+	if got.Description != "Water the plants" || got.Notes != "the ferns too" || got.DueDate == nil || got.DueDate.Format(time.DateOnly) != "2026-10-20" {
+		t.Errorf("expected both edits to be kept, got %+v", got)
+	}
+
+	// Completing against an old revision conflicts too, unless the task
+	// already ended up completed.
+	if r = sync(Mutation{ID: "c", Type: MutationComplete, TaskID: task.ID, Completed: true, BaseRevision: base}); r.Status != SyncConflict || !r.Conflict.Client.Completed {
+		t.Errorf("expected a conflicting completion, got %+v", r)
+	}
+	s.ToggleTaskComplete(ctx, task.ID)
+	if r = sync(Mutation{ID: "d", Type: MutationComplete, TaskID: task.ID, Completed: true, BaseRevision: base}); r.Status != SyncApplied {
+		t.Errorf("expected a completion already made elsewhere to apply, got %+v", r)
+	}
+
+	// Changes queued together against the same revision build on each other.
+	s.ToggleTaskComplete(ctx, task.ID)
+	current, _ := s.GetTask(ctx, task.ID)
+	body, _ := json.Marshal(map[string]interface{}{"mutations": []Mutation{
+		{ID: "f", Type: MutationUpdate, TaskID: task.ID, BaseRevision: current.Revision, Edit: &TaskEdit{Priority: str("high")}},
+		{ID: "g", Type: MutationComplete, TaskID: task.ID, Completed: true, BaseRevision: current.Revision},
+	}})
+	rec := httptest.NewRecorder()
+	h.Handle(h.Sync)(rec, httptest.NewRequest("POST", "/api/sync", bytes.NewReader(body)))
+	if strings.Count(rec.Body.String(), SyncApplied) != 2 {
+		t.Errorf("expected both queued changes to apply, got %s", rec.Body.String())
+	}
+	if got, _ := s.GetTask(ctx, task.ID); got.Priority != "high" || !got.Completed {
+		t.Errorf("expected both queued changes to be kept, got %+v", got)
+	}
+
+	if r = sync(Mutation{ID: "e", Type: MutationUpdate, TaskID: task.ID, Edit: &TaskEdit{Priority: str("urgent")}}); r.Status != SyncRejected {
+		t.Errorf("expected an invalid edit to be rejected, got %+v", r)
+	}
+	if r = sync(Mutation{ID: "a", Type: MutationUpdate, TaskID: task.ID}); r.Status != SyncDuplicate {
+		t.Errorf("expected a conflicted mutation to count as handled, got %+v", r)
+	}
+}
+
+// readBarrierStore holds the first n GetTask calls until all n were made,
+// so that concurrent requests all read the task before any writes it.
+type readBarrierStore struct {
+	store.Store
+	n       int32
+	calls   atomic.Int32
+	arrived sync.WaitGroup
+}
+
+func (s *readBarrierStore) GetTask(ctx context.Context, id int64) (*models.Task, error) {
+	task, err := s.Store.GetTask(ctx, id)
+	if s.calls.Add(1) <= s.n {
+		s.arrived.Done()
+		s.arrived.Wait()
+	}
+	return task, err
+}
+
+func TestUpdateTask_ConcurrentIfMatch(t *testing.T) {
+	_, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, task)
+	id := strconv.FormatInt(task.ID, 10)
+	etag := `"task-` + id + `-` + strconv.FormatInt(task.Revision, 10) + `"`
+
+	barrier := &readBarrierStore{Store: s, n: 2}
+	barrier.arrived.Add(2)
+	h := New(barrier, nil)
+
+	put := func(description string) int {
+		form := url.Values{"description": {description}, "priority": {"high"}}
+		req := httptest.NewRequest("PUT", "/api/tasks/"+id, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("If-Match", etag)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		h.Handle(h.UpdateTask)(rec, req)
+		return rec.Code
+	}
+
+	codes := make([]int, 2)
+	var wg sync.WaitGroup
+	for i, description := range []string{"Water the ferns", "Water the cactus"} {
+		wg.Add(1)
+		go func(i int, description string) {
+			defer wg.Done()
+			codes[i] = put(description)
+		}(i, description)
+	}
+	wg.Wait()
+
+	slices.Sort(codes)
+	if codes[0] != http.StatusOK || codes[1] != http.StatusPreconditionFailed {
+		t.Fatalf("expected one 200 and one 412, got %v", codes)
+	}
+	got, _ := s.GetTask(ctx, task.ID)
+	if got.Revision != task.Revision+1 {
+		t.Errorf("expected a single revision, got %d after %d", got.Revision, task.Revision)
+	}
+}
+
+func TestETags(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, task)
+	id := strconv.FormatInt(task.ID, 10)
+
+	request := func(method, target, header, value string, body io.Reader) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, body)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		switch method {
+		case "GET":
+			h.Handle(h.GetTask)(rec, req)
+		case "PUT":
+			h.Handle(h.UpdateTask)(rec, req)
+		case "DELETE":
+			h.Handle(h.DeleteTask)(rec, req)
+		}
+		return rec
+	}
+	edit := func() io.Reader {
+		return strings.NewReader(url.Values{"description": {"Water the plants"}, "priority": {"high"}}.Encode())
+	}
+
+	rec := request("GET", "/api/tasks/"+id, "", "", nil)
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected the task with an ETag, got %d %q", rec.Code, etag)
+	}
+	if rec = request("GET", "/api/tasks/"+id, "If-None-Match", etag, nil); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("expected %d for a current ETag, got %d", http.StatusNotModified, rec.Code)
+	}
+
+	if rec = request("PUT", "/api/tasks/"+id, "If-Match", etag, edit()); rec.Code != http.StatusOK {
+		t.Fatalf("expected a PUT with the current ETag to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	updated := rec.Header().Get("ETag")
+	if updated == "" || updated == etag {
+		t.Errorf("expected the PUT to return the new ETag, got %q", updated)
+	}
+	if rec = request("GET", "/api/tasks/"+id, "If-None-Match", etag, nil); rec.Code != http.StatusOK {
+		t.Errorf("expected a changed task to be sent again, got %d", rec.Code)
+	}
+
+	// A second writer still holding the old ETag is turned away.
+	if rec = request("PUT", "/api/tasks/"+id, "If-Match", etag, edit()); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected %d for a stale If-Match, got %d", http.StatusPreconditionFailed, rec.Code)
+	}
+	if rec = request("DELETE", "/api/tasks/"+id, "If-Match", etag, nil); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected %d for a stale If-Match, got %d", http.StatusPreconditionFailed, rec.Code)
+	}
+	if _, err := s.GetTask(ctx, task.ID); err != nil {
+		t.Error("expected the task to survive a stale delete")
+	}
+	if rec = request("DELETE", "/api/tasks/"+id, "If-Match", updated, nil); rec.Code != http.StatusOK {
+		t.Errorf("expected a delete with the current ETag to succeed, got %d", rec.Code)
+	}
+
+	// Lists get a weak ETag from their contents.
+	rec = httptest.NewRecorder()
+	h.Handle(h.ListTasks)(rec, httptest.NewRequest("GET", "/api/tasks", nil))
+	list := rec.Header().Get("ETag")
+	if !strings.HasPrefix(list, `W/"`) {
+		t.Fatalf("expected a weak ETag on the list, got %q", list)
+	}
+	req := httptest.NewRequest("GET", "/api/tasks", nil)
+	req.Header.Set("If-None-Match", list)
+	rec = httptest.NewRecorder()
+	h.Handle(h.ListTasks)(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected %d for an unchanged list, got %d", http.StatusNotModified, rec.Code)
+	}
+}
+
+func TestManifest(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+	h.SetBasePath("/tasks")
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.Manifest)(rec, httptest.NewRequest("GET", "/manifest.webmanifest", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/manifest+json" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	var manifest struct {
+		StartURL string `json:"start_url"`
+		Scope    string `json:"scope"`
+		Icons    []struct {
+			Src string `json:"src"`
+		} `json:"icons"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &manifest); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	if manifest.StartURL != "/tasks/" || manifest.Scope != "/tasks/" || len(manifest.Icons) == 0 ||
+		!strings.HasPrefix(manifest.Icons[0].Src, "/tasks/static/icons/") {
+		t.Errorf("expected URLs under the base path, got %+v", manifest)
+	}
+}
+
+func TestCreateTaskBatch(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Trip", Type: "project"}
+	s.CreateProject(ctx, project)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Pack", Priority: "medium", Status: "todo"})
+
+	batch := func(text, accept string) *httptest.ResponseRecorder {
+		form := url.Values{"text": {text}, "priority": {"high"}}
+		req := httptest.NewRequest("POST", "/api/projects/1/tasks/batch", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", strconv.FormatInt(project.ID, 10))
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		h.Handle(h.CreateTaskBatch)(rec, req)
+		return rec
+	}
+
+	text := "# Before the trip\n- [ ] Book flights\n- [x] Buy guidebook\n- [ ] pack\n- [ ] Renew passport tomorrow\n- [ ] Book flights\n- [ ]\n"
+	rec := batch(text, "application/json")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var result BatchResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// Ticked, duplicate (case-insensitive, within the paste or the project) and empty items are skipped.
+	if result.Created != 2 || result.Skipped != 4 {
+		t.Errorf("expected 2 created and 4 skipped, got %+v", result)
+	}
+
+	tasks, _ := s.ListTasksByProject(ctx, project.ID, 0)
+	if len(tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %d", len(tasks))
+	}
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	for _, task := range tasks[1:] {
+		if task.Priority != "high" {
+			t.Errorf("expected high priority, got %+v", task)
+		}
+		if task.Description == "Renew passport" && (task.DueDate == nil || task.DueDate.Format("2006-01-02") != tomorrow) {
+			t.Errorf("expected due date from the line, got %v", task.DueDate)
+		}
+	}
+
+	rec = batch("Buy adapter\nBuy adapter", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Created 1 task, skipped 1.") {
+		t.Errorf("expected summary in the form, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = batch(strings.Repeat("line\n", maxBatchTasks+1), "")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for an oversized paste, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	capture := func(method, target string, body url.Values, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		h.Handle(h.Capture)(rec, req)
+		return rec
+	}
+
+	if rec := capture("GET", "/capture?token=anything&title=x", nil, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("expected %d before a token is issued, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.ResetCaptureToken)(rec, httptest.NewRequest("POST", "/settings/capture/token", nil))
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected %d, got %d", http.StatusSeeOther, rec.Code)
+	}
+	token, err := s.GetSetting(ctx, captureTokenSetting)
+	if err != nil || len(token) != 40 {
+		t.Fatalf("expected a token, got %q, %v", token, err)
+	}
+
+	if rec := capture("GET", "/capture?token=wrong&title=x", nil, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected %d for a wrong token, got %d", http.StatusUnauthorized, rec.Code)
+	}
+
+	q := url.Values{"token": {token}, "title": {"Interesting article"}, "url": {"https://example.com/post?id=1"}}
+	rec = capture("GET", "/capture?"+q.Encode(), nil, nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Interesting article") {
+		t.Fatalf("expected confirmation page, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = capture("POST", "/capture", url.Values{"url": {"https://example.com/untitled"}}, http.Header{"Authorization": {"Bearer " + token}})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	var created models.Task
+	json.NewDecoder(rec.Body).Decode(&created)
+	if created.Description != "https://example.com/untitled" {
+		t.Errorf("expected the url as description, got %q", created.Description)
+	}
+
+	projects, _ := s.ListActiveProjects(ctx)
+	if len(projects) != 1 || projects[0].Name != "Inbox" {
+		t.Fatalf("expected the Inbox project, got %+v", projects)
+	}
+	tasks, _ := s.ListTasksByProject(ctx, projects[0].ID, 0)
+	if len(tasks) != 2 || tasks[0].Description != "Interesting article" || tasks[0].Notes != "https://example.com/post?id=1" {
+		t.Errorf("unexpected captured tasks %+v", tasks)
+	}
+
+	if rec := capture("POST", "/capture", url.Values{"token": {token}}, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected %d without title or url, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestCaptureSettings(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.CaptureSettings)(rec, httptest.NewRequest("GET", "/settings/capture", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Capture is off") {
+		t.Fatalf("expected capture to be off, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	s.SetSetting(ctx, captureTokenSetting, "abc123")
+	rec = httptest.NewRecorder()
+	h.Handle(h.CaptureSettings)(rec, httptest.NewRequest("GET", "http://tasks.example/settings/capture", nil))
+	// html/template percent-encodes the link; browsers decode it before running it.
+	body := rec.Body.String()
+	if !strings.Contains(body, `href="javascript:%28function%28%29`) || !strings.Contains(body, "http://tasks.example/capture?token=abc123&amp;title=") {
+		t.Errorf("expected bookmarklet link, got %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.DisableCapture)(rec, httptest.NewRequest("POST", "/settings/capture/disable", nil))
+	if token, _ := s.GetSetting(ctx, captureTokenSetting); token != "" {
+		t.Errorf("expected token cleared, got %q", token)
+	}
+}
+
+func TestTokenLockout(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+	s.SetSetting(ctx, shortcutsTokenSetting, "secret")
+
+	call := func(token, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/shortcuts/today?token="+token, nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		h.Handle(h.ShortcutsToday)(rec, req)
+		return rec
+	}
+
+	for i := 0; i < models.FreeAuthFailures+1; i++ {
+		if rec := call("nope", "192.0.2.1:1234"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected %d, got %d", i+1, http.StatusUnauthorized, rec.Code)
+		}
+	}
+
+	rec := call("secret", "192.0.2.1:5678")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("expected a locked out client to get %d with Retry-After, got %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if rec := call("secret", "198.51.100.7:1234"); rec.Code != http.StatusOK {
+		t.Errorf("expected other clients to be unaffected, got %d", rec.Code)
+	}
+
+	failure, _ := s.GetAuthFailure(ctx, shortcutsTokenSetting, "192.0.2.1")
+	past := time.Now().Add(-time.Second)
+	failure.LockedUntil = &past
+	s.SaveAuthFailure(ctx, failure)
+	if rec := call("secret", "192.0.2.1:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("expected the right token to work after the lockout, got %d", rec.Code)
+	}
+	if failure, _ := s.GetAuthFailure(ctx, shortcutsTokenSetting, "192.0.2.1"); failure.Failures != 0 {
+		t.Errorf("expected a success to clear the failures, got %d", failure.Failures)
+	}
+}
+
+func TestShortcutsAPI(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	household := &models.Project{Name: "Household", Type: "project"}
+	s.CreateProject(ctx, household)
+	today := dateOnly(time.Now())
+	s.CreateTask(ctx, &models.Task{ProjectID: household.ID, Description: "Water plants", Priority: "low", Status: "todo", DueDate: &today})
+	s.CreateTask(ctx, &models.Task{ProjectID: household.ID, Description: "Call plumber", Priority: "low", Status: "todo"})
+	s.CreateTask(ctx, &models.Task{ProjectID: household.ID, Description: "Call plumber back", Priority: "low", Status: "todo"})
+	s.CreateTask(ctx, &models.Task{ProjectID: household.ID, Description: "Call electrician", Priority: "low", Status: "todo"})
+
+	call := func(handler HandlerFunc, method, target, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+		h.Handle(handler)(rec, req)
+		return rec
+	}
+
+	if rec := call(h.ShortcutsToday, "GET", "/shortcuts/today", "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected %d before a token is issued, got %d", http.StatusNotFound, rec.Code)
+	}
+	s.SetSetting(ctx, shortcutsTokenSetting, "secret")
+	if rec := call(h.ShortcutsToday, "GET", "/shortcuts/today?token=nope", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected %d for a wrong token, got %d", http.StatusUnauthorized, rec.Code)
+	}
+
+	t.Run("add task", func(t *testing.T) {
+		rec := call(h.ShortcutsAddTask, "POST", "/shortcuts/tasks", "application/json", `{"token": "secret", "text": "#household !high buy filters tomorrow"}`)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body.String())
+		}
+		var got ShortcutTask
+		json.NewDecoder(rec.Body).Decode(&got)
+		tomorrow := today.AddDate(0, 0, 1).Format("2006-01-02")
+		if got.Description != "buy filters" || got.Project != "Household" || got.Priority != "high" || got.DueDate != tomorrow {
+			t.Errorf("unexpected task %+v", got)
+		}
+
+		rec = call(h.ShortcutsAddTask, "POST", "/shortcuts/tasks?token=secret", "application/x-www-form-urlencoded", "text=Renew+passport")
+		json.NewDecoder(rec.Body).Decode(&got)
+		if rec.Code != http.StatusCreated || got.Project != "Inbox" {
+			t.Errorf("expected an Inbox task, got %d %+v", rec.Code, got)
+		}
+
+		rec = call(h.ShortcutsAddTask, "POST", "/shortcuts/tasks?token=secret", "application/json", `{"text": "#Garden plant bulbs"}`)
+		if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), `"error"`) {
+			t.Errorf("expected a JSON 404 for an unknown project, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("today", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/shortcuts/today", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		h.Handle(h.ShortcutsToday)(rec, req)
+		var got []ShortcutTask
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(got) != 1 || got[0].Description != "Water plants" || got[0].Project != "Household" {
+			t.Errorf("unexpected tasks %+v", got)
+		}
+	})
+
+	t.Run("complete", func(t *testing.T) {
+		tests := []struct {
+			name string
+			code int
+		}{
+			{"call PLUMBER", http.StatusOK}, // exact match beats "Call plumber back"
+			{"call", http.StatusConflict},   // two open tasks left
+			{"electrician", http.StatusOK},  // unique substring
+			{"mow the lawn", http.StatusNotFound},
+			{"", http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			body, _ := json.Marshal(map[string]string{"token": "secret", "name": tt.name})
+			rec := call(h.ShortcutsCompleteTask, "POST", "/shortcuts/complete", "application/json", string(body))
+			if rec.Code != tt.code {
+				t.Errorf("%q: expected %d, got %d: %s", tt.name, tt.code, rec.Code, rec.Body.String())
+			}
+		}
+
+		open, _ := s.ListOpenTasks(ctx, store.TaskFilter{Search: "call"})
+		if len(open) != 1 || open[0].Description != "Call plumber back" {
+			t.Errorf("expected only \"Call plumber back\" left open, got %+v", open)
+		}
+	})
+}
+
+func TestQuickFind(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	household := &models.Project{Name: "Household", Type: "project"}
+	s.CreateProject(ctx, household)
+	work := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, work)
+	filters := &models.Task{ProjectID: household.ID, Description: "Buy furnace filters", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, filters)
+	report := &models.Task{ProjectID: work.ID, Description: "Write quarterly report", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, report)
+	done := &models.Task{ProjectID: work.ID, Description: "Write old report", Priority: "medium", Status: "done"}
+	s.CreateTask(ctx, done)
+
+	// Age everything except the report so recency decides the empty query.
+	old := time.Now().AddDate(0, -3, 0)
+	s.DB().Exec(`UPDATE projects SET updated_at = ?`, old)
+	s.DB().Exec(`UPDATE tasks SET updated_at = ? WHERE id != ?`, old, report.ID)
+
+	find := func(q string) []QuickFindResult {
+		rec := httptest.NewRecorder()
+		h.Handle(h.QuickFind)(rec, httptest.NewRequest("GET", "/api/quickfind?q="+url.QueryEscape(q), nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: expected %d, got %d", q, http.StatusOK, rec.Code)
+		}
+		var results []QuickFindResult
+		json.NewDecoder(rec.Body).Decode(&results)
+		return results
+	}
+
+	results := find("hh")
+	if len(results) != 1 || results[0].Type != "project" || results[0].URL != fmt.Sprintf("/projects/%d", household.ID) {
+		t.Errorf("expected the Household project, got %+v", results)
+	}
+
+	results = find("wr rep")
+	if len(results) != 1 || results[0].Name != "Write quarterly report" || results[0].Project != "Work" {
+		t.Errorf("expected only the open report task, got %+v", results)
+	}
+	if want := fmt.Sprintf("/projects/%d#task-%d", work.ID, report.ID); len(results) == 1 && results[0].URL != want {
+		t.Errorf("expected url %s, got %s", want, results[0].URL)
+	}
+
+	results = find("")
+	if len(results) != 4 || results[0].Name != "Write quarterly report" {
+		t.Errorf("expected all four items, most recent first, got %+v", results)
+	}
+
+	if results := find("zzz"); results == nil || len(results) != 0 {
+		t.Errorf("expected an empty list, got %+v", results)
+	}
+}
+
+func TestSettingsHandler(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	h.SetPalettes([]string{"forest"})
+	ctx := context.Background()
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.Settings)(rec, httptest.NewRequest("GET", "/settings", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `value="30"`) {
+		t.Errorf("expected the default upcoming window in the form")
+	}
+
+	post := func(form string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/settings", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.Handle(h.UpdateSettings)(rec, req)
+		return rec
+	}
+
+	rec = post("default_priority=low&upcoming_days=400&date_format=2006-01-02&week_start=0")
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "form-error") {
+		t.Errorf("expected a 400 with the form error, got %d", rec.Code)
+	}
+
+	rec = post("default_priority=low&upcoming_days=7&stale_days=21&escalate_days=2&date_format=2006-01-02&week_start=0&theme=dark&palette=sunset&language=auto")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected a palette that isn't installed to be rejected, got %d", rec.Code)
+	}
+
+	rec = post("default_priority=low&upcoming_days=7&stale_days=21&escalate_days=2&date_format=2006-01-02&week_start=0&theme=dark&palette=forest&language=auto")
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected %d, got %d", http.StatusSeeOther, rec.Code)
+	}
+	got, _ := s.GetSettings(ctx)
+	want := models.Settings{DefaultPriority: "low", UpcomingDays: 7, StaleDays: 21, EscalateDays: 2, DateFormat: "2006-01-02", WeekStart: time.Sunday, Theme: "dark", Palette: "forest", Language: "auto"}
+	if *got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	rec = post("default_priority=low&upcoming_days=7&stale_days=21&escalate_days=2&date_format=2006-01-02&week_start=0&theme=dark&palette=forest&language=auto&quiet_hours_start=22:00")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected quiet hours without an end to be rejected, got %d", rec.Code)
+	}
+	rec = post("default_priority=low&upcoming_days=7&stale_days=21&escalate_days=2&date_format=2006-01-02&week_start=0&theme=dark&palette=forest&language=auto&time_zone=Europe/Berlin&quiet_hours_start=22:00&quiet_hours_end=07:00&digest_time=06:30")
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected %d, got %d", http.StatusSeeOther, rec.Code)
+	}
+	got, _ = s.GetSettings(ctx)
+	if got.TimeZone != "Europe/Berlin" || got.QuietHoursStart != "22:00" || got.QuietHoursEnd != "07:00" || got.DigestTime != "06:30" {
+		t.Errorf("expected the notification schedule to be saved, got %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.Settings)(rec, httptest.NewRequest("GET", "/settings", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `<html lang="en" data-theme="dark">`) {
+		t.Errorf("expected the saved theme on the page")
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `href="/static/css/themes/forest.css"`) {
+		t.Errorf("expected the saved palette's stylesheet on the page")
+	}
+
+	t.Run("default priority applies to quick add", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/tasks/quick", strings.NewReader("text=Water+plants"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.Handle(h.QuickAdd)(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+		}
+		inbox, _ := h.inboxProject(req)
+		tasks, _ := s.ListTasksByProjectFiltered(ctx, inbox.ID, false, 0)
+		if len(tasks) != 1 || tasks[0].Priority != "low" {
+			t.Errorf("expected one low-priority task, got %+v", tasks)
+		}
+	})
+
+	t.Run("upcoming window applies to the upcoming view", func(t *testing.T) {
+		project := &models.Project{Name: "P", Type: "project"}
+		s.CreateProject(ctx, project)
+		soon := dateOnly(time.Now()).AddDate(0, 0, 5)
+		later := dateOnly(time.Now()).AddDate(0, 0, 10)
+		s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Soon", Priority: "low", Status: "todo", DueDate: &soon})
+		s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Later", Priority: "low", Status: "todo", DueDate: &later})
+
+		rec := httptest.NewRecorder()
+		h.Handle(h.Upcoming)(rec, httptest.NewRequest("GET", "/upcoming", nil))
+		body := rec.Body.String()
+		if !strings.Contains(body, "Soon") || strings.Contains(body, "Later") {
+			t.Errorf("expected only tasks within the 7-day window")
+		}
+	})
+
+	t.Run("language follows the browser unless set", func(t *testing.T) {
+		get := func(acceptLanguage string) string {
+			req := httptest.NewRequest("GET", "/upcoming", nil)
+			req.Header.Set("Accept-Language", acceptLanguage)
+			rec := httptest.NewRecorder()
+			h.Handle(h.Upcoming)(rec, req)
+			return rec.Body.String()
+		}
+
+		body := get("de-DE,de;q=0.9,en;q=0.8")
+		if !strings.Contains(body, `<html lang="de"`) || !strings.Contains(body, "Anstehende Aufgaben") {
+			t.Errorf("expected the German page for a German browser")
+		}
+
+		settings, _ := s.GetSettings(ctx)
+		settings.Language = "es"
+		s.SaveSettings(ctx, settings)
+		body = get("de")
+		if !strings.Contains(body, `<html lang="es"`) || !strings.Contains(body, "Próximas tareas") {
+			t.Errorf("expected the language setting to override the browser")
+		}
+	})
+}
+
+func TestToggleTheme(t *testing.T) {
+	h, s := setupTestHandlers(t)
+	ctx := context.Background()
+
+	toggle := func(form string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/settings/theme", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.Handle(h.ToggleTheme)(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		form string
+		want string
+	}{
+		{"current=light", "dark"},
+		{"current=dark", "light"},
+		{"", "dark"},
+		{"theme=system", "system"},
+	}
+	for _, tt := range tests {
+		rec := toggle(tt.form)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("%q: expected %d, got %d", tt.form, http.StatusNoContent, rec.Code)
+		}
+		if got := rec.Header().Get("HX-Trigger"); got != fmt.Sprintf(`{"themeChanged": %q}`, tt.want) {
+			t.Errorf("%q: unexpected HX-Trigger %s", tt.form, got)
+		}
+		if settings, _ := s.GetSettings(ctx); settings.Theme != tt.want {
+			t.Errorf("%q: expected theme %q, got %q", tt.form, tt.want, settings.Theme)
+		}
+	}
+
+	if rec := toggle("theme=sepia"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for an unknown theme, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestJobsHandler(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.Jobs)(rec, httptest.NewRequest("GET", "/settings/jobs", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "No background jobs") {
+		t.Fatalf("expected the empty state, got %d", rec.Code)
+	}
+
+	lastRun := time.Now().Add(-time.Hour)
+	s.SaveJobStatus(ctx, &models.JobStatus{Name: "backup", Schedule: "0 3 * * *", LastRunAt: &lastRun, LastError: "disk full", NextRunAt: time.Now().Add(time.Hour)})
+	s.SaveJobStatus(ctx, &models.JobStatus{Name: "github", Schedule: "@every 5m0s", NextRunAt: time.Now()})
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.Jobs)(rec, httptest.NewRequest("GET", "/settings/jobs", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{`id="job-backup"`, "0 3 * * *", "disk full", `id="job-github"`, "Never"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the jobs page", want)
+		}
+	}
+}
+
+// mailbox is a mail.Sender keeping what it sends.
+type mailbox struct {
+	sent []mail.Message
+}
+
+func (m *mailbox) Send(_ context.Context, msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestReportSettings(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.ReportSettings)(rec, httptest.NewRequest("GET", "/settings/reports", nil))
+	if !strings.Contains(rec.Body.String(), "SMTP_HOST") {
+		t.Errorf("expected a hint to configure SMTP")
+	}
+	rec = httptest.NewRecorder()
+	h.Handle(h.CreateReportSchedule)(rec, httptest.NewRequest("POST", "/settings/reports", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without SMTP, got %d", rec.Code)
+	}
+
+	box := &mailbox{}
+	h.SetReports(mail.NewReports(s, box))
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/settings/reports", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.Handle(h.CreateReportSchedule)(rec, req)
+		return rec
+	}
+	if rec := post(url.Values{"recipients": {"Me <me@example.com>"}, "day": {"5"}, "time": {"16:00"}, "days": {"7"}}); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a recipient with a name, got %d", rec.Code)
+	}
+	if rec := post(url.Values{"recipients": {"me@example.com"}, "day": {"8"}, "time": {"16:00"}, "days": {"7"}}); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown weekday, got %d", rec.Code)
+	}
+	if rec := post(url.Values{"recipients": {"me@example.com, boss@example.com"}, "day": {"5"}, "time": {"16:00"}, "days": {"7"}}); rec.Code != http.StatusSeeOther {
+		t.Fatalf("expected a redirect after saving, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	schedules, _ := s.ListReportSchedules(ctx)
+	if len(schedules) != 1 || schedules[0].Schedule != "0 16 * * 5" || len(schedules[0].Recipients) != 2 {
+		t.Fatalf("unexpected schedules %+v", schedules)
+	}
+	id := strconv.FormatInt(schedules[0].ID, 10)
+	withID := func(method, path string) *http.Request {
+		req := httptest.NewRequest(method, path, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.SendReportNow)(rec, withID("POST", "/settings/reports/"+id+"/send"))
+	if rec.Code != http.StatusSeeOther || len(box.sent) != 1 || len(box.sent[0].To) != 2 {
+		t.Errorf("expected the report sent to both, got %d and %+v", rec.Code, box.sent)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.ReportSettings)(rec, httptest.NewRequest("GET", "/settings/reports?sent=1", nil))
+	body := rec.Body.String()
+	for _, want := range []string{"me@example.com, boss@example.com", "<code>0 16 * * 5</code>", "Next report", "Last sent", "The report was sent."} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the page", want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.DeleteReportSchedule)(rec, withID("POST", "/settings/reports/"+id+"/delete"))
+	if schedules, _ := s.ListReportSchedules(ctx); rec.Code != http.StatusSeeOther || len(schedules) != 0 {
+		t.Errorf("expected the schedule deleted, got %d and %+v", rec.Code, schedules)
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	h.SetAdmin(AdminConfig{DBPath: filepath.Join(dir, "missing.db"), BackupDir: filepath.Join(dir, "backups"), BackupKeep: 3})
+	lastRun := time.Now().Add(-time.Hour)
+	s.SaveJobStatus(ctx, &models.JobStatus{Name: "backup", Schedule: "0 3 * * *", LastRunAt: &lastRun, LastError: "disk full", NextRunAt: time.Now()})
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.Admin)(rec, httptest.NewRequest("GET", "/admin", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"missing.db", "migrations are applied", "No backups yet.", "disk full", "no user accounts"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the admin page", want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.AdminBackup)(rec, httptest.NewRequest("POST", "/admin/backup", nil))
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/admin?done=backup" {
+		t.Fatalf("expected a redirect back to the panel, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.Admin)(rec, httptest.NewRequest("GET", "/admin?done=backup", nil))
+	body = rec.Body.String()
+	if !strings.Contains(body, "Backup written.") || !strings.Contains(body, "mytasks-") {
+		t.Errorf("expected the notice and the new backup in the admin page")
+	}
+}
+
+func TestAdminListsWorkspaces(t *testing.T) {
+	h, _ := setupTestHandlersWithTemplates(t)
+	_, family := setupTestHandlers(t)
+	if err := family.CreateProject(context.Background(), &models.Project{Name: "Chores", Type: "project"}); err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	h.SetAdmin(AdminConfig{
+		Workspaces: []AdminWorkspace{{Name: "family", URL: "//family.tasks.example.org/admin", DBPath: "family.db", Store: family}},
+		Tenants:    true,
+	})
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.Admin)(rec, httptest.NewRequest("GET", "/admin", nil))
+	body := rec.Body.String()
+	for _, want := range []string{"Tenants", `href="//family.tasks.example.org/admin"`, "family.db", "Changed"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the admin page", want)
+		}
+	}
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	h, _ := setupTestHandlersWithTemplates(t)
+	h.SetMaintenance(new(atomic.Bool))
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	readOnly := h.ReadOnly(ok)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/admin/maintenance", strings.NewReader("on=1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	h.Handle(h.AdminMaintenance)(rec, req)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/admin?done=maintenance-on" {
+		t.Fatalf("expected a redirect back to the panel, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/api/tasks", nil)
+	req.Header.Set("HX-Request", "true")
+	readOnly.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" || !strings.Contains(rec.Body.String(), "read-only for maintenance") {
+		t.Errorf("expected a change to be refused with 503, got %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, req := range []*http.Request{httptest.NewRequest("GET", "/tasks", nil), httptest.NewRequest("POST", "/admin/backup", nil)} {
+		rec = httptest.NewRecorder()
+		readOnly.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("expected %s %s to be served in maintenance, got %d", req.Method, req.URL.Path, rec.Code)
+		}
+	}
+
+	// GET routes that change data are refused too.
+	capture := h.Handle(h.NotInMaintenance(func(w http.ResponseWriter, r *http.Request) error {
+		t.Error("capture ran in maintenance")
+		return nil
+	}))
+	rec = httptest.NewRecorder()
+	capture(rec, httptest.NewRequest("GET", "/capture?title=Milk", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("expected GET /capture to be refused with 503, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.Admin)(rec, httptest.NewRequest("GET", "/admin?done=maintenance-on", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "Maintenance mode is on") || !strings.Contains(body, "Turn off maintenance mode") {
+		t.Errorf("expected the admin panel to offer turning maintenance off")
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.AdminMaintenance)(rec, httptest.NewRequest("POST", "/admin/maintenance", nil))
+	rec = httptest.NewRecorder()
+	readOnly.ServeHTTP(rec, httptest.NewRequest("POST", "/api/tasks", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected changes to be served after maintenance, got %d", rec.Code)
+	}
+}
+
+func TestDemoLimits(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+	h.SetDemo(DemoConfig{WriteLimit: 2})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	limited := h.DemoLimits(ok)
+
+	post := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/tasks", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		limited.ServeHTTP(rec, req)
+		return rec
+	}
+	for i := 0; i < 2; i++ {
+		if rec := post("192.0.2.1:1234"); rec.Code != http.StatusNoContent {
+			t.Fatalf("expected change %d to be allowed, got %d", i+1, rec.Code)
+		}
+	}
+	if rec := post("192.0.2.1:5678"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("expected the third change to get %d with Retry-After, got %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if rec := post("192.0.2.2:1234"); rec.Code != http.StatusNoContent {
+		t.Errorf("expected another client to be allowed, got %d", rec.Code)
+	}
+	rec := httptest.NewRecorder()
+	limited.ServeHTTP(rec, httptest.NewRequest("GET", "/tasks", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected reads not to be limited, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.NotInDemo(h.CreateTaskAttachment))(rec, httptest.NewRequest("POST", "/api/tasks/1/attachments", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected attachments to be off in the demo, got %d", rec.Code)
+	}
+}
+
+func TestAdminActions(t *testing.T) {
+	h, _ := setupTestHandlers(t)
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.AdminBackup)(rec, httptest.NewRequest("POST", "/admin/backup", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a backup without BACKUP_DIR, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.AdminCheckpoint)(rec, httptest.NewRequest("POST", "/admin/checkpoint", nil))
+	if rec.Code != http.StatusSeeOther {
+		t.Errorf("expected a redirect after the checkpoint, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.AdminCleanup)(rec, httptest.NewRequest("POST", "/admin/cleanup", nil))
+	if rec.Code != http.StatusSeeOther || !strings.Contains(rec.Header().Get("Location"), "done=cleanup") {
+		t.Errorf("expected a redirect after the cleanup, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.AdminDiagnostic)(rec, httptest.NewRequest("POST", "/admin/diagnostic", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "SQLite format 3") {
+		t.Errorf("expected the anonymized database, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Disposition"); !strings.Contains(got, "mytasks-diagnostic-") {
+		t.Errorf("expected a download, got %q", got)
+	}
+}
+
+func TestAdminRedeliverWebhook(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+	status := http.StatusServiceUnavailable
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		io.WriteString(w, "try later")
+	}))
+	defer hook.Close()
+	sender := webhook.NewSender(s, hook.Client())
+	h.SetWebhooks(sender)
+
+	d, _ := sender.Send(ctx, "slack:T1", "overdue alert", hook.URL, []byte(`{"text":"Pay rent"}`))
+	rec := httptest.NewRecorder()
+	h.Handle(h.Admin)(rec, httptest.NewRequest("GET", "/admin", nil))
+	body := rec.Body.String()
+	for _, want := range []string{"overdue alert", "slack:T1", "503", "try later", fmt.Sprintf("/admin/webhooks/%d/redeliver", d.ID)} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the admin page", want)
+		}
+	}
+
+	redeliver := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/admin/webhooks/"+id+"/redeliver", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rec := httptest.NewRecorder()
+		h.Handle(h.AdminRedeliverWebhook)(rec, req)
+		return rec
+	}
+	id := strconv.FormatInt(d.ID, 10)
+	if rec := redeliver(id); rec.Header().Get("Location") != "/admin?done=redeliver-failed" {
+		t.Errorf("expected a redirect reporting the failure, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	status = http.StatusOK
+	if rec := redeliver(id); rec.Header().Get("Location") != "/admin?done=redelivered" {
+		t.Errorf("expected a redirect reporting the redelivery, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if d, _ = s.GetWebhookDelivery(ctx, d.ID); d.Status != models.WebhookDelivered || len(d.Attempts) != 3 {
+		t.Errorf("expected the delivery delivered on its third attempt, got %s after %d", d.Status, len(d.Attempts))
+	}
+	if rec := redeliver("999"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown delivery, got %d", rec.Code)
+	}
+}
+
+func TestSeedDemo(t *testing.T) {
+	h, s := setupTestHandlers(t)
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.SeedDemo)(rec, httptest.NewRequest("POST", "/api/admin/seed", nil))
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"projects":`) {
+		t.Fatalf("expected 201 with counts, got %d %s", rec.Code, rec.Body.String())
+	}
+	projects, _ := s.ListProjects(context.Background())
+	if len(projects) == 0 {
+		t.Fatal("expected demo projects")
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.SeedDemo)(rec, httptest.NewRequest("POST", "/api/admin/seed", nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 when seeding twice, got %d", rec.Code)
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	tests := map[ByteSize]string{
+		0:           "0 B",
+		1023:        "1023 B",
+		1536:        "1.5 KB",
+		5 << 20:     "5.0 MB",
+		3 << 30 / 2: "1.5 GB",
+	}
+	for size, want := range tests {
+		if got := size.String(); got != want {
+			t.Errorf("ByteSize(%d) = %q, want %q", int64(size), got, want)
+		}
+	}
+}
+
+func withIDParam(req *http.Request, id int64) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", fmt.Sprint(id))
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestTaskDetailHandler(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	other := &models.Project{Name: "Garden", Type: "project"}
+	s.CreateProject(ctx, other)
+	task := &models.Task{ProjectID: project.ID, Description: "Paint fence", Notes: "Buy **white** paint <script>alert(1)</script>", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, task)
+	task.ProjectID = other.ID
+	task.Status = "in_progress"
+	s.UpdateTask(ctx, task)
+	s.CreateSubtask(ctx, &models.Subtask{TaskID: task.ID, Title: "Sand the boards"})
+	s.CreateTaskComment(ctx, &models.TaskComment{TaskID: task.ID, Body: "See [the guide](https://example.com/guide)"})
+	for range 3 {
+		s.ToggleTaskComplete(ctx, task.ID) // done, reopened, done again
+	}
+
+	req := withIDParam(httptest.NewRequest("GET", fmt.Sprintf("/tasks/%d", task.ID), nil), task.ID)
+	rec := httptest.NewRecorder()
+	h.Handle(h.TaskDetail)(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"Paint fence",
+		"<strong>white</strong>",
+		"&lt;script&gt;",
+		"Sand the boards",
+		`<a href="https://example.com/guide" rel="noopener noreferrer" target="_blank">the guide</a>`,
+		"<del>Home</del> Garden",
+		`<span class="task-page-count">2 times</span>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected the page to contain %q", want)
+		}
+	}
+	if strings.Contains(body, "<script>alert") {
+		t.Error("expected HTML in the notes to be escaped")
+	}
+
+	req = withIDParam(httptest.NewRequest("GET", "/tasks/999", nil), 999)
+	rec = httptest.NewRecorder()
+	h.Handle(h.TaskDetail)(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing task, got %d", rec.Code)
+	}
+}
+
+func TestTaskCommentAndSubtaskHandlers(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Paint fence", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, task)
+
+	post := func(handler HandlerFunc, id int64, form string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := withIDParam(httptest.NewRequest("POST", "/", strings.NewReader(form)), id)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.Handle(handler)(rec, req)
+		return rec
+	}
+
+	if rec := post(h.CreateTaskComment, task.ID, "body=Done+*soon*"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<em>soon</em>") {
+		t.Errorf("expected the new comment rendered, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := post(h.CreateTaskComment, task.ID, "body=+"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a blank comment, got %d", rec.Code)
+	}
+	if rec := post(h.CreateTaskComment, 999, "body=hi"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 commenting on a missing task, got %d", rec.Code)
+	}
+
+	if rec := post(h.CreateSubtask, task.ID, "title=Sand"); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	subtasks, _ := s.ListSubtasks(ctx, task.ID)
+	if len(subtasks) != 1 {
+		t.Fatalf("expected 1 subtask, got %d", len(subtasks))
+	}
+	if rec := post(h.ToggleSubtask, subtasks[0].ID, ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "checked") {
+		t.Errorf("expected the subtask ticked off, got %d: %s", rec.Code, rec.Body)
+	}
+
+	req := withIDParam(httptest.NewRequest("DELETE", "/", nil), subtasks[0].ID)
+	rec := httptest.NewRecorder()
+	h.Handle(h.DeleteSubtask)(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", rec.Code)
+	}
+	if subtasks, _ := s.ListSubtasks(ctx, task.ID); len(subtasks) != 0 {
+		t.Errorf("expected the subtask deleted, got %v", subtasks)
+	}
+}
+
+func TestTaskAttachmentHandlers(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Paint fence", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, task)
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, _ := mw.CreateFormFile("file", "page.html")
+	part.Write([]byte("<script>alert(1)</script>"))
+	mw.Close()
+
+	req := withIDParam(httptest.NewRequest("POST", "/", &buf), task.ID)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	h.Handle(h.CreateTaskAttachment)(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "page.html") {
+		t.Fatalf("expected the new attachment rendered, got %d: %s", rec.Code, rec.Body)
+	}
+
+	attachments, _ := s.ListTaskAttachments(ctx, task.ID)
+	if len(attachments) != 1 {
+		t.Fatalf("expected 1 attachment, got %d", len(attachments))
+	}
+
+	req = withIDParam(httptest.NewRequest("GET", "/", nil), attachments[0].ID)
+	rec = httptest.NewRecorder()
+	h.Handle(h.DownloadTaskAttachment)(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if got := rec.Body.String(); got != "<script>alert(1)</script>" {
+		t.Errorf("expected the file back, got %q", got)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename=page.html` {
+		t.Errorf("expected the file sent as a download, got Content-Disposition %q", got)
+	}
+	if got := rec.Header().Get("Content-Security-Policy"); got != "sandbox" {
+		t.Errorf("expected a sandbox CSP, got %q", got)
+	}
+
+	req = withIDParam(httptest.NewRequest("POST", "/", strings.NewReader("x")), task.ID)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	h.Handle(h.CreateTaskAttachment)(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a file, got %d", rec.Code)
+	}
+}
+
+func TestProjectLinkHandlers(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Website", Type: "project"}
+	s.CreateProject(ctx, project)
+
+	send := func(method string, handler HandlerFunc, id int64, form string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := withIDParam(httptest.NewRequest(method, "/", strings.NewReader(form)), id)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		h.Handle(handler)(rec, req)
+		return rec
+	}
+
+	rec := send("POST", h.CreateProjectLink, project.ID, "title=&url=https://figma.com/file/1")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), ">figma.com</a>") {
+		t.Fatalf("expected the new link titled with its host, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := send("POST", h.CreateProjectLink, project.ID, "url=javascript:alert(1)"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a javascript: link, got %d", rec.Code)
+	}
+	if rec := send("POST", h.CreateProjectLink, 999, "url=https://example.com"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing project, got %d", rec.Code)
+	}
+
+	links, _ := s.ListProjectLinks(ctx, project.ID)
+	if len(links) != 1 {
+		t.Fatalf("expected 1 link, got %d", len(links))
+	}
+	rec = send("PUT", h.UpdateProjectLink, links[0].ID, "title=Designs&url=https://figma.com/file/2")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), ">Designs</a>") {
+		t.Errorf("expected the link retitled, got %d: %s", rec.Code, rec.Body)
+	}
+
+	req := withIDParam(httptest.NewRequest("GET", "/", nil), project.ID)
+	rec = httptest.NewRecorder()
+	h.Handle(h.KanbanBoard)(rec, req)
+	if !strings.Contains(rec.Body.String(), `href="https://figma.com/file/2"`) {
+		t.Error("expected the link in the board's Resources panel")
+	}
+
+	req = withIDParam(httptest.NewRequest("GET", "/", nil), project.ID)
+	rec = httptest.NewRecorder()
+	h.Handle(h.ListProjectLinks)(rec, req)
+	var listed []models.ProjectLink
+	if err := json.NewDecoder(rec.Body).Decode(&listed); err != nil || len(listed) != 1 || listed[0].Title != "Designs" {
+		t.Errorf("expected the link listed as JSON, got %+v (%v)", listed, err)
+	}
+
+	if rec := send("DELETE", h.DeleteProjectLink, links[0].ID, ""); rec.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", rec.Code)
+	}
+	if rec := send("DELETE", h.DeleteProjectLink, links[0].ID, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 deleting twice, got %d", rec.Code)
+	}
+}
+
+func TestSidebarShowsProjectCounts(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, project)
+	yesterday := time.Now().AddDate(0, 0, -1)
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Late", Priority: "high", Status: "todo", DueDate: &yesterday})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Soon", Priority: "high", Status: "todo", DueDate: &tomorrow})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Someday", Priority: "low", Status: "in_progress"})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Shipped", Priority: "low", Status: "done"})
+
+	req := withIDParam(httptest.NewRequest("GET", "/", nil), project.ID)
+	rec := httptest.NewRecorder()
+	h.Handle(h.KanbanBoard)(rec, req)
+	body := rec.Body.String()
+	for _, want := range []string{
+		`title="1 overdue">1</span>`,
+		`title="1 due this week">1</span>`,
+		`title="3 open">3</span>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected the sidebar to contain %q", want)
+		}
+	}
+}
+
+func TestSidebarShowsOverdueTargetDate(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	lastWeek := time.Now().AddDate(0, 0, -7)
+	s.CreateProject(ctx, &models.Project{Name: "Taxes", Type: "project", TargetDate: &lastWeek})
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.Upcoming)(rec, httptest.NewRequest("GET", "/upcoming", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `class="sidebar-item-date overdue"`) {
+		t.Errorf("expected the sidebar to flag the overdue target date, got %d", rec.Code)
+	}
+}
+
+func TestMoveTaskToProjectHandler(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	from := &models.Project{Name: "Home", Type: "project"}
+	to := &models.Project{Name: "Work", Type: "project"}
+	done := &models.Project{Name: "Shipped", Type: "project"}
+	s.CreateProject(ctx, from)
+	s.CreateProject(ctx, to)
+	s.CreateProject(ctx, done)
+	s.MarkProjectComplete(ctx, done.ID)
+	task := &models.Task{ProjectID: from.ID, Description: "Call plumber", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, task)
+	s.CreateTask(ctx, &models.Task{ProjectID: to.ID, Description: "Standup", Priority: "medium", Status: "todo"})
+
+	send := func(projectID int64) *httptest.ResponseRecorder {
+		t.Helper()
+		form := "project_id=" + strconv.FormatInt(projectID, 10)
+		req := withIDParam(httptest.NewRequest("POST", "/", strings.NewReader(form)), task.ID)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("HX-Request", "true")
+		rec := httptest.NewRecorder()
+		h.Handle(h.MoveTaskToProject)(rec, req)
+		return rec
+	}
+
+	if rec := send(done.ID); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 moving to a completed project, got %d", rec.Code)
+	}
+
+	rec := send(to.ID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`id="sidebar-project-` + strconv.FormatInt(from.ID, 10) + `" hx-swap-oob="innerHTML"`,
+		`id="sidebar-project-` + strconv.FormatInt(to.ID, 10) + `" hx-swap-oob="innerHTML"`,
+		`title="2 open">2</span>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected the response to contain %q, got %s", want, body)
+		}
+	}
+
+	moved, _ := s.GetTask(ctx, task.ID)
+	if moved.ProjectID != to.ID || moved.SortOrder != 2 {
+		t.Errorf("expected the task last in Work, got project %d at %d", moved.ProjectID, moved.SortOrder)
+	}
+}
+
+func TestTaskChangesRefreshCountsOutOfBand(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Garden", Type: "project"}
+	s.CreateProject(ctx, project)
+	yesterday := time.Now().AddDate(0, 0, -1)
+	task := &models.Task{ProjectID: project.ID, Description: "Mow", Priority: "low", Status: "todo", DueDate: &yesterday}
+	s.CreateTask(ctx, task)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Weed", Priority: "low", Status: "todo"})
+
+	send := func(method string, handler HandlerFunc, htmx bool) string {
+		t.Helper()
+		req := withIDParam(httptest.NewRequest(method, "/", nil), task.ID)
+		if htmx {
+			req.Header.Set("HX-Request", "true")
+		}
+		rec := httptest.NewRecorder()
+		h.Handle(handler)(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+		}
+		return rec.Body.String()
+	}
+
+	body := send("POST", h.ToggleTask, true)
+	for _, want := range []string{
+		`id="task-` + strconv.FormatInt(task.ID, 10) + `"`,
+		`id="sidebar-project-` + strconv.FormatInt(project.ID, 10) + `" hx-swap-oob="innerHTML"`,
+		`title="1 open">1</span>`,
+		`id="project-progress-` + strconv.FormatInt(project.ID, 10) + `" hx-swap-oob="true"`,
+		`<progress max="2" value="1"></progress>`,
+		`id="sidebar-upcoming-count" hx-swap-oob="true"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected the toggle response to contain %q, got %s", want, body)
+		}
+	}
+	if strings.Contains(body, "1 overdue or due this week") {
+		t.Error("expected the done task to leave the Upcoming badge")
+	}
+
+	if body := send("POST", h.ToggleTask, false); strings.Contains(body, "hx-swap-oob") {
+		t.Errorf("expected no out-of-band fragments outside htmx, got %s", body)
+	}
+
+	s.DeleteTask(ctx, task.ID+1)
+	body = send("DELETE", h.DeleteTask, true)
+	if !strings.Contains(body, "No tasks yet.") {
+		t.Errorf("expected the board's empty state after deleting its last task, got %s", body)
+	}
+}
+
+func TestNotificationHandlers(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	reminder := &models.Notification{Kind: models.NotificationReminder, Message: "Overdue: Pay rent"}
+	s.CreateNotification(ctx, reminder)
+	s.CreateNotification(ctx, &models.Notification{Kind: models.NotificationImport, Message: "Import finished"})
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.NotificationBell)(rec, httptest.NewRequest("GET", "/notifications", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `notification-count">2<`) || !strings.Contains(body, "Overdue: Pay rent") {
+		t.Errorf("expected the bell with 2 unread, got %s", body)
+	}
+
+	req := withIDParam(httptest.NewRequest("POST", "/api/notifications/1/read", nil), reminder.ID)
+	req.Header.Set("HX-Request", "true")
+	rec = httptest.NewRecorder()
+	h.Handle(h.MarkNotificationRead)(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `notification-count">1<`) || !strings.Contains(body, " open") {
+		t.Errorf("expected the open menu with 1 unread, got %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.MarkNotificationRead)(rec, withIDParam(httptest.NewRequest("POST", "/api/notifications/99/read", nil), 99))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected %d for a missing notification, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.MarkAllNotificationsRead)(rec, httptest.NewRequest("POST", "/api/notifications/read-all", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected %d, got %d: %s", http.StatusNoContent, rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.ListNotifications)(rec, httptest.NewRequest("GET", "/api/notifications", nil))
+	var inbox NotificationInbox
+	if err := json.Unmarshal(rec.Body.Bytes(), &inbox); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if inbox.Unread != 0 || len(inbox.Notifications) != 2 || inbox.Notifications[0].ReadAt == nil {
+		t.Errorf("expected 2 read notifications, got %+v", inbox)
+	}
+
+	if inbox.NextCursor != "" {
+		t.Errorf("expected no cursor when everything fits, got %q", inbox.NextCursor)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.ListNotifications)(rec, httptest.NewRequest("GET", "/api/notifications?limit=1", nil))
+	inbox = NotificationInbox{}
+	json.Unmarshal(rec.Body.Bytes(), &inbox)
+	if len(inbox.Notifications) != 1 || inbox.NextCursor == "" {
+		t.Fatalf("expected one notification and a cursor, got %+v", inbox)
+	}
+	rec = httptest.NewRecorder()
+	h.Handle(h.ListNotifications)(rec, httptest.NewRequest("GET", "/api/notifications?limit=1&cursor="+inbox.NextCursor, nil))
+	newest := inbox.Notifications[0].ID
+	inbox = NotificationInbox{}
+	json.Unmarshal(rec.Body.Bytes(), &inbox)
+	if len(inbox.Notifications) != 1 || inbox.Notifications[0].ID == newest || inbox.NextCursor != "" {
+		t.Errorf("expected the older notification on the last page, got %+v", inbox)
+	}
+}
+
+func TestProjectNotificationPrefsHandlers(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, project)
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.KanbanBoard)(rec, withIDParam(httptest.NewRequest("GET", "/projects/1", nil), project.ID))
+	if body := rec.Body.String(); !strings.Contains(body, `name="email" value="1" checked`) {
+		t.Errorf("expected the board to show email on by default")
+	}
+
+	req := withIDParam(httptest.NewRequest("PUT", "/api/projects/1/notifications", strings.NewReader("muted=1&slack=1")), project.ID)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	rec = httptest.NewRecorder()
+	h.Handle(h.UpdateProjectNotificationPrefs)(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `name="muted" value="1" checked`) || strings.Contains(body, `name="email" value="1" checked`) {
+		t.Errorf("expected the form muted with email off, got %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.GetProjectNotificationPrefs)(rec, withIDParam(httptest.NewRequest("GET", "/api/projects/1/notifications", nil), project.ID))
+	var prefs models.ProjectNotificationPrefs
+	if err := json.Unmarshal(rec.Body.Bytes(), &prefs); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := models.ProjectNotificationPrefs{ProjectID: project.ID, Muted: true, Slack: true}
+	if prefs != want {
+		t.Errorf("expected %+v, got %+v", want, prefs)
+	}
+
+	req = withIDParam(httptest.NewRequest("PUT", "/api/projects/99/notifications", nil), 99)
+	rec = httptest.NewRecorder()
+	h.Handle(h.UpdateProjectNotificationPrefs)(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected %d for a missing project, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestRecentHandlers(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	fence := &models.Task{ProjectID: project.ID, Description: "Paint fence", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, fence)
+	fence.Notes = "White, two coats"
+	s.UpdateTask(ctx, fence)
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.Recent)(rec, httptest.NewRequest("GET", "/recent", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "Paint fence") || !strings.Contains(body, "Changed: notes") {
+		t.Errorf("expected the task with what changed, got %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.ListRecent)(rec, httptest.NewRequest("GET", "/api/recent?limit=1", nil))
+	var items []models.RecentItem
+	if err := json.Unmarshal(rec.Body.Bytes(), &items); err != nil {
+		t.Fatalf("expected JSON, got %s (%v)", rec.Body.String(), err)
+	}
+	if len(items) != 1 || items[0].ID != fence.ID || len(items[0].Changed) != 1 {
+		t.Errorf("expected only the edited task, got %+v", items)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.ListRecent)(rec, httptest.NewRequest("GET", "/api/recent?limit=500", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for a limit over 200, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestExportProjects(t *testing.T) {
+	h, s := setupTestHandlersWithTemplates(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Paint fence", Priority: "medium", Status: "todo"})
+
+	rec := httptest.NewRecorder()
+	h.Handle(h.ImportPage)(rec, httptest.NewRequest("GET", "/import?export=1", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `name="project_id" value="1" checked`) {
+		t.Errorf("expected the project picked in the export form, got %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Handle(h.ExportProjects)(rec, httptest.NewRequest("GET", "/export/projects.zip?project_id=1&format=markdown", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("expected a zip, got %d %q: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename=mytasks-1-home.zip` {
+		t.Errorf("unexpected Content-Disposition %q", got)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil || len(zr.File) != 1 || zr.File[0].Name != "1-home/tasks.md" {
+		t.Errorf("expected only the project's Markdown, got %v (%v)", zr, err)
+	}
+
+	for _, tc := range []struct {
+		query string
+		code  int
+	}{
+		{"", http.StatusBadRequest},
+		{"project_id=x", http.StatusBadRequest},
+		{"project_id=1&format=pdf", http.StatusBadRequest},
+		{"project_id=99", http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		h.Handle(h.ExportProjects)(rec, httptest.NewRequest("GET", "/export/projects.zip?"+tc.query, nil))
+		if rec.Code != tc.code {
+			t.Errorf("%q: expected %d, got %d", tc.query, tc.code, rec.Code)
+		}
+		if rec.Header().Get("Content-Disposition") != "" {
+			t.Errorf("%q: expected no download on error", tc.query)
+		}
+	}
+}
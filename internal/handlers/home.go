@@ -6,18 +6,17 @@ import (
 )
 
 // Home redirects to the first active project's Kanban board, or shows an empty state.
-func (h *Handlers) Home(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) Home(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	activeProjects, err := h.loadActiveProjects(ctx)
 	if err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 
 	if len(activeProjects) > 0 {
-		http.Redirect(w, r, fmt.Sprintf("/projects/%d", activeProjects[0].ID), http.StatusFound)
-		return
+		http.Redirect(w, r, h.url(fmt.Sprintf("/projects/%d", activeProjects[0].ID)), http.StatusFound)
+		return nil
 	}
 
 	// No active projects — show empty state with sidebar
@@ -25,7 +24,9 @@ func (h *Handlers) Home(w http.ResponseWriter, r *http.Request) {
 		Title:          "My Tasks",
 		ActiveProjects: activeProjects,
 		CurrentView:    "home",
+		Prefs:          h.prefs(r.Context()),
+		Lang:           h.localizer(r),
 	}
 
-	h.renderTemplate(w, "empty.html", data)
+	return h.renderTemplate(w, "empty.html", data)
 }
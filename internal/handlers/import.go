@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mytasks/internal/events"
+	"mytasks/internal/export"
+	"mytasks/internal/importer"
+	"mytasks/internal/models"
+)
+
+// maxImportSize bounds uploaded or pasted import data.
+const maxImportSize = 5 << 20
+
+// ImportData holds data for the import page.
+type ImportData struct {
+	PageData
+	Formats []importer.Format
+	Format  string
+	Text    string
+	Summary *importer.Summary
+	Error   string
+	// Projects and ExportFormats fill the export form; ExportProjectID,
+	// from ?export, is checked in it to begin with.
+	Projects        []models.Project
+	ExportFormats   []string
+	ExportProjectID int64
+}
+
+// ImportPage renders the import form.
+func (h *Handlers) ImportPage(w http.ResponseWriter, r *http.Request) error {
+	return h.renderImport(w, r, ImportData{Format: importer.Formats[0].Name})
+}
+
+// Import parses pasted text or an uploaded file and creates the projects and
+// tasks it describes.
+func (h *Handlers) Import(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	if err := r.ParseMultipartForm(maxImportSize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return badRequest("invalid form data")
+	}
+
+	data := ImportData{Format: r.FormValue("format"), Text: r.FormValue("text")}
+	format, ok := importer.LookupFormat(data.Format)
+	if !ok {
+		data.Error = "Unknown import format."
+		return h.renderImport(w, r, data)
+	}
+
+	var source io.Reader = strings.NewReader(data.Text)
+	if file, _, err := r.FormFile("file"); err == nil {
+		defer file.Close()
+		source = file
+	} else if strings.TrimSpace(data.Text) == "" {
+		data.Error = "Paste some text or choose a file to import."
+		return h.renderImport(w, r, data)
+	}
+
+	projects, err := format.Parse(source)
+	if err != nil {
+		data.Error = "Could not read import: " + err.Error()
+		return h.renderImport(w, r, data)
+	}
+
+	return h.applyImport(w, r, format.Name, projects, data)
+}
+
+// ImportMicrosoftToDo pulls lists and tasks straight from Microsoft Graph
+// using a short-lived access token pasted by the user. The token is not stored.
+func (h *Handlers) ImportMicrosoftToDo(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	data := ImportData{Format: importer.MicrosoftToDoSource}
+	token := strings.TrimSpace(strings.TrimPrefix(r.FormValue("token"), "Bearer "))
+	if token == "" {
+		data.Error = "Paste a Microsoft Graph access token with the Tasks.Read permission."
+		return h.renderImport(w, r, data)
+	}
+
+	projects, err := importer.FetchMicrosoftToDo(r.Context(), h.httpClient(), h.graphBaseURL, token)
+	if err != nil {
+		data.Error = "Could not fetch from Microsoft To Do: " + err.Error()
+		return h.renderImport(w, r, data)
+	}
+
+	return h.applyImport(w, r, importer.MicrosoftToDoSource, projects, data)
+}
+
+// applyImport stores parsed projects, publishes the resulting events and
+// renders the summary.
+func (h *Handlers) applyImport(w http.ResponseWriter, r *http.Request, source string, projects []importer.Project, data ImportData) error {
+	summary, err := importer.Apply(r.Context(), h.store, source, projects)
+	if summary != nil {
+		for i := range summary.Projects {
+			p := &summary.Projects[i]
+			h.publish(r, events.Event{Type: events.ProjectCreated, ProjectID: p.ID, Project: p})
+		}
+		for i := range summary.Tasks {
+			t := &summary.Tasks[i]
+			h.publish(r, events.Event{Type: events.TaskCreated, TaskID: t.ID, ProjectID: t.ProjectID, Task: t})
+		}
+		for i := range summary.Updated {
+			c := &summary.Updated[i]
+			if c.PreviousProjectID != c.Task.ProjectID {
+				h.publish(r, events.Event{Type: events.TaskMoved, TaskID: c.Task.ID, ProjectID: c.Task.ProjectID, PreviousProjectID: c.PreviousProjectID, Task: &c.Task})
+			} else {
+				h.publish(r, events.Event{Type: events.TaskUpdated, TaskID: c.Task.ID, ProjectID: c.Task.ProjectID, Task: &c.Task})
+			}
+		}
+		h.publish(r, events.Event{Type: events.ImportFinished, Source: source, Count: len(summary.Tasks) + len(summary.Updated)})
+	}
+	if err != nil {
+		data.Error = "Import stopped: " + err.Error()
+	}
+
+	data.Summary = summary
+	data.Text = ""
+	return h.renderImport(w, r, data)
+}
+
+func (h *Handlers) renderImport(w http.ResponseWriter, r *http.Request, data ImportData) error {
+	activeProjects, err := h.loadActiveProjects(r.Context())
+	if err != nil {
+		return err
+	}
+
+	data.PageData = PageData{
+		Title:          "Import",
+		ActiveProjects: activeProjects,
+		CurrentView:    "import",
+		Prefs:          h.prefs(r.Context()),
+		Lang:           h.localizer(r),
+	}
+	data.Formats = importer.Formats
+	if data.Projects, err = h.store.ListProjects(r.Context()); err != nil {
+		return err
+	}
+	data.ExportFormats = export.Formats
+	data.ExportProjectID, _ = strconv.ParseInt(r.URL.Query().Get("export"), 10, 64)
+
+	if data.Error != "" && data.Summary == nil {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	return h.renderTemplate(w, "import.html", data)
+}
+
+// ExportTaskwarrior downloads every task as Taskwarrior JSON for `task import`.
+func (h *Handlers) ExportTaskwarrior(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="mytasks-taskwarrior.json"`)
+
+	stream := newJSONArrayStream(w)
+	err := importer.StreamTaskwarrior(r.Context(), h.store, func(tw importer.TaskwarriorTask) error {
+		return stream.Write(tw)
+	})
+	if err != nil {
+		return stream.Fail(r, err)
+	}
+	return stream.Close()
+}
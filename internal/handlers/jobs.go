@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"mytasks/internal/models"
+)
+
+// JobsData holds data for the background jobs page.
+type JobsData struct {
+	PageData
+	Jobs []models.JobStatus
+}
+
+// Jobs lists the scheduled background jobs with their last and next runs.
+func (h *Handlers) Jobs(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	jobs, err := h.store.ListJobStatuses(ctx)
+	if err != nil {
+		return err
+	}
+
+	activeProjects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	data := JobsData{
+		PageData: PageData{
+			Title:          "Background Jobs",
+			ActiveProjects: activeProjects,
+			CurrentView:    "jobs",
+			Prefs:          h.prefs(ctx),
+			Lang:           h.localizer(r),
+		},
+		Jobs: jobs,
+	}
+	return h.renderTemplate(w, "jobs_settings.html", data)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"mytasks/internal/models"
+	"mytasks/internal/store"
 )
 
 const donePruneWindowDays = 7
@@ -16,47 +17,86 @@ type KanbanData struct {
 	TodoTasks       []models.Task
 	InProgressTasks []models.Task
 	DoneTasks       []models.Task
+	// Links fill the Resources panel.
+	Links []models.ProjectLink
+	// Notifications are the project's notification preferences.
+	Notifications models.ProjectNotificationPrefs
+	// DefaultPriority is preselected in the new task forms.
+	DefaultPriority string
+	SortModes       []models.SortMode
+	// Filter narrows the columns; Filtered is set when it does.
+	Filter   store.TaskFilter
+	Filtered bool
+	// SortMode is the order the open columns are in: Filter.SortMode if
+	// chosen, else the project's own.
+	SortMode string
+	// CanReorder is set when the board shows whole columns in manual order,
+	// the only time dragging cards can't scramble the order of the rest.
+	CanReorder bool
 }
 
-// KanbanBoard renders the Kanban board for a project.
-func (h *Handlers) KanbanBoard(w http.ResponseWriter, r *http.Request) {
+// KanbanBoard renders the Kanban board for a project, narrowed by the
+// filters in the query string and sorted by its sort param, which defaults
+// to the project's sort mode.
+func (h *Handlers) KanbanBoard(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	id, err := parseID(r, "id")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
-		return
+		return badRequest("invalid project id")
 	}
 
 	project, err := h.store.GetProject(ctx, id)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "project not found")
-		return
+		return err
 	}
 
-	todoTasks, err := h.store.ListTasksByProjectAndStatus(ctx, id, "todo")
-	if err != nil {
-		respondServerError(w, err)
-		return
+	filter := parseBoardFilter(r)
+	filtered := filter != store.TaskFilter{}
+	filter.ProjectID = id
+	if mode := r.URL.Query().Get("sort"); models.ValidSortMode(mode) {
+		filter.SortMode = mode
+	}
+	sortMode := project.SortMode
+	if filter.SortMode != "" {
+		sortMode = filter.SortMode
 	}
 
-	inProgressTasks, err := h.store.ListTasksByProjectAndStatus(ctx, id, "in_progress")
+	todoFilter := filter
+	todoFilter.Status = "todo"
+	todoTasks, err := h.store.ListProjectTasks(ctx, todoFilter)
 	if err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 
-	since := time.Now().AddDate(0, 0, -donePruneWindowDays)
-	doneTasks, err := h.store.ListRecentDoneTasks(ctx, id, since)
+	inProgressFilter := filter
+	inProgressFilter.Status = "in_progress"
+	inProgressTasks, err := h.store.ListProjectTasks(ctx, inProgressFilter)
 	if err != nil {
-		respondServerError(w, err)
-		return
+		return err
+	}
+
+	// Due date filters are about work still to do, so they leave the Done
+	// column empty.
+	var doneTasks []models.Task
+	if filter.Due == "" {
+		since := time.Now().AddDate(0, 0, -donePruneWindowDays)
+		doneFilter := filter
+		doneFilter.Status = "done"
+		doneFilter.CompletedSince = &since
+		if doneTasks, err = h.store.ListProjectTasks(ctx, doneFilter); err != nil {
+			return err
+		}
 	}
 
 	activeProjects, err := h.loadActiveProjects(ctx)
 	if err != nil {
-		respondServerError(w, err)
-		return
+		return err
+	}
+	for _, p := range activeProjects {
+		if p.ID == project.ID {
+			project.Counts = p.Counts
+		}
 	}
 
 	// Set overdue flag on tasks
@@ -66,6 +106,29 @@ func (h *Handlers) KanbanBoard(w http.ResponseWriter, r *http.Request) {
 	for i := range inProgressTasks {
 		inProgressTasks[i].Overdue = inProgressTasks[i].IsOverdue()
 	}
+	prefs := h.prefs(ctx)
+	flagStale(prefs.StaleDays, todoTasks, inProgressTasks)
+
+	if err := h.linkGitHubIssues(ctx, todoTasks, inProgressTasks, doneTasks); err != nil {
+		return err
+	}
+	if err := h.attachRecurrences(ctx, todoTasks, inProgressTasks, doneTasks); err != nil {
+		return err
+	}
+
+	priority, err := h.defaultPriority(ctx)
+	if err != nil {
+		return err
+	}
+
+	links, err := h.store.ListProjectLinks(ctx, id)
+	if err != nil {
+		return err
+	}
+	notifications, err := h.store.GetProjectNotificationPrefs(ctx, id)
+	if err != nil {
+		return err
+	}
 
 	data := KanbanData{
 		PageData: PageData{
@@ -73,12 +136,39 @@ func (h *Handlers) KanbanBoard(w http.ResponseWriter, r *http.Request) {
 			ActiveProjects:   activeProjects,
 			CurrentProjectID: id,
 			CurrentView:      "kanban",
+			Prefs:            prefs,
+			Lang:             h.localizer(r),
 		},
 		Project:         project,
 		TodoTasks:       todoTasks,
 		InProgressTasks: inProgressTasks,
 		DoneTasks:       doneTasks,
+		Links:           links,
+		Notifications:   *notifications,
+		DefaultPriority: priority,
+		SortModes:       models.SortModes,
+		Filter:          filter,
+		Filtered:        filtered,
+		SortMode:        sortMode,
+		CanReorder:      !filtered && sortMode == models.SortManual,
 	}
 
-	h.renderTemplate(w, "kanban.html", data)
+	return h.renderTemplate(w, "kanban.html", data)
+}
+
+// parseBoardFilter reads the board's filters from the query string, dropping
+// values it does not recognize.
+func parseBoardFilter(r *http.Request) store.TaskFilter {
+	q := r.URL.Query()
+	var filter store.TaskFilter
+	switch v := q.Get("priority"); v {
+	case "high", "medium", "low":
+		filter.Priority = v
+	}
+	switch v := q.Get("due"); v {
+	case store.DueOverdue, store.DueThisWeek:
+		filter.Due = v
+	}
+	filter.HasNotes = q.Get("notes") == "1"
+	return filter
 }
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// maintenanceMessage answers changes refused in maintenance mode.
+const maintenanceMessage = "My Tasks is read-only for maintenance. Your change was not saved; try again in a few minutes."
+
+// SetMaintenance shares the read-only maintenance flag, which the admin
+// panel turns on and off.
+func (h *Handlers) SetMaintenance(flag *atomic.Bool) {
+	h.maintenance = flag
+}
+
+func (h *Handlers) inMaintenance() bool {
+	return h.maintenance != nil && h.maintenance.Load()
+}
+
+// ReadOnly refuses every request but GET, HEAD and OPTIONS with 503 while
+// maintenance mode is on. The admin panel stays writable, so backups can be
+// taken and maintenance turned off again, and /graphql refuses mutations
+// itself, since queries are POSTed too.
+func (h *Handlers) ReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !h.inMaintenance() || strings.HasPrefix(r.URL.Path, "/admin/") || r.URL.Path == "/graphql" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Retry-After", "120")
+		h.writeError(w, r, http.StatusServiceUnavailable, maintenanceMessage)
+	})
+}
+
+// NotInMaintenance refuses f with 503 while maintenance mode is on, for the
+// GET routes that change data, such as the capture bookmarklet and the
+// OAuth callbacks, which ReadOnly lets through.
+func (h *Handlers) NotInMaintenance(f HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if h.inMaintenance() {
+			w.Header().Set("Retry-After", "120")
+			return withStatus(http.StatusServiceUnavailable, maintenanceMessage)
+		}
+		return f(w, r)
+	}
+}
+
+// AdminMaintenance turns maintenance mode on when the form value on is
+// set, and off otherwise.
+func (h *Handlers) AdminMaintenance(w http.ResponseWriter, r *http.Request) error {
+	if h.maintenance == nil {
+		return notFound("maintenance mode is not available")
+	}
+	on := r.FormValue("on") != ""
+	h.maintenance.Store(on)
+	done := "maintenance-off"
+	if on {
+		done = "maintenance-on"
+	}
+	h.adminDone(w, r, url.Values{"done": {done}})
+	return nil
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// manifestIcon is an icon entry of the web app manifest.
+type manifestIcon struct {
+	Src     string `json:"src"`
+	Sizes   string `json:"sizes"`
+	Type    string `json:"type"`
+	Purpose string `json:"purpose,omitempty"`
+}
+
+// Manifest serves the web app manifest, which lets browsers install the app
+// to the home screen and open it in its own window.
+func (h *Handlers) Manifest(w http.ResponseWriter, r *http.Request) error {
+	manifest := struct {
+		Name            string         `json:"name"`
+		ShortName       string         `json:"short_name"`
+		StartURL        string         `json:"start_url"`
+		Scope           string         `json:"scope"`
+		Display         string         `json:"display"`
+		BackgroundColor string         `json:"background_color"`
+		ThemeColor      string         `json:"theme_color"`
+		Icons           []manifestIcon `json:"icons"`
+	}{
+		Name:            "My Tasks",
+		ShortName:       "Tasks",
+		StartURL:        h.url("/"),
+		Scope:           h.url("/"),
+		Display:         "standalone",
+		BackgroundColor: "#ffffff",
+		ThemeColor:      "#2563eb",
+		Icons: []manifestIcon{
+			{Src: h.url("/static/icons/icon.svg"), Sizes: "any", Type: "image/svg+xml"},
+			{Src: h.url("/static/icons/icon-192.png"), Sizes: "192x192", Type: "image/png"},
+			{Src: h.url("/static/icons/icon-512.png"), Sizes: "512x512", Type: "image/png"},
+			{Src: h.url("/static/icons/icon-512.png"), Sizes: "512x512", Type: "image/png", Purpose: "maskable"},
+		},
+	}
+	w.Header().Set("Content-Type", "application/manifest+json")
+	return json.NewEncoder(w).Encode(manifest)
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mytasks/internal/i18n"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// myDayRolloverSetting is the settings key holding the last day, as
+// YYYY-MM-DD, the carry-over prompt was answered, so it shows once a day.
+const myDayRolloverSetting = "my_day_rollover"
+
+// MyDayData holds data for the My Day template.
+type MyDayData struct {
+	PageData
+	Day   time.Time
+	Today bool
+	Tasks []models.Task
+	Done  int
+	// Carryover lists the unfinished tasks of the last earlier plan, from
+	// CarryoverFrom, while today's prompt hasn't been answered.
+	Carryover     []models.Task
+	CarryoverFrom time.Time
+}
+
+// MyDayButtonData holds data for the button adding a task to My Day.
+type MyDayButtonData struct {
+	TaskID  int64
+	Planned bool
+	Lang    *i18n.Localizer
+}
+
+// MyDayPlan is the JSON answer of GET /api/my-day.
+type MyDayPlan struct {
+	Day   string        `json:"day"`
+	Tasks []models.Task `json:"tasks"`
+}
+
+// MyDay renders today's plan, or that of the day given as ?day=YYYY-MM-DD.
+// The first visit of a day offers to carry over what was left unfinished
+// the last time there was a plan.
+func (h *Handlers) MyDay(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	today := dateOnly(time.Now())
+	day := today
+	if raw := r.URL.Query().Get("day"); raw != "" {
+		parsed := parseDate(raw)
+		if parsed == nil {
+			return badRequest("day must be YYYY-MM-DD")
+		}
+		day = *parsed
+	}
+
+	tasks, err := h.store.ListDayPlan(ctx, day)
+	if err != nil {
+		return err
+	}
+	projects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	data := MyDayData{
+		PageData: PageData{
+			Title:          "My Day",
+			ActiveProjects: projects,
+			CurrentView:    "my_day",
+			Prefs:          h.prefs(ctx),
+			Lang:           h.localizer(r),
+		},
+		Day:   day,
+		Today: day.Equal(today),
+		Tasks: tasks,
+	}
+	for _, task := range tasks {
+		if task.Status == "done" {
+			data.Done++
+		}
+	}
+	if data.Today {
+		answered, err := h.store.GetSetting(ctx, myDayRolloverSetting)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+		if answered != today.Format("2006-01-02") {
+			data.CarryoverFrom, data.Carryover, err = h.store.ListDayPlanCarryover(ctx, today)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return h.renderTemplate(w, "my_day.html", data)
+}
+
+// ListMyDay returns the plan for today, or for ?day=YYYY-MM-DD, as JSON.
+func (h *Handlers) ListMyDay(w http.ResponseWriter, r *http.Request) error {
+	day := dateOnly(time.Now())
+	if raw := r.URL.Query().Get("day"); raw != "" {
+		parsed := parseDate(raw)
+		if parsed == nil {
+			return badRequest("day must be YYYY-MM-DD")
+		}
+		day = *parsed
+	}
+
+	tasks, err := h.store.ListDayPlan(r.Context(), day)
+	if err != nil {
+		return err
+	}
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+	return writeJSON(w, r, MyDayPlan{Day: day.Format("2006-01-02"), Tasks: tasks})
+}
+
+// AddToMyDay adds a task to today's plan and re-renders its My Day button.
+func (h *Handlers) AddToMyDay(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid task id")
+	}
+	if err := h.store.AddToDayPlan(r.Context(), dateOnly(time.Now()), id); err != nil {
+		return err
+	}
+	return h.renderPartial(w, "my_day_button.html", MyDayButtonData{TaskID: id, Planned: true, Lang: h.localizer(r)})
+}
+
+// RemoveFromMyDay takes a task out of today's plan and re-renders its My
+// Day button.
+func (h *Handlers) RemoveFromMyDay(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid task id")
+	}
+	if err := h.store.RemoveFromDayPlan(r.Context(), dateOnly(time.Now()), id); err != nil {
+		return err
+	}
+	return h.renderPartial(w, "my_day_button.html", MyDayButtonData{TaskID: id, Lang: h.localizer(r)})
+}
+
+// RolloverMyDay carries unfinished tasks of the last plan over to today:
+// the one given as task_id, or all of them, which also answers the prompt.
+// The page reloads to show the new plan.
+func (h *Handlers) RolloverMyDay(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	today := dateOnly(time.Now())
+	if raw := r.FormValue("task_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return badRequest("invalid task id")
+		}
+		if err := h.store.AddToDayPlan(ctx, today, id); err != nil {
+			return err
+		}
+	} else {
+		_, carryover, err := h.store.ListDayPlanCarryover(ctx, today)
+		if err != nil {
+			return err
+		}
+		ids := make([]int64, len(carryover))
+		for i, task := range carryover {
+			ids[i] = task.ID
+		}
+		if err := h.store.AddToDayPlan(ctx, today, ids...); err != nil {
+			return err
+		}
+		if err := h.store.SetSetting(ctx, myDayRolloverSetting, today.Format("2006-01-02")); err != nil {
+			return err
+		}
+	}
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// DismissMyDayRollover answers today's carry-over prompt without carrying
+// anything over.
+func (h *Handlers) DismissMyDayRollover(w http.ResponseWriter, r *http.Request) error {
+	today := dateOnly(time.Now()).Format("2006-01-02")
+	if err := h.store.SetSetting(r.Context(), myDayRolloverSetting, today); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"mytasks/internal/i18n"
+	"mytasks/internal/models"
+)
+
+// notificationMenuSize is how many of the newest notifications the bell menu
+// shows, and GET /api/notifications by default.
+const notificationMenuSize = 20
+
+// maxNotificationsLimit caps the ?limit of GET /api/notifications.
+const maxNotificationsLimit = 100
+
+// NotificationsData holds data for the notification bell menu.
+type NotificationsData struct {
+	Notifications []models.Notification
+	Unread        int
+	// Open keeps the menu open when it is re-rendered after marking
+	// notifications read.
+	Open  bool
+	Prefs models.Settings
+	Lang  *i18n.Localizer
+}
+
+// NotificationInbox is the JSON answer of GET /api/notifications.
+type NotificationInbox struct {
+	Unread        int                   `json:"unread"`
+	Notifications []models.Notification `json:"notifications"`
+	// NextCursor fetches the older notifications after these; it is left
+	// out when there are none.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NotificationBell renders the sidebar bell with its unread count and the
+// menu listing the newest notifications.
+func (h *Handlers) NotificationBell(w http.ResponseWriter, r *http.Request) error {
+	return h.renderNotifications(w, r, false)
+}
+
+// ListNotifications returns the unread count and the newest notifications
+// as JSON, or the ones after ?cursor, ?limit at a time.
+func (h *Handlers) ListNotifications(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	after, limit, err := pageParams(r, notificationMenuSize, maxNotificationsLimit)
+	if err != nil {
+		return err
+	}
+	notifications, next, err := h.store.ListNotificationsPage(ctx, after, limit)
+	if err != nil {
+		return err
+	}
+	unread, err := h.store.CountUnreadNotifications(ctx)
+	if err != nil {
+		return err
+	}
+	if notifications == nil {
+		notifications = []models.Notification{}
+	}
+	return writeJSON(w, r, NotificationInbox{Unread: unread, Notifications: notifications, NextCursor: encodeCursor(next)})
+}
+
+// MarkNotificationRead marks one notification read. htmx gets the bell menu
+// back, other clients an empty answer.
+func (h *Handlers) MarkNotificationRead(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid notification id")
+	}
+	if err := h.store.MarkNotificationRead(r.Context(), id, time.Now()); err != nil {
+		return err
+	}
+	return h.notificationsChanged(w, r)
+}
+
+// MarkAllNotificationsRead empties the unread count.
+func (h *Handlers) MarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) error {
+	if err := h.store.MarkAllNotificationsRead(r.Context(), time.Now()); err != nil {
+		return err
+	}
+	return h.notificationsChanged(w, r)
+}
+
+func (h *Handlers) notificationsChanged(w http.ResponseWriter, r *http.Request) error {
+	if r.Header.Get("HX-Request") == "true" {
+		return h.renderNotifications(w, r, true)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *Handlers) renderNotifications(w http.ResponseWriter, r *http.Request, open bool) error {
+	ctx := r.Context()
+	notifications, err := h.store.ListNotifications(ctx, notificationMenuSize)
+	if err != nil {
+		return err
+	}
+	unread, err := h.store.CountUnreadNotifications(ctx)
+	if err != nil {
+		return err
+	}
+	return h.renderPartial(w, "notifications.html", NotificationsData{
+		Notifications: notifications,
+		Unread:        unread,
+		Open:          open,
+		Prefs:         h.prefs(ctx),
+		Lang:          h.localizer(r),
+	})
+}
+
+// ProjectNotificationsData holds data for a project's notification
+// preferences form.
+type ProjectNotificationsData struct {
+	Prefs models.ProjectNotificationPrefs
+	Lang  *i18n.Localizer
+}
+
+// GetProjectNotificationPrefs returns a project's notification preferences
+// as JSON.
+func (h *Handlers) GetProjectNotificationPrefs(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid project id")
+	}
+	prefs, err := h.store.GetProjectNotificationPrefs(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, r, prefs)
+}
+
+// UpdateProjectNotificationPrefs saves which channels announce a project's
+// tasks, from the checkboxes muted, email, slack and inbox; a checkbox left
+// out is off. htmx gets the form back, other clients JSON.
+func (h *Handlers) UpdateProjectNotificationPrefs(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid project id")
+	}
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	prefs := models.ProjectNotificationPrefs{
+		ProjectID: id,
+		Muted:     r.FormValue("muted") != "",
+		Email:     r.FormValue("email") != "",
+		Slack:     r.FormValue("slack") != "",
+		Inbox:     r.FormValue("inbox") != "",
+	}
+	if err := h.store.SaveProjectNotificationPrefs(r.Context(), &prefs); err != nil {
+		return err
+	}
+
+	if r.Header.Get("HX-Request") != "true" {
+		return writeJSON(w, r, prefs)
+	}
+	return h.renderPartial(w, "project_notifications.html", ProjectNotificationsData{Prefs: prefs, Lang: h.localizer(r)})
+}
@@ -2,39 +2,44 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"mytasks/internal/events"
+	"mytasks/internal/i18n"
 	"mytasks/internal/models"
+	"mytasks/internal/store"
 )
 
 // ProjectDetailData holds data for the project detail page.
 type ProjectDetailData struct {
 	Title   string
+	Prefs   models.Settings
+	Lang    *i18n.Localizer
 	Project *models.Project
 }
 
 // ProjectDetail renders the project detail page with active (not completed) tasks.
-func (h *Handlers) ProjectDetail(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) ProjectDetail(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	id, err := parseID(r, "id")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
-		return
+		return badRequest("invalid project id")
 	}
 
 	project, err := h.store.GetProject(ctx, id)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "project not found")
-		return
+		return err
 	}
 
 	// Load active tasks only (no limit)
 	tasks, err := h.store.ListTasksByProjectFiltered(ctx, id, false, 0)
 	if err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 	for i := range tasks {
 		tasks[i].InlineEdit = true
@@ -43,19 +48,20 @@ func (h *Handlers) ProjectDetail(w http.ResponseWriter, r *http.Request) {
 
 	data := ProjectDetailData{
 		Title:   project.Name,
+		Prefs:   h.prefs(ctx),
+		Lang:    h.localizer(r),
 		Project: project,
 	}
 
-	h.renderTemplate(w, "project_detail.html", data)
+	return h.renderTemplate(w, "project_detail.html", data)
 }
 
 // CreateProject creates a new project.
-func (h *Handlers) CreateProject(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) CreateProject(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	if err := r.ParseForm(); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid form data")
-		return
+		return badRequest("invalid form data")
 	}
 
 	project := &models.Project{
@@ -64,41 +70,54 @@ func (h *Handlers) CreateProject(w http.ResponseWriter, r *http.Request) {
 		Type:        r.FormValue("type"),
 		TargetDate:  parseDate(r.FormValue("target_date")),
 	}
+	project.ChecklistReset, project.ChecklistHour = parseChecklist(r)
 
 	if err := project.Validate(); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
-		return
+		return err
 	}
 
 	if err := h.store.CreateProject(ctx, project); err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 
+	h.publish(r, events.Event{Type: events.ProjectCreated, ProjectID: project.ID, Project: project})
+
 	// Redirect to the new project's Kanban board
-	w.Header().Set("HX-Redirect", fmt.Sprintf("/projects/%d", project.ID))
+	w.Header().Set("HX-Redirect", h.url(fmt.Sprintf("/projects/%d", project.ID)))
 	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// parseChecklist reads a project form's checklist schedule. An hour that
+// isn't a number is midnight; Validate rejects one out of range.
+func parseChecklist(r *http.Request) (reset string, hour int) {
+	reset = r.FormValue("checklist_reset")
+	if reset != "" {
+		hour, _ = strconv.Atoi(r.FormValue("checklist_hour"))
+	}
+	return reset, hour
 }
 
 // UpdateProject updates an existing project.
-func (h *Handlers) UpdateProject(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) UpdateProject(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	id, err := parseID(r, "id")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
-		return
+		return badRequest("invalid project id")
 	}
 
 	project, err := h.store.GetProject(ctx, id)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "project not found")
-		return
+		return err
+	}
+	if err := checkIfMatch(r, revisionETag("project", project.ID, project.Revision)); err != nil {
+		return err
 	}
+	revision := ifMatchRevision(r, project.Revision)
 
 	if err := r.ParseForm(); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid form data")
-		return
+		return badRequest("invalid form data")
 	}
 
 	project.Name = r.FormValue("name")
@@ -108,79 +127,147 @@ func (h *Handlers) UpdateProject(w http.ResponseWriter, r *http.Request) {
 	if project.Type == "category" {
 		project.TargetDate = nil
 	}
+	project.ChecklistReset, project.ChecklistHour = parseChecklist(r)
 
 	if err := project.Validate(); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
-		return
+		return err
 	}
 
-	if err := h.store.UpdateProject(ctx, project); err != nil {
-		respondServerError(w, err)
-		return
+	if err := h.store.UpdateProjectAtRevision(ctx, project, revision); err != nil {
+		return err
 	}
 
+	h.publish(r, events.Event{Type: events.ProjectUpdated, ProjectID: project.ID, Project: project})
+
+	w.Header().Set("ETag", revisionETag("project", project.ID, project.Revision))
 	w.Header().Set("HX-Refresh", "true")
 	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// GetProject returns one project as JSON, with an ETag to send back in
+// If-Match when changing it.
+func (h *Handlers) GetProject(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid project id")
+	}
+	project, err := h.store.GetProject(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	if notModified(w, r, revisionETag("project", project.ID, project.Revision)) {
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(project)
+}
+
+// SetProjectSortMode changes the order the project's tasks are listed in.
+func (h *Handlers) SetProjectSortMode(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid project id")
+	}
+
+	mode := r.FormValue("sort_mode")
+	if !models.ValidSortMode(mode) {
+		return badRequest("unsupported sort mode")
+	}
+
+	project, err := h.store.GetProject(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := h.store.SetProjectSortMode(ctx, id, mode); err != nil {
+		return err
+	}
+	project.SortMode = mode
+
+	h.publish(r, events.Event{Type: events.ProjectUpdated, ProjectID: id, Project: project})
+
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusOK)
+	return nil
 }
 
 // DeleteProject deletes a project.
-func (h *Handlers) DeleteProject(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) DeleteProject(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	id, err := parseID(r, "id")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
-		return
+		return badRequest("invalid project id")
+	}
+
+	if r.Header.Get("If-Match") != "" {
+		project, err := h.store.GetProject(ctx, id)
+		if errors.Is(err, store.ErrNotFound) {
+			return withStatus(http.StatusPreconditionFailed, "project no longer exists")
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkIfMatch(r, revisionETag("project", project.ID, project.Revision)); err != nil {
+			return err
+		}
 	}
 
 	if err := h.store.DeleteProject(ctx, id); err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 
+	h.publish(r, events.Event{Type: events.ProjectDeleted, ProjectID: id})
+
 	w.WriteHeader(http.StatusOK)
+	return nil
 }
 
 // CompleteProject marks a project as completed.
-func (h *Handlers) CompleteProject(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) CompleteProject(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	id, err := parseID(r, "id")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
-		return
+		return badRequest("invalid project id")
 	}
 
 	if err := h.store.MarkProjectComplete(ctx, id); err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 
-	w.Header().Set("HX-Redirect", "/archive")
+	h.publish(r, events.Event{Type: events.ProjectCompleted, ProjectID: id})
+
+	w.Header().Set("HX-Redirect", h.url("/projects/completed"))
 	w.WriteHeader(http.StatusOK)
+	return nil
 }
 
 // ReopenProject marks a completed project as incomplete.
-func (h *Handlers) ReopenProject(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) ReopenProject(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	id, err := parseID(r, "id")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
-		return
+		return badRequest("invalid project id")
 	}
 
 	if err := h.store.MarkProjectIncomplete(ctx, id); err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 
-	w.Header().Set("HX-Redirect", fmt.Sprintf("/projects/%d", id))
+	h.publish(r, events.Event{Type: events.ProjectReopened, ProjectID: id})
+
+	w.Header().Set("HX-Redirect", h.url(fmt.Sprintf("/projects/%d", id)))
 	w.WriteHeader(http.StatusOK)
+	return nil
 }
 
 // ReorderProjects updates the order of projects.
-func (h *Handlers) ReorderProjects(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) ReorderProjects(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	var payload struct {
@@ -188,34 +275,97 @@ func (h *Handlers) ReorderProjects(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid json")
-		return
+		return badRequest("invalid json")
 	}
 
 	if err := h.store.ReorderProjects(ctx, payload.IDs); err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 
+	h.publish(r, events.Event{Type: events.ProjectsReordered, IDs: payload.IDs})
+
 	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// ProjectHeaderData holds data for the name and description at the top of
+// a project's board, shown or being edited in place.
+type ProjectHeaderData struct {
+	Project *models.Project
+	Lang    *i18n.Localizer
+}
+
+// GetProjectHeader returns a project's board header, for cancelling an
+// inline edit.
+func (h *Handlers) GetProjectHeader(w http.ResponseWriter, r *http.Request) error {
+	return h.renderProjectHeader(w, r, "project_header.html")
+}
+
+// GetProjectHeaderForm returns the inline form for a project's name and
+// description.
+func (h *Handlers) GetProjectHeaderForm(w http.ResponseWriter, r *http.Request) error {
+	return h.renderProjectHeader(w, r, "project_header_form.html")
+}
+
+func (h *Handlers) renderProjectHeader(w http.ResponseWriter, r *http.Request, name string) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid project id")
+	}
+	project, err := h.store.GetProject(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return h.renderPartial(w, name, ProjectHeaderData{Project: project, Lang: h.localizer(r)})
+}
+
+// UpdateProjectHeader changes just a project's name and description, leaving
+// the rest to the full project form. htmx requests also get the project's
+// sidebar entry out of band.
+func (h *Handlers) UpdateProjectHeader(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid project id")
+	}
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	project, err := h.store.GetProject(ctx, id)
+	if err != nil {
+		return err
+	}
+	project.Name = strings.TrimSpace(r.FormValue("name"))
+	project.Description = strings.TrimSpace(r.FormValue("description"))
+	if err := project.Validate(); err != nil {
+		return err
+	}
+	if err := h.store.UpdateProject(ctx, project); err != nil {
+		return err
+	}
+
+	h.publish(r, events.Event{Type: events.ProjectUpdated, ProjectID: project.ID, Project: project})
+
+	w.Header().Set("ETag", revisionETag("project", project.ID, project.Revision))
+	return h.renderTaskChange(w, r, "project_header.html", ProjectHeaderData{Project: project, Lang: h.localizer(r)}, project.ID)
 }
 
 // GetProjectForm returns the project form for editing.
-func (h *Handlers) GetProjectForm(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) GetProjectForm(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	id, err := parseID(r, "id")
 	if err != nil {
 		// New project form
-		h.renderPartial(w, "project_form.html", nil)
-		return
+		return h.renderPartial(w, "project_form.html", nil)
 	}
 
 	project, err := h.store.GetProject(ctx, id)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "project not found")
-		return
+		return err
 	}
 
-	h.renderPartial(w, "project_form.html", project)
+	return h.renderPartial(w, "project_form.html", project)
 }
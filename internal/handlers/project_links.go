@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+
+	"mytasks/internal/models"
+)
+
+// ListProjectLinks returns a project's links as JSON.
+func (h *Handlers) ListProjectLinks(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	projectID, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid project id")
+	}
+	if _, err := h.store.GetProject(ctx, projectID); err != nil {
+		return err
+	}
+	links, err := h.store.ListProjectLinks(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if links == nil {
+		links = []models.ProjectLink{}
+	}
+	return writeJSON(w, r, links)
+}
+
+// CreateProjectLink adds a link to a project's Resources panel.
+func (h *Handlers) CreateProjectLink(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	projectID, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid project id")
+	}
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+	if _, err := h.store.GetProject(ctx, projectID); err != nil {
+		return err
+	}
+
+	link := &models.ProjectLink{
+		ProjectID: projectID,
+		Title:     r.FormValue("title"),
+		URL:       r.FormValue("url"),
+	}
+	if err := link.Validate(); err != nil {
+		return err
+	}
+	if err := h.store.CreateProjectLink(ctx, link); err != nil {
+		return err
+	}
+	return h.renderPartial(w, "project_link.html", link)
+}
+
+// UpdateProjectLink changes a link's title and URL.
+func (h *Handlers) UpdateProjectLink(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid link id")
+	}
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	link, err := h.store.GetProjectLink(ctx, id)
+	if err != nil {
+		return err
+	}
+	link.Title = r.FormValue("title")
+	link.URL = r.FormValue("url")
+	if err := link.Validate(); err != nil {
+		return err
+	}
+	if err := h.store.UpdateProjectLink(ctx, link); err != nil {
+		return err
+	}
+	return h.renderPartial(w, "project_link.html", link)
+}
+
+// DeleteProjectLink removes a link from a project.
+func (h *Handlers) DeleteProjectLink(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid link id")
+	}
+	if err := h.store.DeleteProjectLink(r.Context(), id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
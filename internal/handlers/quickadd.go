@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/i18n"
+	"mytasks/internal/models"
+	"mytasks/internal/nldate"
+	"mytasks/internal/quickadd"
+)
+
+// maxBatchTasks bounds how many lines one paste may create.
+const maxBatchTasks = 500
+
+// QuickAddData holds data for the sidebar capture box.
+type QuickAddData struct {
+	// Text is echoed back when the entry could not be added.
+	Text        string
+	Task        *models.Task
+	ProjectName string
+	Error       string
+	Lang        *i18n.Localizer
+}
+
+// BatchResult reports what a pasted list created.
+type BatchResult struct {
+	ProjectID int64  `json:"project_id"`
+	Created   int    `json:"created"`
+	Skipped   int    `json:"skipped"`
+	Error     string `json:"error,omitempty"`
+	// Text is echoed back to the form when the paste was rejected.
+	Text string `json:"-"`
+	// Priority stays selected in the re-rendered form.
+	Priority string `json:"-"`
+}
+
+// QuickAdd creates a task from one line of capture syntax, e.g.
+// "#Household !high @errands buy filters tomorrow". Without a "#project"
+// marker the task goes to ?project_id when given, otherwise the Inbox.
+func (h *Handlers) QuickAdd(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	text := r.FormValue("text")
+	task, project, err := h.quickAddTask(r, text, r.FormValue("project_id"), time.Now())
+	var ve *models.ValidationError
+	if errors.As(err, &ve) {
+		return h.renderQuickAdd(w, r, QuickAddData{Text: text, Error: ve.Message})
+	}
+	if err != nil {
+		return err
+	}
+	return h.renderQuickAdd(w, r, QuickAddData{Task: task, ProjectName: project.Name})
+}
+
+// quickAddTask creates the task a line of capture syntax describes, reading
+// dates relative to now. Text that can't be added comes back as a
+// *models.ValidationError with a translated message.
+func (h *Handlers) quickAddTask(r *http.Request, text, projectID string, now time.Time) (*models.Task, *models.Project, error) {
+	ctx := r.Context()
+	entry := quickadd.Parse(text, now)
+
+	var project *models.Project
+	switch {
+	case entry.Project != "":
+		projects, err := h.loadActiveProjects(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		project = quickadd.MatchProject(entry.Project, projects)
+		if project == nil {
+			return nil, nil, &models.ValidationError{Message: h.localizer(r).T("No active project named %q.", entry.Project)}
+		}
+	case projectID != "":
+		id, err := strconv.ParseInt(projectID, 10, 64)
+		if err == nil {
+			project, err = h.store.GetProject(ctx, id)
+		}
+		if err != nil || project.Completed {
+			return nil, nil, badRequest("invalid project id")
+		}
+	default:
+		var err error
+		if project, err = h.inboxProject(r); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	task := &models.Task{
+		ProjectID:   project.ID,
+		Description: entry.Description,
+		Priority:    entry.Priority,
+		Status:      "todo",
+		DueDate:     entry.Due,
+		Tags:        entry.Tags,
+		Recurrence:  entry.Recurrence,
+	}
+	if task.Priority == "" {
+		priority, err := h.defaultPriority(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		task.Priority = priority
+	}
+	if err := task.Validate(); err != nil {
+		return nil, nil, &models.ValidationError{Message: h.localizer(r).T(err.Error())}
+	}
+
+	if err := h.store.CreateTask(ctx, task); err != nil {
+		return nil, nil, err
+	}
+
+	h.publish(r, events.Event{Type: events.TaskCreated, TaskID: task.ID, ProjectID: task.ProjectID, Task: task})
+	return task, project, nil
+}
+
+// renderQuickAdd re-renders the capture box, with a 400 status when the
+// entry was rejected.
+func (h *Handlers) renderQuickAdd(w http.ResponseWriter, r *http.Request, data QuickAddData) error {
+	data.Lang = h.localizer(r)
+	if data.Error != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	return h.renderPartial(w, "quick_add.html", data)
+}
+
+// CreateTaskBatch creates one task per line of pasted text, or per item of a
+// Markdown checklist, in a single transaction. Ticked items, empty items and
+// lines repeating an open task in the project are skipped. Each line may end
+// with a date phrase, as in CreateTask. The result is JSON when the client
+// asks for it, otherwise the batch form partial.
+func (h *Handlers) CreateTaskBatch(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	projectID, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid project id")
+	}
+	project, err := h.store.GetProject(ctx, projectID)
+	if err != nil || project.Completed {
+		return notFound("project not found")
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	status := r.FormValue("status")
+	if status == "" {
+		status = "todo"
+	}
+	priority := r.FormValue("priority")
+	if priority == "" {
+		if priority, err = h.defaultPriority(ctx); err != nil {
+			return err
+		}
+	}
+
+	result := BatchResult{ProjectID: projectID, Priority: priority}
+	items := quickadd.ParseList(r.FormValue("text"))
+	if len(items) > maxBatchTasks {
+		result.Error = fmt.Sprintf("Paste at most %d lines at a time.", maxBatchTasks)
+		result.Text = r.FormValue("text")
+		return h.renderTaskBatch(w, r, result)
+	}
+
+	existing, err := h.store.ListTasksByProjectFiltered(ctx, projectID, false, 0)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(existing)+len(items))
+	for _, t := range existing {
+		seen[strings.ToLower(t.Description)] = true
+	}
+
+	now := time.Now()
+	var tasks []*models.Task
+	for _, item := range items {
+		parsed := nldate.Parse(item.Text, now)
+		task := &models.Task{
+			ProjectID:   projectID,
+			Description: parsed.Text,
+			Priority:    priority,
+			Status:      status,
+			DueDate:     parsed.Due,
+			Recurrence:  parsed.Recurrence,
+		}
+		key := strings.ToLower(task.Description)
+		if item.Checked || seen[key] || task.Validate() != nil {
+			result.Skipped++
+			continue
+		}
+		seen[key] = true
+		tasks = append(tasks, task)
+	}
+
+	if len(tasks) > 0 {
+		if err := h.store.CreateTasks(ctx, tasks); err != nil {
+			return err
+		}
+	}
+	result.Created = len(tasks)
+
+	for _, task := range tasks {
+		h.publish(r, events.Event{Type: events.TaskCreated, TaskID: task.ID, ProjectID: task.ProjectID, Task: task})
+	}
+	return h.renderTaskBatch(w, r, result)
+}
+
+func (h *Handlers) renderTaskBatch(w http.ResponseWriter, r *http.Request, result BatchResult) error {
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		if result.Error != "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		json.NewEncoder(w).Encode(result)
+		return nil
+	}
+
+	if result.Error != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	return h.renderPartial(w, "task_batch_form.html", result)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"mytasks/internal/fuzzy"
+	"mytasks/internal/store"
+)
+
+// quickFindLimit is how many results the quick switcher shows.
+const quickFindLimit = 15
+
+// QuickFindResult is one project or task offered by the quick switcher.
+type QuickFindResult struct {
+	Type    string `json:"type"` // "project" or "task"
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Project string `json:"project,omitempty"` // the task's project
+	URL     string `json:"url"`
+
+	score     int
+	updatedAt time.Time
+}
+
+// QuickFind fuzzy-matches ?q against project names and open task
+// descriptions for the Ctrl+K switcher. Results rank by match quality plus
+// a bonus for recently updated items; an empty query lists the most
+// recently updated.
+func (h *Handlers) QuickFind(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	q := r.URL.Query().Get("q")
+	now := time.Now()
+
+	projects, err := h.store.ListProjects(ctx)
+	if err != nil {
+		return err
+	}
+	tasks, err := h.store.ListOpenTasks(ctx, store.TaskFilter{})
+	if err != nil {
+		return err
+	}
+
+	results := []QuickFindResult{}
+	for _, p := range projects {
+		if score, ok := fuzzy.Score(q, p.Name); ok {
+			results = append(results, QuickFindResult{
+				Type: "project", ID: p.ID, Name: p.Name,
+				URL:   h.url(fmt.Sprintf("/projects/%d", p.ID)),
+				score: score + recencyBonus(p.UpdatedAt, now), updatedAt: p.UpdatedAt,
+			})
+		}
+	}
+	for _, t := range tasks {
+		if score, ok := fuzzy.Score(q, t.Description); ok {
+			results = append(results, QuickFindResult{
+				Type: "task", ID: t.ID, Name: t.Description, Project: t.ProjectName,
+				URL:   h.url(fmt.Sprintf("/projects/%d#task-%d", t.ProjectID, t.ID)),
+				score: score + recencyBonus(t.UpdatedAt, now), updatedAt: t.UpdatedAt,
+			})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].updatedAt.After(results[j].updatedAt)
+	})
+	if len(results) > quickFindLimit {
+		results = results[:quickFindLimit]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		return err
+	}
+	return nil
+}
+
+// recencyBonus favours items touched lately, so among similar matches the
+// one being worked on comes first.
+func recencyBonus(updated, now time.Time) int {
+	switch age := now.Sub(updated); {
+	case age < 24*time.Hour:
+		return 6
+	case age < 7*24*time.Hour:
+		return 4
+	case age < 30*24*time.Hour:
+		return 2
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Realtime upgrades to a WebSocket that streams task and project change events.
+// Query params:
+//   - project_id: optional; limits task events to a single project.
+func (h *Handlers) Realtime(w http.ResponseWriter, r *http.Request) error {
+	if h.hub == nil {
+		return notFound("realtime updates are disabled")
+	}
+
+	var projectID int64
+	if raw := r.URL.Query().Get("project_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id < 0 {
+			return badRequest("invalid project_id")
+		}
+		projectID = id
+	}
+
+	h.hub.ServeWS(w, r, projectID)
+	return nil
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"mytasks/internal/models"
+)
+
+// Sizes of the Recent list.
+const (
+	defaultRecentLimit = 50
+	maxRecentLimit     = 200
+)
+
+// RecentData holds data for the Recent template.
+type RecentData struct {
+	PageData
+	Items []models.RecentItem
+}
+
+// Recent renders the tasks and projects changed last, across every project,
+// with what each edit changed.
+func (h *Handlers) Recent(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	items, err := h.store.ListRecentlyChanged(ctx, defaultRecentLimit)
+	if err != nil {
+		return err
+	}
+	projects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	return h.renderTemplate(w, "recent.html", RecentData{
+		PageData: PageData{
+			Title:          "Recent",
+			ActiveProjects: projects,
+			CurrentView:    "recent",
+			Prefs:          h.prefs(ctx),
+			Lang:           h.localizer(r),
+		},
+		Items: items,
+	})
+}
+
+// ListRecent returns the tasks and projects changed last as JSON, most
+// recent first; ?limit (1-200, default 50) caps how many.
+func (h *Handlers) ListRecent(w http.ResponseWriter, r *http.Request) error {
+	limit := defaultRecentLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > maxRecentLimit {
+			return badRequest("invalid limit")
+		}
+	}
+
+	items, err := h.store.ListRecentlyChanged(r.Context(), limit)
+	if err != nil {
+		return err
+	}
+	if items == nil {
+		items = []models.RecentItem{}
+	}
+	return writeJSON(w, r, items)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// Report is the JSON body of GET /api/reports.
+type Report struct {
+	From    string             `json:"from"`
+	To      string             `json:"to"`
+	GroupBy string             `json:"group_by"`
+	Rows    []models.ReportRow `json:"rows"`
+	Totals  models.ReportRow   `json:"totals"`
+}
+
+// Reports returns created and completed task counts for a date range.
+// ?from and ?to are inclusive YYYY-MM-DD dates defaulting to the current
+// month so far; ?group_by is project (default), priority or week, where weeks
+// start on the day chosen in the settings. The
+// response is JSON unless ?format=csv or the client accepts text/csv.
+func (h *Handlers) Reports(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query()
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := dateOnly(now)
+	if v := q.Get("from"); v != "" {
+		t := parseDate(v)
+		if t == nil {
+			return badRequest("invalid from date")
+		}
+		from = *t
+	}
+	if v := q.Get("to"); v != "" {
+		t := parseDate(v)
+		if t == nil {
+			return badRequest("invalid to date")
+		}
+		to = *t
+	}
+	if to.Before(from) {
+		return badRequest("to must not be before from")
+	}
+
+	groupBy := q.Get("group_by")
+	switch groupBy {
+	case "":
+		groupBy = store.GroupByProject
+	case store.GroupByProject, store.GroupByPriority, store.GroupByWeek:
+	default:
+		return badRequest("group_by must be project, priority or week")
+	}
+
+	rows, err := h.store.ReportCounts(r.Context(), from, to, groupBy, h.prefs(r.Context()).WeekStart)
+	if err != nil {
+		return err
+	}
+
+	report := Report{
+		From:    from.Format("2006-01-02"),
+		To:      to.Format("2006-01-02"),
+		GroupBy: groupBy,
+		Rows:    rows,
+		Totals:  models.ReportRow{Group: "total"},
+	}
+	for _, row := range rows {
+		report.Totals.Created += row.Created
+		report.Totals.Completed += row.Completed
+	}
+
+	if wantsCSV(r) {
+		writeReportCSV(w, report)
+		return nil
+	}
+
+	return writeJSON(w, r, report)
+}
+
+func wantsCSV(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "csv"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/csv")
+}
+
+// writeReportCSV writes one line per group followed by a totals line.
+func writeReportCSV(w http.ResponseWriter, report Report) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="mytasks-report-%s-%s.csv"`, report.From, report.To))
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{report.GroupBy, "created", "completed"})
+	for _, row := range append(report.Rows, report.Totals) {
+		cw.Write([]string{row.Group, strconv.Itoa(row.Created), strconv.Itoa(row.Completed)})
+	}
+	cw.Flush()
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mytasks/internal/mail"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// ReportSettingsData holds data for the emailed reports page.
+type ReportSettingsData struct {
+	PageData
+	Schedules []ReportScheduleView
+	// Configured reports whether SMTP is set up to send the reports.
+	Configured bool
+	Error      string
+	Notice     string
+}
+
+// ReportScheduleView is a report schedule with its next run, zero when the
+// schedule never fires again.
+type ReportScheduleView struct {
+	models.ReportSchedule
+	Next time.Time
+}
+
+// SetReports enables emailing reports on the schedules kept in the store.
+func (h *Handlers) SetReports(reports *mail.Reports) {
+	h.reports = reports
+}
+
+// ReportSettings lists the emailed report schedules and the form to add one.
+func (h *Handlers) ReportSettings(w http.ResponseWriter, r *http.Request) error {
+	notice := ""
+	if r.URL.Query().Get("sent") != "" {
+		notice = "The report was sent."
+	}
+	return h.renderReportSettings(w, r, "", notice)
+}
+
+func (h *Handlers) renderReportSettings(w http.ResponseWriter, r *http.Request, formError, notice string) error {
+	ctx := r.Context()
+
+	schedules, err := h.store.ListReportSchedules(ctx)
+	if err != nil {
+		return err
+	}
+	activeProjects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if formError != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	prefs := h.prefs(ctx)
+	data := ReportSettingsData{
+		PageData: PageData{
+			Title:          "Emailed Reports",
+			ActiveProjects: activeProjects,
+			CurrentView:    "reports",
+			Prefs:          prefs,
+			Lang:           h.localizer(r),
+		},
+		Configured: h.reports != nil,
+		Error:      formError,
+		Notice:     notice,
+	}
+	for _, rs := range schedules {
+		next, _ := mail.NextReport(rs, prefs.Location())
+		data.Schedules = append(data.Schedules, ReportScheduleView{ReportSchedule: rs, Next: next})
+	}
+	return h.renderTemplate(w, "report_settings.html", data)
+}
+
+// CreateReportSchedule adds a report emailed every day, or every week on the
+// chosen day, at the chosen time.
+func (h *Handlers) CreateReportSchedule(w http.ResponseWriter, r *http.Request) error {
+	if h.reports == nil {
+		return notFound("set SMTP_HOST and SMTP_FROM to email reports")
+	}
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	at, err := time.Parse("15:04", r.FormValue("time"))
+	if err != nil {
+		return h.renderReportSettings(w, r, "Choose a time of day.", "")
+	}
+	weekday := "*"
+	if day := r.FormValue("day"); day != "" {
+		n, err := strconv.Atoi(day)
+		if err != nil || n < 0 || n > 6 {
+			return h.renderReportSettings(w, r, "Choose a day of the week.", "")
+		}
+		weekday = day
+	}
+	days, err := strconv.Atoi(r.FormValue("days"))
+	if err != nil {
+		return h.renderReportSettings(w, r, "Enter how many days the report covers.", "")
+	}
+
+	rs := &models.ReportSchedule{
+		Schedule: fmt.Sprintf("%d %d * * %s", at.Minute(), at.Hour(), weekday),
+		Days:     days,
+	}
+	for _, addr := range strings.Split(r.FormValue("recipients"), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			rs.Recipients = append(rs.Recipients, addr)
+		}
+	}
+	if err := rs.Validate(); err != nil {
+		return h.renderReportSettings(w, r, err.Error(), "")
+	}
+	if err := h.store.CreateReportSchedule(r.Context(), rs); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, h.url("/settings/reports"), http.StatusSeeOther)
+	return nil
+}
+
+// SendReportNow emails a scheduled report right away.
+func (h *Handlers) SendReportNow(w http.ResponseWriter, r *http.Request) error {
+	if h.reports == nil {
+		return notFound("set SMTP_HOST and SMTP_FROM to email reports")
+	}
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid report schedule id")
+	}
+	if err := h.reports.SendNow(r.Context(), id); errors.Is(err, store.ErrNotFound) {
+		return err
+	} else if err != nil {
+		return h.renderReportSettings(w, r, "Could not send the report: "+err.Error(), "")
+	}
+
+	http.Redirect(w, r, h.url("/settings/reports?sent=1"), http.StatusSeeOther)
+	return nil
+}
+
+// DeleteReportSchedule stops a scheduled report.
+func (h *Handlers) DeleteReportSchedule(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid report schedule id")
+	}
+	if err := h.store.DeleteReportSchedule(r.Context(), id); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, h.url("/settings/reports"), http.StatusSeeOther)
+	return nil
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// reviewSnoozeDays is how far "Snooze" pushes a task's due date.
+const reviewSnoozeDays = 7
+
+// ReviewData holds data for the review overview.
+type ReviewData struct {
+	PageData
+	Projects  []ReviewProject
+	StaleDays int
+	// Someday lists the tasks parked in Someday/Maybe, to promote back or
+	// leave for another week.
+	Someday []models.Task
+}
+
+// ReviewProject summarizes what the review found in one project.
+type ReviewProject struct {
+	models.Project
+	LastReviewed *time.Time
+	OpenCount    int
+	Stale        []models.Task
+	Undated      []models.Task
+}
+
+// Empty reports whether the project has no open tasks.
+func (p ReviewProject) Empty() bool {
+	return p.OpenCount == 0
+}
+
+// ReviewProjectData holds data for reviewing a single project.
+type ReviewProjectData struct {
+	PageData
+	ReviewProject
+	StaleDays   int
+	Position    int
+	Total       int
+	NextProject *models.Project
+}
+
+// Review renders the weekly review overview: every active project with its
+// stale tasks, tasks missing due dates and when it was last reviewed, and
+// the Someday/Maybe tasks.
+func (h *Handlers) Review(w http.ResponseWriter, r *http.Request) error {
+	staleDays := parseStaleDays(r, h.prefs(r.Context()).StaleDays)
+
+	projects, activeProjects, err := h.loadReviewProjects(r, staleDays)
+	if err != nil {
+		return err
+	}
+	someday, err := h.store.ListOpenTasks(r.Context(), store.TaskFilter{Someday: true, Sort: store.SortProject})
+	if err != nil {
+		return err
+	}
+
+	data := ReviewData{
+		PageData: PageData{
+			Title:          "Weekly Review",
+			ActiveProjects: activeProjects,
+			CurrentView:    "review",
+			Prefs:          h.prefs(r.Context()),
+			Lang:           h.localizer(r),
+		},
+		Projects:  projects,
+		StaleDays: staleDays,
+		Someday:   someday,
+	}
+
+	return h.renderTemplate(w, "review.html", data)
+}
+
+// ReviewProject renders one step of the review. Projects are walked in
+// sidebar order.
+func (h *Handlers) ReviewProject(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid project id")
+	}
+	staleDays := parseStaleDays(r, h.prefs(r.Context()).StaleDays)
+
+	projects, activeProjects, err := h.loadReviewProjects(r, staleDays)
+	if err != nil {
+		return err
+	}
+
+	i := reviewIndex(projects, id)
+	if i < 0 {
+		return notFound("project not found")
+	}
+
+	data := ReviewProjectData{
+		PageData: PageData{
+			Title:            "Review: " + projects[i].Name,
+			ActiveProjects:   activeProjects,
+			CurrentProjectID: id,
+			CurrentView:      "review",
+			Prefs:            h.prefs(r.Context()),
+			Lang:             h.localizer(r),
+		},
+		ReviewProject: projects[i],
+		StaleDays:     staleDays,
+		Position:      i + 1,
+		Total:         len(projects),
+	}
+	if i+1 < len(projects) {
+		data.NextProject = &projects[i+1].Project
+	}
+
+	return h.renderTemplate(w, "review_project.html", data)
+}
+
+// CompleteProjectReview records that a project was reviewed and moves on to
+// the next one, or back to the overview after the last.
+func (h *Handlers) CompleteProjectReview(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid project id")
+	}
+
+	projects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+	i := -1
+	for j, p := range projects {
+		if p.ID == id {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return notFound("project not found")
+	}
+
+	if err := h.store.RecordProjectReview(ctx, id, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	next := "/review"
+	if i+1 < len(projects) {
+		next = "/review/" + strconv.FormatInt(projects[i+1].ID, 10)
+	}
+	if v := r.URL.Query().Get("stale"); v != "" {
+		next += "?stale=" + strconv.Itoa(parseStaleDays(r, h.prefs(r.Context()).StaleDays))
+	}
+	http.Redirect(w, r, h.url(next), http.StatusSeeOther)
+	return nil
+}
+
+// SnoozeReviewTask pushes a task's due date a week out. Saving the task also
+// marks it as touched, so it drops off the stale list.
+func (h *Handlers) SnoozeReviewTask(w http.ResponseWriter, r *http.Request) error {
+	return h.updateReviewTask(w, r, func(task *models.Task) {
+		due := dateOnly(time.Now()).AddDate(0, 0, reviewSnoozeDays)
+		task.DueDate = &due
+	})
+}
+
+// ReprioritizeReviewTask sets a task's priority from the review.
+func (h *Handlers) ReprioritizeReviewTask(w http.ResponseWriter, r *http.Request) error {
+	return h.updateReviewTask(w, r, func(task *models.Task) {
+		task.Priority = r.FormValue("priority")
+	})
+}
+
+func (h *Handlers) updateReviewTask(w http.ResponseWriter, r *http.Request, apply func(*models.Task)) error {
+	ctx := r.Context()
+
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid task id")
+	}
+
+	task, err := h.store.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	wasDone := task.IsDone()
+	apply(task)
+	if err := task.Validate(); err != nil {
+		return err
+	}
+
+	if err := h.store.UpdateTask(ctx, task); err != nil {
+		return err
+	}
+
+	h.publishTaskChange(r, task, wasDone, task.ProjectID)
+	return h.renderPartial(w, "review_task.html", map[string]interface{}{
+		"Task":  task,
+		"Prefs": h.prefs(ctx),
+	})
+}
+
+// loadReviewProjects buckets open tasks by project. A task untouched for
+// more than staleDays is listed as stale; other open tasks without a due
+// date are listed as undated.
+func (h *Handlers) loadReviewProjects(r *http.Request, staleDays int) ([]ReviewProject, []models.Project, error) {
+	ctx := r.Context()
+
+	activeProjects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	tasks, err := h.store.ListOpenTasks(ctx, store.TaskFilter{Sort: store.SortProject})
+	if err != nil {
+		return nil, nil, err
+	}
+	reviews, err := h.store.ListLastProjectReviews(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	projects := make([]ReviewProject, len(activeProjects))
+	index := make(map[int64]int, len(activeProjects))
+	for i, p := range activeProjects {
+		projects[i] = ReviewProject{Project: p}
+		if reviewed, ok := reviews[p.ID]; ok {
+			projects[i].LastReviewed = &reviewed
+		}
+		index[p.ID] = i
+	}
+
+	for _, task := range tasks {
+		i, ok := index[task.ProjectID]
+		if !ok {
+			continue
+		}
+		p := &projects[i]
+		p.OpenCount++
+		switch {
+		case task.IsStale(staleDays):
+			p.Stale = append(p.Stale, task)
+		case task.DueDate == nil:
+			p.Undated = append(p.Undated, task)
+		}
+	}
+
+	return projects, activeProjects, nil
+}
+
+func reviewIndex(projects []ReviewProject, id int64) int {
+	for i, p := range projects {
+		if p.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseStaleDays reads ?stale (1–365 days), defaulting to the stale
+// threshold setting.
+func parseStaleDays(r *http.Request, fallback int) int {
+	if v := r.URL.Query().Get("stale"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err == nil && d >= 1 && d <= models.MaxStaleDays {
+			return d
+		}
+	}
+	return fallback
+}
+
+// flagStale marks the open tasks in lists untouched for more than staleDays.
+func flagStale(staleDays int, lists ...[]models.Task) {
+	for _, tasks := range lists {
+		for i := range tasks {
+			tasks[i].Stale = tasks[i].IsStale(staleDays)
+		}
+	}
+}
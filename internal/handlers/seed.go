@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"mytasks/internal/seed"
+)
+
+// SeedDemo fills the database with demo projects and tasks, like
+// "mytasks seed". It refuses if there are projects already, unless
+// ?force=1 is given. Only mounted in development mode.
+func (h *Handlers) SeedDemo(w http.ResponseWriter, r *http.Request) error {
+	result, err := seed.Run(r.Context(), h.store, time.Now(), r.URL.Query().Get("force") == "1")
+	if errors.Is(err, seed.ErrNotEmpty) {
+		return withStatus(http.StatusConflict, "the database already has projects; add ?force=1 to seed anyway")
+	}
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	return json.NewEncoder(w).Encode(result)
+}
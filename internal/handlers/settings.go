@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"mytasks/internal/i18n"
+	"mytasks/internal/models"
+)
+
+// SettingsData holds data for the settings page.
+type SettingsData struct {
+	PageData
+	Settings    models.Settings
+	DateFormats []models.DateFormat
+	Languages   []i18n.Language
+	Palettes    []string
+	// Sample is a fixed date shown in each format.
+	Sample time.Time
+	Saved  bool
+	Error  string
+}
+
+// Settings renders the preferences form.
+func (h *Handlers) Settings(w http.ResponseWriter, r *http.Request) error {
+	settings, err := h.store.GetSettings(r.Context())
+	if err != nil {
+		return err
+	}
+	return h.renderSettings(w, r, SettingsData{Settings: *settings, Saved: r.URL.Query().Get("saved") == "1"})
+}
+
+// UpdateSettings saves the preferences form.
+func (h *Handlers) UpdateSettings(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	upcomingDays, _ := strconv.Atoi(r.FormValue("upcoming_days"))
+	staleDays, _ := strconv.Atoi(r.FormValue("stale_days"))
+	escalateDays, _ := strconv.Atoi(r.FormValue("escalate_days"))
+	weekStart, _ := strconv.Atoi(r.FormValue("week_start"))
+	settings := models.Settings{
+		DefaultPriority: r.FormValue("default_priority"),
+		UpcomingDays:    upcomingDays,
+		StaleDays:       staleDays,
+		EscalateDays:    escalateDays,
+		DateFormat:      r.FormValue("date_format"),
+		WeekStart:       time.Weekday(weekStart),
+		Theme:           r.FormValue("theme"),
+		Palette:         r.FormValue("palette"),
+		Language:        r.FormValue("language"),
+		TimeZone:        strings.TrimSpace(r.FormValue("time_zone")),
+		QuietHoursStart: r.FormValue("quiet_hours_start"),
+		QuietHoursEnd:   r.FormValue("quiet_hours_end"),
+		DigestTime:      r.FormValue("digest_time"),
+	}
+	err := settings.Validate()
+	if err == nil && settings.Palette != "" && !slices.Contains(h.palettes, settings.Palette) {
+		err = errors.New("unsupported color palette")
+	}
+	if err == nil && (settings.QuietHoursStart == "") != (settings.QuietHoursEnd == "") {
+		err = errors.New("quiet hours need both a start and an end")
+	}
+	if err != nil {
+		return h.renderSettings(w, r, SettingsData{Settings: settings, Error: h.localizer(r).T(err.Error())})
+	}
+
+	if err := h.store.SaveSettings(r.Context(), &settings); err != nil {
+		return err
+	}
+	if err := h.store.EscalatePriorities(r.Context(), settings.EscalateDays, time.Now()); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, h.url("/settings?saved=1"), http.StatusSeeOther)
+	return nil
+}
+
+func (h *Handlers) renderSettings(w http.ResponseWriter, r *http.Request, data SettingsData) error {
+	activeProjects, err := h.loadActiveProjects(r.Context())
+	if err != nil {
+		return err
+	}
+
+	data.PageData = PageData{
+		Title:          "Settings",
+		ActiveProjects: activeProjects,
+		CurrentView:    "settings",
+		Prefs:          h.prefs(r.Context()),
+		Lang:           h.localizer(r),
+	}
+	data.DateFormats = models.DateFormats
+	data.Languages = i18n.Languages
+	data.Palettes = h.palettes
+	data.Sample = time.Date(time.Now().Year(), time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	if data.Error != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	return h.renderTemplate(w, "settings.html", data)
+}
+
+// ToggleTheme saves the theme from the sidebar toggle. The form sends either
+// an explicit "theme" or "current", the theme the browser is showing, which
+// is flipped. The page updates itself on the themeChanged event.
+func (h *Handlers) ToggleTheme(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	settings, err := h.store.GetSettings(r.Context())
+	if err != nil {
+		return err
+	}
+
+	settings.Theme = r.FormValue("theme")
+	if settings.Theme == "" {
+		settings.Theme = "dark"
+		if r.FormValue("current") == "dark" {
+			settings.Theme = "light"
+		}
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	if err := h.store.SaveSettings(r.Context(), settings); err != nil {
+		return err
+	}
+
+	w.Header().Set("HX-Trigger", fmt.Sprintf(`{"themeChanged": %q}`, settings.Theme))
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// prefs returns the settings page templates are styled with. Pages still
+// render with the defaults when the settings cannot be read.
+func (h *Handlers) prefs(ctx context.Context) models.Settings {
+	settings, err := h.store.GetSettings(ctx)
+	if err != nil {
+		slog.Error("failed to load settings", "err", err)
+		return models.DefaultSettings()
+	}
+	return *settings
+}
+
+// localizer translates into the language chosen in the settings, or the
+// browser's preferred one when set to "auto".
+func (h *Handlers) localizer(r *http.Request) *i18n.Localizer {
+	lang := h.prefs(r.Context()).Language
+	if lang == "auto" {
+		lang = i18n.Match(r.Header.Get("Accept-Language"))
+	}
+	return i18n.New(lang)
+}
+
+// defaultPriority returns the preferred priority for new tasks.
+func (h *Handlers) defaultPriority(ctx context.Context) (string, error) {
+	settings, err := h.store.GetSettings(ctx)
+	if err != nil {
+		return "", err
+	}
+	return settings.DefaultPriority, nil
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/models"
+	"mytasks/internal/quickadd"
+	"mytasks/internal/store"
+)
+
+// shortcutsTokenSetting is the settings key for the Shortcuts API token.
+const shortcutsTokenSetting = "shortcuts_token"
+
+// maxShortcutsBody bounds Shortcuts API request bodies.
+const maxShortcutsBody = 64 << 10
+
+// ShortcutTask is the flat task shape the Shortcuts API returns, easy to
+// pick apart with "Get Dictionary Value".
+type ShortcutTask struct {
+	ID          int64  `json:"id"`
+	Description string `json:"description"`
+	Project     string `json:"project"`
+	Priority    string `json:"priority"`
+	Status      string `json:"status"`
+	DueDate     string `json:"due_date,omitempty"`
+	Overdue     bool   `json:"overdue"`
+}
+
+// ShortcutsSettingsData holds data for the Shortcuts settings page.
+type ShortcutsSettingsData struct {
+	PageData
+	Token   string
+	BaseURL string
+}
+
+// ShortcutsAddTask creates a task from "text", which accepts the quick-add
+// syntax ("#Household !high @errands buy filters tomorrow"). Tasks without a
+// "#project" go to the Inbox.
+func (h *Handlers) ShortcutsAddTask(w http.ResponseWriter, r *http.Request) error {
+	input, ok := shortcutsInput(w, r)
+	if !ok {
+		return nil
+	}
+	if err := h.authorizeToken(w, r, shortcutsTokenSetting, bearerToken(r, input.Get("token"))); err != nil {
+		return err
+	}
+	ctx := r.Context()
+
+	entry := quickadd.Parse(input.Get("text"), time.Now())
+
+	var project *models.Project
+	if entry.Project != "" {
+		projects, err := h.loadActiveProjects(ctx)
+		if err != nil {
+			return err
+		}
+		if project = quickadd.MatchProject(entry.Project, projects); project == nil {
+			writeShortcutsError(w, http.StatusNotFound, "No active project named "+entry.Project+".")
+			return nil
+		}
+	} else {
+		var err error
+		if project, err = h.inboxProject(r); err != nil {
+			return err
+		}
+	}
+
+	task := &models.Task{
+		ProjectID:   project.ID,
+		Description: entry.Description,
+		Priority:    entry.Priority,
+		Status:      "todo",
+		DueDate:     entry.Due,
+		Tags:        entry.Tags,
+		Recurrence:  entry.Recurrence,
+	}
+	if task.Priority == "" {
+		priority, err := h.defaultPriority(ctx)
+		if err != nil {
+			return err
+		}
+		task.Priority = priority
+	}
+	if err := task.Validate(); err != nil {
+		writeShortcutsError(w, http.StatusBadRequest, err.Error())
+		return nil
+	}
+	if err := h.store.CreateTask(ctx, task); err != nil {
+		return err
+	}
+
+	h.publish(r, events.Event{Type: events.TaskCreated, TaskID: task.ID, ProjectID: task.ProjectID, Task: task})
+	task.ProjectName = project.Name
+	writeShortcutsJSON(w, http.StatusCreated, shortcutTask(task))
+	return nil
+}
+
+// ShortcutsToday lists open tasks due today or overdue, oldest due first.
+func (h *Handlers) ShortcutsToday(w http.ResponseWriter, r *http.Request) error {
+	if err := h.authorizeToken(w, r, shortcutsTokenSetting, bearerToken(r, r.URL.Query().Get("token"))); err != nil {
+		return err
+	}
+
+	tasks, err := h.store.ListUpcomingTasks(r.Context(), 0)
+	if err != nil {
+		return err
+	}
+
+	out := make([]ShortcutTask, 0, len(tasks))
+	for i := range tasks {
+		out = append(out, shortcutTask(&tasks[i]))
+	}
+	writeShortcutsJSON(w, http.StatusOK, out)
+	return nil
+}
+
+// ShortcutsCompleteTask marks the open task called "name" done. An exact
+// (case-insensitive) description match wins; otherwise the name must appear
+// in exactly one open task. Ambiguous names answer 409 with the candidates.
+func (h *Handlers) ShortcutsCompleteTask(w http.ResponseWriter, r *http.Request) error {
+	input, ok := shortcutsInput(w, r)
+	if !ok {
+		return nil
+	}
+	if err := h.authorizeToken(w, r, shortcutsTokenSetting, bearerToken(r, input.Get("token"))); err != nil {
+		return err
+	}
+	ctx := r.Context()
+
+	name := strings.TrimSpace(input.Get("name"))
+	if name == "" {
+		writeShortcutsError(w, http.StatusBadRequest, "name is required")
+		return nil
+	}
+
+	open, err := h.store.ListOpenTasks(ctx, store.TaskFilter{Search: name})
+	if err != nil {
+		return err
+	}
+	var exact, partial []*models.Task
+	for i := range open {
+		desc := strings.ToLower(open[i].Description)
+		switch {
+		case desc == strings.ToLower(name):
+			exact = append(exact, &open[i])
+		case strings.Contains(desc, strings.ToLower(name)):
+			partial = append(partial, &open[i])
+		}
+	}
+	matches := exact
+	if len(matches) == 0 {
+		matches = partial
+	}
+
+	switch len(matches) {
+	case 0:
+		writeShortcutsError(w, http.StatusNotFound, "No open task matches "+name+".")
+		return nil
+	case 1:
+	default:
+		candidates := make([]ShortcutTask, 0, len(matches))
+		for _, t := range matches {
+			candidates = append(candidates, shortcutTask(t))
+		}
+		writeShortcutsJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":      "More than one open task matches " + name + ".",
+			"candidates": candidates,
+		})
+		return nil
+	}
+
+	task, err := h.store.GetTask(ctx, matches[0].ID)
+	if err != nil {
+		return err
+	}
+	task.Status = "done"
+	if err := h.store.UpdateTask(ctx, task); err != nil {
+		return err
+	}
+
+	h.publishTaskChange(r, task, false, task.ProjectID)
+	task.ProjectName = matches[0].ProjectName
+	writeShortcutsJSON(w, http.StatusOK, shortcutTask(task))
+	return nil
+}
+
+// ShortcutsSettings shows the Shortcuts token and how to set up shortcuts.
+func (h *Handlers) ShortcutsSettings(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	token, err := h.store.GetSetting(ctx, shortcutsTokenSetting)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+
+	activeProjects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	return h.renderTemplate(w, "shortcuts_settings.html", ShortcutsSettingsData{
+		PageData: PageData{
+			Title:          "Shortcuts",
+			ActiveProjects: activeProjects,
+			CurrentView:    "shortcuts",
+			Prefs:          h.prefs(r.Context()),
+			Lang:           h.localizer(r),
+		},
+		Token:   token,
+		BaseURL: requestBaseURL(r) + h.url(""),
+	})
+}
+
+// ResetShortcutsToken issues a new Shortcuts token.
+func (h *Handlers) ResetShortcutsToken(w http.ResponseWriter, r *http.Request) error {
+	return h.setToken(w, r, shortcutsTokenSetting, true, "/settings/shortcuts")
+}
+
+// DisableShortcuts removes the Shortcuts token, turning the API off.
+func (h *Handlers) DisableShortcuts(w http.ResponseWriter, r *http.Request) error {
+	return h.setToken(w, r, shortcutsTokenSetting, false, "/settings/shortcuts")
+}
+
+// shortcutsInput reads the request's fields from a JSON object body, as sent
+// by "Get Contents of URL" with a JSON request body, or from form values.
+func shortcutsInput(w http.ResponseWriter, r *http.Request) (url.Values, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxShortcutsBody)
+
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "application/json" {
+		if err := r.ParseForm(); err != nil {
+			writeShortcutsError(w, http.StatusBadRequest, "invalid form data")
+			return nil, false
+		}
+		return r.Form, true
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		writeShortcutsError(w, http.StatusBadRequest, "invalid JSON")
+		return nil, false
+	}
+	input := r.URL.Query()
+	for k, v := range body {
+		if s, ok := v.(string); ok {
+			input.Set(k, s)
+		}
+	}
+	return input, true
+}
+
+func shortcutTask(t *models.Task) ShortcutTask {
+	st := ShortcutTask{
+		ID:          t.ID,
+		Description: t.Description,
+		Project:     t.ProjectName,
+		Priority:    t.Priority,
+		Status:      t.Status,
+		Overdue:     t.IsOverdue(),
+	}
+	if t.DueDate != nil {
+		st.DueDate = t.DueDate.Format("2006-01-02")
+	}
+	return st
+}
+
+func writeShortcutsJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeShortcutsError(w http.ResponseWriter, code int, message string) {
+	writeShortcutsJSON(w, code, map[string]string{"error": message})
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"mytasks/internal/events"
+	"mytasks/internal/models"
+	"mytasks/internal/slack"
+	"mytasks/internal/store"
+)
+
+// maxSlackBody bounds slash command payloads, which are small form posts.
+const maxSlackBody = 64 << 10
+
+const slackHelp = "Usage:\n" +
+	"• `/mytasks add <description>` — add a task\n" +
+	"• `/mytasks today` — list tasks due today or overdue"
+
+// SlackSettingsData holds data for the Slack settings page.
+type SlackSettingsData struct {
+	PageData
+	Workspaces []models.SlackWorkspace
+	Error      string
+}
+
+// SlackCommand handles the /mytasks slash command. Requests must carry a
+// valid Slack signature; the command is disabled without a signing secret.
+func (h *Handlers) SlackCommand(w http.ResponseWriter, r *http.Request) error {
+	if h.slackSigningSecret == "" {
+		return notFound("slack integration is disabled")
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSlackBody))
+	if err != nil {
+		return badRequest("invalid request body")
+	}
+	if err := slack.VerifyRequest(h.slackSigningSecret, r.Header, body, time.Now()); err != nil {
+		return withStatus(http.StatusUnauthorized, "invalid signature")
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return badRequest("invalid form data")
+	}
+
+	ctx := r.Context()
+	teamID := form.Get("team_id")
+	if teamID == "" {
+		return badRequest("team_id is required")
+	}
+	if err := h.store.RegisterSlackWorkspace(ctx, teamID, form.Get("team_domain")); err != nil {
+		return err
+	}
+
+	text := strings.TrimSpace(form.Get("text"))
+	verb, rest, _ := strings.Cut(text, " ")
+	rest = strings.TrimSpace(rest)
+
+	var reply string
+	switch strings.ToLower(verb) {
+	case "add":
+		reply, err = h.slackAdd(r, teamID, rest)
+	case "today":
+		reply, err = h.slackToday(r)
+	default:
+		reply = slackHelp
+	}
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(slack.Message{ResponseType: "ephemeral", Text: reply})
+	return nil
+}
+
+func (h *Handlers) slackAdd(r *http.Request, teamID, description string) (string, error) {
+	if description == "" {
+		return "Tell me what to add, e.g. `/mytasks add Renew passport`.", nil
+	}
+
+	ctx := r.Context()
+	ws, err := h.store.GetSlackWorkspace(ctx, teamID)
+	if err != nil {
+		return "", err
+	}
+
+	var project *models.Project
+	if ws.ProjectID != nil {
+		project, err = h.store.GetProject(ctx, *ws.ProjectID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return "", err
+		}
+		if project != nil && project.Completed {
+			project = nil
+		}
+	}
+	if project == nil {
+		project, err = h.inboxProject(r)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	priority, err := h.defaultPriority(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	task := &models.Task{
+		ProjectID:   project.ID,
+		Description: description,
+		Priority:    priority,
+		Status:      "todo",
+	}
+	if err := task.Validate(); err != nil {
+		return err.Error(), nil
+	}
+	if err := h.store.CreateTask(ctx, task); err != nil {
+		return "", err
+	}
+
+	h.publish(r, events.Event{Type: events.TaskCreated, TaskID: task.ID, ProjectID: task.ProjectID, Task: task})
+	return fmt.Sprintf("Added %q to %s.", description, project.Name), nil
+}
+
+func (h *Handlers) slackToday(r *http.Request) (string, error) {
+	tasks, err := h.store.ListUpcomingTasks(r.Context(), 0)
+	if err != nil {
+		return "", err
+	}
+	return slack.FormatDueToday(tasks), nil
+}
+
+// SlackSettings renders per-workspace Slack settings.
+func (h *Handlers) SlackSettings(w http.ResponseWriter, r *http.Request) error {
+	return h.renderSlackSettings(w, r, "")
+}
+
+func (h *Handlers) renderSlackSettings(w http.ResponseWriter, r *http.Request, formError string) error {
+	ctx := r.Context()
+
+	workspaces, err := h.store.ListSlackWorkspaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	activeProjects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if formError != "" {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	data := SlackSettingsData{
+		PageData: PageData{
+			Title:          "Slack",
+			ActiveProjects: activeProjects,
+			CurrentView:    "slack",
+			Prefs:          h.prefs(r.Context()),
+			Lang:           h.localizer(r),
+		},
+		Workspaces: workspaces,
+		Error:      formError,
+	}
+
+	return h.renderTemplate(w, "slack_settings.html", data)
+}
+
+// UpdateSlackWorkspace saves a workspace's webhook, quick-add project and
+// notification preference.
+func (h *Handlers) UpdateSlackWorkspace(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	ws, err := h.store.GetSlackWorkspace(ctx, chi.URLParam(r, "team_id"))
+	if err != nil {
+		return err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	ws.WebhookURL = strings.TrimSpace(r.FormValue("webhook_url"))
+	ws.NotifyOverdue = r.FormValue("notify_overdue") != ""
+	ws.ProjectID = nil
+	if raw := r.FormValue("project_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			return badRequest("invalid project id")
+		}
+		ws.ProjectID = &id
+	}
+
+	if err := ws.Validate(); err != nil {
+		return h.renderSlackSettings(w, r, ws.TeamID+": "+err.Error())
+	}
+
+	if err := h.store.UpdateSlackWorkspace(ctx, ws); err != nil {
+		return err
+	}
+
+	http.Redirect(w, r, h.url("/settings/slack"), http.StatusSeeOther)
+	return nil
+}
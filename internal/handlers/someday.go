@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"mytasks/internal/events"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// SomedayData holds data for the Someday/Maybe template.
+type SomedayData struct {
+	PageData
+	Tasks []models.Task
+}
+
+// Someday renders the tasks parked in Someday/Maybe, by project, each with
+// a button promoting it back to the active lists.
+func (h *Handlers) Someday(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	tasks, err := h.store.ListOpenTasks(ctx, store.TaskFilter{Someday: true, Sort: store.SortProject})
+	if err != nil {
+		return err
+	}
+	projects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	return h.renderTemplate(w, "someday.html", SomedayData{
+		PageData: PageData{
+			Title:          "Someday/Maybe",
+			ActiveProjects: projects,
+			CurrentView:    "someday",
+			Prefs:          h.prefs(ctx),
+			Lang:           h.localizer(r),
+		},
+		Tasks: tasks,
+	})
+}
+
+// ParkTask moves a task to Someday/Maybe, out of the boards, lists and
+// counts.
+func (h *Handlers) ParkTask(w http.ResponseWriter, r *http.Request) error {
+	return h.setSomeday(w, r, true)
+}
+
+// PromoteTask brings a task back from Someday/Maybe to the active lists.
+func (h *Handlers) PromoteTask(w http.ResponseWriter, r *http.Request) error {
+	return h.setSomeday(w, r, false)
+}
+
+// setSomeday answers API clients with the updated task, and htmx with an
+// empty body, so the row that sent the request can be swapped out.
+func (h *Handlers) setSomeday(w http.ResponseWriter, r *http.Request, someday bool) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid task id")
+	}
+
+	task, err := h.store.SetTaskSomeday(r.Context(), id, someday)
+	if err != nil {
+		return err
+	}
+	h.publish(r, events.Event{Type: events.TaskUpdated, TaskID: task.ID, ProjectID: task.ProjectID, Task: task})
+
+	if r.Header.Get("HX-Request") != "true" {
+		return writeJSON(w, r, task)
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+const (
+	// dailyGoalSetting is the settings key for the daily completion goal.
+	dailyGoalSetting = "daily_goal"
+	defaultDailyGoal = 3
+	maxDailyGoal     = 100
+	// streakHistoryDays is how many recent days the widget charts.
+	streakHistoryDays = 7
+)
+
+// StreakData holds data for the streak widget.
+type StreakData struct {
+	models.Streaks
+	// Recent has one entry per day for the last streakHistoryDays days,
+	// oldest first, including days with nothing completed.
+	Recent []StreakDay
+}
+
+// StreakDay is one bar in the widget's recent-days chart.
+type StreakDay struct {
+	Day   time.Time
+	Count int
+	// Percent is Count relative to the larger of the goal and the busiest
+	// recent day, for sizing the bar.
+	Percent int
+}
+
+// Streak renders the completion streak widget shown in the sidebar.
+func (h *Handlers) Streak(w http.ResponseWriter, r *http.Request) error {
+	return h.renderStreak(w, r)
+}
+
+// UpdateDailyGoal saves the daily completion goal and re-renders the widget.
+func (h *Handlers) UpdateDailyGoal(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+
+	goal, err := strconv.Atoi(r.FormValue("goal"))
+	if err != nil || goal < 1 || goal > maxDailyGoal {
+		return badRequest("goal must be between 1 and 100")
+	}
+
+	if err := h.store.SetSetting(r.Context(), dailyGoalSetting, strconv.Itoa(goal)); err != nil {
+		return err
+	}
+
+	return h.renderStreak(w, r)
+}
+
+func (h *Handlers) renderStreak(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	goal, err := h.dailyGoal(ctx)
+	if err != nil {
+		return err
+	}
+	counts, err := h.store.ListDailyCompletionCounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	today := dateOnly(time.Now())
+	data := StreakData{Streaks: models.ComputeStreaks(counts, today, goal)}
+
+	byDay := make(map[time.Time]int, streakHistoryDays)
+	for _, c := range counts {
+		byDay[dateOnly(c.Day)] = c.Count
+	}
+	scale := goal
+	for i := streakHistoryDays - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		data.Recent = append(data.Recent, StreakDay{Day: day, Count: byDay[day]})
+		if byDay[day] > scale {
+			scale = byDay[day]
+		}
+	}
+	for i := range data.Recent {
+		data.Recent[i].Percent = data.Recent[i].Count * 100 / scale
+	}
+
+	return h.renderPartial(w, "streak.html", data)
+}
+
+// dailyGoal returns the saved daily goal, or the default when none is set.
+func (h *Handlers) dailyGoal(ctx context.Context) (int, error) {
+	raw, err := h.store.GetSetting(ctx, dailyGoalSetting)
+	if errors.Is(err, store.ErrNotFound) {
+		return defaultDailyGoal, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	goal, err := strconv.Atoi(raw)
+	if err != nil || goal < 1 {
+		return defaultDailyGoal, nil
+	}
+	return goal, nil
+}
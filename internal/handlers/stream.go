@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// streamFlushEvery is how many items a streamed export writes between
+// flushes.
+const streamFlushEvery = 200
+
+// jsonArrayStream writes a JSON array one element at a time, flushing as it
+// goes, so an export is sent chunked as it is read instead of being built
+// whole in memory first.
+type jsonArrayStream struct {
+	w   http.ResponseWriter
+	rc  *http.ResponseController
+	enc *json.Encoder
+	n   int
+}
+
+func newJSONArrayStream(w http.ResponseWriter) *jsonArrayStream {
+	return &jsonArrayStream{w: w, rc: http.NewResponseController(w), enc: json.NewEncoder(w)}
+}
+
+// Write adds v to the array.
+func (s *jsonArrayStream) Write(v interface{}) error {
+	sep := ","
+	if s.n == 0 {
+		sep = "["
+	}
+	if _, err := io.WriteString(s.w, sep); err != nil {
+		return err
+	}
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	s.n++
+	if s.n%streamFlushEvery == 0 {
+		// Not every writer can flush; the rows still go out, only later.
+		s.rc.Flush()
+	}
+	return nil
+}
+
+// Close ends the array.
+func (s *jsonArrayStream) Close() error {
+	end := "]\n"
+	if s.n == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(s.w, end)
+	return err
+}
+
+// Fail handles err from producing the stream. Before anything is written it
+// is returned to be reported as usual. After that the status has been sent,
+// so the response is cut off instead, letting the client see it is
+// incomplete rather than receiving a short export that looks whole.
+func (s *jsonArrayStream) Fail(r *http.Request, err error) error {
+	if s.n == 0 {
+		s.w.Header().Del("Content-Disposition")
+		return err
+	}
+	if r.Context().Err() == nil {
+		slog.Error("export failed part way", "path", r.URL.Path, "written", s.n, "err", err)
+	}
+	panic(http.ErrAbortHandler)
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// maxSyncMutations bounds how many queued changes one replay may carry.
+const maxSyncMutations = 500
+
+// Mutation types.
+const (
+	// MutationComplete sets whether a task is completed.
+	MutationComplete = "complete"
+	// MutationAdd creates a task from a line of capture syntax.
+	MutationAdd = "add"
+	// MutationUpdate edits a task's fields.
+	MutationUpdate = "update"
+)
+
+// Mutation is a change made offline, queued by the browser and replayed
+// through Sync once it reconnects.
+type Mutation struct {
+	// ID is chosen by the client. A mutation sent twice is applied once.
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// At is when the change was made; quick-add dates are relative to it.
+	At time.Time `json:"at"`
+
+	// TaskID and Completed are for MutationComplete. Completed is the state
+	// the task ends up in, so replaying it again changes nothing.
+	TaskID    int64 `json:"task_id,omitempty"`
+	Completed bool  `json:"completed,omitempty"`
+
+	// Text and ProjectID are for MutationAdd: the capture text, and the
+	// project it was typed on, if any.
+	Text      string `json:"text,omitempty"`
+	ProjectID int64  `json:"project_id,omitempty"`
+
+	// Edit is for MutationUpdate: the fields changed, with the rest left
+	// alone.
+	Edit *TaskEdit `json:"task,omitempty"`
+
+	// BaseRevision is the revision of the task the change was made to. If
+	// the task has been changed since, the mutation is not applied and a
+	// conflict is returned instead. Zero skips the check.
+	BaseRevision int64 `json:"base_revision,omitempty"`
+}
+
+// TaskEdit holds the fields a MutationUpdate sets. Nil fields are left as
+// they are; DueDate is "YYYY-MM-DD", or empty to clear it.
+type TaskEdit struct {
+	Description *string   `json:"description,omitempty"`
+	Notes       *string   `json:"notes,omitempty"`
+	Priority    *string   `json:"priority,omitempty"`
+	Status      *string   `json:"status,omitempty"`
+	DueDate     *string   `json:"due_date,omitempty"`
+	Tags        *[]string `json:"tags,omitempty"`
+}
+
+// Statuses of a MutationResult.
+const (
+	SyncApplied   = "applied"
+	SyncDuplicate = "duplicate"
+	SyncRejected  = "rejected"
+	SyncConflict  = "conflict"
+)
+
+// MutationResult says what became of one replayed mutation. The client can
+// drop every mutation it gets a result for.
+type MutationResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	// Error explains a rejection.
+	Error  string `json:"error,omitempty"`
+	TaskID int64  `json:"task_id,omitempty"`
+	// Conflict is set with SyncConflict.
+	Conflict *Conflict `json:"conflict,omitempty"`
+}
+
+// Conflict is a change made to a task that has been changed elsewhere
+// since. Server is the task as it is now; Client is how the change would
+// have left it. The client can resend the change with Server.Revision as
+// its base to keep it anyway.
+type Conflict struct {
+	Server *models.Task `json:"server"`
+	Client *models.Task `json:"client"`
+}
+
+// conflictError is returned by the sync appliers for a stale base revision.
+type conflictError struct {
+	Conflict
+}
+
+func (e *conflictError) Error() string {
+	return fmt.Sprintf("task %d has changed since revision %d", e.Server.ID, e.Client.Revision)
+}
+
+// checkRevision returns a conflict if task has moved past the revision the
+// mutation was made against. edited is the task with the change applied.
+func checkRevision(m Mutation, task, edited *models.Task) error {
+	if m.BaseRevision == 0 || m.BaseRevision == task.Revision {
+		return nil
+	}
+	edited.Revision = m.BaseRevision
+	return &conflictError{Conflict{Server: task, Client: edited}}
+}
+
+// Sync applies changes made offline, in the order given, and returns a
+// result for each. A change that no longer makes sense, such as completing
+// a deleted task, is rejected rather than failing the replay. A server
+// error fails it; the client sends the queue again later and the changes
+// already applied are skipped.
+func (h *Handlers) Sync(w http.ResponseWriter, r *http.Request) error {
+	var payload struct {
+		Mutations []Mutation `json:"mutations"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&payload); err != nil {
+		return badRequest("invalid json")
+	}
+	if len(payload.Mutations) > maxSyncMutations {
+		return badRequest(fmt.Sprintf("at most %d mutations can be synced at once", maxSyncMutations))
+	}
+
+	// Changes queued one after another on a task were all made against the
+	// revision the page showed, so each is checked against the revision the
+	// one before it left instead.
+	rebased := make(map[int64][2]int64)
+	results := make([]MutationResult, 0, len(payload.Mutations))
+	for _, m := range payload.Mutations {
+		base := m.BaseRevision
+		if prev, ok := rebased[m.TaskID]; ok && base != 0 && base == prev[0] {
+			m.BaseRevision = prev[1]
+		}
+		result, err := h.applyMutation(r, m)
+		if err != nil {
+			return err
+		}
+		if result.Status == SyncApplied && base != 0 {
+			task, err := h.store.GetTask(r.Context(), m.TaskID)
+			if err != nil {
+				return err
+			}
+			rebased[m.TaskID] = [2]int64{base, task.Revision}
+		}
+		results = append(results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(struct {
+		Results []MutationResult `json:"results"`
+	}{results})
+}
+
+func (h *Handlers) applyMutation(r *http.Request, m Mutation) (MutationResult, error) {
+	ctx := r.Context()
+	result := MutationResult{ID: m.ID}
+	if m.ID == "" {
+		result.Status, result.Error = SyncRejected, "missing id"
+		return result, nil
+	}
+	applied, err := h.store.SyncMutationApplied(ctx, m.ID)
+	if err != nil {
+		return result, err
+	}
+	if applied {
+		result.Status = SyncDuplicate
+		return result, nil
+	}
+	if m.At.IsZero() {
+		m.At = time.Now()
+	}
+
+	switch m.Type {
+	case MutationComplete:
+		result.TaskID, err = h.syncComplete(r, m)
+	case MutationAdd:
+		result.TaskID, err = h.syncAdd(r, m)
+	case MutationUpdate:
+		result.TaskID, err = h.syncUpdate(r, m)
+	default:
+		err = badRequest(fmt.Sprintf("unknown mutation type %q", m.Type))
+	}
+
+	result.Status = SyncApplied
+	var se *statusError
+	var ve *models.ValidationError
+	var ce *conflictError
+	switch {
+	case errors.As(err, &ce):
+		result.Status, result.Error, result.TaskID = SyncConflict, err.Error(), ce.Server.ID
+		result.Conflict = &ce.Conflict
+	case errors.As(err, &se), errors.As(err, &ve):
+		result.Status, result.Error = SyncRejected, err.Error()
+	case errors.Is(err, store.ErrNotFound):
+		result.Status, result.Error = SyncRejected, "task not found"
+	case err != nil:
+		return result, err
+	}
+	return result, h.store.RecordSyncMutation(ctx, m.ID, time.Now())
+}
+
+func (h *Handlers) syncComplete(r *http.Request, m Mutation) (int64, error) {
+	ctx := r.Context()
+	task, err := h.store.GetTask(ctx, m.TaskID)
+	if err != nil {
+		return 0, err
+	}
+	if task.Completed == m.Completed {
+		return task.ID, nil
+	}
+	edited := *task
+	edited.Completed, edited.Status = m.Completed, "todo"
+	if m.Completed {
+		edited.Status = "done"
+	}
+	if err := checkRevision(m, task, &edited); err != nil {
+		return 0, err
+	}
+	if err := h.store.ToggleTaskComplete(ctx, task.ID); err != nil {
+		return 0, err
+	}
+	wasDone := task.IsDone()
+	if task, err = h.store.GetTask(ctx, task.ID); err != nil {
+		return 0, err
+	}
+	h.publishTaskChange(r, task, wasDone, task.ProjectID)
+	return task.ID, nil
+}
+
+// syncAdd quick-adds a task. If the project it was typed on has since been
+// completed or deleted, the task goes to the Inbox rather than being lost.
+func (h *Handlers) syncAdd(r *http.Request, m Mutation) (int64, error) {
+	var projectID string
+	if m.ProjectID != 0 {
+		project, err := h.store.GetProject(r.Context(), m.ProjectID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return 0, err
+		}
+		if err == nil && !project.Completed {
+			projectID = strconv.FormatInt(project.ID, 10)
+		}
+	}
+	task, _, err := h.quickAddTask(r, m.Text, projectID, m.At)
+	if err != nil {
+		return 0, err
+	}
+	return task.ID, nil
+}
+
+// syncUpdate applies a TaskEdit. An edit that leaves the task as it is
+// succeeds whatever its base revision, since there is nothing to lose.
+func (h *Handlers) syncUpdate(r *http.Request, m Mutation) (int64, error) {
+	ctx := r.Context()
+	if m.Edit == nil {
+		return 0, badRequest("missing task")
+	}
+	task, err := h.store.GetTask(ctx, m.TaskID)
+	if err != nil {
+		return 0, err
+	}
+	edited := *task
+	m.Edit.apply(&edited)
+	if err := edited.Validate(); err != nil {
+		return 0, err
+	}
+	if sameContents(task, &edited) {
+		return task.ID, nil
+	}
+	if err := checkRevision(m, task, &edited); err != nil {
+		return 0, err
+	}
+
+	if err := h.store.UpdateTask(ctx, &edited); err != nil {
+		return 0, err
+	}
+	h.publishTaskChange(r, &edited, task.IsDone(), task.ProjectID)
+	return task.ID, nil
+}
+
+func (e *TaskEdit) apply(t *models.Task) {
+	if e.Description != nil {
+		t.Description = *e.Description
+	}
+	if e.Notes != nil {
+		t.Notes = *e.Notes
+	}
+	if e.Priority != nil {
+		t.Priority = *e.Priority
+	}
+	if e.Status != nil {
+		t.Status = *e.Status
+	}
+	if e.DueDate != nil {
+		t.DueDate = parseDate(*e.DueDate)
+	}
+	if e.Tags != nil {
+		t.Tags = models.NormalizeTags(*e.Tags)
+	}
+}
+
+// sameContents reports whether a and b differ in none of the fields a
+// TaskEdit can set.
+func sameContents(a, b *models.Task) bool {
+	return a.Description == b.Description &&
+		a.Notes == b.Notes &&
+		a.Priority == b.Priority &&
+		a.Status == b.Status &&
+		sameDate(a.DueDate, b.DueDate) &&
+		slices.Equal(a.Tags, b.Tags)
+}
+
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Format(time.DateOnly) == b.Format(time.DateOnly)
+}
@@ -1,29 +1,33 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"mytasks/internal/events"
 	"mytasks/internal/models"
+	"mytasks/internal/nldate"
+	"mytasks/internal/store"
 )
 
 // CreateTask creates a new task for a project.
-func (h *Handlers) CreateTask(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) CreateTask(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	if err := r.ParseForm(); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid form data")
-		return
+		return badRequest("invalid form data")
 	}
 
 	projectID, err := parseID(r, "id")
 	if err != nil {
 		projectID, err = strconv.ParseInt(r.FormValue("project_id"), 10, 64)
 		if err != nil || projectID <= 0 {
-			respondError(w, http.StatusBadRequest, "invalid project id")
-			return
+			return badRequest("invalid project id")
 		}
 	}
 
@@ -39,42 +43,55 @@ func (h *Handlers) CreateTask(w http.ResponseWriter, r *http.Request) {
 		Priority:    r.FormValue("priority"),
 		Status:      status,
 		DueDate:     parseDate(r.FormValue("due_date")),
+		WaitingOn:   strings.TrimSpace(r.FormValue("waiting_on")),
+	}
+
+	// "pay rent tomorrow" or "call mom every monday": take the date from the
+	// description unless one was picked explicitly.
+	if parsed := nldate.Parse(task.Description, time.Now()); parsed.Due != nil {
+		task.Description = parsed.Text
+		task.Recurrence = parsed.Recurrence
+		if task.DueDate == nil {
+			task.DueDate = parsed.Due
+		}
 	}
 
 	if err := task.Validate(); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
-		return
+		return err
 	}
 
 	if err := h.store.CreateTask(ctx, task); err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 
-	h.renderPartial(w, "task_item.html", task)
+	h.publish(r, events.Event{Type: events.TaskCreated, TaskID: task.ID, ProjectID: task.ProjectID, Task: task})
+	return h.renderTaskChange(w, r, "task_item.html", task, task.ProjectID)
 }
 
 // UpdateTask updates an existing task.
-func (h *Handlers) UpdateTask(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) UpdateTask(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	id, err := parseID(r, "id")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid task id")
-		return
+		return badRequest("invalid task id")
 	}
 
 	task, err := h.store.GetTask(ctx, id)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "task not found")
-		return
+		return err
+	}
+	if err := checkIfMatch(r, revisionETag("task", task.ID, task.Revision)); err != nil {
+		return err
 	}
+	revision := ifMatchRevision(r, task.Revision)
 
 	if err := r.ParseForm(); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid form data")
-		return
+		return badRequest("invalid form data")
 	}
 
+	previousProjectID := task.ProjectID
+	wasDone := task.IsDone()
 	task.Description = r.FormValue("description")
 	task.Notes = r.FormValue("notes")
 	task.Priority = r.FormValue("priority")
@@ -84,6 +101,17 @@ func (h *Handlers) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		task.Status = status
 	}
 
+	if _, ok := r.Form["recurrence"]; ok {
+		rule, err := nldate.ParseRule(r.FormValue("recurrence"))
+		if err != nil {
+			return badRequest(err.Error())
+		}
+		task.Recurrence = rule
+	}
+	if _, ok := r.Form["waiting_on"]; ok {
+		task.WaitingOn = strings.TrimSpace(r.FormValue("waiting_on"))
+	}
+
 	// Support legacy completed checkbox — sync to status
 	if r.FormValue("completed") == "true" {
 		task.Status = "done"
@@ -93,83 +121,124 @@ func (h *Handlers) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	if rawProjectID := r.FormValue("project_id"); rawProjectID != "" {
 		destID, err := strconv.ParseInt(rawProjectID, 10, 64)
 		if err != nil {
-			respondError(w, http.StatusBadRequest, "invalid project_id")
-			return
+			return badRequest("invalid project_id")
 		}
 		if destID != task.ProjectID {
 			dest, err := h.store.GetProject(ctx, destID)
 			if err != nil || dest.Completed {
-				respondError(w, http.StatusBadRequest, "invalid destination project")
-				return
+				return badRequest("invalid destination project")
 			}
 			task.ProjectID = destID
 		}
 	}
 
 	if err := task.Validate(); err != nil {
-		respondError(w, http.StatusBadRequest, err.Error())
-		return
+		return err
 	}
 
-	if err := h.store.UpdateTask(ctx, task); err != nil {
-		respondServerError(w, err)
-		return
+	if err := h.store.UpdateTaskAtRevision(ctx, task, revision); err != nil {
+		return err
 	}
 
-	h.renderPartial(w, "task_item.html", task)
+	h.publishTaskChange(r, task, wasDone, previousProjectID)
+	w.Header().Set("ETag", revisionETag("task", task.ID, task.Revision))
+	return h.renderTaskChange(w, r, "task_item.html", task, previousProjectID, task.ProjectID)
 }
 
-// DeleteTask deletes a task.
-func (h *Handlers) DeleteTask(w http.ResponseWriter, r *http.Request) {
+// DeleteTask deletes a task, keeping it for models.TaskUndoWindow so the
+// undo toast it answers with can bring it back. The housekeeping purge job
+// removes it for good after that.
+func (h *Handlers) DeleteTask(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	id, err := parseID(r, "id")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid task id")
-		return
+		return badRequest("invalid task id")
 	}
 
-	if err := h.store.DeleteTask(ctx, id); err != nil {
-		respondServerError(w, err)
-		return
+	// A missing task still deletes cleanly (as a no-op).
+	task, err := h.store.GetTask(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		if r.Header.Get("If-Match") != "" {
+			return withStatus(http.StatusPreconditionFailed, "task no longer exists")
+		}
+		return h.renderTaskChange(w, r, "", nil)
+	} else if err != nil {
+		return err
+	}
+	if err := checkIfMatch(r, revisionETag("task", task.ID, task.Revision)); err != nil {
+		return err
 	}
 
-	w.WriteHeader(http.StatusOK)
+	token, err := newSecret()
+	if err != nil {
+		return err
+	}
+	if err := h.store.SoftDeleteTask(ctx, id, token, time.Now()); err != nil {
+		return err
+	}
+
+	h.publish(r, events.Event{Type: events.TaskDeleted, TaskID: id, ProjectID: task.ProjectID})
+	return h.renderTaskChange(w, r, "undo_toast.html", UndoToastData{
+		Token:   token,
+		Seconds: int(models.TaskUndoWindow / time.Second),
+		Lang:    h.localizer(r),
+	}, task.ProjectID)
+}
+
+// UndoDeleteTask brings back a task deleted from the web UI, given the token
+// from its undo toast, as long as the undo window hasn't passed.
+func (h *Handlers) UndoDeleteTask(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+	id, err := h.store.RestoreTask(ctx, r.FormValue("token"), time.Now().Add(-models.TaskUndoWindow))
+	if errors.Is(err, store.ErrNotFound) {
+		return withStatus(http.StatusGone, "too late to undo, the task is gone")
+	} else if err != nil {
+		return err
+	}
+	task, err := h.store.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	h.publish(r, events.Event{Type: events.TaskCreated, TaskID: task.ID, ProjectID: task.ProjectID})
+	return h.renderTaskChange(w, r, "", nil, task.ProjectID)
 }
 
 // ToggleTask toggles the completion status of a task.
-func (h *Handlers) ToggleTask(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) ToggleTask(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	id, err := parseID(r, "id")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid task id")
-		return
+		return badRequest("invalid task id")
 	}
 
 	if err := h.store.ToggleTaskComplete(ctx, id); err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 
 	// Return the updated task
 	task, err := h.store.GetTask(ctx, id)
 	if err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 
-	h.renderPartial(w, "task_item.html", task)
+	h.publishTaskChange(r, task, !task.IsDone(), task.ProjectID)
+	return h.renderTaskChange(w, r, "task_item.html", task, task.ProjectID)
 }
 
 // MoveTask changes a task's status (Kanban column move).
-func (h *Handlers) MoveTask(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) MoveTask(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	id, err := parseID(r, "id")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid task id")
-		return
+		return badRequest("invalid task id")
 	}
 
 	var payload struct {
@@ -178,32 +247,34 @@ func (h *Handlers) MoveTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid json")
-		return
+		return badRequest("invalid json")
 	}
 
 	if payload.Status != "todo" && payload.Status != "in_progress" && payload.Status != "done" {
-		respondError(w, http.StatusBadRequest, "invalid status")
-		return
+		return badRequest("invalid status")
 	}
 
+	before, beforeErr := h.store.GetTask(ctx, id)
+
 	if err := h.store.MoveTaskToStatus(ctx, id, payload.Status, payload.SortOrder); err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 
+	if task, err := h.store.GetTask(ctx, id); err == nil && beforeErr == nil {
+		h.publishTaskChange(r, task, before.IsDone(), before.ProjectID)
+	}
 	w.WriteHeader(http.StatusOK)
+	return nil
 }
 
 // ReorderTasks updates the order of tasks within a project.
 // Accepts an optional "status" query parameter to scope the reorder.
-func (h *Handlers) ReorderTasks(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) ReorderTasks(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	projectID, err := parseID(r, "id")
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid project id")
-		return
+		return badRequest("invalid project id")
 	}
 
 	var payload struct {
@@ -211,79 +282,209 @@ func (h *Handlers) ReorderTasks(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid json")
-		return
+		return badRequest("invalid json")
 	}
 
 	status := r.URL.Query().Get("status")
 	if status != "" {
 		if err := h.store.ReorderTasksInStatus(ctx, projectID, status, payload.IDs); err != nil {
-			respondServerError(w, err)
-			return
+			return err
 		}
 	} else {
 		if err := h.store.ReorderTasks(ctx, projectID, payload.IDs); err != nil {
-			respondServerError(w, err)
-			return
+			return err
 		}
 	}
 
+	h.publish(r, events.Event{Type: events.TasksReordered, ProjectID: projectID, Status: status, IDs: payload.IDs})
 	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// MoveTaskToProject moves a task dropped on another project in the sidebar
+// to the end of that project. The response is only the out-of-band updates
+// for both projects.
+func (h *Handlers) MoveTaskToProject(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid task id")
+	}
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+	destID, err := strconv.ParseInt(r.FormValue("project_id"), 10, 64)
+	if err != nil {
+		return badRequest("invalid project_id")
+	}
+
+	task, err := h.store.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	dest, err := h.store.GetProject(ctx, destID)
+	if err != nil || dest.Completed {
+		return badRequest("invalid destination project")
+	}
+
+	previousProjectID := task.ProjectID
+	if err := h.store.MoveTaskToProject(ctx, id, destID); err != nil {
+		return err
+	}
+	task, err = h.store.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	h.publishTaskChange(r, task, task.IsDone(), previousProjectID)
+	return h.renderTaskChange(w, r, "", nil, previousProjectID, destID)
+}
+
+// publishTaskChange emits the events describing how task changed relative to
+// its previous completion state and project.
+func (h *Handlers) publishTaskChange(r *http.Request, task *models.Task, wasDone bool, previousProjectID int64) {
+	base := events.Event{TaskID: task.ID, ProjectID: task.ProjectID, Task: task}
+
+	moved := previousProjectID != task.ProjectID
+	if moved {
+		e := base
+		e.Type = events.TaskMoved
+		e.PreviousProjectID = previousProjectID
+		h.publish(r, e)
+	}
+
+	switch {
+	case !wasDone && task.IsDone():
+		base.Type = events.TaskCompleted
+	case wasDone && !task.IsDone():
+		base.Type = events.TaskReopened
+	case moved:
+		return
+	default:
+		base.Type = events.TaskUpdated
+	}
+	h.publish(r, base)
 }
 
 // GetTaskForm returns the task form for editing.
-func (h *Handlers) GetTaskForm(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) GetTaskForm(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
 	id, err := parseID(r, "id")
 	if err != nil {
 		// New task form - need project ID from URL
 		projectID, _ := parseID(r, "project_id")
-		h.renderPartial(w, "task_form.html", map[string]interface{}{
-			"ProjectID": projectID,
+		priority, err := h.defaultPriority(ctx)
+		if err != nil {
+			return err
+		}
+		return h.renderPartial(w, "task_form.html", map[string]interface{}{
+			"ProjectID":       projectID,
+			"DefaultPriority": priority,
 		})
-		return
 	}
 
 	task, err := h.store.GetTask(ctx, id)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "task not found")
-		return
+		return err
 	}
 
-	h.renderPartial(w, "task_form.html", task)
+	return h.renderPartial(w, "task_edit_form.html", task)
+}
+
+// Page sizes for ListTasks.
+const (
+	defaultTasksLimit = 100
+	maxTasksLimit     = 500
+)
+
+// TasksPage is a page of GET /api/tasks.
+type TasksPage struct {
+	Tasks []models.Task `json:"tasks"`
+	// NextCursor fetches the page after this one; it is left out on the
+	// last page.
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // ListTasks returns all tasks, optionally filtered by completion window.
 // Query params:
 //   - completed_within_days: optional non-negative integer; when set, only done tasks completed within the last N days are returned.
-func (h *Handlers) ListTasks(w http.ResponseWriter, r *http.Request) {
+//   - filter: optional filter expression (see package taskql), such as completed=false AND priority IN (high,medium).
+//   - limit, cursor: optional; either one answers a TasksPage instead of every task, ordered by sort_order, or newest completion first with completed_within_days.
+func (h *Handlers) ListTasks(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
+	q := r.URL.Query()
+
+	match, err := parseFilterExpr(q.Get("filter"))
+	if err != nil {
+		return err
+	}
 
 	var completedSince *time.Time
-	if rawDays := r.URL.Query().Get("completed_within_days"); rawDays != "" {
+	if rawDays := q.Get("completed_within_days"); rawDays != "" {
 		days, err := strconv.Atoi(rawDays)
 		if err != nil || days < 0 {
-			respondError(w, http.StatusBadRequest, "invalid completed_within_days")
-			return
+			return badRequest("invalid completed_within_days")
 		}
 
 		since := time.Now().AddDate(0, 0, -days)
 		completedSince = &since
 	}
 
-	tasks, err := h.store.ListTasks(ctx, completedSince)
+	if q.Has("limit") || q.Has("cursor") {
+		after, limit, err := pageParams(r, defaultTasksLimit, maxTasksLimit)
+		if err != nil {
+			return err
+		}
+		tasks, next, err := h.store.ListTasksPage(ctx, completedSince, match, after, limit)
+		if err != nil {
+			return keysetError(err)
+		}
+		if tasks == nil {
+			tasks = []models.Task{}
+		}
+		return writeJSON(w, r, TasksPage{Tasks: tasks, NextCursor: encodeCursor(next)})
+	}
+
+	tasks, err := h.store.ListTasks(ctx, completedSince, match)
 	if err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 	if tasks == nil {
 		tasks = []models.Task{}
 	}
 
+	return writeJSON(w, r, tasks)
+}
+
+// GetTask returns one task as JSON, with an ETag to send back in If-Match
+// when changing it.
+func (h *Handlers) GetTask(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid task id")
+	}
+	task, err := h.store.GetTask(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	if notModified(w, r, revisionETag("task", task.ID, task.Revision)) {
+		return nil
+	}
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(tasks); err != nil {
-		respondServerError(w, err)
-		return
+	return json.NewEncoder(w).Encode(task)
+}
+
+// attachRecurrences fills in Recurrence on listed tasks that repeat.
+func (h *Handlers) attachRecurrences(ctx context.Context, lists ...[]models.Task) error {
+	rules, err := h.store.ListTaskRecurrences(ctx)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	for _, tasks := range lists {
+		for i := range tasks {
+			tasks[i].Recurrence = rules[tasks[i].ID]
+		}
 	}
+	return nil
 }
@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// maxAttachmentUpload leaves room around the file for the rest of the
+// multipart body.
+const maxAttachmentUpload = models.MaxAttachmentSize + 1<<20
+
+// TaskDetailData holds data for a task's own page.
+type TaskDetailData struct {
+	PageData
+	Task         *models.Task
+	Project      *models.Project
+	Comments     []models.TaskComment
+	Subtasks     []models.Subtask
+	SubtasksDone int
+	Attachments  []models.TaskAttachment
+	// Completions lists every time the task was completed, newest first.
+	Completions []models.TaskCompletion
+	// History is newest first, with project IDs replaced by names.
+	History []models.TaskHistoryEntry
+	// Focus has the time spent focusing on the task per day, newest first.
+	Focus        []models.FocusTotal
+	FocusTotal   time.Duration
+	FocusRunning bool
+	// Planned is whether the task is in today's My Day plan.
+	Planned bool
+	// Links are the task's related tasks; LinkCandidates the open tasks it
+	// could be linked to.
+	Links          []models.TaskLink
+	LinkCandidates []models.Task
+}
+
+// historyFields labels the fields task_history records.
+var historyFields = map[string]string{
+	"project_id":  "Project",
+	"description": "Description",
+	"notes":       "Notes",
+	"priority":    "Priority",
+	"status":      "Status",
+	"due_date":    "Due date",
+}
+
+// TaskDetail renders the page for one task, with everything about it that
+// doesn't fit in a list row.
+func (h *Handlers) TaskDetail(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid task id")
+	}
+
+	task, err := h.store.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+	project, err := h.store.GetProject(ctx, task.ProjectID)
+	if err != nil {
+		return err
+	}
+	projects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+	comments, err := h.store.ListTaskComments(ctx, id)
+	if err != nil {
+		return err
+	}
+	subtasks, err := h.store.ListSubtasks(ctx, id)
+	if err != nil {
+		return err
+	}
+	attachments, err := h.store.ListTaskAttachments(ctx, id)
+	if err != nil {
+		return err
+	}
+	completions, err := h.store.ListTaskCompletions(ctx, id)
+	if err != nil {
+		return err
+	}
+	history, err := h.store.ListTaskHistory(ctx, id)
+	if err != nil {
+		return err
+	}
+	links, err := h.store.ListTaskLinks(ctx, id)
+	if err != nil {
+		return err
+	}
+	open, err := h.store.ListOpenTasks(ctx, store.TaskFilter{Sort: store.SortProject})
+	if err != nil {
+		return err
+	}
+	candidates := open[:0]
+	for _, t := range open {
+		if t.ID != id {
+			candidates = append(candidates, t)
+		}
+	}
+	now := time.Now()
+	sessions, err := h.store.ListFocusSessions(ctx, id, time.Time{}, now.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	focus := models.SumFocusSessions(sessions, now)
+	var focusTotal time.Duration
+	for i, j := 0, len(focus)-1; i < j; i, j = i+1, j-1 {
+		focus[i], focus[j] = focus[j], focus[i]
+	}
+	for _, f := range focus {
+		focusTotal += f.Duration()
+	}
+	running, err := h.runningFocusSession(r)
+	if err != nil {
+		return err
+	}
+	plan, err := h.store.ListDayPlan(ctx, dateOnly(now))
+	if err != nil {
+		return err
+	}
+	planned := false
+	for _, t := range plan {
+		if t.ID == id {
+			planned = true
+		}
+	}
+	done := 0
+	for _, s := range subtasks {
+		if s.Done {
+			done++
+		}
+	}
+
+	// Moves are recorded by project ID; show the project names, looking up
+	// completed projects that aren't in the sidebar.
+	names := make(map[string]string, len(projects))
+	for _, p := range projects {
+		names[strconv.FormatInt(p.ID, 10)] = p.Name
+	}
+	for i := range history {
+		entry := &history[i]
+		if entry.Field == "project_id" {
+			entry.OldValue = h.projectName(r, names, entry.OldValue)
+			entry.NewValue = h.projectName(r, names, entry.NewValue)
+		}
+		if label, ok := historyFields[entry.Field]; ok {
+			entry.Field = label
+		}
+	}
+
+	return h.renderTemplate(w, "task_detail.html", TaskDetailData{
+		PageData: PageData{
+			Title:            task.Description,
+			ActiveProjects:   projects,
+			CurrentProjectID: task.ProjectID,
+			Prefs:            h.prefs(ctx),
+			Lang:             h.localizer(r),
+		},
+		Task:           task,
+		Project:        project,
+		Comments:       comments,
+		Subtasks:       subtasks,
+		SubtasksDone:   done,
+		Attachments:    attachments,
+		Completions:    completions,
+		History:        history,
+		Focus:          focus,
+		FocusTotal:     focusTotal,
+		FocusRunning:   running != nil && running.TaskID == id,
+		Planned:        planned,
+		Links:          links,
+		LinkCandidates: candidates,
+	})
+}
+
+// projectName returns the name of the project with the given ID, caching
+// lookups in names. A deleted project keeps its ID.
+func (h *Handlers) projectName(r *http.Request, names map[string]string, id string) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+	name := id
+	if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+		if p, err := h.store.GetProject(r.Context(), n); err == nil {
+			name = p.Name
+		}
+	}
+	names[id] = name
+	return name
+}
+
+// CreateTaskComment adds a comment to a task.
+func (h *Handlers) CreateTaskComment(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	taskID, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid task id")
+	}
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+	if _, err := h.store.GetTask(ctx, taskID); err != nil {
+		return err
+	}
+
+	comment := &models.TaskComment{TaskID: taskID, Body: r.FormValue("body")}
+	if err := comment.Validate(); err != nil {
+		return err
+	}
+	if err := h.store.CreateTaskComment(ctx, comment); err != nil {
+		return err
+	}
+	return h.renderPartial(w, "task_comment.html", comment)
+}
+
+// DeleteTaskComment deletes a comment.
+func (h *Handlers) DeleteTaskComment(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid comment id")
+	}
+	if err := h.store.DeleteTaskComment(r.Context(), id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// CreateSubtask adds a subtask to the end of a task's list.
+func (h *Handlers) CreateSubtask(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	taskID, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid task id")
+	}
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+	if _, err := h.store.GetTask(ctx, taskID); err != nil {
+		return err
+	}
+
+	subtask := &models.Subtask{TaskID: taskID, Title: strings.TrimSpace(r.FormValue("title"))}
+	if err := subtask.Validate(); err != nil {
+		return err
+	}
+	if err := h.store.CreateSubtask(ctx, subtask); err != nil {
+		return err
+	}
+	return h.renderPartial(w, "subtask_item.html", subtask)
+}
+
+// ToggleSubtask ticks a subtask off, or back on.
+func (h *Handlers) ToggleSubtask(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid subtask id")
+	}
+	subtask, err := h.store.ToggleSubtask(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	return h.renderPartial(w, "subtask_item.html", subtask)
+}
+
+// DeleteSubtask deletes a subtask.
+func (h *Handlers) DeleteSubtask(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid subtask id")
+	}
+	if err := h.store.DeleteSubtask(r.Context(), id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// CreateTaskAttachment stores a file uploaded as the "file" field of a
+// multipart form.
+func (h *Handlers) CreateTaskAttachment(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	taskID, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid task id")
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentUpload)
+	if err := r.ParseMultipartForm(maxAttachmentUpload); err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			return withStatus(http.StatusRequestEntityTooLarge, "attachments must be 10 MB or smaller")
+		}
+		return badRequest("invalid form data")
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return badRequest("choose a file to attach")
+	}
+	defer file.Close()
+	if _, err := h.store.GetTask(ctx, taskID); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, models.MaxAttachmentSize+1))
+	if err != nil {
+		return err
+	}
+	contentType := header.Header.Get("Content-Type")
+	if _, _, err := mime.ParseMediaType(contentType); err != nil {
+		contentType = http.DetectContentType(data)
+	}
+	attachment := &models.TaskAttachment{
+		TaskID:      taskID,
+		Name:        header.Filename,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Data:        data,
+	}
+	if err := attachment.Validate(); err != nil {
+		return err
+	}
+	if err := h.store.CreateTaskAttachment(ctx, attachment); err != nil {
+		return err
+	}
+	return h.renderPartial(w, "task_attachment.html", attachment)
+}
+
+// DownloadTaskAttachment sends an attachment's file. It is always sent as a
+// download so an uploaded page or script never runs as part of the app.
+func (h *Handlers) DownloadTaskAttachment(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid attachment id")
+	}
+	attachment, err := h.store.GetTaskAttachment(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(attachment.Data)))
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	_, err = w.Write(attachment.Data)
+	return err
+}
+
+// DeleteTaskAttachment deletes an attachment.
+func (h *Handlers) DeleteTaskAttachment(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid attachment id")
+	}
+	if err := h.store.DeleteTaskAttachment(r.Context(), id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"mytasks/internal/models"
+)
+
+// ListTaskLinks returns a task's links, made on either task, as JSON.
+func (h *Handlers) ListTaskLinks(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid task id")
+	}
+	if _, err := h.store.GetTask(ctx, id); err != nil {
+		return err
+	}
+	links, err := h.store.ListTaskLinks(ctx, id)
+	if err != nil {
+		return err
+	}
+	if links == nil {
+		links = []models.TaskLink{}
+	}
+	return writeJSON(w, r, links)
+}
+
+// CreateTaskLink links a task to the one given as related_task_id and
+// renders the link for the task's Related section.
+func (h *Handlers) CreateTaskLink(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid task id")
+	}
+	if err := r.ParseForm(); err != nil {
+		return badRequest("invalid form data")
+	}
+	relatedID, err := strconv.ParseInt(r.FormValue("related_task_id"), 10, 64)
+	if err != nil {
+		return badRequest("invalid related_task_id")
+	}
+
+	link := &models.TaskLink{TaskID: id, RelatedTaskID: relatedID, Relation: r.FormValue("relation")}
+	if err := link.Validate(); err != nil {
+		return err
+	}
+	if err := h.store.CreateTaskLink(ctx, link); err != nil {
+		return err
+	}
+
+	// Read the link back with the related task filled in.
+	links, err := h.store.ListTaskLinks(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, l := range links {
+		if l.ID == link.ID {
+			link = &l
+			break
+		}
+	}
+	return h.renderPartial(w, "task_link.html", map[string]interface{}{
+		"Link": link,
+		"Lang": h.localizer(r),
+	})
+}
+
+// DeleteTaskLink unlinks two tasks.
+func (h *Handlers) DeleteTaskLink(w http.ResponseWriter, r *http.Request) error {
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid link id")
+	}
+	if err := h.store.DeleteTaskLink(r.Context(), id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
@@ -2,40 +2,76 @@ package handlers
 
 import (
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
+	"mytasks/internal/i18n"
 	"mytasks/internal/models"
+	"mytasks/internal/store"
 )
 
 // UpcomingData holds data for the Upcoming tasks template.
 type UpcomingData struct {
 	PageData
-	UpcomingTasks []models.Task
-	UpcomingDays  int
+	Groups         []TaskGroup
+	UpcomingDays   int
+	IncludeUndated bool
 }
 
-// Upcoming renders the cross-project upcoming tasks view.
-func (h *Handlers) Upcoming(w http.ResponseWriter, r *http.Request) {
+// TaskGroup is a labelled run of tasks, such as all tasks due on one day.
+type TaskGroup struct {
+	Label   string
+	Overdue bool
+	Tasks   []models.Task
+}
+
+// Upcoming renders the cross-project upcoming tasks view, grouped by due day
+// with the most urgent tasks of each day first.
+// ?days sets the range (1–365, defaulting to the upcoming window setting) and
+// ?undated=1 adds high-priority tasks without a due date.
+func (h *Handlers) Upcoming(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
-	days := 30
+	settings, err := h.store.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	days := settings.UpcomingDays
 	if v := r.URL.Query().Get("days"); v != "" {
 		d, err := strconv.Atoi(v)
-		if err == nil && (d == 7 || d == 14 || d == 30) {
+		if err == nil && d >= 1 && d <= models.MaxUpcomingDays {
 			days = d
 		}
 	}
+	includeUndated := r.URL.Query().Get("undated") == "1"
 
 	tasks, err := h.store.ListUpcomingTasks(ctx, days)
 	if err != nil {
-		respondServerError(w, err)
-		return
+		return err
+	}
+
+	flagStale(settings.StaleDays, tasks)
+	lang := h.localizer(r)
+	groups := groupByDueDay(tasks, dateOnly(time.Now()), lang)
+	for _, group := range groups {
+		sortByUrgency(group.Tasks)
+	}
+	if includeUndated {
+		undated, err := h.store.ListOpenTasks(ctx, store.TaskFilter{Priority: "high", Due: store.DueNone})
+		if err != nil {
+			return err
+		}
+		flagStale(settings.StaleDays, undated)
+		sortByUrgency(undated)
+		if len(undated) > 0 {
+			groups = append(groups, TaskGroup{Label: lang.T("No due date"), Tasks: undated})
+		}
 	}
 
 	activeProjects, err := h.loadActiveProjects(ctx)
 	if err != nil {
-		respondServerError(w, err)
-		return
+		return err
 	}
 
 	data := UpcomingData{
@@ -43,10 +79,51 @@ func (h *Handlers) Upcoming(w http.ResponseWriter, r *http.Request) {
 			Title:          "Upcoming",
 			ActiveProjects: activeProjects,
 			CurrentView:    "upcoming",
+			Prefs:          *settings,
+			Lang:           lang,
 		},
-		UpcomingTasks: tasks,
-		UpcomingDays:  days,
+		Groups:         groups,
+		UpcomingDays:   days,
+		IncludeUndated: includeUndated,
 	}
 
-	h.renderTemplate(w, "upcoming.html", data)
+	return h.renderTemplate(w, "upcoming.html", data)
+}
+
+// groupByDueDay splits tasks sorted by due date into an "Overdue" group and
+// one group per day labelled "Today", "Tomorrow" or e.g. "Fri 21 Jun", in
+// lang's language.
+func groupByDueDay(tasks []models.Task, today time.Time, lang *i18n.Localizer) []TaskGroup {
+	var groups []TaskGroup
+	for _, task := range tasks {
+		if task.DueDate == nil {
+			continue
+		}
+		due := dateOnly(*task.DueDate)
+
+		overdue := due.Before(today)
+		label := lang.Day(due, today)
+		if overdue {
+			label = lang.T("Overdue")
+		}
+
+		if n := len(groups); n > 0 && groups[n-1].Label == label {
+			groups[n-1].Tasks = append(groups[n-1].Tasks, task)
+			continue
+		}
+		groups = append(groups, TaskGroup{Label: label, Overdue: overdue, Tasks: []models.Task{task}})
+	}
+	return groups
+}
+
+// sortByUrgency orders tasks most urgent first, keeping the existing order
+// between equally urgent ones.
+func sortByUrgency(tasks []models.Task) {
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Urgency > tasks[j].Urgency })
+}
+
+// dateOnly returns t's calendar date as midnight UTC, the form due dates are
+// stored in.
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 }
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// WaitingData holds data for the Waiting For template.
+type WaitingData struct {
+	PageData
+	Tasks []models.Task
+	Now   time.Time
+}
+
+// Waiting renders the open tasks delegated to someone else, those waited on
+// the longest first, with how many days each has been waiting.
+func (h *Handlers) Waiting(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	tasks, err := h.store.ListOpenTasks(ctx, store.TaskFilter{Waiting: true})
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i].WaitingSince, tasks[j].WaitingSince
+		return a != nil && (b == nil || a.Before(*b))
+	})
+	projects, err := h.loadActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	return h.renderTemplate(w, "waiting.html", WaitingData{
+		PageData: PageData{
+			Title:          "Waiting For",
+			ActiveProjects: projects,
+			CurrentView:    "waiting",
+			Prefs:          h.prefs(ctx),
+			Lang:           h.localizer(r),
+		},
+		Tasks: tasks,
+		Now:   time.Now(),
+	})
+}
+
+// StopWaiting takes a task back from whoever it was waiting on, returning
+// it to the open counts. API clients get the task back; htmx gets an empty
+// body, so the row that sent the request can be swapped out.
+func (h *Handlers) StopWaiting(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	id, err := parseID(r, "id")
+	if err != nil {
+		return badRequest("invalid task id")
+	}
+	task, err := h.store.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	task.WaitingOn = ""
+	if err := h.store.UpdateTask(ctx, task); err != nil {
+		return err
+	}
+	h.publishTaskChange(r, task, task.IsDone(), task.ProjectID)
+
+	if r.Header.Get("HX-Request") != "true" {
+		return writeJSON(w, r, task)
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
@@ -0,0 +1,65 @@
+package housekeeping
+
+import (
+	"context"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/store"
+)
+
+// AutoArchiver completes projects whose tasks are all done and have not
+// changed for a while, moving them off the sidebar and into the archive.
+type AutoArchiver struct {
+	store store.Store
+	bus   *events.Bus
+	after time.Duration
+	now   func() time.Time
+}
+
+// NewAutoArchiver creates an archiver for projects left finished for the
+// given number of days. bus may be nil.
+func NewAutoArchiver(s store.Store, bus *events.Bus, days int) *AutoArchiver {
+	return &AutoArchiver{store: s, bus: bus, after: time.Duration(days) * 24 * time.Hour, now: time.Now}
+}
+
+// Run archives every finished project. Empty projects are left alone, since
+// they are more likely new than finished, and so are checklists.
+func (a *AutoArchiver) Run(ctx context.Context) error {
+	projects, err := a.store.ListActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+	cutoff := a.now().Add(-a.after)
+
+	for _, project := range projects {
+		// A checklist with everything ticked off is waiting for its reset.
+		if project.IsChecklist() {
+			continue
+		}
+		tasks, err := a.store.ListTasksByProject(ctx, project.ID, 0)
+		if err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			continue
+		}
+
+		finished := true
+		for _, task := range tasks {
+			if !task.IsDone() || task.UpdatedAt.After(cutoff) {
+				finished = false
+				break
+			}
+		}
+		if !finished {
+			continue
+		}
+
+		if err := a.store.MarkProjectComplete(ctx, project.ID); err != nil {
+			return err
+		}
+		a.bus.Publish(ctx, events.Event{Type: events.ProjectCompleted, ProjectID: project.ID})
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// Package housekeeping holds the retention and cleanup jobs the scheduler
+// runs: database backups, archiving finished projects and purging deleted
+// tasks.
+package housekeeping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"mytasks/internal/store"
+)
+
+const backupPrefix = "mytasks-"
+
+// Backup copies the database into a directory and keeps the newest few
+// copies.
+type Backup struct {
+	store store.Store
+	dir   string
+	keep  int
+	now   func() time.Time
+}
+
+// NewBackup creates a backup job writing to dir. keep below 1 keeps every
+// backup.
+func NewBackup(s store.Store, dir string, keep int) *Backup {
+	return &Backup{store: s, dir: dir, keep: keep, now: time.Now}
+}
+
+// Run writes one backup named after the current time, then deletes the
+// oldest backups beyond the ones to keep.
+func (b *Backup) Run(ctx context.Context) error {
+	if err := os.MkdirAll(b.dir, 0755); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	path := filepath.Join(b.dir, backupPrefix+b.now().UTC().Format("20060102-150405")+".db")
+	if err := b.store.Backup(ctx, path); err != nil {
+		return err
+	}
+	return b.prune()
+}
+
+func (b *Backup) prune() error {
+	if b.keep < 1 {
+		return nil
+	}
+	backups, err := ListBackups(b.dir)
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups[min(b.keep, len(backups)):] {
+		if err := os.Remove(filepath.Join(b.dir, backup.Name)); err != nil {
+			return fmt.Errorf("backup: %w", err)
+		}
+	}
+	return nil
+}
+
+// BackupFile is one backup written by Backup.
+type BackupFile struct {
+	Name      string
+	Size      int64
+	CreatedAt time.Time
+}
+
+// ListBackups returns the backups in dir, newest first. A directory that does
+// not exist yet has none.
+func ListBackups(dir string) ([]BackupFile, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("backup: %w", err)
+	}
+
+	var backups []BackupFile
+	for _, e := range entries {
+		name := e.Name()
+		stamp, ok := strings.CutPrefix(strings.TrimSuffix(name, ".db"), backupPrefix)
+		if e.IsDir() || !ok || !strings.HasSuffix(name, ".db") {
+			continue
+		}
+		backup := BackupFile{Name: name}
+		backup.CreatedAt, _ = time.Parse("20060102-150405", stamp)
+		if info, err := e.Info(); err == nil {
+			backup.Size = info.Size()
+		}
+		backups = append(backups, backup)
+	}
+	// The timestamped names sort oldest first.
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Name > backups[j].Name })
+	return backups, nil
+}
@@ -0,0 +1,53 @@
+package housekeeping
+
+import (
+	"context"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/store"
+)
+
+// ChecklistResetter puts the done tasks of checklist projects back in To Do
+// once their daily or weekly reset comes round.
+type ChecklistResetter struct {
+	store store.Store
+	bus   *events.Bus
+	now   func() time.Time
+}
+
+// NewChecklistResetter creates a resetter for checklist projects. bus may be
+// nil.
+func NewChecklistResetter(s store.Store, bus *events.Bus) *ChecklistResetter {
+	return &ChecklistResetter{store: s, bus: bus, now: time.Now}
+}
+
+// Run resets every active checklist that hasn't reset since its last
+// scheduled time. A reset missed while the server was down happens on the
+// next run, once.
+func (c *ChecklistResetter) Run(ctx context.Context) error {
+	settings, err := c.store.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	projects, err := c.store.ListActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+	now := c.now()
+
+	for _, project := range projects {
+		if !project.IsChecklist() {
+			continue
+		}
+		due := project.LastChecklistReset(now, settings.WeekStart)
+		if project.ChecklistResetAt != nil && !project.ChecklistResetAt.Before(due) {
+			continue
+		}
+		if _, err := c.store.ResetChecklist(ctx, project.ID, now); err != nil {
+			return err
+		}
+		c.bus.Publish(ctx, events.Event{Type: events.ProjectUpdated, ProjectID: project.ID})
+	}
+	return nil
+}
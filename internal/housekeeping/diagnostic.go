@@ -0,0 +1,80 @@
+package housekeeping
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	mathrand "math/rand/v2"
+	"os"
+	"path/filepath"
+	"unicode"
+
+	"mytasks/internal/store"
+)
+
+// NewScrambler returns a function replacing every letter of a text with a
+// random letter of the same case and every digit with a random digit,
+// keeping spaces, punctuation and line breaks, so texts keep their shape
+// but not their words. Equal texts scramble alike, with a key of its own
+// for each scrambler, so the output can't be matched against guesses.
+func NewScrambler() func(string) string {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err) // crypto/rand doesn't fail on supported platforms
+	}
+	return func(text string) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(text))
+		var seed [32]byte
+		copy(seed[:], mac.Sum(nil))
+		rng := mathrand.New(mathrand.NewChaCha8(seed))
+
+		out := []rune(text)
+		for i, r := range out {
+			switch {
+			case unicode.IsUpper(r):
+				out[i] = 'A' + rng.Int32N(26)
+			case unicode.IsLetter(r):
+				out[i] = 'a' + rng.Int32N(26)
+			case unicode.IsDigit(r):
+				out[i] = '0' + rng.Int32N(10)
+			}
+		}
+		return string(out)
+	}
+}
+
+// Diagnostic is an anonymized copy of the database, for attaching to a bug
+// report; see store.Store.BackupAnonymized. It is written to a temporary
+// directory, removed by Close.
+type Diagnostic struct {
+	*os.File
+	dir string
+}
+
+// OpenDiagnostic writes a Diagnostic and opens it for reading.
+func OpenDiagnostic(ctx context.Context, s store.Store) (*Diagnostic, error) {
+	dir, err := os.MkdirTemp("", "mytasks-diagnostic-")
+	if err != nil {
+		return nil, fmt.Errorf("diagnostic: %w", err)
+	}
+	path := filepath.Join(dir, "mytasks.db")
+	if err := s.BackupAnonymized(ctx, path, NewScrambler()); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("diagnostic: %w", err)
+	}
+	return &Diagnostic{File: f, dir: dir}, nil
+}
+
+// Close closes and deletes the copy.
+func (d *Diagnostic) Close() error {
+	d.File.Close()
+	return os.RemoveAll(d.dir)
+}
@@ -0,0 +1,236 @@
+package housekeeping
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+	"unicode"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+func setupStore(t *testing.T) *store.SQLiteStore {
+	t.Helper()
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestBackupKeepsNewest(t *testing.T) {
+	s := setupStore(t)
+	dir := t.TempDir()
+	os.WriteFile(dir+"/notes.txt", nil, 0644)
+
+	b := NewBackup(s, dir, 2)
+	start := time.Date(2024, time.June, 19, 3, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		b.now = func() time.Time { return start.AddDate(0, 0, i) }
+		if err := b.Run(context.Background()); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{"mytasks-20240620-030000.db", "mytasks-20240621-030000.db", "notes.txt"}
+	if len(names) != len(want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, names)
+			break
+		}
+	}
+}
+
+func TestListBackups(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"mytasks-20240619-030000.db", "mytasks-20240620-030000.db", "notes.txt"} {
+		os.WriteFile(dir+"/"+name, []byte("x"), 0644)
+	}
+
+	backups, err := ListBackups(dir)
+	if err != nil {
+		t.Fatalf("ListBackups failed: %v", err)
+	}
+	if len(backups) != 2 || backups[0].Name != "mytasks-20240620-030000.db" || backups[1].Size != 1 {
+		t.Fatalf("expected the two backups newest first, got %+v", backups)
+	}
+	if want := time.Date(2024, time.June, 20, 3, 0, 0, 0, time.UTC); !backups[0].CreatedAt.Equal(want) {
+		t.Errorf("CreatedAt = %v, want %v", backups[0].CreatedAt, want)
+	}
+
+	if backups, err := ListBackups(dir + "/missing"); err != nil || len(backups) != 0 {
+		t.Errorf("expected no backups in a missing directory, got %v, %v", backups, err)
+	}
+}
+
+func TestAutoArchiver(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	finished := &models.Project{Name: "Move house", Type: "project"}
+	open := &models.Project{Name: "Garden", Type: "project"}
+	empty := &models.Project{Name: "New idea", Type: "project"}
+	for _, p := range []*models.Project{finished, open, empty} {
+		s.CreateProject(ctx, p)
+	}
+	s.CreateTask(ctx, &models.Task{ProjectID: finished.ID, Description: "Pack", Priority: "medium", Status: "done"})
+	s.CreateTask(ctx, &models.Task{ProjectID: open.ID, Description: "Mow", Priority: "medium", Status: "done"})
+	s.CreateTask(ctx, &models.Task{ProjectID: open.ID, Description: "Weed", Priority: "medium", Status: "todo"})
+
+	a := NewAutoArchiver(s, nil, 7)
+	if err := a.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if p, _ := s.GetProject(ctx, finished.ID); p.Completed {
+		t.Fatal("expected a recently finished project to stay active")
+	}
+
+	a.now = func() time.Time { return time.Now().AddDate(0, 0, 8) }
+	if err := a.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, tt := range []struct {
+		project *models.Project
+		want    bool
+	}{{finished, true}, {open, false}, {empty, false}} {
+		if p, _ := s.GetProject(ctx, tt.project.ID); p.Completed != tt.want {
+			t.Errorf("%s: expected completed=%v, got %v", tt.project.Name, tt.want, p.Completed)
+		}
+	}
+}
+
+func TestDeletedTaskPurger(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Errands", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Post parcel", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, task)
+	s.SoftDeleteTask(ctx, task.ID, "undo", time.Now())
+
+	p := NewDeletedTaskPurger(s)
+	if err := p.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if _, err := s.RestoreTask(ctx, "undo", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("expected a task inside the undo window to survive, got %v", err)
+	}
+
+	s.SoftDeleteTask(ctx, task.ID, "undo", time.Now())
+	p.now = func() time.Time { return time.Now().Add(models.TaskUndoWindow + time.Second) }
+	if err := p.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if _, err := s.RestoreTask(ctx, "undo", time.Time{}); err == nil {
+		t.Fatal("expected the task to be purged once the undo window passed")
+	}
+}
+
+func TestChecklistResetter(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	morning := &models.Project{Name: "Morning", Type: "project", ChecklistReset: models.ChecklistDaily, ChecklistHour: 6}
+	errands := &models.Project{Name: "Errands", Type: "project"}
+	for _, p := range []*models.Project{morning, errands} {
+		s.CreateProject(ctx, p)
+	}
+	stretch := &models.Task{ProjectID: morning.ID, Description: "Stretch", Priority: "medium", Status: "todo"}
+	post := &models.Task{ProjectID: errands.ID, Description: "Post parcel", Priority: "medium", Status: "todo"}
+	for _, task := range []*models.Task{stretch, post} {
+		s.CreateTask(ctx, task)
+		s.ToggleTaskComplete(ctx, task.ID)
+	}
+
+	c := NewChecklistResetter(s, nil)
+	if err := c.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if task, _ := s.GetTask(ctx, stretch.ID); !task.IsDone() {
+		t.Fatal("expected the checklist to wait for its next reset")
+	}
+
+	c.now = func() time.Time { return time.Now().AddDate(0, 0, 1) }
+	if err := c.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if task, _ := s.GetTask(ctx, stretch.ID); task.IsDone() || task.CompletedAt != nil {
+		t.Errorf("expected the checklist task to reset, got status %q", task.Status)
+	}
+	if task, _ := s.GetTask(ctx, post.ID); !task.IsDone() {
+		t.Error("expected tasks outside checklists to stay done")
+	}
+	if completions, _ := s.ListTaskCompletions(ctx, stretch.ID); len(completions) != 1 {
+		t.Errorf("expected the completion to be kept, got %d", len(completions))
+	}
+
+	// Ticked off again the same day, it stays done until the next reset.
+	s.ToggleTaskComplete(ctx, stretch.ID)
+	if err := c.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if task, _ := s.GetTask(ctx, stretch.ID); !task.IsDone() {
+		t.Error("expected a checklist to reset once per day")
+	}
+}
+
+func TestScrambler(t *testing.T) {
+	scramble := NewScrambler()
+	text := "Call Dr. Smith at 10:30\n- [ ] Ask about Zoë"
+	got := scramble(text)
+
+	if got == text || len([]rune(got)) != len([]rune(text)) {
+		t.Fatalf("expected a different text of the same length, got %q", got)
+	}
+	for i, r := range []rune(text) {
+		g := []rune(got)[i]
+		switch {
+		case unicode.IsUpper(r) && !unicode.IsUpper(g),
+			unicode.IsLower(r) && !unicode.IsLower(g),
+			unicode.IsDigit(r) && !unicode.IsDigit(g),
+			!unicode.IsLetter(r) && !unicode.IsDigit(r) && g != r:
+			t.Errorf("character %d: %q became %q", i, r, g)
+		}
+	}
+	if scramble(text) != got {
+		t.Error("expected equal texts to scramble alike")
+	}
+	if NewScrambler()(text) == got {
+		t.Error("expected another scrambler to use another key")
+	}
+}
+
+func TestOpenDiagnostic(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	s.CreateProject(ctx, &models.Project{Name: "Home", Type: "project"})
+
+	diagnostic, err := OpenDiagnostic(ctx, s)
+	if err != nil {
+		t.Fatalf("OpenDiagnostic failed: %v", err)
+	}
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(diagnostic, header); err != nil || string(header) != "SQLite format 3\x00" {
+		t.Errorf("expected an SQLite database, got %q (%v)", header, err)
+	}
+	name := diagnostic.Name()
+	if err := diagnostic.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("expected the copy deleted on Close, got %v", err)
+	}
+}
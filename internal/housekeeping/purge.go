@@ -0,0 +1,26 @@
+package housekeeping
+
+import (
+	"context"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// DeletedTaskPurger finishes deleting tasks whose undo window has passed.
+type DeletedTaskPurger struct {
+	store store.Store
+	now   func() time.Time
+}
+
+// NewDeletedTaskPurger creates a purger for tasks deleted from the web UI.
+func NewDeletedTaskPurger(s store.Store) *DeletedTaskPurger {
+	return &DeletedTaskPurger{store: s, now: time.Now}
+}
+
+// Run removes every task deleted more than models.TaskUndoWindow ago.
+func (p *DeletedTaskPurger) Run(ctx context.Context) error {
+	_, err := p.store.PurgeDeletedTasks(ctx, p.now().Add(-models.TaskUndoWindow))
+	return err
+}
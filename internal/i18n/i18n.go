@@ -0,0 +1,154 @@
+// Package i18n translates the interface. Messages are keyed by their English
+// text, so English needs no catalog and anything not yet translated falls
+// back to English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultLang is used when no supported language is requested.
+const DefaultLang = "en"
+
+// Language is a language the interface is available in.
+type Language struct {
+	Code string // BCP 47 primary tag, e.g. "de"
+	Name string // in the language itself
+}
+
+// Languages lists the supported languages, DefaultLang first.
+var Languages = []Language{
+	{Code: "en", Name: "English"},
+	{Code: "es", Name: "Español"},
+	{Code: "de", Name: "Deutsch"},
+}
+
+//go:embed locales/*.json
+var localesFS embed.FS
+
+// catalogs maps a language code to its translations, keyed by English text.
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	files, err := localesFS.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	catalogs := make(map[string]map[string]string, len(files))
+	for _, f := range files {
+		data, err := localesFS.ReadFile(path.Join("locales", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: %s: %v", f.Name(), err))
+		}
+		catalogs[strings.TrimSuffix(f.Name(), ".json")] = messages
+	}
+	return catalogs
+}
+
+// Supported reports whether code is one of Languages.
+func Supported(code string) bool {
+	for _, l := range Languages {
+		if l.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Localizer translates messages into one language. A nil Localizer leaves
+// messages in English.
+type Localizer struct {
+	lang     string
+	messages map[string]string
+}
+
+// New returns a Localizer for code, or for DefaultLang when code is not
+// supported.
+func New(code string) *Localizer {
+	if !Supported(code) {
+		code = DefaultLang
+	}
+	return &Localizer{lang: code, messages: catalogs[code]}
+}
+
+// Code returns the language code, for the lang attribute.
+func (l *Localizer) Code() string {
+	if l == nil {
+		return DefaultLang
+	}
+	return l.lang
+}
+
+// T translates msg and, given args, formats the result with fmt.Sprintf.
+func (l *Localizer) T(msg string, args ...interface{}) string {
+	if l != nil {
+		if translated, ok := l.messages[msg]; ok {
+			msg = translated
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Day names a calendar day relative to today: "Yesterday", "Today",
+// "Tomorrow", or e.g. "Fri 21 Jun", with the year when it is not today's.
+func (l *Localizer) Day(day, today time.Time) string {
+	switch {
+	case day.Equal(today.AddDate(0, 0, -1)):
+		return l.T("Yesterday")
+	case day.Equal(today):
+		return l.T("Today")
+	case day.Equal(today.AddDate(0, 0, 1)):
+		return l.T("Tomorrow")
+	}
+
+	label := l.T(day.Format("Mon")) + " " + strconv.Itoa(day.Day()) + " " + l.T(day.Format("Jan"))
+	if day.Year() != today.Year() {
+		label += " " + strconv.Itoa(day.Year())
+	}
+	return label
+}
+
+// Match picks the supported language a browser prefers from its
+// Accept-Language header, or DefaultLang.
+func Match(acceptLanguage string) string {
+	type choice struct {
+		code string
+		q    float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		code, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if !Supported(code) {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			choices = append(choices, choice{code, q})
+		}
+	}
+	if len(choices) == 0 {
+		return DefaultLang
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+	return choices[0].code
+}
@@ -0,0 +1,83 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"de", "de"},
+		{"de-AT,de;q=0.9", "de"},
+		{"fr-FR,fr;q=0.9,es;q=0.8,en;q=0.7", "es"},
+		{"en;q=0.5,es;q=0.8", "es"},
+		{"es;q=0,de;q=0.1", "de"},
+		{"fr, it", "en"},
+		{"*", "en"},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.header); got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	de := New("de")
+	if got := de.T("Settings"); got != "Einstellungen" {
+		t.Errorf("expected a translation, got %q", got)
+	}
+	if got := de.T("No upcoming tasks in the next %d days.", 7); got != "Keine Aufgaben in den nächsten 7 Tagen." {
+		t.Errorf("expected a formatted translation, got %q", got)
+	}
+	if got := de.T("Not in any catalog"); got != "Not in any catalog" {
+		t.Errorf("expected untranslated text to fall back to English, got %q", got)
+	}
+
+	var none *Localizer
+	if got := none.T("%d Days", 7); got != "7 Days" {
+		t.Errorf("expected a nil Localizer to format English, got %q", got)
+	}
+	if New("fr").Code() != DefaultLang {
+		t.Error("expected unsupported languages to fall back to the default")
+	}
+}
+
+func TestCatalogsCoverEachOther(t *testing.T) {
+	for _, l := range Languages[1:] {
+		for _, other := range Languages[1:] {
+			for msg := range catalogs[other.Code] {
+				if _, ok := catalogs[l.Code][msg]; !ok {
+					t.Errorf("%s: missing translation for %q", l.Code, msg)
+				}
+			}
+		}
+	}
+}
+
+func TestDay(t *testing.T) {
+	today := time.Date(2024, time.June, 19, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		lang string
+		days int
+		want string
+	}{
+		{"en", -1, "Yesterday"},
+		{"en", 0, "Today"},
+		{"en", 2, "Fri 21 Jun"},
+		{"en", 200, "Sun 5 Jan 2025"},
+		{"es", 1, "Mañana"},
+		{"es", 2, "vie 21 jun"},
+		{"de", 0, "Heute"},
+		{"de", 200, "So 5 Jan 2025"},
+	}
+	for _, tt := range tests {
+		if got := New(tt.lang).Day(today.AddDate(0, 0, tt.days), today); got != tt.want {
+			t.Errorf("%s %+d: got %q, want %q", tt.lang, tt.days, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Package importer converts other task managers' formats into projects and
+// tasks and writes them through the Store.
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// DefaultProjectName receives tasks that appear outside any project.
+const DefaultProjectName = "Inbox"
+
+// Project is a parsed project and its tasks, not yet persisted.
+type Project struct {
+	Name        string
+	Description string
+	Tasks       []Task
+}
+
+// Task is a parsed task. ExternalID, when set, identifies the task in the
+// source system so importing the same data again updates it in place.
+type Task struct {
+	models.Task
+	ExternalID string
+}
+
+// Summary reports the records Apply created or updated.
+type Summary struct {
+	Projects []models.Project // newly created projects
+	Tasks    []models.Task    // all created tasks, across new and existing projects
+	Updated  []Change         // previously imported tasks that were updated
+}
+
+// Change is an updated task and the project it was in before the import.
+type Change struct {
+	Task              models.Task
+	PreviousProjectID int64
+}
+
+// Format is a supported import format.
+type Format struct {
+	Name  string
+	Label string
+	Parse func(r io.Reader) ([]Project, error)
+}
+
+// Formats lists the formats offered on the import page, in display order.
+var Formats = []Format{
+	{Name: "taskpaper", Label: "TaskPaper / plain-text outline", Parse: ParseTaskPaper},
+	{Name: TaskwarriorSource, Label: "Taskwarrior JSON (task export)", Parse: ParseTaskwarrior},
+	{Name: MicrosoftToDoSource, Label: "Microsoft To Do JSON", Parse: ParseMicrosoftToDo},
+}
+
+// LookupFormat returns the format with the given name.
+func LookupFormat(name string) (Format, bool) {
+	for _, f := range Formats {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// Apply creates the parsed projects and tasks. Tasks are added to an existing
+// active project when one has the same name (case-insensitively); otherwise
+// a new project is created. Tasks with an ExternalID already linked under
+// source are updated instead of created.
+func Apply(ctx context.Context, s store.Store, source string, projects []Project) (*Summary, error) {
+	active, err := s.ListActiveProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]int64, len(active))
+	for _, p := range active {
+		byName[strings.ToLower(p.Name)] = p.ID
+	}
+
+	summary := &Summary{}
+	for _, parsed := range projects {
+		name := strings.TrimSpace(parsed.Name)
+		if name == "" {
+			name = DefaultProjectName
+		}
+
+		projectID, ok := byName[strings.ToLower(name)]
+		if !ok {
+			project := &models.Project{Name: name, Description: parsed.Description, Type: "project"}
+			if err := project.Validate(); err != nil {
+				return summary, fmt.Errorf("project %q: %w", name, err)
+			}
+			if err := s.CreateProject(ctx, project); err != nil {
+				return summary, err
+			}
+			projectID = project.ID
+			byName[strings.ToLower(name)] = projectID
+			summary.Projects = append(summary.Projects, *project)
+		}
+
+		for _, parsedTask := range parsed.Tasks {
+			task := parsedTask.Task
+			task.ProjectID = projectID
+			if task.Priority == "" {
+				task.Priority = "medium"
+			}
+			if task.Status == "" {
+				task.Status = "todo"
+			}
+			task.Notes = models.TruncateNotes(task.Notes)
+			task.Tags = models.NormalizeTags(task.Tags)
+			if err := task.Validate(); err != nil {
+				return summary, fmt.Errorf("task %q: %w", task.Description, err)
+			}
+
+			if source != "" && parsedTask.ExternalID != "" {
+				updated, err := updateLinked(ctx, s, source, parsedTask.ExternalID, task)
+				if err != nil {
+					return summary, err
+				}
+				if updated != nil {
+					summary.Updated = append(summary.Updated, *updated)
+					continue
+				}
+			}
+
+			if err := s.CreateTask(ctx, &task); err != nil {
+				return summary, err
+			}
+			if source != "" && parsedTask.ExternalID != "" {
+				if err := s.SetTaskExternalRef(ctx, source, parsedTask.ExternalID, task.ID); err != nil {
+					return summary, err
+				}
+			}
+			summary.Tasks = append(summary.Tasks, task)
+		}
+	}
+
+	return summary, nil
+}
+
+// updateLinked overwrites the task linked to externalID with parsed values.
+// It returns nil when no live task is linked.
+func updateLinked(ctx context.Context, s store.Store, source, externalID string, parsed models.Task) (*Change, error) {
+	taskID, err := s.GetTaskIDByExternalRef(ctx, source, externalID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.GetTask(ctx, taskID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	change := &Change{PreviousProjectID: existing.ProjectID}
+	existing.ProjectID = parsed.ProjectID
+	existing.Description = parsed.Description
+	existing.Notes = parsed.Notes
+	existing.Priority = parsed.Priority
+	existing.Status = parsed.Status
+	existing.DueDate = parsed.DueDate
+	existing.Tags = parsed.Tags
+	if parsed.CompletedAt != nil {
+		existing.CompletedAt = parsed.CompletedAt
+	}
+	if err := s.UpdateTask(ctx, existing); err != nil {
+		return nil, err
+	}
+
+	change.Task = *existing
+	return change, nil
+}
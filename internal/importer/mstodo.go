@@ -0,0 +1,213 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"mytasks/internal/models"
+)
+
+// MicrosoftToDoSource is the external-ref source for Microsoft To Do task IDs.
+const MicrosoftToDoSource = "mstodo"
+
+// GraphBaseURL is the Microsoft Graph endpoint used by FetchMicrosoftToDo.
+const GraphBaseURL = "https://graph.microsoft.com/v1.0"
+
+// msTodoList mirrors a Graph todoTaskList, with its tasks inlined.
+type msTodoList struct {
+	ID          string       `json:"id"`
+	DisplayName string       `json:"displayName"`
+	Tasks       []msTodoTask `json:"tasks"`
+}
+
+// msTodoTask mirrors the Graph todoTask fields the importer maps.
+type msTodoTask struct {
+	ID                string            `json:"id"`
+	Title             string            `json:"title"`
+	Status            string            `json:"status"`
+	Importance        string            `json:"importance"`
+	Body              *msTodoBody       `json:"body"`
+	DueDateTime       *msTodoDateTime   `json:"dueDateTime"`
+	CompletedDateTime *msTodoDateTime   `json:"completedDateTime"`
+	Categories        []string          `json:"categories"`
+	ChecklistItems    []msTodoChecklist `json:"checklistItems"`
+}
+
+type msTodoBody struct {
+	Content     string `json:"content"`
+	ContentType string `json:"contentType"`
+}
+
+type msTodoDateTime struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+type msTodoChecklist struct {
+	DisplayName string `json:"displayName"`
+	IsChecked   bool   `json:"isChecked"`
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// ParseMicrosoftToDo reads exported Microsoft To Do data: either a JSON array
+// of lists or an object with a "lists" (or Graph-style "value") array, where
+// each list carries its "tasks" as returned by the Graph todo API.
+func ParseMicrosoftToDo(r io.Reader) ([]Project, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var lists []msTodoList
+	trimmed := strings.TrimSpace(string(raw))
+	if strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(raw, &lists)
+	} else {
+		var wrapper struct {
+			Lists []msTodoList `json:"lists"`
+			Value []msTodoList `json:"value"`
+		}
+		err = json.Unmarshal(raw, &wrapper)
+		lists = append(wrapper.Lists, wrapper.Value...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid Microsoft To Do JSON: %w", err)
+	}
+
+	return msTodoProjects(lists), nil
+}
+
+// FetchMicrosoftToDo downloads every list and task for the signed-in user
+// from Microsoft Graph using a bearer token with Tasks.Read scope.
+func FetchMicrosoftToDo(ctx context.Context, client *http.Client, baseURL, token string) ([]Project, error) {
+	var lists []msTodoList
+	if err := graphGetAll(ctx, client, baseURL+"/me/todo/lists", token, &lists); err != nil {
+		return nil, err
+	}
+
+	for i := range lists {
+		endpoint := fmt.Sprintf("%s/me/todo/lists/%s/tasks?$expand=checklistItems", baseURL, url.PathEscape(lists[i].ID))
+		if err := graphGetAll(ctx, client, endpoint, token, &lists[i].Tasks); err != nil {
+			return nil, err
+		}
+	}
+
+	return msTodoProjects(lists), nil
+}
+
+// graphGetAll follows @odata.nextLink pages, appending each page's "value"
+// array to out (a pointer to a slice).
+func graphGetAll[T any](ctx context.Context, client *http.Client, endpoint, token string, out *[]T) error {
+	for endpoint != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("microsoft graph request failed: %w", err)
+		}
+
+		var page struct {
+			Value    []T    `json:"value"`
+			NextLink string `json:"@odata.nextLink"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("microsoft graph returned %s", resp.Status)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("invalid microsoft graph response: %w", err)
+		}
+
+		*out = append(*out, page.Value...)
+		endpoint = page.NextLink
+	}
+	return nil
+}
+
+func msTodoProjects(lists []msTodoList) []Project {
+	projects := make([]Project, 0, len(lists))
+	for _, list := range lists {
+		project := Project{Name: list.DisplayName}
+		for _, t := range list.Tasks {
+			project.Tasks = append(project.Tasks, Task{Task: msTodoTaskToModel(t), ExternalID: t.ID})
+		}
+		projects = append(projects, project)
+	}
+	return projects
+}
+
+func msTodoTaskToModel(t msTodoTask) models.Task {
+	task := models.Task{
+		Description: strings.TrimSpace(t.Title),
+		Priority:    "medium",
+		Status:      "todo",
+		Tags:        t.Categories,
+	}
+
+	switch strings.ToLower(t.Importance) {
+	case "high":
+		task.Priority = "high"
+	case "low":
+		task.Priority = "low"
+	}
+
+	switch t.Status {
+	case "completed":
+		task.Status = "done"
+		task.CompletedAt = msTodoDate(t.CompletedDateTime)
+	case "inProgress":
+		task.Status = "in_progress"
+	}
+	task.DueDate = msTodoDate(t.DueDateTime)
+
+	// Steps have no dedicated model yet; keep them as a checklist in the notes.
+	var notes []string
+	if t.Body != nil {
+		content := t.Body.Content
+		if strings.EqualFold(t.Body.ContentType, "html") {
+			content = html.UnescapeString(htmlTagPattern.ReplaceAllString(content, " "))
+		}
+		if content = strings.TrimSpace(content); content != "" {
+			notes = append(notes, content)
+		}
+	}
+	for _, item := range t.ChecklistItems {
+		mark := "[ ]"
+		if item.IsChecked {
+			mark = "[x]"
+		}
+		notes = append(notes, "- "+mark+" "+strings.TrimSpace(item.DisplayName))
+	}
+	task.Notes = strings.Join(notes, "\n")
+
+	return task
+}
+
+// msTodoDate reads a Graph dateTimeTimeZone as a calendar date. To Do stores
+// due dates as midnight in the user's zone, so only the date part is kept.
+func msTodoDate(dt *msTodoDateTime) *time.Time {
+	if dt == nil || len(dt.DateTime) < len("2006-01-02") {
+		return nil
+	}
+	t, err := time.Parse("2006-01-02", dt.DateTime[:len("2006-01-02")])
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const sampleMicrosoftToDo = `{"lists":[{"id":"L1","displayName":"Groceries","tasks":[
+{"id":"T1","title":"Buy coffee","status":"notStarted","importance":"high",
+ "body":{"content":"<p>Whole beans &amp; filters</p>","contentType":"html"},
+ "dueDateTime":{"dateTime":"2025-02-01T00:00:00.0000000","timeZone":"UTC"},
+ "categories":["Shopping"],
+ "checklistItems":[{"displayName":"Decaf","isChecked":true},{"displayName":"Espresso","isChecked":false}]},
+{"id":"T2","title":"Milk","status":"completed","importance":"normal",
+ "completedDateTime":{"dateTime":"2025-01-20T10:00:00.0000000","timeZone":"UTC"}}
+]}]}`
+
+func TestParseMicrosoftToDo(t *testing.T) {
+	projects, err := ParseMicrosoftToDo(strings.NewReader(sampleMicrosoftToDo))
+	if err != nil {
+		t.Fatalf("ParseMicrosoftToDo failed: %v", err)
+	}
+	if len(projects) != 1 || projects[0].Name != "Groceries" || len(projects[0].Tasks) != 2 {
+		t.Fatalf("unexpected projects %+v", projects)
+	}
+
+	coffee := projects[0].Tasks[0]
+	if coffee.ExternalID != "T1" || coffee.Priority != "high" || coffee.DueDate == nil {
+		t.Errorf("unexpected task %+v", coffee)
+	}
+	wantNotes := "Whole beans & filters\n- [x] Decaf\n- [ ] Espresso"
+	if coffee.Notes != wantNotes {
+		t.Errorf("expected notes %q, got %q", wantNotes, coffee.Notes)
+	}
+	if len(coffee.Tags) != 1 || coffee.Tags[0] != "Shopping" {
+		t.Errorf("expected categories as tags, got %v", coffee.Tags)
+	}
+
+	milk := projects[0].Tasks[1]
+	if milk.Status != "done" || milk.CompletedAt == nil || milk.Priority != "medium" {
+		t.Errorf("unexpected completed task %+v", milk.Task)
+	}
+}
+
+func TestFetchMicrosoftToDo_FollowsPages(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/me/todo/lists":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"value": []map[string]string{{"id": "L1", "displayName": "Work"}},
+			})
+		case r.URL.Path == "/me/todo/lists/L1/tasks" && r.URL.Query().Get("page") == "":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"value":           []map[string]string{{"id": "T1", "title": "First"}},
+				"@odata.nextLink": srv.URL + "/me/todo/lists/L1/tasks?page=2",
+			})
+		case r.URL.Path == "/me/todo/lists/L1/tasks":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"value": []map[string]string{{"id": "T2", "title": "Second"}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	projects, err := FetchMicrosoftToDo(context.Background(), srv.Client(), srv.URL, "tok")
+	if err != nil {
+		t.Fatalf("FetchMicrosoftToDo failed: %v", err)
+	}
+	if len(projects) != 1 || len(projects[0].Tasks) != 2 {
+		t.Fatalf("expected both pages of tasks, got %+v", projects)
+	}
+
+	if _, err := FetchMicrosoftToDo(context.Background(), srv.Client(), srv.URL, "bad"); err == nil {
+		t.Error("expected an error for a rejected token")
+	}
+}
@@ -0,0 +1,156 @@
+package importer
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"mytasks/internal/models"
+)
+
+// tagPattern matches TaskPaper tags such as @high or @due(2025-01-01).
+var tagPattern = regexp.MustCompile(`(^|\s)@([A-Za-z][\w-]*)(?:\(([^)]*)\))?`)
+
+// ParseTaskPaper reads TaskPaper-style outlines:
+//
+//	Errands:
+//		- Buy stamps @due(2025-01-01) @high
+//			Notes are any other indented line.
+//		- Return library books @done(2024-12-30)
+//
+// Lines ending in ":" start a project (nesting is flattened). Lines starting
+// with "- " or "* " are tasks. Recognised tags are @due(date), @high,
+// @medium, @low, @priority(level), @in_progress and @done[(date)]; any
+// other tag becomes a task tag. Tasks before the first project go to the
+// Inbox.
+func ParseTaskPaper(r io.Reader) ([]Project, error) {
+	var projects []Project
+	current := -1
+	var lastTask *Task
+
+	ensureProject := func() {
+		if current < 0 {
+			projects = append(projects, Project{Name: DefaultProjectName})
+			current = len(projects) - 1
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if text, ok := cutTaskPrefix(line); ok {
+			ensureProject()
+			task := parseTaskPaperTask(text)
+			projects[current].Tasks = append(projects[current].Tasks, Task{Task: task})
+			lastTask = &projects[current].Tasks[len(projects[current].Tasks)-1]
+			continue
+		}
+
+		if name, ok := projectHeader(line); ok {
+			projects = append(projects, Project{Name: name})
+			current = len(projects) - 1
+			lastTask = nil
+			continue
+		}
+
+		// Anything else is a note on the preceding task or project.
+		if lastTask != nil {
+			lastTask.Notes = appendLine(lastTask.Notes, line)
+		} else {
+			ensureProject()
+			projects[current].Description = appendLine(projects[current].Description, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+func cutTaskPrefix(line string) (string, bool) {
+	for _, prefix := range []string{"- ", "* "} {
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimSpace(line[len(prefix):]), true
+		}
+	}
+	return "", false
+}
+
+// projectHeader recognises "Name:" optionally followed by tags.
+func projectHeader(line string) (string, bool) {
+	stripped := strings.TrimSpace(tagPattern.ReplaceAllString(line, ""))
+	if !strings.HasSuffix(stripped, ":") {
+		return "", false
+	}
+	name := strings.TrimSpace(strings.TrimSuffix(stripped, ":"))
+	return name, name != ""
+}
+
+func parseTaskPaperTask(text string) models.Task {
+	task := models.Task{Priority: "medium", Status: "todo"}
+
+	description := tagPattern.ReplaceAllStringFunc(text, func(match string) string {
+		sub := tagPattern.FindStringSubmatch(match)
+		lead, name, value := sub[1], strings.ToLower(sub[2]), strings.TrimSpace(sub[3])
+
+		switch name {
+		case "due":
+			if due := parseTagDate(value); due != nil {
+				task.DueDate = due
+				return lead
+			}
+		case "high", "medium", "low":
+			task.Priority = name
+			return lead
+		case "priority":
+			if p := strings.ToLower(value); p == "high" || p == "medium" || p == "low" {
+				task.Priority = p
+				return lead
+			}
+		case "in_progress", "started":
+			if task.Status != "done" {
+				task.Status = "in_progress"
+			}
+			return lead
+		case "done":
+			task.Status = "done"
+			task.CompletedAt = parseTagDate(value)
+			return lead
+		}
+		if value == "" {
+			task.Tags = append(task.Tags, name)
+			return lead
+		}
+		return match
+	})
+
+	task.Description = strings.Join(strings.Fields(description), " ")
+	return task
+}
+
+// parseTagDate accepts "2006-01-02" optionally followed by a time.
+func parseTagDate(value string) *time.Time {
+	if len(value) < len("2006-01-02") {
+		return nil
+	}
+	t, err := time.Parse("2006-01-02", value[:len("2006-01-02")])
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+func appendLine(existing, line string) string {
+	if existing == "" {
+		return line
+	}
+	return existing + "\n" + line
+}
@@ -0,0 +1,86 @@
+package importer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"mytasks/internal/models"
+)
+
+const sampleTaskPaper = `- Loose task @low
+
+Errands: @area
+	Things to do in town.
+	- Buy stamps @due(2025-01-01) @high
+		Second-class only
+	- Return books @done(2024-12-30) @library
+Work:
+	- Draft report @priority(high) @in_progress
+`
+
+func TestParseTaskPaper(t *testing.T) {
+	projects, err := ParseTaskPaper(strings.NewReader(sampleTaskPaper))
+	if err != nil {
+		t.Fatalf("ParseTaskPaper failed: %v", err)
+	}
+	if len(projects) != 3 {
+		t.Fatalf("expected 3 projects, got %d: %+v", len(projects), projects)
+	}
+
+	inbox, errands, work := projects[0], projects[1], projects[2]
+	if inbox.Name != DefaultProjectName || len(inbox.Tasks) != 1 || inbox.Tasks[0].Priority != "low" {
+		t.Errorf("unexpected inbox %+v", inbox)
+	}
+
+	if errands.Name != "Errands" || errands.Description != "Things to do in town." {
+		t.Errorf("unexpected errands project %+v", errands)
+	}
+	if len(errands.Tasks) != 2 {
+		t.Fatalf("expected 2 errands, got %d", len(errands.Tasks))
+	}
+	stamps := errands.Tasks[0]
+	if stamps.Description != "Buy stamps" || stamps.Priority != "high" || stamps.Notes != "Second-class only" {
+		t.Errorf("unexpected task %+v", stamps)
+	}
+	if stamps.DueDate == nil || stamps.DueDate.Format("2006-01-02") != "2025-01-01" {
+		t.Errorf("expected due date 2025-01-01, got %v", stamps.DueDate)
+	}
+	books := errands.Tasks[1]
+	if books.Status != "done" || books.CompletedAt == nil {
+		t.Errorf("expected done task with completion date, got %+v", books)
+	}
+	if books.Description != "Return books" || len(books.Tags) != 1 || books.Tags[0] != "library" {
+		t.Errorf("expected unknown tags to become task tags, got %q %v", books.Description, books.Tags)
+	}
+
+	if work.Tasks[0].Priority != "high" || work.Tasks[0].Status != "in_progress" {
+		t.Errorf("unexpected work task %+v", work.Tasks[0])
+	}
+}
+
+func TestApply_ReusesExistingProjects(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	existing := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, existing)
+
+	projects, _ := ParseTaskPaper(strings.NewReader(sampleTaskPaper))
+	summary, err := Apply(ctx, s, "taskpaper", projects)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if len(summary.Projects) != 2 {
+		t.Errorf("expected Inbox and Errands to be created, got %d projects", len(summary.Projects))
+	}
+	if len(summary.Tasks) != 4 {
+		t.Errorf("expected 4 tasks, got %d", len(summary.Tasks))
+	}
+
+	workTasks, _ := s.ListTasksByProject(ctx, existing.ID, 0)
+	if len(workTasks) != 1 || workTasks[0].Description != "Draft report" {
+		t.Errorf("expected task to land in existing project, got %+v", workTasks)
+	}
+}
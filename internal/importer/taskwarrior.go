@@ -0,0 +1,249 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// TaskwarriorSource is the external-ref source for Taskwarrior UUIDs.
+const TaskwarriorSource = "taskwarrior"
+
+// taskwarriorTime is Taskwarrior's ISO 8601 basic UTC format.
+const taskwarriorTime = "20060102T150405Z"
+
+// TaskwarriorTask is one element of `task export` / `task import` JSON.
+type TaskwarriorTask struct {
+	UUID        string                  `json:"uuid"`
+	Description string                  `json:"description"`
+	Status      string                  `json:"status"`
+	Entry       string                  `json:"entry,omitempty"`
+	Modified    string                  `json:"modified,omitempty"`
+	Start       string                  `json:"start,omitempty"`
+	End         string                  `json:"end,omitempty"`
+	Due         string                  `json:"due,omitempty"`
+	Priority    string                  `json:"priority,omitempty"`
+	Project     string                  `json:"project,omitempty"`
+	Tags        []string                `json:"tags,omitempty"`
+	Annotations []TaskwarriorAnnotation `json:"annotations,omitempty"`
+}
+
+// TaskwarriorAnnotation is a timestamped note on a Taskwarrior task.
+type TaskwarriorAnnotation struct {
+	Entry       string `json:"entry"`
+	Description string `json:"description"`
+}
+
+// ParseTaskwarrior reads the JSON array written by `task export`. Deleted
+// tasks and recurrence templates are skipped; waiting tasks import as to-do
+// and started tasks as in progress. Annotations become notes and the
+// Taskwarrior project becomes the project name.
+func ParseTaskwarrior(r io.Reader) ([]Project, error) {
+	var exported []TaskwarriorTask
+	if err := json.NewDecoder(r).Decode(&exported); err != nil {
+		return nil, fmt.Errorf("invalid Taskwarrior JSON: %w", err)
+	}
+
+	var projects []Project
+	index := make(map[string]int)
+	for _, tw := range exported {
+		if tw.Status == "deleted" || tw.Status == "recurring" {
+			continue
+		}
+
+		task := models.Task{
+			Description: strings.TrimSpace(tw.Description),
+			Priority:    priorityFromTaskwarrior(tw.Priority),
+			Status:      "todo",
+			Tags:        tw.Tags,
+		}
+		switch {
+		case tw.Status == "completed":
+			task.Status = "done"
+			task.CompletedAt = parseTaskwarriorDate(tw.End)
+		case tw.Start != "":
+			task.Status = "in_progress"
+		}
+		task.DueDate = parseTaskwarriorDate(tw.Due)
+
+		notes := make([]string, 0, len(tw.Annotations))
+		for _, a := range tw.Annotations {
+			notes = append(notes, strings.TrimSpace(a.Description))
+		}
+		task.Notes = strings.Join(notes, "\n")
+
+		i, ok := index[tw.Project]
+		if !ok {
+			projects = append(projects, Project{Name: tw.Project})
+			i = len(projects) - 1
+			index[tw.Project] = i
+		}
+		projects[i].Tasks = append(projects[i].Tasks, Task{Task: task, ExternalID: tw.UUID})
+	}
+
+	return projects, nil
+}
+
+// exportPageSize is how many tasks an export loads from the store at a time.
+const exportPageSize = 500
+
+// ExportTaskwarrior returns every task in `task import` format. See
+// StreamTaskwarrior.
+func ExportTaskwarrior(ctx context.Context, s store.Store) ([]TaskwarriorTask, error) {
+	out := []TaskwarriorTask{}
+	err := StreamTaskwarrior(ctx, s, func(tw TaskwarriorTask) error {
+		out = append(out, tw)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StreamTaskwarrior calls emit with every task in `task import` format, in ID
+// order. Tasks are loaded a page at a time, so a large export never holds
+// them all. Tasks that came from Taskwarrior keep their UUID; others are
+// assigned one derived from their ID and linked, so importing the file back
+// (on either side) updates tasks rather than duplicating them.
+func StreamTaskwarrior(ctx context.Context, s store.Store, emit func(TaskwarriorTask) error) error {
+	projects, err := s.ListProjects(ctx)
+	if err != nil {
+		return err
+	}
+	x := taskwarriorExport{store: s, projectNames: make(map[int64]string, len(projects))}
+	for _, p := range projects {
+		x.projectNames[p.ID] = p.Name
+	}
+	if x.tags, err = s.ListTaskTags(ctx); err != nil {
+		return err
+	}
+	if x.refs, err = s.ListTaskExternalRefs(ctx, TaskwarriorSource); err != nil {
+		return err
+	}
+
+	var after int64
+	for {
+		tasks, err := s.ListTasksAfter(ctx, after, exportPageSize)
+		if err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			return nil
+		}
+		for _, task := range tasks {
+			tw, err := x.convert(ctx, task)
+			if err != nil {
+				return err
+			}
+			if err := emit(tw); err != nil {
+				return err
+			}
+		}
+		after = tasks[len(tasks)-1].ID
+	}
+}
+
+// taskwarriorExport holds what StreamTaskwarrior looks up for every task.
+type taskwarriorExport struct {
+	store        store.Store
+	projectNames map[int64]string
+	tags         map[int64][]string
+	refs         map[int64]string
+}
+
+func (x *taskwarriorExport) convert(ctx context.Context, task models.Task) (TaskwarriorTask, error) {
+	uuid, ok := x.refs[task.ID]
+	if !ok {
+		uuid = derivedUUID(task.ID)
+		if err := x.store.SetTaskExternalRef(ctx, TaskwarriorSource, uuid, task.ID); err != nil {
+			return TaskwarriorTask{}, err
+		}
+	}
+
+	tw := TaskwarriorTask{
+		UUID:        uuid,
+		Description: task.Description,
+		Status:      "pending",
+		Entry:       task.CreatedAt.UTC().Format(taskwarriorTime),
+		Modified:    task.UpdatedAt.UTC().Format(taskwarriorTime),
+		Priority:    priorityToTaskwarrior(task.Priority),
+		Project:     x.projectNames[task.ProjectID],
+		Tags:        x.tags[task.ID],
+	}
+	if task.DueDate != nil {
+		tw.Due = localMidnight(*task.DueDate).UTC().Format(taskwarriorTime)
+	}
+	switch task.Status {
+	case "done":
+		tw.Status = "completed"
+		end := task.UpdatedAt
+		if task.CompletedAt != nil {
+			end = localMidnight(*task.CompletedAt)
+		}
+		tw.End = end.UTC().Format(taskwarriorTime)
+	case "in_progress":
+		tw.Start = task.UpdatedAt.UTC().Format(taskwarriorTime)
+	}
+	if task.Notes != "" {
+		tw.Annotations = []TaskwarriorAnnotation{{Entry: tw.Modified, Description: task.Notes}}
+	}
+
+	return tw, nil
+}
+
+func priorityFromTaskwarrior(p string) string {
+	switch strings.ToUpper(p) {
+	case "H":
+		return "high"
+	case "L":
+		return "low"
+	default:
+		return "medium"
+	}
+}
+
+func priorityToTaskwarrior(p string) string {
+	switch p {
+	case "high":
+		return "H"
+	case "low":
+		return "L"
+	default:
+		return "M"
+	}
+}
+
+// parseTaskwarriorDate converts a UTC timestamp to the local calendar date,
+// since Taskwarrior stores "due:2025-01-01" as local midnight in UTC.
+func parseTaskwarriorDate(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(taskwarriorTime, value)
+	if err != nil {
+		return nil
+	}
+	local := t.In(time.Local)
+	date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	return &date
+}
+
+func localMidnight(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// derivedUUID returns a name-based (version 5 style) UUID for a task ID.
+func derivedUUID(taskID int64) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("mytasks:task:%d", taskID)))
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
@@ -0,0 +1,140 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+const sampleTaskwarrior = `[
+{"id":1,"description":"Write tests","entry":"20240101T090000Z","modified":"20240102T090000Z","status":"pending","uuid":"9d5a5d7e-1c1b-4f9f-9d2c-3f2b1a0e0001","priority":"H","project":"Work","tags":["code","urgent"],"due":"20250115T120000Z","annotations":[{"entry":"20240102T090000Z","description":"Cover the parser"}]},
+{"id":0,"description":"Old chore","entry":"20240101T090000Z","end":"20240103T120000Z","status":"completed","uuid":"9d5a5d7e-1c1b-4f9f-9d2c-3f2b1a0e0002","project":"Home"},
+{"id":0,"description":"Gone","entry":"20240101T090000Z","status":"deleted","uuid":"9d5a5d7e-1c1b-4f9f-9d2c-3f2b1a0e0003"},
+{"id":2,"description":"Started","entry":"20240101T090000Z","start":"20240101T100000Z","status":"pending","uuid":"9d5a5d7e-1c1b-4f9f-9d2c-3f2b1a0e0004","priority":"L","project":"Work"}
+]`
+
+func setupStore(t *testing.T) *store.SQLiteStore {
+	t.Helper()
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestParseTaskwarrior(t *testing.T) {
+	projects, err := ParseTaskwarrior(strings.NewReader(sampleTaskwarrior))
+	if err != nil {
+		t.Fatalf("ParseTaskwarrior failed: %v", err)
+	}
+	if len(projects) != 2 || projects[0].Name != "Work" || projects[1].Name != "Home" {
+		t.Fatalf("unexpected projects %+v", projects)
+	}
+
+	work := projects[0].Tasks
+	if len(work) != 2 {
+		t.Fatalf("expected 2 work tasks, got %d", len(work))
+	}
+	first := work[0]
+	if first.Priority != "high" || first.Notes != "Cover the parser" || first.ExternalID != "9d5a5d7e-1c1b-4f9f-9d2c-3f2b1a0e0001" {
+		t.Errorf("unexpected task %+v", first)
+	}
+	if len(first.Tags) != 2 || first.DueDate == nil {
+		t.Errorf("expected tags and due date, got %+v", first.Task)
+	}
+	if work[1].Status != "in_progress" || work[1].Priority != "low" {
+		t.Errorf("expected started low-priority task, got %+v", work[1].Task)
+	}
+
+	home := projects[1].Tasks
+	if len(home) != 1 || home[0].Status != "done" || home[0].CompletedAt == nil {
+		t.Errorf("expected completed chore, got %+v", home)
+	}
+}
+
+func TestTaskwarrior_ReimportUpdatesInPlace(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	projects, _ := ParseTaskwarrior(strings.NewReader(sampleTaskwarrior))
+	if _, err := Apply(ctx, s, TaskwarriorSource, projects); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	edited := strings.Replace(sampleTaskwarrior, `"description":"Write tests"`, `"description":"Write more tests"`, 1)
+	projects, _ = ParseTaskwarrior(strings.NewReader(edited))
+	summary, err := Apply(ctx, s, TaskwarriorSource, projects)
+	if err != nil {
+		t.Fatalf("second Apply failed: %v", err)
+	}
+	if len(summary.Tasks) != 0 || len(summary.Updated) != 3 {
+		t.Fatalf("expected 3 updates and no new tasks, got %d new, %d updated", len(summary.Tasks), len(summary.Updated))
+	}
+
+	all, _ := s.ListTasks(ctx, nil, nil)
+	if len(all) != 3 {
+		t.Fatalf("expected 3 tasks after re-import, got %d", len(all))
+	}
+	id, _ := s.GetTaskIDByExternalRef(ctx, TaskwarriorSource, "9d5a5d7e-1c1b-4f9f-9d2c-3f2b1a0e0001")
+	task, _ := s.GetTask(ctx, id)
+	if task.Description != "Write more tests" {
+		t.Errorf("expected description to be updated, got %q", task.Description)
+	}
+	if strings.Join(task.Tags, ",") != "code,urgent" {
+		t.Errorf("expected tags to be stored, got %v", task.Tags)
+	}
+}
+
+func TestExportTaskwarrior_RoundTrip(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Garden", Type: "project"}
+	s.CreateProject(ctx, project)
+	due := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	task := &models.Task{ProjectID: project.ID, Description: "Prune roses", Notes: "Before spring", Priority: "high", Status: "todo", DueDate: &due, Tags: []string{"outside"}}
+	s.CreateTask(ctx, task)
+
+	exported, err := ExportTaskwarrior(ctx, s)
+	if err != nil {
+		t.Fatalf("ExportTaskwarrior failed: %v", err)
+	}
+	if len(exported) != 1 {
+		t.Fatalf("expected 1 exported task, got %d", len(exported))
+	}
+	tw := exported[0]
+	if tw.Priority != "H" || tw.Project != "Garden" || tw.Status != "pending" || len(tw.Tags) != 1 {
+		t.Errorf("unexpected export %+v", tw)
+	}
+
+	again, _ := ExportTaskwarrior(ctx, s)
+	if again[0].UUID != tw.UUID {
+		t.Errorf("expected stable UUID, got %s then %s", tw.UUID, again[0].UUID)
+	}
+
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(exported)
+	projects, err := ParseTaskwarrior(&buf)
+	if err != nil {
+		t.Fatalf("ParseTaskwarrior failed: %v", err)
+	}
+	parsed := projects[0].Tasks[0]
+	if parsed.DueDate == nil || !parsed.DueDate.Equal(due) {
+		t.Errorf("expected due date to round-trip, got %v", parsed.DueDate)
+	}
+
+	summary, err := Apply(ctx, s, TaskwarriorSource, projects)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if len(summary.Tasks) != 0 || len(summary.Updated) != 1 {
+		t.Errorf("expected exported task to be matched on re-import, got %d new", len(summary.Tasks))
+	}
+}
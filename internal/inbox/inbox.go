@@ -0,0 +1,156 @@
+// Package inbox keeps the in-app notification inbox, written from domain
+// events so reminders, sync changes and finished imports are seen even when
+// no email or push channel is configured.
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/gcal"
+	"mytasks/internal/github"
+	"mytasks/internal/gtasks"
+	"mytasks/internal/importer"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// OverdueChannel is the overdue_notifications channel key for inbox
+// reminders.
+const OverdueChannel = "inbox"
+
+// Types lists the events the Recorder turns into notifications, for
+// subscribing it to the bus.
+var Types = []events.Type{
+	events.TaskOverdue,
+	events.ImportFinished,
+	events.TaskCreated,
+	events.TaskUpdated,
+	events.TaskCompleted,
+	events.TaskReopened,
+}
+
+// syncOrigins names the integrations whose task changes are announced. Edits
+// made in the app itself aren't.
+var syncOrigins = map[string]string{
+	github.Origin: "GitHub",
+	gtasks.Origin: "Google Tasks",
+	gcal.Origin:   "Google Calendar",
+}
+
+// Recorder writes notifications for the events in Types.
+type Recorder struct {
+	store store.Store
+}
+
+// NewRecorder creates a Recorder.
+func NewRecorder(s store.Store) *Recorder {
+	return &Recorder{store: s}
+}
+
+// HandleEvent records a notification for e, if it warrants one and the
+// project, if any, isn't muted in the inbox. It is meant to be registered
+// with events.Bus.Subscribe.
+func (r *Recorder) HandleEvent(ctx context.Context, e events.Event) {
+	n := notificationFor(e)
+	if n == nil {
+		return
+	}
+	if n.ProjectID != nil {
+		prefs, err := r.store.GetProjectNotificationPrefs(ctx, *n.ProjectID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			slog.Error("inbox: failed to load notification preferences", "project_id", *n.ProjectID, "err", err)
+		}
+		if prefs != nil && !prefs.Allows(models.ChannelInbox) {
+			return
+		}
+	}
+	if err := r.store.CreateNotification(ctx, n); err != nil {
+		slog.Error("inbox: failed to record notification", "event", e.Type, "err", err)
+	}
+}
+
+func notificationFor(e events.Event) *models.Notification {
+	n := &models.Notification{CreatedAt: e.At}
+	if e.TaskID != 0 {
+		n.TaskID = &e.TaskID
+	}
+	if e.ProjectID != 0 {
+		n.ProjectID = &e.ProjectID
+	}
+
+	switch e.Type {
+	case events.TaskOverdue:
+		if e.Task == nil {
+			return nil
+		}
+		n.Kind = models.NotificationReminder
+		n.Message = "Overdue: " + e.Task.Description
+	case events.ImportFinished:
+		label := e.Source
+		if format, ok := importer.LookupFormat(e.Source); ok {
+			label = format.Label
+		}
+		n.Kind = models.NotificationImport
+		n.Message = fmt.Sprintf("Import from %s finished: %d task%s created or updated", label, e.Count, plural(e.Count))
+	default:
+		integration, ok := syncOrigins[e.Origin]
+		if !ok || e.Task == nil {
+			return nil
+		}
+		n.Kind = models.NotificationSync
+		switch e.Type {
+		case events.TaskCreated:
+			n.Message = fmt.Sprintf("New task from %s: %s", integration, e.Task.Description)
+		case events.TaskCompleted:
+			n.Message = fmt.Sprintf("Completed in %s: %s", integration, e.Task.Description)
+		case events.TaskReopened:
+			n.Message = fmt.Sprintf("Reopened in %s: %s", integration, e.Task.Description)
+		default:
+			n.Message = fmt.Sprintf("Updated from %s: %s", integration, e.Task.Description)
+		}
+	}
+	return n
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// OverdueReminder publishes TaskOverdue once per task and due date, for the
+// Recorder to put in the inbox.
+type OverdueReminder struct {
+	store store.Store
+	bus   *events.Bus
+	now   func() time.Time
+}
+
+// NewOverdueReminder creates an OverdueReminder. bus may be nil.
+func NewOverdueReminder(s store.Store, bus *events.Bus) *OverdueReminder {
+	return &OverdueReminder{store: s, bus: bus, now: time.Now}
+}
+
+// Run announces the tasks that became overdue since the last run. It is
+// meant to be called periodically by the scheduler.
+func (o *OverdueReminder) Run(ctx context.Context) error {
+	tasks, err := o.store.ListOverdueTasksPendingNotification(ctx, OverdueChannel, o.now())
+	if err != nil {
+		return err
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+		o.bus.Publish(ctx, events.Event{Type: events.TaskOverdue, TaskID: task.ID, ProjectID: task.ProjectID, Task: task})
+		if err := o.store.MarkOverdueNotified(ctx, task.ID, OverdueChannel, *task.DueDate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
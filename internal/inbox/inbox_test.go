@@ -0,0 +1,107 @@
+package inbox
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/github"
+	"mytasks/internal/importer"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+func setupStore(t *testing.T) *store.SQLiteStore {
+	t.Helper()
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestRecorder(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	bus := events.NewBus()
+	bus.Subscribe(NewRecorder(s).HandleEvent, Types...)
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Fix login", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, task)
+
+	// Changes made in the app aren't announced, those from a sync are.
+	bus.Publish(ctx, events.Event{Type: events.TaskCreated, TaskID: task.ID, ProjectID: project.ID, Task: task, Origin: "browser-1"})
+	bus.Publish(ctx, events.Event{Type: events.TaskCompleted, TaskID: task.ID, ProjectID: project.ID, Task: task, Origin: github.Origin})
+	bus.Publish(ctx, events.Event{Type: events.ImportFinished, Source: importer.TaskwarriorSource, Count: 3})
+
+	list, err := s.ListNotifications(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListNotifications failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 notifications, got %+v", list)
+	}
+	byKind := map[string]models.Notification{}
+	for _, n := range list {
+		byKind[n.Kind] = n
+	}
+	if n := byKind[models.NotificationSync]; n.Message != "Completed in GitHub: Fix login" || n.TaskID == nil || *n.TaskID != task.ID {
+		t.Errorf("unexpected sync notification %+v", n)
+	}
+	if n := byKind[models.NotificationImport]; !strings.Contains(n.Message, "Taskwarrior") || !strings.Contains(n.Message, "3 tasks") || n.TaskID != nil {
+		t.Errorf("unexpected import notification %+v", n)
+	}
+}
+
+func TestOverdueReminder(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	bus := events.NewBus()
+	bus.Subscribe(NewRecorder(s).HandleEvent, Types...)
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	today := time.Date(2026, time.March, 10, 9, 0, 0, 0, time.Local)
+	yesterday := today.AddDate(0, 0, -1)
+	tomorrow := today.AddDate(0, 0, 1)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Pay rent", Priority: "high", Status: "todo", DueDate: &yesterday})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "low", Status: "todo", DueDate: &tomorrow})
+
+	reminder := NewOverdueReminder(s, bus)
+	reminder.now = func() time.Time { return today }
+	for i := 0; i < 2; i++ {
+		if err := reminder.Run(ctx); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+	}
+
+	list, _ := s.ListNotifications(ctx, 10)
+	if len(list) != 1 || list[0].Kind != models.NotificationReminder || list[0].Message != "Overdue: Pay rent" {
+		t.Errorf("expected one reminder for the overdue task, got %+v", list)
+	}
+}
+
+func TestRecorder_SkipsProjectsMutedInInbox(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	recorder := NewRecorder(s)
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, project)
+	s.SaveProjectNotificationPrefs(ctx, &models.ProjectNotificationPrefs{ProjectID: project.ID, Muted: true, Email: true, Slack: true, Inbox: true})
+	task := &models.Task{ProjectID: project.ID, Description: "Fix login", Priority: "medium", Status: "todo"}
+	s.CreateTask(ctx, task)
+
+	recorder.HandleEvent(ctx, events.Event{Type: events.TaskOverdue, TaskID: task.ID, ProjectID: project.ID, Task: task})
+	recorder.HandleEvent(ctx, events.Event{Type: events.ImportFinished, Source: "taskpaper", Count: 1})
+
+	list, _ := s.ListNotifications(ctx, 10)
+	if len(list) != 1 || list[0].Kind != models.NotificationImport {
+		t.Errorf("expected only the import notification, got %+v", list)
+	}
+}
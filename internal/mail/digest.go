@@ -0,0 +1,117 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// Digest emails a summary of overdue tasks and tasks due today. Nothing is
+// sent on days with nothing due.
+type Digest struct {
+	store  store.Store
+	sender Sender
+	to     []string
+	now    func() time.Time
+}
+
+// NewDigest creates a digest mailed to the given recipients. Blank entries
+// are ignored so a comma-separated setting can be split directly.
+func NewDigest(s store.Store, sender Sender, to []string) *Digest {
+	recipients := make([]string, 0, len(to))
+	for _, addr := range to {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			recipients = append(recipients, addr)
+		}
+	}
+	return &Digest{store: s, sender: sender, to: recipients, now: time.Now}
+}
+
+// digestSentSetting is the settings key holding the last day, as
+// YYYY-MM-DD, a digest went out, so each day gets one.
+const digestSentSetting = "digest_sent"
+
+// Run sends today's digest once the digest time in Settings has passed. It
+// is meant to be called periodically; once a digest went out, later runs
+// the same day do nothing.
+func (d *Digest) Run(ctx context.Context) error {
+	settings, err := d.store.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	now := d.now()
+	day, due := settings.DigestDue(now)
+	if !due {
+		return nil
+	}
+	sent, err := d.store.GetSetting(ctx, digestSentSetting)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	if sent == day {
+		return nil
+	}
+
+	overdue, err := d.store.ListOpenTasks(ctx, store.TaskFilter{Due: store.DueOverdue})
+	if err != nil {
+		return err
+	}
+	today, err := d.store.ListOpenTasks(ctx, store.TaskFilter{Due: store.DueToday})
+	if err != nil {
+		return err
+	}
+	prefs, err := d.store.ListNotificationPrefs(ctx)
+	if err != nil {
+		return err
+	}
+	overdue, today = emailed(prefs, overdue), emailed(prefs, today)
+	if len(overdue) == 0 && len(today) == 0 {
+		return nil
+	}
+	if err := d.sender.Send(ctx, digestMessage(d.to, now, overdue, today)); err != nil {
+		return err
+	}
+	return d.store.SetSetting(ctx, digestSentSetting, day)
+}
+
+// emailed drops the tasks of projects muted on email.
+func emailed(prefs models.NotificationPrefs, tasks []models.Task) []models.Task {
+	kept := tasks[:0]
+	for _, task := range tasks {
+		if prefs.Allows(task.ProjectID, models.ChannelEmail) {
+			kept = append(kept, task)
+		}
+	}
+	return kept
+}
+
+func digestMessage(to []string, now time.Time, overdue, today []models.Task) Message {
+	var body strings.Builder
+	section := func(title string, tasks []models.Task) {
+		if len(tasks) == 0 {
+			return
+		}
+		fmt.Fprintf(&body, "%s\n\n", title)
+		for _, task := range tasks {
+			fmt.Fprintf(&body, "- %s (%s, %s", task.Description, task.ProjectName, task.Priority)
+			if task.DueDate != nil && title == "Overdue" {
+				fmt.Fprintf(&body, ", due %s", task.DueDate.Format("Jan 2"))
+			}
+			body.WriteString(")\n")
+		}
+		body.WriteString("\n")
+	}
+	section("Overdue", overdue)
+	section("Due today", today)
+
+	return Message{
+		To:      to,
+		Subject: fmt.Sprintf("Tasks for %s: %d due, %d overdue", now.Format("Mon, Jan 2"), len(today), len(overdue)),
+		Body:    body.String(),
+	}
+}
@@ -0,0 +1,93 @@
+package mail
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"mytasks/internal/models"
+)
+
+func TestDigest(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	sender := &fakeSender{}
+	digest := NewDigest(s, sender, []string{"me@example.com", " "})
+	y, m, d := time.Now().Date()
+	digest.now = func() time.Time { return time.Date(y, m, d, 23, 59, 0, 0, time.Local) }
+
+	if err := digest.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(sender.sent) != 0 {
+		t.Fatalf("expected no mail with nothing due, got %d", len(sender.sent))
+	}
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	now := time.Now()
+	lastWeek := now.AddDate(0, 0, -7)
+	nextWeek := now.AddDate(0, 0, 7)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Pay rent", Priority: "high", Status: "todo", DueDate: &lastWeek})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "low", Status: "todo", DueDate: &now})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Book dentist", Priority: "low", Status: "todo", DueDate: &nextWeek})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Old chore", Priority: "low", Status: "done", DueDate: &lastWeek})
+
+	if err := digest.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("expected 1 mail, got %d", len(sender.sent))
+	}
+	msg := sender.sent[0]
+	if len(msg.To) != 1 || !strings.HasSuffix(msg.Subject, ": 1 due, 1 overdue") {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+	if !strings.Contains(msg.Body, "Pay rent") || !strings.Contains(msg.Body, "Water plants") {
+		t.Errorf("expected the due tasks in the body, got %q", msg.Body)
+	}
+	if strings.Contains(msg.Body, "Book dentist") || strings.Contains(msg.Body, "Old chore") {
+		t.Errorf("expected only open tasks due by today, got %q", msg.Body)
+	}
+
+	if err := digest.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(sender.sent) != 1 {
+		t.Errorf("expected one digest a day, got %d", len(sender.sent))
+	}
+}
+
+func TestDigest_WaitsForDigestTime(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	sender := &fakeSender{}
+	digest := NewDigest(s, sender, []string{"me@example.com"})
+
+	settings := models.DefaultSettings()
+	settings.TimeZone = "America/New_York"
+	settings.DigestTime = "08:30"
+	s.SaveSettings(ctx, &settings)
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	lastWeek := time.Now().AddDate(0, 0, -7)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Pay rent", Priority: "high", Status: "todo", DueDate: &lastWeek})
+
+	// 12:00 UTC is 08:00 in New York, still before the digest time.
+	y, m, d := time.Now().Date()
+	digest.now = func() time.Time { return time.Date(y, m, d, 12, 0, 0, 0, time.UTC) }
+	digest.Run(ctx)
+	if len(sender.sent) != 0 {
+		t.Fatalf("expected no digest before 08:30 New York time, got %d", len(sender.sent))
+	}
+
+	digest.now = func() time.Time { return time.Date(y, m, d, 12, 45, 0, 0, time.UTC) }
+	if err := digest.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(sender.sent) != 1 {
+		t.Errorf("expected the digest after 08:30 New York time, got %d", len(sender.sent))
+	}
+}
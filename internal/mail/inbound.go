@@ -0,0 +1,84 @@
+package mail
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	netmail "net/mail"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxWebhookAge bounds how old a signed inbound webhook may be.
+const MaxWebhookAge = 5 * time.Minute
+
+var (
+	ErrBadSignature  = errors.New("mail: invalid webhook signature")
+	ErrStaleWebhook  = errors.New("mail: webhook timestamp too old")
+	ErrSenderBlocked = errors.New("mail: sender not allowed")
+)
+
+// InboundConfig configures the inbound email webhook.
+type InboundConfig struct {
+	// SigningKey is the Mailgun HTTP webhook signing key.
+	SigningKey string
+	// AllowedSenders lists addresses ("me@example.com") or whole domains
+	// ("@example.com") permitted to create tasks.
+	AllowedSenders []string
+}
+
+// Enabled reports whether the inbound webhook should accept requests.
+func (c InboundConfig) Enabled() bool {
+	return c.SigningKey != ""
+}
+
+// Allowed reports whether from, a bare address or an RFC 5322 "Name <addr>"
+// value, is on the allow list.
+func (c InboundConfig) Allowed(from string) bool {
+	addr := strings.TrimSpace(from)
+	if parsed, err := netmail.ParseAddress(addr); err == nil {
+		addr = parsed.Address
+	}
+	addr = strings.ToLower(addr)
+	if addr == "" {
+		return false
+	}
+
+	for _, allowed := range c.AllowedSenders {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		switch {
+		case allowed == "":
+			continue
+		case strings.HasPrefix(allowed, "@"):
+			if strings.HasSuffix(addr, allowed) {
+				return true
+			}
+		case addr == allowed:
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyMailgun checks a Mailgun webhook signature: hex HMAC-SHA256 of
+// timestamp+token keyed by the signing key.
+func VerifyMailgun(signingKey, timestamp, token, signature string, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || token == "" || signature == "" {
+		return ErrBadSignature
+	}
+	age := now.Sub(time.Unix(ts, 0))
+	if age > MaxWebhookAge || age < -MaxWebhookAge {
+		return ErrStaleWebhook
+	}
+
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(timestamp + token))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return ErrBadSignature
+	}
+	return nil
+}
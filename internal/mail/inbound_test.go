@@ -0,0 +1,56 @@
+package mail
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestInboundConfig_Allowed(t *testing.T) {
+	cfg := InboundConfig{AllowedSenders: []string{"me@example.com", "@work.example"}}
+
+	tests := []struct {
+		from string
+		want bool
+	}{
+		{"me@example.com", true},
+		{"Me <ME@Example.com>", true},
+		{"boss@work.example", true},
+		{"someone@example.com", false},
+		{"me@example.com.evil", false},
+		{"not an address", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.Allowed(tt.from); got != tt.want {
+			t.Errorf("Allowed(%q) = %v, want %v", tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyMailgun(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	sign := func(key, ts, token string) string {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(ts + token))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	if err := VerifyMailgun("key", timestamp, "tok", sign("key", timestamp, "tok"), now); err != nil {
+		t.Errorf("expected valid signature, got %v", err)
+	}
+	if err := VerifyMailgun("key", timestamp, "tok", sign("other", timestamp, "tok"), now); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature, got %v", err)
+	}
+
+	old := strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)
+	if err := VerifyMailgun("key", old, "tok", sign("key", old, "tok"), now); !errors.Is(err, ErrStaleWebhook) {
+		t.Errorf("expected ErrStaleWebhook, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+// Package mail sends notification email over SMTP.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages. SMTPMailer is the production implementation.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config holds SMTP connection settings.
+type Config struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Enabled reports whether enough settings are present to send mail.
+func (c Config) Enabled() bool {
+	return c.Host != "" && c.From != ""
+}
+
+// SMTPMailer sends mail through an SMTP relay, using STARTTLS when offered.
+type SMTPMailer struct {
+	cfg Config
+}
+
+// NewSMTPMailer creates a mailer. Port defaults to 587.
+func NewSMTPMailer(cfg Config) *SMTPMailer {
+	if cfg.Port == "" {
+		cfg.Port = "587"
+	}
+	return &SMTPMailer{cfg: cfg}
+}
+
+// Send delivers msg. The context is checked before dialing; net/smtp itself
+// does not support cancellation.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("mail: no recipients")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	addr := net.JoinHostPort(m.cfg.Host, m.cfg.Port)
+	if err := smtp.SendMail(addr, auth, m.cfg.From, msg.To, Format(m.cfg.From, msg, time.Now())); err != nil {
+		return fmt.Errorf("mail: send to %s failed: %w", strings.Join(msg.To, ", "), err)
+	}
+	return nil
+}
+
+// Format renders msg as an RFC 5322 message with CRLF line endings.
+func Format(from string, msg Message, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return b.Bytes()
+}
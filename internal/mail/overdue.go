@@ -0,0 +1,89 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// OverdueChannel is the overdue_notifications channel key for email alerts.
+const OverdueChannel = "email"
+
+// OverdueAlerter emails one alert per task as soon as it becomes overdue.
+// Sent alerts are recorded so later polls skip them until the due date changes.
+type OverdueAlerter struct {
+	store  store.Store
+	sender Sender
+	to     []string
+	now    func() time.Time
+}
+
+// NewOverdueAlerter creates an alerter that mails the given recipients.
+// Blank entries are ignored so a comma-separated setting can be split directly.
+func NewOverdueAlerter(s store.Store, sender Sender, to []string) *OverdueAlerter {
+	recipients := make([]string, 0, len(to))
+	for _, addr := range to {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			recipients = append(recipients, addr)
+		}
+	}
+	return &OverdueAlerter{store: s, sender: sender, to: recipients, now: time.Now}
+}
+
+// Run sends alerts for tasks that became overdue since the last run. It is
+// meant to be called periodically by the scheduler. Alerts due during quiet
+// hours wait for the first run after they end.
+func (a *OverdueAlerter) Run(ctx context.Context) error {
+	now := a.now()
+	settings, err := a.store.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	if settings.InQuietHours(now) {
+		return nil
+	}
+
+	tasks, err := a.store.ListOverdueTasksPendingNotification(ctx, OverdueChannel, now)
+	if err != nil {
+		return err
+	}
+	prefs, err := a.store.ListNotificationPrefs(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Tasks of projects muted on email count as notified, so unmuting the
+	// project doesn't send them late.
+	for _, task := range tasks {
+		if prefs.Allows(task.ProjectID, models.ChannelEmail) {
+			if err := a.sender.Send(ctx, overdueMessage(a.to, task)); err != nil {
+				return err
+			}
+		}
+		if err := a.store.MarkOverdueNotified(ctx, task.ID, OverdueChannel, *task.DueDate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func overdueMessage(to []string, task models.Task) Message {
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s\n\n", task.Description)
+	fmt.Fprintf(&body, "Project:  %s\n", task.ProjectName)
+	fmt.Fprintf(&body, "Due:      %s\n", task.DueDate.Format("Mon, Jan 2, 2006"))
+	fmt.Fprintf(&body, "Priority: %s\n", task.Priority)
+	if task.Notes != "" {
+		fmt.Fprintf(&body, "\n%s\n", task.Notes)
+	}
+
+	return Message{
+		To:      to,
+		Subject: "Overdue: " + task.Description,
+		Body:    body.String(),
+	}
+}
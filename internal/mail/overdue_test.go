@@ -0,0 +1,172 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+type fakeSender struct {
+	sent []Message
+	err  error
+}
+
+func (f *fakeSender) Send(_ context.Context, msg Message) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func setupStore(t *testing.T) *store.SQLiteStore {
+	t.Helper()
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestOverdueAlerter_OneMailPerTask(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	yesterday := time.Now().AddDate(0, 0, -1)
+	lastWeek := time.Now().AddDate(0, 0, -7)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Pay rent", Priority: "high", Status: "todo", DueDate: &yesterday})
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Call plumber", Priority: "low", Status: "in_progress", DueDate: &lastWeek})
+
+	sender := &fakeSender{}
+	alerter := NewOverdueAlerter(s, sender, []string{"me@example.com"})
+
+	for i := 0; i < 3; i++ {
+		if err := alerter.Run(ctx); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+	}
+
+	if len(sender.sent) != 2 {
+		t.Fatalf("expected 2 mails across 3 polls, got %d", len(sender.sent))
+	}
+	subjects := sender.sent[0].Subject + "|" + sender.sent[1].Subject
+	if !strings.Contains(subjects, "Overdue: Pay rent") || !strings.Contains(subjects, "Overdue: Call plumber") {
+		t.Errorf("unexpected subjects %q", subjects)
+	}
+	if !strings.Contains(sender.sent[0].Body, "Home") {
+		t.Errorf("expected project name in body, got %q", sender.sent[0].Body)
+	}
+}
+
+func TestOverdueAlerter_RetriesAfterSendFailure(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	yesterday := time.Now().AddDate(0, 0, -1)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Pay rent", Priority: "high", Status: "todo", DueDate: &yesterday})
+
+	sender := &fakeSender{err: errors.New("relay down")}
+	alerter := NewOverdueAlerter(s, sender, []string{"me@example.com"})
+	if err := alerter.Run(ctx); err == nil {
+		t.Fatal("expected send error to be returned")
+	}
+
+	sender.err = nil
+	if err := alerter.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(sender.sent) != 1 {
+		t.Errorf("expected the alert to be retried, got %d mails", len(sender.sent))
+	}
+}
+
+func TestOverdueAlerter_HoldsAlertsDuringQuietHours(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	settings := models.DefaultSettings()
+	settings.TimeZone = "UTC"
+	settings.QuietHoursStart = "22:00"
+	settings.QuietHoursEnd = "07:00"
+	s.SaveSettings(ctx, &settings)
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	lastWeek := time.Now().AddDate(0, 0, -7)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Pay rent", Priority: "high", Status: "todo", DueDate: &lastWeek})
+
+	sender := &fakeSender{}
+	alerter := NewOverdueAlerter(s, sender, []string{"me@example.com"})
+	y, m, d := time.Now().Date()
+	alerter.now = func() time.Time { return time.Date(y, m, d, 3, 0, 0, 0, time.UTC) }
+	if err := alerter.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(sender.sent) != 0 {
+		t.Fatalf("expected no mail at 3am, got %d", len(sender.sent))
+	}
+
+	alerter.now = func() time.Time { return time.Date(y, m, d, 7, 5, 0, 0, time.UTC) }
+	if err := alerter.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(sender.sent) != 1 {
+		t.Errorf("expected the held alert once quiet hours ended, got %d mails", len(sender.sent))
+	}
+}
+
+func TestOverdueAlerter_SkipsProjectsMutedOnEmail(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	home := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, home)
+	work := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, work)
+	s.SaveProjectNotificationPrefs(ctx, &models.ProjectNotificationPrefs{ProjectID: work.ID, Email: false, Slack: true, Inbox: true})
+	yesterday := time.Now().AddDate(0, 0, -1)
+	s.CreateTask(ctx, &models.Task{ProjectID: home.ID, Description: "Pay rent", Priority: "high", Status: "todo", DueDate: &yesterday})
+	s.CreateTask(ctx, &models.Task{ProjectID: work.ID, Description: "Send invoice", Priority: "high", Status: "todo", DueDate: &yesterday})
+
+	sender := &fakeSender{}
+	alerter := NewOverdueAlerter(s, sender, []string{"me@example.com"})
+	if err := alerter.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].Subject != "Overdue: Pay rent" {
+		t.Fatalf("expected only the Home alert, got %+v", sender.sent)
+	}
+
+	// Turning email back on doesn't send the skipped alert late.
+	s.SaveProjectNotificationPrefs(ctx, &models.ProjectNotificationPrefs{ProjectID: work.ID, Email: true, Slack: true, Inbox: true})
+	alerter.Run(ctx)
+	if len(sender.sent) != 1 {
+		t.Errorf("expected no late alert, got %d mails", len(sender.sent))
+	}
+}
+
+func TestFormat_EncodesSubjectAndUsesCRLF(t *testing.T) {
+	date := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	raw := string(Format("tasks@example.com", Message{
+		To:      []string{"me@example.com"},
+		Subject: "Overdue: Café run",
+		Body:    "line one\nline two",
+	}, date))
+
+	if !strings.Contains(raw, "Subject: =?utf-8?q?Overdue:_Caf=C3=A9_run?=\r\n") {
+		t.Errorf("expected Q-encoded subject, got %q", raw)
+	}
+	if !strings.HasSuffix(raw, "\r\n\r\nline one\r\nline two") {
+		t.Errorf("expected CRLF body, got %q", raw)
+	}
+}
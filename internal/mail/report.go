@@ -0,0 +1,154 @@
+package mail
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/scheduler"
+	"mytasks/internal/store"
+)
+
+//go:embed report.txt
+var reportText string
+
+// reportTemplate renders a report's body. Its date function is replaced
+// with one using the date format picked in the settings.
+var reportTemplate = template.Must(template.New("report").
+	Funcs(template.FuncMap{"date": models.Settings{}.FormatDate}).
+	Parse(reportText))
+
+// reportData is what report.txt renders.
+type reportData struct {
+	From, To  time.Time
+	ByProject []models.ReportRow
+	Totals    models.ReportRow
+	Overdue   []models.Task
+}
+
+// Reports emails the scheduled reports: how many tasks were created and
+// completed over the days a report covers, by project, and which tasks are
+// overdue when it goes out.
+type Reports struct {
+	store  store.Store
+	sender Sender
+	now    func() time.Time
+}
+
+// NewReports creates the job sending the report schedules in s.
+func NewReports(s store.Store, sender Sender) *Reports {
+	return &Reports{store: s, sender: sender, now: time.Now}
+}
+
+// NextReport returns when rs next sends a report: its first scheduled time
+// after the last report, or after it was created, in loc.
+func NextReport(rs models.ReportSchedule, loc *time.Location) (time.Time, error) {
+	schedule, err := scheduler.Parse(rs.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	last := rs.CreatedAt
+	if rs.LastRunAt != nil {
+		last = *rs.LastRunAt
+	}
+	return schedule.Next(last.In(loc)), nil
+}
+
+// Run sends the reports that are due. It is meant to be called every minute
+// or so; a report that could not be sent is tried again on the next run.
+func (r *Reports) Run(ctx context.Context) error {
+	settings, err := r.store.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	schedules, err := r.store.ListReportSchedules(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := r.now()
+	var firstErr error
+	for i := range schedules {
+		rs := &schedules[i]
+		next, err := NextReport(*rs, settings.Location())
+		if err == nil {
+			if next.IsZero() || next.After(now) {
+				continue
+			}
+			err = r.send(ctx, rs, settings, now)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("report %d: %w", rs.ID, err)
+		}
+	}
+	return firstErr
+}
+
+// SendNow sends the report of schedule id right away. The next scheduled
+// report follows at its usual time.
+func (r *Reports) SendNow(ctx context.Context, id int64) error {
+	rs, err := r.store.GetReportSchedule(ctx, id)
+	if err != nil {
+		return err
+	}
+	settings, err := r.store.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	return r.send(ctx, rs, settings, r.now())
+}
+
+// send mails rs's report and records the run, or why it failed.
+func (r *Reports) send(ctx context.Context, rs *models.ReportSchedule, settings *models.Settings, now time.Time) error {
+	msg, err := r.message(ctx, rs, settings, now)
+	if err == nil {
+		err = r.sender.Send(ctx, msg)
+	}
+	if err != nil {
+		rs.LastError = err.Error()
+	} else {
+		rs.LastRunAt, rs.LastError = &now, ""
+	}
+	if recordErr := r.store.RecordReportRun(ctx, rs); recordErr != nil {
+		return recordErr
+	}
+	return err
+}
+
+func (r *Reports) message(ctx context.Context, rs *models.ReportSchedule, settings *models.Settings, now time.Time) (Message, error) {
+	y, m, d := now.In(settings.Location()).Date()
+	to := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	data := reportData{From: to.AddDate(0, 0, 1-rs.Days), To: to, Totals: models.ReportRow{Group: "total"}}
+
+	var err error
+	data.ByProject, err = r.store.ReportCounts(ctx, data.From, data.To, store.GroupByProject, settings.WeekStart)
+	if err != nil {
+		return Message{}, err
+	}
+	for _, row := range data.ByProject {
+		data.Totals.Created += row.Created
+		data.Totals.Completed += row.Completed
+	}
+	if data.Overdue, err = r.store.ListOpenTasks(ctx, store.TaskFilter{Due: store.DueOverdue}); err != nil {
+		return Message{}, err
+	}
+
+	tmpl, err := reportTemplate.Clone()
+	if err != nil {
+		return Message{}, err
+	}
+	var body strings.Builder
+	if err := tmpl.Funcs(template.FuncMap{"date": settings.FormatDate}).Execute(&body, data); err != nil {
+		return Message{}, err
+	}
+	return Message{
+		To: rs.Recipients,
+		Subject: fmt.Sprintf("Tasks report for %s to %s: %d completed, %d overdue",
+			settings.FormatShortDate(data.From), settings.FormatShortDate(data.To), data.Totals.Completed, len(data.Overdue)),
+		Body: body.String(),
+	}, nil
+}
@@ -0,0 +1,107 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"mytasks/internal/models"
+)
+
+func TestReports_SendsOnSchedule(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	sender := &fakeSender{}
+	reports := NewReports(s, sender)
+
+	settings := models.DefaultSettings()
+	settings.TimeZone = "UTC"
+	s.SaveSettings(ctx, &settings)
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	lastWeek := time.Now().AddDate(0, 0, -7)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Pay rent", Priority: "high", Status: "todo", DueDate: &lastWeek})
+	done := &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "low", Status: "todo"}
+	s.CreateTask(ctx, done)
+	s.ToggleTaskComplete(ctx, done.ID)
+
+	// Today at 16:00, in the time zone of the settings.
+	y, m, d := time.Now().UTC().Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	rs := &models.ReportSchedule{
+		Recipients: []string{"me@example.com"},
+		Schedule:   fmt.Sprintf("0 16 * * %d", today.Weekday()),
+		Days:       7,
+		CreatedAt:  today,
+	}
+	if err := s.CreateReportSchedule(ctx, rs); err != nil {
+		t.Fatalf("CreateReportSchedule failed: %v", err)
+	}
+
+	for _, at := range []time.Time{today.Add(15*time.Hour + 59*time.Minute), today.Add(16 * time.Hour), today.Add(16*time.Hour + time.Minute)} {
+		reports.now = func() time.Time { return at }
+		if err := reports.Run(ctx); err != nil {
+			t.Fatalf("Run at %s failed: %v", at.Format("15:04"), err)
+		}
+	}
+	if len(sender.sent) != 1 {
+		t.Fatalf("expected 1 report at 16:00, got %d", len(sender.sent))
+	}
+	msg := sender.sent[0]
+	if len(msg.To) != 1 || msg.To[0] != "me@example.com" || !strings.HasSuffix(msg.Subject, ": 1 completed, 1 overdue") {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+	for _, want := range []string{"Completed: 1", "Created:   2", "- Home: 1 / 2", "- Pay rent (Home, high, due "} {
+		if !strings.Contains(msg.Body, want) {
+			t.Errorf("expected %q in the body:\n%s", want, msg.Body)
+		}
+	}
+
+	got, err := s.GetReportSchedule(ctx, rs.ID)
+	if err != nil {
+		t.Fatalf("GetReportSchedule failed: %v", err)
+	}
+	next, err := NextReport(*got, time.UTC)
+	if err != nil || !next.Equal(today.AddDate(0, 0, 7).Add(16*time.Hour)) {
+		t.Errorf("next report at %v (%v), want a week later", next, err)
+	}
+}
+
+func TestReports_RetriesFailedSends(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	sender := &fakeSender{err: errors.New("connection refused")}
+	reports := NewReports(s, sender)
+
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	rs := &models.ReportSchedule{Recipients: []string{"me@example.com"}, Schedule: "0 10 * * *", Days: 1, CreatedAt: created}
+	if err := s.CreateReportSchedule(ctx, rs); err != nil {
+		t.Fatalf("CreateReportSchedule failed: %v", err)
+	}
+	reports.now = func() time.Time { return created.Add(time.Hour) }
+
+	if err := reports.Run(ctx); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("Run returned %v, want the send error", err)
+	}
+	got, _ := s.GetReportSchedule(ctx, rs.ID)
+	if got.LastRunAt != nil || got.LastError != "connection refused" {
+		t.Fatalf("expected the error recorded without a run, got %+v", got)
+	}
+
+	sender.err = nil
+	if err := reports.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	got, _ = s.GetReportSchedule(ctx, rs.ID)
+	if len(sender.sent) != 1 || got.LastRunAt == nil || got.LastError != "" {
+		t.Errorf("expected the retry to send the report, got %d sent and %+v", len(sender.sent), got)
+	}
+
+	if err := reports.SendNow(ctx, rs.ID); err != nil || len(sender.sent) != 2 {
+		t.Errorf("SendNow: %v, %d sent", err, len(sender.sent))
+	}
+}
@@ -0,0 +1,207 @@
+// Package markdown renders the Markdown people write in task notes and
+// comments: paragraphs, headings, lists and checklists, quotes, code, and
+// emphasis and links inline. Everything else is shown as written. Raw HTML
+// is always escaped and links only go to http, https and mailto URLs, so the
+// output is safe to put in a page.
+package markdown
+
+import (
+	"html"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	orderedItem = regexp.MustCompile(`^\d{1,9}[.)] `)
+	linkPattern = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	urlPattern  = regexp.MustCompile(`https?://[^\s<>"]+`)
+	strongStars = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*`)
+	emStars     = regexp.MustCompile(`\*(\S(?:.*?\S)?)\*`)
+)
+
+// Render turns src into HTML. Headings start at h3, as notes sit under a
+// page's own headings.
+func Render(src string) template.HTML {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			i++
+
+		case strings.HasPrefix(trimmed, "```"):
+			i++
+			b.WriteString("<pre><code>")
+			for ; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				b.WriteString(html.EscapeString(lines[i]))
+				b.WriteByte('\n')
+			}
+			b.WriteString("</code></pre>\n")
+			i++ // the closing fence
+
+		case headingLevel(trimmed) > 0:
+			level := headingLevel(trimmed)
+			tag := "h" + strconv.Itoa(min(level+2, 6))
+			b.WriteString("<" + tag + ">" + inline(strings.TrimSpace(trimmed[level:])) + "</" + tag + ">\n")
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quoted = append(quoted, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+			}
+			b.WriteString("<blockquote>" + string(Render(strings.Join(quoted, "\n"))) + "</blockquote>\n")
+
+		case listItem(trimmed, false) != "":
+			i = list(&b, lines, i, false)
+
+		case listItem(trimmed, true) != "":
+			i = list(&b, lines, i, true)
+
+		default:
+			var para []string
+			for ; i < len(lines) && startsParagraphLine(lines[i]); i++ {
+				para = append(para, inline(strings.TrimSpace(lines[i])))
+			}
+			b.WriteString("<p>" + strings.Join(para, "<br>\n") + "</p>\n")
+		}
+	}
+	return template.HTML(b.String())
+}
+
+// startsParagraphLine reports whether line carries on a paragraph rather
+// than ending it or starting another block.
+func startsParagraphLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed != "" &&
+		!strings.HasPrefix(trimmed, "```") &&
+		!strings.HasPrefix(trimmed, ">") &&
+		headingLevel(trimmed) == 0 &&
+		listItem(trimmed, false) == "" &&
+		listItem(trimmed, true) == ""
+}
+
+// headingLevel returns how many #s start an ATX heading, or 0.
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level == len(line) || line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+// listItem returns the text of a list item line, or "" if line isn't one.
+func listItem(line string, ordered bool) string {
+	if ordered {
+		if m := orderedItem.FindString(line); m != "" {
+			return strings.TrimSpace(line[len(m):])
+		}
+		return ""
+	}
+	for _, marker := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(line, marker) {
+			return strings.TrimSpace(line[len(marker):])
+		}
+	}
+	return ""
+}
+
+// list writes the list starting at lines[i] and returns the index after it.
+// Items written "[ ]" or "[x]" become checkboxes.
+func list(b *strings.Builder, lines []string, i int, ordered bool) int {
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag + ">\n")
+	for ; i < len(lines); i++ {
+		item := listItem(strings.TrimSpace(lines[i]), ordered)
+		if item == "" {
+			break
+		}
+		switch {
+		case strings.HasPrefix(item, "[ ] "):
+			b.WriteString(`<li class="checklist-item"><input type="checkbox" disabled> ` + inline(item[4:]) + "</li>\n")
+		case strings.HasPrefix(item, "[x] "), strings.HasPrefix(item, "[X] "):
+			b.WriteString(`<li class="checklist-item"><input type="checkbox" checked disabled> ` + inline(item[4:]) + "</li>\n")
+		default:
+			b.WriteString("<li>" + inline(item) + "</li>\n")
+		}
+	}
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// inline renders code spans, links and emphasis within a line, escaping the
+// rest.
+func inline(text string) string {
+	var b strings.Builder
+	for n, part := range strings.Split(text, "`") {
+		// Odd parts are between backticks. An unmatched backtick leaves the
+		// last part odd with nothing closing it; it is written as it was.
+		if n%2 == 1 && n < strings.Count(text, "`") {
+			b.WriteString("<code>" + html.EscapeString(part) + "</code>")
+			continue
+		}
+		if n%2 == 1 {
+			b.WriteString("`")
+		}
+		b.WriteString(links(part))
+	}
+	return b.String()
+}
+
+// links renders [text](url) links, then bare URLs, in text.
+func links(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range linkPattern.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(autolinks(text[last:m[0]]))
+		label, href := text[m[2]:m[3]], text[m[4]:m[5]]
+		if safeURL(href) {
+			b.WriteString(anchor(href, emphasis(html.EscapeString(label))))
+		} else {
+			b.WriteString(emphasis(html.EscapeString(text[m[0]:m[1]])))
+		}
+		last = m[1]
+	}
+	b.WriteString(autolinks(text[last:]))
+	return b.String()
+}
+
+// autolinks links bare http and https URLs in text.
+func autolinks(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range urlPattern.FindAllStringIndex(text, -1) {
+		href := strings.TrimRight(text[m[0]:m[1]], ".,;:!?)")
+		b.WriteString(emphasis(html.EscapeString(text[last:m[0]])))
+		b.WriteString(anchor(href, html.EscapeString(href)))
+		last = m[0] + len(href)
+	}
+	b.WriteString(emphasis(html.EscapeString(text[last:])))
+	return b.String()
+}
+
+// emphasis renders **strong** and *emphasized* text in escaped text.
+func emphasis(escaped string) string {
+	escaped = strongStars.ReplaceAllString(escaped, "<strong>$1</strong>")
+	return emStars.ReplaceAllString(escaped, "<em>$1</em>")
+}
+
+func anchor(href, label string) string {
+	return `<a href="` + html.EscapeString(href) + `" rel="noopener noreferrer" target="_blank">` + label + `</a>`
+}
+
+// safeURL reports whether href is a link the renderer will write.
+func safeURL(href string) bool {
+	lower := strings.ToLower(href)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "mailto:")
+}
@@ -0,0 +1,36 @@
+package markdown
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"paragraphs", "one\ntwo\n\nthree", "<p>one<br>\ntwo</p>\n<p>three</p>\n"},
+		{"heading", "# Plan", "<h3>Plan</h3>\n"},
+		{"not a heading", "#hashtag", "<p>#hashtag</p>\n"},
+		{"list", "- a\n- b", "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n"},
+		{"ordered list", "1. a\n2. b", "<ol>\n<li>a</li>\n<li>b</li>\n</ol>\n"},
+		{"checklist", "- [ ] a\n- [x] b", "<ul>\n<li class=\"checklist-item\"><input type=\"checkbox\" disabled> a</li>\n<li class=\"checklist-item\"><input type=\"checkbox\" checked disabled> b</li>\n</ul>\n"},
+		{"quote", "> said", "<blockquote><p>said</p>\n</blockquote>\n"},
+		{"code block", "```\n<b>x</b>\n```", "<pre><code>&lt;b&gt;x&lt;/b&gt;\n</code></pre>\n"},
+		{"emphasis", "**bold** and *em*", "<p><strong>bold</strong> and <em>em</em></p>\n"},
+		{"lone star", "5 * 3", "<p>5 * 3</p>\n"},
+		{"code span", "run `a *b*`", "<p>run <code>a *b*</code></p>\n"},
+		{"unmatched backtick", "a `b", "<p>a `b</p>\n"},
+		{"link", "[docs](https://example.com/a?b=1&c=2)", `<p><a href="https://example.com/a?b=1&amp;c=2" rel="noopener noreferrer" target="_blank">docs</a></p>` + "\n"},
+		{"bare url", "see https://example.com.", `<p>see <a href="https://example.com" rel="noopener noreferrer" target="_blank">https://example.com</a>.</p>` + "\n"},
+		{"html is escaped", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"unsafe link", "[x](javascript:alert(1))", "<p>[x](javascript:alert(1))</p>\n"},
+		{"quoted attribute", `[x](https://e.com/"onmouseover=)`, `<p><a href="https://e.com/&#34;onmouseover=" rel="noopener noreferrer" target="_blank">x</a></p>` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(Render(tt.src)); got != tt.want {
+				t.Errorf("Render(%q)\n got %q\nwant %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
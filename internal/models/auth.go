@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Token guessing limits: after FreeAuthFailures failed attempts, each
+// further failure locks the client out for twice as long as the previous
+// one, starting at one second and capped at MaxAuthLockout. Counting starts
+// over once a client has not failed for AuthFailureWindow.
+const (
+	FreeAuthFailures  = 5
+	MaxAuthLockout    = time.Hour
+	AuthFailureWindow = 24 * time.Hour
+)
+
+// AuthFailure counts a client's failed authentications for one feature,
+// such as the capture token.
+type AuthFailure struct {
+	Scope         string
+	Client        string // IP address
+	Failures      int
+	LastFailureAt time.Time
+	// LockedUntil is nil while the client is within FreeAuthFailures.
+	LockedUntil *time.Time
+}
+
+// LockedFor returns how much longer the client is locked out at now, or 0.
+func (f *AuthFailure) LockedFor(now time.Time) time.Duration {
+	if f.LockedUntil == nil || !now.Before(*f.LockedUntil) {
+		return 0
+	}
+	return f.LockedUntil.Sub(now)
+}
+
+// Record counts a failure at now and extends the lockout.
+func (f *AuthFailure) Record(now time.Time) {
+	if now.Sub(f.LastFailureAt) > AuthFailureWindow {
+		f.Failures = 0
+		f.LockedUntil = nil
+	}
+	f.Failures++
+	f.LastFailureAt = now
+
+	if over := f.Failures - FreeAuthFailures; over > 0 {
+		lockout := MaxAuthLockout
+		if over <= 12 {
+			lockout = min(time.Duration(1<<(over-1))*time.Second, MaxAuthLockout)
+		}
+		until := now.Add(lockout)
+		f.LockedUntil = &until
+	}
+}
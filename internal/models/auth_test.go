@@ -0,0 +1,42 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuthFailureBackoff(t *testing.T) {
+	start := time.Date(2024, time.June, 19, 12, 0, 0, 0, time.UTC)
+	f := AuthFailure{Scope: "capture_token", Client: "192.0.2.1"}
+
+	for i := 0; i < FreeAuthFailures; i++ {
+		f.Record(start)
+	}
+	if f.LockedFor(start) != 0 {
+		t.Fatalf("expected no lockout within %d failures", FreeAuthFailures)
+	}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}
+	for _, d := range want {
+		f.Record(start)
+		if got := f.LockedFor(start); got != d {
+			t.Errorf("after %d failures: locked for %v, want %v", f.Failures, got, d)
+		}
+	}
+	if f.LockedFor(start.Add(8*time.Second)) != 0 {
+		t.Error("expected the lockout to expire")
+	}
+
+	for i := 0; i < 40; i++ {
+		f.Record(start)
+	}
+	if got := f.LockedFor(start); got != MaxAuthLockout {
+		t.Errorf("expected the lockout to be capped at %v, got %v", MaxAuthLockout, got)
+	}
+
+	later := start.Add(AuthFailureWindow + time.Minute)
+	f.Record(later)
+	if f.Failures != 1 || f.LockedFor(later) != 0 {
+		t.Errorf("expected counting to start over after a quiet day, got %d failures", f.Failures)
+	}
+}
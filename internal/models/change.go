@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Entities and operations recorded in the change log.
+const (
+	ChangeProject = "project"
+	ChangeTask    = "task"
+
+	ChangeCreate = "create"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+)
+
+// Change is the last change to a project or task. Seq grows with every
+// change, so a client that has seen up to some Seq only needs the changes
+// after it.
+type Change struct {
+	Seq       int64     `json:"seq"`
+	Entity    string    `json:"entity"`
+	EntityID  int64     `json:"id"`
+	Op        string    `json:"op"`
+	ChangedAt time.Time `json:"changed_at"`
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// FocusSession is a stretch of time spent working on one task, started and
+// stopped from the app. At most one session runs at a time.
+type FocusSession struct {
+	ID        int64      `json:"id"`
+	TaskID    int64      `json:"task_id"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"` // nil while running
+	// Task is the task's description, for showing the session on its own.
+	Task string `json:"task"`
+}
+
+// Running reports whether the session hasn't been stopped yet.
+func (s *FocusSession) Running() bool {
+	return s.EndedAt == nil
+}
+
+// Duration is how long the session lasted, or has lasted so far at now.
+func (s *FocusSession) Duration(now time.Time) time.Duration {
+	end := now
+	if s.EndedAt != nil {
+		end = *s.EndedAt
+	}
+	if end.Before(s.StartedAt) {
+		return 0
+	}
+	return end.Sub(s.StartedAt)
+}
+
+// FocusTotal is the time spent focusing on one task on one day.
+type FocusTotal struct {
+	Day     time.Time `json:"day"`
+	TaskID  int64     `json:"task_id"`
+	Task    string    `json:"task"`
+	Seconds int64     `json:"seconds"`
+}
+
+// Duration returns Seconds as a time.Duration.
+func (t FocusTotal) Duration() time.Duration {
+	return time.Duration(t.Seconds) * time.Second
+}
+
+// SumFocusSessions totals sessions by task and the local day they started
+// on, counting a running session up to now. Totals are ordered by day, then
+// task.
+func SumFocusSessions(sessions []FocusSession, now time.Time) []FocusTotal {
+	type key struct {
+		day    time.Time
+		taskID int64
+	}
+	index := make(map[key]int)
+	var totals []FocusTotal
+	for _, s := range sessions {
+		start := s.StartedAt.In(now.Location())
+		k := key{civilDay(start), s.TaskID}
+		i, ok := index[k]
+		if !ok {
+			i = len(totals)
+			index[k] = i
+			totals = append(totals, FocusTotal{Day: k.day, TaskID: s.TaskID, Task: s.Task})
+		}
+		totals[i].Seconds += int64(s.Duration(now) / time.Second)
+	}
+	sort.SliceStable(totals, func(i, j int) bool {
+		if !totals[i].Day.Equal(totals[j].Day) {
+			return totals[i].Day.Before(totals[j].Day)
+		}
+		return totals[i].TaskID < totals[j].TaskID
+	})
+	return totals
+}
+
+// FormatDuration shows d in whole minutes, as "25m" or "1h 05m".
+func FormatDuration(d time.Duration) string {
+	minutes := int(d / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
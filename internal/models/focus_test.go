@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSumFocusSessions(t *testing.T) {
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2026, time.March, day, hour, min, 0, 0, time.Local)
+	}
+	end := func(day, hour, min int) *time.Time { t := at(day, hour, min); return &t }
+	now := at(11, 10, 0)
+
+	sessions := []FocusSession{
+		{TaskID: 2, Task: "Write report", StartedAt: at(10, 9, 0), EndedAt: end(10, 9, 25)},
+		{TaskID: 1, Task: "Inbox zero", StartedAt: at(10, 14, 0), EndedAt: end(10, 14, 10)},
+		{TaskID: 2, Task: "Write report", StartedAt: at(10, 23, 50), EndedAt: end(11, 0, 20)},
+		{TaskID: 2, Task: "Write report", StartedAt: at(11, 9, 30)},
+	}
+	totals := SumFocusSessions(sessions, now)
+
+	want := []FocusTotal{
+		{Day: civilDay(at(10, 0, 0)), TaskID: 1, Seconds: 10 * 60},
+		{Day: civilDay(at(10, 0, 0)), TaskID: 2, Seconds: 55 * 60},
+		{Day: civilDay(at(11, 0, 0)), TaskID: 2, Seconds: 30 * 60},
+	}
+	if len(totals) != len(want) {
+		t.Fatalf("expected %d totals, got %+v", len(want), totals)
+	}
+	for i, w := range want {
+		got := totals[i]
+		if !got.Day.Equal(w.Day) || got.TaskID != w.TaskID || got.Seconds != w.Seconds {
+			t.Errorf("total %d: expected %v task %d %ds, got %v task %d %ds", i, w.Day, w.TaskID, w.Seconds, got.Day, got.TaskID, got.Seconds)
+		}
+	}
+	if totals[0].Task != "Inbox zero" {
+		t.Errorf("expected totals to keep the task description, got %q", totals[0].Task)
+	}
+}
+
+func TestFocusSessionDuration(t *testing.T) {
+	start := time.Date(2026, time.March, 10, 9, 0, 0, 0, time.UTC)
+	s := FocusSession{StartedAt: start}
+	if !s.Running() || s.Duration(start.Add(5*time.Minute)) != 5*time.Minute {
+		t.Errorf("expected a running session of 5m, got %v", s.Duration(start.Add(5*time.Minute)))
+	}
+	if s.Duration(start.Add(-time.Minute)) != 0 {
+		t.Error("expected no negative durations")
+	}
+	ended := start.Add(25 * time.Minute)
+	s.EndedAt = &ended
+	if s.Running() || s.Duration(start.Add(time.Hour)) != 25*time.Minute {
+		t.Errorf("expected a stopped session of 25m, got %v", s.Duration(start.Add(time.Hour)))
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                                 "0m",
+		59 * time.Second:                  "0m",
+		25 * time.Minute:                  "25m",
+		time.Hour + 5*time.Minute:         "1h 05m",
+		26*time.Hour + 30*time.Minute + 9: "26h 30m",
+	} {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"regexp"
+	"time"
+)
+
+var githubRepoPattern = regexp.MustCompile(`^[A-Za-z0-9-]+/[A-Za-z0-9._-]+$`)
+
+// GitHubLink connects a project to a GitHub repository.
+type GitHubLink struct {
+	ProjectID int64  `json:"project_id"`
+	Repo      string `json:"repo"` // "owner/name"
+	Token     string `json:"-"`
+	// Login is the token's owner; only issues assigned to them are synced.
+	Login string `json:"login"`
+	// WebhookSecret signs deliveries to the GitHub webhook endpoint.
+	WebhookSecret string     `json:"-"`
+	LastSyncedAt  *time.Time `json:"last_synced_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Validate checks that the link names a repository and has credentials.
+func (l *GitHubLink) Validate() error {
+	if l.ProjectID == 0 {
+		return invalid("project_id is required")
+	}
+
+	if !githubRepoPattern.MatchString(l.Repo) {
+		return invalid("repo must look like owner/name")
+	}
+
+	if l.Token == "" {
+		return invalid("token is required")
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// GoogleTasksAccount is the stored OAuth connection for Google Tasks sync.
+type GoogleTasksAccount struct {
+	RefreshToken string     `json:"-"`
+	AccessToken  string     `json:"-"`
+	TokenExpiry  *time.Time `json:"-"`
+	// ProjectID is the project new remote tasks land in; nil pauses syncing.
+	ProjectID    *int64     `json:"project_id,omitempty"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TargetProjectID returns the designated project, or 0 when none is set.
+func (a *GoogleTasksAccount) TargetProjectID() int64 {
+	if a.ProjectID == nil {
+		return 0
+	}
+	return *a.ProjectID
+}
+
+// GoogleCalendarAccount is the stored OAuth connection for Google Calendar
+// sync.
+type GoogleCalendarAccount struct {
+	RefreshToken string     `json:"-"`
+	AccessToken  string     `json:"-"`
+	TokenExpiry  *time.Time `json:"-"`
+	// CalendarID is the calendar events are written to, "primary" for the
+	// account's own.
+	CalendarID   string     `json:"calendar_id"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// CalendarEvent links a task to the Google Calendar event created for it.
+// Title, DueDate and Done are what both sides agreed on at the last sync; a
+// side that no longer matches them has changed since.
+type CalendarEvent struct {
+	TaskID  int64
+	EventID string
+	// ETag is the event's version after the last sync.
+	ETag    string
+	Title   string
+	DueDate time.Time
+	Done    bool
+	// Conflict describes how the last change made on both sides at once
+	// was resolved, empty if there was none.
+	Conflict   string
+	ConflictAt *time.Time
+	SyncedAt   time.Time
+}
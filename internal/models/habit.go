@@ -0,0 +1,92 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// MaxHabitNameLength limits a habit's name.
+const MaxHabitNameLength = 255
+
+// Habit cadences: how often a habit is meant to be done.
+const (
+	HabitDaily  = "daily"
+	HabitWeekly = "weekly"
+)
+
+// Habit is something done regularly rather than finished once, tracked by
+// checking in on the days it was done. Unlike tasks, habits have no project,
+// status or due date.
+type Habit struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Cadence   string    `json:"cadence"` // HabitDaily or HabitWeekly
+	SortOrder int       `json:"sort_order"`
+	CreatedAt time.Time `json:"created_at"`
+	// CheckIns holds the days the habit was done, oldest first.
+	CheckIns []time.Time `json:"check_ins"`
+}
+
+// Validate checks the habit's name and cadence. A habit without a cadence
+// is daily.
+func (h *Habit) Validate() error {
+	h.Name = strings.TrimSpace(h.Name)
+	if h.Name == "" {
+		return invalid("name is required")
+	}
+	if len(h.Name) > MaxHabitNameLength {
+		return invalid("names must be 255 characters or fewer")
+	}
+	switch h.Cadence {
+	case "":
+		h.Cadence = HabitDaily
+	case HabitDaily, HabitWeekly:
+	default:
+		return invalid("habits are daily or weekly")
+	}
+	return nil
+}
+
+// CheckedIn reports whether the habit was done on day.
+func (h *Habit) CheckedIn(day time.Time) bool {
+	key := civilDay(day)
+	for _, d := range h.CheckIns {
+		if civilDay(d).Equal(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Streak counts the consecutive days, or weeks starting on weekStart for
+// weekly habits, with at least one check-in, ending with the one today
+// falls in. As with ComputeStreaks, a period not yet checked in does not
+// break the streak until it is over.
+func (h *Habit) Streak(today time.Time, weekStart time.Weekday) int {
+	period := func(t time.Time) time.Time {
+		t = civilDay(t)
+		if h.Cadence == HabitWeekly {
+			t = t.AddDate(0, 0, -((int(t.Weekday()) - int(weekStart) + 7) % 7))
+		}
+		return t
+	}
+	step := -1
+	if h.Cadence == HabitWeekly {
+		step = -7
+	}
+
+	done := make(map[time.Time]bool, len(h.CheckIns))
+	for _, d := range h.CheckIns {
+		done[period(d)] = true
+	}
+	current := period(today)
+	if !done[current] {
+		current = current.AddDate(0, 0, step)
+	}
+	streak := 0
+	for done[current] {
+		streak++
+		current = current.AddDate(0, 0, step)
+	}
+	return streak
+}
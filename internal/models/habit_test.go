@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHabitValidation(t *testing.T) {
+	h := &Habit{Name: "  Floss  "}
+	if err := h.Validate(); err != nil {
+		t.Fatalf("expected a valid habit, got %v", err)
+	}
+	if h.Name != "Floss" || h.Cadence != HabitDaily {
+		t.Errorf("expected a trimmed daily habit, got %q %q", h.Name, h.Cadence)
+	}
+
+	for _, h := range []*Habit{
+		{Name: ""},
+		{Name: "Run", Cadence: "hourly"},
+	} {
+		if err := h.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", h)
+		}
+	}
+}
+
+func TestHabitStreak(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, time.March, d, 8, 0, 0, 0, time.Local) }
+	// Thursday 12 March 2026.
+	today := day(12)
+
+	tests := []struct {
+		name     string
+		cadence  string
+		checkIns []time.Time
+		want     int
+	}{
+		{"none", HabitDaily, nil, 0},
+		{"ending today", HabitDaily, []time.Time{day(9), day(10), day(11), day(12)}, 4},
+		{"today still open", HabitDaily, []time.Time{day(10), day(11)}, 2},
+		{"broken yesterday", HabitDaily, []time.Time{day(9), day(10)}, 0},
+		{"gap", HabitDaily, []time.Time{day(8), day(10), day(11), day(12)}, 3},
+		{"weekly", HabitWeekly, []time.Time{day(3), day(10)}, 2},
+		{"weekly this week still open", HabitWeekly, []time.Time{day(1), day(4)}, 2},
+		{"weekly broken", HabitWeekly, []time.Time{day(1)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Habit{Cadence: tt.cadence, CheckIns: tt.checkIns}
+			if got := h.Streak(today, time.Monday); got != tt.want {
+				t.Errorf("expected streak %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
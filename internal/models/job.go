@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// JobStatus is what the scheduler last recorded about a background job.
+type JobStatus struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"` // e.g. "@every 5m" or "0 3 * * *"
+	// LastRunAt is nil until the job has run once.
+	LastRunAt    *time.Time    `json:"last_run_at,omitempty"`
+	LastDuration time.Duration `json:"last_duration"`
+	// LastError is empty when the last run succeeded.
+	LastError string    `json:"last_error,omitempty"`
+	NextRunAt time.Time `json:"next_run_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Migration is a database schema change and whether it has been applied.
+type Migration struct {
+	Version int
+	Name    string
+	// AppliedAt is nil for migrations this build knows but has not applied.
+	AppliedAt *time.Time
+	// Unknown marks migrations recorded in the database that this build
+	// does not have, as after downgrading.
+	Unknown bool
+}
+
+// CleanupResult reports what Store.Cleanup removed.
+type CleanupResult struct {
+	ExpiredAuthFailures int64
+	// SizeBefore and SizeAfter are the database size in bytes around the
+	// VACUUM.
+	SizeBefore int64
+	SizeAfter  int64
+}
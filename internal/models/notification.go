@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Notification kinds, naming what put an entry in the in-app inbox.
+const (
+	NotificationReminder = "reminder" // a task became overdue
+	NotificationSync     = "sync"     // an integration changed a task
+	NotificationImport   = "import"   // an import finished
+)
+
+// Notification is an entry in the in-app inbox, kept so events aren't lost
+// when no email or push channel is configured.
+type Notification struct {
+	ID        int64      `json:"id"`
+	Kind      string     `json:"kind"`
+	Message   string     `json:"message"`
+	TaskID    *int64     `json:"task_id"`
+	ProjectID *int64     `json:"project_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at"` // nil while unread
+}
+
+// Channels a project's notifications can be turned off on.
+const (
+	ChannelEmail = "email"
+	ChannelSlack = "slack"
+	ChannelInbox = "inbox"
+)
+
+// ProjectNotificationPrefs chooses the channels that announce a project's
+// tasks. Muted turns every channel off, whatever the others say.
+type ProjectNotificationPrefs struct {
+	ProjectID int64 `json:"project_id"`
+	Muted     bool  `json:"muted"`
+	Email     bool  `json:"email"`
+	Slack     bool  `json:"slack"`
+	Inbox     bool  `json:"inbox"`
+}
+
+// DefaultProjectNotificationPrefs returns the preferences of a project that
+// never saved any: every channel on.
+func DefaultProjectNotificationPrefs(projectID int64) ProjectNotificationPrefs {
+	return ProjectNotificationPrefs{ProjectID: projectID, Email: true, Slack: true, Inbox: true}
+}
+
+// Allows reports whether channel may announce the project's tasks.
+func (p ProjectNotificationPrefs) Allows(channel string) bool {
+	if p.Muted {
+		return false
+	}
+	switch channel {
+	case ChannelEmail:
+		return p.Email
+	case ChannelSlack:
+		return p.Slack
+	case ChannelInbox:
+		return p.Inbox
+	}
+	return true
+}
+
+// NotificationPrefs holds the saved preferences of projects by ID.
+type NotificationPrefs map[int64]ProjectNotificationPrefs
+
+// Allows reports whether channel may announce tasks of the project. Projects
+// without saved preferences allow every channel.
+func (p NotificationPrefs) Allows(projectID int64, channel string) bool {
+	prefs, ok := p[projectID]
+	return !ok || prefs.Allows(channel)
+}
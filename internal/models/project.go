@@ -1,7 +1,6 @@
 package models
 
 import (
-	"errors"
 	"strings"
 	"time"
 )
@@ -16,18 +15,130 @@ type Project struct {
 	Completed   bool       `json:"completed"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
 	SortOrder   int        `json:"sort_order"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	ViewTab     string     `json:"-"`
+	SortMode    string     `json:"sort_mode"` // one of SortModes
+	// ChecklistReset makes the project a checklist of routines whose done
+	// tasks go back to To Do on a schedule: ChecklistDaily or
+	// ChecklistWeekly, or "" for an ordinary project. See LastChecklistReset.
+	ChecklistReset string `json:"checklist_reset,omitempty"`
+	ChecklistHour  int    `json:"checklist_hour,omitempty"` // 0-23, server local time
+	// ChecklistResetAt is when the checklist last reset, or started being
+	// one.
+	ChecklistResetAt *time.Time `json:"-"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	// Revision goes up whenever the project's contents change; see
+	// Task.Revision.
+	Revision int64  `json:"revision"`
+	ViewTab  string `json:"-"`
+	// Counts summarizes the open tasks, for badges; pages that show them
+	// fill it in.
+	Counts ProjectCounts `json:"-"`
 
 	// Tasks holds the tasks for this project (populated by queries)
 	Tasks []Task `json:"tasks,omitempty"`
 }
 
+// ProjectCounts counts a project's open tasks: all of them, those overdue
+// and those due in the next seven days, today included. Done counts its
+// finished tasks.
+type ProjectCounts struct {
+	Open        int `json:"open"`
+	Overdue     int `json:"overdue"`
+	DueThisWeek int `json:"due_this_week"`
+	Done        int `json:"done"`
+}
+
+// Total returns how many tasks the project has.
+func (c ProjectCounts) Total() int {
+	return c.Open + c.Done
+}
+
+// Due returns how many open tasks are overdue or due in the next week.
+func (c ProjectCounts) Due() int {
+	return c.Overdue + c.DueThisWeek
+}
+
+// TotalCounts adds up the counts of projects.
+func TotalCounts(projects []Project) ProjectCounts {
+	var total ProjectCounts
+	for _, p := range projects {
+		total.Open += p.Counts.Open
+		total.Overdue += p.Counts.Overdue
+		total.DueThisWeek += p.Counts.DueThisWeek
+		total.Done += p.Counts.Done
+	}
+	return total
+}
+
+// Task sort modes for Project.SortMode. Manual keeps the drag-and-drop order.
+const (
+	SortManual   = "manual"
+	SortPriority = "priority"
+	SortDueDate  = "due_date"
+	SortCreated  = "created"
+)
+
+// SortMode is a way of ordering a project's tasks.
+type SortMode struct {
+	Value string
+	Label string
+}
+
+// SortModes lists the task sort modes, SortManual first.
+var SortModes = []SortMode{
+	{SortManual, "Manual"},
+	{SortPriority, "Priority"},
+	{SortDueDate, "Due date"},
+	{SortCreated, "Date added"},
+}
+
+// ValidSortMode reports whether mode is one of SortModes.
+func ValidSortMode(mode string) bool {
+	for _, m := range SortModes {
+		if m.Value == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Checklist schedules for Project.ChecklistReset.
+const (
+	ChecklistDaily  = "daily"
+	ChecklistWeekly = "weekly"
+)
+
+// IsChecklist reports whether the project's tasks reset on a schedule.
+func (p *Project) IsChecklist() bool {
+	return p.ChecklistReset != ""
+}
+
+// LastChecklistReset returns when the checklist was last due to reset at or
+// before now: today or yesterday at ChecklistHour for daily checklists, and
+// that hour on the first day of this week or last for weekly ones. It
+// returns the zero time for projects that aren't checklists.
+func (p *Project) LastChecklistReset(now time.Time, weekStart time.Weekday) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), p.ChecklistHour, 0, 0, 0, now.Location())
+	switch p.ChecklistReset {
+	case ChecklistDaily:
+		if day.After(now) {
+			day = day.AddDate(0, 0, -1)
+		}
+		return day
+	case ChecklistWeekly:
+		day = day.AddDate(0, 0, -((int(now.Weekday()) - int(weekStart) + 7) % 7))
+		if day.After(now) {
+			day = day.AddDate(0, 0, -7)
+		}
+		return day
+	}
+	return time.Time{}
+}
+
 // Validate checks that the project has valid field values.
 func (p *Project) Validate() error {
 	if strings.TrimSpace(p.Name) == "" {
-		return errors.New("name is required")
+		return invalid("name is required")
 	}
 
 	// Default type to "project" — the category distinction is no longer used in the UI
@@ -35,11 +146,28 @@ func (p *Project) Validate() error {
 		p.Type = "project"
 	}
 
+	if p.SortMode == "" {
+		p.SortMode = SortManual
+	}
+	if !ValidSortMode(p.SortMode) {
+		return invalid("unsupported sort mode")
+	}
+	switch p.ChecklistReset {
+	case "", ChecklistDaily, ChecklistWeekly:
+	default:
+		return invalid("checklists reset daily or weekly")
+	}
+	if p.ChecklistHour < 0 || p.ChecklistHour > 23 {
+		return invalid("reset hour must be between 0 and 23")
+	}
+
 	return nil
 }
 
 // IsOverdue returns true if the project has a target date that has passed.
-func (p *Project) IsOverdue() bool {
+// It takes a value so templates can call it on a project passed through
+// dict, which isn't addressable.
+func (p Project) IsOverdue() bool {
 	if p.TargetDate == nil {
 		return false
 	}
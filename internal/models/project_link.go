@@ -0,0 +1,42 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MaxLinkTitleLength limits a project link's title.
+const MaxLinkTitleLength = 255
+
+// ProjectLink is a reference link kept with a project, shown in its
+// Resources panel.
+type ProjectLink struct {
+	ID        int64     `json:"id"`
+	ProjectID int64     `json:"project_id"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	SortOrder int       `json:"sort_order"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks that the link goes to an http or https URL. A link
+// without a title is titled with the URL's host.
+func (l *ProjectLink) Validate() error {
+	l.URL = strings.TrimSpace(l.URL)
+	l.Title = strings.TrimSpace(l.Title)
+	if l.URL == "" {
+		return invalid("url is required")
+	}
+	u, err := url.Parse(l.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return invalid("links must be http or https URLs")
+	}
+	if l.Title == "" {
+		l.Title = u.Host
+	}
+	if len(l.Title) > MaxLinkTitleLength {
+		return invalid("titles must be 255 characters or fewer")
+	}
+	return nil
+}
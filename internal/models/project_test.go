@@ -128,3 +128,84 @@ func TestProject_IsOverdue(t *testing.T) {
 		})
 	}
 }
+
+func TestProject_LastChecklistReset(t *testing.T) {
+	// Wednesday 2026-03-11, 07:30.
+	now := time.Date(2026, 3, 11, 7, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		reset     string
+		hour      int
+		weekStart time.Weekday
+		want      string
+	}{
+		{"daily, hour passed", ChecklistDaily, 6, time.Monday, "2026-03-11 06:00"},
+		{"daily, hour to come", ChecklistDaily, 8, time.Monday, "2026-03-10 08:00"},
+		{"weekly from Monday", ChecklistWeekly, 6, time.Monday, "2026-03-09 06:00"},
+		{"weekly from Sunday", ChecklistWeekly, 6, time.Sunday, "2026-03-08 06:00"},
+		{"not a checklist", "", 6, time.Monday, "0001-01-01 00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Project{ChecklistReset: tt.reset, ChecklistHour: tt.hour}
+			if got := p.LastChecklistReset(now, tt.weekStart).Format("2006-01-02 15:04"); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	// On the first day of the week, before the hour, it's last week's.
+	monday := time.Date(2026, 3, 9, 5, 0, 0, 0, time.UTC)
+	p := Project{ChecklistReset: ChecklistWeekly, ChecklistHour: 6}
+	if got := p.LastChecklistReset(monday, time.Monday).Format("2006-01-02 15:04"); got != "2026-03-02 06:00" {
+		t.Errorf("expected last Monday, got %s", got)
+	}
+}
+
+func TestProjectValidation_Checklist(t *testing.T) {
+	for _, p := range []Project{
+		{Name: "Morning", ChecklistReset: "hourly"},
+		{Name: "Morning", ChecklistReset: ChecklistDaily, ChecklistHour: 24},
+	} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", p)
+		}
+	}
+	p := Project{Name: "Morning", ChecklistReset: ChecklistWeekly, ChecklistHour: 23}
+	if err := p.Validate(); err != nil {
+		t.Errorf("expected a weekly checklist to be valid, got %v", err)
+	}
+}
+
+func TestProjectLinkValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		link      ProjectLink
+		wantErr   string
+		wantTitle string
+	}{
+		{"titled link", ProjectLink{Title: "Design doc", URL: "https://docs.example.com/d/1"}, "", "Design doc"},
+		{"untitled link takes the host", ProjectLink{URL: " https://figma.com/file/abc "}, "", "figma.com"},
+		{"missing url", ProjectLink{Title: "Docs"}, "url is required", ""},
+		{"javascript url", ProjectLink{URL: "javascript:alert(1)"}, "links must be http or https URLs", ""},
+		{"relative url", ProjectLink{URL: "/projects/1"}, "links must be http or https URLs", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.link.Validate()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.link.Title != tt.wantTitle {
+				t.Errorf("expected title %q, got %q", tt.wantTitle, tt.link.Title)
+			}
+		})
+	}
+}
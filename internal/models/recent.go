@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// RecentItem is a task or project in the Recent view, which lists what was
+// changed last across every project.
+type RecentItem struct {
+	Kind        string `json:"kind"` // "task" or "project"
+	ID          int64  `json:"id"`
+	Name        string `json:"name"` // a task's description or a project's name
+	ProjectID   int64  `json:"project_id,omitempty"`
+	ProjectName string `json:"project_name,omitempty"`
+	Status      string `json:"status,omitempty"` // tasks only
+	Completed   bool   `json:"completed"`
+	// Changed names the fields the last edit changed, such as "notes" or
+	// "due date". It is empty when the item wasn't edited since it was
+	// created, or only moved or reordered.
+	Changed   []string  `json:"changed"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// New reports whether the item is unchanged since it was created.
+func (i RecentItem) New() bool {
+	return len(i.Changed) == 0 && i.UpdatedAt.Sub(i.CreatedAt) < time.Second
+}
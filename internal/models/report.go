@@ -0,0 +1,53 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"time"
+)
+
+// ReportRow counts tasks created and completed within one report group.
+type ReportRow struct {
+	// Group is the project name, the priority, or the Monday (YYYY-MM-DD)
+	// starting the week, depending on how the report is grouped.
+	Group     string `json:"group"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+}
+
+// ReportSchedule emails a report of the tasks created, completed and overdue
+// on a cron schedule.
+type ReportSchedule struct {
+	ID         int64
+	Recipients []string
+	// Schedule is a five-field cron expression read in the time zone picked
+	// in the settings, e.g. "0 16 * * 5" for Fridays at 16:00.
+	Schedule string
+	// Days is how many days, up to the day it is sent, a report covers.
+	Days int
+	// LastRunAt is when a report last went out, nil before the first.
+	LastRunAt *time.Time
+	// LastError says why the last attempt to send one failed, if it did.
+	LastError string
+	CreatedAt time.Time
+}
+
+// MaxReportDays bounds how far back a scheduled report looks.
+const MaxReportDays = 366
+
+// Validate checks the recipients and the covered days. The schedule is
+// checked by the scheduler that runs it.
+func (r *ReportSchedule) Validate() error {
+	if len(r.Recipients) == 0 {
+		return invalid("at least one recipient is required")
+	}
+	for _, addr := range r.Recipients {
+		if a, err := mail.ParseAddress(addr); err != nil || a.Address != addr {
+			return invalid(fmt.Sprintf("%q is not an email address", addr))
+		}
+	}
+	if r.Days < 1 || r.Days > MaxReportDays {
+		return invalid(fmt.Sprintf("days must be between 1 and %d", MaxReportDays))
+	}
+	return nil
+}
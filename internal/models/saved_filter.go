@@ -0,0 +1,36 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// MaxSavedFilterNameLength limits a saved filter's name.
+const MaxSavedFilterNameLength = 100
+
+// SavedFilter is a filter expression kept under a name, to show the All
+// Tasks list through again later.
+type SavedFilter struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// Query is the expression, in the task filter language.
+	Query     string    `json:"query"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks the name and that there is an expression. Whether the
+// expression parses is checked by the caller, with the filter language.
+func (f *SavedFilter) Validate() error {
+	f.Name = strings.TrimSpace(f.Name)
+	f.Query = strings.TrimSpace(f.Query)
+	if f.Name == "" {
+		return invalid("name is required")
+	}
+	if len(f.Name) > MaxSavedFilterNameLength {
+		return invalid("names must be 100 characters or fewer")
+	}
+	if f.Query == "" {
+		return invalid("filter is required")
+	}
+	return nil
+}
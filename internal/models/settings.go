@@ -0,0 +1,229 @@
+package models
+
+import (
+	"time"
+
+	"mytasks/internal/i18n"
+)
+
+// Settings are user preferences stored server-side.
+type Settings struct {
+	// DefaultPriority is preselected for new tasks and used when a capture
+	// channel (quick add, email, Slack, ...) does not name one.
+	DefaultPriority string `json:"default_priority"`
+	// UpcomingDays is the Upcoming view's range when none is picked.
+	UpcomingDays int `json:"upcoming_days"`
+	// StaleDays is how many days an open task can go untouched before lists
+	// and the weekly review flag it.
+	StaleDays int `json:"stale_days"`
+	// EscalateDays raises the priority of open tasks due within this many
+	// days by one level, and of overdue tasks to high. 0 turns it off.
+	EscalateDays int `json:"escalate_days"`
+	// DateFormat is a Go time layout from DateFormats.
+	DateFormat string `json:"date_format"`
+	// WeekStart is time.Monday or time.Sunday.
+	WeekStart time.Weekday `json:"week_start"`
+	// Theme is "light", "dark", or "system" to follow the browser.
+	Theme string `json:"theme"`
+	// Palette names a stylesheet in static/css/themes that recolors the
+	// app, or is empty for the built-in colors.
+	Palette string `json:"palette"`
+	// Language is an i18n language code, or "auto" to follow the browser.
+	Language string `json:"language"`
+	// TimeZone is the IANA zone quiet hours and the digest time are read
+	// in, or empty for the server's.
+	TimeZone string `json:"time_zone"`
+	// QuietHoursStart and QuietHoursEnd, as "15:04", bound the stretch of
+	// each day in which overdue alerts are held back until it ends. Quiet
+	// hours are off unless both are set.
+	QuietHoursStart string `json:"quiet_hours_start"`
+	QuietHoursEnd   string `json:"quiet_hours_end"`
+	// DigestTime, as "15:04", is when the daily digest email goes out;
+	// empty means 07:00.
+	DigestTime string `json:"digest_time"`
+}
+
+// MaxUpcomingDays bounds UpcomingDays and the Upcoming view's ?days range.
+const MaxUpcomingDays = 365
+
+// MaxStaleDays bounds StaleDays and the weekly review's ?stale override.
+const MaxStaleDays = 365
+
+// MaxEscalateDays bounds EscalateDays.
+const MaxEscalateDays = 30
+
+// DateFormat is a date display format users can choose.
+type DateFormat struct {
+	Layout string
+	// Short leaves out the year, for dates on cards and in the sidebar.
+	Short string
+	Label string
+}
+
+// DateFormats lists the supported date formats; the first is the default.
+var DateFormats = []DateFormat{
+	{Layout: "Jan 2, 2006", Short: "Jan 2", Label: "Jan 2, 2006"},
+	{Layout: "2 Jan 2006", Short: "2 Jan", Label: "2 Jan 2006"},
+	{Layout: "2006-01-02", Short: "01-02", Label: "2006-01-02 (ISO)"},
+	{Layout: "01/02/2006", Short: "01/02", Label: "01/02/2006 (US)"},
+	{Layout: "02/01/2006", Short: "02/01", Label: "02/01/2006"},
+}
+
+// DefaultSettings returns the preferences used until the user changes them.
+func DefaultSettings() Settings {
+	return Settings{
+		DefaultPriority: "medium",
+		UpcomingDays:    30,
+		StaleDays:       14,
+		DateFormat:      DateFormats[0].Layout,
+		WeekStart:       time.Monday,
+		Theme:           "system",
+		Language:        "auto",
+		DigestTime:      "07:00",
+	}
+}
+
+// Validate checks that every preference has a supported value.
+func (s *Settings) Validate() error {
+	if s.DefaultPriority != "high" && s.DefaultPriority != "medium" && s.DefaultPriority != "low" {
+		return invalid("default priority must be 'high', 'medium', or 'low'")
+	}
+
+	if s.UpcomingDays < 1 || s.UpcomingDays > MaxUpcomingDays {
+		return invalid("upcoming window must be between 1 and 365 days")
+	}
+
+	if s.StaleDays < 1 || s.StaleDays > MaxStaleDays {
+		return invalid("stale threshold must be between 1 and 365 days")
+	}
+
+	if s.EscalateDays < 0 || s.EscalateDays > MaxEscalateDays {
+		return invalid("escalation window must be between 0 and 30 days")
+	}
+
+	known := false
+	for _, f := range DateFormats {
+		known = known || f.Layout == s.DateFormat
+	}
+	if !known {
+		return invalid("unsupported date format")
+	}
+
+	if s.WeekStart != time.Monday && s.WeekStart != time.Sunday {
+		return invalid("week must start on Monday or Sunday")
+	}
+
+	if s.Theme != "light" && s.Theme != "dark" && s.Theme != "system" {
+		return invalid("theme must be 'light', 'dark', or 'system'")
+	}
+
+	if !ValidPaletteName(s.Palette) {
+		return invalid("unsupported color palette")
+	}
+
+	if s.Language != "auto" && !i18n.Supported(s.Language) {
+		return invalid("unsupported language")
+	}
+
+	if s.TimeZone != "" {
+		if _, err := time.LoadLocation(s.TimeZone); err != nil || s.TimeZone == "Local" {
+			return invalid("unknown time zone")
+		}
+	}
+
+	for _, value := range []string{s.QuietHoursStart, s.QuietHoursEnd} {
+		if _, ok := clockMinutes(value); value != "" && !ok {
+			return invalid("quiet hours must be given as HH:MM")
+		}
+	}
+
+	if _, ok := clockMinutes(s.DigestTime); s.DigestTime != "" && !ok {
+		return invalid("digest time must be HH:MM")
+	}
+
+	return nil
+}
+
+// Location is the time zone quiet hours and the digest time are read in.
+func (s Settings) Location() *time.Location {
+	if s.TimeZone != "" {
+		if loc, err := time.LoadLocation(s.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// InQuietHours reports whether t falls within quiet hours. A start after the
+// end spans midnight, e.g. 22:00 to 07:00.
+func (s Settings) InQuietHours(t time.Time) bool {
+	start, startOK := clockMinutes(s.QuietHoursStart)
+	end, endOK := clockMinutes(s.QuietHoursEnd)
+	if !startOK || !endOK || start == end {
+		return false
+	}
+	t = t.In(s.Location())
+	now := t.Hour()*60 + t.Minute()
+	if start < end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+// DigestDue reports whether the digest time has passed on t's day, in the
+// settings' time zone, and returns that day as YYYY-MM-DD.
+func (s Settings) DigestDue(t time.Time) (string, bool) {
+	t = t.In(s.Location())
+	at, ok := clockMinutes(s.DigestTime)
+	if !ok {
+		at = 7 * 60
+	}
+	return t.Format("2006-01-02"), t.Hour()*60+t.Minute() >= at
+}
+
+// clockMinutes parses a "15:04" time of day into minutes after midnight.
+func clockMinutes(value string) (int, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// ValidPaletteName accepts names usable as a file name in a URL: lower-case
+// letters, digits and dashes. Empty means no palette.
+func ValidPaletteName(name string) bool {
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatDate formats t in the chosen date format.
+func (s Settings) FormatDate(t time.Time) string {
+	return t.Format(s.dateFormat().Layout)
+}
+
+// FormatShortDate formats t in the chosen date format without the year.
+func (s Settings) FormatShortDate(t time.Time) string {
+	return t.Format(s.dateFormat().Short)
+}
+
+// dateFormat returns the chosen DateFormat, or the default for settings
+// that were never loaded.
+func (s Settings) dateFormat() DateFormat {
+	for _, f := range DateFormats {
+		if f.Layout == s.DateFormat {
+			return f
+		}
+	}
+	return DateFormats[0]
+}
+
+// StartOfWeek returns the first day, on or before t, of the week t falls in.
+func (s Settings) StartOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) - int(s.WeekStart) + 7) % 7
+	return t.AddDate(0, 0, -offset)
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Settings)
+		wantErr bool
+	}{
+		{"defaults", func(*Settings) {}, false},
+		{"sunday week and ISO dates", func(s *Settings) { s.WeekStart = time.Sunday; s.DateFormat = "2006-01-02" }, false},
+		{"unknown priority", func(s *Settings) { s.DefaultPriority = "urgent" }, true},
+		{"zero upcoming days", func(s *Settings) { s.UpcomingDays = 0 }, true},
+		{"too many upcoming days", func(s *Settings) { s.UpcomingDays = MaxUpcomingDays + 1 }, true},
+		{"zero stale days", func(s *Settings) { s.StaleDays = 0 }, true},
+		{"escalation on", func(s *Settings) { s.EscalateDays = 3 }, false},
+		{"negative escalation", func(s *Settings) { s.EscalateDays = -1 }, true},
+		{"escalation too far out", func(s *Settings) { s.EscalateDays = MaxEscalateDays + 1 }, true},
+		{"arbitrary layout", func(s *Settings) { s.DateFormat = "Mon Jan 2" }, true},
+		{"wednesday week", func(s *Settings) { s.WeekStart = time.Wednesday }, true},
+		{"dark theme", func(s *Settings) { s.Theme = "dark" }, false},
+		{"unknown theme", func(s *Settings) { s.Theme = "solarized" }, true},
+		{"palette", func(s *Settings) { s.Palette = "high-contrast" }, false},
+		{"palette outside themes", func(s *Settings) { s.Palette = "../styles" }, true},
+		{"german", func(s *Settings) { s.Language = "de" }, false},
+		{"unsupported language", func(s *Settings) { s.Language = "fr" }, true},
+		{"time zone", func(s *Settings) { s.TimeZone = "Europe/Berlin" }, false},
+		{"unknown time zone", func(s *Settings) { s.TimeZone = "Mars/Olympus" }, true},
+		{"quiet hours", func(s *Settings) { s.QuietHoursStart = "22:00"; s.QuietHoursEnd = "07:00" }, false},
+		{"quiet hours not a time", func(s *Settings) { s.QuietHoursStart = "10pm" }, true},
+		{"digest time not a time", func(s *Settings) { s.DigestTime = "25:00" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := DefaultSettings()
+			tt.modify(&s)
+			if err := s.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSettingsFormatDate(t *testing.T) {
+	day := time.Date(2025, time.June, 9, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		layout    string
+		wantLong  string
+		wantShort string
+	}{
+		{"Jan 2, 2006", "Jun 9, 2025", "Jun 9"},
+		{"2006-01-02", "2025-06-09", "06-09"},
+		{"02/01/2006", "09/06/2025", "09/06"},
+		{"", "Jun 9, 2025", "Jun 9"}, // settings never loaded
+	}
+	for _, tt := range tests {
+		s := Settings{DateFormat: tt.layout}
+		if got := s.FormatDate(day); got != tt.wantLong {
+			t.Errorf("FormatDate with %q = %q, want %q", tt.layout, got, tt.wantLong)
+		}
+		if got := s.FormatShortDate(day); got != tt.wantShort {
+			t.Errorf("FormatShortDate with %q = %q, want %q", tt.layout, got, tt.wantShort)
+		}
+	}
+}
+
+func TestSettingsStartOfWeek(t *testing.T) {
+	wednesday := time.Date(2025, time.June, 11, 0, 0, 0, 0, time.UTC)
+	sunday := time.Date(2025, time.June, 8, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		weekStart time.Weekday
+		day       time.Time
+		want      time.Time
+	}{
+		{time.Monday, wednesday, time.Date(2025, time.June, 9, 0, 0, 0, 0, time.UTC)},
+		{time.Sunday, wednesday, sunday},
+		{time.Monday, sunday, time.Date(2025, time.June, 2, 0, 0, 0, 0, time.UTC)},
+		{time.Sunday, sunday, sunday},
+	}
+	for _, tt := range tests {
+		s := Settings{WeekStart: tt.weekStart}
+		if got := s.StartOfWeek(tt.day); !got.Equal(tt.want) {
+			t.Errorf("StartOfWeek(%s) with %s start = %s, want %s", tt.day.Format("Mon Jan 2"), tt.weekStart, got.Format("Mon Jan 2"), tt.want.Format("Mon Jan 2"))
+		}
+	}
+}
+
+func TestSettingsInQuietHours(t *testing.T) {
+	at := func(hour, min int) time.Time { return time.Date(2026, time.March, 2, hour, min, 0, 0, time.UTC) }
+	tests := []struct {
+		name       string
+		start, end string
+		t          time.Time
+		want       bool
+	}{
+		{"off", "", "", at(3, 0), false},
+		{"only a start", "22:00", "", at(23, 0), false},
+		{"overnight, late", "22:00", "07:00", at(23, 30), true},
+		{"overnight, early", "22:00", "07:00", at(3, 0), true},
+		{"overnight, at the end", "22:00", "07:00", at(7, 0), false},
+		{"overnight, daytime", "22:00", "07:00", at(12, 0), false},
+		{"same day", "12:00", "14:00", at(13, 0), true},
+		{"same day, after", "12:00", "14:00", at(14, 30), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := DefaultSettings()
+			s.TimeZone = "UTC"
+			s.QuietHoursStart, s.QuietHoursEnd = tt.start, tt.end
+			if got := s.InQuietHours(tt.t); got != tt.want {
+				t.Errorf("InQuietHours(%s) = %v, want %v", tt.t.Format("15:04"), got, tt.want)
+			}
+		})
+	}
+
+	// Quiet hours are read in the chosen time zone: 05:00 UTC is midnight in
+	// New York.
+	s := DefaultSettings()
+	s.TimeZone = "America/New_York"
+	s.QuietHoursStart, s.QuietHoursEnd = "22:00", "07:00"
+	if !s.InQuietHours(time.Date(2026, time.March, 2, 5, 0, 0, 0, time.UTC)) {
+		t.Error("expected 05:00 UTC to be quiet in New York")
+	}
+}
+
+func TestSettingsDigestDue(t *testing.T) {
+	s := DefaultSettings()
+	s.TimeZone = "Asia/Tokyo"
+	s.DigestTime = "08:00"
+
+	// 23:30 UTC on March 1 is 08:30 on March 2 in Tokyo.
+	day, due := s.DigestDue(time.Date(2026, time.March, 1, 23, 30, 0, 0, time.UTC))
+	if day != "2026-03-02" || !due {
+		t.Errorf("expected the March 2 digest to be due, got %s %v", day, due)
+	}
+	if _, due := s.DigestDue(time.Date(2026, time.March, 1, 22, 30, 0, 0, time.UTC)); due {
+		t.Error("expected no digest before 08:00 Tokyo time")
+	}
+}
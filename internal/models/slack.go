@@ -0,0 +1,44 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// SlackWorkspace holds per-workspace settings for the Slack integration.
+type SlackWorkspace struct {
+	TeamID   string `json:"team_id"`
+	TeamName string `json:"team_name"`
+	// WebhookURL is a Slack incoming webhook used for notifications.
+	WebhookURL string `json:"webhook_url,omitempty"`
+	// ProjectID is where quick-added tasks go; nil means the Inbox project.
+	ProjectID     *int64    `json:"project_id,omitempty"`
+	NotifyOverdue bool      `json:"notify_overdue"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Validate checks that the workspace settings are usable.
+func (w *SlackWorkspace) Validate() error {
+	if strings.TrimSpace(w.TeamID) == "" {
+		return invalid("team_id is required")
+	}
+
+	if w.WebhookURL != "" && !strings.HasPrefix(w.WebhookURL, "https://") {
+		return invalid("webhook_url must be an https URL")
+	}
+
+	if w.NotifyOverdue && w.WebhookURL == "" {
+		return invalid("webhook_url is required for overdue notifications")
+	}
+
+	return nil
+}
+
+// QuickAddProjectID returns the configured quick-add project, or 0 for the Inbox.
+func (w *SlackWorkspace) QuickAddProjectID() int64 {
+	if w.ProjectID == nil {
+		return 0
+	}
+	return *w.ProjectID
+}
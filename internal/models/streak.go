@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// DailyCount is how many tasks were completed on one calendar day.
+type DailyCount struct {
+	Day   time.Time `json:"day"`
+	Count int       `json:"count"`
+}
+
+// Streaks summarizes completion history against a daily goal.
+type Streaks struct {
+	// Current counts consecutive days with at least one completion, ending
+	// today. A day with nothing done yet does not break the streak until it
+	// is over, so the run may end yesterday instead.
+	Current   int  `json:"current"`
+	Longest   int  `json:"longest"`
+	Today     int  `json:"today"`
+	DailyGoal int  `json:"daily_goal"`
+	GoalMet   bool `json:"goal_met"`
+}
+
+// ComputeStreaks derives streaks from per-day completion counts sorted by
+// day. Days are compared by calendar date; today is the caller's local day.
+func ComputeStreaks(days []DailyCount, today time.Time, goal int) Streaks {
+	s := Streaks{DailyGoal: goal}
+	todayKey := civilDay(today)
+
+	run := 0
+	var prev time.Time
+	for _, d := range days {
+		if d.Count <= 0 {
+			continue
+		}
+		day := civilDay(d.Day)
+		if run > 0 && day.Equal(prev.AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run = 1
+		}
+		prev = day
+		if run > s.Longest {
+			s.Longest = run
+		}
+		if day.Equal(todayKey) {
+			s.Today = d.Count
+		}
+	}
+
+	if run > 0 && (prev.Equal(todayKey) || prev.Equal(todayKey.AddDate(0, 0, -1))) {
+		s.Current = run
+	}
+	s.GoalMet = goal > 0 && s.Today >= goal
+	return s
+}
+
+// civilDay returns t's calendar date as midnight UTC so days compare equal
+// regardless of the location they were parsed in.
+func civilDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
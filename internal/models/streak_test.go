@@ -0,0 +1,51 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeStreaks(t *testing.T) {
+	today := time.Date(2025, 6, 10, 15, 0, 0, 0, time.Local)
+	day := func(d int, count int) DailyCount {
+		return DailyCount{Day: time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC), Count: count}
+	}
+
+	tests := []struct {
+		name string
+		days []DailyCount
+		goal int
+		want Streaks
+	}{
+		{
+			name: "no history",
+			goal: 3,
+			want: Streaks{DailyGoal: 3},
+		},
+		{
+			name: "streak through today",
+			days: []DailyCount{day(1, 1), day(2, 2), day(8, 1), day(9, 4), day(10, 3)},
+			goal: 3,
+			want: Streaks{Current: 3, Longest: 3, Today: 3, DailyGoal: 3, GoalMet: true},
+		},
+		{
+			name: "nothing yet today keeps yesterday's streak",
+			days: []DailyCount{day(7, 1), day(8, 1), day(9, 1)},
+			goal: 2,
+			want: Streaks{Current: 3, Longest: 3, DailyGoal: 2},
+		},
+		{
+			name: "gap breaks the current streak",
+			days: []DailyCount{day(1, 1), day(2, 1), day(3, 1), day(4, 1), day(8, 5)},
+			goal: 2,
+			want: Streaks{Longest: 4, DailyGoal: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputeStreaks(tt.days, today, tt.goal); got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -1,50 +1,92 @@
 package models
 
 import (
-	"errors"
+	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+// MaxNotesLength is the longest Notes value Validate accepts, in bytes.
+const MaxNotesLength = 255
+
+// MaxWaitingOnLength is the longest WaitingOn value Validate accepts, in
+// characters.
+const MaxWaitingOnLength = 100
+
+// TaskUndoWindow is how long a deleted task can be brought back before the
+// purge job removes it for good.
+const TaskUndoWindow = 30 * time.Second
+
 // Task represents a single task within a project.
 type Task struct {
-	ID          int64      `json:"id"`
-	ProjectID   int64      `json:"project_id"`
-	ProjectName string     `json:"-"`
-	Description string     `json:"description"`
-	Notes       string     `json:"notes,omitempty"`
-	Priority    string     `json:"priority"` // "high", "medium", "low"
-	Status      string     `json:"status"`   // "todo", "in_progress", "done"
-	DueDate     *time.Time `json:"due_date,omitempty"`
-	Completed   bool       `json:"completed"`
-	CompletedAt *time.Time `json:"completed_at,omitempty"`
-	Overdue     bool       `json:"-"`
-	InlineEdit  bool       `json:"-"`
-	SortOrder   int        `json:"sort_order"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID          int64  `json:"id"`
+	ProjectID   int64  `json:"project_id"`
+	ProjectName string `json:"-"`
+	Description string `json:"description"`
+	Notes       string `json:"notes,omitempty"`
+	Priority    string `json:"priority"` // "high", "medium", "low"
+	// EscalatedPriority is set by the escalation job when the due date is
+	// near, and empty otherwise. See EffectivePriority.
+	EscalatedPriority string     `json:"escalated_priority,omitempty"`
+	Status            string     `json:"status"` // "todo", "in_progress", "done"
+	DueDate           *time.Time `json:"due_date,omitempty"`
+	Tags              []string   `json:"tags,omitempty"`
+	Recurrence        string     `json:"recurrence,omitempty"` // e.g. "every monday"; see package nldate
+	Completed         bool       `json:"completed"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+	Overdue           bool       `json:"-"`
+	Untouched         int        `json:"-"`       // calendar days since UpdatedAt, filled in by the store
+	Stale             bool       `json:"-"`       // open and untouched for longer than the stale setting
+	Urgency           int        `json:"urgency"` // see UrgencyOn, filled in by the store
+	InlineEdit        bool       `json:"-"`
+	URL               string     `json:"-"` // link to the task's source, filled in by views that show it
+	SortOrder         int        `json:"sort_order"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	// Revision goes up whenever the task's contents change (not its
+	// position or escalation), so an edit made against an older revision
+	// can be recognized as a conflict.
+	Revision int64 `json:"revision"`
+	// Someday tasks are parked in Someday/Maybe, out of the active lists
+	// until promoted back.
+	Someday bool `json:"someday,omitempty"`
+	// WaitingOn names who a delegated task is waiting on; empty for tasks
+	// of my own. WaitingSince is set by the store when the wait begins.
+	WaitingOn    string     `json:"waiting_on,omitempty"`
+	WaitingSince *time.Time `json:"waiting_since,omitempty"`
 }
 
 // Validate checks that the task has valid field values.
 func (t *Task) Validate() error {
 	if strings.TrimSpace(t.Description) == "" {
-		return errors.New("description is required")
+		return invalid("description is required")
 	}
 
 	if t.ProjectID == 0 {
-		return errors.New("project_id is required")
+		return invalid("project_id is required")
 	}
 
 	if t.Priority != "high" && t.Priority != "medium" && t.Priority != "low" {
-		return errors.New("priority must be 'high', 'medium', or 'low'")
+		return invalid("priority must be 'high', 'medium', or 'low'")
 	}
 
 	if t.Status != "todo" && t.Status != "in_progress" && t.Status != "done" {
-		return errors.New("status must be 'todo', 'in_progress', or 'done'")
+		return invalid("status must be 'todo', 'in_progress', or 'done'")
+	}
+
+	if len(t.Notes) > MaxNotesLength {
+		return invalid("notes must be 255 characters or fewer")
+	}
+
+	if utf8.RuneCountInString(t.WaitingOn) > MaxWaitingOnLength {
+		return invalid("waiting_on must be 100 characters or fewer")
 	}
 
-	if len(t.Notes) > 255 {
-		return errors.New("notes must be 255 characters or fewer")
+	for _, tag := range t.Tags {
+		if tag == "" || strings.ContainsAny(tag, " \t\n") {
+			return invalid("tags must be single words")
+		}
 	}
 
 	return nil
@@ -58,6 +100,64 @@ func (t *Task) IsOverdue() bool {
 	return t.DueDate.Before(time.Now())
 }
 
+// Waiting returns true if the task is delegated and waiting on someone.
+func (t *Task) Waiting() bool {
+	return t.WaitingOn != ""
+}
+
+// WaitingDays counts the calendar days from WaitingSince to now, or 0 when
+// the task isn't waiting.
+func (t *Task) WaitingDays(now time.Time) int {
+	if !t.Waiting() || t.WaitingSince == nil {
+		return 0
+	}
+	since := time.Date(t.WaitingSince.Year(), t.WaitingSince.Month(), t.WaitingSince.Day(), 0, 0, 0, 0, time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return int(today.Sub(since).Hours() / 24)
+}
+
+// IsStale returns true if the task is open and has not been touched for more
+// than staleDays. Untouched must have been filled in by the store.
+func (t *Task) IsStale(staleDays int) bool {
+	return t.Status != "done" && t.Untouched > staleDays
+}
+
+// EffectivePriority returns the higher of Priority and EscalatedPriority.
+func (t *Task) EffectivePriority() string {
+	if t.EscalatedPriority != "" && priorityRank(t.EscalatedPriority) < priorityRank(t.Priority) {
+		return t.EscalatedPriority
+	}
+	return t.Priority
+}
+
+// Escalated returns true if the task's effective priority was raised above
+// the one it was given.
+func (t *Task) Escalated() bool {
+	return t.EffectivePriority() != t.Priority
+}
+
+// UrgencyOn scores how pressing an open task is on the given day, for
+// sorting: 10 points per effective priority level (low 1, medium 2, high 3)
+// plus up to 7 points as the due date nears (7 when due today, 0 from a week
+// out) and 10 when overdue. Done tasks score 0.
+func (t *Task) UrgencyOn(today time.Time) int {
+	if t.Status == "done" {
+		return 0
+	}
+	score := 10 * (4 - priorityRank(t.EffectivePriority()))
+	if t.DueDate != nil {
+		due := time.Date(t.DueDate.Year(), t.DueDate.Month(), t.DueDate.Day(), 0, 0, 0, 0, time.UTC)
+		day := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+		switch days := int(due.Sub(day).Hours() / 24); {
+		case days < 0:
+			score += 10
+		case days < 7:
+			score += 7 - days
+		}
+	}
+	return score
+}
+
 // IsDone returns true if the task status is "done".
 func (t *Task) IsDone() bool {
 	return t.Status == "done"
@@ -66,7 +166,11 @@ func (t *Task) IsDone() bool {
 // PriorityOrder returns a numeric value for sorting by priority.
 // Lower numbers indicate higher priority.
 func (t *Task) PriorityOrder() int {
-	switch t.Priority {
+	return priorityRank(t.Priority)
+}
+
+func priorityRank(priority string) int {
+	switch priority {
 	case "high":
 		return 1
 	case "medium":
@@ -77,3 +181,34 @@ func (t *Task) PriorityOrder() int {
 		return 99
 	}
 }
+
+// NormalizeTags lowercases tags, strips a leading "+", "@" or "#" marker,
+// and removes blanks and duplicates, returning them sorted.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		tag = strings.TrimLeft(tag, "+@#")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// TruncateNotes shortens s to MaxNotesLength bytes without splitting a UTF-8
+// sequence, for notes that come from external sources.
+func TruncateNotes(s string) string {
+	if len(s) <= MaxNotesLength {
+		return s
+	}
+	s = s[:MaxNotesLength]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits Validate enforces on the task page's extras.
+const (
+	MaxCommentLength  = 4000
+	MaxSubtaskLength  = 255
+	MaxAttachmentSize = 10 << 20
+)
+
+// TaskComment is a remark left on a task's page.
+type TaskComment struct {
+	ID        int64     `json:"id"`
+	TaskID    int64     `json:"task_id"`
+	Body      string    `json:"body"` // Markdown
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks that the comment says something and isn't too long.
+func (c *TaskComment) Validate() error {
+	if strings.TrimSpace(c.Body) == "" {
+		return invalid("comment is required")
+	}
+	if len(c.Body) > MaxCommentLength {
+		return invalid("comments must be 4000 characters or fewer")
+	}
+	return nil
+}
+
+// Subtask is one step of a task, ticked off on the task's page.
+type Subtask struct {
+	ID        int64     `json:"id"`
+	TaskID    int64     `json:"task_id"`
+	Title     string    `json:"title"`
+	Done      bool      `json:"done"`
+	SortOrder int       `json:"sort_order"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks that the subtask has a title of a sensible length.
+func (s *Subtask) Validate() error {
+	if strings.TrimSpace(s.Title) == "" {
+		return invalid("title is required")
+	}
+	if len(s.Title) > MaxSubtaskLength {
+		return invalid("subtasks must be 255 characters or fewer")
+	}
+	return nil
+}
+
+// TaskAttachment is a file attached to a task. Data is only loaded by
+// GetTaskAttachment; lists leave it nil.
+type TaskAttachment struct {
+	ID          int64     `json:"id"`
+	TaskID      int64     `json:"task_id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Data        []byte    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Validate checks that the attachment has a name and fits the size limit.
+func (a *TaskAttachment) Validate() error {
+	if strings.TrimSpace(a.Name) == "" {
+		return invalid("file name is required")
+	}
+	if a.Size > MaxAttachmentSize {
+		return invalid("attachments must be 10 MB or smaller")
+	}
+	return nil
+}
+
+// SizeLabel returns the attachment's size for people, e.g. "340 KB".
+func (a TaskAttachment) SizeLabel() string {
+	switch {
+	case a.Size < 1<<10:
+		return strconv.FormatInt(a.Size, 10) + " B"
+	case a.Size < 1<<20:
+		return strconv.FormatInt(a.Size>>10, 10) + " KB"
+	default:
+		return strconv.FormatFloat(float64(a.Size)/(1<<20), 'f', 1, 64) + " MB"
+	}
+}
+
+// TaskHistoryEntry records one field of a task changing. Field is the
+// column name, e.g. "status" or "due_date"; the values are as stored, with
+// due dates as YYYY-MM-DD and projects as IDs.
+type TaskHistoryEntry struct {
+	ID        int64     `json:"id"`
+	TaskID    int64     `json:"task_id"`
+	Field     string    `json:"field"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// TaskCompletion records one time a task was completed. A task reopened and
+// finished again has one per time; CompletedAt is in server local time, and
+// only has the day for tasks completed on an earlier day, e.g. by an import.
+type TaskCompletion struct {
+	ID          int64     `json:"id"`
+	TaskID      int64     `json:"task_id"`
+	CompletedAt time.Time `json:"completed_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTaskDetailValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       interface{ Validate() error }
+		wantErr bool
+	}{
+		{"comment", &TaskComment{Body: "Looks good"}, false},
+		{"blank comment", &TaskComment{Body: "  \n"}, true},
+		{"long comment", &TaskComment{Body: strings.Repeat("a", MaxCommentLength+1)}, true},
+		{"subtask", &Subtask{Title: "Buy paint"}, false},
+		{"blank subtask", &Subtask{Title: " "}, true},
+		{"long subtask", &Subtask{Title: strings.Repeat("a", MaxSubtaskLength+1)}, true},
+		{"attachment", &TaskAttachment{Name: "plan.pdf", Size: 1 << 20}, false},
+		{"unnamed attachment", &TaskAttachment{Size: 10}, true},
+		{"large attachment", &TaskAttachment{Name: "video.mp4", Size: MaxAttachmentSize + 1}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.v.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTaskAttachment_SizeLabel(t *testing.T) {
+	for size, want := range map[int64]string{
+		0:             "0 B",
+		1023:          "1023 B",
+		340 << 10:     "340 KB",
+		3<<20 + 1<<19: "3.5 MB",
+	} {
+		if got := (TaskAttachment{Size: size}).SizeLabel(); got != want {
+			t.Errorf("SizeLabel() for %d = %q, want %q", size, got, want)
+		}
+	}
+}
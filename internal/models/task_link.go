@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Relations a task link can have.
+const (
+	RelationRelated   = "related"
+	RelationDuplicate = "duplicate"
+	RelationBlocks    = "blocks"
+)
+
+// TaskLinkRelations lists the relations in the order forms offer them.
+var TaskLinkRelations = []string{RelationRelated, RelationDuplicate, RelationBlocks}
+
+// TaskLink connects two tasks, as seen from TaskID: the link was made on
+// TaskID unless Inverse is set, in which case it was made on the related
+// task and reads the other way round, e.g. "Blocked by" for "blocks".
+type TaskLink struct {
+	ID            int64     `json:"id"`
+	TaskID        int64     `json:"task_id"`
+	RelatedTaskID int64     `json:"related_task_id"`
+	Relation      string    `json:"relation"`
+	Inverse       bool      `json:"inverse,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	// The related task, filled in when listing a task's links.
+	RelatedDescription string `json:"related_description,omitempty"`
+	RelatedStatus      string `json:"related_status,omitempty"`
+	RelatedProject     string `json:"related_project,omitempty"`
+}
+
+// Validate checks the relation, defaulting it to "related", and that the
+// link joins two different tasks.
+func (l *TaskLink) Validate() error {
+	if l.Relation == "" {
+		l.Relation = RelationRelated
+	}
+	if l.Relation != RelationRelated && l.Relation != RelationDuplicate && l.Relation != RelationBlocks {
+		return invalid("relation must be 'related', 'duplicate', or 'blocks'")
+	}
+	if l.RelatedTaskID == 0 {
+		return invalid("related_task_id is required")
+	}
+	if l.TaskID == l.RelatedTaskID {
+		return invalid("a task can't be linked to itself")
+	}
+	return nil
+}
+
+// Label describes the relation as read from TaskID, e.g. "Blocks" or
+// "Blocked by".
+func (l TaskLink) Label() string {
+	switch {
+	case l.Relation == RelationDuplicate && l.Inverse:
+		return "Duplicated by"
+	case l.Relation == RelationDuplicate:
+		return "Duplicate of"
+	case l.Relation == RelationBlocks && l.Inverse:
+		return "Blocked by"
+	case l.Relation == RelationBlocks:
+		return "Blocks"
+	default:
+		return "Related to"
+	}
+}
@@ -129,6 +129,34 @@ func TestTaskValidation_NotesLength(t *testing.T) {
 	}
 }
 
+func TestTask_WaitingDays(t *testing.T) {
+	now := time.Date(2024, 3, 6, 9, 0, 0, 0, time.UTC)
+	since := time.Date(2024, 3, 1, 17, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		task Task
+		want int
+	}{
+		{"waiting counts calendar days", Task{WaitingOn: "Sam", WaitingSince: &since}, 5},
+		{"not waiting", Task{WaitingSince: &since}, 0},
+		{"no start recorded", Task{WaitingOn: "Sam"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.task.WaitingDays(now); got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+
+	long := Task{Description: "Task", ProjectID: 1, Priority: "medium", Status: "todo", WaitingOn: strings.Repeat("é", MaxWaitingOnLength+1)}
+	if err := long.Validate(); err == nil {
+		t.Error("expected validation error for waiting_on longer than 100 characters")
+	}
+}
+
 func TestTask_IsOverdue(t *testing.T) {
 	yesterday := time.Now().AddDate(0, 0, -1)
 	tomorrow := time.Now().AddDate(0, 0, 1)
@@ -207,3 +235,62 @@ func TestTask_PriorityOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestTruncateNotes_KeepsValidUTF8(t *testing.T) {
+	long := strings.Repeat("a", MaxNotesLength-1) + "ñ"
+	got := TruncateNotes(long)
+	if got != strings.Repeat("a", MaxNotesLength-1) {
+		t.Errorf("expected split rune to be dropped, got length %d", len(got))
+	}
+	if TruncateNotes("short") != "short" {
+		t.Error("expected short notes to be unchanged")
+	}
+}
+
+func TestTask_EffectivePriority(t *testing.T) {
+	tests := []struct {
+		priority, escalated string
+		want                string
+	}{
+		{"low", "", "low"},
+		{"low", "medium", "medium"},
+		{"medium", "high", "high"},
+		// Raised by hand above the escalation.
+		{"high", "medium", "high"},
+	}
+	for _, tt := range tests {
+		task := Task{Priority: tt.priority, EscalatedPriority: tt.escalated}
+		if got := task.EffectivePriority(); got != tt.want {
+			t.Errorf("%s escalated to %q: got %s, want %s", tt.priority, tt.escalated, got, tt.want)
+		}
+		if task.Escalated() != (tt.want != tt.priority) {
+			t.Errorf("%s escalated to %q: unexpected Escalated() %v", tt.priority, tt.escalated, task.Escalated())
+		}
+	}
+}
+
+func TestTask_UrgencyOn(t *testing.T) {
+	today := time.Date(2024, time.June, 19, 15, 0, 0, 0, time.Local)
+	due := func(days int) *time.Time {
+		d := time.Date(2024, time.June, 19+days, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	tests := []struct {
+		name string
+		task Task
+		want int
+	}{
+		{"undated low", Task{Priority: "low", Status: "todo"}, 10},
+		{"high due next month", Task{Priority: "high", Status: "todo", DueDate: due(30)}, 30},
+		{"medium due today", Task{Priority: "medium", Status: "todo", DueDate: due(0)}, 27},
+		{"medium due in 3 days", Task{Priority: "medium", Status: "in_progress", DueDate: due(3)}, 24},
+		{"escalated to high, due tomorrow", Task{Priority: "medium", EscalatedPriority: "high", Status: "todo", DueDate: due(1)}, 36},
+		{"low overdue", Task{Priority: "low", Status: "todo", DueDate: due(-5)}, 20},
+		{"done", Task{Priority: "high", Status: "done", DueDate: due(-1)}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.task.UrgencyOn(today); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
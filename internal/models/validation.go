@@ -0,0 +1,16 @@
+package models
+
+// ValidationError is returned by the Validate methods. Its message describes
+// the problem in terms a user can act on.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// invalid returns a ValidationError with message.
+func invalid(message string) error {
+	return &ValidationError{Message: message}
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate_ReturnsValidationError(t *testing.T) {
+	task := &Task{ProjectID: 1, Priority: "high", Status: "todo"}
+	var ve *ValidationError
+	if err := task.Validate(); !errors.As(err, &ve) || ve.Message != "description is required" {
+		t.Errorf("Validate() = %v, want a ValidationError about the description", err)
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Webhook delivery statuses.
+const (
+	WebhookPending   = "pending"   // not delivered yet; retried at NextAttemptAt
+	WebhookDelivered = "delivered" // the last attempt succeeded
+	WebhookFailed    = "failed"    // every attempt failed, or a redelivery did
+)
+
+// WebhookDelivery is a payload sent to an outgoing webhook, such as a Slack
+// overdue alert, kept with its attempts so failures can be retried and
+// replayed.
+type WebhookDelivery struct {
+	ID int64
+	// Target names what the webhook belongs to, e.g. "slack:T0123".
+	Target string
+	// Event describes what the payload announces, e.g. "overdue alert".
+	Event   string
+	URL     string
+	Payload string // JSON
+	Status  string // one of the webhook delivery statuses
+	// NextAttemptAt is when a pending delivery is retried.
+	NextAttemptAt *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	// Attempts lists the tries so far, oldest first.
+	Attempts []WebhookAttempt
+}
+
+// LastAttempt returns the most recent attempt, or nil before the first.
+func (d WebhookDelivery) LastAttempt() *WebhookAttempt {
+	if len(d.Attempts) == 0 {
+		return nil
+	}
+	return &d.Attempts[len(d.Attempts)-1]
+}
+
+// WebhookAttempt is one try at a WebhookDelivery.
+type WebhookAttempt struct {
+	ID         int64
+	DeliveryID int64
+	At         time.Time
+	// StatusCode is the response's, or 0 when there was none.
+	StatusCode int
+	Latency    time.Duration
+	// Response is the start of the response body.
+	Response string
+	// Error says why the request failed, when it did.
+	Error string
+}
+
+// OK reports whether the attempt succeeded.
+func (a *WebhookAttempt) OK() bool {
+	return a.Error == "" && a.StatusCode >= 200 && a.StatusCode < 300
+}
@@ -0,0 +1,8 @@
+package models
+
+// WorkspaceLink is an entry of the sidebar's workspace switcher.
+type WorkspaceLink struct {
+	Name    string // empty for the main workspace, served at the root
+	URL     string
+	Current bool
+}
@@ -0,0 +1,204 @@
+// Package nldate reads due dates and repeat rules written in plain English at
+// the end of a task description, such as "pay rent tomorrow", "review PR
+// next friday" or "call mom every monday".
+package nldate
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPhraseWords bounds how many trailing words Parse considers.
+const maxPhraseWords = 4
+
+// Result is what Parse found in a task description.
+type Result struct {
+	// Text is the description with the date phrase removed.
+	Text string
+	// Due is the date the phrase names, as midnight UTC, or nil.
+	Due *time.Time
+	// Recurrence is the canonical repeat rule, e.g. "every monday", or "".
+	Recurrence string
+}
+
+// Parse looks for a date phrase at the end of text, optionally introduced by
+// "on", "by" or "due". now supplies the current calendar day. When nothing
+// matches, or the phrase is all there is, Text is text unchanged.
+//
+// Bare weekdays mean the next such day after today; "next friday" means
+// Friday of the following (Monday-based) week. Repeat rules set Due to their
+// first occurrence on or after today.
+func Parse(text string, now time.Time) Result {
+	words := strings.Fields(text)
+	today := day(now)
+
+	for k := min(maxPhraseWords, len(words)-1); k >= 1; k-- {
+		phrase := make([]string, k)
+		for i, w := range words[len(words)-k:] {
+			phrase[i] = strings.ToLower(strings.TrimRight(w, ".,;:!?"))
+		}
+
+		due, rule, ok := parsePhrase(phrase, today)
+		if !ok {
+			continue
+		}
+
+		rest := words[:len(words)-k]
+		if len(rest) > 1 {
+			switch strings.ToLower(rest[len(rest)-1]) {
+			case "on", "by", "due":
+				rest = rest[:len(rest)-1]
+			}
+		}
+		return Result{Text: strings.Join(rest, " "), Due: &due, Recurrence: rule}
+	}
+
+	return Result{Text: text}
+}
+
+func parsePhrase(p []string, today time.Time) (time.Time, string, bool) {
+	if p[0] == "every" || len(p) == 1 {
+		if r, ok := parseRule(p); ok {
+			return r.first(today), r.String(), true
+		}
+	}
+
+	switch len(p) {
+	case 1:
+		switch p[0] {
+		case "today", "tonight", "tod":
+			return today, "", true
+		case "tomorrow", "tmrw", "tmr":
+			return today.AddDate(0, 0, 1), "", true
+		}
+		if wd, ok := weekdays[p[0]]; ok {
+			return nextWeekday(today, wd), "", true
+		}
+		if t, err := time.Parse("2006-01-02", p[0]); err == nil {
+			return t, "", true
+		}
+
+	case 2:
+		switch {
+		case p[0] == "next" && p[1] == "week":
+			return nextWeekday(today, time.Monday), "", true
+		case p[0] == "next" && p[1] == "month":
+			return time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, time.UTC), "", true
+		case p[0] == "next":
+			if wd, ok := weekdays[p[1]]; ok {
+				monday := nextWeekday(today, time.Monday)
+				return monday.AddDate(0, 0, (int(wd)+6)%7), "", true
+			}
+		case p[0] == "this":
+			if wd, ok := weekdays[p[1]]; ok {
+				return onOrAfter(today, wd), "", true
+			}
+		}
+		if t, ok := monthDay(p[0], p[1], today); ok {
+			return t, "", true
+		}
+		if t, ok := monthDay(p[1], p[0], today); ok {
+			return t, "", true
+		}
+
+	case 3:
+		if p[0] == "in" {
+			if n, ok := count(p[1]); ok {
+				switch strings.TrimSuffix(p[2], "s") {
+				case "day":
+					return today.AddDate(0, 0, n), "", true
+				case "week":
+					return today.AddDate(0, 0, 7*n), "", true
+				case "month":
+					return addMonths(today, n), "", true
+				}
+			}
+		}
+	}
+
+	return time.Time{}, "", false
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+var months = map[string]time.Month{
+	"january": time.January, "jan": time.January,
+	"february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March,
+	"april": time.April, "apr": time.April,
+	"may":  time.May,
+	"june": time.June, "jun": time.June,
+	"july": time.July, "jul": time.July,
+	"august": time.August, "aug": time.August,
+	"september": time.September, "sep": time.September, "sept": time.September,
+	"october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November,
+	"december": time.December, "dec": time.December,
+}
+
+// monthDay reads "jun 30" style dates, rolling into next year once the date
+// has passed.
+func monthDay(month, dayOfMonth string, today time.Time) (time.Time, bool) {
+	m, ok := months[month]
+	if !ok {
+		return time.Time{}, false
+	}
+	d, err := strconv.Atoi(strings.TrimRight(dayOfMonth, "stndrh"))
+	if err != nil || d < 1 || d > 31 {
+		return time.Time{}, false
+	}
+
+	t := time.Date(today.Year(), m, d, 0, 0, 0, 0, time.UTC)
+	if t.Month() != m {
+		return time.Time{}, false // e.g. feb 30
+	}
+	if t.Before(today) {
+		t = time.Date(today.Year()+1, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	return t, true
+}
+
+// count reads a small positive number written as digits or "a"/"one".
+func count(s string) (int, bool) {
+	switch s {
+	case "a", "an", "one":
+		return 1, true
+	case "two":
+		return 2, true
+	case "three":
+		return 3, true
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n > 0
+}
+
+// day returns t's calendar date as midnight UTC.
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// nextWeekday returns the first wd strictly after t.
+func nextWeekday(t time.Time, wd time.Weekday) time.Time {
+	return onOrAfter(t.AddDate(0, 0, 1), wd)
+}
+
+// onOrAfter returns t if it falls on wd, otherwise the next wd.
+func onOrAfter(t time.Time, wd time.Weekday) time.Time {
+	return t.AddDate(0, 0, (int(wd)-int(t.Weekday())+7)%7)
+}
+
+// addMonths adds n months, clamping to the end of shorter months.
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
@@ -0,0 +1,123 @@
+package nldate
+
+import (
+	"testing"
+	"time"
+)
+
+// now is Wednesday 11 June 2025, mid-afternoon local time.
+var now = time.Date(2025, 6, 11, 15, 30, 0, 0, time.Local)
+
+func date(year int, month time.Month, d int) *time.Time {
+	t := time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		text string
+		due  *time.Time
+		rule string
+	}{
+		{"pay rent tomorrow", "pay rent", date(2025, 6, 12), ""},
+		{"pay rent Tomorrow.", "pay rent", date(2025, 6, 12), ""},
+		{"ship it today", "ship it", date(2025, 6, 11), ""},
+		{"review PR friday", "review PR", date(2025, 6, 13), ""},
+		{"review PR on Fri", "review PR", date(2025, 6, 13), ""},
+		{"review PR next friday", "review PR", date(2025, 6, 20), ""},
+		{"standup wednesday", "standup", date(2025, 6, 18), ""},
+		{"standup this wednesday", "standup", date(2025, 6, 11), ""},
+		{"plan next week", "plan", date(2025, 6, 16), ""},
+		{"invoice next month", "invoice", date(2025, 7, 1), ""},
+		{"renew passport in 3 weeks", "renew passport", date(2025, 7, 2), ""},
+		{"call back in a day", "call back", date(2025, 6, 12), ""},
+		{"close books in 1 month", "close books", date(2025, 7, 11), ""},
+		{"dentist jun 30", "dentist", date(2025, 6, 30), ""},
+		{"dentist by 3rd march", "dentist", date(2026, 3, 3), ""},
+		{"taxes due 2025-04-15", "taxes", date(2025, 4, 15), ""},
+		{"call mom every monday", "call mom", date(2025, 6, 16), "every monday"},
+		{"water plants every wednesday", "water plants", date(2025, 6, 11), "every wednesday"},
+		{"stretch daily", "stretch", date(2025, 6, 11), "every day"},
+		{"backups every 2 weeks", "backups", date(2025, 6, 11), "every 2 weeks"},
+		{"inbox zero every weekday", "inbox zero", date(2025, 6, 11), "every weekday"},
+
+		// Not dates, or nothing left once the date is removed.
+		{"buy a new monitor", "buy a new monitor", nil, ""},
+		{"tomorrow", "tomorrow", nil, ""},
+		{"dentist feb 30", "dentist feb 30", nil, ""},
+		{"every day counts", "every day counts", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := Parse(tt.in, now)
+			if got.Text != tt.text {
+				t.Errorf("text: expected %q, got %q", tt.text, got.Text)
+			}
+			switch {
+			case tt.due == nil && got.Due != nil:
+				t.Errorf("due: expected none, got %s", got.Due.Format("2006-01-02"))
+			case tt.due != nil && (got.Due == nil || !got.Due.Equal(*tt.due)):
+				t.Errorf("due: expected %s, got %v", tt.due.Format("2006-01-02"), got.Due)
+			}
+			if got.Recurrence != tt.rule {
+				t.Errorf("rule: expected %q, got %q", tt.rule, got.Recurrence)
+			}
+		})
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"", "", true},
+		{"Daily", "every day", true},
+		{"weekly", "every week", true},
+		{"every Mon", "every monday", true},
+		{"every other week", "every 2 weeks", true},
+		{"every 3 months", "every 3 months", true},
+		{"every weekday", "every weekday", true},
+		{"every blue moon", "", false},
+		{"sometimes", "", false},
+	}
+	for _, tt := range tests {
+		got, err := ParseRule(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseRule(%q) = %q, %v; want %q, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		rule  string
+		after *time.Time
+		want  *time.Time
+	}{
+		{"every day", date(2025, 6, 11), date(2025, 6, 12)},
+		{"every monday", date(2025, 6, 16), date(2025, 6, 23)},
+		{"every monday", date(2025, 6, 11), date(2025, 6, 16)},
+		{"every weekday", date(2025, 6, 13), date(2025, 6, 16)},
+		{"every 2 weeks", date(2025, 6, 11), date(2025, 6, 25)},
+		{"every month", date(2025, 1, 31), date(2025, 2, 28)},
+		{"every year", date(2024, 2, 29), date(2025, 2, 28)},
+	}
+	for _, tt := range tests {
+		got, err := Next(tt.rule, *tt.after)
+		if err != nil {
+			t.Errorf("Next(%q): %v", tt.rule, err)
+			continue
+		}
+		if !got.Equal(*tt.want) {
+			t.Errorf("Next(%q, %s) = %s, want %s", tt.rule, tt.after.Format("2006-01-02"), got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+
+	if _, err := Next("whenever", now); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+}
@@ -0,0 +1,144 @@
+package nldate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rule is a parsed repeat rule: every n units, or every given weekday.
+type rule struct {
+	n       int
+	unit    string // "day", "weekday", "week", "month", "year"
+	weekday time.Weekday
+	// onWeekday is set for rules like "every monday".
+	onWeekday bool
+}
+
+// ParseRule normalizes a repeat rule such as "daily", "Every Mon" or
+// "every 2 weeks" to its canonical form. An empty rule is returned as is.
+func ParseRule(s string) (string, error) {
+	words := strings.Fields(strings.ToLower(s))
+	if len(words) == 0 {
+		return "", nil
+	}
+	r, ok := parseRule(words)
+	if !ok {
+		return "", fmt.Errorf("unrecognized repeat rule %q", s)
+	}
+	return r.String(), nil
+}
+
+// Next returns the first occurrence of rule strictly after the given day.
+func Next(ruleText string, after time.Time) (time.Time, error) {
+	r, ok := parseRule(strings.Fields(strings.ToLower(ruleText)))
+	if !ok {
+		return time.Time{}, fmt.Errorf("unrecognized repeat rule %q", ruleText)
+	}
+	return r.next(day(after)), nil
+}
+
+func parseRule(words []string) (rule, bool) {
+	if len(words) == 1 {
+		switch words[0] {
+		case "daily":
+			return rule{n: 1, unit: "day"}, true
+		case "weekdays":
+			return rule{n: 1, unit: "weekday"}, true
+		case "weekly":
+			return rule{n: 1, unit: "week"}, true
+		case "monthly":
+			return rule{n: 1, unit: "month"}, true
+		case "yearly", "annually":
+			return rule{n: 1, unit: "year"}, true
+		}
+		return rule{}, false
+	}
+
+	if words[0] != "every" {
+		return rule{}, false
+	}
+	words = words[1:]
+
+	n := 1
+	if len(words) == 2 {
+		if words[0] == "other" {
+			n = 2
+		} else if v, ok := count(words[0]); ok {
+			n = v
+		} else {
+			return rule{}, false
+		}
+		words = words[1:]
+	}
+	if len(words) != 1 {
+		return rule{}, false
+	}
+
+	unit := words[0]
+	if n == 1 {
+		if wd, ok := weekdays[unit]; ok {
+			return rule{n: 1, unit: "week", weekday: wd, onWeekday: true}, true
+		}
+		if unit == "weekday" {
+			return rule{n: 1, unit: "weekday"}, true
+		}
+	}
+	switch strings.TrimSuffix(unit, "s") {
+	case "day", "week", "month", "year":
+		return rule{n: n, unit: strings.TrimSuffix(unit, "s")}, true
+	}
+	return rule{}, false
+}
+
+// String returns the canonical form, e.g. "every day", "every 2 weeks" or
+// "every friday".
+func (r rule) String() string {
+	switch {
+	case r.onWeekday:
+		return "every " + strings.ToLower(r.weekday.String())
+	case r.n == 1:
+		return "every " + r.unit
+	default:
+		return "every " + strconv.Itoa(r.n) + " " + r.unit + "s"
+	}
+}
+
+// first returns the rule's first occurrence on or after today.
+func (r rule) first(today time.Time) time.Time {
+	switch {
+	case r.onWeekday:
+		return onOrAfter(today, r.weekday)
+	case r.unit == "weekday" && isWeekend(today):
+		return onOrAfter(today, time.Monday)
+	default:
+		return today
+	}
+}
+
+// next returns the rule's first occurrence strictly after the given day.
+func (r rule) next(after time.Time) time.Time {
+	switch {
+	case r.onWeekday:
+		return nextWeekday(after, r.weekday)
+	case r.unit == "weekday":
+		t := after.AddDate(0, 0, 1)
+		for isWeekend(t) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t
+	case r.unit == "week":
+		return after.AddDate(0, 0, 7*r.n)
+	case r.unit == "month":
+		return addMonths(after, r.n)
+	case r.unit == "year":
+		return addMonths(after, 12*r.n)
+	default:
+		return after.AddDate(0, 0, r.n)
+	}
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
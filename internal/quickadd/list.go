@@ -0,0 +1,46 @@
+package quickadd
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Item is one line of a pasted list.
+type Item struct {
+	Text string
+	// Checked is set for Markdown checklist items already ticked, "- [x] ...".
+	Checked bool
+}
+
+var (
+	listMarker = regexp.MustCompile(`^(?:[-*+•]|\d+[.)])\s+`)
+	checkbox   = regexp.MustCompile(`^\[([ xX]?)\]\s*`)
+)
+
+// ParseList splits pasted text into one item per line, stripping bullets,
+// numbering and Markdown checkboxes. Blank lines and Markdown headings
+// ("# Groceries") are dropped; a bare "- [ ]" yields an item with no text.
+func ParseList(text string) []Item {
+	var items []Item
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || isHeading(line) {
+			continue
+		}
+
+		line = listMarker.ReplaceAllString(line, "")
+		var item Item
+		if m := checkbox.FindStringSubmatch(line); m != nil {
+			item.Checked = strings.EqualFold(m[1], "x")
+			line = line[len(m[0]):]
+		}
+		item.Text = strings.TrimSpace(line)
+		items = append(items, item)
+	}
+	return items
+}
+
+func isHeading(line string) bool {
+	hashes := len(line) - len(strings.TrimLeft(line, "#"))
+	return hashes > 0 && (hashes == len(line) || line[hashes] == ' ')
+}
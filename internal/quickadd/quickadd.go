@@ -0,0 +1,80 @@
+// Package quickadd parses the single-line capture syntax, where
+// "#Household !high @errands buy filters" names the project, priority and
+// tags alongside the task description.
+package quickadd
+
+import (
+	"strings"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/nldate"
+)
+
+// Entry is what Parse found in a line of capture text.
+type Entry struct {
+	// Description is the text left once markers and any date phrase are removed.
+	Description string
+	// Project is the name written after "#", or "" when none was given.
+	Project string
+	// Priority is "high", "medium" or "low", or "" when none was given.
+	Priority string
+	// Tags are the "@" markers, normalized.
+	Tags []string
+	// Due and Recurrence come from a trailing date phrase; see nldate.Parse.
+	Due        *time.Time
+	Recurrence string
+}
+
+var priorities = map[string]string{
+	"high": "high", "h": "high", "1": "high",
+	"medium": "medium", "med": "medium", "m": "medium", "2": "medium",
+	"low": "low", "l": "low", "3": "low",
+}
+
+// Parse reads markers anywhere in text: "#project" (the first one wins),
+// "!priority" (high/h/1, medium/med/m/2, low/l/3) and "@tag". Words that
+// only look like markers, such as "!" or "!wow", stay in the description.
+func Parse(text string, now time.Time) Entry {
+	var entry Entry
+	var words, tags []string
+
+	for _, w := range strings.Fields(text) {
+		switch {
+		case len(w) > 1 && w[0] == '#' && entry.Project == "":
+			entry.Project = w[1:]
+		case len(w) > 1 && w[0] == '!' && priorities[strings.ToLower(w[1:])] != "":
+			entry.Priority = priorities[strings.ToLower(w[1:])]
+		case len(w) > 1 && w[0] == '@':
+			tags = append(tags, w)
+		default:
+			words = append(words, w)
+		}
+	}
+	if len(tags) > 0 {
+		entry.Tags = models.NormalizeTags(tags)
+	}
+
+	parsed := nldate.Parse(strings.Join(words, " "), now)
+	entry.Description = parsed.Text
+	entry.Due = parsed.Due
+	entry.Recurrence = parsed.Recurrence
+	return entry
+}
+
+// MatchProject finds the project a "#name" marker refers to. Names compare
+// case-insensitively and ignoring spaces, hyphens and underscores, so
+// "#home-office" matches "Home Office". It returns nil when nothing matches.
+func MatchProject(name string, projects []models.Project) *models.Project {
+	key := projectKey(name)
+	for i := range projects {
+		if projectKey(projects[i].Name) == key {
+			return &projects[i]
+		}
+	}
+	return nil
+}
+
+func projectKey(name string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(name))
+}
@@ -0,0 +1,85 @@
+package quickadd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"mytasks/internal/models"
+)
+
+func TestParse(t *testing.T) {
+	// Wednesday 11 June 2025.
+	now := time.Date(2025, 6, 11, 9, 0, 0, 0, time.UTC)
+	tomorrow := time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		text string
+		want Entry
+	}{
+		{"#Household !high @errands buy filters", Entry{Description: "buy filters", Project: "Household", Priority: "high", Tags: []string{"errands"}}},
+		{"buy filters #Household @Errands @home !l", Entry{Description: "buy filters", Project: "Household", Priority: "low", Tags: []string{"errands", "home"}}},
+		{"!2 call the bank tomorrow", Entry{Description: "call the bank", Priority: "medium", Due: &tomorrow}},
+		{"#Work #urgent fix build", Entry{Description: "#urgent fix build", Project: "Work"}},
+		{"say hi ! !wow # @", Entry{Description: "say hi ! !wow # @"}},
+		{"plain task", Entry{Description: "plain task"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := Parse(tt.text, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchProject(t *testing.T) {
+	projects := []models.Project{{ID: 1, Name: "Household"}, {ID: 2, Name: "Home Office"}}
+
+	tests := []struct {
+		name string
+		want int64
+	}{
+		{"household", 1},
+		{"home-office", 2},
+		{"Home_Office", 2},
+		{"garden", 0},
+	}
+	for _, tt := range tests {
+		var got int64
+		if p := MatchProject(tt.name, projects); p != nil {
+			got = p.ID
+		}
+		if got != tt.want {
+			t.Errorf("MatchProject(%q) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseList(t *testing.T) {
+	text := "# Groceries\r\n" +
+		"- [ ] milk\n" +
+		"* [x] eggs\n" +
+		"\n" +
+		"  + bread  \n" +
+		"1. butter\n" +
+		"2) jam\n" +
+		"- [ ]\n" +
+		"#Household call plumber\n" +
+		"-5 degrees tonight\n"
+
+	want := []Item{
+		{Text: "milk"},
+		{Text: "eggs", Checked: true},
+		{Text: "bread"},
+		{Text: "butter"},
+		{Text: "jam"},
+		{Text: ""},
+		{Text: "#Household call plumber"},
+		{Text: "-5 degrees tonight"},
+	}
+	if got := ParseList(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseList() = %+v, want %+v", got, want)
+	}
+}
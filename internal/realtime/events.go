@@ -0,0 +1,37 @@
+package realtime
+
+import (
+	"context"
+
+	"mytasks/internal/events"
+)
+
+// HandleEvent translates domain events into client-facing change events.
+// It is meant to be registered with events.Bus.Subscribe.
+func (h *Hub) HandleEvent(_ context.Context, e events.Event) {
+	switch e.Type {
+	case events.TaskCreated:
+		h.Broadcast(Event{Type: "created", Entity: "task", ID: e.TaskID, ProjectID: e.ProjectID, Payload: e.Task, Origin: e.Origin})
+	case events.TaskUpdated, events.TaskCompleted, events.TaskReopened:
+		h.Broadcast(Event{Type: "updated", Entity: "task", ID: e.TaskID, ProjectID: e.ProjectID, Payload: e.Task, Origin: e.Origin})
+	case events.TaskMoved:
+		h.Broadcast(Event{Type: "deleted", Entity: "task", ID: e.TaskID, ProjectID: e.PreviousProjectID, Origin: e.Origin})
+		h.Broadcast(Event{Type: "created", Entity: "task", ID: e.TaskID, ProjectID: e.ProjectID, Payload: e.Task, Origin: e.Origin})
+	case events.TaskDeleted:
+		h.Broadcast(Event{Type: "deleted", Entity: "task", ID: e.TaskID, ProjectID: e.ProjectID, Origin: e.Origin})
+	case events.TasksReordered:
+		h.Broadcast(Event{Type: "reordered", Entity: "task", ProjectID: e.ProjectID, Status: e.Status, IDs: e.IDs, Origin: e.Origin})
+	case events.ProjectCreated:
+		h.Broadcast(Event{Type: "created", Entity: "project", ID: e.ProjectID, Payload: e.Project, Origin: e.Origin})
+	case events.ProjectUpdated, events.ProjectCompleted, events.ProjectReopened:
+		h.Broadcast(Event{Type: "updated", Entity: "project", ID: e.ProjectID, Payload: e.Project, Origin: e.Origin})
+	case events.ProjectDeleted:
+		h.Broadcast(Event{Type: "deleted", Entity: "project", ID: e.ProjectID, Origin: e.Origin})
+	case events.ProjectsReordered:
+		h.Broadcast(Event{Type: "reordered", Entity: "project", IDs: e.IDs, Origin: e.Origin})
+	case events.FocusStarted:
+		h.Broadcast(Event{Type: "started", Entity: "focus", ID: e.TaskID, Origin: e.Origin})
+	case events.FocusStopped:
+		h.Broadcast(Event{Type: "stopped", Entity: "focus", ID: e.TaskID, Origin: e.Origin})
+	}
+}
@@ -0,0 +1,130 @@
+package realtime
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// Event describes a change pushed to connected clients.
+type Event struct {
+	Type      string      `json:"type"`   // "created", "updated", "deleted", "reordered"; "started", "stopped" for focus
+	Entity    string      `json:"entity"` // "task", "project" or "focus"
+	ID        int64       `json:"id,omitempty"`
+	ProjectID int64       `json:"project_id,omitempty"`
+	IDs       []int64     `json:"ids,omitempty"`
+	Status    string      `json:"status,omitempty"`
+	Payload   interface{} `json:"payload,omitempty"`
+	Origin    string      `json:"origin,omitempty"`
+}
+
+// subscriberBuffer is the number of pending messages a subscriber may queue
+// before it is considered too slow and dropped.
+const subscriberBuffer = 32
+
+// Subscription receives encoded events for a single connected client.
+type Subscription struct {
+	// ProjectID limits delivery to events for one project. Zero receives everything.
+	ProjectID int64
+	C         chan []byte
+}
+
+// Hub fans out events to all current subscriptions.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+	// ownHost, if set, decides which Origin hosts may connect; see Upgrade.
+	ownHost func(host, requestHost string) bool
+}
+
+// NewHub creates an empty Hub.
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*Subscription]struct{})}
+}
+
+// SetOwnHost sets the check of the Origin host of connections, for pages
+// also served under other hosts, such as those of a reverse proxy. By default
+// only the host requested is accepted.
+func (h *Hub) SetOwnHost(ownHost func(host, requestHost string) bool) {
+	h.ownHost = ownHost
+}
+
+// Subscribe registers a new subscription filtered to projectID (0 for all projects).
+func (h *Hub) Subscribe(projectID int64) *Subscription {
+	sub := &Subscription{ProjectID: projectID, C: make(chan []byte, subscriberBuffer)}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe removes the subscription and closes its channel.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.C)
+	}
+}
+
+// ServeWS upgrades the request to a WebSocket and streams events for projectID
+// until either side closes the connection.
+func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request, projectID int64) {
+	conn, err := Upgrade(w, r, h.ownHost)
+	if err != nil {
+		return
+	}
+
+	sub := h.Subscribe(projectID)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.ReadLoop()
+	}()
+
+	defer conn.Close()
+	defer h.Unsubscribe(sub)
+	for {
+		select {
+		case msg, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err := conn.WriteText(msg); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// Broadcast sends the event to every matching subscription. Subscribers whose
+// buffers are full are dropped rather than blocking the caller.
+func (h *Hub) Broadcast(event Event) {
+	if h == nil {
+		return
+	}
+
+	msg, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("realtime: failed to encode event", "err", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		if sub.ProjectID != 0 && event.ProjectID != 0 && sub.ProjectID != event.ProjectID {
+			continue
+		}
+		select {
+		case sub.C <- msg:
+		default:
+			delete(h.subs, sub)
+			close(sub.C)
+		}
+	}
+}
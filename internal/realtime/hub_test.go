@@ -0,0 +1,262 @@
+package realtime
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHub_BroadcastFiltersByProject(t *testing.T) {
+	hub := NewHub()
+	all := hub.Subscribe(0)
+	p1 := hub.Subscribe(1)
+	p2 := hub.Subscribe(2)
+
+	hub.Broadcast(Event{Type: "created", Entity: "task", ID: 10, ProjectID: 1})
+
+	for name, sub := range map[string]*Subscription{"all": all, "p1": p1} {
+		select {
+		case msg := <-sub.C:
+			var ev Event
+			if err := json.Unmarshal(msg, &ev); err != nil {
+				t.Fatalf("%s: failed to decode event: %v", name, err)
+			}
+			if ev.ID != 10 || ev.Type != "created" {
+				t.Errorf("%s: unexpected event %+v", name, ev)
+			}
+		default:
+			t.Errorf("%s: expected event to be delivered", name)
+		}
+	}
+
+	select {
+	case msg := <-p2.C:
+		t.Errorf("expected project 2 subscriber to be skipped, got %s", msg)
+	default:
+	}
+}
+
+func TestHub_DropsSlowSubscribers(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe(0)
+
+	for i := 0; i < subscriberBuffer+1; i++ {
+		hub.Broadcast(Event{Type: "updated", Entity: "task", ID: int64(i)})
+	}
+
+	count := 0
+	for range sub.C {
+		count++
+	}
+	if count != subscriberBuffer {
+		t.Errorf("expected %d buffered events before drop, got %d", subscriberBuffer, count)
+	}
+}
+
+func TestAcceptKey(t *testing.T) {
+	// Example from RFC 6455 section 1.3.
+	got := AcceptKey("dGhlIHNhbXBsZSBub25jZQ==")
+	if got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("unexpected accept key %q", got)
+	}
+}
+
+func TestEncodeFrame_ExtendedLength(t *testing.T) {
+	payload := bytes.Repeat([]byte("a"), 300)
+	frame := encodeFrame(opText, payload)
+	if frame[0] != 0x81 || frame[1] != 126 {
+		t.Fatalf("unexpected frame header % x", frame[:2])
+	}
+	if len(frame) != 4+len(payload) {
+		t.Errorf("expected frame length %d, got %d", 4+len(payload), len(frame))
+	}
+}
+
+func TestServeWS_StreamsEvents(t *testing.T) {
+	hub := NewHub()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeWS(w, r, 0)
+	}))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req := "GET / HTTP/1.1\r\nHost: " + strings.TrimPrefix(srv.URL, "http://") + "\r\n" +
+		"Connection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatalf("write handshake failed: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("read handshake failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+
+	// Wait for the subscription to register before broadcasting.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		hub.mu.Lock()
+		n := len(hub.subs)
+		hub.mu.Unlock()
+		if n == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	hub.Broadcast(Event{Type: "deleted", Entity: "task", ID: 7})
+
+	var head [2]byte
+	if _, err := io.ReadFull(reader, head[:]); err != nil {
+		t.Fatalf("read frame failed: %v", err)
+	}
+	if head[0] != 0x81 {
+		t.Fatalf("expected text frame, got % x", head[0])
+	}
+	payload := make([]byte, int(head[1]))
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		t.Fatalf("read payload failed: %v", err)
+	}
+	if !strings.Contains(string(payload), `"type":"deleted"`) {
+		t.Errorf("unexpected payload %s", payload)
+	}
+}
+
+func TestServeWS_ChecksOrigin(t *testing.T) {
+	hub := NewHub()
+	hub.SetOwnHost(func(host, requestHost string) bool {
+		return strings.EqualFold(host, requestHost) || host == "tasks.example.org"
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeWS(w, r, 0)
+	}))
+	defer srv.Close()
+
+	handshake := func(origin string) int {
+		t.Helper()
+		conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		req := "GET / HTTP/1.1\r\nHost: " + strings.TrimPrefix(srv.URL, "http://") + "\r\n" +
+			"Connection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\n" +
+			"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nOrigin: " + origin + "\r\n\r\n"
+		if _, err := conn.Write([]byte(req)); err != nil {
+			t.Fatalf("write handshake failed: %v", err)
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			t.Fatalf("read handshake failed: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	if code := handshake("https://tasks.example.org"); code != http.StatusSwitchingProtocols {
+		t.Errorf("external host: status %d, want 101", code)
+	}
+	if code := handshake("https://evil.example.com"); code != http.StatusForbidden {
+		t.Errorf("other host: status %d, want 403", code)
+	}
+}
+
+func TestServeWS_AnswersPingsWhileStreaming(t *testing.T) {
+	hub := NewHub()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeWS(w, r, 0)
+	}))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	req := "GET / HTTP/1.1\r\nHost: " + strings.TrimPrefix(srv.URL, "http://") + "\r\n" +
+		"Connection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatalf("write handshake failed: %v", err)
+	}
+	reader := bufio.NewReader(conn)
+	if resp, err := http.ReadResponse(reader, nil); err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake failed: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		hub.mu.Lock()
+		n := len(hub.subs)
+		hub.mu.Unlock()
+		if n == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	const n = 50
+	go func() {
+		// Masked with a zero key, so the payload is sent as is.
+		ping := []byte{0x89, 0x80 | 4, 0, 0, 0, 0, 'p', 'i', 'n', 'g'}
+		for i := 0; i < n; i++ {
+			if _, err := conn.Write(ping); err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		for i := 0; i < n; i++ {
+			hub.Broadcast(Event{Type: "updated", Entity: "task", ID: int64(i + 1)})
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	var texts, pongs int
+	for texts < n || pongs < n {
+		var head [2]byte
+		if _, err := io.ReadFull(reader, head[:]); err != nil {
+			t.Fatalf("read frame failed after %d events and %d pongs: %v", texts, pongs, err)
+		}
+		if head[1] > 125 {
+			t.Fatalf("unexpected frame length byte %d", head[1])
+		}
+		payload := make([]byte, int(head[1]))
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			t.Fatalf("read payload failed: %v", err)
+		}
+		switch head[0] {
+		case 0x81:
+			var ev Event
+			if err := json.Unmarshal(payload, &ev); err != nil {
+				t.Fatalf("corrupt event %q: %v", payload, err)
+			}
+			texts++
+		case 0x8A:
+			if string(payload) != "ping" {
+				t.Fatalf("corrupt pong %q", payload)
+			}
+			pongs++
+		default:
+			t.Fatalf("unexpected frame % x", head[0])
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package realtime
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// websocketGUID is the fixed key suffix defined by RFC 6455.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xA
+
+	// maxClientFrame bounds control/data frames read from clients; the
+	// channel is server-push only, so anything larger is a protocol abuse.
+	maxClientFrame = 4096
+
+	writeTimeout = 10 * time.Second
+)
+
+// Conn is a minimal server-side WebSocket connection that supports pushing
+// text messages and answering the client's control frames.
+type Conn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+	// writeMu serializes frames: ReadLoop answers pings while events are
+	// written from another goroutine.
+	writeMu sync.Mutex
+}
+
+// Upgrade performs the WebSocket handshake for r. Cross-origin upgrades are
+// rejected because the browser does not apply CORS to WebSockets: the
+// Origin's host must be r.Host, or one ownHost accepts when it is not nil.
+func Upgrade(w http.ResponseWriter, r *http.Request, ownHost func(host, requestHost string) bool) (*Conn, error) {
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "websocket upgrade required", http.StatusBadRequest)
+		return nil, errors.New("not a websocket upgrade request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusBadRequest)
+		return nil, errors.New("unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "missing websocket key", http.StatusBadRequest)
+		return nil, errors.New("missing Sec-WebSocket-Key")
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if ownHost == nil {
+			ownHost = strings.EqualFold
+		}
+		if err != nil || !ownHost(u.Host, r.Host) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return nil, errors.New("cross-origin websocket request")
+		}
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, errors.New("response writer does not support hijacking")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hijack connection: %w", err)
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + AcceptKey(key) + "\r\n\r\n"
+	if _, err := rw.WriteString(response); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to write handshake: %w", err)
+	}
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to flush handshake: %w", err)
+	}
+
+	return &Conn{conn: conn, rw: rw}, nil
+}
+
+// AcceptKey computes the Sec-WebSocket-Accept value for a client key.
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// WriteText sends a single unfragmented text frame.
+func (c *Conn) WriteText(msg []byte) error {
+	return c.writeFrame(opText, msg)
+}
+
+// Close sends a close frame and closes the underlying connection.
+func (c *Conn) Close() error {
+	_ = c.writeFrame(opClose, nil)
+	return c.conn.Close()
+}
+
+// ReadLoop consumes client frames until the connection closes, answering
+// pings and close requests. Data frames from the client are ignored.
+func (c *Conn) ReadLoop() error {
+	for {
+		op, payload, err := readFrame(c.rw.Reader)
+		if err != nil {
+			return err
+		}
+		switch op {
+		case opClose:
+			_ = c.writeFrame(opClose, nil)
+			return io.EOF
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(encodeFrame(op, payload)); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
+
+// encodeFrame builds an unmasked server frame with the FIN bit set.
+func encodeFrame(op byte, payload []byte) []byte {
+	n := len(payload)
+	header := []byte{0x80 | op}
+	switch {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+	return append(header, payload...)
+}
+
+// readFrame reads one masked client frame and returns its opcode and payload.
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	op := head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxClientFrame {
+		return 0, nil, errors.New("websocket frame too large")
+	}
+	if !masked {
+		return 0, nil, errors.New("client frames must be masked")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(r, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return op, payload, nil
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+// Package recurring creates the next occurrence of a repeating task when the
+// current one is completed.
+package recurring
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/models"
+	"mytasks/internal/nldate"
+	"mytasks/internal/store"
+)
+
+// Spawner listens for completed tasks and schedules their next occurrence.
+type Spawner struct {
+	store store.Store
+	bus   *events.Bus
+	now   func() time.Time
+}
+
+// NewSpawner creates a Spawner. bus may be nil; it is used to announce the
+// tasks it creates.
+func NewSpawner(s store.Store, bus *events.Bus) *Spawner {
+	return &Spawner{store: s, bus: bus, now: time.Now}
+}
+
+// HandleEvent is an events.Handler for TaskCompleted.
+func (s *Spawner) HandleEvent(ctx context.Context, e events.Event) {
+	if e.Type != events.TaskCompleted {
+		return
+	}
+	if _, err := s.Spawn(ctx, e.TaskID); err != nil {
+		slog.Error("recurring: failed to schedule next occurrence", "task", e.TaskID, "err", err)
+	}
+}
+
+// Spawn creates the next occurrence of a completed repeating task and moves
+// the repeat rule onto it, so reopening and completing the old task again
+// does not create duplicates. It returns nil when the task does not repeat.
+func (s *Spawner) Spawn(ctx context.Context, taskID int64) (*models.Task, error) {
+	task, err := s.store.GetTask(ctx, taskID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if task.Recurrence == "" || !task.IsDone() {
+		return nil, nil
+	}
+
+	// Repeat from the due date so a weekly task stays on its weekday, but
+	// never schedule the next occurrence in the past.
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := today
+	if task.DueDate != nil {
+		from = *task.DueDate
+	}
+	due, err := nldate.Next(task.Recurrence, from)
+	if err != nil {
+		return nil, err
+	}
+	for due.Before(today) {
+		if due, err = nldate.Next(task.Recurrence, due); err != nil {
+			return nil, err
+		}
+	}
+
+	next := &models.Task{
+		ProjectID:   task.ProjectID,
+		Description: task.Description,
+		Notes:       task.Notes,
+		Priority:    task.Priority,
+		Status:      "todo",
+		DueDate:     &due,
+		Tags:        task.Tags,
+		Recurrence:  task.Recurrence,
+	}
+	if err := s.store.CreateTask(ctx, next); err != nil {
+		return nil, err
+	}
+	if err := s.store.SetTaskRecurrence(ctx, task.ID, ""); err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(ctx, events.Event{Type: events.TaskCreated, TaskID: next.ID, ProjectID: next.ProjectID, Task: next})
+	return next, nil
+}
@@ -0,0 +1,100 @@
+package recurring
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mytasks/internal/events"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+func setupSpawner(t *testing.T) (*Spawner, store.Store, *models.Project) {
+	t.Helper()
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	if err := s.CreateProject(context.Background(), project); err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+
+	sp := NewSpawner(s, events.NewBus())
+	// Wednesday 11 June 2025.
+	sp.now = func() time.Time { return time.Date(2025, 6, 11, 9, 0, 0, 0, time.Local) }
+	return sp, s, project
+}
+
+func TestSpawn(t *testing.T) {
+	day := func(m time.Month, d int) *time.Time {
+		t := time.Date(2025, m, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	tests := []struct {
+		name string
+		rule string
+		due  *time.Time
+		want *time.Time
+	}{
+		{"keeps the weekday", "every monday", day(6, 9), day(6, 16)},
+		{"skips occurrences in the past", "every day", day(6, 1), day(6, 11)},
+		{"undated repeats from today", "every 2 weeks", nil, day(6, 25)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp, s, project := setupSpawner(t)
+			ctx := context.Background()
+
+			task := &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "low", Status: "done", DueDate: tt.due, Tags: []string{"home"}, Recurrence: tt.rule}
+			if err := s.CreateTask(ctx, task); err != nil {
+				t.Fatalf("CreateTask: %v", err)
+			}
+
+			next, err := sp.Spawn(ctx, task.ID)
+			if err != nil {
+				t.Fatalf("Spawn: %v", err)
+			}
+			if next == nil {
+				t.Fatal("expected a next occurrence")
+			}
+			if !next.DueDate.Equal(*tt.want) {
+				t.Errorf("expected due %s, got %s", tt.want.Format("2006-01-02"), next.DueDate.Format("2006-01-02"))
+			}
+
+			got, _ := s.GetTask(ctx, next.ID)
+			if got.Status != "todo" || got.Recurrence != tt.rule || len(got.Tags) != 1 {
+				t.Errorf("unexpected next occurrence %+v", got)
+			}
+			old, _ := s.GetTask(ctx, task.ID)
+			if old.Recurrence != "" {
+				t.Errorf("expected the rule to move to the new task, old task still has %q", old.Recurrence)
+			}
+
+			// Completing the old task again must not spawn a duplicate.
+			if again, err := sp.Spawn(ctx, task.ID); err != nil || again != nil {
+				t.Errorf("expected no second occurrence, got %+v, %v", again, err)
+			}
+		})
+	}
+}
+
+func TestSpawn_IgnoresOneOffAndOpenTasks(t *testing.T) {
+	sp, s, project := setupSpawner(t)
+	ctx := context.Background()
+
+	oneOff := &models.Task{ProjectID: project.ID, Description: "Once", Priority: "low", Status: "done"}
+	open := &models.Task{ProjectID: project.ID, Description: "Open", Priority: "low", Status: "todo", Recurrence: "every day"}
+	s.CreateTask(ctx, oneOff)
+	s.CreateTask(ctx, open)
+
+	for _, task := range []*models.Task{oneOff, open} {
+		if next, err := sp.Spawn(ctx, task.ID); err != nil || next != nil {
+			t.Errorf("%s: expected nothing spawned, got %+v, %v", task.Description, next, err)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+// Package rpc serves the gRPC TaskService defined in proto/mytasks/v1.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"mytasks/internal/events"
+	"mytasks/internal/models"
+	"mytasks/internal/rpc/mytasksv1"
+	"mytasks/internal/store"
+)
+
+// Server implements mytasksv1.TaskServiceServer on top of a Store.
+type Server struct {
+	mytasksv1.UnimplementedTaskServiceServer
+
+	store store.Store
+	bus   *events.Bus
+}
+
+// NewServer creates a TaskService implementation. bus may be nil.
+func NewServer(s store.Store, bus *events.Bus) *Server {
+	return &Server{store: s, bus: bus}
+}
+
+// NewGRPCServer returns a grpc.Server with the TaskService registered.
+func NewGRPCServer(s store.Store, bus *events.Bus, opts ...grpc.ServerOption) *grpc.Server {
+	srv := grpc.NewServer(opts...)
+	mytasksv1.RegisterTaskServiceServer(srv, NewServer(s, bus))
+	return srv
+}
+
+// ReadOnly returns an interceptor refusing every call but the List and Get
+// methods with Unavailable while active returns true, for maintenance mode.
+func ReadOnly(active func() bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		method := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+		if active() && !strings.HasPrefix(method, "List") && !strings.HasPrefix(method, "Get") {
+			return nil, status.Error(codes.Unavailable, "read-only for maintenance")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// ListProjects returns active projects, or all projects when include_completed is set.
+func (s *Server) ListProjects(ctx context.Context, req *mytasksv1.ListProjectsRequest) (*mytasksv1.ListProjectsResponse, error) {
+	var projects []models.Project
+	var err error
+	if req.GetIncludeCompleted() {
+		projects, err = s.store.ListProjects(ctx)
+	} else {
+		projects, err = s.store.ListActiveProjects(ctx)
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &mytasksv1.ListProjectsResponse{Projects: make([]*mytasksv1.Project, 0, len(projects))}
+	for i := range projects {
+		resp.Projects = append(resp.Projects, projectToProto(&projects[i]))
+	}
+	return resp, nil
+}
+
+// GetProject returns a single project.
+func (s *Server) GetProject(ctx context.Context, req *mytasksv1.GetProjectRequest) (*mytasksv1.Project, error) {
+	project, err := s.store.GetProject(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return projectToProto(project), nil
+}
+
+// ListTasks returns a project's tasks, optionally limited to one status.
+func (s *Server) ListTasks(ctx context.Context, req *mytasksv1.ListTasksRequest) (*mytasksv1.ListTasksResponse, error) {
+	if req.GetProjectId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "project_id is required")
+	}
+
+	var tasks []models.Task
+	var err error
+	if req.GetStatus() == mytasksv1.Status_STATUS_UNSPECIFIED {
+		tasks, err = s.store.ListTasksByProject(ctx, req.GetProjectId(), 0)
+	} else {
+		tasks, err = s.store.ListTasksByProjectAndStatus(ctx, req.GetProjectId(), statusFromProto(req.GetStatus()))
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &mytasksv1.ListTasksResponse{Tasks: make([]*mytasksv1.Task, 0, len(tasks))}
+	for i := range tasks {
+		resp.Tasks = append(resp.Tasks, taskToProto(&tasks[i]))
+	}
+	return resp, nil
+}
+
+// GetTask returns a single task.
+func (s *Server) GetTask(ctx context.Context, req *mytasksv1.GetTaskRequest) (*mytasksv1.Task, error) {
+	task, err := s.store.GetTask(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return taskToProto(task), nil
+}
+
+// CreateTask creates a task in an existing project.
+func (s *Server) CreateTask(ctx context.Context, req *mytasksv1.CreateTaskRequest) (*mytasksv1.Task, error) {
+	if _, err := s.store.GetProject(ctx, req.GetProjectId()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	dueDate, err := parseDate(req.GetDueDate())
+	if err != nil {
+		return nil, err
+	}
+
+	task := &models.Task{
+		ProjectID:   req.GetProjectId(),
+		Description: req.GetDescription(),
+		Notes:       req.GetNotes(),
+		Priority:    priorityFromProto(req.GetPriority(), "medium"),
+		Status:      statusFromProto(req.GetStatus()),
+		DueDate:     dueDate,
+	}
+	if task.Status == "" {
+		task.Status = "todo"
+	}
+	if err := task.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.store.CreateTask(ctx, task); err != nil {
+		return nil, toStatus(err)
+	}
+
+	s.bus.Publish(ctx, events.Event{Type: events.TaskCreated, TaskID: task.ID, ProjectID: task.ProjectID, Task: task})
+	return taskToProto(task), nil
+}
+
+// UpdateTask replaces a task's editable fields.
+func (s *Server) UpdateTask(ctx context.Context, req *mytasksv1.UpdateTaskRequest) (*mytasksv1.Task, error) {
+	task, err := s.store.GetTask(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	previousProjectID := task.ProjectID
+
+	dueDate, err := parseDate(req.GetDueDate())
+	if err != nil {
+		return nil, err
+	}
+
+	task.Description = req.GetDescription()
+	task.Notes = req.GetNotes()
+	task.Priority = priorityFromProto(req.GetPriority(), task.Priority)
+	task.DueDate = dueDate
+
+	if destID := req.GetProjectId(); destID != 0 && destID != task.ProjectID {
+		dest, err := s.store.GetProject(ctx, destID)
+		if err != nil || dest.Completed {
+			return nil, status.Error(codes.InvalidArgument, "invalid destination project")
+		}
+		task.ProjectID = destID
+	}
+
+	if err := task.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.store.UpdateTask(ctx, task); err != nil {
+		return nil, toStatus(err)
+	}
+
+	if previousProjectID != task.ProjectID {
+		s.bus.Publish(ctx, events.Event{Type: events.TaskMoved, TaskID: task.ID, ProjectID: task.ProjectID, PreviousProjectID: previousProjectID, Task: task})
+	} else {
+		s.bus.Publish(ctx, events.Event{Type: events.TaskUpdated, TaskID: task.ID, ProjectID: task.ProjectID, Task: task})
+	}
+	return taskToProto(task), nil
+}
+
+// SetTaskStatus moves a task to another status column.
+func (s *Server) SetTaskStatus(ctx context.Context, req *mytasksv1.SetTaskStatusRequest) (*mytasksv1.Task, error) {
+	newStatus := statusFromProto(req.GetStatus())
+	if newStatus == "" {
+		return nil, status.Error(codes.InvalidArgument, "status is required")
+	}
+
+	task, err := s.store.GetTask(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	wasDone := task.IsDone()
+
+	task.Status = newStatus
+	if err := s.store.UpdateTask(ctx, task); err != nil {
+		return nil, toStatus(err)
+	}
+
+	eventType := events.TaskUpdated
+	switch {
+	case !wasDone && task.IsDone():
+		eventType = events.TaskCompleted
+	case wasDone && !task.IsDone():
+		eventType = events.TaskReopened
+	}
+	s.bus.Publish(ctx, events.Event{Type: eventType, TaskID: task.ID, ProjectID: task.ProjectID, Task: task})
+	return taskToProto(task), nil
+}
+
+// DeleteTask removes a task.
+func (s *Server) DeleteTask(ctx context.Context, req *mytasksv1.DeleteTaskRequest) (*mytasksv1.DeleteTaskResponse, error) {
+	task, err := s.store.GetTask(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if err := s.store.DeleteTask(ctx, task.ID); err != nil {
+		return nil, toStatus(err)
+	}
+
+	s.bus.Publish(ctx, events.Event{Type: events.TaskDeleted, TaskID: task.ID, ProjectID: task.ProjectID})
+	return &mytasksv1.DeleteTaskResponse{}, nil
+}
+
+func toStatus(err error) error {
+	if errors.Is(err, store.ErrNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, "internal error")
+}
+
+func parseDate(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid date %q: expected YYYY-MM-DD", s)
+	}
+	return &t, nil
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func projectToProto(p *models.Project) *mytasksv1.Project {
+	return &mytasksv1.Project{
+		Id:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		TargetDate:  formatDate(p.TargetDate),
+		Completed:   p.Completed,
+		CompletedAt: formatDate(p.CompletedAt),
+		SortOrder:   int32(p.SortOrder),
+		CreatedAt:   timestamppb.New(p.CreatedAt),
+		UpdatedAt:   timestamppb.New(p.UpdatedAt),
+	}
+}
+
+func taskToProto(t *models.Task) *mytasksv1.Task {
+	return &mytasksv1.Task{
+		Id:          t.ID,
+		ProjectId:   t.ProjectID,
+		Description: t.Description,
+		Notes:       t.Notes,
+		Priority:    priorityToProto(t.Priority),
+		Status:      statusToProto(t.Status),
+		DueDate:     formatDate(t.DueDate),
+		Completed:   t.Completed,
+		CompletedAt: formatDate(t.CompletedAt),
+		SortOrder:   int32(t.SortOrder),
+		CreatedAt:   timestamppb.New(t.CreatedAt),
+		UpdatedAt:   timestamppb.New(t.UpdatedAt),
+	}
+}
+
+func priorityToProto(p string) mytasksv1.Priority {
+	switch p {
+	case "high":
+		return mytasksv1.Priority_PRIORITY_HIGH
+	case "medium":
+		return mytasksv1.Priority_PRIORITY_MEDIUM
+	case "low":
+		return mytasksv1.Priority_PRIORITY_LOW
+	default:
+		return mytasksv1.Priority_PRIORITY_UNSPECIFIED
+	}
+}
+
+// priorityFromProto maps an enum to the model value, using fallback when unspecified.
+func priorityFromProto(p mytasksv1.Priority, fallback string) string {
+	switch p {
+	case mytasksv1.Priority_PRIORITY_HIGH:
+		return "high"
+	case mytasksv1.Priority_PRIORITY_MEDIUM:
+		return "medium"
+	case mytasksv1.Priority_PRIORITY_LOW:
+		return "low"
+	default:
+		return fallback
+	}
+}
+
+func statusToProto(s string) mytasksv1.Status {
+	switch s {
+	case "todo":
+		return mytasksv1.Status_STATUS_TODO
+	case "in_progress":
+		return mytasksv1.Status_STATUS_IN_PROGRESS
+	case "done":
+		return mytasksv1.Status_STATUS_DONE
+	default:
+		return mytasksv1.Status_STATUS_UNSPECIFIED
+	}
+}
+
+func statusFromProto(s mytasksv1.Status) string {
+	switch s {
+	case mytasksv1.Status_STATUS_TODO:
+		return "todo"
+	case mytasksv1.Status_STATUS_IN_PROGRESS:
+		return "in_progress"
+	case mytasksv1.Status_STATUS_DONE:
+		return "done"
+	default:
+		return ""
+	}
+}
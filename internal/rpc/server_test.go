@@ -0,0 +1,148 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"mytasks/internal/events"
+	"mytasks/internal/models"
+	"mytasks/internal/rpc/mytasksv1"
+	"mytasks/internal/store"
+)
+
+func setupTestClient(t *testing.T) (mytasksv1.TaskServiceClient, *store.SQLiteStore, *events.Bus) {
+	t.Helper()
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	bus := events.NewBus()
+	lis := bufconn.Listen(1 << 20)
+	srv := NewGRPCServer(s, bus)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return mytasksv1.NewTaskServiceClient(conn), s, bus
+}
+
+func TestCreateAndListTasks(t *testing.T) {
+	client, s, bus := setupTestClient(t)
+	ctx := context.Background()
+
+	var published []events.Type
+	bus.Subscribe(func(_ context.Context, e events.Event) { published = append(published, e.Type) })
+
+	project := &models.Project{Name: "Integrations", Type: "project"}
+	s.CreateProject(ctx, project)
+
+	created, err := client.CreateTask(ctx, &mytasksv1.CreateTaskRequest{
+		ProjectId:   project.ID,
+		Description: "Ship gRPC API",
+		Priority:    mytasksv1.Priority_PRIORITY_HIGH,
+		DueDate:     "2030-01-15",
+	})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if created.GetId() == 0 || created.GetStatus() != mytasksv1.Status_STATUS_TODO {
+		t.Errorf("unexpected created task %+v", created)
+	}
+	if created.GetDueDate() != "2030-01-15" {
+		t.Errorf("expected due date 2030-01-15, got %q", created.GetDueDate())
+	}
+
+	list, err := client.ListTasks(ctx, &mytasksv1.ListTasksRequest{ProjectId: project.ID})
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	if len(list.GetTasks()) != 1 || list.GetTasks()[0].GetPriority() != mytasksv1.Priority_PRIORITY_HIGH {
+		t.Errorf("unexpected task list %+v", list.GetTasks())
+	}
+
+	if len(published) != 1 || published[0] != events.TaskCreated {
+		t.Errorf("expected TaskCreated to be published, got %v", published)
+	}
+}
+
+func TestSetTaskStatus_CompletesTask(t *testing.T) {
+	client, s, _ := setupTestClient(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Test", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Task", Priority: "low"}
+	s.CreateTask(ctx, task)
+
+	got, err := client.SetTaskStatus(ctx, &mytasksv1.SetTaskStatusRequest{Id: task.ID, Status: mytasksv1.Status_STATUS_DONE})
+	if err != nil {
+		t.Fatalf("SetTaskStatus failed: %v", err)
+	}
+	if !got.GetCompleted() || got.GetCompletedAt() == "" {
+		t.Errorf("expected task to be completed, got %+v", got)
+	}
+}
+
+func TestGetTask_NotFound(t *testing.T) {
+	client, _, _ := setupTestClient(t)
+
+	_, err := client.GetTask(context.Background(), &mytasksv1.GetTaskRequest{Id: 999})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+}
+
+func TestCreateTask_InvalidArgument(t *testing.T) {
+	client, s, _ := setupTestClient(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Test", Type: "project"}
+	s.CreateProject(ctx, project)
+
+	_, err := client.CreateTask(ctx, &mytasksv1.CreateTaskRequest{ProjectId: project.ID, Description: "  "})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	active := true
+	interceptor := ReadOnly(func() bool { return active })
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+	call := func(method string) error {
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/mytasks.v1.TaskService/" + method}, handler)
+		return err
+	}
+
+	for _, method := range []string{"ListTasks", "GetProject"} {
+		if err := call(method); err != nil {
+			t.Errorf("%s in maintenance: %v, want it served", method, err)
+		}
+	}
+	for _, method := range []string{"CreateTask", "SetTaskStatus", "DeleteTask"} {
+		if err := call(method); status.Code(err) != codes.Unavailable {
+			t.Errorf("%s in maintenance: %v, want Unavailable", method, err)
+		}
+	}
+	active = false
+	if err := call("CreateTask"); err != nil {
+		t.Errorf("CreateTask after maintenance: %v", err)
+	}
+}
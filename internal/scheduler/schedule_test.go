@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	valid := []string{"0 3 * * *", "*/15 * * * *", "0 9-17/2 * * 1-5", "0 0 1,15 * *", "0 0 * * 7", "@every 90s", "@hourly", "@daily", "@weekly"}
+	for _, spec := range valid {
+		if _, err := Parse(spec); err != nil {
+			t.Errorf("Parse(%q): unexpected error %v", spec, err)
+		}
+	}
+
+	invalid := []string{"", "0 3 * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *", "0 0 30 2 *", "@every 10ms", "@every soon"}
+	for _, spec := range invalid {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q): expected an error", spec)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Wednesday
+	after := time.Date(2024, time.June, 19, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"0 3 * * *", time.Date(2024, time.June, 20, 3, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.June, 19, 10, 45, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2024, time.June, 20, 10, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1", time.Date(2024, time.June, 24, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2024, time.June, 23, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2024, time.July, 31, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either one matches.
+		{"0 0 1 * 5", time.Date(2024, time.June, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@every 1h", time.Date(2024, time.June, 19, 11, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		schedule, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.spec, err)
+		}
+		if got := schedule.Next(after); !got.Equal(tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+// Package scheduler runs named background jobs on intervals or cron-style
+// schedules, recording each job's last and next run.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mytasks/internal/models"
+)
+
+// Job is a unit of periodic work.
+type Job func(ctx context.Context) error
+
+// StatusStore persists job statuses across restarts. store.Store satisfies it.
+type StatusStore interface {
+	SaveJobStatus(ctx context.Context, status *models.JobStatus) error
+	ListJobStatuses(ctx context.Context) ([]models.JobStatus, error)
+	PruneJobStatuses(ctx context.Context, keep []string) error
+}
+
+// Off disables a job when given as its schedule override.
+const Off = "off"
+
+type entry struct {
+	name     string
+	spec     string
+	schedule Schedule
+	fn       Job
+	status   models.JobStatus
+}
+
+// Scheduler runs registered jobs until its context is cancelled.
+type Scheduler struct {
+	mu        sync.Mutex
+	entries   []*entry
+	overrides map[string]string
+	statuses  StatusStore
+	paused    func() bool
+	now       func() time.Time
+}
+
+// New creates an empty Scheduler.
+func New() *Scheduler {
+	return &Scheduler{overrides: make(map[string]string), now: time.Now}
+}
+
+// SetStatusStore makes the scheduler persist job statuses, and catch up on
+// scheduled runs missed while the process was down.
+func (s *Scheduler) SetStatusStore(st StatusStore) {
+	s.statuses = st
+}
+
+// SetPaused makes the scheduler skip runs while paused returns true, such as
+// during read-only maintenance. A skipped job runs again on its schedule.
+func (s *Scheduler) SetPaused(paused func() bool) {
+	s.paused = paused
+}
+
+// Every registers fn to run once at start and then every interval.
+func (s *Scheduler) Every(name string, interval time.Duration, fn Job) {
+	s.add(&entry{name: name, spec: "@every " + interval.String(), schedule: Interval(interval), fn: fn})
+}
+
+// Cron registers fn to run on spec (see Parse). A run missed while the
+// process was down happens once at start.
+func (s *Scheduler) Cron(name, spec string, fn Job) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+	s.add(&entry{name: name, spec: spec, schedule: schedule, fn: fn})
+	return nil
+}
+
+func (s *Scheduler) add(e *entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+}
+
+// Override replaces the schedule of the job registered under name, or
+// disables it when spec is Off. Overrides apply when the scheduler starts.
+func (s *Scheduler) Override(name, spec string) error {
+	spec = strings.TrimSpace(spec)
+	if spec != Off {
+		if _, err := Parse(spec); err != nil {
+			return fmt.Errorf("job %s: %w", name, err)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides[name] = spec
+	return nil
+}
+
+// Start launches one goroutine per registered job. Jobs stop when ctx is done.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	var entries []*entry
+	for _, e := range s.entries {
+		if spec, ok := s.overrides[e.name]; ok {
+			if spec == Off {
+				slog.Info("scheduler: job is disabled", "job", e.name)
+				continue
+			}
+			e.spec = spec
+			e.schedule, _ = Parse(spec)
+		}
+		entries = append(entries, e)
+	}
+	for name := range s.overrides {
+		if !hasJob(s.entries, name) {
+			slog.Warn("scheduler: no job to reschedule", "job", name)
+		}
+	}
+	s.mu.Unlock()
+
+	previous := s.loadStatuses(ctx, entries)
+	now := s.now()
+	for _, e := range entries {
+		e.status = models.JobStatus{Name: e.name, Schedule: e.spec, NextRunAt: e.schedule.Next(now)}
+		if prev, ok := previous[e.name]; ok {
+			e.status.LastRunAt = prev.LastRunAt
+			e.status.LastDuration = prev.LastDuration
+			e.status.LastError = prev.LastError
+			if prev.Schedule == e.spec && prev.NextRunAt.Before(e.status.NextRunAt) {
+				e.status.NextRunAt = prev.NextRunAt
+			}
+		}
+		if _, isInterval := e.schedule.(interval); isInterval || e.status.NextRunAt.Before(now) {
+			e.status.NextRunAt = now
+		}
+		s.saveStatus(ctx, e)
+		go s.run(ctx, e)
+	}
+}
+
+// Statuses returns a snapshot of every started job's status, by name.
+func (s *Scheduler) Statuses() []models.JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var statuses []models.JobStatus
+	for _, e := range s.entries {
+		if e.status.Name != "" {
+			statuses = append(statuses, e.status)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+func hasJob(entries []*entry, name string) bool {
+	for _, e := range entries {
+		if e.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// loadStatuses reads the persisted statuses and forgets jobs that are no
+// longer scheduled.
+func (s *Scheduler) loadStatuses(ctx context.Context, entries []*entry) map[string]models.JobStatus {
+	previous := make(map[string]models.JobStatus)
+	if s.statuses == nil {
+		return previous
+	}
+
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.name
+	}
+	if err := s.statuses.PruneJobStatuses(ctx, names); err != nil {
+		slog.Error("scheduler: failed to prune job statuses", "err", err)
+	}
+	statuses, err := s.statuses.ListJobStatuses(ctx)
+	if err != nil {
+		slog.Error("scheduler: failed to load job statuses", "err", err)
+		return previous
+	}
+	for _, status := range statuses {
+		previous[status.Name] = status
+	}
+	return previous
+}
+
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	for {
+		s.mu.Lock()
+		wait := e.status.NextRunAt.Sub(s.now())
+		s.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.runOnce(ctx, e)
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, e *entry) {
+	if s.paused != nil && s.paused() {
+		slog.Info("scheduler: job skipped while paused", "job", e.name)
+		s.mu.Lock()
+		e.status.NextRunAt = e.schedule.Next(s.now())
+		s.mu.Unlock()
+		return
+	}
+	start := s.now()
+	err := call(ctx, e)
+	if err != nil {
+		slog.Error("scheduler: job failed", "job", e.name, "err", err)
+	}
+
+	end := s.now()
+	s.mu.Lock()
+	e.status.LastRunAt = &start
+	e.status.LastDuration = end.Sub(start)
+	e.status.LastError = ""
+	if err != nil {
+		e.status.LastError = err.Error()
+	}
+	e.status.NextRunAt = e.schedule.Next(end)
+	s.mu.Unlock()
+
+	s.saveStatus(ctx, e)
+}
+
+func call(ctx context.Context, e *entry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return e.fn(ctx)
+}
+
+func (s *Scheduler) saveStatus(ctx context.Context, e *entry) {
+	if s.statuses == nil {
+		return
+	}
+	s.mu.Lock()
+	status := e.status
+	s.mu.Unlock()
+	if err := s.statuses.SaveJobStatus(ctx, &status); err != nil && ctx.Err() == nil {
+		slog.Error("scheduler: failed to persist job status", "err", err)
+	}
+}
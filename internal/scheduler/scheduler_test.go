@@ -0,0 +1,162 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"mytasks/internal/models"
+)
+
+type memStatusStore struct {
+	mu       sync.Mutex
+	statuses map[string]models.JobStatus
+}
+
+func (m *memStatusStore) SaveJobStatus(_ context.Context, status *models.JobStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statuses[status.Name] = *status
+	return nil
+}
+
+func (m *memStatusStore) ListJobStatuses(_ context.Context) ([]models.JobStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var statuses []models.JobStatus
+	for _, status := range m.statuses {
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func (m *memStatusStore) PruneJobStatuses(_ context.Context, keep []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name := range m.statuses {
+		if !contains(keep, name) {
+			delete(m.statuses, name)
+		}
+	}
+	return nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStart(t *testing.T) {
+	lastRun := time.Now().Add(-48 * time.Hour)
+	st := &memStatusStore{statuses: map[string]models.JobStatus{
+		// Missed while the process was down: runs at start.
+		"backup": {Name: "backup", Schedule: "0 3 * * *", LastRunAt: &lastRun, NextRunAt: lastRun.Add(24 * time.Hour)},
+		"gone":   {Name: "gone", Schedule: "@hourly", NextRunAt: time.Now()},
+	}}
+
+	ran := make(chan string, 3)
+	job := func(name string) Job {
+		return func(ctx context.Context) error {
+			ran <- name
+			return nil
+		}
+	}
+
+	s := New()
+	s.SetStatusStore(st)
+	if err := s.Cron("backup", "0 3 * * *", job("backup")); err != nil {
+		t.Fatalf("Cron failed: %v", err)
+	}
+	if err := s.Cron("digest", "0 7 * * *", job("digest")); err != nil {
+		t.Fatalf("Cron failed: %v", err)
+	}
+	s.Every("sync", time.Hour, job("sync"))
+	if err := s.Override("sync", Off); err != nil {
+		t.Fatalf("Override failed: %v", err)
+	}
+	if err := s.Override("digest", "not a schedule"); err == nil {
+		t.Error("expected an invalid override to be rejected")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Start(ctx)
+
+	select {
+	case name := <-ran:
+		if name != "backup" {
+			t.Fatalf("expected the missed backup to run first, got %s", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the missed backup to run at start")
+	}
+	select {
+	case name := <-ran:
+		t.Fatalf("expected no other job to run, got %s", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	statuses := s.Statuses()
+	if len(statuses) != 2 || statuses[0].Name != "backup" || statuses[1].Name != "digest" {
+		t.Fatalf("expected backup and digest to be scheduled, got %+v", statuses)
+	}
+	if statuses[1].LastRunAt != nil {
+		t.Error("expected digest to have no last run")
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if _, ok := st.statuses["gone"]; ok {
+		t.Error("expected the status of an unregistered job to be pruned")
+	}
+	if _, ok := st.statuses["digest"]; !ok {
+		t.Error("expected the digest status to be saved")
+	}
+}
+
+func TestJobPanicIsRecorded(t *testing.T) {
+	s := New()
+	s.Every("boom", time.Hour, func(ctx context.Context) error { panic("oops") })
+	e := s.entries[0]
+	e.status.Name = e.name
+
+	s.runOnce(context.Background(), e)
+
+	status := s.Statuses()[0]
+	if status.LastRunAt == nil || status.LastError != "panic: oops" {
+		t.Errorf("expected the panic to be recorded, got %+v", status)
+	}
+	if !status.NextRunAt.After(*status.LastRunAt) {
+		t.Error("expected a next run after the failed one")
+	}
+}
+
+func TestPausedJobIsSkipped(t *testing.T) {
+	s := New()
+	paused := true
+	s.SetPaused(func() bool { return paused })
+	runs := 0
+	s.Every("purge", time.Hour, func(ctx context.Context) error { runs++; return nil })
+	e := s.entries[0]
+	e.status.Name = e.name
+
+	s.runOnce(context.Background(), e)
+	status := s.Statuses()[0]
+	if runs != 0 || status.LastRunAt != nil {
+		t.Errorf("expected a paused job not to run, got %d runs and %+v", runs, status)
+	}
+	if !status.NextRunAt.After(time.Now()) {
+		t.Error("expected the skipped job to be rescheduled")
+	}
+
+	paused = false
+	s.runOnce(context.Background(), e)
+	if runs != 1 {
+		t.Errorf("expected the job to run once resumed, got %d runs", runs)
+	}
+}
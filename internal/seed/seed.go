@@ -0,0 +1,195 @@
+// Package seed fills a store with demo projects and tasks, spread across
+// priorities, due dates and completion states, for demos, screenshots and
+// trying out changes by hand.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+// ErrNotEmpty is returned when the store already has projects and Run was
+// not forced.
+var ErrNotEmpty = errors.New("the database already has projects")
+
+// Result counts what Run added.
+type Result struct {
+	Projects int `json:"projects"`
+	Tasks    int `json:"tasks"`
+}
+
+type demoTask struct {
+	description string
+	priority    string
+	status      string
+	// due and done are days from today; nil means no due date or not done.
+	due, done *int
+	tags      []string
+	notes     string
+	repeat    string
+}
+
+type demoProject struct {
+	name, description string
+	target            *int
+	completed         bool
+	tasks             []demoTask
+}
+
+func day(n int) *int { return &n }
+
+var demo = []demoProject{
+	{
+		name:        "Home renovation",
+		description: "Kitchen and bathroom before the winter",
+		target:      day(60),
+		tasks: []demoTask{
+			{description: "Get three quotes for the kitchen", priority: "high", status: "in_progress", due: day(-2), tags: []string{"calls"}},
+			{description: "Pick bathroom tiles", priority: "medium", status: "todo", due: day(4), tags: []string{"errands"}},
+			{description: "Order new extractor fan", priority: "low", status: "todo", due: day(12)},
+			{description: "Measure the kitchen walls", priority: "medium", status: "done", done: day(-6)},
+			{description: "Clear out the spare room", priority: "low", status: "done", done: day(-15)},
+			{description: "Decide on worktop material", priority: "medium", status: "todo", notes: "Oak vs. composite; check the samples first"},
+		},
+	},
+	{
+		name:        "Website relaunch",
+		description: "New design and blog for the studio",
+		target:      day(21),
+		tasks: []demoTask{
+			{description: "Write the About page", priority: "high", status: "todo", due: day(0), tags: []string{"writing"}},
+			{description: "Review homepage mockups", priority: "high", status: "in_progress", due: day(1), tags: []string{"review"}},
+			{description: "Set up redirects from old URLs", priority: "medium", status: "todo", due: day(9)},
+			{description: "Compress portfolio images", priority: "low", status: "todo", due: day(14)},
+			{description: "Choose a hosting plan", priority: "medium", status: "done", done: day(-3)},
+			{description: "Draft launch announcement", priority: "medium", status: "todo", tags: []string{"writing"}},
+			{description: "Publish weekly blog post", priority: "medium", status: "todo", due: day(2), tags: []string{"writing"}, repeat: "every monday"},
+		},
+	},
+	{
+		name:        "Garden",
+		description: "Spring planting",
+		tasks: []demoTask{
+			{description: "Buy seed potatoes", priority: "medium", status: "todo", due: day(-5), tags: []string{"errands"}},
+			{description: "Prune the apple tree", priority: "low", status: "todo", due: day(6)},
+			{description: "Fix the shed door", priority: "low", status: "todo"},
+			{description: "Turn the compost", priority: "low", status: "done", done: day(-1)},
+			{description: "Water the seedlings", priority: "medium", status: "todo", due: day(0), repeat: "every 2 days"},
+		},
+	},
+	{
+		name:        "Trip to Lisbon",
+		description: "Long weekend in May",
+		target:      day(35),
+		tasks: []demoTask{
+			{description: "Book flights", priority: "high", status: "done", done: day(-10)},
+			{description: "Book a hotel in Alfama", priority: "high", status: "todo", due: day(3)},
+			{description: "Renew passport", priority: "high", status: "todo", due: day(-1), tags: []string{"errands"}, notes: "Photo booth at the post office"},
+			{description: "List restaurants to try", priority: "low", status: "todo"},
+		},
+	},
+	{
+		name: "Errands",
+		tasks: []demoTask{
+			{description: "Return library books", priority: "medium", status: "todo", due: day(1), tags: []string{"errands"}},
+			{description: "Call the dentist", priority: "medium", status: "todo", due: day(5), tags: []string{"calls"}},
+			{description: "Pay council tax", priority: "high", status: "done", done: day(-2)},
+			{description: "Pick up dry cleaning", priority: "low", status: "done", done: day(0)},
+		},
+	},
+	{
+		name:        "Tax return",
+		description: "Last year's self assessment",
+		completed:   true,
+		tasks: []demoTask{
+			{description: "Collect bank statements", priority: "medium", status: "done", done: day(-30)},
+			{description: "Submit the return", priority: "high", status: "done", done: day(-25)},
+		},
+	},
+}
+
+// Run adds the demo projects and tasks, with dates relative to today. Unless
+// force is set, it leaves a store that already has projects alone and
+// returns ErrNotEmpty.
+func Run(ctx context.Context, s store.Store, today time.Time, force bool) (*Result, error) {
+	if !force {
+		projects, err := s.ListProjects(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(projects) > 0 {
+			return nil, ErrNotEmpty
+		}
+	}
+
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	date := func(offset *int) *time.Time {
+		if offset == nil {
+			return nil
+		}
+		d := today.AddDate(0, 0, *offset)
+		return &d
+	}
+
+	result := &Result{}
+	for _, p := range demo {
+		project := &models.Project{Name: p.name, Description: p.description, Type: "project", TargetDate: date(p.target)}
+		if err := s.CreateProject(ctx, project); err != nil {
+			return result, fmt.Errorf("seed: %w", err)
+		}
+		result.Projects++
+
+		tasks := make([]*models.Task, 0, len(p.tasks))
+		for _, t := range p.tasks {
+			tasks = append(tasks, &models.Task{
+				ProjectID:   project.ID,
+				Description: t.description,
+				Notes:       t.notes,
+				Priority:    t.priority,
+				Status:      t.status,
+				DueDate:     date(t.due),
+				CompletedAt: date(t.done),
+				Tags:        t.tags,
+				Recurrence:  t.repeat,
+			})
+		}
+		if err := s.CreateTasks(ctx, tasks); err != nil {
+			return result, fmt.Errorf("seed: %w", err)
+		}
+		result.Tasks += len(tasks)
+
+		if p.completed {
+			if err := s.MarkProjectComplete(ctx, project.ID); err != nil {
+				return result, fmt.Errorf("seed: %w", err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// DemoReset empties the store and adds the demo data again, so a public
+// demo starts over from the same projects.
+type DemoReset struct {
+	store store.Store
+	now   func() time.Time
+}
+
+// NewDemoReset creates a DemoReset.
+func NewDemoReset(s store.Store) *DemoReset {
+	return &DemoReset{store: s, now: time.Now}
+}
+
+// Run resets the store. It is meant to be called periodically by the
+// scheduler.
+func (d *DemoReset) Run(ctx context.Context) error {
+	if err := d.store.Reset(ctx); err != nil {
+		return err
+	}
+	_, err := Run(ctx, d.store, d.now(), true)
+	return err
+}
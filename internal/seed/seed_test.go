@@ -0,0 +1,107 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mytasks/internal/nldate"
+	"mytasks/internal/store"
+)
+
+func TestRun(t *testing.T) {
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+	today := time.Now()
+
+	result, err := Run(ctx, s, today, false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Projects != len(demo) || result.Tasks < 20 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	completed, _ := s.ListCompletedProjects(ctx)
+	if len(completed) != 1 {
+		t.Errorf("expected one completed project, got %d", len(completed))
+	}
+	tasks, err := s.ListTasks(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	counts := make(map[string]int)
+	for _, task := range tasks {
+		counts[task.Status]++
+		if task.IsOverdue() {
+			counts["overdue"]++
+		}
+		if task.Status == "done" && task.CompletedAt == nil {
+			t.Errorf("%q is done without a completion date", task.Description)
+		}
+	}
+	for _, kind := range []string{"todo", "in_progress", "done", "overdue"} {
+		if counts[kind] == 0 {
+			t.Errorf("expected some %s tasks, got %v", kind, counts)
+		}
+	}
+
+	if _, err := Run(ctx, s, today, false); !errors.Is(err, ErrNotEmpty) {
+		t.Errorf("expected ErrNotEmpty for a second run, got %v", err)
+	}
+	if _, err := Run(ctx, s, today, true); err != nil {
+		t.Errorf("expected a forced run to add the data again: %v", err)
+	}
+}
+
+func TestDemoData(t *testing.T) {
+	for _, p := range demo {
+		for _, task := range p.tasks {
+			if (task.status == "done") != (task.done != nil) {
+				t.Errorf("%q: done date does not match status %s", task.description, task.status)
+			}
+			if rule, err := nldate.ParseRule(task.repeat); err != nil || rule != task.repeat {
+				t.Errorf("%q: repeat rule %q is not canonical (%q, %v)", task.description, task.repeat, rule, err)
+			}
+		}
+	}
+}
+
+func TestDemoReset(t *testing.T) {
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	defer s.Close()
+	ctx := context.Background()
+
+	reset := NewDemoReset(s)
+	if err := reset.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	projects, _ := s.ListProjects(ctx)
+	if len(projects) != len(demo) {
+		t.Fatalf("expected the demo projects, got %d", len(projects))
+	}
+	if err := s.DeleteProject(ctx, projects[0].ID); err != nil {
+		t.Fatalf("DeleteProject failed: %v", err)
+	}
+
+	if err := reset.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	after, _ := s.ListProjects(ctx)
+	if len(after) != len(demo) {
+		t.Errorf("expected the demo projects after a reset, got %d", len(after))
+	}
+	for _, p := range after {
+		if p.ID == projects[0].ID {
+			t.Errorf("expected new IDs after a reset, got %d again", p.ID)
+		}
+	}
+}
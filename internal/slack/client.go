@@ -0,0 +1,7 @@
+package slack
+
+// Message is the JSON body sent to incoming webhooks and returned from slash commands.
+type Message struct {
+	ResponseType string `json:"response_type,omitempty"` // "ephemeral" or "in_channel"
+	Text         string `json:"text"`
+}
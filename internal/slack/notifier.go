@@ -0,0 +1,151 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+	"mytasks/internal/webhook"
+)
+
+// Notifier posts newly overdue tasks to every workspace that opted in.
+// Each task is announced once per workspace and due date.
+type Notifier struct {
+	store    store.Store
+	webhooks *webhook.Sender
+	now      func() time.Time
+}
+
+// NewNotifier creates a Notifier posting through webhooks, or through a new
+// Sender when it is nil.
+func NewNotifier(s store.Store, webhooks *webhook.Sender) *Notifier {
+	if webhooks == nil {
+		webhooks = webhook.NewSender(s, nil)
+	}
+	return &Notifier{store: s, webhooks: webhooks, now: time.Now}
+}
+
+// Channel is the overdue_notifications channel key for a workspace.
+func Channel(teamID string) string {
+	return "slack:" + teamID
+}
+
+// Run sends pending overdue notifications. It is meant to be called
+// periodically. During quiet hours nothing is sent; the notifications go out
+// on the first run after they end.
+func (n *Notifier) Run(ctx context.Context) error {
+	today := n.now()
+	settings, err := n.store.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	if settings.InQuietHours(today) {
+		return nil
+	}
+
+	workspaces, err := n.store.ListSlackWorkspaces(ctx)
+	if err != nil {
+		return err
+	}
+	prefs, err := n.store.ListNotificationPrefs(ctx)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, ws := range workspaces {
+		if !ws.NotifyOverdue || ws.WebhookURL == "" {
+			continue
+		}
+		if err := n.notifyWorkspace(ctx, ws, today, prefs); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("workspace %s: %w", ws.TeamID, err)
+		}
+	}
+	return firstErr
+}
+
+func (n *Notifier) notifyWorkspace(ctx context.Context, ws models.SlackWorkspace, today time.Time, prefs models.NotificationPrefs) error {
+	channel := Channel(ws.TeamID)
+	pending, err := n.store.ListOverdueTasksPendingNotification(ctx, channel, today)
+	if err != nil {
+		return err
+	}
+
+	// Tasks of projects muted on Slack count as notified, so unmuting the
+	// project doesn't announce them late.
+	var tasks []models.Task
+	for _, task := range pending {
+		if prefs.Allows(task.ProjectID, models.ChannelSlack) {
+			tasks = append(tasks, task)
+		} else if err := n.store.MarkOverdueNotified(ctx, task.ID, channel, *task.DueDate); err != nil {
+			return err
+		}
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(Message{Text: FormatOverdue(tasks)})
+	if err != nil {
+		return err
+	}
+	// Once the alert is in the delivery log, its retries are the sender's
+	// job; the tasks count as notified even if this first attempt failed.
+	_, sendErr := n.webhooks.Send(ctx, channel, "overdue alert", ws.WebhookURL, body)
+	if sendErr != nil && !webhook.IsDeliveryError(sendErr) {
+		return sendErr
+	}
+
+	for _, task := range tasks {
+		if err := n.store.MarkOverdueNotified(ctx, task.ID, channel, *task.DueDate); err != nil {
+			return err
+		}
+	}
+	return sendErr
+}
+
+// FormatOverdue renders an overdue alert for tasks.
+func FormatOverdue(tasks []models.Task) string {
+	var b strings.Builder
+	if len(tasks) == 1 {
+		b.WriteString(":warning: 1 task is now overdue:\n")
+	} else {
+		fmt.Fprintf(&b, ":warning: %d tasks are now overdue:\n", len(tasks))
+	}
+	writeTaskLines(&b, tasks)
+	return b.String()
+}
+
+// FormatDueToday renders the reply to "/mytasks today".
+func FormatDueToday(tasks []models.Task) string {
+	if len(tasks) == 0 {
+		return "Nothing due today. :tada:"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Due today or overdue (%d):\n", len(tasks))
+	writeTaskLines(&b, tasks)
+	return b.String()
+}
+
+func writeTaskLines(b *strings.Builder, tasks []models.Task) {
+	for _, task := range tasks {
+		fmt.Fprintf(b, "• %s", escape(task.Description))
+		if task.ProjectName != "" {
+			fmt.Fprintf(b, " _(%s)_", escape(task.ProjectName))
+		}
+		if task.DueDate != nil {
+			fmt.Fprintf(b, " — due %s", task.DueDate.Format("Jan 2"))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// escape applies Slack's mrkdwn escaping for control characters.
+func escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,61 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+	"mytasks/internal/webhook"
+)
+
+func TestNotifier_PostsEachOverdueTaskOnce(t *testing.T) {
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	ctx := context.Background()
+
+	var posts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg Message
+		json.NewDecoder(r.Body).Decode(&msg)
+		posts = append(posts, msg.Text)
+	}))
+	defer srv.Close()
+
+	project := &models.Project{Name: "Errands", Type: "project"}
+	s.CreateProject(ctx, project)
+	yesterday := time.Now().AddDate(0, 0, -1)
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Pay rent", Priority: "high", Status: "todo", DueDate: &yesterday})
+
+	if err := s.RegisterSlackWorkspace(ctx, "T1", "acme"); err != nil {
+		t.Fatalf("RegisterSlackWorkspace failed: %v", err)
+	}
+	ws, _ := s.GetSlackWorkspace(ctx, "T1")
+	ws.WebhookURL = srv.URL
+	ws.NotifyOverdue = true
+	if err := s.UpdateSlackWorkspace(ctx, ws); err != nil {
+		t.Fatalf("UpdateSlackWorkspace failed: %v", err)
+	}
+
+	n := NewNotifier(s, webhook.NewSender(s, srv.Client()))
+	for i := 0; i < 2; i++ {
+		if err := n.Run(ctx); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+	}
+
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 webhook post, got %d", len(posts))
+	}
+	if !strings.Contains(posts[0], "Pay rent") || !strings.Contains(posts[0], "Errands") {
+		t.Errorf("unexpected message %q", posts[0])
+	}
+}
@@ -0,0 +1,55 @@
+// Package slack implements the Slack slash command and overdue notifications.
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MaxRequestAge is how far a request timestamp may drift from now before the
+// request is rejected as a possible replay.
+const MaxRequestAge = 5 * time.Minute
+
+var (
+	ErrMissingSignature = errors.New("slack: missing signature headers")
+	ErrStaleRequest     = errors.New("slack: request timestamp too old")
+	ErrBadSignature     = errors.New("slack: signature mismatch")
+)
+
+// VerifyRequest checks a request's X-Slack-Signature against the signing
+// secret, as described in https://api.slack.com/authentication/verifying-requests-from-slack.
+// body must be the raw, unparsed request body.
+func VerifyRequest(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	signature := header.Get("X-Slack-Signature")
+	if timestamp == "" || signature == "" {
+		return ErrMissingSignature
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrMissingSignature
+	}
+	age := now.Sub(time.Unix(ts, 0))
+	if age > MaxRequestAge || age < -MaxRequestAge {
+		return ErrStaleRequest
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Sign returns the v0 signature Slack sends for the given timestamp and body.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
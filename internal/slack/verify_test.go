@@ -0,0 +1,63 @@
+package slack
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signedHeader(secret string, ts time.Time, body []byte) http.Header {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	h := http.Header{}
+	h.Set("X-Slack-Request-Timestamp", timestamp)
+	h.Set("X-Slack-Signature", Sign(secret, timestamp, body))
+	return h
+}
+
+func TestSign_MatchesSlackExample(t *testing.T) {
+	// Example from Slack's "Verifying requests from Slack" documentation.
+	body := []byte("token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c")
+	got := Sign("8f742231b10e8888abcd99yyyzzz85a5", "1531420618", body)
+	want := "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503"
+	if got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	secret := "shhh"
+	body := []byte("team_id=T1&text=today")
+	now := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   error
+	}{
+		{name: "valid", header: signedHeader(secret, now, body), want: nil},
+		{name: "missing headers", header: http.Header{}, want: ErrMissingSignature},
+		{name: "stale timestamp", header: signedHeader(secret, now.Add(-10*time.Minute), body), want: ErrStaleRequest},
+		{name: "wrong secret", header: signedHeader("other", now, body), want: ErrBadSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyRequest(secret, tt.header, body, now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("VerifyRequest() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRequest_TamperedBody(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	header := signedHeader("shhh", now, []byte("text=add+milk"))
+
+	err := VerifyRequest("shhh", header, []byte("text=add+bread"), now)
+	if !errors.Is(err, ErrBadSignature) {
+		t.Errorf("expected ErrBadSignature, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"fmt"
@@ -8,6 +9,9 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	"mytasks/internal/models"
 )
 
 //go:embed migrations/*.sql
@@ -51,6 +55,51 @@ func runMigrations(db *sql.DB) error {
 	return nil
 }
 
+// ListMigrations returns the migrations this build knows and those recorded
+// in the database, by version.
+func (s *SQLiteStore) ListMigrations(ctx context.Context) ([]models.Migration, error) {
+	known, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*models.Migration, len(known))
+	for _, m := range known {
+		byVersion[m.version] = &models.Migration{Version: m.version, Name: m.name}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		var name string
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &name, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &models.Migration{Version: version, Name: name, Unknown: true}
+			byVersion[version] = m
+		}
+		m.AppliedAt = &appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	migrations := make([]models.Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
 func ensureMigrationsTable(db *sql.DB) error {
 	_, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS schema_migrations (
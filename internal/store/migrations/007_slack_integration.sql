@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS slack_workspaces (
+    team_id TEXT PRIMARY KEY,
+    team_name TEXT NOT NULL DEFAULT '',
+    webhook_url TEXT NOT NULL DEFAULT '',
+    project_id INTEGER REFERENCES projects(id) ON DELETE SET NULL,
+    notify_overdue BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One row per task, channel and due date so a notifier sends at most one
+-- overdue alert per task until its due date changes.
+CREATE TABLE IF NOT EXISTS overdue_notifications (
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    channel TEXT NOT NULL,
+    due_date DATE NOT NULL,
+    notified_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (task_id, channel, due_date)
+);
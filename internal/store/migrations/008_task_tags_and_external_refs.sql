@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS task_tags (
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (task_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_tags_tag ON task_tags(tag);
+
+-- Maps tasks to their IDs in other systems (Taskwarrior UUIDs, remote sync
+-- IDs) so repeated imports update tasks instead of duplicating them.
+CREATE TABLE IF NOT EXISTS task_external_refs (
+    source TEXT NOT NULL,
+    external_id TEXT NOT NULL,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (source, external_id),
+    UNIQUE (source, task_id)
+);
@@ -0,0 +1,11 @@
+-- Single-row table holding the Google Tasks OAuth connection.
+CREATE TABLE IF NOT EXISTS google_tasks_account (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    refresh_token TEXT NOT NULL,
+    access_token TEXT NOT NULL DEFAULT '',
+    token_expiry DATETIME,
+    project_id INTEGER REFERENCES projects(id) ON DELETE SET NULL,
+    last_synced_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
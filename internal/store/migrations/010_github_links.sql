@@ -0,0 +1,12 @@
+-- Links a project to a GitHub repository. Issues in the repo assigned to the
+-- token's owner are synced into the project as tasks.
+CREATE TABLE IF NOT EXISTS github_links (
+    project_id INTEGER PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    repo TEXT NOT NULL UNIQUE,
+    token TEXT NOT NULL,
+    login TEXT NOT NULL,
+    webhook_secret TEXT NOT NULL,
+    last_synced_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
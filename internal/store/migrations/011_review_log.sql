@@ -0,0 +1,9 @@
+-- Records each time a project is marked reviewed in the weekly review, so the
+-- review can show when a project was last looked at.
+CREATE TABLE IF NOT EXISTS review_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    reviewed_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_log_project ON review_log(project_id, reviewed_at);
@@ -0,0 +1,6 @@
+-- Key/value store for small server-side preferences such as the daily goal.
+CREATE TABLE IF NOT EXISTS settings (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
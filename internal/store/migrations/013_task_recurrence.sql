@@ -0,0 +1,6 @@
+-- Repeat rules such as "every monday". Completing a repeating task creates
+-- its next occurrence, which takes over the rule.
+CREATE TABLE IF NOT EXISTS task_recurrences (
+    task_id INTEGER PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+    rule TEXT NOT NULL
+);
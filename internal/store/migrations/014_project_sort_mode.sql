@@ -0,0 +1,2 @@
+-- How a project's tasks are ordered: by hand (sort_order) or automatically.
+ALTER TABLE projects ADD COLUMN sort_mode TEXT NOT NULL DEFAULT 'manual';
@@ -0,0 +1,10 @@
+-- The scheduler's bookkeeping for each background job, so missed runs can be
+-- caught up after a restart and the status page can show what ran when.
+CREATE TABLE IF NOT EXISTS job_status (
+    name TEXT PRIMARY KEY,
+    schedule TEXT NOT NULL,
+    last_run_at DATETIME,
+    last_duration_ms INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_run_at DATETIME NOT NULL
+);
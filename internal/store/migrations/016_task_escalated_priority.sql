@@ -0,0 +1,3 @@
+-- Priority raised by the escalation job as a task's due date nears; empty
+-- when the task is not escalated.
+ALTER TABLE tasks ADD COLUMN escalated_priority TEXT NOT NULL DEFAULT '';
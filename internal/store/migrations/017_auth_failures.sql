@@ -0,0 +1,10 @@
+-- Failed token authentications per feature (scope) and client IP, used to
+-- slow down guessing.
+CREATE TABLE IF NOT EXISTS auth_failures (
+    scope TEXT NOT NULL,
+    client TEXT NOT NULL,
+    failures INTEGER NOT NULL,
+    last_failure_at DATETIME NOT NULL,
+    locked_until DATETIME,
+    PRIMARY KEY (scope, client)
+);
@@ -0,0 +1,8 @@
+-- Changes made offline and replayed through /api/sync, by the ID the client
+-- gave them, so a replay that is retried is applied only once.
+CREATE TABLE IF NOT EXISTS sync_mutations (
+    id TEXT PRIMARY KEY,
+    applied_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_mutations_applied_at ON sync_mutations(applied_at);
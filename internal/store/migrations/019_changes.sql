@@ -0,0 +1,78 @@
+-- The last change to each project and task, numbered by seq, so clients can
+-- ask for what changed after the last seq they saw. The triggers below keep
+-- it up to date whichever code path writes. Each change replaces the
+-- entity's previous row, so the log stays one row per entity and deletions
+-- are kept as tombstones. (The triggers delete and insert rather than use
+-- INSERT OR REPLACE, which an INSERT OR IGNORE firing them would override.)
+CREATE TABLE IF NOT EXISTS changes (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity TEXT NOT NULL CHECK(entity IN ('project', 'task')),
+    entity_id INTEGER NOT NULL,
+    op TEXT NOT NULL CHECK(op IN ('create', 'update', 'delete')),
+    changed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (entity, entity_id)
+);
+
+-- Existing data counts as created, so a client starting from seq 0 gets all
+-- of it.
+INSERT OR IGNORE INTO changes (entity, entity_id, op) SELECT 'project', id, 'create' FROM projects ORDER BY id;
+INSERT OR IGNORE INTO changes (entity, entity_id, op) SELECT 'task', id, 'create' FROM tasks ORDER BY id;
+
+CREATE TRIGGER IF NOT EXISTS changes_project_insert AFTER INSERT ON projects BEGIN
+    DELETE FROM changes WHERE entity = 'project' AND entity_id = NEW.id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('project', NEW.id, 'create');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_project_update AFTER UPDATE ON projects BEGIN
+    DELETE FROM changes WHERE entity = 'project' AND entity_id = NEW.id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('project', NEW.id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_project_delete AFTER DELETE ON projects BEGIN
+    DELETE FROM changes WHERE entity = 'project' AND entity_id = OLD.id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('project', OLD.id, 'delete');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_task_insert AFTER INSERT ON tasks BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id = NEW.id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', NEW.id, 'create');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_task_update AFTER UPDATE ON tasks BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id = NEW.id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', NEW.id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_task_delete AFTER DELETE ON tasks BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id = OLD.id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', OLD.id, 'delete');
+END;
+
+-- Tags and repeat rules are part of the task. Rows removed along with their
+-- task don't count.
+CREATE TRIGGER IF NOT EXISTS changes_task_tag_insert AFTER INSERT ON task_tags BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id = NEW.task_id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', NEW.task_id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_task_tag_delete AFTER DELETE ON task_tags
+WHEN EXISTS (SELECT 1 FROM tasks WHERE id = OLD.task_id) BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id = OLD.task_id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', OLD.task_id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_task_recurrence_insert AFTER INSERT ON task_recurrences BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id = NEW.task_id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', NEW.task_id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_task_recurrence_update AFTER UPDATE ON task_recurrences BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id = NEW.task_id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', NEW.task_id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_task_recurrence_delete AFTER DELETE ON task_recurrences
+WHEN EXISTS (SELECT 1 FROM tasks WHERE id = OLD.task_id) BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id = OLD.task_id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', OLD.task_id, 'update');
+END;
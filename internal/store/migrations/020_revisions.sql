@@ -0,0 +1,51 @@
+-- Revisions count changes to what a task or project says, so a change made
+-- offline against an older revision is reported as a conflict instead of
+-- overwriting the newer one. Reordering and priority escalation don't count.
+ALTER TABLE tasks ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE projects ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+
+CREATE TRIGGER IF NOT EXISTS revision_task_update AFTER UPDATE ON tasks
+WHEN NEW.revision = OLD.revision AND (
+    NEW.project_id IS NOT OLD.project_id OR
+    NEW.description IS NOT OLD.description OR
+    NEW.notes IS NOT OLD.notes OR
+    NEW.priority IS NOT OLD.priority OR
+    NEW.status IS NOT OLD.status OR
+    NEW.due_date IS NOT OLD.due_date OR
+    NEW.completed IS NOT OLD.completed
+) BEGIN
+    UPDATE tasks SET revision = OLD.revision + 1 WHERE id = NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS revision_project_update AFTER UPDATE ON projects
+WHEN NEW.revision = OLD.revision AND (
+    NEW.name IS NOT OLD.name OR
+    NEW.description IS NOT OLD.description OR
+    NEW.type IS NOT OLD.type OR
+    NEW.target_date IS NOT OLD.target_date OR
+    NEW.completed IS NOT OLD.completed
+) BEGIN
+    UPDATE projects SET revision = OLD.revision + 1 WHERE id = NEW.id;
+END;
+
+-- Tags and repeat rules are part of the task.
+CREATE TRIGGER IF NOT EXISTS revision_task_tag_insert AFTER INSERT ON task_tags BEGIN
+    UPDATE tasks SET revision = revision + 1 WHERE id = NEW.task_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS revision_task_tag_delete AFTER DELETE ON task_tags BEGIN
+    UPDATE tasks SET revision = revision + 1 WHERE id = OLD.task_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS revision_task_recurrence_insert AFTER INSERT ON task_recurrences BEGIN
+    UPDATE tasks SET revision = revision + 1 WHERE id = NEW.task_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS revision_task_recurrence_update AFTER UPDATE ON task_recurrences
+WHEN NEW.rule IS NOT OLD.rule BEGIN
+    UPDATE tasks SET revision = revision + 1 WHERE id = NEW.task_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS revision_task_recurrence_delete AFTER DELETE ON task_recurrences BEGIN
+    UPDATE tasks SET revision = revision + 1 WHERE id = OLD.task_id;
+END;
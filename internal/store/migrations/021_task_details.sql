@@ -0,0 +1,106 @@
+-- What the task page shows beyond the task itself: comments, subtasks,
+-- attached files and a history of edits.
+CREATE TABLE IF NOT EXISTS task_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_comments_task ON task_comments(task_id, id);
+
+-- Subtasks are steps of one task, ticked off on its page, rather than tasks
+-- of their own that would show on boards and lists.
+CREATE TABLE IF NOT EXISTS subtasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_subtasks_task ON subtasks(task_id, sort_order);
+
+-- Attachments are kept in the database, so backups and the admin panel's
+-- copy of it include them.
+CREATE TABLE IF NOT EXISTS task_attachments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    data BLOB NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_attachments_task ON task_attachments(task_id, id);
+
+-- Every change to a task's fields after it was created, one row per field,
+-- recorded by the trigger below whichever code path writes. Reordering and
+-- escalation aren't edits and aren't recorded.
+CREATE TABLE IF NOT EXISTS task_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    field TEXT NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    changed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_history_task ON task_history(task_id, id);
+
+CREATE TRIGGER IF NOT EXISTS task_history_update AFTER UPDATE ON tasks BEGIN
+    INSERT INTO task_history (task_id, field, old_value, new_value)
+    SELECT NEW.id, 'project_id', OLD.project_id, NEW.project_id WHERE NEW.project_id IS NOT OLD.project_id;
+    INSERT INTO task_history (task_id, field, old_value, new_value)
+    SELECT NEW.id, 'description', OLD.description, NEW.description WHERE NEW.description IS NOT OLD.description;
+    INSERT INTO task_history (task_id, field, old_value, new_value)
+    SELECT NEW.id, 'notes', OLD.notes, NEW.notes WHERE NEW.notes IS NOT OLD.notes;
+    INSERT INTO task_history (task_id, field, old_value, new_value)
+    SELECT NEW.id, 'priority', OLD.priority, NEW.priority WHERE NEW.priority IS NOT OLD.priority;
+    INSERT INTO task_history (task_id, field, old_value, new_value)
+    SELECT NEW.id, 'status', OLD.status, NEW.status WHERE NEW.status IS NOT OLD.status;
+    INSERT INTO task_history (task_id, field, old_value, new_value)
+    SELECT NEW.id, 'due_date', date(OLD.due_date), date(NEW.due_date) WHERE date(NEW.due_date) IS NOT date(OLD.due_date);
+END;
+
+-- Comments, subtasks and attachments are part of the task for the change
+-- log, like its tags.
+CREATE TRIGGER IF NOT EXISTS changes_task_comment_insert AFTER INSERT ON task_comments BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id = NEW.task_id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', NEW.task_id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_task_comment_delete AFTER DELETE ON task_comments
+WHEN EXISTS (SELECT 1 FROM tasks WHERE id = OLD.task_id) BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id = OLD.task_id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', OLD.task_id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_subtask_insert AFTER INSERT ON subtasks BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id = NEW.task_id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', NEW.task_id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_subtask_update AFTER UPDATE ON subtasks BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id = NEW.task_id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', NEW.task_id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_subtask_delete AFTER DELETE ON subtasks
+WHEN EXISTS (SELECT 1 FROM tasks WHERE id = OLD.task_id) BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id = OLD.task_id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', OLD.task_id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_task_attachment_insert AFTER INSERT ON task_attachments BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id = NEW.task_id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', NEW.task_id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_task_attachment_delete AFTER DELETE ON task_attachments
+WHEN EXISTS (SELECT 1 FROM tasks WHERE id = OLD.task_id) BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id = OLD.task_id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', OLD.task_id, 'update');
+END;
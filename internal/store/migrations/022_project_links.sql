@@ -0,0 +1,28 @@
+-- Reference links kept with a project: docs, designs, dashboards.
+CREATE TABLE IF NOT EXISTS project_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_links_project ON project_links(project_id, sort_order);
+
+-- Links are part of the project for the change log.
+CREATE TRIGGER IF NOT EXISTS changes_project_link_insert AFTER INSERT ON project_links BEGIN
+    DELETE FROM changes WHERE entity = 'project' AND entity_id = NEW.project_id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('project', NEW.project_id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_project_link_update AFTER UPDATE ON project_links BEGIN
+    DELETE FROM changes WHERE entity = 'project' AND entity_id = NEW.project_id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('project', NEW.project_id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_project_link_delete AFTER DELETE ON project_links
+WHEN EXISTS (SELECT 1 FROM projects WHERE id = OLD.project_id) BEGIN
+    DELETE FROM changes WHERE entity = 'project' AND entity_id = OLD.project_id;
+    INSERT INTO changes (entity, entity_id, op) VALUES ('project', OLD.project_id, 'update');
+END;
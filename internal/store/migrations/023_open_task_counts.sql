@@ -0,0 +1,3 @@
+-- Covers the per-project open task counts shown as badges on every page, so
+-- they are read from the index without touching the tasks themselves.
+CREATE INDEX IF NOT EXISTS idx_tasks_project_status_due ON tasks(project_id, status, due_date);
@@ -0,0 +1,13 @@
+-- Deleting a task only marks it for a short while, so the delete can be
+-- undone with the token the undo toast holds. Marked tasks are hidden
+-- everywhere and removed for good by the purge-deleted job.
+ALTER TABLE tasks ADD COLUMN deleted_at DATETIME;
+ALTER TABLE tasks ADD COLUMN undo_token TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_deleted ON tasks(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_undo_token ON tasks(undo_token) WHERE undo_token IS NOT NULL;
+
+-- The count badges skip deleted tasks, so the index behind them covers
+-- deleted_at too.
+DROP INDEX IF EXISTS idx_tasks_project_status_due;
+CREATE INDEX IF NOT EXISTS idx_tasks_project_status_due ON tasks(project_id, status, deleted_at, due_date);
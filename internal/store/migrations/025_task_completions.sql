@@ -0,0 +1,50 @@
+-- Every time a task was completed, so reopening and finishing it again keeps
+-- the earlier completion. tasks.completed_at only holds the latest. Rows are
+-- written by the triggers below, whichever code path completes the task, in
+-- server local time like completed_at.
+CREATE TABLE IF NOT EXISTS task_completions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    completed_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_completions_task ON task_completions(task_id, id);
+CREATE INDEX IF NOT EXISTS idx_task_completions_day ON task_completions(completed_at);
+
+-- Tasks done before this table existed have one completion each. Those
+-- finished before completed_at was recorded have no known day and none.
+INSERT INTO task_completions (task_id, completed_at)
+SELECT id, completed_at FROM tasks WHERE status = 'done' AND completed_at IS NOT NULL;
+
+-- Imports may create tasks already done on an earlier day.
+CREATE TRIGGER IF NOT EXISTS task_completions_insert AFTER INSERT ON tasks
+WHEN NEW.status = 'done' BEGIN
+    INSERT INTO task_completions (task_id, completed_at)
+    VALUES (NEW.id, COALESCE(NEW.completed_at, datetime('now', 'localtime')));
+END;
+
+-- completed_at only has the day; completing a task today records the time
+-- too.
+CREATE TRIGGER IF NOT EXISTS task_completions_complete AFTER UPDATE OF status ON tasks
+WHEN NEW.status = 'done' AND OLD.status IS NOT 'done' BEGIN
+    INSERT INTO task_completions (task_id, completed_at)
+    VALUES (NEW.id, CASE
+        WHEN NEW.completed_at IS NULL OR NEW.completed_at = date('now', 'localtime') THEN datetime('now', 'localtime')
+        ELSE NEW.completed_at
+    END);
+END;
+
+-- Changing the completion date of a done task corrects its latest
+-- completion rather than adding one; a rewrite with the same day keeps the
+-- recorded time. Clearing it forgets the completion, as for tasks finished
+-- before completed_at was recorded.
+CREATE TRIGGER IF NOT EXISTS task_completions_redate AFTER UPDATE OF completed_at ON tasks
+WHEN NEW.status = 'done' AND OLD.status = 'done' AND NEW.completed_at IS NOT OLD.completed_at BEGIN
+    DELETE FROM task_completions
+    WHERE NEW.completed_at IS NULL
+    AND id = (SELECT MAX(id) FROM task_completions WHERE task_id = NEW.id);
+    UPDATE task_completions SET completed_at = NEW.completed_at
+    WHERE NEW.completed_at IS NOT NULL
+    AND id = (SELECT MAX(id) FROM task_completions WHERE task_id = NEW.id)
+    AND date(completed_at) IS NOT date(NEW.completed_at);
+END;
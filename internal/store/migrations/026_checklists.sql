@@ -0,0 +1,21 @@
+-- Checklist projects hold routines: the checklist-reset job puts their done
+-- tasks back in To Do daily or weekly at checklist_hour. Each time they were
+-- done stays in task_completions.
+ALTER TABLE projects ADD COLUMN checklist_reset TEXT NOT NULL DEFAULT '';
+ALTER TABLE projects ADD COLUMN checklist_hour INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN checklist_reset_at DATETIME;
+
+-- The schedule is part of what the project says.
+DROP TRIGGER IF EXISTS revision_project_update;
+CREATE TRIGGER IF NOT EXISTS revision_project_update AFTER UPDATE ON projects
+WHEN NEW.revision = OLD.revision AND (
+    NEW.name IS NOT OLD.name OR
+    NEW.description IS NOT OLD.description OR
+    NEW.type IS NOT OLD.type OR
+    NEW.target_date IS NOT OLD.target_date OR
+    NEW.completed IS NOT OLD.completed OR
+    NEW.checklist_reset IS NOT OLD.checklist_reset OR
+    NEW.checklist_hour IS NOT OLD.checklist_hour
+) BEGIN
+    UPDATE projects SET revision = OLD.revision + 1 WHERE id = NEW.id;
+END;
@@ -0,0 +1,13 @@
+-- Time spent focusing on a task, timed from the app. The session still
+-- running has no ended_at; there is at most one, so every device shows the
+-- same timer.
+CREATE TABLE IF NOT EXISTS focus_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    started_at DATETIME NOT NULL,
+    ended_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_focus_sessions_task ON focus_sessions(task_id, started_at);
+CREATE INDEX IF NOT EXISTS idx_focus_sessions_started ON focus_sessions(started_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_focus_sessions_running ON focus_sessions(ended_at IS NULL) WHERE ended_at IS NULL;
@@ -0,0 +1,12 @@
+-- My Day: the tasks picked for a day's plan, independent of due dates. Each
+-- day keeps its own plan, so unfinished items can be carried over to the
+-- next one.
+CREATE TABLE IF NOT EXISTS day_plans (
+    day DATE NOT NULL,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    added_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (day, task_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_day_plans_task ON day_plans(task_id);
@@ -0,0 +1,25 @@
+-- Someday/Maybe tasks are parked: they stay out of the boards, lists, counts
+-- and reminders until promoted back, and are reviewed on their own page and
+-- during the weekly review.
+ALTER TABLE tasks ADD COLUMN someday BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Parking a task or promoting it back changes what the task says.
+DROP TRIGGER IF EXISTS revision_task_update;
+CREATE TRIGGER IF NOT EXISTS revision_task_update AFTER UPDATE ON tasks
+WHEN NEW.revision = OLD.revision AND (
+    NEW.project_id IS NOT OLD.project_id OR
+    NEW.description IS NOT OLD.description OR
+    NEW.notes IS NOT OLD.notes OR
+    NEW.priority IS NOT OLD.priority OR
+    NEW.status IS NOT OLD.status OR
+    NEW.due_date IS NOT OLD.due_date OR
+    NEW.completed IS NOT OLD.completed OR
+    NEW.someday IS NOT OLD.someday
+) BEGIN
+    UPDATE tasks SET revision = OLD.revision + 1 WHERE id = NEW.id;
+END;
+
+-- The count badges skip someday tasks, so the index behind them covers
+-- someday too.
+DROP INDEX IF EXISTS idx_tasks_project_status_due;
+CREATE INDEX IF NOT EXISTS idx_tasks_project_status_due ON tasks(project_id, status, deleted_at, someday, due_date);
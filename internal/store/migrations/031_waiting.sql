@@ -0,0 +1,26 @@
+-- Delegated tasks wait on someone else: waiting_on names who, and
+-- waiting_since is when the wait began. Waiting tasks stay on their boards
+-- but not in the open counts, and are followed up from the Waiting For view.
+ALTER TABLE tasks ADD COLUMN waiting_on TEXT NOT NULL DEFAULT '';
+ALTER TABLE tasks ADD COLUMN waiting_since DATETIME;
+
+-- Who a task waits on is part of what it says.
+DROP TRIGGER IF EXISTS revision_task_update;
+CREATE TRIGGER IF NOT EXISTS revision_task_update AFTER UPDATE ON tasks
+WHEN NEW.revision = OLD.revision AND (
+    NEW.project_id IS NOT OLD.project_id OR
+    NEW.description IS NOT OLD.description OR
+    NEW.notes IS NOT OLD.notes OR
+    NEW.priority IS NOT OLD.priority OR
+    NEW.status IS NOT OLD.status OR
+    NEW.due_date IS NOT OLD.due_date OR
+    NEW.completed IS NOT OLD.completed OR
+    NEW.someday IS NOT OLD.someday OR
+    NEW.waiting_on IS NOT OLD.waiting_on
+) BEGIN
+    UPDATE tasks SET revision = OLD.revision + 1 WHERE id = NEW.id;
+END;
+
+-- The count badges skip waiting tasks too.
+DROP INDEX IF EXISTS idx_tasks_project_status_due;
+CREATE INDEX IF NOT EXISTS idx_tasks_project_status_due ON tasks(project_id, status, deleted_at, someday, waiting_on, due_date);
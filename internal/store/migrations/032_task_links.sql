@@ -0,0 +1,25 @@
+-- Links between tasks that are connected or duplicate each other. A link
+-- is stored once, from the task it was made on, and shown on both tasks.
+CREATE TABLE IF NOT EXISTS task_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    related_task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    relation TEXT NOT NULL DEFAULT 'related',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (task_id != related_task_id),
+    UNIQUE (task_id, related_task_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_links_related ON task_links(related_task_id);
+
+-- Links are part of both tasks for the change log.
+CREATE TRIGGER IF NOT EXISTS changes_task_link_insert AFTER INSERT ON task_links BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id IN (NEW.task_id, NEW.related_task_id);
+    INSERT INTO changes (entity, entity_id, op) VALUES ('task', NEW.task_id, 'update'), ('task', NEW.related_task_id, 'update');
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_task_link_delete AFTER DELETE ON task_links BEGIN
+    DELETE FROM changes WHERE entity = 'task' AND entity_id IN (OLD.task_id, OLD.related_task_id);
+    INSERT INTO changes (entity, entity_id, op)
+    SELECT 'task', id, 'update' FROM tasks WHERE id IN (OLD.task_id, OLD.related_task_id);
+END;
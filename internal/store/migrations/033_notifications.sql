@@ -0,0 +1,13 @@
+-- In-app notification inbox, written from domain events so reminders, sync
+-- changes and finished imports are seen even without email or push.
+CREATE TABLE IF NOT EXISTS notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    message TEXT NOT NULL,
+    task_id INTEGER REFERENCES tasks(id) ON DELETE SET NULL,
+    project_id INTEGER REFERENCES projects(id) ON DELETE SET NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    read_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(read_at, created_at);
@@ -0,0 +1,10 @@
+-- Per-project notification preferences. Projects without a row are announced
+-- on every channel.
+CREATE TABLE IF NOT EXISTS project_notification_prefs (
+    project_id INTEGER PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    muted BOOLEAN NOT NULL DEFAULT FALSE,
+    email BOOLEAN NOT NULL DEFAULT TRUE,
+    slack BOOLEAN NOT NULL DEFAULT TRUE,
+    inbox BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,55 @@
+-- What the last edit of each task and project changed, for the Recent view,
+-- as a comma-separated list of field names. As with revisions, only changes
+-- to what an item says count; reordering and priority escalation don't.
+CREATE TABLE IF NOT EXISTS recent_edits (
+    entity TEXT NOT NULL CHECK(entity IN ('project', 'task')),
+    entity_id INTEGER NOT NULL,
+    fields TEXT NOT NULL,
+    PRIMARY KEY (entity, entity_id)
+);
+
+CREATE TRIGGER IF NOT EXISTS recent_edits_task_update AFTER UPDATE ON tasks
+WHEN NEW.project_id IS NOT OLD.project_id OR
+    NEW.description IS NOT OLD.description OR
+    NEW.notes IS NOT OLD.notes OR
+    NEW.priority IS NOT OLD.priority OR
+    NEW.status IS NOT OLD.status OR
+    NEW.due_date IS NOT OLD.due_date OR
+    NEW.someday IS NOT OLD.someday OR
+    NEW.waiting_on IS NOT OLD.waiting_on
+BEGIN
+    INSERT INTO recent_edits (entity, entity_id, fields) VALUES ('task', NEW.id, substr(
+        CASE WHEN NEW.description IS NOT OLD.description THEN ',description' ELSE '' END ||
+        CASE WHEN NEW.notes IS NOT OLD.notes THEN ',notes' ELSE '' END ||
+        CASE WHEN NEW.priority IS NOT OLD.priority THEN ',priority' ELSE '' END ||
+        CASE WHEN NEW.status IS NOT OLD.status THEN ',status' ELSE '' END ||
+        CASE WHEN NEW.due_date IS NOT OLD.due_date THEN ',due date' ELSE '' END ||
+        CASE WHEN NEW.project_id IS NOT OLD.project_id THEN ',project' ELSE '' END ||
+        CASE WHEN NEW.someday IS NOT OLD.someday THEN ',someday' ELSE '' END ||
+        CASE WHEN NEW.waiting_on IS NOT OLD.waiting_on THEN ',waiting on' ELSE '' END, 2))
+    ON CONFLICT(entity, entity_id) DO UPDATE SET fields = excluded.fields;
+END;
+
+CREATE TRIGGER IF NOT EXISTS recent_edits_task_delete AFTER DELETE ON tasks BEGIN
+    DELETE FROM recent_edits WHERE entity = 'task' AND entity_id = OLD.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS recent_edits_project_update AFTER UPDATE ON projects
+WHEN NEW.name IS NOT OLD.name OR
+    NEW.description IS NOT OLD.description OR
+    NEW.type IS NOT OLD.type OR
+    NEW.target_date IS NOT OLD.target_date OR
+    NEW.completed IS NOT OLD.completed
+BEGIN
+    INSERT INTO recent_edits (entity, entity_id, fields) VALUES ('project', NEW.id, substr(
+        CASE WHEN NEW.name IS NOT OLD.name THEN ',name' ELSE '' END ||
+        CASE WHEN NEW.description IS NOT OLD.description THEN ',description' ELSE '' END ||
+        CASE WHEN NEW.type IS NOT OLD.type THEN ',type' ELSE '' END ||
+        CASE WHEN NEW.target_date IS NOT OLD.target_date THEN ',target date' ELSE '' END ||
+        CASE WHEN NEW.completed IS NOT OLD.completed THEN ',completed' ELSE '' END, 2))
+    ON CONFLICT(entity, entity_id) DO UPDATE SET fields = excluded.fields;
+END;
+
+CREATE TRIGGER IF NOT EXISTS recent_edits_project_delete AFTER DELETE ON projects BEGIN
+    DELETE FROM recent_edits WHERE entity = 'project' AND entity_id = OLD.id;
+END;
@@ -0,0 +1,11 @@
+-- Reports emailed on a schedule, e.g. tasks completed and overdue every
+-- Friday at 16:00.
+CREATE TABLE IF NOT EXISTS report_schedules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    recipients TEXT NOT NULL, -- comma-separated
+    schedule TEXT NOT NULL,   -- five-field cron expression
+    days INTEGER NOT NULL DEFAULT 7 CHECK(days > 0),
+    last_run_at DATETIME,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,27 @@
+-- Single-row table holding the Google Calendar OAuth connection.
+CREATE TABLE IF NOT EXISTS google_calendar_account (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    refresh_token TEXT NOT NULL,
+    access_token TEXT NOT NULL DEFAULT '',
+    token_expiry DATETIME,
+    calendar_id TEXT NOT NULL DEFAULT 'primary',
+    last_synced_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Links tasks to the calendar events sync created for them, with the state
+-- both sides agreed on at the last sync, so a change can be told apart from
+-- a conflict. task_id has no foreign key: the event of a purged task is
+-- still deleted by the next sync.
+CREATE TABLE IF NOT EXISTS calendar_events (
+    task_id INTEGER PRIMARY KEY,
+    event_id TEXT NOT NULL UNIQUE,
+    etag TEXT NOT NULL DEFAULT '',
+    title TEXT NOT NULL DEFAULT '',
+    due_date TEXT NOT NULL, -- YYYY-MM-DD
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    conflict TEXT NOT NULL DEFAULT '',
+    conflict_at DATETIME,
+    synced_at DATETIME NOT NULL
+);
@@ -0,0 +1,7 @@
+-- Filter expressions saved under a name, picked from the All Tasks list.
+CREATE TABLE IF NOT EXISTS saved_filters (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    query TEXT NOT NULL, -- in the task filter language, see internal/taskql
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -5,17 +5,24 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 
 	"mytasks/internal/models"
+	"mytasks/internal/taskql"
 )
 
 // SQLiteStore implements the Store interface using SQLite.
 type SQLiteStore struct {
 	db *sql.DB
+
+	stmtsMu sync.Mutex
+	stmts   map[string]*sql.Stmt
 }
 
 var sqliteDateLayouts = []string{
@@ -44,11 +51,16 @@ func parseSQLiteDate(value string) (*time.Time, error) {
 
 // NewSQLiteStore creates a new SQLite store with the given database path.
 func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
-	dsn := dbPath + "?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000"
-	db, err := sql.Open("sqlite3", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+	return NewTunedSQLiteStore(dbPath, DefaultTuning())
+}
+
+// NewTunedSQLiteStore creates a new SQLite store with the given database
+// path, applying tuning to its connections.
+func NewTunedSQLiteStore(dbPath string, tuning Tuning) (*SQLiteStore, error) {
+	if err := tuning.Validate(); err != nil {
+		return nil, err
 	}
+	db := sql.OpenDB(newConnector(dbPath+"?_foreign_keys=on&_journal_mode=WAL", tuning))
 	db.SetMaxOpenConns(1)
 	db.SetMaxIdleConns(1)
 
@@ -66,20 +78,52 @@ func (s *SQLiteStore) DB() *sql.DB {
 	return s.db
 }
 
+// Stats returns the connection pool statistics, for diagnostics.
+func (s *SQLiteStore) Stats() sql.DBStats {
+	return s.db.Stats()
+}
+
 func (s *SQLiteStore) migrate() error {
 	return runMigrations(s.db)
 }
 
 // Close closes the database connection.
 func (s *SQLiteStore) Close() error {
+	s.stmtsMu.Lock()
+	for _, stmt := range s.stmts {
+		stmt.Close()
+	}
+	s.stmts = nil
+	s.stmtsMu.Unlock()
 	return s.db.Close()
 }
 
+// stmt returns query prepared on first use and kept until Close, for the
+// queries behind every page load, which would otherwise be parsed and
+// planned again on each call. Queries run inside a transaction can't use it.
+func (s *SQLiteStore) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	s.stmtsMu.Lock()
+	defer s.stmtsMu.Unlock()
+	if stmt, ok := s.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := s.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	if s.stmts == nil {
+		s.stmts = map[string]*sql.Stmt{}
+	}
+	s.stmts[query] = stmt
+	return stmt, nil
+}
+
 // CreateProject creates a new project in the database.
 func (s *SQLiteStore) CreateProject(ctx context.Context, project *models.Project) error {
 	now := time.Now()
 	project.CreatedAt = now
 	project.UpdatedAt = now
+	project.Revision = 1
 
 	var targetDate interface{}
 	if project.TargetDate != nil {
@@ -90,13 +134,26 @@ func (s *SQLiteStore) CreateProject(ctx context.Context, project *models.Project
 	if sortOrder <= 0 {
 		sortOrder = -1
 	}
+	if project.SortMode == "" {
+		project.SortMode = models.SortManual
+	}
+
+	// A new checklist starts fresh; its first reset is the next scheduled one.
+	var checklistResetAt interface{}
+	project.ChecklistResetAt = nil
+	if project.IsChecklist() {
+		project.ChecklistResetAt = &now
+		checklistResetAt = now
+	}
 
 	result, err := s.db.ExecContext(ctx, `
-		INSERT INTO projects (name, description, type, target_date, completed, completed_at, sort_order, created_at, updated_at)
+		INSERT INTO projects (name, description, type, target_date, completed, completed_at, sort_order, sort_mode,
+			checklist_reset, checklist_hour, checklist_reset_at, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?,
 			CASE WHEN ? > 0 THEN ? ELSE COALESCE((SELECT MAX(sort_order) + 1 FROM projects), 1) END,
-			?, ?)
-	`, project.Name, project.Description, project.Type, targetDate, false, nil, sortOrder, sortOrder, now, now)
+			?, ?, ?, ?, ?, ?)
+	`, project.Name, project.Description, project.Type, targetDate, false, nil, sortOrder, sortOrder, project.SortMode,
+		project.ChecklistReset, project.ChecklistHour, checklistResetAt, now, now)
 	if err != nil {
 		return fmt.Errorf("failed to create project: %w", err)
 	}
@@ -114,57 +171,64 @@ func (s *SQLiteStore) CreateProject(ctx context.Context, project *models.Project
 	return nil
 }
 
-// GetProject retrieves a project by ID.
-func (s *SQLiteStore) GetProject(ctx context.Context, id int64) (*models.Project, error) {
-	project := &models.Project{}
-	var targetDate sql.NullString
-	var completedAt sql.NullString
-
-	err := s.db.QueryRowContext(ctx, `
-		SELECT id, name, description, type, target_date, completed, completed_at, sort_order, created_at, updated_at
-		FROM projects WHERE id = ?
-	`, id).Scan(
-		&project.ID,
-		&project.Name,
-		&project.Description,
-		&project.Type,
-		&targetDate,
-		&project.Completed,
-		&completedAt,
-		&project.SortOrder,
-		&project.CreatedAt,
-		&project.UpdatedAt,
-	)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("project not found: %d", id)
-		}
-		return nil, fmt.Errorf("failed to get project: %w", err)
+// projectColumns lists the projects columns scanProject reads, in order.
+const projectColumns = `id, name, description, type, target_date, completed, completed_at, sort_order, sort_mode, checklist_reset, checklist_hour, checklist_reset_at, created_at, updated_at, revision`
+
+// scanProject scans a row selecting projectColumns, then extra, into project.
+func scanProject(row interface{ Scan(...interface{}) error }, project *models.Project, extra ...interface{}) error {
+	var targetDate, completedAt, checklistResetAt sql.NullString
+	dest := []interface{}{
+		&project.ID, &project.Name, &project.Description, &project.Type,
+		&targetDate, &project.Completed, &completedAt, &project.SortOrder, &project.SortMode,
+		&project.ChecklistReset, &project.ChecklistHour, &checklistResetAt,
+		&project.CreatedAt, &project.UpdatedAt, &project.Revision,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
 	}
 
+	var err error
 	if targetDate.Valid {
-		parsedDate, err := parseSQLiteDate(targetDate.String)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse project target_date: %w", err)
+		if project.TargetDate, err = parseSQLiteDate(targetDate.String); err != nil {
+			return fmt.Errorf("failed to parse project target_date: %w", err)
 		}
-		project.TargetDate = parsedDate
 	}
-
 	if completedAt.Valid {
-		parsedDate, err := parseSQLiteDate(completedAt.String)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse project completed_at: %w", err)
+		if project.CompletedAt, err = parseSQLiteDate(completedAt.String); err != nil {
+			return fmt.Errorf("failed to parse project completed_at: %w", err)
 		}
-		project.CompletedAt = parsedDate
 	}
+	if checklistResetAt.Valid {
+		if project.ChecklistResetAt, err = parseSQLiteDate(checklistResetAt.String); err != nil {
+			return fmt.Errorf("failed to parse project checklist_reset_at: %w", err)
+		}
+	}
+	return nil
+}
 
+// GetProject retrieves a project by ID.
+func (s *SQLiteStore) GetProject(ctx context.Context, id int64) (*models.Project, error) {
+	stmt, err := s.stmt(ctx, `
+		SELECT `+projectColumns+`
+		FROM projects WHERE id = ?
+	`)
+	if err != nil {
+		return nil, err
+	}
+	project := &models.Project{}
+	if err := scanProject(stmt.QueryRowContext(ctx, id), project); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("project %d: %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
 	return project, nil
 }
 
 // ListProjects retrieves all projects ordered by sort_order.
 func (s *SQLiteStore) ListProjects(ctx context.Context) ([]models.Project, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, name, description, type, target_date, completed, completed_at, sort_order, created_at, updated_at
+		SELECT `+projectColumns+`
 		FROM projects ORDER BY sort_order ASC
 	`)
 	if err != nil {
@@ -175,41 +239,9 @@ func (s *SQLiteStore) ListProjects(ctx context.Context) ([]models.Project, error
 	var projects []models.Project
 	for rows.Next() {
 		var project models.Project
-		var targetDate sql.NullString
-		var completedAt sql.NullString
-
-		err := rows.Scan(
-			&project.ID,
-			&project.Name,
-			&project.Description,
-			&project.Type,
-			&targetDate,
-			&project.Completed,
-			&completedAt,
-			&project.SortOrder,
-			&project.CreatedAt,
-			&project.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanProject(rows, &project); err != nil {
 			return nil, fmt.Errorf("failed to scan project: %w", err)
 		}
-
-		if targetDate.Valid {
-			parsedDate, err := parseSQLiteDate(targetDate.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse project target_date: %w", err)
-			}
-			project.TargetDate = parsedDate
-		}
-
-		if completedAt.Valid {
-			parsedDate, err := parseSQLiteDate(completedAt.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse project completed_at: %w", err)
-			}
-			project.CompletedAt = parsedDate
-		}
-
 		projects = append(projects, project)
 	}
 
@@ -218,6 +250,18 @@ func (s *SQLiteStore) ListProjects(ctx context.Context) ([]models.Project, error
 
 // UpdateProject updates an existing project.
 func (s *SQLiteStore) UpdateProject(ctx context.Context, project *models.Project) error {
+	return s.updateProject(ctx, project, 0)
+}
+
+// UpdateProjectAtRevision updates a project only if it is still at
+// revision, and fails with ErrStale otherwise, so of two edits made to the
+// same revision the second is refused rather than lost.
+func (s *SQLiteStore) UpdateProjectAtRevision(ctx context.Context, project *models.Project, revision int64) error {
+	return s.updateProject(ctx, project, revision)
+}
+
+// updateProject updates a project, guarded by revision unless it is 0.
+func (s *SQLiteStore) updateProject(ctx context.Context, project *models.Project, revision int64) error {
 	project.UpdatedAt = time.Now()
 
 	var targetDate interface{}
@@ -234,15 +278,39 @@ func (s *SQLiteStore) UpdateProject(ctx context.Context, project *models.Project
 		completedAt = project.CompletedAt.Format("2006-01-02")
 	}
 
-	_, err := s.db.ExecContext(ctx, `
+	// Turning a project into a checklist counts as its first reset, so
+	// tasks already done stay done until the next scheduled one.
+	result, err := s.db.ExecContext(ctx, `
 		UPDATE projects
-		SET name = ?, description = ?, type = ?, target_date = ?, completed = ?, completed_at = ?, sort_order = ?, updated_at = ?
-		WHERE id = ?
-	`, project.Name, project.Description, project.Type, targetDate, project.Completed, completedAt, project.SortOrder, project.UpdatedAt, project.ID)
+		SET name = ?, description = ?, type = ?, target_date = ?, completed = ?, completed_at = ?, sort_order = ?, sort_mode = ?,
+		    checklist_reset = ?, checklist_hour = ?,
+		    checklist_reset_at = CASE WHEN ? = '' THEN NULL ELSE COALESCE(checklist_reset_at, ?) END,
+		    updated_at = ?
+		WHERE id = ? AND (? = 0 OR revision = ?)
+	`, project.Name, project.Description, project.Type, targetDate, project.Completed, completedAt, project.SortOrder, project.SortMode,
+		project.ChecklistReset, project.ChecklistHour, project.ChecklistReset, project.UpdatedAt,
+		project.UpdatedAt, project.ID, revision, revision)
 	if err != nil {
 		return fmt.Errorf("failed to update project: %w", err)
 	}
+	if revision != 0 {
+		if n, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to update project: %w", err)
+		} else if n == 0 {
+			return fmt.Errorf("project %d: %w", project.ID, ErrStale)
+		}
+	}
 
+	var checklistResetAt sql.NullString
+	if err := s.db.QueryRowContext(ctx, `SELECT revision, checklist_reset_at FROM projects WHERE id = ?`, project.ID).Scan(&project.Revision, &checklistResetAt); err != nil {
+		return fmt.Errorf("failed to load project revision: %w", err)
+	}
+	project.ChecklistResetAt = nil
+	if checklistResetAt.Valid {
+		if project.ChecklistResetAt, err = parseSQLiteDate(checklistResetAt.String); err != nil {
+			return fmt.Errorf("failed to parse project checklist_reset_at: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -289,6 +357,44 @@ func (s *SQLiteStore) DeleteProject(ctx context.Context, id int64) error {
 	return nil
 }
 
+// ResetChecklist puts the done tasks of a checklist project back in To Do
+// and records at as its latest reset. Their completions stay in
+// task_completions. It returns the number of tasks reset.
+func (s *SQLiteStore) ResetChecklist(ctx context.Context, projectID int64, at time.Time) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE projects SET checklist_reset_at = ? WHERE id = ?
+	`, at, projectID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record checklist reset: %w", err)
+	}
+	if err := expectOneRow(result, "project", projectID); err != nil {
+		return 0, err
+	}
+
+	result, err = tx.ExecContext(ctx, `
+		UPDATE tasks
+		SET status = 'todo',
+		    completed = FALSE,
+		    completed_at = NULL,
+		    updated_at = ?
+		WHERE project_id = ? AND status = 'done' AND deleted_at IS NULL
+	`, at, projectID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset checklist tasks: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count reset tasks: %w", err)
+	}
+	return n, tx.Commit()
+}
+
 // ReorderProjects updates the sort_order of projects based on the given order of IDs.
 func (s *SQLiteStore) ReorderProjects(ctx context.Context, ids []int64) error {
 	tx, err := s.db.BeginTx(ctx, nil)
@@ -313,8 +419,147 @@ func (s *SQLiteStore) ReorderProjects(ctx context.Context, ids []int64) error {
 	return tx.Commit()
 }
 
+// ProjectTaskCounts counts the open and done tasks of each active project,
+// keyed by project ID. Projects without tasks are left out. today is the
+// first day of the week counted as due this week.
+func (s *SQLiteStore) ProjectTaskCounts(ctx context.Context, today time.Time) (map[int64]models.ProjectCounts, error) {
+	// Done tasks pile up, so they are only counted, from the index, rather
+	// than joined and read like the open ones.
+	stmt, err := s.stmt(ctx, `
+		SELECT p.id,
+			COUNT(t.id),
+			COALESCE(SUM(date(t.due_date) < ?1), 0),
+			COALESCE(SUM(date(t.due_date) >= ?1 AND date(t.due_date) < ?2), 0),
+			(SELECT COUNT(*) FROM tasks d WHERE d.project_id = p.id AND d.status = 'done' AND d.deleted_at IS NULL)
+		FROM projects p
+		LEFT JOIN tasks t ON t.project_id = p.id AND t.status IN ('todo', 'in_progress') AND t.deleted_at IS NULL AND t.someday = FALSE AND t.waiting_on = ''
+		WHERE p.completed = FALSE
+		GROUP BY p.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, today.Format("2006-01-02"), today.AddDate(0, 0, 7).Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count project tasks: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[int64]models.ProjectCounts)
+	for rows.Next() {
+		var id int64
+		var c models.ProjectCounts
+		if err := rows.Scan(&id, &c.Open, &c.Overdue, &c.DueThisWeek, &c.Done); err != nil {
+			return nil, fmt.Errorf("failed to scan project task counts: %w", err)
+		}
+		if c.Total() == 0 {
+			continue
+		}
+		counts[id] = c
+	}
+	return counts, rows.Err()
+}
+
+// CreateProjectLink adds a link after a project's existing ones.
+func (s *SQLiteStore) CreateProjectLink(ctx context.Context, link *models.ProjectLink) error {
+	link.CreatedAt = time.Now()
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO project_links (project_id, title, url, sort_order, created_at)
+		VALUES (?, ?, ?, COALESCE((SELECT MAX(sort_order) + 1 FROM project_links WHERE project_id = ?), 1), ?)
+	`, link.ProjectID, link.Title, link.URL, link.ProjectID, link.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create project link: %w", err)
+	}
+	if link.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	if err := s.db.QueryRowContext(ctx, `SELECT sort_order FROM project_links WHERE id = ?`, link.ID).Scan(&link.SortOrder); err != nil {
+		return fmt.Errorf("failed to load project link sort order: %w", err)
+	}
+	return nil
+}
+
+// GetProjectLink retrieves a project link by ID.
+func (s *SQLiteStore) GetProjectLink(ctx context.Context, id int64) (*models.ProjectLink, error) {
+	var l models.ProjectLink
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, project_id, title, url, sort_order, created_at FROM project_links WHERE id = ?
+	`, id).Scan(&l.ID, &l.ProjectID, &l.Title, &l.URL, &l.SortOrder, &l.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("project link %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project link: %w", err)
+	}
+	return &l, nil
+}
+
+// ListProjectLinks retrieves a project's links in the order they were added.
+func (s *SQLiteStore) ListProjectLinks(ctx context.Context, projectID int64) ([]models.ProjectLink, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, project_id, title, url, sort_order, created_at
+		FROM project_links WHERE project_id = ? ORDER BY sort_order, id
+	`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []models.ProjectLink
+	for rows.Next() {
+		var l models.ProjectLink
+		if err := rows.Scan(&l.ID, &l.ProjectID, &l.Title, &l.URL, &l.SortOrder, &l.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan project link: %w", err)
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// UpdateProjectLink changes a link's title and URL.
+func (s *SQLiteStore) UpdateProjectLink(ctx context.Context, link *models.ProjectLink) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE project_links SET title = ?, url = ? WHERE id = ?
+	`, link.Title, link.URL, link.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update project link: %w", err)
+	}
+	return expectOneRow(result, "project link", link.ID)
+}
+
+// DeleteProjectLink deletes a project link.
+func (s *SQLiteStore) DeleteProjectLink(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM project_links WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete project link: %w", err)
+	}
+	return expectOneRow(result, "project link", id)
+}
+
 // CreateTask creates a new task in the database.
 func (s *SQLiteStore) CreateTask(ctx context.Context, task *models.Task) error {
+	return s.CreateTasks(ctx, []*models.Task{task})
+}
+
+// CreateTasks creates several tasks in one transaction: either all of them
+// are saved or none are.
+func (s *SQLiteStore) CreateTasks(ctx context.Context, tasks []*models.Task) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, task := range tasks {
+		if err := insertTask(ctx, tx, task); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func insertTask(ctx context.Context, tx *sql.Tx, task *models.Task) error {
 	now := time.Now()
 	task.CreatedAt = now
 	task.UpdatedAt = now
@@ -348,12 +593,17 @@ func (s *SQLiteStore) CreateTask(ctx context.Context, task *models.Task) error {
 		sortOrder = -1
 	}
 
-	result, err := s.db.ExecContext(ctx, `
-		INSERT INTO tasks (project_id, description, notes, priority, status, due_date, completed, completed_at, sort_order, created_at, updated_at)
+	task.WaitingSince = nil
+	if task.Waiting() {
+		task.WaitingSince = &now
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO tasks (project_id, description, notes, priority, status, due_date, completed, completed_at, sort_order, waiting_on, waiting_since, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?,
 			CASE WHEN ? > 0 THEN ? ELSE COALESCE((SELECT MAX(sort_order) + 1 FROM tasks WHERE project_id = ? AND status = ?), 1) END,
-			?, ?)
-	`, task.ProjectID, task.Description, task.Notes, task.Priority, task.Status, dueDate, task.Completed, completedAt, sortOrder, sortOrder, task.ProjectID, task.Status, now, now)
+			?, ?, ?, ?)
+	`, task.ProjectID, task.Description, task.Notes, task.Priority, task.Status, dueDate, task.Completed, completedAt, sortOrder, sortOrder, task.ProjectID, task.Status, task.WaitingOn, task.WaitingSince, now, now)
 	if err != nil {
 		return fmt.Errorf("failed to create task: %w", err)
 	}
@@ -364,72 +614,93 @@ func (s *SQLiteStore) CreateTask(ctx context.Context, task *models.Task) error {
 	}
 	task.ID = id
 
-	if err := s.db.QueryRowContext(ctx, `SELECT sort_order FROM tasks WHERE id = ?`, id).Scan(&task.SortOrder); err != nil {
+	if err := tx.QueryRowContext(ctx, `SELECT sort_order FROM tasks WHERE id = ?`, id).Scan(&task.SortOrder); err != nil {
 		return fmt.Errorf("failed to load task sort order: %w", err)
 	}
 
+	if len(task.Tags) > 0 {
+		task.Tags = models.NormalizeTags(task.Tags)
+		for _, tag := range task.Tags {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO task_tags (task_id, tag) VALUES (?, ?)`, task.ID, tag); err != nil {
+				return fmt.Errorf("failed to insert task tag: %w", err)
+			}
+		}
+	}
+
+	if task.Recurrence != "" {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO task_recurrences (task_id, rule) VALUES (?, ?)`, task.ID, task.Recurrence); err != nil {
+			return fmt.Errorf("failed to save task recurrence: %w", err)
+		}
+	}
+
+	// Adding tags and the repeat rule counted as revisions.
+	if err := tx.QueryRowContext(ctx, `SELECT revision FROM tasks WHERE id = ?`, id).Scan(&task.Revision); err != nil {
+		return fmt.Errorf("failed to load task revision: %w", err)
+	}
 	return nil
 }
 
 // GetTask retrieves a task by ID.
 func (s *SQLiteStore) GetTask(ctx context.Context, id int64) (*models.Task, error) {
-	task := &models.Task{}
-	var dueDate sql.NullString
-	var completedAt sql.NullString
-
-	err := s.db.QueryRowContext(ctx, `
-		SELECT id, project_id, description, notes, priority, status, due_date, completed, completed_at, sort_order, created_at, updated_at
-		FROM tasks WHERE id = ?
-	`, id).Scan(
-		&task.ID,
-		&task.ProjectID,
-		&task.Description,
-		&task.Notes,
-		&task.Priority,
-		&task.Status,
-		&dueDate,
-		&task.Completed,
-		&completedAt,
-		&task.SortOrder,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-	)
+	stmt, err := s.stmt(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id = ? AND deleted_at IS NULL`)
 	if err != nil {
+		return nil, err
+	}
+	task := &models.Task{}
+	if err := scanTask(stmt.QueryRowContext(ctx, id), task, time.Now()); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("task not found: %d", id)
+			return nil, fmt.Errorf("task %d: %w", id, ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get task: %w", err)
 	}
 
-	if dueDate.Valid {
-		parsedDate, err := parseSQLiteDate(dueDate.String)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse task due_date: %w", err)
-		}
-		task.DueDate = parsedDate
+	tags, err := s.taskTags(ctx, task.ID)
+	if err != nil {
+		return nil, err
 	}
+	task.Tags = tags
 
-	if completedAt.Valid {
-		parsedDate, err := parseSQLiteDate(completedAt.String)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse task completed_at: %w", err)
-		}
-		task.CompletedAt = parsedDate
+	if stmt, err = s.stmt(ctx, `SELECT rule FROM task_recurrences WHERE task_id = ?`); err != nil {
+		return nil, err
+	}
+	err = stmt.QueryRowContext(ctx, task.ID).Scan(&task.Recurrence)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to load task recurrence: %w", err)
 	}
-
 	return task, nil
 }
 
-// ListTasks retrieves all tasks, optionally filtered to tasks completed on/after completedSince.
-func (s *SQLiteStore) ListTasks(ctx context.Context, completedSince *time.Time) ([]models.Task, error) {
+// SetTaskSomeday parks a task in Someday/Maybe, or promotes it back to the
+// active lists, and returns it updated.
+func (s *SQLiteStore) SetTaskSomeday(ctx context.Context, id int64, someday bool) (*models.Task, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE tasks SET someday = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL
+	`, someday, time.Now(), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update task: %w", err)
+	}
+	if err := expectOneRow(result, "task", id); err != nil {
+		return nil, err
+	}
+	return s.GetTask(ctx, id)
+}
+
+// ListTasks retrieves all tasks, optionally filtered to tasks completed on/after completedSince
+// and to those match, if not nil, matches.
+func (s *SQLiteStore) ListTasks(ctx context.Context, completedSince *time.Time, match *taskql.Query) ([]models.Task, error) {
 	query := `
-		SELECT id, project_id, description, notes, priority, status, due_date, completed, completed_at, sort_order, created_at, updated_at
-		FROM tasks
+		SELECT ` + taskColumns + `
+		FROM tasks t WHERE deleted_at IS NULL
 	`
 	args := []interface{}{}
 
+	if match != nil {
+		where, matchArgs := match.SQL()
+		query += ` AND ` + where
+		args = append(args, matchArgs...)
+	}
 	if completedSince != nil {
-		query += ` WHERE status = 'done' AND completed_at IS NOT NULL AND completed_at >= ?`
+		query += ` AND status = 'done' AND completed_at IS NOT NULL AND completed_at >= ?`
 		args = append(args, completedSince.Format("2006-01-02"))
 		query += ` ORDER BY completed_at DESC, sort_order ASC`
 	} else {
@@ -441,207 +712,269 @@ func (s *SQLiteStore) ListTasks(ctx context.Context, completedSince *time.Time)
 		return nil, fmt.Errorf("failed to list tasks: %w", err)
 	}
 	defer rows.Close()
+	return scanTasks(rows)
+}
 
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		var dueDate sql.NullString
-		var completedAt sql.NullString
-
-		err := rows.Scan(
-			&task.ID,
-			&task.ProjectID,
-			&task.Description,
-			&task.Notes,
-			&task.Priority,
-			&task.Status,
-			&dueDate,
-			&task.Completed,
-			&completedAt,
-			&task.SortOrder,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan task: %w", err)
-		}
+// ListTasksPage retrieves up to limit tasks after the keyset, or from the
+// start when after is nil. Tasks are ordered by sort_order, or, when
+// completedSince is set, are the ones completed on or after it, newest
+// completion first; match, if not nil, narrows them further. It also returns
+// the keyset of the last task, or nil when there are no more.
+func (s *SQLiteStore) ListTasksPage(ctx context.Context, completedSince *time.Time, match *taskql.Query, after *Keyset, limit int) ([]models.Task, *Keyset, error) {
+	if limit <= 0 {
+		return nil, nil, nil
+	}
+	keyColumn := "sort_order"
+	if completedSince != nil {
+		keyColumn = "completed_at"
+	}
+	query := `
+		SELECT ` + taskColumns + `, CAST(` + keyColumn + ` AS TEXT)
+		FROM tasks t WHERE deleted_at IS NULL
+	`
+	var args []interface{}
 
-		if dueDate.Valid {
-			parsedDate, err := parseSQLiteDate(dueDate.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse task due_date: %w", err)
+	if match != nil {
+		where, matchArgs := match.SQL()
+		query += ` AND ` + where
+		args = append(args, matchArgs...)
+	}
+
+	if completedSince != nil {
+		query += ` AND status = 'done' AND completed_at IS NOT NULL AND completed_at >= ?`
+		args = append(args, completedSince.Format("2006-01-02"))
+		if after != nil {
+			if date, err := parseSQLiteDate(after.Key); err != nil || date == nil {
+				return nil, nil, fmt.Errorf("completion date %q: %w", after.Key, ErrInvalidKeyset)
 			}
-			task.DueDate = parsedDate
+			query += ` AND (completed_at, id) < (?, ?)`
+			args = append(args, after.Key, after.ID)
 		}
-
-		if completedAt.Valid {
-			parsedDate, err := parseSQLiteDate(completedAt.String)
+		query += ` ORDER BY completed_at DESC, id DESC`
+	} else {
+		if after != nil {
+			sortOrder, err := strconv.ParseInt(after.Key, 10, 64)
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse task completed_at: %w", err)
+				return nil, nil, fmt.Errorf("sort order %q: %w", after.Key, ErrInvalidKeyset)
 			}
-			task.CompletedAt = parsedDate
+			query += ` AND (sort_order, id) > (?, ?)`
+			args = append(args, sortOrder, after.ID)
 		}
-
-		tasks = append(tasks, task)
+		query += ` ORDER BY sort_order ASC, id ASC`
 	}
+	query += ` LIMIT ?`
+	args = append(args, limit+1)
 
-	return tasks, rows.Err()
-}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	defer rows.Close()
 
-// ListTasksByProject retrieves tasks for a project ordered by sort_order.
-// If limit is 0, all tasks are returned.
-func (s *SQLiteStore) ListTasksByProject(ctx context.Context, projectID int64, limit int) ([]models.Task, error) {
-	query := `
-		SELECT id, project_id, description, notes, priority, status, due_date, completed, completed_at, sort_order, created_at, updated_at
-		FROM tasks WHERE project_id = ? ORDER BY sort_order ASC
-	`
-	args := []interface{}{projectID}
-	if limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, limit)
+	now := time.Now()
+	var tasks []models.Task
+	var next *Keyset
+	var lastKey string
+	for rows.Next() {
+		if len(tasks) == limit {
+			next = &Keyset{Key: lastKey, ID: tasks[limit-1].ID}
+			break
+		}
+		var task models.Task
+		if err := scanTask(rows, &task, now, &lastKey); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
 	}
+	return tasks, next, nil
+}
 
-	rows, err := s.db.QueryContext(ctx, query, args...)
+// ListTasksAfter returns up to limit tasks with IDs above afterID, in ID
+// order, so everything can be walked a page at a time.
+func (s *SQLiteStore) ListTasksAfter(ctx context.Context, afterID int64, limit int) ([]models.Task, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+taskColumns+`
+		FROM tasks WHERE id > ? AND deleted_at IS NULL ORDER BY id LIMIT ?
+	`, afterID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tasks: %w", err)
 	}
 	defer rows.Close()
+	return scanTasks(rows)
+}
 
+// scanTasks reads rows of taskColumns.
+func scanTasks(rows *sql.Rows) ([]models.Task, error) {
+	now := time.Now()
 	var tasks []models.Task
 	for rows.Next() {
 		var task models.Task
-		var dueDate sql.NullString
-		var completedAt sql.NullString
-
-		err := rows.Scan(
-			&task.ID,
-			&task.ProjectID,
-			&task.Description,
-			&task.Notes,
-			&task.Priority,
-			&task.Status,
-			&dueDate,
-			&task.Completed,
-			&completedAt,
-			&task.SortOrder,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanTask(rows, &task, now); err != nil {
 			return nil, fmt.Errorf("failed to scan task: %w", err)
 		}
-
-		if dueDate.Valid {
-			parsedDate, err := parseSQLiteDate(dueDate.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse task due_date: %w", err)
-			}
-			task.DueDate = parsedDate
-		}
-
-		if completedAt.Valid {
-			parsedDate, err := parseSQLiteDate(completedAt.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse task completed_at: %w", err)
-			}
-			task.CompletedAt = parsedDate
-		}
-
 		tasks = append(tasks, task)
 	}
-
 	return tasks, rows.Err()
 }
 
-// ListTasksByProjectFiltered retrieves tasks for a project filtered by completion status.
-// If limit is 0, all matching tasks are returned.
-func (s *SQLiteStore) ListTasksByProjectFiltered(ctx context.Context, projectID int64, completed bool, limit int) ([]models.Task, error) {
+// taskColumns are the columns scanTask reads, in order. The dates are read
+// as text: as DATE columns the driver would try each of its timestamp layouts
+// on them, failing on all but the last, only for the time to be formatted
+// back into a string to scan.
+const taskColumns = `id, project_id, description, notes, priority, escalated_priority, status, CAST(due_date AS TEXT), completed, CAST(completed_at AS TEXT), sort_order, created_at, updated_at, revision, someday, waiting_on, waiting_since`
+
+// joinedTaskColumns is taskColumns for queries that call the tasks table t.
+const joinedTaskColumns = `t.id, t.project_id, t.description, t.notes, t.priority, t.escalated_priority, t.status, CAST(t.due_date AS TEXT), t.completed, CAST(t.completed_at AS TEXT), t.sort_order, t.created_at, t.updated_at, t.revision, t.someday, t.waiting_on, t.waiting_since`
+
+// scanTask reads taskColumns, followed by extra, into task, and fills in the
+// fields worked out from them as of now.
+func scanTask(row interface{ Scan(...interface{}) error }, task *models.Task, now time.Time, extra ...interface{}) error {
+	var dueDate, completedAt sql.NullString
+	var waitingSince sql.NullTime
+	dest := append([]interface{}{
+		&task.ID,
+		&task.ProjectID,
+		&task.Description,
+		&task.Notes,
+		&task.Priority,
+		&task.EscalatedPriority,
+		&task.Status,
+		&dueDate,
+		&task.Completed,
+		&completedAt,
+		&task.SortOrder,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+		&task.Revision,
+		&task.Someday,
+		&task.WaitingOn,
+		&waitingSince,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+
+	var err error
+	if dueDate.Valid {
+		if task.DueDate, err = parseSQLiteDate(dueDate.String); err != nil {
+			return fmt.Errorf("failed to parse task due_date: %w", err)
+		}
+	}
+	if completedAt.Valid {
+		if task.CompletedAt, err = parseSQLiteDate(completedAt.String); err != nil {
+			return fmt.Errorf("failed to parse task completed_at: %w", err)
+		}
+	}
+	if waitingSince.Valid {
+		task.WaitingSince = &waitingSince.Time
+	}
+	task.Untouched = daysUntouched(task.UpdatedAt, now)
+	task.Urgency = task.UrgencyOn(now)
+	return nil
+}
+
+// ListTasksByProject retrieves tasks for a project in the project's sort mode.
+// If limit is 0, all tasks are returned.
+func (s *SQLiteStore) ListTasksByProject(ctx context.Context, projectID int64, limit int) ([]models.Task, error) {
+	orderBy, err := s.taskOrder(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
 	query := `
-		SELECT id, project_id, description, notes, priority, status, due_date, completed, completed_at, sort_order, created_at, updated_at
-		FROM tasks WHERE project_id = ? AND completed = ? ORDER BY sort_order ASC
-	`
-	args := []interface{}{projectID, completed}
+		SELECT ` + taskColumns + `
+		FROM tasks t WHERE project_id = ? AND deleted_at IS NULL ORDER BY ` + orderBy
+	args := []interface{}{projectID}
 	if limit > 0 {
 		query += " LIMIT ?"
 		args = append(args, limit)
 	}
 
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	stmt, err := s.stmt(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tasks: %w", err)
 	}
 	defer rows.Close()
 
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		var dueDate sql.NullString
-		var completedAt sql.NullString
-
-		err := rows.Scan(
-			&task.ID,
-			&task.ProjectID,
-			&task.Description,
-			&task.Notes,
-			&task.Priority,
-			&task.Status,
-			&dueDate,
-			&task.Completed,
-			&completedAt,
-			&task.SortOrder,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan task: %w", err)
-		}
-
-		if dueDate.Valid {
-			parsedDate, err := parseSQLiteDate(dueDate.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse task due_date: %w", err)
-			}
-			task.DueDate = parsedDate
-		}
+	return scanTasks(rows)
+}
 
-		if completedAt.Valid {
-			parsedDate, err := parseSQLiteDate(completedAt.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse task completed_at: %w", err)
-			}
-			task.CompletedAt = parsedDate
-		}
+// ListTasksByProjectFiltered retrieves tasks for a project filtered by completion status,
+// in the project's sort mode. If limit is 0, all matching tasks are returned.
+func (s *SQLiteStore) ListTasksByProjectFiltered(ctx context.Context, projectID int64, completed bool, limit int) ([]models.Task, error) {
+	orderBy, err := s.taskOrder(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks t WHERE project_id = ? AND completed = ? AND deleted_at IS NULL ORDER BY ` + orderBy
+	args := []interface{}{projectID, completed}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
 
-		tasks = append(tasks, task)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
 	}
+	defer rows.Close()
 
-	return tasks, rows.Err()
+	return scanTasks(rows)
 }
 
-// ListTasksByProjectCompletedBetween retrieves completed tasks for a project within a completion date range.
-// When from/to are nil they are not applied as filters. If limit is 0, all matching tasks are returned.
-func (s *SQLiteStore) ListTasksByProjectCompletedBetween(ctx context.Context, projectID int64, from, to *time.Time, limit int) ([]models.Task, error) {
-	query := `
-		SELECT id, project_id, description, notes, priority, status, due_date, completed, completed_at, sort_order, created_at, updated_at
-		FROM tasks WHERE project_id = ? AND completed = TRUE AND completed_at IS NOT NULL
-	`
+// daysUntouched counts the calendar days from updatedAt, a task's last
+// change, to now.
+func daysUntouched(updatedAt, now time.Time) int {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	day := time.Date(updatedAt.Year(), updatedAt.Month(), updatedAt.Day(), 0, 0, 0, 0, time.UTC)
+	return int(today.Sub(day).Hours() / 24)
+}
+
+// completedDateExpr is a done task's completion date, falling back to its
+// last update for tasks finished before completed_at was recorded.
+const completedDateExpr = `COALESCE(completed_at, substr(updated_at, 1, 10))`
+
+// completedBetweenWhere builds the shared filter for completed-range queries.
+func completedBetweenWhere(projectID int64, from, to *time.Time) (string, []interface{}) {
+	where := ` WHERE project_id = ? AND status = 'done' AND deleted_at IS NULL`
 	args := []interface{}{projectID}
 
 	if from != nil {
-		query += ` AND completed_at >= ?`
+		where += ` AND ` + completedDateExpr + ` >= ?`
 		args = append(args, from.Format("2006-01-02"))
 	}
 
 	if to != nil {
-		query += ` AND completed_at <= ?`
+		where += ` AND ` + completedDateExpr + ` <= ?`
 		args = append(args, to.Format("2006-01-02"))
 	}
 
-	query += ` ORDER BY completed_at DESC, sort_order ASC`
+	return where, args
+}
+
+// ListTasksByProjectCompletedBetween retrieves done tasks for a project within an inclusive completion date range,
+// newest first. When from/to are nil they are not applied as filters. If limit is 0, all matching tasks
+// after offset are returned.
+func (s *SQLiteStore) ListTasksByProjectCompletedBetween(ctx context.Context, projectID int64, from, to *time.Time, limit, offset int) ([]models.Task, error) {
+	where, args := completedBetweenWhere(projectID, from, to)
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks` + where + ` ORDER BY ` + completedDateExpr + ` DESC, sort_order ASC, id DESC`
 
-	if limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, limit)
+	if limit > 0 || offset > 0 {
+		if limit <= 0 {
+			limit = -1
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
 	}
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
@@ -650,62 +983,44 @@ func (s *SQLiteStore) ListTasksByProjectCompletedBetween(ctx context.Context, pr
 	}
 	defer rows.Close()
 
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		var dueDate sql.NullString
-		var completedAt sql.NullString
-
-		err := rows.Scan(
-			&task.ID,
-			&task.ProjectID,
-			&task.Description,
-			&task.Notes,
-			&task.Priority,
-			&task.Status,
-			&dueDate,
-			&task.Completed,
-			&completedAt,
-			&task.SortOrder,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan completed task: %w", err)
-		}
-
-		if dueDate.Valid {
-			parsedDate, err := parseSQLiteDate(dueDate.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse task due_date: %w", err)
-			}
-			task.DueDate = parsedDate
-		}
+	return scanTasks(rows)
+}
 
-		if completedAt.Valid {
-			parsedDate, err := parseSQLiteDate(completedAt.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse task completed_at: %w", err)
-			}
-			task.CompletedAt = parsedDate
-		}
+// CountTasksByProjectCompletedBetween counts the tasks ListTasksByProjectCompletedBetween would return without a limit.
+func (s *SQLiteStore) CountTasksByProjectCompletedBetween(ctx context.Context, projectID int64, from, to *time.Time) (int, error) {
+	where, args := completedBetweenWhere(projectID, from, to)
 
-		tasks = append(tasks, task)
+	var count int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks`+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count completed tasks by range: %w", err)
 	}
-
-	return tasks, rows.Err()
+	return count, nil
 }
 
 // UpdateTask updates an existing task.
 func (s *SQLiteStore) UpdateTask(ctx context.Context, task *models.Task) error {
+	return s.updateTask(ctx, task, 0)
+}
+
+// UpdateTaskAtRevision updates a task only if it is still at revision, and
+// fails with ErrStale otherwise, so of two edits made to the same revision
+// the second is refused rather than lost.
+func (s *SQLiteStore) UpdateTaskAtRevision(ctx context.Context, task *models.Task, revision int64) error {
+	return s.updateTask(ctx, task, revision)
+}
+
+// updateTask updates a task, guarded by revision unless it is 0.
+func (s *SQLiteStore) updateTask(ctx context.Context, task *models.Task, revision int64) error {
 	task.UpdatedAt = time.Now()
 
 	var wasCompleted bool
 	var existingCompletedAt sql.NullString
-	err := s.db.QueryRowContext(ctx, `SELECT completed, completed_at FROM tasks WHERE id = ?`, task.ID).Scan(&wasCompleted, &existingCompletedAt)
+	var wasWaitingOn string
+	var existingWaitingSince sql.NullTime
+	err := s.db.QueryRowContext(ctx, `SELECT completed, completed_at, waiting_on, waiting_since FROM tasks WHERE id = ?`, task.ID).Scan(&wasCompleted, &existingCompletedAt, &wasWaitingOn, &existingWaitingSince)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("task not found: %d", task.ID)
+			return fmt.Errorf("task %d: %w", task.ID, ErrNotFound)
 		}
 		return fmt.Errorf("failed to load task completion state: %w", err)
 	}
@@ -734,15 +1049,44 @@ func (s *SQLiteStore) UpdateTask(ctx context.Context, task *models.Task) error {
 		task.CompletedAt = nil
 	}
 
-	_, err = s.db.ExecContext(ctx, `
+	// The wait starts when the task is first handed off; naming someone
+	// else keeps the original start.
+	switch {
+	case !task.Waiting():
+		task.WaitingSince = nil
+	case wasWaitingOn == "" || !existingWaitingSince.Valid:
+		task.WaitingSince = &task.UpdatedAt
+	default:
+		task.WaitingSince = &existingWaitingSince.Time
+	}
+
+	result, err := s.db.ExecContext(ctx, `
 		UPDATE tasks
-		SET description = ?, notes = ?, priority = ?, status = ?, due_date = ?, completed = ?, completed_at = ?, project_id = ?, sort_order = ?, updated_at = ?
-		WHERE id = ?
-	`, task.Description, task.Notes, task.Priority, task.Status, dueDate, task.Completed, completedAt, task.ProjectID, task.SortOrder, task.UpdatedAt, task.ID)
+		SET description = ?, notes = ?, priority = ?, status = ?, due_date = ?, completed = ?, completed_at = ?, project_id = ?, sort_order = ?,
+		    waiting_on = ?, waiting_since = ?, updated_at = ?
+		WHERE id = ? AND (? = 0 OR revision = ?)
+	`, task.Description, task.Notes, task.Priority, task.Status, dueDate, task.Completed, completedAt, task.ProjectID, task.SortOrder,
+		task.WaitingOn, task.WaitingSince, task.UpdatedAt, task.ID, revision, revision)
 	if err != nil {
 		return fmt.Errorf("failed to update task: %w", err)
 	}
+	if revision != 0 {
+		if n, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		} else if n == 0 {
+			return fmt.Errorf("task %d: %w", task.ID, ErrStale)
+		}
+	}
 
+	if err := s.SetTaskRecurrence(ctx, task.ID, task.Recurrence); err != nil {
+		return err
+	}
+	if err := s.replaceTaskTags(ctx, task); err != nil {
+		return err
+	}
+	if err := s.db.QueryRowContext(ctx, `SELECT revision FROM tasks WHERE id = ?`, task.ID).Scan(&task.Revision); err != nil {
+		return fmt.Errorf("failed to load task revision: %w", err)
+	}
 	return nil
 }
 
@@ -755,6 +1099,53 @@ func (s *SQLiteStore) DeleteTask(ctx context.Context, id int64) error {
 	return nil
 }
 
+// SoftDeleteTask hides a task as if deleted, keeping it until
+// PurgeDeletedTasks removes it so RestoreTask can bring it back with token.
+func (s *SQLiteStore) SoftDeleteTask(ctx context.Context, id int64, token string, at time.Time) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE tasks SET deleted_at = ?, undo_token = ? WHERE id = ? AND deleted_at IS NULL
+	`, at, token, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+	return expectOneRow(result, "task", id)
+}
+
+// RestoreTask undoes the SoftDeleteTask given token, if the task was deleted
+// at or after since, and returns the task's ID.
+func (s *SQLiteStore) RestoreTask(ctx context.Context, token string, since time.Time) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT id FROM tasks WHERE undo_token = ? AND deleted_at >= ?
+	`, token, since).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("deleted task: %w", ErrNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find deleted task: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE tasks SET deleted_at = NULL, undo_token = NULL WHERE id = ?`, id); err != nil {
+		return 0, fmt.Errorf("failed to restore task: %w", err)
+	}
+	return id, tx.Commit()
+}
+
+// PurgeDeletedTasks removes for good the tasks soft-deleted before the
+// given time, and returns how many there were.
+func (s *SQLiteStore) PurgeDeletedTasks(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM tasks WHERE deleted_at < ?`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted tasks: %w", err)
+	}
+	return result.RowsAffected()
+}
+
 // ToggleTaskComplete toggles the completed status of a task.
 func (s *SQLiteStore) ToggleTaskComplete(ctx context.Context, id int64) error {
 	now := time.Now()
@@ -777,10 +1168,14 @@ func (s *SQLiteStore) ToggleTaskComplete(ctx context.Context, id int64) error {
 
 // ListActiveProjects retrieves all active (non-completed) projects ordered by sort_order.
 func (s *SQLiteStore) ListActiveProjects(ctx context.Context) ([]models.Project, error) {
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, name, description, type, target_date, completed, completed_at, sort_order, created_at, updated_at
+	stmt, err := s.stmt(ctx, `
+		SELECT `+projectColumns+`
 		FROM projects WHERE completed = FALSE ORDER BY sort_order ASC
 	`)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list active projects: %w", err)
 	}
@@ -789,41 +1184,9 @@ func (s *SQLiteStore) ListActiveProjects(ctx context.Context) ([]models.Project,
 	var projects []models.Project
 	for rows.Next() {
 		var project models.Project
-		var targetDate sql.NullString
-		var completedAt sql.NullString
-
-		err := rows.Scan(
-			&project.ID,
-			&project.Name,
-			&project.Description,
-			&project.Type,
-			&targetDate,
-			&project.Completed,
-			&completedAt,
-			&project.SortOrder,
-			&project.CreatedAt,
-			&project.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanProject(rows, &project); err != nil {
 			return nil, fmt.Errorf("failed to scan project: %w", err)
 		}
-
-		if targetDate.Valid {
-			parsedDate, err := parseSQLiteDate(targetDate.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse project target_date: %w", err)
-			}
-			project.TargetDate = parsedDate
-		}
-
-		if completedAt.Valid {
-			parsedDate, err := parseSQLiteDate(completedAt.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse project completed_at: %w", err)
-			}
-			project.CompletedAt = parsedDate
-		}
-
 		projects = append(projects, project)
 	}
 
@@ -832,162 +1195,91 @@ func (s *SQLiteStore) ListActiveProjects(ctx context.Context) ([]models.Project,
 
 // ListCompletedProjects retrieves all completed projects ordered by completion date.
 func (s *SQLiteStore) ListCompletedProjects(ctx context.Context) ([]models.Project, error) {
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, name, description, type, target_date, completed, completed_at, sort_order, created_at, updated_at
-		FROM projects WHERE completed = TRUE ORDER BY completed_at DESC
-	`)
+	return s.ListProjectsFiltered(ctx, true, 0)
+}
+
+// ListProjectsFiltered retrieves active or completed projects with the Open
+// and Done counts of their tasks filled in. Active projects come in sidebar
+// order, completed ones most recently completed first. If limit is 0, all
+// matching projects are returned.
+func (s *SQLiteStore) ListProjectsFiltered(ctx context.Context, completed bool, limit int) ([]models.Project, error) {
+	orderBy := "p.sort_order ASC"
+	if completed {
+		orderBy = "p.completed_at DESC, p.id DESC"
+	}
+	query := `
+		SELECT ` + projectColumns + `,
+			(SELECT COUNT(*) FROM tasks t WHERE t.project_id = p.id AND t.status IN ('todo', 'in_progress') AND t.deleted_at IS NULL AND t.someday = FALSE AND t.waiting_on = ''),
+			(SELECT COUNT(*) FROM tasks t WHERE t.project_id = p.id AND t.status = 'done' AND t.deleted_at IS NULL)
+		FROM projects p WHERE p.completed = ? ORDER BY ` + orderBy
+	args := []interface{}{completed}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list completed projects: %w", err)
+		return nil, fmt.Errorf("failed to list projects: %w", err)
 	}
 	defer rows.Close()
 
 	var projects []models.Project
 	for rows.Next() {
 		var project models.Project
-		var targetDate sql.NullString
-		var completedAt sql.NullString
-
-		err := rows.Scan(
-			&project.ID,
-			&project.Name,
-			&project.Description,
-			&project.Type,
-			&targetDate,
-			&project.Completed,
-			&completedAt,
-			&project.SortOrder,
-			&project.CreatedAt,
-			&project.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanProject(rows, &project, &project.Counts.Open, &project.Counts.Done); err != nil {
 			return nil, fmt.Errorf("failed to scan project: %w", err)
 		}
-
-		if targetDate.Valid {
-			parsedDate, err := parseSQLiteDate(targetDate.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse project target_date: %w", err)
-			}
-			project.TargetDate = parsedDate
-		}
-
-		if completedAt.Valid {
-			parsedDate, err := parseSQLiteDate(completedAt.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse project completed_at: %w", err)
-			}
-			project.CompletedAt = parsedDate
-		}
-
 		projects = append(projects, project)
 	}
 
 	return projects, rows.Err()
 }
 
-// ListTasksByProjectAndStatus retrieves tasks for a project with a specific status.
+// ListTasksByProjectAndStatus retrieves tasks for a project with a specific status,
+// in the project's sort mode.
 func (s *SQLiteStore) ListTasksByProjectAndStatus(ctx context.Context, projectID int64, status string) ([]models.Task, error) {
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, project_id, description, notes, priority, status, due_date, completed, completed_at, sort_order, created_at, updated_at
-		FROM tasks WHERE project_id = ? AND status = ? ORDER BY sort_order ASC
-	`, projectID, status)
+	orderBy, err := s.taskOrder(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := s.stmt(ctx, `
+		SELECT `+taskColumns+`
+		FROM tasks t WHERE project_id = ? AND status = ? AND deleted_at IS NULL ORDER BY `+orderBy)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, projectID, status)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tasks by status: %w", err)
 	}
 	defer rows.Close()
 
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		var dueDate sql.NullString
-		var completedAt sql.NullString
-
-		err := rows.Scan(
-			&task.ID,
-			&task.ProjectID,
-			&task.Description,
-			&task.Notes,
-			&task.Priority,
-			&task.Status,
-			&dueDate,
-			&task.Completed,
-			&completedAt,
-			&task.SortOrder,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan task: %w", err)
-		}
-
-		if dueDate.Valid {
-			parsedDate, err := parseSQLiteDate(dueDate.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse task due_date: %w", err)
-			}
-			task.DueDate = parsedDate
-		}
-
-		if completedAt.Valid {
-			parsedDate, err := parseSQLiteDate(completedAt.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse task completed_at: %w", err)
-			}
-			task.CompletedAt = parsedDate
-		}
-
-		tasks = append(tasks, task)
-	}
-
-	return tasks, rows.Err()
+	return scanTasks(rows)
 }
 
 // ListRecentDoneTasks retrieves done tasks completed on or after the given time (for the Kanban Done column).
 // Tasks with NULL completed_at are included as a fallback for legacy data.
 func (s *SQLiteStore) ListRecentDoneTasks(ctx context.Context, projectID int64, since time.Time) ([]models.Task, error) {
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, project_id, description, notes, priority, status, due_date, completed, completed_at, sort_order, created_at, updated_at
+	stmt, err := s.stmt(ctx, `
+		SELECT `+taskColumns+`
 		FROM tasks
 		WHERE project_id = ?
 		  AND status = 'done'
+		  AND deleted_at IS NULL
 		  AND (completed_at >= ? OR completed_at IS NULL)
 		ORDER BY completed_at DESC, sort_order ASC
-	`, projectID, since.Format("2006-01-02"))
+	`)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, projectID, since.Format("2006-01-02"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list recent done tasks: %w", err)
 	}
 	defer rows.Close()
 
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		var dueDate sql.NullString
-		var completedAt sql.NullString
-
-		err := rows.Scan(
-			&task.ID, &task.ProjectID, &task.Description, &task.Notes, &task.Priority, &task.Status,
-			&dueDate, &task.Completed, &completedAt, &task.SortOrder, &task.CreatedAt, &task.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan task: %w", err)
-		}
-		if dueDate.Valid {
-			if parsedDate, err := parseSQLiteDate(dueDate.String); err != nil {
-				return nil, fmt.Errorf("failed to parse task due_date: %w", err)
-			} else {
-				task.DueDate = parsedDate
-			}
-		}
-		if completedAt.Valid {
-			if parsedDate, err := parseSQLiteDate(completedAt.String); err != nil {
-				return nil, fmt.Errorf("failed to parse task completed_at: %w", err)
-			} else {
-				task.CompletedAt = parsedDate
-			}
-		}
-		tasks = append(tasks, task)
-	}
-	return tasks, rows.Err()
+	return scanTasks(rows)
 }
 
 // ListOldDoneTasks retrieves done tasks completed before the given time (for the Archive view).
@@ -995,10 +1287,11 @@ func (s *SQLiteStore) ListRecentDoneTasks(ctx context.Context, projectID int64,
 func (s *SQLiteStore) ListOldDoneTasks(ctx context.Context, projectID int64, before time.Time) ([]models.Task, error) {
 	beforeStr := before.Format("2006-01-02")
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, project_id, description, notes, priority, status, due_date, completed, completed_at, sort_order, created_at, updated_at
+		SELECT `+taskColumns+`
 		FROM tasks
 		WHERE project_id = ?
 		  AND status = 'done'
+		  AND deleted_at IS NULL
 		  AND (
 		      (completed_at IS NOT NULL AND completed_at < ?)
 		      OR (completed_at IS NULL AND updated_at < ?)
@@ -1010,36 +1303,7 @@ func (s *SQLiteStore) ListOldDoneTasks(ctx context.Context, projectID int64, bef
 	}
 	defer rows.Close()
 
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		var dueDate sql.NullString
-		var completedAt sql.NullString
-
-		err := rows.Scan(
-			&task.ID, &task.ProjectID, &task.Description, &task.Notes, &task.Priority, &task.Status,
-			&dueDate, &task.Completed, &completedAt, &task.SortOrder, &task.CreatedAt, &task.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan task: %w", err)
-		}
-		if dueDate.Valid {
-			if parsedDate, err := parseSQLiteDate(dueDate.String); err != nil {
-				return nil, fmt.Errorf("failed to parse task due_date: %w", err)
-			} else {
-				task.DueDate = parsedDate
-			}
-		}
-		if completedAt.Valid {
-			if parsedDate, err := parseSQLiteDate(completedAt.String); err != nil {
-				return nil, fmt.Errorf("failed to parse task completed_at: %w", err)
-			} else {
-				task.CompletedAt = parsedDate
-			}
-		}
-		tasks = append(tasks, task)
-	}
-	return tasks, rows.Err()
+	return scanTasks(rows)
 }
 
 // ListActiveProjectsWithOldDoneTasks returns active projects that have at least one done task
@@ -1047,13 +1311,14 @@ func (s *SQLiteStore) ListOldDoneTasks(ctx context.Context, projectID int64, bef
 func (s *SQLiteStore) ListActiveProjectsWithOldDoneTasks(ctx context.Context, before time.Time) ([]models.Project, error) {
 	beforeStr := before.Format("2006-01-02")
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, name, description, type, target_date, completed, completed_at, sort_order, created_at, updated_at
+		SELECT `+projectColumns+`
 		FROM projects
 		WHERE completed = FALSE
 		  AND EXISTS (
 		      SELECT 1 FROM tasks
 		      WHERE tasks.project_id = projects.id
 		        AND tasks.status = 'done'
+		        AND tasks.deleted_at IS NULL
 		        AND (
 		            (tasks.completed_at IS NOT NULL AND tasks.completed_at < ?)
 		            OR (tasks.completed_at IS NULL AND tasks.updated_at < ?)
@@ -1069,100 +1334,198 @@ func (s *SQLiteStore) ListActiveProjectsWithOldDoneTasks(ctx context.Context, be
 	var projects []models.Project
 	for rows.Next() {
 		var project models.Project
-		var targetDate sql.NullString
-		var completedAt sql.NullString
-
-		err := rows.Scan(
-			&project.ID, &project.Name, &project.Description, &project.Type,
-			&targetDate, &project.Completed, &completedAt, &project.SortOrder,
-			&project.CreatedAt, &project.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanProject(rows, &project); err != nil {
 			return nil, fmt.Errorf("failed to scan project: %w", err)
 		}
-		if targetDate.Valid {
-			if parsedDate, err := parseSQLiteDate(targetDate.String); err != nil {
-				return nil, fmt.Errorf("failed to parse project target_date: %w", err)
-			} else {
-				project.TargetDate = parsedDate
-			}
-		}
-		if completedAt.Valid {
-			if parsedDate, err := parseSQLiteDate(completedAt.String); err != nil {
-				return nil, fmt.Errorf("failed to parse project completed_at: %w", err)
-			} else {
-				project.CompletedAt = parsedDate
-			}
-		}
 		projects = append(projects, project)
 	}
 	return projects, rows.Err()
 }
 
-// ListUpcomingTasks retrieves non-done tasks with due dates within the given number of days across all active projects.
-func (s *SQLiteStore) ListUpcomingTasks(ctx context.Context, days int) ([]models.Task, error) {
-	cutoff := time.Now().AddDate(0, 0, days).Format("2006-01-02")
+// ListTasksDueBetween retrieves tasks in active projects due on or after start
+// and before end, including done ones, ordered by due date then priority.
+func (s *SQLiteStore) ListTasksDueBetween(ctx context.Context, start, end time.Time) ([]models.Task, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT t.id, t.project_id, t.description, t.notes, t.priority, t.status, t.due_date, t.completed, t.completed_at, t.sort_order, t.created_at, t.updated_at, p.name
+		SELECT `+joinedTaskColumns+`, p.name
 		FROM tasks t
 		JOIN projects p ON t.project_id = p.id
-		WHERE t.status != 'done' AND t.due_date IS NOT NULL AND t.due_date <= ?
-		AND p.completed = FALSE
-		ORDER BY t.due_date ASC, t.priority ASC
-	`, cutoff)
+		WHERE t.due_date IS NOT NULL AND date(t.due_date) >= ? AND date(t.due_date) < ?
+		AND t.deleted_at IS NULL AND t.someday = FALSE AND p.completed = FALSE
+		ORDER BY date(t.due_date) ASC, `+priorityRank+`, p.sort_order ASC, t.sort_order ASC
+	`, start.Format("2006-01-02"), end.Format("2006-01-02"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to list upcoming tasks: %w", err)
+		return nil, fmt.Errorf("failed to list tasks due between dates: %w", err)
 	}
-	defer rows.Close()
+	return scanTasksWithProject(rows)
+}
 
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		var dueDate sql.NullString
-		var completedAt sql.NullString
-
-		err := rows.Scan(
-			&task.ID,
-			&task.ProjectID,
-			&task.Description,
-			&task.Notes,
-			&task.Priority,
-			&task.Status,
-			&dueDate,
-			&task.Completed,
-			&completedAt,
-			&task.SortOrder,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-			&task.ProjectName,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan upcoming task: %w", err)
-		}
+// ListOpenTasks retrieves tasks that are not done across all active projects,
+// narrowed and ordered by filter.
+func (s *SQLiteStore) ListOpenTasks(ctx context.Context, filter TaskFilter) ([]models.Task, error) {
+	where, args := taskFilterConditions(filter)
+	where = append([]string{"t.status != 'done'", "p.completed = FALSE"}, where...)
+
+	var orderBy string
+	switch filter.Sort {
+	case SortPriority:
+		orderBy = priorityRank + ", t.due_date IS NULL, date(t.due_date) ASC, p.sort_order ASC, t.sort_order ASC, t.id ASC"
+	case SortProject:
+		orderBy = "p.sort_order ASC, p.id ASC, t.sort_order ASC, t.id ASC"
+	default:
+		orderBy = "t.due_date IS NULL, date(t.due_date) ASC, " + priorityRank + ", p.sort_order ASC, t.sort_order ASC, t.id ASC"
+	}
 
-		if dueDate.Valid {
-			parsedDate, err := parseSQLiteDate(dueDate.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse task due_date: %w", err)
-			}
-			task.DueDate = parsedDate
-		}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+joinedTaskColumns+`, p.name
+		FROM tasks t
+		JOIN projects p ON t.project_id = p.id
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+orderBy, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open tasks: %w", err)
+	}
+	return scanTasksWithProject(rows)
+}
 
-		if completedAt.Valid {
-			parsedDate, err := parseSQLiteDate(completedAt.String)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse task completed_at: %w", err)
-			}
-			task.CompletedAt = parsedDate
+// ListProjectTasks retrieves the tasks of filter.ProjectID narrowed by the
+// rest of filter, as on the project's board: open tasks in filter.SortMode,
+// or else the project's sort mode, and done ones most recently completed
+// first. filter.Sort is ignored.
+func (s *SQLiteStore) ListProjectTasks(ctx context.Context, filter TaskFilter) ([]models.Task, error) {
+	if filter.ProjectID == 0 {
+		return nil, errors.New("listing project tasks needs a project")
+	}
+	where, args := taskFilterConditions(filter)
+
+	orderBy := "t.completed_at DESC, t.sort_order ASC"
+	if filter.Status != "done" && filter.SortMode != "" {
+		orderBy = taskOrderBy(filter.SortMode)
+	} else if filter.Status != "done" {
+		var err error
+		if orderBy, err = s.taskOrder(ctx, filter.ProjectID); err != nil {
+			return nil, err
 		}
+	}
+
+	stmt, err := s.stmt(ctx, `
+		SELECT `+taskColumns+`
+		FROM tasks t
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY `+orderBy)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project tasks: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTasks(rows)
+}
+
+// taskFilterConditions turns filter into WHERE conditions, joined with AND,
+// on tasks aliased as t. Deleted tasks are always left out, and someday
+// tasks unless filter.Someday asks for them alone.
+func taskFilterConditions(filter TaskFilter) ([]string, []interface{}) {
+	where := []string{"t.deleted_at IS NULL", "t.someday = ?"}
+	args := []interface{}{filter.Someday}
+
+	if filter.ProjectID != 0 {
+		where = append(where, "t.project_id = ?")
+		args = append(args, filter.ProjectID)
+	}
+	if filter.Priority != "" {
+		where = append(where, "t.priority = ?")
+		args = append(args, filter.Priority)
+	}
+	if filter.Status != "" {
+		where = append(where, "t.status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Search != "" {
+		where = append(where, "(t.description LIKE ? ESCAPE '\\' OR t.notes LIKE ? ESCAPE '\\')")
+		pattern := "%" + escapeLike(filter.Search) + "%"
+		args = append(args, pattern, pattern)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	switch filter.Due {
+	case DueOverdue:
+		where = append(where, "t.due_date IS NOT NULL AND date(t.due_date) < ?")
+		args = append(args, today)
+	case DueToday:
+		where = append(where, "date(t.due_date) = ?")
+		args = append(args, today)
+	case DueThisWeek:
+		where = append(where, "date(t.due_date) >= ? AND date(t.due_date) < ?")
+		args = append(args, today, time.Now().AddDate(0, 0, 7).Format("2006-01-02"))
+	case DueNone:
+		where = append(where, "t.due_date IS NULL")
+	}
+
+	if filter.HasNotes {
+		where = append(where, "t.notes != ''")
+	}
+	if filter.Waiting {
+		where = append(where, "t.waiting_on != ''")
+	}
+	if filter.CompletedSince != nil {
+		where = append(where, "(t.completed_at >= ? OR t.completed_at IS NULL)")
+		args = append(args, filter.CompletedSince.Format("2006-01-02"))
+	}
+	if filter.Match != nil {
+		matchWhere, matchArgs := filter.Match.SQL()
+		where = append(where, matchWhere)
+		args = append(args, matchArgs...)
+	}
+
+	return where, args
+}
+
+// priorityRank orders tasks high, medium, low in ORDER BY clauses.
+const priorityRank = `CASE t.priority WHEN 'high' THEN 0 WHEN 'medium' THEN 1 ELSE 2 END`
+
+// escapeLike escapes LIKE wildcards so user input matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
 
+// scanTasksWithProject reads rows selecting the task columns followed by the
+// project name, and closes rows.
+func scanTasksWithProject(rows *sql.Rows) ([]models.Task, error) {
+	defer rows.Close()
+
+	now := time.Now()
+	var tasks []models.Task
+	for rows.Next() {
+		var task models.Task
+		if err := scanTask(rows, &task, now, &task.ProjectName); err != nil {
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
 		task.Overdue = task.IsOverdue()
 		tasks = append(tasks, task)
 	}
-
 	return tasks, rows.Err()
 }
 
+// ListUpcomingTasks retrieves non-done tasks with due dates within the given number of days across all active projects.
+func (s *SQLiteStore) ListUpcomingTasks(ctx context.Context, days int) ([]models.Task, error) {
+	cutoff := time.Now().AddDate(0, 0, days).Format("2006-01-02")
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+joinedTaskColumns+`, p.name
+		FROM tasks t
+		JOIN projects p ON t.project_id = p.id
+		WHERE t.status != 'done' AND t.due_date IS NOT NULL AND t.due_date <= ?
+		AND t.deleted_at IS NULL AND t.someday = FALSE AND p.completed = FALSE
+		ORDER BY t.due_date ASC, t.priority ASC
+	`, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upcoming tasks: %w", err)
+	}
+	return scanTasksWithProject(rows)
+}
+
 // MoveTaskToStatus changes a task's status and sort_order within the new status column.
 func (s *SQLiteStore) MoveTaskToStatus(ctx context.Context, taskID int64, newStatus string, newSortOrder int) error {
 	now := time.Now()
@@ -1185,7 +1548,8 @@ func (s *SQLiteStore) MoveTaskToStatus(ctx context.Context, taskID int64, newSta
 	return nil
 }
 
-// ReorderTasksInStatus updates the sort_order of tasks within a project and status column.
+// ReorderTasksInStatus updates the sort_order of tasks within a project and status column,
+// switching the project to manual sorting.
 func (s *SQLiteStore) ReorderTasksInStatus(ctx context.Context, projectID int64, status string, ids []int64) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -1193,7 +1557,13 @@ func (s *SQLiteStore) ReorderTasksInStatus(ctx context.Context, projectID int64,
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `UPDATE tasks SET sort_order = ? WHERE id = ? AND project_id = ? AND status = ?`)
+	if err := switchToManualSort(ctx, tx, projectID); err != nil {
+		return err
+	}
+
+	// Tasks already in place are left alone, so moving one card doesn't
+	// rewrite, and log a change for, every task in the column.
+	stmt, err := tx.PrepareContext(ctx, `UPDATE tasks SET sort_order = ?1 WHERE id = ?2 AND project_id = ?3 AND status = ?4 AND sort_order IS NOT ?1`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
@@ -1209,7 +1579,8 @@ func (s *SQLiteStore) ReorderTasksInStatus(ctx context.Context, projectID int64,
 	return tx.Commit()
 }
 
-// ReorderTasks updates the sort_order of tasks within a project.
+// ReorderTasks updates the sort_order of tasks within a project, switching the
+// project to manual sorting.
 func (s *SQLiteStore) ReorderTasks(ctx context.Context, projectID int64, ids []int64) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -1217,7 +1588,13 @@ func (s *SQLiteStore) ReorderTasks(ctx context.Context, projectID int64, ids []i
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `UPDATE tasks SET sort_order = ? WHERE id = ? AND project_id = ?`)
+	if err := switchToManualSort(ctx, tx, projectID); err != nil {
+		return err
+	}
+
+	// Tasks already in place are left alone, so moving one doesn't rewrite,
+	// and log a change for, every task in the project.
+	stmt, err := tx.PrepareContext(ctx, `UPDATE tasks SET sort_order = ?1 WHERE id = ?2 AND project_id = ?3 AND sort_order IS NOT ?1`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
@@ -1232,3 +1609,2733 @@ func (s *SQLiteStore) ReorderTasks(ctx context.Context, projectID int64, ids []i
 
 	return tx.Commit()
 }
+
+// MoveTaskToProject moves a task to the end of another project. Both
+// projects' tasks are then numbered 1, 2, 3... again, closing the gap the
+// task left behind.
+func (s *SQLiteStore) MoveTaskToProject(ctx context.Context, taskID, projectID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var fromProjectID int64
+	err = tx.QueryRowContext(ctx, `SELECT project_id FROM tasks WHERE id = ?`, taskID).Scan(&fromProjectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("task %d: %w", taskID, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load task: %w", err)
+	}
+	if fromProjectID == projectID {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tasks SET
+			project_id = ?1,
+			sort_order = (SELECT COALESCE(MAX(sort_order), 0) + 1 FROM tasks WHERE project_id = ?1),
+			updated_at = ?2
+		WHERE id = ?3
+	`, projectID, time.Now(), taskID)
+	if err != nil {
+		return fmt.Errorf("failed to move task: %w", err)
+	}
+
+	for _, id := range []int64{fromProjectID, projectID} {
+		if err := renumberTasks(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// renumberTasks numbers a project's tasks from 1 in their current order.
+// Tasks already in place are left alone, as in ReorderTasks.
+func renumberTasks(ctx context.Context, tx *sql.Tx, projectID int64) error {
+	_, err := tx.ExecContext(ctx, `
+		WITH ranked AS (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY sort_order, id) AS position
+			FROM tasks WHERE project_id = ?1
+		)
+		UPDATE tasks SET sort_order = (SELECT position FROM ranked WHERE ranked.id = tasks.id)
+		WHERE project_id = ?1
+		  AND sort_order IS NOT (SELECT position FROM ranked WHERE ranked.id = tasks.id)
+	`, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to renumber tasks: %w", err)
+	}
+	return nil
+}
+
+// SetProjectSortMode changes how a project's tasks are ordered. Switching back
+// to manual restores the last drag-and-drop order.
+func (s *SQLiteStore) SetProjectSortMode(ctx context.Context, projectID int64, mode string) error {
+	if !models.ValidSortMode(mode) {
+		return fmt.Errorf("invalid sort mode %q", mode)
+	}
+	_, err := s.db.ExecContext(ctx, `UPDATE projects SET sort_mode = ?, updated_at = ? WHERE id = ?`, mode, time.Now(), projectID)
+	if err != nil {
+		return fmt.Errorf("failed to set project sort mode: %w", err)
+	}
+	return nil
+}
+
+// taskOrder returns the ORDER BY clause for a project's tasks in its sort
+// mode. Queries using it alias tasks as t.
+func (s *SQLiteStore) taskOrder(ctx context.Context, projectID int64) (string, error) {
+	stmt, err := s.stmt(ctx, `SELECT sort_mode FROM projects WHERE id = ?`)
+	if err != nil {
+		return "", err
+	}
+	var mode string
+	err = stmt.QueryRowContext(ctx, projectID).Scan(&mode)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("failed to load project sort mode: %w", err)
+	}
+	return taskOrderBy(mode), nil
+}
+
+func taskOrderBy(mode string) string {
+	switch mode {
+	case models.SortPriority:
+		return priorityRank + ", t.sort_order ASC, t.id ASC"
+	case models.SortDueDate:
+		return "t.due_date IS NULL, date(t.due_date) ASC, t.sort_order ASC, t.id ASC"
+	case models.SortCreated:
+		return "t.created_at ASC, t.id ASC"
+	default:
+		return "t.sort_order ASC"
+	}
+}
+
+// switchToManualSort puts a project back in manual mode before its tasks are
+// reordered by hand. Tasks the user did not move keep the place the automatic
+// order gave them, so the list does not jump when the mode changes.
+func switchToManualSort(ctx context.Context, tx *sql.Tx, projectID int64) error {
+	var mode string
+	err := tx.QueryRowContext(ctx, `SELECT sort_mode FROM projects WHERE id = ?`, projectID).Scan(&mode)
+	if errors.Is(err, sql.ErrNoRows) || mode == models.SortManual {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load project sort mode: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tasks SET sort_order = (
+			SELECT position FROM (
+				SELECT t.id, ROW_NUMBER() OVER (ORDER BY `+taskOrderBy(mode)+`) AS position
+				FROM tasks t WHERE t.project_id = ?
+			) ranked WHERE ranked.id = tasks.id
+		)
+		WHERE project_id = ?
+	`, projectID, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to keep automatic task order: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE projects SET sort_mode = ? WHERE id = ?`, models.SortManual, projectID); err != nil {
+		return fmt.Errorf("failed to switch project to manual sort: %w", err)
+	}
+	return nil
+}
+
+// RegisterSlackWorkspace records a Slack workspace the first time it is seen,
+// refreshing its display name on later calls without touching its settings.
+func (s *SQLiteStore) RegisterSlackWorkspace(ctx context.Context, teamID, teamName string) error {
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO slack_workspaces (team_id, team_name, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(team_id) DO UPDATE SET
+			team_name = CASE WHEN excluded.team_name != '' THEN excluded.team_name ELSE slack_workspaces.team_name END
+	`, teamID, teamName, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to register slack workspace: %w", err)
+	}
+	return nil
+}
+
+// GetSlackWorkspace retrieves a Slack workspace by team ID.
+func (s *SQLiteStore) GetSlackWorkspace(ctx context.Context, teamID string) (*models.SlackWorkspace, error) {
+	ws := &models.SlackWorkspace{}
+	var projectID sql.NullInt64
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT team_id, team_name, webhook_url, project_id, notify_overdue, created_at, updated_at
+		FROM slack_workspaces WHERE team_id = ?
+	`, teamID).Scan(
+		&ws.TeamID,
+		&ws.TeamName,
+		&ws.WebhookURL,
+		&projectID,
+		&ws.NotifyOverdue,
+		&ws.CreatedAt,
+		&ws.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("slack workspace %s: %w", teamID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get slack workspace: %w", err)
+	}
+
+	if projectID.Valid {
+		ws.ProjectID = &projectID.Int64
+	}
+
+	return ws, nil
+}
+
+// ListSlackWorkspaces retrieves all registered Slack workspaces ordered by name.
+func (s *SQLiteStore) ListSlackWorkspaces(ctx context.Context) ([]models.SlackWorkspace, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT team_id, team_name, webhook_url, project_id, notify_overdue, created_at, updated_at
+		FROM slack_workspaces
+		ORDER BY team_name ASC, team_id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list slack workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	var workspaces []models.SlackWorkspace
+	for rows.Next() {
+		var ws models.SlackWorkspace
+		var projectID sql.NullInt64
+
+		if err := rows.Scan(
+			&ws.TeamID,
+			&ws.TeamName,
+			&ws.WebhookURL,
+			&projectID,
+			&ws.NotifyOverdue,
+			&ws.CreatedAt,
+			&ws.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan slack workspace: %w", err)
+		}
+
+		if projectID.Valid {
+			id := projectID.Int64
+			ws.ProjectID = &id
+		}
+
+		workspaces = append(workspaces, ws)
+	}
+
+	return workspaces, rows.Err()
+}
+
+// UpdateSlackWorkspace saves a workspace's settings.
+func (s *SQLiteStore) UpdateSlackWorkspace(ctx context.Context, ws *models.SlackWorkspace) error {
+	ws.UpdatedAt = time.Now()
+
+	var projectID interface{}
+	if ws.ProjectID != nil {
+		projectID = *ws.ProjectID
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE slack_workspaces
+		SET webhook_url = ?, project_id = ?, notify_overdue = ?, updated_at = ?
+		WHERE team_id = ?
+	`, ws.WebhookURL, projectID, ws.NotifyOverdue, ws.UpdatedAt, ws.TeamID)
+	if err != nil {
+		return fmt.Errorf("failed to update slack workspace: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check updated slack workspace: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("slack workspace %s: %w", ws.TeamID, ErrNotFound)
+	}
+
+	return nil
+}
+
+// ListOverdueTasksPendingNotification retrieves open tasks in active projects
+// whose due date is before today and that have not yet been notified on channel
+// for their current due date.
+func (s *SQLiteStore) ListOverdueTasksPendingNotification(ctx context.Context, channel string, today time.Time) ([]models.Task, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+joinedTaskColumns+`, p.name
+		FROM tasks t
+		JOIN projects p ON t.project_id = p.id
+		WHERE t.status != 'done' AND t.due_date IS NOT NULL AND date(t.due_date) < ?
+		AND t.deleted_at IS NULL AND t.someday = FALSE AND p.completed = FALSE
+		AND NOT EXISTS (
+			SELECT 1 FROM overdue_notifications n
+			WHERE n.task_id = t.id AND n.channel = ? AND n.due_date = date(t.due_date)
+		)
+		ORDER BY t.due_date ASC, t.id ASC
+	`, today.Format("2006-01-02"), channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overdue tasks: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var tasks []models.Task
+	for rows.Next() {
+		var task models.Task
+		if err := scanTask(rows, &task, now, &task.ProjectName); err != nil {
+			return nil, fmt.Errorf("failed to scan overdue task: %w", err)
+		}
+		task.Overdue = true
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// MarkOverdueNotified records that an overdue alert for the task's due date
+// was sent on channel. Marking the same task twice is a no-op.
+func (s *SQLiteStore) MarkOverdueNotified(ctx context.Context, taskID int64, channel string, dueDate time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT OR IGNORE INTO overdue_notifications (task_id, channel, due_date, notified_at)
+		VALUES (?, ?, ?, ?)
+	`, taskID, channel, dueDate.Format("2006-01-02"), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to mark overdue notification: %w", err)
+	}
+	return nil
+}
+
+// replaceTaskTags overwrites a task's tags with task.Tags, normalizing them.
+func (s *SQLiteStore) replaceTaskTags(ctx context.Context, task *models.Task) error {
+	task.Tags = models.NormalizeTags(task.Tags)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Tags that stay are left alone, so saving them unchanged isn't a new
+	// revision.
+	query := `DELETE FROM task_tags WHERE task_id = ?`
+	args := []interface{}{task.ID}
+	if len(task.Tags) > 0 {
+		query += ` AND tag NOT IN (?` + strings.Repeat(", ?", len(task.Tags)-1) + `)`
+		for _, tag := range task.Tags {
+			args = append(args, tag)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to clear task tags: %w", err)
+	}
+	for _, tag := range task.Tags {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO task_tags (task_id, tag) VALUES (?, ?)`, task.ID, tag); err != nil {
+			return fmt.Errorf("failed to insert task tag: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) taskTags(ctx context.Context, taskID int64) ([]string, error) {
+	stmt, err := s.stmt(ctx, `SELECT tag FROM task_tags WHERE task_id = ? ORDER BY tag ASC`)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan task tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// EscalatePriorities sets the escalated priority of every task: open tasks
+// due within days of today go up one level and overdue ones to high, while
+// the rest are cleared. days 0 clears every escalation.
+func (s *SQLiteStore) EscalatePriorities(ctx context.Context, days int, today time.Time) error {
+	day := today.Format("2006-01-02")
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE tasks SET escalated_priority = CASE
+			WHEN ? = 0 OR status = 'done' OR due_date IS NULL OR date(due_date) > date(?, '+' || ? || ' days') THEN ''
+			WHEN date(due_date) < ? THEN 'high'
+			WHEN priority = 'low' THEN 'medium'
+			ELSE 'high'
+		END
+	`, days, day, days, day)
+	if err != nil {
+		return fmt.Errorf("failed to escalate priorities: %w", err)
+	}
+	return nil
+}
+
+// ListTaskTags retrieves the tags of every tagged task, keyed by task ID.
+func (s *SQLiteStore) ListTaskTags(ctx context.Context) (map[int64][]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT task_id, tag FROM task_tags ORDER BY task_id ASC, tag ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make(map[int64][]string)
+	for rows.Next() {
+		var taskID int64
+		var tag string
+		if err := rows.Scan(&taskID, &tag); err != nil {
+			return nil, fmt.Errorf("failed to scan task tag: %w", err)
+		}
+		tags[taskID] = append(tags[taskID], tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// SetTaskRecurrence stores a task's repeat rule; an empty rule removes it.
+func (s *SQLiteStore) SetTaskRecurrence(ctx context.Context, taskID int64, rule string) error {
+	var err error
+	if rule == "" {
+		_, err = s.db.ExecContext(ctx, `DELETE FROM task_recurrences WHERE task_id = ?`, taskID)
+	} else {
+		_, err = s.db.ExecContext(ctx, `
+			INSERT INTO task_recurrences (task_id, rule) VALUES (?, ?)
+			ON CONFLICT(task_id) DO UPDATE SET rule = excluded.rule
+		`, taskID, rule)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save task recurrence: %w", err)
+	}
+	return nil
+}
+
+// ListTaskRecurrences retrieves the repeat rule of every repeating task,
+// keyed by task ID.
+func (s *SQLiteStore) ListTaskRecurrences(ctx context.Context) (map[int64]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT task_id, rule FROM task_recurrences`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task recurrences: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make(map[int64]string)
+	for rows.Next() {
+		var taskID int64
+		var rule string
+		if err := rows.Scan(&taskID, &rule); err != nil {
+			return nil, fmt.Errorf("failed to scan task recurrence: %w", err)
+		}
+		rules[taskID] = rule
+	}
+
+	return rules, rows.Err()
+}
+
+// GetTaskIDByExternalRef returns the task linked to an external ID.
+func (s *SQLiteStore) GetTaskIDByExternalRef(ctx context.Context, source, externalID string) (int64, error) {
+	var taskID int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT task_id FROM task_external_refs WHERE source = ? AND external_id = ?
+	`, source, externalID).Scan(&taskID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s ref %s: %w", source, externalID, ErrNotFound)
+		}
+		return 0, fmt.Errorf("failed to get external ref: %w", err)
+	}
+	return taskID, nil
+}
+
+// GetTaskExternalRef returns the external ID a task is linked to in source.
+func (s *SQLiteStore) GetTaskExternalRef(ctx context.Context, source string, taskID int64) (string, error) {
+	var externalID string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT external_id FROM task_external_refs WHERE source = ? AND task_id = ?
+	`, source, taskID).Scan(&externalID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%s ref for task %d: %w", source, taskID, ErrNotFound)
+		}
+		return "", fmt.Errorf("failed to get external ref: %w", err)
+	}
+	return externalID, nil
+}
+
+// SetTaskExternalRef links a task to an external ID, replacing any previous
+// link for either side within the source.
+func (s *SQLiteStore) SetTaskExternalRef(ctx context.Context, source, externalID string, taskID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM task_external_refs WHERE source = ? AND (external_id = ? OR task_id = ?)
+	`, source, externalID, taskID); err != nil {
+		return fmt.Errorf("failed to clear external ref: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO task_external_refs (source, external_id, task_id, updated_at) VALUES (?, ?, ?, ?)
+	`, source, externalID, taskID, time.Now()); err != nil {
+		return fmt.Errorf("failed to set external ref: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// ListTaskExternalRefs retrieves external IDs for a source, keyed by task ID.
+func (s *SQLiteStore) ListTaskExternalRefs(ctx context.Context, source string) (map[int64]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT task_id, external_id FROM task_external_refs WHERE source = ?`, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list external refs: %w", err)
+	}
+	defer rows.Close()
+
+	refs := make(map[int64]string)
+	for rows.Next() {
+		var taskID int64
+		var externalID string
+		if err := rows.Scan(&taskID, &externalID); err != nil {
+			return nil, fmt.Errorf("failed to scan external ref: %w", err)
+		}
+		refs[taskID] = externalID
+	}
+
+	return refs, rows.Err()
+}
+
+// GetGoogleTasksAccount retrieves the Google Tasks connection, if any.
+func (s *SQLiteStore) GetGoogleTasksAccount(ctx context.Context) (*models.GoogleTasksAccount, error) {
+	account := &models.GoogleTasksAccount{}
+	var tokenExpiry, lastSyncedAt sql.NullTime
+	var projectID sql.NullInt64
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT refresh_token, access_token, token_expiry, project_id, last_synced_at, created_at, updated_at
+		FROM google_tasks_account WHERE id = 1
+	`).Scan(
+		&account.RefreshToken,
+		&account.AccessToken,
+		&tokenExpiry,
+		&projectID,
+		&lastSyncedAt,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("google tasks account: %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get google tasks account: %w", err)
+	}
+
+	if tokenExpiry.Valid {
+		account.TokenExpiry = &tokenExpiry.Time
+	}
+	if projectID.Valid {
+		account.ProjectID = &projectID.Int64
+	}
+	if lastSyncedAt.Valid {
+		account.LastSyncedAt = &lastSyncedAt.Time
+	}
+
+	return account, nil
+}
+
+// SaveGoogleTasksAccount creates or replaces the Google Tasks connection.
+func (s *SQLiteStore) SaveGoogleTasksAccount(ctx context.Context, account *models.GoogleTasksAccount) error {
+	now := time.Now()
+	account.UpdatedAt = now
+	if account.CreatedAt.IsZero() {
+		account.CreatedAt = now
+	}
+
+	var projectID interface{}
+	if account.ProjectID != nil {
+		projectID = *account.ProjectID
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO google_tasks_account (id, refresh_token, access_token, token_expiry, project_id, last_synced_at, created_at, updated_at)
+		VALUES (1, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			refresh_token = excluded.refresh_token,
+			access_token = excluded.access_token,
+			token_expiry = excluded.token_expiry,
+			project_id = excluded.project_id,
+			last_synced_at = excluded.last_synced_at,
+			updated_at = excluded.updated_at
+	`, account.RefreshToken, account.AccessToken, account.TokenExpiry, projectID, account.LastSyncedAt, account.CreatedAt, account.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save google tasks account: %w", err)
+	}
+	return nil
+}
+
+// DeleteGoogleTasksAccount removes the Google Tasks connection.
+func (s *SQLiteStore) DeleteGoogleTasksAccount(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM google_tasks_account WHERE id = 1`); err != nil {
+		return fmt.Errorf("failed to delete google tasks account: %w", err)
+	}
+	return nil
+}
+
+// GetGoogleCalendarAccount retrieves the Google Calendar connection, if any.
+func (s *SQLiteStore) GetGoogleCalendarAccount(ctx context.Context) (*models.GoogleCalendarAccount, error) {
+	account := &models.GoogleCalendarAccount{}
+	var tokenExpiry, lastSyncedAt sql.NullTime
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT refresh_token, access_token, token_expiry, calendar_id, last_synced_at, created_at, updated_at
+		FROM google_calendar_account WHERE id = 1
+	`).Scan(
+		&account.RefreshToken,
+		&account.AccessToken,
+		&tokenExpiry,
+		&account.CalendarID,
+		&lastSyncedAt,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("google calendar account: %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get google calendar account: %w", err)
+	}
+
+	if tokenExpiry.Valid {
+		account.TokenExpiry = &tokenExpiry.Time
+	}
+	if lastSyncedAt.Valid {
+		account.LastSyncedAt = &lastSyncedAt.Time
+	}
+
+	return account, nil
+}
+
+// SaveGoogleCalendarAccount creates or replaces the Google Calendar
+// connection. An empty CalendarID is saved as "primary".
+func (s *SQLiteStore) SaveGoogleCalendarAccount(ctx context.Context, account *models.GoogleCalendarAccount) error {
+	now := time.Now()
+	account.UpdatedAt = now
+	if account.CreatedAt.IsZero() {
+		account.CreatedAt = now
+	}
+	if account.CalendarID == "" {
+		account.CalendarID = "primary"
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO google_calendar_account (id, refresh_token, access_token, token_expiry, calendar_id, last_synced_at, created_at, updated_at)
+		VALUES (1, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			refresh_token = excluded.refresh_token,
+			access_token = excluded.access_token,
+			token_expiry = excluded.token_expiry,
+			calendar_id = excluded.calendar_id,
+			last_synced_at = excluded.last_synced_at,
+			updated_at = excluded.updated_at
+	`, account.RefreshToken, account.AccessToken, account.TokenExpiry, account.CalendarID, account.LastSyncedAt, account.CreatedAt, account.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save google calendar account: %w", err)
+	}
+	return nil
+}
+
+// DeleteGoogleCalendarAccount removes the Google Calendar connection and
+// forgets which events belong to which tasks. The events themselves are left
+// in the calendar.
+func (s *SQLiteStore) DeleteGoogleCalendarAccount(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM google_calendar_account WHERE id = 1`); err != nil {
+		return fmt.Errorf("failed to delete google calendar account: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM calendar_events`); err != nil {
+		return fmt.Errorf("failed to delete calendar events: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ListCalendarEvents retrieves every task's calendar event link, most recent
+// conflicts first.
+func (s *SQLiteStore) ListCalendarEvents(ctx context.Context) ([]models.CalendarEvent, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT task_id, event_id, etag, title, due_date, done, conflict, conflict_at, synced_at
+		FROM calendar_events
+		ORDER BY conflict_at IS NULL, conflict_at DESC, task_id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendar events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.CalendarEvent
+	for rows.Next() {
+		var e models.CalendarEvent
+		var dueDate string
+		var conflictAt sql.NullTime
+		if err := rows.Scan(&e.TaskID, &e.EventID, &e.ETag, &e.Title, &dueDate, &e.Done, &e.Conflict, &conflictAt, &e.SyncedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan calendar event: %w", err)
+		}
+		if e.DueDate, err = time.Parse("2006-01-02", dueDate); err != nil {
+			return nil, fmt.Errorf("invalid due date of calendar event for task %d: %w", e.TaskID, err)
+		}
+		if conflictAt.Valid {
+			e.ConflictAt = &conflictAt.Time
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// SaveCalendarEvent creates or replaces the calendar event link of a task.
+func (s *SQLiteStore) SaveCalendarEvent(ctx context.Context, event *models.CalendarEvent) error {
+	if event.SyncedAt.IsZero() {
+		event.SyncedAt = time.Now()
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO calendar_events (task_id, event_id, etag, title, due_date, done, conflict, conflict_at, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(task_id) DO UPDATE SET
+			event_id = excluded.event_id,
+			etag = excluded.etag,
+			title = excluded.title,
+			due_date = excluded.due_date,
+			done = excluded.done,
+			conflict = excluded.conflict,
+			conflict_at = excluded.conflict_at,
+			synced_at = excluded.synced_at
+	`, event.TaskID, event.EventID, event.ETag, event.Title, event.DueDate.Format("2006-01-02"), event.Done, event.Conflict, event.ConflictAt, event.SyncedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save calendar event: %w", err)
+	}
+	return nil
+}
+
+// DeleteCalendarEvent forgets the calendar event link of a task.
+func (s *SQLiteStore) DeleteCalendarEvent(ctx context.Context, taskID int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM calendar_events WHERE task_id = ?`, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to delete calendar event: %w", err)
+	}
+	return expectOneRow(result, "calendar event for task", taskID)
+}
+
+const githubLinkColumns = `project_id, repo, token, login, webhook_secret, last_synced_at, created_at, updated_at`
+
+func scanGitHubLink(row interface{ Scan(...interface{}) error }) (*models.GitHubLink, error) {
+	link := &models.GitHubLink{}
+	var lastSyncedAt sql.NullTime
+	if err := row.Scan(
+		&link.ProjectID,
+		&link.Repo,
+		&link.Token,
+		&link.Login,
+		&link.WebhookSecret,
+		&lastSyncedAt,
+		&link.CreatedAt,
+		&link.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if lastSyncedAt.Valid {
+		link.LastSyncedAt = &lastSyncedAt.Time
+	}
+	return link, nil
+}
+
+// SaveGitHubLink creates or replaces a project's GitHub link.
+func (s *SQLiteStore) SaveGitHubLink(ctx context.Context, link *models.GitHubLink) error {
+	now := time.Now()
+	link.UpdatedAt = now
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = now
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO github_links (`+githubLinkColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(project_id) DO UPDATE SET
+			repo = excluded.repo,
+			token = excluded.token,
+			login = excluded.login,
+			webhook_secret = excluded.webhook_secret,
+			last_synced_at = excluded.last_synced_at,
+			updated_at = excluded.updated_at
+	`, link.ProjectID, link.Repo, link.Token, link.Login, link.WebhookSecret, link.LastSyncedAt, link.CreatedAt, link.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save github link: %w", err)
+	}
+	return nil
+}
+
+// GetGitHubLink retrieves the GitHub link for a project.
+func (s *SQLiteStore) GetGitHubLink(ctx context.Context, projectID int64) (*models.GitHubLink, error) {
+	link, err := scanGitHubLink(s.db.QueryRowContext(ctx, `
+		SELECT `+githubLinkColumns+` FROM github_links WHERE project_id = ?
+	`, projectID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("github link for project %d: %w", projectID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get github link: %w", err)
+	}
+	return link, nil
+}
+
+// GetGitHubLinkByRepo retrieves the link for an "owner/name" repository.
+// Repository names are matched case-insensitively, as on GitHub.
+func (s *SQLiteStore) GetGitHubLinkByRepo(ctx context.Context, repo string) (*models.GitHubLink, error) {
+	link, err := scanGitHubLink(s.db.QueryRowContext(ctx, `
+		SELECT `+githubLinkColumns+` FROM github_links WHERE repo = ? COLLATE NOCASE
+	`, repo))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("github link for %s: %w", repo, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get github link: %w", err)
+	}
+	return link, nil
+}
+
+// ListGitHubLinks returns every GitHub link ordered by repository.
+func (s *SQLiteStore) ListGitHubLinks(ctx context.Context) ([]models.GitHubLink, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+githubLinkColumns+` FROM github_links ORDER BY repo
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list github links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []models.GitHubLink
+	for rows.Next() {
+		link, err := scanGitHubLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan github link: %w", err)
+		}
+		links = append(links, *link)
+	}
+	return links, rows.Err()
+}
+
+// DeleteGitHubLink unlinks a project. Tasks created from issues are kept.
+func (s *SQLiteStore) DeleteGitHubLink(ctx context.Context, projectID int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM github_links WHERE project_id = ?`, projectID); err != nil {
+		return fmt.Errorf("failed to delete github link: %w", err)
+	}
+	return nil
+}
+
+// RecordProjectReview appends an entry to the review log for a project.
+func (s *SQLiteStore) RecordProjectReview(ctx context.Context, projectID int64, reviewedAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO review_log (project_id, reviewed_at) VALUES (?, ?)
+	`, projectID, reviewedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record project review: %w", err)
+	}
+	return nil
+}
+
+// ListLastProjectReviews returns when each reviewed project was last
+// reviewed, keyed by project ID. Projects never reviewed are absent.
+func (s *SQLiteStore) ListLastProjectReviews(ctx context.Context) (map[int64]time.Time, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.project_id, r.reviewed_at
+		FROM review_log r
+		WHERE r.id = (SELECT MAX(id) FROM review_log WHERE project_id = r.project_id)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := make(map[int64]time.Time)
+	for rows.Next() {
+		var projectID int64
+		var reviewedAt time.Time
+		if err := rows.Scan(&projectID, &reviewedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan project review: %w", err)
+		}
+		reviews[projectID] = reviewedAt
+	}
+	return reviews, rows.Err()
+}
+
+// GetAuthFailure returns the failed authentications recorded for client in
+// scope, or an empty record when there are none.
+func (s *SQLiteStore) GetAuthFailure(ctx context.Context, scope, client string) (*models.AuthFailure, error) {
+	failure := &models.AuthFailure{Scope: scope, Client: client}
+	var lockedUntil sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT failures, last_failure_at, locked_until FROM auth_failures WHERE scope = ? AND client = ?
+	`, scope, client).Scan(&failure.Failures, &failure.LastFailureAt, &lockedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return failure, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auth failures: %w", err)
+	}
+	if lockedUntil.Valid {
+		failure.LockedUntil = &lockedUntil.Time
+	}
+	return failure, nil
+}
+
+// SaveAuthFailure stores failure, replacing the previous record for its
+// scope and client.
+func (s *SQLiteStore) SaveAuthFailure(ctx context.Context, failure *models.AuthFailure) error {
+	var lockedUntil interface{}
+	if failure.LockedUntil != nil {
+		lockedUntil = *failure.LockedUntil
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO auth_failures (scope, client, failures, last_failure_at, locked_until)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(scope, client) DO UPDATE SET
+			failures = excluded.failures,
+			last_failure_at = excluded.last_failure_at,
+			locked_until = excluded.locked_until
+	`, failure.Scope, failure.Client, failure.Failures, failure.LastFailureAt, lockedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to save auth failures: %w", err)
+	}
+	return nil
+}
+
+// ClearAuthFailures forgets client's failed authentications in scope.
+func (s *SQLiteStore) ClearAuthFailures(ctx context.Context, scope, client string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM auth_failures WHERE scope = ? AND client = ?`, scope, client); err != nil {
+		return fmt.Errorf("failed to clear auth failures: %w", err)
+	}
+	return nil
+}
+
+// ListChanges returns the projects and tasks changed after seq since, at
+// most limit of them, oldest change first. Each appears once, with its last
+// change.
+func (s *SQLiteStore) ListChanges(ctx context.Context, since int64, limit int) ([]models.Change, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT seq, entity, entity_id, op, changed_at FROM changes WHERE seq > ? ORDER BY seq LIMIT ?
+	`, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []models.Change
+	for rows.Next() {
+		var c models.Change
+		if err := rows.Scan(&c.Seq, &c.Entity, &c.EntityID, &c.Op, &c.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan change: %w", err)
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// syncMutationTTL is how long a replayed mutation's ID is remembered. A
+// client offline for longer may have a change applied twice.
+const syncMutationTTL = 30 * 24 * time.Hour
+
+// SyncMutationApplied reports whether the offline change with the given
+// client ID was recorded by RecordSyncMutation.
+func (s *SQLiteStore) SyncMutationApplied(ctx context.Context, id string) (bool, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sync_mutations WHERE id = ?`, id).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to look up sync mutation: %w", err)
+	}
+	return n > 0, nil
+}
+
+// RecordSyncMutation remembers that the offline change id was applied at
+// the given time, and forgets those applied more than syncMutationTTL
+// before it.
+func (s *SQLiteStore) RecordSyncMutation(ctx context.Context, id string, at time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM sync_mutations WHERE applied_at < ?`, at.Add(-syncMutationTTL)); err != nil {
+		return fmt.Errorf("failed to prune sync mutations: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO sync_mutations (id, applied_at) VALUES (?, ?)`, id, at); err != nil {
+		return fmt.Errorf("failed to record sync mutation: %w", err)
+	}
+	return nil
+}
+
+// SaveJobStatus records a background job's last and next run.
+func (s *SQLiteStore) SaveJobStatus(ctx context.Context, status *models.JobStatus) error {
+	var lastRunAt interface{}
+	if status.LastRunAt != nil {
+		lastRunAt = *status.LastRunAt
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO job_status (name, schedule, last_run_at, last_duration_ms, last_error, next_run_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			schedule = excluded.schedule,
+			last_run_at = excluded.last_run_at,
+			last_duration_ms = excluded.last_duration_ms,
+			last_error = excluded.last_error,
+			next_run_at = excluded.next_run_at
+	`, status.Name, status.Schedule, lastRunAt, status.LastDuration.Milliseconds(), status.LastError, status.NextRunAt)
+	if err != nil {
+		return fmt.Errorf("failed to save job status: %w", err)
+	}
+	return nil
+}
+
+// ListJobStatuses returns every recorded background job, by name.
+func (s *SQLiteStore) ListJobStatuses(ctx context.Context) ([]models.JobStatus, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT name, schedule, last_run_at, last_duration_ms, last_error, next_run_at
+		FROM job_status ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job statuses: %w", err)
+	}
+	defer rows.Close()
+
+	var statuses []models.JobStatus
+	for rows.Next() {
+		var status models.JobStatus
+		var lastRunAt sql.NullTime
+		var durationMS int64
+		if err := rows.Scan(&status.Name, &status.Schedule, &lastRunAt, &durationMS, &status.LastError, &status.NextRunAt); err != nil {
+			return nil, fmt.Errorf("failed to scan job status: %w", err)
+		}
+		if lastRunAt.Valid {
+			status.LastRunAt = &lastRunAt.Time
+		}
+		status.LastDuration = time.Duration(durationMS) * time.Millisecond
+		statuses = append(statuses, status)
+	}
+	return statuses, rows.Err()
+}
+
+// PruneJobStatuses forgets jobs that are no longer registered.
+func (s *SQLiteStore) PruneJobStatuses(ctx context.Context, keep []string) error {
+	query := `DELETE FROM job_status`
+	args := make([]interface{}, len(keep))
+	if len(keep) > 0 {
+		query += ` WHERE name NOT IN (?` + strings.Repeat(", ?", len(keep)-1) + `)`
+		for i, name := range keep {
+			args[i] = name
+		}
+	}
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to prune job statuses: %w", err)
+	}
+	return nil
+}
+
+// Backup writes a consistent copy of the database to path, which must not
+// exist yet.
+func (s *SQLiteStore) Backup(ctx context.Context, path string) error {
+	if _, err := s.db.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	return nil
+}
+
+// anonymizedColumns lists the text people write, by table, for
+// BackupAnonymized to scramble. Values the app itself compares, such as
+// tags, scramble the same everywhere, so they still match up.
+var anonymizedColumns = []struct {
+	table   string
+	columns []string
+	where   string
+}{
+	{"projects", []string{"name", "description"}, ""},
+	{"tasks", []string{"description", "notes", "waiting_on"}, ""},
+	{"task_tags", []string{"tag"}, ""},
+	{"task_comments", []string{"body"}, ""},
+	{"subtasks", []string{"title"}, ""},
+	{"task_attachments", []string{"name"}, ""},
+	{"task_history", []string{"old_value", "new_value"}, "field IN ('description', 'notes')"},
+	{"task_external_refs", []string{"external_id"}, ""},
+	{"calendar_events", []string{"event_id", "title"}, ""},
+	{"project_links", []string{"title", "url"}, ""},
+	{"habits", []string{"name"}, ""},
+	{"notifications", []string{"message"}, ""},
+	{"slack_workspaces", []string{"team_name"}, ""},
+	{"github_links", []string{"repo", "login"}, ""},
+	{"report_schedules", []string{"recipients"}, ""},
+	{"saved_filters", []string{"name", "query"}, ""},
+	{"auth_failures", []string{"client"}, ""},
+}
+
+// BackupAnonymized writes a copy of the database to path, which must not
+// exist yet, for attaching to a bug report: everything people wrote is
+// passed through scramble, attachments are zeroed and credentials are
+// removed, while IDs, dates, statuses and counts are kept.
+func (s *SQLiteStore) BackupAnonymized(ctx context.Context, path string, scramble func(string) string) error {
+	if err := s.Backup(ctx, path); err != nil {
+		return err
+	}
+	db := sql.OpenDB(&connector{dsn: path, driver: &sqlite3.SQLiteDriver{}})
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize backup: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Triggers would record the scrambling as edits, in the history and
+	// revisions, so they are set aside while it runs.
+	triggers, err := listTriggers(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize backup: %w", err)
+	}
+	for _, trigger := range triggers {
+		if _, err := tx.ExecContext(ctx, `DROP TRIGGER "`+trigger.name+`"`); err != nil {
+			return fmt.Errorf("failed to anonymize backup: %w", err)
+		}
+	}
+
+	for _, t := range anonymizedColumns {
+		if err := scrambleColumns(ctx, tx, t.table, t.columns, t.where, scramble); err != nil {
+			return fmt.Errorf("failed to anonymize %s: %w", t.table, err)
+		}
+	}
+	for _, stmt := range []string{
+		`UPDATE task_attachments SET data = zeroblob(size)`,
+		`UPDATE slack_workspaces SET webhook_url = ''`,
+		`UPDATE google_tasks_account SET refresh_token = '', access_token = ''`,
+		`UPDATE google_calendar_account SET refresh_token = '', access_token = ''`,
+		`UPDATE github_links SET token = '', webhook_secret = ''`,
+		`UPDATE webhook_deliveries SET url = '', payload = ''`,
+		`UPDATE webhook_attempts SET response = ''`,
+		`DELETE FROM settings WHERE key LIKE '%token%' OR key LIKE '%secret%'`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to anonymize backup: %w", err)
+		}
+	}
+
+	for _, trigger := range triggers {
+		if _, err := tx.ExecContext(ctx, trigger.sql); err != nil {
+			return fmt.Errorf("failed to anonymize backup: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to anonymize backup: %w", err)
+	}
+	// Leave nothing of the original text in free pages.
+	if _, err := db.ExecContext(ctx, `VACUUM`); err != nil {
+		return fmt.Errorf("failed to anonymize backup: %w", err)
+	}
+	return nil
+}
+
+// scrambleColumns passes the non-empty values of columns in table, on the
+// rows matching where, through scramble.
+func scrambleColumns(ctx context.Context, tx *sql.Tx, table string, columns []string, where string, scramble func(string) string) error {
+	query := `SELECT rowid, ` + strings.Join(columns, ", ") + ` FROM ` + table
+	if where != "" {
+		query += ` WHERE ` + where
+	}
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	type row struct {
+		id     int64
+		values []sql.NullString
+	}
+	var all []row
+	for rows.Next() {
+		r := row{values: make([]sql.NullString, len(columns))}
+		dest := []interface{}{&r.id}
+		for i := range r.values {
+			dest = append(dest, &r.values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return err
+		}
+		all = append(all, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	sets := make([]string, len(columns))
+	for i, c := range columns {
+		sets[i] = c + ` = ?`
+	}
+	// OR REPLACE: two values scrambling alike in a unique column keep one
+	// row rather than failing the export.
+	update := `UPDATE OR REPLACE ` + table + ` SET ` + strings.Join(sets, ", ") + ` WHERE rowid = ?`
+	for _, r := range all {
+		args := make([]interface{}, 0, len(columns)+1)
+		for _, v := range r.values {
+			if v.Valid && v.String != "" {
+				args = append(args, scramble(v.String))
+			} else {
+				args = append(args, v)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, update, append(args, r.id)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type trigger struct{ name, sql string }
+
+func listTriggers(ctx context.Context, tx *sql.Tx) ([]trigger, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT name, sql FROM sqlite_master WHERE type = 'trigger' ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var triggers []trigger
+	for rows.Next() {
+		var t trigger
+		if err := rows.Scan(&t.name, &t.sql); err != nil {
+			return nil, err
+		}
+		triggers = append(triggers, t)
+	}
+	return triggers, rows.Err()
+}
+
+// Checkpoint copies the write-ahead log into the database file and empties
+// it. It fails if a reader keeps part of the log in use.
+func (s *SQLiteStore) Checkpoint(ctx context.Context) error {
+	var busy, logFrames, checkpointed int
+	if err := s.db.QueryRowContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`).Scan(&busy, &logFrames, &checkpointed); err != nil {
+		return fmt.Errorf("failed to checkpoint database: %w", err)
+	}
+	if busy != 0 {
+		return errors.New("failed to checkpoint database: the log is in use")
+	}
+	return nil
+}
+
+// Cleanup forgets token failures that no longer count towards a lockout,
+// then compacts the database file.
+func (s *SQLiteStore) Cleanup(ctx context.Context, now time.Time) (*models.CleanupResult, error) {
+	result := &models.CleanupResult{}
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM auth_failures
+		WHERE last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)
+	`, now.Add(-models.AuthFailureWindow), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete expired auth failures: %w", err)
+	}
+	result.ExpiredAuthFailures, _ = res.RowsAffected()
+
+	if result.SizeBefore, err = s.size(ctx); err != nil {
+		return nil, err
+	}
+	if _, err := s.db.ExecContext(ctx, `VACUUM`); err != nil {
+		return nil, fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	if result.SizeAfter, err = s.size(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Reset deletes every row of every table but the migration history,
+// leaving the database as a fresh install would, for demo mode. IDs are not
+// reused, so pages open from before the reset don't show other rows.
+func (s *SQLiteStore) Reset(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `PRAGMA defer_foreign_keys = ON`); err != nil {
+		return fmt.Errorf("failed to defer foreign keys: %w", err)
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'
+		ORDER BY name
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to list tables: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	// Delete triggers log tombstones in other tables, which the second
+	// pass removes.
+	for pass := 0; pass < 2; pass++ {
+		for _, table := range tables {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM "`+table+`"`); err != nil {
+				return fmt.Errorf("failed to empty %s: %w", table, err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reset: %w", err)
+	}
+	return nil
+}
+
+// size returns the size of the database in bytes, not counting the
+// write-ahead log.
+func (s *SQLiteStore) size(ctx context.Context) (int64, error) {
+	var pages, pageSize int64
+	if err := s.db.QueryRowContext(ctx, `PRAGMA page_count`).Scan(&pages); err != nil {
+		return 0, fmt.Errorf("failed to get database size: %w", err)
+	}
+	if err := s.db.QueryRowContext(ctx, `PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to get database size: %w", err)
+	}
+	return pages * pageSize, nil
+}
+
+// ListDailyCompletionCounts returns how many tasks were completed on each day
+// that had at least one completion, oldest first. Every completion counts,
+// including those of tasks since reopened. Tasks completed before
+// completed_at was recorded are not counted.
+func (s *SQLiteStore) ListDailyCompletionCounts(ctx context.Context) ([]models.DailyCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT date(c.completed_at) AS day, COUNT(*)
+		FROM task_completions c
+		JOIN tasks t ON t.id = c.task_id
+		WHERE t.deleted_at IS NULL
+		GROUP BY day
+		ORDER BY day
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daily completion counts: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []models.DailyCount
+	for rows.Next() {
+		var day sql.NullString
+		var c models.DailyCount
+		if err := rows.Scan(&day, &c.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan daily completion count: %w", err)
+		}
+		parsed, err := parseSQLiteDate(day.String)
+		if err != nil || parsed == nil {
+			// date() yields NULL for values it cannot read; skip those rows.
+			continue
+		}
+		c.Day = *parsed
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// CreateHabit adds a habit after the existing ones.
+func (s *SQLiteStore) CreateHabit(ctx context.Context, habit *models.Habit) error {
+	habit.CreatedAt = time.Now()
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO habits (name, cadence, sort_order, created_at)
+		VALUES (?, ?, COALESCE((SELECT MAX(sort_order) + 1 FROM habits), 1), ?)
+	`, habit.Name, habit.Cadence, habit.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create habit: %w", err)
+	}
+	if habit.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	if err := s.db.QueryRowContext(ctx, `SELECT sort_order FROM habits WHERE id = ?`, habit.ID).Scan(&habit.SortOrder); err != nil {
+		return fmt.Errorf("failed to load habit sort order: %w", err)
+	}
+	return nil
+}
+
+// ListHabits retrieves every habit in the order they were added, with its
+// check-ins.
+func (s *SQLiteStore) ListHabits(ctx context.Context) ([]models.Habit, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, cadence, sort_order, created_at FROM habits ORDER BY sort_order, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list habits: %w", err)
+	}
+	defer rows.Close()
+
+	var habits []models.Habit
+	byID := make(map[int64]int)
+	for rows.Next() {
+		var h models.Habit
+		if err := rows.Scan(&h.ID, &h.Name, &h.Cadence, &h.SortOrder, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan habit: %w", err)
+		}
+		byID[h.ID] = len(habits)
+		habits = append(habits, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	checkIns, err := s.db.QueryContext(ctx, `SELECT habit_id, day FROM habit_checkins ORDER BY day`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list habit check-ins: %w", err)
+	}
+	defer checkIns.Close()
+	for checkIns.Next() {
+		var habitID int64
+		var day string
+		if err := checkIns.Scan(&habitID, &day); err != nil {
+			return nil, fmt.Errorf("failed to scan habit check-in: %w", err)
+		}
+		parsed, err := parseSQLiteDate(day)
+		if err != nil || parsed == nil {
+			continue
+		}
+		if i, ok := byID[habitID]; ok {
+			habits[i].CheckIns = append(habits[i].CheckIns, *parsed)
+		}
+	}
+	return habits, checkIns.Err()
+}
+
+// DeleteHabit deletes a habit and its check-ins.
+func (s *SQLiteStore) DeleteHabit(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM habits WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete habit: %w", err)
+	}
+	return expectOneRow(result, "habit", id)
+}
+
+// ToggleHabitCheckIn checks a habit in on day, or takes back the check-in
+// if it already had one, and reports whether it is now checked in.
+func (s *SQLiteStore) ToggleHabitCheckIn(ctx context.Context, habitID int64, day time.Time) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM habits WHERE id = ?)`, habitID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to get habit: %w", err)
+	}
+	if !exists {
+		return false, fmt.Errorf("habit %d: %w", habitID, ErrNotFound)
+	}
+
+	key := day.Format("2006-01-02")
+	result, err := tx.ExecContext(ctx, `DELETE FROM habit_checkins WHERE habit_id = ? AND day = ?`, habitID, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove habit check-in: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to count removed check-ins: %w", err)
+	}
+	checkedIn := n == 0
+	if checkedIn {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO habit_checkins (habit_id, day, created_at) VALUES (?, ?, ?)
+		`, habitID, key, time.Now()); err != nil {
+			return false, fmt.Errorf("failed to check in habit: %w", err)
+		}
+	}
+	return checkedIn, tx.Commit()
+}
+
+// GetSetting returns the stored value for key, or ErrNotFound.
+func (s *SQLiteStore) GetSetting(ctx context.Context, key string) (string, error) {
+	var value string
+	err := s.db.QueryRowContext(ctx, `SELECT value FROM settings WHERE key = ?`, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("setting %q: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get setting: %w", err)
+	}
+	return value, nil
+}
+
+// SetSetting stores value under key, replacing any previous value.
+func (s *SQLiteStore) SetSetting(ctx context.Context, key, value string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`, key, value)
+	if err != nil {
+		return fmt.Errorf("failed to save setting: %w", err)
+	}
+	return nil
+}
+
+// Settings keys for the preferences in models.Settings.
+const (
+	settingDefaultPriority = "default_priority"
+	settingUpcomingDays    = "upcoming_days"
+	settingStaleDays       = "stale_days"
+	settingEscalateDays    = "escalate_days"
+	settingDateFormat      = "date_format"
+	settingWeekStart       = "week_start"
+	settingTheme           = "theme"
+	settingPalette         = "palette"
+	settingLanguage        = "language"
+	settingTimeZone        = "time_zone"
+	settingQuietStart      = "quiet_hours_start"
+	settingQuietEnd        = "quiet_hours_end"
+	settingDigestTime      = "digest_time"
+)
+
+// GetSettings returns the user's preferences. Preferences never saved, or
+// saved with a value no longer supported, fall back to their defaults.
+func (s *SQLiteStore) GetSettings(ctx context.Context) (*models.Settings, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT key, value FROM settings WHERE key IN (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		settingDefaultPriority, settingUpcomingDays, settingStaleDays, settingEscalateDays, settingDateFormat, settingWeekStart, settingTheme, settingPalette, settingLanguage,
+		settingTimeZone, settingQuietStart, settingQuietEnd, settingDigestTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	defer rows.Close()
+
+	// Every accepted value is valid, so checking the whole struct after each
+	// one rejects only that value.
+	settings := models.DefaultSettings()
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan setting: %w", err)
+		}
+		next := settings
+		switch key {
+		case settingDefaultPriority:
+			next.DefaultPriority = value
+		case settingUpcomingDays:
+			next.UpcomingDays, _ = strconv.Atoi(value)
+		case settingStaleDays:
+			next.StaleDays, _ = strconv.Atoi(value)
+		case settingEscalateDays:
+			next.EscalateDays, _ = strconv.Atoi(value)
+		case settingDateFormat:
+			next.DateFormat = value
+		case settingWeekStart:
+			day, _ := strconv.Atoi(value)
+			next.WeekStart = time.Weekday(day)
+		case settingTheme:
+			next.Theme = value
+		case settingPalette:
+			next.Palette = value
+		case settingLanguage:
+			next.Language = value
+		case settingTimeZone:
+			next.TimeZone = value
+		case settingQuietStart:
+			next.QuietHoursStart = value
+		case settingQuietEnd:
+			next.QuietHoursEnd = value
+		case settingDigestTime:
+			next.DigestTime = value
+		}
+		if next.Validate() == nil {
+			settings = next
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+// SaveSettings stores every preference in settings in one transaction.
+func (s *SQLiteStore) SaveSettings(ctx context.Context, settings *models.Settings) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	values := map[string]string{
+		settingDefaultPriority: settings.DefaultPriority,
+		settingUpcomingDays:    strconv.Itoa(settings.UpcomingDays),
+		settingStaleDays:       strconv.Itoa(settings.StaleDays),
+		settingEscalateDays:    strconv.Itoa(settings.EscalateDays),
+		settingDateFormat:      settings.DateFormat,
+		settingWeekStart:       strconv.Itoa(int(settings.WeekStart)),
+		settingTheme:           settings.Theme,
+		settingPalette:         settings.Palette,
+		settingLanguage:        settings.Language,
+		settingTimeZone:        settings.TimeZone,
+		settingQuietStart:      settings.QuietHoursStart,
+		settingQuietEnd:        settings.QuietHoursEnd,
+		settingDigestTime:      settings.DigestTime,
+	}
+	for key, value := range values {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO settings (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+		`, key, value); err != nil {
+			return fmt.Errorf("failed to save setting: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ReportCounts counts tasks created and task completions between from and to
+// (inclusive dates), grouped by one of the GroupBy constants. A task reopened
+// and completed again counts each time. Weeks start on
+// weekStart and are labelled with their first day. Rows are ordered by group:
+// priorities high to low, otherwise alphabetically.
+func (s *SQLiteStore) ReportCounts(ctx context.Context, from, to time.Time, groupBy string, weekStart time.Weekday) ([]models.ReportRow, error) {
+	fromStr, toStr := from.Format("2006-01-02"), to.Format("2006-01-02")
+	rows := make(map[string]*models.ReportRow)
+	var order []*models.ReportRow
+
+	count := func(from, day string, add func(*models.ReportRow, int)) error {
+		key, label, err := reportGroupExpr(groupBy, day, weekStart)
+		if err != nil {
+			return err
+		}
+		q, err := s.db.QueryContext(ctx, `
+			SELECT CAST(`+key+` AS TEXT), `+label+`, COUNT(*)
+			FROM `+from+`
+			WHERE `+day+` BETWEEN ? AND ? AND deleted_at IS NULL
+			GROUP BY `+key, fromStr, toStr)
+		if err != nil {
+			return err
+		}
+		defer q.Close()
+
+		for q.Next() {
+			var k string
+			var l sql.NullString
+			var n int
+			if err := q.Scan(&k, &l, &n); err != nil {
+				return err
+			}
+			row, ok := rows[k]
+			if !ok {
+				row = &models.ReportRow{Group: l.String}
+				rows[k] = row
+				order = append(order, row)
+			}
+			add(row, n)
+		}
+		return q.Err()
+	}
+
+	if err := count(`tasks`, `substr(created_at, 1, 10)`, func(r *models.ReportRow, n int) { r.Created = n }); err != nil {
+		return nil, fmt.Errorf("failed to count created tasks: %w", err)
+	}
+	completions := `tasks JOIN task_completions c ON c.task_id = tasks.id`
+	if err := count(completions, `date(c.completed_at)`, func(r *models.ReportRow, n int) { r.Completed = n }); err != nil {
+		return nil, fmt.Errorf("failed to count completed tasks: %w", err)
+	}
+
+	report := make([]models.ReportRow, 0, len(order))
+	for _, row := range order {
+		report = append(report, *row)
+	}
+	sort.SliceStable(report, func(i, j int) bool {
+		if groupBy == GroupByPriority {
+			return priorityOrder(report[i].Group) < priorityOrder(report[j].Group)
+		}
+		return report[i].Group < report[j].Group
+	})
+	return report, nil
+}
+
+// reportGroupExpr returns the SQL expressions a report groups by and labels
+// rows with. day is the task date the report counts by.
+func reportGroupExpr(groupBy, day string, weekStart time.Weekday) (key, label string, err error) {
+	switch groupBy {
+	case GroupByProject:
+		return `project_id`, `(SELECT name FROM projects WHERE projects.id = tasks.project_id)`, nil
+	case GroupByPriority:
+		return `priority`, `priority`, nil
+	case GroupByWeek:
+		// Six days before the last day of the week, found with SQLite's
+		// "weekday N" modifier (0 is Sunday).
+		lastDay := (int(weekStart) + 6) % 7
+		week := `date(` + day + `, 'weekday ` + strconv.Itoa(lastDay) + `', '-6 days')`
+		return week, week, nil
+	}
+	return "", "", fmt.Errorf("unknown report grouping %q", groupBy)
+}
+
+func priorityOrder(priority string) int {
+	switch priority {
+	case "high":
+		return 0
+	case "medium":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// CreateReportSchedule stores a report schedule. CreatedAt defaults to now,
+// and the first report goes out at the first scheduled time after it.
+func (s *SQLiteStore) CreateReportSchedule(ctx context.Context, rs *models.ReportSchedule) error {
+	if rs.CreatedAt.IsZero() {
+		rs.CreatedAt = time.Now()
+	}
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO report_schedules (recipients, schedule, days, last_run_at, last_error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, strings.Join(rs.Recipients, ","), rs.Schedule, rs.Days, rs.LastRunAt, rs.LastError, rs.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create report schedule: %w", err)
+	}
+	if rs.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return nil
+}
+
+const reportScheduleColumns = `id, recipients, schedule, days, last_run_at, last_error, created_at`
+
+// ListReportSchedules retrieves the report schedules, oldest first.
+func (s *SQLiteStore) ListReportSchedules(ctx context.Context) ([]models.ReportSchedule, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+reportScheduleColumns+` FROM report_schedules ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list report schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []models.ReportSchedule
+	for rows.Next() {
+		rs, err := scanReportSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *rs)
+	}
+	return schedules, rows.Err()
+}
+
+// GetReportSchedule retrieves a report schedule by ID.
+func (s *SQLiteStore) GetReportSchedule(ctx context.Context, id int64) (*models.ReportSchedule, error) {
+	rs, err := scanReportSchedule(s.db.QueryRowContext(ctx, `SELECT `+reportScheduleColumns+` FROM report_schedules WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("report schedule %d: %w", id, ErrNotFound)
+	}
+	return rs, err
+}
+
+func scanReportSchedule(row interface{ Scan(...interface{}) error }) (*models.ReportSchedule, error) {
+	var rs models.ReportSchedule
+	var recipients string
+	var lastRun sql.NullTime
+	if err := row.Scan(&rs.ID, &recipients, &rs.Schedule, &rs.Days, &lastRun, &rs.LastError, &rs.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan report schedule: %w", err)
+	}
+	rs.Recipients = strings.Split(recipients, ",")
+	if lastRun.Valid {
+		rs.LastRunAt = &lastRun.Time
+	}
+	return &rs, nil
+}
+
+// RecordReportRun saves when rs last sent a report and the error of its
+// last attempt.
+func (s *SQLiteStore) RecordReportRun(ctx context.Context, rs *models.ReportSchedule) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE report_schedules SET last_run_at = ?, last_error = ? WHERE id = ?
+	`, rs.LastRunAt, rs.LastError, rs.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record report run: %w", err)
+	}
+	return expectOneRow(result, "report schedule", rs.ID)
+}
+
+// DeleteReportSchedule stops a scheduled report.
+func (s *SQLiteStore) DeleteReportSchedule(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM report_schedules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete report schedule: %w", err)
+	}
+	return expectOneRow(result, "report schedule", id)
+}
+
+// SaveFilter stores a filter expression under its name, replacing the
+// expression saved under that name before, if any.
+func (s *SQLiteStore) SaveFilter(ctx context.Context, f *models.SavedFilter) error {
+	if f.CreatedAt.IsZero() {
+		f.CreatedAt = time.Now()
+	}
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO saved_filters (name, query, created_at) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET query = excluded.query
+		RETURNING id, created_at
+	`, f.Name, f.Query, f.CreatedAt).Scan(&f.ID, &f.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save filter: %w", err)
+	}
+	return nil
+}
+
+// ListSavedFilters retrieves the saved filters by name.
+func (s *SQLiteStore) ListSavedFilters(ctx context.Context) ([]models.SavedFilter, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, query, created_at FROM saved_filters ORDER BY name COLLATE NOCASE, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved filters: %w", err)
+	}
+	defer rows.Close()
+
+	var filters []models.SavedFilter
+	for rows.Next() {
+		var f models.SavedFilter
+		if err := rows.Scan(&f.ID, &f.Name, &f.Query, &f.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan saved filter: %w", err)
+		}
+		filters = append(filters, f)
+	}
+	return filters, rows.Err()
+}
+
+// DeleteSavedFilter forgets a saved filter.
+func (s *SQLiteStore) DeleteSavedFilter(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM saved_filters WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved filter: %w", err)
+	}
+	return expectOneRow(result, "saved filter", id)
+}
+
+// CreateTaskComment adds a comment to a task.
+func (s *SQLiteStore) CreateTaskComment(ctx context.Context, comment *models.TaskComment) error {
+	comment.CreatedAt = time.Now()
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO task_comments (task_id, body, created_at) VALUES (?, ?, ?)
+	`, comment.TaskID, comment.Body, comment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create task comment: %w", err)
+	}
+	if comment.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return nil
+}
+
+// ListTaskComments retrieves a task's comments, oldest first.
+func (s *SQLiteStore) ListTaskComments(ctx context.Context, taskID int64) ([]models.TaskComment, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, task_id, body, created_at FROM task_comments WHERE task_id = ? ORDER BY id
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []models.TaskComment
+	for rows.Next() {
+		var c models.TaskComment
+		if err := rows.Scan(&c.ID, &c.TaskID, &c.Body, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan task comment: %w", err)
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+// DeleteTaskComment deletes a comment.
+func (s *SQLiteStore) DeleteTaskComment(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM task_comments WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete task comment: %w", err)
+	}
+	return expectOneRow(result, "comment", id)
+}
+
+// CreateSubtask adds a subtask after a task's existing ones.
+func (s *SQLiteStore) CreateSubtask(ctx context.Context, subtask *models.Subtask) error {
+	subtask.CreatedAt = time.Now()
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO subtasks (task_id, title, done, sort_order, created_at)
+		VALUES (?, ?, ?, COALESCE((SELECT MAX(sort_order) + 1 FROM subtasks WHERE task_id = ?), 1), ?)
+	`, subtask.TaskID, subtask.Title, subtask.Done, subtask.TaskID, subtask.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create subtask: %w", err)
+	}
+	if subtask.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	if err := s.db.QueryRowContext(ctx, `SELECT sort_order FROM subtasks WHERE id = ?`, subtask.ID).Scan(&subtask.SortOrder); err != nil {
+		return fmt.Errorf("failed to load subtask sort order: %w", err)
+	}
+	return nil
+}
+
+const subtaskColumns = `id, task_id, title, done, sort_order, created_at`
+
+func scanSubtask(row interface{ Scan(...interface{}) error }, subtask *models.Subtask) error {
+	return row.Scan(&subtask.ID, &subtask.TaskID, &subtask.Title, &subtask.Done, &subtask.SortOrder, &subtask.CreatedAt)
+}
+
+// ListSubtasks retrieves a task's subtasks in order.
+func (s *SQLiteStore) ListSubtasks(ctx context.Context, taskID int64) ([]models.Subtask, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+subtaskColumns+` FROM subtasks WHERE task_id = ? ORDER BY sort_order, id
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subtasks: %w", err)
+	}
+	defer rows.Close()
+
+	var subtasks []models.Subtask
+	for rows.Next() {
+		var st models.Subtask
+		if err := scanSubtask(rows, &st); err != nil {
+			return nil, fmt.Errorf("failed to scan subtask: %w", err)
+		}
+		subtasks = append(subtasks, st)
+	}
+	return subtasks, rows.Err()
+}
+
+// ToggleSubtask ticks a subtask off, or back on, and returns it.
+func (s *SQLiteStore) ToggleSubtask(ctx context.Context, id int64) (*models.Subtask, error) {
+	result, err := s.db.ExecContext(ctx, `UPDATE subtasks SET done = NOT done WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to toggle subtask: %w", err)
+	}
+	if err := expectOneRow(result, "subtask", id); err != nil {
+		return nil, err
+	}
+	subtask := &models.Subtask{}
+	row := s.db.QueryRowContext(ctx, `SELECT `+subtaskColumns+` FROM subtasks WHERE id = ?`, id)
+	if err := scanSubtask(row, subtask); err != nil {
+		return nil, fmt.Errorf("failed to get subtask: %w", err)
+	}
+	return subtask, nil
+}
+
+// DeleteSubtask deletes a subtask.
+func (s *SQLiteStore) DeleteSubtask(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM subtasks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete subtask: %w", err)
+	}
+	return expectOneRow(result, "subtask", id)
+}
+
+// CreateTaskAttachment stores a file attached to a task.
+func (s *SQLiteStore) CreateTaskAttachment(ctx context.Context, attachment *models.TaskAttachment) error {
+	attachment.CreatedAt = time.Now()
+	attachment.Size = int64(len(attachment.Data))
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO task_attachments (task_id, name, content_type, size, data, created_at) VALUES (?, ?, ?, ?, ?, ?)
+	`, attachment.TaskID, attachment.Name, attachment.ContentType, attachment.Size, attachment.Data, attachment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create task attachment: %w", err)
+	}
+	if attachment.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return nil
+}
+
+// ListTaskAttachments retrieves a task's attachments, oldest first, without
+// their contents.
+func (s *SQLiteStore) ListTaskAttachments(ctx context.Context, taskID int64) ([]models.TaskAttachment, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, task_id, name, content_type, size, created_at FROM task_attachments WHERE task_id = ? ORDER BY id
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []models.TaskAttachment
+	for rows.Next() {
+		var a models.TaskAttachment
+		if err := rows.Scan(&a.ID, &a.TaskID, &a.Name, &a.ContentType, &a.Size, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan task attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// GetTaskAttachment retrieves an attachment with its contents.
+func (s *SQLiteStore) GetTaskAttachment(ctx context.Context, id int64) (*models.TaskAttachment, error) {
+	a := &models.TaskAttachment{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, task_id, name, content_type, size, data, created_at FROM task_attachments WHERE id = ?
+	`, id).Scan(&a.ID, &a.TaskID, &a.Name, &a.ContentType, &a.Size, &a.Data, &a.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("attachment %d: %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get task attachment: %w", err)
+	}
+	return a, nil
+}
+
+// DeleteTaskAttachment deletes an attachment.
+func (s *SQLiteStore) DeleteTaskAttachment(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM task_attachments WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete task attachment: %w", err)
+	}
+	return expectOneRow(result, "attachment", id)
+}
+
+// ListTaskHistory retrieves the recorded changes to a task, oldest first.
+func (s *SQLiteStore) ListTaskHistory(ctx context.Context, taskID int64) ([]models.TaskHistoryEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, task_id, field, COALESCE(old_value, ''), COALESCE(new_value, ''), changed_at
+		FROM task_history WHERE task_id = ? ORDER BY id
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []models.TaskHistoryEntry
+	for rows.Next() {
+		var e models.TaskHistoryEntry
+		if err := rows.Scan(&e.ID, &e.TaskID, &e.Field, &e.OldValue, &e.NewValue, &e.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan task history: %w", err)
+		}
+		history = append(history, e)
+	}
+	return history, rows.Err()
+}
+
+// ListTaskCompletions lists every time a task was completed, newest first.
+func (s *SQLiteStore) ListTaskCompletions(ctx context.Context, taskID int64) ([]models.TaskCompletion, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, task_id, completed_at FROM task_completions WHERE task_id = ? ORDER BY id DESC
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task completions: %w", err)
+	}
+	defer rows.Close()
+
+	var completions []models.TaskCompletion
+	for rows.Next() {
+		var c models.TaskCompletion
+		if err := rows.Scan(&c.ID, &c.TaskID, &c.CompletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan task completion: %w", err)
+		}
+		completions = append(completions, c)
+	}
+	return completions, rows.Err()
+}
+
+// CreateTaskLink links two tasks, replacing any link already between them
+// in either direction.
+func (s *SQLiteStore) CreateTaskLink(ctx context.Context, link *models.TaskLink) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range []int64{link.TaskID, link.RelatedTaskID} {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM tasks WHERE id = ? AND deleted_at IS NULL)`, id).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to get task: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("task %d: %w", id, ErrNotFound)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM task_links
+		WHERE (task_id = ?1 AND related_task_id = ?2) OR (task_id = ?2 AND related_task_id = ?1)
+	`, link.TaskID, link.RelatedTaskID); err != nil {
+		return fmt.Errorf("failed to replace task link: %w", err)
+	}
+	link.CreatedAt = time.Now()
+	link.Inverse = false
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO task_links (task_id, related_task_id, relation, created_at) VALUES (?, ?, ?, ?)
+	`, link.TaskID, link.RelatedTaskID, link.Relation, link.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create task link: %w", err)
+	}
+	if link.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ListTaskLinks retrieves the links of a task made on either end, as seen
+// from taskID, with the related tasks' descriptions, statuses and projects.
+// Links to deleted tasks are left out.
+func (s *SQLiteStore) ListTaskLinks(ctx context.Context, taskID int64) ([]models.TaskLink, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT l.id, l.relation, l.created_at, l.task_id != ?1, t.id, t.description, t.status, p.name
+		FROM task_links l
+		JOIN tasks t ON t.id = CASE WHEN l.task_id = ?1 THEN l.related_task_id ELSE l.task_id END
+		JOIN projects p ON p.id = t.project_id
+		WHERE (l.task_id = ?1 OR l.related_task_id = ?1) AND t.deleted_at IS NULL
+		ORDER BY l.created_at, l.id
+	`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []models.TaskLink
+	for rows.Next() {
+		link := models.TaskLink{TaskID: taskID}
+		if err := rows.Scan(&link.ID, &link.Relation, &link.CreatedAt, &link.Inverse,
+			&link.RelatedTaskID, &link.RelatedDescription, &link.RelatedStatus, &link.RelatedProject); err != nil {
+			return nil, fmt.Errorf("failed to scan task link: %w", err)
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// DeleteTaskLink removes a link between two tasks.
+func (s *SQLiteStore) DeleteTaskLink(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM task_links WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete task link: %w", err)
+	}
+	return expectOneRow(result, "task link", id)
+}
+
+// AddToDayPlan adds tasks to the plan for day, after those already in it.
+// Tasks already planned for the day stay where they are. Either every task
+// is added or, when one doesn't exist, none are.
+func (s *SQLiteStore) AddToDayPlan(ctx context.Context, day time.Time, taskIDs ...int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	key := day.Format("2006-01-02")
+	now := time.Now()
+	for _, id := range taskIDs {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM tasks WHERE id = ? AND deleted_at IS NULL)`, id).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to get task: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("task %d: %w", id, ErrNotFound)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO day_plans (day, task_id, sort_order, added_at)
+			VALUES (?1, ?2, COALESCE((SELECT MAX(sort_order) + 1 FROM day_plans WHERE day = ?1), 1), ?3)
+		`, key, id, now); err != nil {
+			return fmt.Errorf("failed to add task to day plan: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// RemoveFromDayPlan takes a task out of the plan for day.
+func (s *SQLiteStore) RemoveFromDayPlan(ctx context.Context, day time.Time, taskID int64) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM day_plans WHERE day = ? AND task_id = ?
+	`, day.Format("2006-01-02"), taskID)
+	if err != nil {
+		return fmt.Errorf("failed to remove task from day plan: %w", err)
+	}
+	return expectOneRow(result, "planned task", taskID)
+}
+
+// ListDayPlan retrieves the tasks planned for day, with their project names,
+// in the order they were added. Done tasks stay in the plan.
+func (s *SQLiteStore) ListDayPlan(ctx context.Context, day time.Time) ([]models.Task, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+joinedTaskColumns+`, p.name
+		FROM day_plans d
+		JOIN tasks t ON t.id = d.task_id
+		JOIN projects p ON t.project_id = p.id
+		WHERE d.day = ? AND t.deleted_at IS NULL
+		ORDER BY d.sort_order, d.added_at
+	`, day.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list day plan: %w", err)
+	}
+	return scanTasksWithProject(rows)
+}
+
+// ListDayPlanCarryover finds the last day before day that had a plan and
+// returns it with its tasks still open and not planned for day, in plan
+// order. It returns the zero time when there is no earlier plan.
+func (s *SQLiteStore) ListDayPlanCarryover(ctx context.Context, day time.Time) (time.Time, []models.Task, error) {
+	key := day.Format("2006-01-02")
+	var last sql.NullString
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT CAST(MAX(day) AS TEXT) FROM day_plans WHERE day < ?
+	`, key).Scan(&last); err != nil {
+		return time.Time{}, nil, fmt.Errorf("failed to find last day plan: %w", err)
+	}
+	if !last.Valid {
+		return time.Time{}, nil, nil
+	}
+	previous, err := parseSQLiteDate(last.String)
+	if err != nil || previous == nil {
+		return time.Time{}, nil, fmt.Errorf("failed to parse day plan date %q: %w", last.String, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+joinedTaskColumns+`, p.name
+		FROM day_plans d
+		JOIN tasks t ON t.id = d.task_id
+		JOIN projects p ON t.project_id = p.id
+		WHERE d.day = ? AND t.status != 'done' AND t.deleted_at IS NULL AND t.someday = FALSE AND p.completed = FALSE
+		AND t.id NOT IN (SELECT task_id FROM day_plans WHERE day = ?)
+		ORDER BY d.sort_order, d.added_at
+	`, last.String, key)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("failed to list day plan carryover: %w", err)
+	}
+	tasks, err := scanTasksWithProject(rows)
+	return *previous, tasks, err
+}
+
+// StartFocusSession starts timing work on a task at the given time,
+// stopping the session already running, if any.
+func (s *SQLiteStore) StartFocusSession(ctx context.Context, taskID int64, at time.Time) (*models.FocusSession, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	session := &models.FocusSession{TaskID: taskID, StartedAt: at}
+	err = tx.QueryRowContext(ctx, `SELECT description FROM tasks WHERE id = ? AND deleted_at IS NULL`, taskID).Scan(&session.Task)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("task %d: %w", taskID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE focus_sessions SET ended_at = ? WHERE ended_at IS NULL`, at); err != nil {
+		return nil, fmt.Errorf("failed to stop focus session: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, `INSERT INTO focus_sessions (task_id, started_at) VALUES (?, ?)`, taskID, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start focus session: %w", err)
+	}
+	if session.ID, err = result.LastInsertId(); err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return session, tx.Commit()
+}
+
+// StopFocusSession stops the running focus session at the given time and
+// returns it. It returns ErrNotFound when none is running.
+func (s *SQLiteStore) StopFocusSession(ctx context.Context, at time.Time) (*models.FocusSession, error) {
+	session, err := s.GetRunningFocusSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if at.Before(session.StartedAt) {
+		at = session.StartedAt
+	}
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE focus_sessions SET ended_at = ? WHERE id = ? AND ended_at IS NULL
+	`, at, session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stop focus session: %w", err)
+	}
+	if err := expectOneRow(result, "focus session", session.ID); err != nil {
+		return nil, err
+	}
+	session.EndedAt = &at
+	return session, nil
+}
+
+// GetRunningFocusSession returns the focus session still running, or
+// ErrNotFound when there is none.
+func (s *SQLiteStore) GetRunningFocusSession(ctx context.Context) (*models.FocusSession, error) {
+	var session models.FocusSession
+	err := s.db.QueryRowContext(ctx, `
+		SELECT f.id, f.task_id, f.started_at, t.description
+		FROM focus_sessions f
+		JOIN tasks t ON t.id = f.task_id
+		WHERE f.ended_at IS NULL AND t.deleted_at IS NULL
+	`).Scan(&session.ID, &session.TaskID, &session.StartedAt, &session.Task)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("running focus session: %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get running focus session: %w", err)
+	}
+	return &session, nil
+}
+
+// ListFocusSessions retrieves the focus sessions started in [from, to),
+// oldest first, for one task or, with taskID 0, every task.
+func (s *SQLiteStore) ListFocusSessions(ctx context.Context, taskID int64, from, to time.Time) ([]models.FocusSession, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT f.id, f.task_id, f.started_at, f.ended_at, t.description
+		FROM focus_sessions f
+		JOIN tasks t ON t.id = f.task_id
+		WHERE (?1 = 0 OR f.task_id = ?1) AND f.started_at >= ?2 AND f.started_at < ?3
+		AND t.deleted_at IS NULL
+		ORDER BY f.started_at, f.id
+	`, taskID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list focus sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []models.FocusSession
+	for rows.Next() {
+		var f models.FocusSession
+		var endedAt sql.NullTime
+		if err := rows.Scan(&f.ID, &f.TaskID, &f.StartedAt, &endedAt, &f.Task); err != nil {
+			return nil, fmt.Errorf("failed to scan focus session: %w", err)
+		}
+		if endedAt.Valid {
+			f.EndedAt = &endedAt.Time
+		}
+		sessions = append(sessions, f)
+	}
+	return sessions, rows.Err()
+}
+
+// CreateNotification adds an unread entry to the inbox. CreatedAt defaults
+// to now.
+func (s *SQLiteStore) CreateNotification(ctx context.Context, n *models.Notification) error {
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO notifications (kind, message, task_id, project_id, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, n.Kind, n.Message, n.TaskID, n.ProjectID, n.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	if n.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	n.ReadAt = nil
+	return nil
+}
+
+// ListNotifications retrieves the newest limit entries of the inbox, read or
+// not, newest first.
+func (s *SQLiteStore) ListNotifications(ctx context.Context, limit int) ([]models.Notification, error) {
+	notifications, _, err := s.ListNotificationsPage(ctx, nil, limit)
+	return notifications, err
+}
+
+// ListNotificationsPage retrieves up to limit inbox entries after the keyset,
+// or the newest ones when after is nil, newest first. It also returns the
+// keyset of the last entry, or nil when there are no more.
+func (s *SQLiteStore) ListNotificationsPage(ctx context.Context, after *Keyset, limit int) ([]models.Notification, *Keyset, error) {
+	if limit <= 0 {
+		return nil, nil, nil
+	}
+	query := `
+		SELECT id, kind, message, task_id, project_id, created_at, read_at, CAST(created_at AS TEXT)
+		FROM notifications`
+	var args []interface{}
+	if after != nil {
+		query += ` WHERE (created_at, id) < (?, ?)`
+		args = append(args, after.Key, after.ID)
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit+1)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+	var next *Keyset
+	var lastKey string
+	for rows.Next() {
+		if len(notifications) == limit {
+			next = &Keyset{Key: lastKey, ID: notifications[limit-1].ID}
+			break
+		}
+		var n models.Notification
+		var taskID, projectID sql.NullInt64
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.Kind, &n.Message, &taskID, &projectID, &n.CreatedAt, &readAt, &lastKey); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		if taskID.Valid {
+			n.TaskID = &taskID.Int64
+		}
+		if projectID.Valid {
+			n.ProjectID = &projectID.Int64
+		}
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return notifications, next, nil
+}
+
+// CountUnreadNotifications returns how many inbox entries haven't been read.
+func (s *SQLiteStore) CountUnreadNotifications(ctx context.Context) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notifications WHERE read_at IS NULL`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkNotificationRead marks one inbox entry read at the given time. An entry
+// already read keeps its first read time.
+func (s *SQLiteStore) MarkNotificationRead(ctx context.Context, id int64, at time.Time) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE notifications SET read_at = COALESCE(read_at, ?) WHERE id = ?
+	`, at, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification read: %w", err)
+	}
+	return expectOneRow(result, "notification", id)
+}
+
+// MarkAllNotificationsRead marks every unread inbox entry read at the given
+// time.
+func (s *SQLiteStore) MarkAllNotificationsRead(ctx context.Context, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE notifications SET read_at = ? WHERE read_at IS NULL`, at)
+	if err != nil {
+		return fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return nil
+}
+
+// GetProjectNotificationPrefs returns the notification preferences of a
+// project, every channel on when none were saved, or ErrNotFound when the
+// project doesn't exist.
+func (s *SQLiteStore) GetProjectNotificationPrefs(ctx context.Context, projectID int64) (*models.ProjectNotificationPrefs, error) {
+	prefs := models.DefaultProjectNotificationPrefs(projectID)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(n.muted, FALSE), COALESCE(n.email, TRUE), COALESCE(n.slack, TRUE), COALESCE(n.inbox, TRUE)
+		FROM projects p
+		LEFT JOIN project_notification_prefs n ON n.project_id = p.id
+		WHERE p.id = ?
+	`, projectID).Scan(&prefs.Muted, &prefs.Email, &prefs.Slack, &prefs.Inbox)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("project %d: %w", projectID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	return &prefs, nil
+}
+
+// SaveProjectNotificationPrefs stores the notification preferences of a
+// project. It returns ErrNotFound when the project doesn't exist.
+func (s *SQLiteStore) SaveProjectNotificationPrefs(ctx context.Context, prefs *models.ProjectNotificationPrefs) error {
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO project_notification_prefs (project_id, muted, email, slack, inbox, updated_at)
+		SELECT id, ?, ?, ?, ?, CURRENT_TIMESTAMP FROM projects WHERE id = ?
+		ON CONFLICT(project_id) DO UPDATE SET
+			muted = excluded.muted, email = excluded.email, slack = excluded.slack,
+			inbox = excluded.inbox, updated_at = excluded.updated_at
+	`, prefs.Muted, prefs.Email, prefs.Slack, prefs.Inbox, prefs.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return expectOneRow(result, "project", prefs.ProjectID)
+}
+
+// ListNotificationPrefs returns every saved project notification
+// preference, for dispatchers to check before sending.
+func (s *SQLiteStore) ListNotificationPrefs(ctx context.Context) (models.NotificationPrefs, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT project_id, muted, email, slack, inbox FROM project_notification_prefs`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification preferences: %w", err)
+	}
+	defer rows.Close()
+
+	prefs := make(models.NotificationPrefs)
+	for rows.Next() {
+		var p models.ProjectNotificationPrefs
+		if err := rows.Scan(&p.ProjectID, &p.Muted, &p.Email, &p.Slack, &p.Inbox); err != nil {
+			return nil, fmt.Errorf("failed to scan notification preferences: %w", err)
+		}
+		prefs[p.ProjectID] = p
+	}
+	return prefs, rows.Err()
+}
+
+// ListRecentlyChanged retrieves the limit tasks and projects updated last,
+// most recent first, with what their last edit changed. Deleted tasks are
+// left out.
+func (s *SQLiteStore) ListRecentlyChanged(ctx context.Context, limit int) ([]models.RecentItem, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.id, t.description, t.project_id, p.name, t.status, t.completed,
+		       COALESCE(e.fields, ''), t.created_at, t.updated_at
+		FROM tasks t
+		JOIN projects p ON p.id = t.project_id
+		LEFT JOIN recent_edits e ON e.entity = 'task' AND e.entity_id = t.id
+		WHERE t.deleted_at IS NULL
+		ORDER BY t.updated_at DESC, t.id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent tasks: %w", err)
+	}
+	items, err := scanRecentItems(rows, "task")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT p.id, p.name, 0, '', '', p.completed,
+		       COALESCE(e.fields, ''), p.created_at, p.updated_at
+		FROM projects p
+		LEFT JOIN recent_edits e ON e.entity = 'project' AND e.entity_id = p.id
+		ORDER BY p.updated_at DESC, p.id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent projects: %w", err)
+	}
+	projects, err := scanRecentItems(rows, "project")
+	if err != nil {
+		return nil, err
+	}
+
+	items = append(items, projects...)
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].UpdatedAt.After(items[j].UpdatedAt)
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+func scanRecentItems(rows *sql.Rows, kind string) ([]models.RecentItem, error) {
+	defer rows.Close()
+
+	var items []models.RecentItem
+	for rows.Next() {
+		item := models.RecentItem{Kind: kind, Changed: []string{}}
+		var fields string
+		if err := rows.Scan(&item.ID, &item.Name, &item.ProjectID, &item.ProjectName, &item.Status,
+			&item.Completed, &fields, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan recent %s: %w", kind, err)
+		}
+		if fields != "" {
+			item.Changed = strings.Split(fields, ",")
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// CreateWebhookDelivery stores a delivery before its first attempt.
+// CreatedAt defaults to now.
+func (s *SQLiteStore) CreateWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = time.Now()
+	}
+	if d.Status == "" {
+		d.Status = models.WebhookPending
+	}
+	d.UpdatedAt = d.CreatedAt
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (target, event, url, payload, status, next_attempt_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.Target, d.Event, d.URL, d.Payload, d.Status, d.NextAttemptAt, d.CreatedAt, d.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+	if d.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return nil
+}
+
+const webhookDeliveryColumns = `id, target, event, url, payload, status, next_attempt_at, created_at, updated_at`
+
+// GetWebhookDelivery retrieves a delivery with its attempts.
+func (s *SQLiteStore) GetWebhookDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	deliveries, err := s.queryWebhookDeliveries(ctx, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, fmt.Errorf("webhook delivery %d: %w", id, ErrNotFound)
+	}
+	return &deliveries[0], nil
+}
+
+// ListWebhookDeliveries retrieves the newest limit deliveries with their
+// attempts, newest first.
+func (s *SQLiteStore) ListWebhookDeliveries(ctx context.Context, limit int) ([]models.WebhookDelivery, error) {
+	return s.queryWebhookDeliveries(ctx, `
+		SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries ORDER BY created_at DESC, id DESC LIMIT ?
+	`, limit)
+}
+
+// ListDueWebhookDeliveries retrieves the pending deliveries whose next
+// attempt is due at now, with their attempts, oldest first.
+func (s *SQLiteStore) ListDueWebhookDeliveries(ctx context.Context, now time.Time) ([]models.WebhookDelivery, error) {
+	return s.queryWebhookDeliveries(ctx, `
+		SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE status = 'pending' AND (next_attempt_at IS NULL OR next_attempt_at <= ?)
+		ORDER BY id
+	`, now)
+}
+
+func (s *SQLiteStore) queryWebhookDeliveries(ctx context.Context, query string, args ...interface{}) ([]models.WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	index := map[int64]int{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var next sql.NullTime
+		if err := rows.Scan(&d.ID, &d.Target, &d.Event, &d.URL, &d.Payload, &d.Status, &next, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		if next.Valid {
+			d.NextAttemptAt = &next.Time
+		}
+		index[d.ID] = len(deliveries)
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return deliveries, nil
+	}
+
+	ids := make([]interface{}, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.ID)
+	}
+	attempts, err := s.db.QueryContext(ctx, `
+		SELECT id, delivery_id, attempted_at, status_code, latency_ms, response, error
+		FROM webhook_attempts WHERE delivery_id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+		ORDER BY id
+	`, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook attempts: %w", err)
+	}
+	defer attempts.Close()
+	for attempts.Next() {
+		var a models.WebhookAttempt
+		var latency int64
+		if err := attempts.Scan(&a.ID, &a.DeliveryID, &a.At, &a.StatusCode, &latency, &a.Response, &a.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook attempt: %w", err)
+		}
+		a.Latency = time.Duration(latency) * time.Millisecond
+		d := &deliveries[index[a.DeliveryID]]
+		d.Attempts = append(d.Attempts, a)
+	}
+	return deliveries, attempts.Err()
+}
+
+// RecordWebhookAttempt adds attempt to d's attempts and saves d's status and
+// next attempt time with it.
+func (s *SQLiteStore) RecordWebhookAttempt(ctx context.Context, d *models.WebhookDelivery, attempt *models.WebhookAttempt) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	attempt.DeliveryID = d.ID
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO webhook_attempts (delivery_id, attempted_at, status_code, latency_ms, response, error)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, d.ID, attempt.At, attempt.StatusCode, attempt.Latency.Milliseconds(), attempt.Response, attempt.Error)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+	if attempt.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	d.UpdatedAt = attempt.At
+	result, err = tx.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET status = ?, next_attempt_at = ?, updated_at = ? WHERE id = ?
+	`, d.Status, d.NextAttemptAt, d.UpdatedAt, d.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	if err := expectOneRow(result, "webhook delivery", d.ID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit webhook attempt: %w", err)
+	}
+	d.Attempts = append(d.Attempts, *attempt)
+	return nil
+}
+
+// DeleteWebhookDeliveriesBefore forgets the deliveries created before
+// before that are no longer pending, with their attempts, and returns how
+// many there were.
+func (s *SQLiteStore) DeleteWebhookDeliveriesBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM webhook_deliveries WHERE status != 'pending' AND created_at < ?
+	`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// expectOneRow returns ErrNotFound when result changed no rows, meaning the
+// kind of record with id didn't exist.
+func expectOneRow(result sql.Result, kind string, id int64) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to count changed rows: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s %d: %w", kind, id, ErrNotFound)
+	}
+	return nil
+}
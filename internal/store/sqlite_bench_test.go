@@ -0,0 +1,217 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"mytasks/internal/models"
+)
+
+// The benchmarks share one generated database, made on first use: 50
+// projects of 1,000 tasks each, most of them done over the past three
+// years, the way a long-used install looks.
+const (
+	benchProjects        = 50
+	benchTasksPerProject = 1000
+)
+
+var (
+	benchOnce  sync.Once
+	benchDir   string
+	benchStore *SQLiteStore
+	benchErr   error
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if benchStore != nil {
+		benchStore.Close()
+	}
+	if benchDir != "" {
+		os.RemoveAll(benchDir)
+	}
+	os.Exit(code)
+}
+
+func setupBenchStore(b *testing.B) *SQLiteStore {
+	b.Helper()
+	benchOnce.Do(func() {
+		if benchDir, benchErr = os.MkdirTemp("", "mytasks-bench-"); benchErr != nil {
+			return
+		}
+		if benchStore, benchErr = NewSQLiteStore(filepath.Join(benchDir, "bench.db")); benchErr != nil {
+			return
+		}
+		benchErr = generateBenchData(context.Background(), benchStore)
+	})
+	if benchErr != nil {
+		b.Fatalf("failed to set up benchmark data: %v", benchErr)
+	}
+	return benchStore
+}
+
+func generateBenchData(ctx context.Context, s *SQLiteStore) error {
+	today := time.Now().Truncate(24 * time.Hour)
+	priorities := []string{"high", "medium", "low"}
+	for p := 0; p < benchProjects; p++ {
+		project := &models.Project{Name: fmt.Sprintf("Project %d", p), Type: "project"}
+		if err := s.CreateProject(ctx, project); err != nil {
+			return err
+		}
+		tasks := make([]*models.Task, benchTasksPerProject)
+		for i := range tasks {
+			task := &models.Task{
+				ProjectID:   project.ID,
+				Description: fmt.Sprintf("Task %d of project %d", i, p),
+				Priority:    priorities[i%3],
+				Status:      "done",
+				SortOrder:   i + 1,
+			}
+			switch {
+			case i%20 < 3:
+				task.Status = "in_progress"
+			case i%20 < 8:
+				task.Status = "todo"
+			default:
+				completed := today.AddDate(0, 0, -(i*7)%1095)
+				task.CompletedAt = &completed
+			}
+			if i%5 < 2 {
+				due := today.AddDate(0, 0, i%60-20)
+				task.DueDate = &due
+			}
+			if i%5 == 0 {
+				task.Tags = []string{"errand", fmt.Sprintf("tag%d", i%7)}
+			}
+			if i%3 == 0 {
+				task.Notes = "Some notes about the task"
+			}
+			tasks[i] = task
+		}
+		if err := s.CreateTasks(ctx, tasks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func BenchmarkListActiveProjects(b *testing.B) {
+	s := setupBenchStore(b)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.ListActiveProjects(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkKanbanBoard makes the store calls behind a project's board,
+// which is also where the home page lands.
+func BenchmarkKanbanBoard(b *testing.B) {
+	s := setupBenchStore(b)
+	ctx := context.Background()
+	projects, _ := s.ListActiveProjects(ctx)
+	id := projects[0].ID
+	since := time.Now().AddDate(0, 0, -7)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.ListActiveProjects(ctx); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := s.ProjectTaskCounts(ctx, time.Now()); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := s.GetProject(ctx, id); err != nil {
+			b.Fatal(err)
+		}
+		for _, status := range []string{"todo", "in_progress"} {
+			if _, err := s.ListProjectTasks(ctx, TaskFilter{ProjectID: id, Status: status}); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if _, err := s.ListProjectTasks(ctx, TaskFilter{ProjectID: id, Status: "done", CompletedSince: &since}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkListTasksByProject(b *testing.B) {
+	s := setupBenchStore(b)
+	ctx := context.Background()
+	projects, _ := s.ListActiveProjects(ctx)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.ListTasksByProject(ctx, projects[i%len(projects)].ID, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkListOpenTasks(b *testing.B) {
+	s := setupBenchStore(b)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.ListOpenTasks(ctx, TaskFilter{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkListTasks(b *testing.B) {
+	s := setupBenchStore(b)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.ListTasks(ctx, nil, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkReorderTasks drags one task to the top of a 1,000-task project.
+func BenchmarkReorderTasks(b *testing.B) {
+	s := setupBenchStore(b)
+	ctx := context.Background()
+	projects, _ := s.ListActiveProjects(ctx)
+	id := projects[1].ID
+	tasks, err := s.ListTasksByProject(ctx, id, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ids := make([]int64, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ids[0], ids[len(ids)-1] = ids[len(ids)-1], ids[0]
+		if err := s.ReorderTasks(ctx, id, ids); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProjectTaskCounts(b *testing.B) {
+	s := setupBenchStore(b)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.ProjectTaskCounts(ctx, time.Now()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -3,12 +3,17 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"mytasks/internal/models"
+	"mytasks/internal/taskql"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -89,6 +94,9 @@ func TestGetProject_NotFound(t *testing.T) {
 	if err == nil {
 		t.Error("expected error for non-existent project")
 	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
 }
 
 func TestListProjects_OrderedBySortOrder(t *testing.T) {
@@ -486,7 +494,7 @@ func TestListTasksByProjectCompletedBetween(t *testing.T) {
 	from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
 	to := time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC)
 
-	tasks, err := store.ListTasksByProjectCompletedBetween(ctx, project.ID, &from, &to, 0)
+	tasks, err := store.ListTasksByProjectCompletedBetween(ctx, project.ID, &from, &to, 0, 0)
 	if err != nil {
 		t.Fatalf("ListTasksByProjectCompletedBetween failed: %v", err)
 	}
@@ -502,6 +510,206 @@ func TestListTasksByProjectCompletedBetween(t *testing.T) {
 	}
 }
 
+func TestListTasksByProjectCompletedBetween_Paginates(t *testing.T) {
+	s := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Test", Type: "project"}
+	if err := s.CreateProject(ctx, project); err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+
+	dates := []string{"2025-03-01", "2025-03-03", "2025-03-02", ""}
+	for i, date := range dates {
+		task := &models.Task{ProjectID: project.ID, Description: fmt.Sprintf("Task %d", i), Priority: "medium", Status: "todo"}
+		if err := s.CreateTask(ctx, task); err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		if err := s.MoveTaskToStatus(ctx, task.ID, "done", i); err != nil {
+			t.Fatalf("MoveTaskToStatus: %v", err)
+		}
+		if date == "" {
+			// Tasks finished before completed_at existed fall back to updated_at.
+			if _, err := s.db.ExecContext(ctx, `UPDATE tasks SET completed_at = NULL, updated_at = ? WHERE id = ?`, "2025-03-04 09:00:00", task.ID); err != nil {
+				t.Fatalf("clear completed_at: %v", err)
+			}
+			continue
+		}
+		if _, err := s.db.ExecContext(ctx, `UPDATE tasks SET completed_at = ? WHERE id = ?`, date, task.ID); err != nil {
+			t.Fatalf("set completed_at: %v", err)
+		}
+	}
+
+	to := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	count, err := s.CountTasksByProjectCompletedBetween(ctx, project.ID, nil, &to)
+	if err != nil {
+		t.Fatalf("CountTasksByProjectCompletedBetween: %v", err)
+	}
+	if count != 4 {
+		t.Fatalf("expected 4 completed tasks, got %d", count)
+	}
+
+	tests := []struct {
+		name   string
+		limit  int
+		offset int
+		want   []string
+	}{
+		{"first page", 2, 0, []string{"Task 3", "Task 1"}},
+		{"second page", 2, 2, []string{"Task 2", "Task 0"}},
+		{"past the end", 2, 4, nil},
+		{"offset without limit", 0, 3, []string{"Task 0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := s.ListTasksByProjectCompletedBetween(ctx, project.ID, nil, &to, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatalf("ListTasksByProjectCompletedBetween: %v", err)
+			}
+			var got []string
+			for _, task := range tasks {
+				got = append(got, task.Description)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestListTasksPage(t *testing.T) {
+	s := setupTestDB(t)
+	ctx := context.Background()
+
+	p := &models.Project{Name: "P", Type: "project"}
+	if err := s.CreateProject(ctx, p); err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+
+	// Sort orders with a tie, and completion dates with one.
+	sortOrders := []int{2, 1, 1, 0}
+	completed := []string{"2025-03-02", "", "2025-03-03", "2025-03-02"}
+	var tasks []*models.Task
+	for i := range sortOrders {
+		task := &models.Task{ProjectID: p.ID, Description: fmt.Sprintf("Task %d", i), Priority: "medium", Status: "todo"}
+		if err := s.CreateTask(ctx, task); err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		if _, err := s.db.ExecContext(ctx, `UPDATE tasks SET sort_order = ? WHERE id = ?`, sortOrders[i], task.ID); err != nil {
+			t.Fatalf("set sort_order: %v", err)
+		}
+		if completed[i] != "" {
+			if _, err := s.db.ExecContext(ctx, `UPDATE tasks SET status = 'done', completed = 1, completed_at = ? WHERE id = ?`, completed[i], task.ID); err != nil {
+				t.Fatalf("set completed_at: %v", err)
+			}
+		}
+		tasks = append(tasks, task)
+	}
+
+	walk := func(completedSince *time.Time) []string {
+		t.Helper()
+		var got []string
+		var after *Keyset
+		for pages := 0; ; pages++ {
+			if pages > len(tasks) {
+				t.Fatalf("expected to run out of pages, got %v", got)
+			}
+			page, next, err := s.ListTasksPage(ctx, completedSince, nil, after, 2)
+			if err != nil {
+				t.Fatalf("ListTasksPage: %v", err)
+			}
+			for _, task := range page {
+				got = append(got, task.Description)
+			}
+			if next == nil {
+				return got
+			}
+			after = next
+		}
+	}
+
+	if got, want := walk(nil), []string{"Task 3", "Task 1", "Task 2", "Task 0"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v by sort order, got %v", want, got)
+	}
+	since := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	if got, want := walk(&since), []string{"Task 2", "Task 3", "Task 0"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v by completion, got %v", want, got)
+	}
+
+	// A keyset from one order doesn't fit the other.
+	if _, _, err := s.ListTasksPage(ctx, nil, nil, &Keyset{Key: "2025-03-02", ID: tasks[0].ID}, 2); !errors.Is(err, ErrInvalidKeyset) {
+		t.Errorf("expected ErrInvalidKeyset for a date sort order, got %v", err)
+	}
+	if _, _, err := s.ListTasksPage(ctx, &since, nil, &Keyset{Key: "1", ID: tasks[0].ID}, 2); !errors.Is(err, ErrInvalidKeyset) {
+		t.Errorf("expected ErrInvalidKeyset for a numeric completion date, got %v", err)
+	}
+}
+
+func TestListTasks_Match(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	work := &models.Project{Name: "Work", Type: "project"}
+	store.CreateProject(ctx, work)
+	home := &models.Project{Name: "Home", Type: "project"}
+	store.CreateProject(ctx, home)
+
+	july := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	august := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	store.CreateTask(ctx, &models.Task{ProjectID: work.ID, Description: "Report", Priority: "high", Status: "todo", DueDate: &july, Tags: []string{"q3"}})
+	store.CreateTask(ctx, &models.Task{ProjectID: work.ID, Description: "Deploy", Priority: "medium", Status: "in_progress", DueDate: &august})
+	store.CreateTask(ctx, &models.Task{ProjectID: work.ID, Description: "Hire", Priority: "high", Status: "done", DueDate: &july})
+	store.CreateTask(ctx, &models.Task{ProjectID: home.ID, Description: "Laundry", Priority: "low", Status: "todo", Tags: []string{"errands", "q3"}})
+	store.CreateTask(ctx, &models.Task{ProjectID: home.ID, Description: "Paint fence", Notes: "50% done", Priority: "medium", Status: "todo"})
+
+	tests := []struct {
+		filter string
+		want   string
+	}{
+		{"completed=false AND priority IN (high,medium) AND due<=2025-07-01", "Report"},
+		{"completed=false AND priority IN (high,medium) AND due<=2025-08-01", "Deploy,Report"},
+		{"due = none", "Laundry,Paint fence"},
+		{"project = home", "Laundry,Paint fence"},
+		{"project != Home AND NOT completed = true", "Deploy,Report"},
+		{"tag = Q3", "Laundry,Report"},
+		{"tag = q3 AND tag != errands", "Report"},
+		{"notes ~ '50%'", "Paint fence"},
+		{"priority = low OR status = in_progress", "Laundry,Deploy"},
+		{`description = "Robert'); DROP TABLE tasks; --"`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			match, err := taskql.Parse(tt.filter, time.Now())
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			tasks, err := store.ListTasks(ctx, nil, match)
+			if err != nil {
+				t.Fatalf("ListTasks failed: %v", err)
+			}
+			got := make([]string, len(tasks))
+			for i, task := range tasks {
+				got[i] = task.Description
+			}
+			sort.Strings(got)
+			want := strings.Split(tt.want, ",")
+			sort.Strings(want)
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+		})
+	}
+
+	match, _ := taskql.Parse("priority = high", time.Now())
+	open, err := store.ListOpenTasks(ctx, TaskFilter{Match: match})
+	if err != nil {
+		t.Fatalf("ListOpenTasks failed: %v", err)
+	}
+	if len(open) != 1 || open[0].Description != "Report" {
+		t.Errorf("expected ListOpenTasks to match only Report, got %+v", open)
+	}
+}
+
 func TestListTasks(t *testing.T) {
 	s := setupTestDB(t)
 	ctx := context.Background()
@@ -533,7 +741,7 @@ func TestListTasks(t *testing.T) {
 		t.Fatalf("set old completed_at: %v", err)
 	}
 
-	allTasks, err := s.ListTasks(ctx, nil)
+	allTasks, err := s.ListTasks(ctx, nil, nil)
 	if err != nil {
 		t.Fatalf("ListTasks all: %v", err)
 	}
@@ -542,7 +750,7 @@ func TestListTasks(t *testing.T) {
 	}
 
 	since := time.Now().AddDate(0, 0, -7)
-	recentTasks, err := s.ListTasks(ctx, &since)
+	recentTasks, err := s.ListTasks(ctx, &since, nil)
 	if err != nil {
 		t.Fatalf("ListTasks filtered: %v", err)
 	}
@@ -583,6 +791,220 @@ func TestReorderTasks(t *testing.T) {
 	}
 }
 
+func TestMoveTaskToProject(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	from := &models.Project{Name: "From", Type: "project"}
+	to := &models.Project{Name: "To", Type: "project"}
+	store.CreateProject(ctx, from)
+	store.CreateProject(ctx, to)
+
+	var moving *models.Task
+	for _, desc := range []string{"A", "B", "C"} {
+		task := &models.Task{ProjectID: from.ID, Description: desc, Priority: "medium"}
+		store.CreateTask(ctx, task)
+		if desc == "B" {
+			moving = task
+		}
+	}
+	for _, desc := range []string{"X", "Y"} {
+		store.CreateTask(ctx, &models.Task{ProjectID: to.ID, Description: desc, Priority: "medium"})
+	}
+
+	if err := store.MoveTaskToProject(ctx, moving.ID, to.ID); err != nil {
+		t.Fatalf("MoveTaskToProject failed: %v", err)
+	}
+
+	check := func(projectID int64, want []string) {
+		t.Helper()
+		tasks, _ := store.ListTasksByProject(ctx, projectID, 0)
+		if len(tasks) != len(want) {
+			t.Fatalf("project %d: expected %d tasks, got %d", projectID, len(want), len(tasks))
+		}
+		for i, task := range tasks {
+			if task.Description != want[i] || task.SortOrder != i+1 {
+				t.Errorf("project %d position %d: expected %q at %d, got %q at %d",
+					projectID, i, want[i], i+1, task.Description, task.SortOrder)
+			}
+		}
+	}
+	check(from.ID, []string{"A", "C"})
+	check(to.ID, []string{"X", "Y", "B"})
+
+	history, _ := store.ListTaskHistory(ctx, moving.ID)
+	if len(history) != 1 || history[0].Field != "project_id" {
+		t.Errorf("expected the move in the task's history, got %+v", history)
+	}
+
+	// Moving to the project it's already in changes nothing.
+	if err := store.MoveTaskToProject(ctx, moving.ID, to.ID); err != nil {
+		t.Fatalf("MoveTaskToProject to same project failed: %v", err)
+	}
+	check(to.ID, []string{"X", "Y", "B"})
+
+	if err := store.MoveTaskToProject(ctx, 99999, to.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing task, got %v", err)
+	}
+}
+
+func TestSoftDeleteTask(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Test", Type: "project"}
+	store.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Oops", Priority: "medium", Status: "todo"}
+	store.CreateTask(ctx, task)
+	store.CreateTaskComment(ctx, &models.TaskComment{TaskID: task.ID, Body: "keep me"})
+
+	deletedAt := time.Now()
+	if err := store.SoftDeleteTask(ctx, task.ID, "tok", deletedAt); err != nil {
+		t.Fatalf("SoftDeleteTask failed: %v", err)
+	}
+	if _, err := store.GetTask(ctx, task.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a deleted task to be hidden, got %v", err)
+	}
+	if tasks, _ := store.ListTasksByProject(ctx, project.ID, 0); len(tasks) != 0 {
+		t.Errorf("expected no tasks listed, got %d", len(tasks))
+	}
+	if counts, _ := store.ProjectTaskCounts(ctx, time.Now()); counts[project.ID].Open != 0 {
+		t.Errorf("expected a deleted task not to be counted, got %+v", counts[project.ID])
+	}
+	if err := store.SoftDeleteTask(ctx, task.ID, "tok2", deletedAt); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	if _, err := store.RestoreTask(ctx, "wrong", deletedAt); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown token, got %v", err)
+	}
+	if _, err := store.RestoreTask(ctx, "tok", deletedAt.Add(time.Second)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound once the undo window has passed, got %v", err)
+	}
+	id, err := store.RestoreTask(ctx, "tok", deletedAt)
+	if err != nil || id != task.ID {
+		t.Fatalf("RestoreTask = %d, %v; want %d", id, err, task.ID)
+	}
+	if comments, _ := store.ListTaskComments(ctx, task.ID); len(comments) != 1 {
+		t.Errorf("expected the restored task to keep its comments, got %d", len(comments))
+	}
+
+	store.SoftDeleteTask(ctx, task.ID, "tok", deletedAt)
+	if n, err := store.PurgeDeletedTasks(ctx, deletedAt); err != nil || n != 0 {
+		t.Errorf("expected nothing purged before the task was deleted, got %d, %v", n, err)
+	}
+	if n, err := store.PurgeDeletedTasks(ctx, deletedAt.Add(time.Second)); err != nil || n != 1 {
+		t.Errorf("expected 1 task purged, got %d, %v", n, err)
+	}
+	if _, err := store.RestoreTask(ctx, "tok", deletedAt); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a purged task to be gone for good, got %v", err)
+	}
+}
+
+func TestProjectSortModes(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Test", Type: "project"}
+	store.CreateProject(ctx, project)
+	if got, _ := store.GetProject(ctx, project.ID); got.SortMode != models.SortManual {
+		t.Fatalf("expected new projects to sort manually, got %q", got.SortMode)
+	}
+
+	soon := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	later := soon.AddDate(0, 0, 7)
+	for _, task := range []*models.Task{
+		{Description: "A", Priority: "low", DueDate: &later},
+		{Description: "B", Priority: "high"},
+		{Description: "C", Priority: "medium", DueDate: &soon},
+	} {
+		task.ProjectID = project.ID
+		store.CreateTask(ctx, task)
+	}
+	store.DB().Exec(`UPDATE tasks SET created_at = datetime('2024-01-01', '-' || sort_order || ' days')`)
+
+	order := func() string {
+		tasks, err := store.ListTasksByProject(ctx, project.ID, 0)
+		if err != nil {
+			t.Fatalf("ListTasksByProject: %v", err)
+		}
+		var got string
+		for _, task := range tasks {
+			got += task.Description
+		}
+		return got
+	}
+
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{models.SortManual, "ABC"},
+		{models.SortPriority, "BCA"},
+		{models.SortDueDate, "CAB"},
+		{models.SortCreated, "CBA"},
+	}
+	for _, tt := range tests {
+		if err := store.SetProjectSortMode(ctx, project.ID, tt.mode); err != nil {
+			t.Fatalf("SetProjectSortMode(%q): %v", tt.mode, err)
+		}
+		if got := order(); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.mode, tt.want, got)
+		}
+	}
+	if err := store.SetProjectSortMode(ctx, project.ID, "random"); err == nil {
+		t.Error("expected an error for an unknown sort mode")
+	}
+
+	// Dragging a task switches back to manual, keeping the automatic order
+	// of the tasks that were not moved.
+	store.SetProjectSortMode(ctx, project.ID, models.SortPriority)
+	tasks, _ := store.ListTasksByProject(ctx, project.ID, 0)
+	if err := store.ReorderTasksInStatus(ctx, project.ID, "todo", []int64{tasks[1].ID, tasks[0].ID}); err != nil {
+		t.Fatalf("ReorderTasksInStatus: %v", err)
+	}
+	if got, _ := store.GetProject(ctx, project.ID); got.SortMode != models.SortManual {
+		t.Errorf("expected a manual reorder to switch to manual, got %q", got.SortMode)
+	}
+	if got := order(); got != "CBA" {
+		t.Errorf("expected CBA after the reorder, got %s", got)
+	}
+}
+
+func TestTaskUntouchedDays(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Test", Type: "project"}
+	store.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Old", Priority: "low", Status: "todo"}
+	store.CreateTask(ctx, task)
+	store.DB().Exec(`UPDATE tasks SET updated_at = ? WHERE id = ?`, time.Now().AddDate(0, 0, -12), task.ID)
+
+	got, err := store.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.Untouched != 12 {
+		t.Errorf("expected 12 days untouched, got %d", got.Untouched)
+	}
+	if !got.IsStale(11) || got.IsStale(12) {
+		t.Errorf("expected the task to be stale after more than 11 days only")
+	}
+
+	open, _ := store.ListOpenTasks(ctx, TaskFilter{})
+	if len(open) != 1 || open[0].Untouched != 12 {
+		t.Errorf("expected list queries to fill in the age, got %+v", open)
+	}
+
+	if err := store.UpdateTask(ctx, got); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if got, _ := store.GetTask(ctx, task.ID); got.Untouched != 0 {
+		t.Errorf("expected an update to reset the age, got %d", got.Untouched)
+	}
+}
+
 func TestMarkProjectComplete(t *testing.T) {
 	store := setupTestDB(t)
 	ctx := context.Background()
@@ -608,6 +1030,45 @@ func TestMarkProjectComplete(t *testing.T) {
 	}
 }
 
+func TestListProjectsFiltered(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	active := &models.Project{Name: "Active", Type: "project"}
+	older := &models.Project{Name: "Older", Type: "project"}
+	newer := &models.Project{Name: "Newer", Type: "project"}
+	for _, p := range []*models.Project{active, older, newer} {
+		store.CreateProject(ctx, p)
+	}
+	store.CreateTask(ctx, &models.Task{ProjectID: newer.ID, Description: "Done", Priority: "medium", Status: "done"})
+	store.CreateTask(ctx, &models.Task{ProjectID: newer.ID, Description: "Done too", Priority: "medium", Status: "done"})
+	store.CreateTask(ctx, &models.Task{ProjectID: newer.ID, Description: "Left over", Priority: "medium", Status: "todo"})
+	deleted := &models.Task{ProjectID: newer.ID, Description: "Deleted", Priority: "medium", Status: "done"}
+	store.CreateTask(ctx, deleted)
+	store.SoftDeleteTask(ctx, deleted.ID, "tok", time.Now())
+	store.MarkProjectComplete(ctx, older.ID)
+	store.MarkProjectComplete(ctx, newer.ID)
+	store.DB().ExecContext(ctx, `UPDATE projects SET completed_at = '2026-01-01 00:00:00' WHERE id = ?`, older.ID)
+
+	completed, err := store.ListProjectsFiltered(ctx, true, 0)
+	if err != nil {
+		t.Fatalf("ListProjectsFiltered failed: %v", err)
+	}
+	if len(completed) != 2 || completed[0].ID != newer.ID || completed[1].ID != older.ID {
+		t.Fatalf("expected Newer then Older, got %+v", completed)
+	}
+	if got := completed[0].Counts; got.Done != 2 || got.Open != 1 {
+		t.Errorf("expected 2 done and 1 open, got %+v", got)
+	}
+
+	if limited, _ := store.ListProjectsFiltered(ctx, true, 1); len(limited) != 1 {
+		t.Errorf("expected the limit to apply, got %d projects", len(limited))
+	}
+	if open, _ := store.ListProjectsFiltered(ctx, false, 0); len(open) != 1 || open[0].ID != active.ID {
+		t.Errorf("expected only the active project, got %+v", open)
+	}
+}
+
 func TestMarkProjectIncomplete(t *testing.T) {
 	store := setupTestDB(t)
 	ctx := context.Background()
@@ -637,6 +1098,59 @@ func TestMarkProjectIncomplete(t *testing.T) {
 	}
 }
 
+func TestResetChecklist(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Morning", Type: "project"}
+	store.CreateProject(ctx, project)
+	if project.ChecklistResetAt != nil {
+		t.Fatal("expected an ordinary project to have no reset time")
+	}
+	project.ChecklistReset = models.ChecklistWeekly
+	project.ChecklistHour = 7
+	if err := store.UpdateProject(ctx, project); err != nil {
+		t.Fatalf("UpdateProject failed: %v", err)
+	}
+	if project.ChecklistResetAt == nil {
+		t.Fatal("expected turning a project into a checklist to count as a reset")
+	}
+
+	done := &models.Task{ProjectID: project.ID, Description: "Stretch", Priority: "medium", Status: "todo"}
+	open := &models.Task{ProjectID: project.ID, Description: "Make bed", Priority: "medium", Status: "todo"}
+	store.CreateTask(ctx, done)
+	store.CreateTask(ctx, open)
+	store.ToggleTaskComplete(ctx, done.ID)
+
+	at := time.Now().Add(time.Hour).Truncate(time.Second)
+	n, err := store.ResetChecklist(ctx, project.ID, at)
+	if err != nil {
+		t.Fatalf("ResetChecklist failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 task reset, got %d", n)
+	}
+	task, _ := store.GetTask(ctx, done.ID)
+	if task.Status != "todo" || task.Completed || task.CompletedAt != nil {
+		t.Errorf("expected the task back in To Do, got status %q", task.Status)
+	}
+	if completions, _ := store.ListTaskCompletions(ctx, done.ID); len(completions) != 1 {
+		t.Errorf("expected the completion to be kept, got %d", len(completions))
+	}
+
+	got, _ := store.GetProject(ctx, project.ID)
+	if got.ChecklistReset != models.ChecklistWeekly || got.ChecklistHour != 7 {
+		t.Errorf("expected a weekly checklist at 7, got %q at %d", got.ChecklistReset, got.ChecklistHour)
+	}
+	if got.ChecklistResetAt == nil || !got.ChecklistResetAt.Equal(at) {
+		t.Errorf("expected reset time %v, got %v", at, got.ChecklistResetAt)
+	}
+
+	if _, err := store.ResetChecklist(ctx, 999, at); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
 func TestNewSQLiteStore_MigratesLegacyDatabaseAndPreservesData(t *testing.T) {
 	tempDir := t.TempDir()
 	dbPath := filepath.Join(tempDir, "legacy.db")
@@ -931,3 +1445,2230 @@ func TestUpdateTask_ChangesProjectID(t *testing.T) {
 		t.Errorf("expected task in p2, got %v", p2Tasks)
 	}
 }
+
+func TestRegisterSlackWorkspace_KeepsSettings(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	if err := store.RegisterSlackWorkspace(ctx, "T1", "acme"); err != nil {
+		t.Fatalf("RegisterSlackWorkspace failed: %v", err)
+	}
+
+	ws, err := store.GetSlackWorkspace(ctx, "T1")
+	if err != nil {
+		t.Fatalf("GetSlackWorkspace failed: %v", err)
+	}
+	ws.WebhookURL = "https://hooks.slack.com/services/x"
+	ws.NotifyOverdue = true
+	if err := store.UpdateSlackWorkspace(ctx, ws); err != nil {
+		t.Fatalf("UpdateSlackWorkspace failed: %v", err)
+	}
+
+	if err := store.RegisterSlackWorkspace(ctx, "T1", "acme-renamed"); err != nil {
+		t.Fatalf("RegisterSlackWorkspace failed: %v", err)
+	}
+
+	workspaces, err := store.ListSlackWorkspaces(ctx)
+	if err != nil {
+		t.Fatalf("ListSlackWorkspaces failed: %v", err)
+	}
+	if len(workspaces) != 1 {
+		t.Fatalf("expected 1 workspace, got %d", len(workspaces))
+	}
+	got := workspaces[0]
+	if got.TeamName != "acme-renamed" || !got.NotifyOverdue || got.WebhookURL == "" {
+		t.Errorf("unexpected workspace after re-register: %+v", got)
+	}
+}
+
+func TestGetSlackWorkspace_NotFound(t *testing.T) {
+	store := setupTestDB(t)
+
+	_, err := store.GetSlackWorkspace(context.Background(), "missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestOverdueNotifications_ThrottledPerDueDate(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Test", Type: "project"}
+	store.CreateProject(ctx, project)
+
+	today := time.Now()
+	yesterday := today.AddDate(0, 0, -1)
+	tomorrow := today.AddDate(0, 0, 1)
+	overdue := &models.Task{ProjectID: project.ID, Description: "Overdue", Priority: "medium", Status: "todo", DueDate: &yesterday}
+	store.CreateTask(ctx, overdue)
+	store.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Due today", Priority: "medium", Status: "todo", DueDate: &today})
+	store.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Future", Priority: "medium", Status: "todo", DueDate: &tomorrow})
+	store.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Done", Priority: "medium", Status: "done", DueDate: &yesterday})
+
+	tasks, err := store.ListOverdueTasksPendingNotification(ctx, "slack:T1", today)
+	if err != nil {
+		t.Fatalf("ListOverdueTasksPendingNotification failed: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != overdue.ID {
+		t.Fatalf("expected only the overdue task, got %+v", tasks)
+	}
+	if tasks[0].ProjectName != "Test" {
+		t.Errorf("expected project name to be populated, got %q", tasks[0].ProjectName)
+	}
+
+	if err := store.MarkOverdueNotified(ctx, overdue.ID, "slack:T1", yesterday); err != nil {
+		t.Fatalf("MarkOverdueNotified failed: %v", err)
+	}
+	if err := store.MarkOverdueNotified(ctx, overdue.ID, "slack:T1", yesterday); err != nil {
+		t.Fatalf("MarkOverdueNotified should be idempotent: %v", err)
+	}
+
+	tasks, _ = store.ListOverdueTasksPendingNotification(ctx, "slack:T1", today)
+	if len(tasks) != 0 {
+		t.Errorf("expected no pending tasks after notifying, got %d", len(tasks))
+	}
+
+	// Other channels are tracked independently.
+	tasks, _ = store.ListOverdueTasksPendingNotification(ctx, "slack:T2", today)
+	if len(tasks) != 1 {
+		t.Errorf("expected task to still be pending on another channel, got %d", len(tasks))
+	}
+
+	// Rescheduling to a new past date makes the task eligible again.
+	earlier := today.AddDate(0, 0, -3)
+	overdue.DueDate = &earlier
+	store.UpdateTask(ctx, overdue)
+	tasks, _ = store.ListOverdueTasksPendingNotification(ctx, "slack:T1", today)
+	if len(tasks) != 1 {
+		t.Errorf("expected rescheduled task to be pending again, got %d", len(tasks))
+	}
+}
+
+func TestTaskTags_SavedAndReplaced(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Test", Type: "project"}
+	store.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Tagged", Priority: "medium", Status: "todo", Tags: []string{"Home", "+errands", "home"}}
+	if err := store.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	got, _ := store.GetTask(ctx, task.ID)
+	if len(got.Tags) != 2 || got.Tags[0] != "errands" || got.Tags[1] != "home" {
+		t.Fatalf("expected normalized tags [errands home], got %v", got.Tags)
+	}
+
+	got.Tags = []string{"work"}
+	if err := store.UpdateTask(ctx, got); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+
+	all, err := store.ListTaskTags(ctx)
+	if err != nil {
+		t.Fatalf("ListTaskTags failed: %v", err)
+	}
+	if tags := all[task.ID]; len(tags) != 1 || tags[0] != "work" {
+		t.Errorf("expected tags to be replaced, got %v", tags)
+	}
+}
+
+func TestTaskExternalRefs(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Test", Type: "project"}
+	store.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Linked", Priority: "medium", Status: "todo"}
+	store.CreateTask(ctx, task)
+
+	if _, err := store.GetTaskIDByExternalRef(ctx, "taskwarrior", "abc"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound before linking, got %v", err)
+	}
+
+	if err := store.SetTaskExternalRef(ctx, "taskwarrior", "abc", task.ID); err != nil {
+		t.Fatalf("SetTaskExternalRef failed: %v", err)
+	}
+	// Relinking the task replaces its previous external ID.
+	if err := store.SetTaskExternalRef(ctx, "taskwarrior", "def", task.ID); err != nil {
+		t.Fatalf("SetTaskExternalRef failed: %v", err)
+	}
+
+	id, err := store.GetTaskIDByExternalRef(ctx, "taskwarrior", "def")
+	if err != nil || id != task.ID {
+		t.Errorf("expected task %d, got %d (%v)", task.ID, id, err)
+	}
+	refs, _ := store.ListTaskExternalRefs(ctx, "taskwarrior")
+	if len(refs) != 1 || refs[task.ID] != "def" {
+		t.Errorf("unexpected refs %v", refs)
+	}
+	if ref, err := store.GetTaskExternalRef(ctx, "taskwarrior", task.ID); err != nil || ref != "def" {
+		t.Errorf("expected ref def, got %q (%v)", ref, err)
+	}
+
+	store.DeleteTask(ctx, task.ID)
+	if _, err := store.GetTaskIDByExternalRef(ctx, "taskwarrior", "def"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ref to be removed with the task, got %v", err)
+	}
+}
+
+func TestGoogleTasksAccount_SaveGetDelete(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	if _, err := store.GetGoogleTasksAccount(ctx); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	project := &models.Project{Name: "Phone", Type: "project"}
+	store.CreateProject(ctx, project)
+	expiry := time.Now().Add(time.Hour)
+	account := &models.GoogleTasksAccount{RefreshToken: "r1", AccessToken: "a1", TokenExpiry: &expiry}
+	if err := store.SaveGoogleTasksAccount(ctx, account); err != nil {
+		t.Fatalf("SaveGoogleTasksAccount failed: %v", err)
+	}
+
+	account.ProjectID = &project.ID
+	if err := store.SaveGoogleTasksAccount(ctx, account); err != nil {
+		t.Fatalf("SaveGoogleTasksAccount failed: %v", err)
+	}
+
+	got, err := store.GetGoogleTasksAccount(ctx)
+	if err != nil {
+		t.Fatalf("GetGoogleTasksAccount failed: %v", err)
+	}
+	if got.RefreshToken != "r1" || got.TargetProjectID() != project.ID || got.TokenExpiry == nil {
+		t.Errorf("unexpected account %+v", got)
+	}
+
+	// Deleting the target project pauses sync rather than removing the account.
+	store.DeleteProject(ctx, project.ID)
+	got, _ = store.GetGoogleTasksAccount(ctx)
+	if got == nil || got.ProjectID != nil {
+		t.Errorf("expected project to be cleared, got %+v", got)
+	}
+
+	if err := store.DeleteGoogleTasksAccount(ctx); err != nil {
+		t.Fatalf("DeleteGoogleTasksAccount failed: %v", err)
+	}
+	if _, err := store.GetGoogleTasksAccount(ctx); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestGoogleCalendar_AccountAndEvents(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	if _, err := store.GetGoogleCalendarAccount(ctx); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.SaveGoogleCalendarAccount(ctx, &models.GoogleCalendarAccount{RefreshToken: "r1"}); err != nil {
+		t.Fatalf("SaveGoogleCalendarAccount failed: %v", err)
+	}
+	account, err := store.GetGoogleCalendarAccount(ctx)
+	if err != nil || account.RefreshToken != "r1" || account.CalendarID != "primary" {
+		t.Fatalf("unexpected account %+v (%v)", account, err)
+	}
+
+	due := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	conflictAt := time.Now()
+	for _, e := range []*models.CalendarEvent{
+		{TaskID: 1, EventID: "ev1", ETag: `"1"`, Title: "Pay rent", DueDate: due},
+		{TaskID: 2, EventID: "ev2", ETag: `"2"`, Title: "Dentist", DueDate: due, Done: true, Conflict: "kept Mon, Mar 2.", ConflictAt: &conflictAt},
+	} {
+		if err := store.SaveCalendarEvent(ctx, e); err != nil {
+			t.Fatalf("SaveCalendarEvent failed: %v", err)
+		}
+	}
+	// Saving again replaces the link of the task.
+	if err := store.SaveCalendarEvent(ctx, &models.CalendarEvent{TaskID: 1, EventID: "ev1", ETag: `"3"`, Title: "Pay rent", DueDate: due.AddDate(0, 0, 1)}); err != nil {
+		t.Fatalf("SaveCalendarEvent failed: %v", err)
+	}
+
+	events, err := store.ListCalendarEvents(ctx)
+	if err != nil {
+		t.Fatalf("ListCalendarEvents failed: %v", err)
+	}
+	if len(events) != 2 || events[0].TaskID != 2 || !events[0].Done || events[0].ConflictAt == nil {
+		t.Fatalf("expected the conflict first, got %+v", events)
+	}
+	if e := events[1]; e.ETag != `"3"` || e.DueDate.Format("2006-01-02") != "2026-03-02" || e.SyncedAt.IsZero() {
+		t.Errorf("unexpected link %+v", e)
+	}
+
+	if err := store.DeleteCalendarEvent(ctx, 1); err != nil {
+		t.Fatalf("DeleteCalendarEvent failed: %v", err)
+	}
+	if err := store.DeleteCalendarEvent(ctx, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	// Disconnecting forgets the links too.
+	if err := store.DeleteGoogleCalendarAccount(ctx); err != nil {
+		t.Fatalf("DeleteGoogleCalendarAccount failed: %v", err)
+	}
+	if events, _ := store.ListCalendarEvents(ctx); len(events) != 0 {
+		t.Errorf("expected no links after disconnecting, got %+v", events)
+	}
+}
+
+func TestGitHubLinks(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "App", Type: "project"}
+	store.CreateProject(ctx, project)
+
+	link := &models.GitHubLink{ProjectID: project.ID, Repo: "Octo/App", Token: "t1", Login: "me", WebhookSecret: "s"}
+	if err := store.SaveGitHubLink(ctx, link); err != nil {
+		t.Fatalf("SaveGitHubLink failed: %v", err)
+	}
+	link.Token = "t2"
+	if err := store.SaveGitHubLink(ctx, link); err != nil {
+		t.Fatalf("SaveGitHubLink failed: %v", err)
+	}
+
+	got, err := store.GetGitHubLinkByRepo(ctx, "octo/app")
+	if err != nil {
+		t.Fatalf("GetGitHubLinkByRepo failed: %v", err)
+	}
+	if got.ProjectID != project.ID || got.Token != "t2" {
+		t.Errorf("unexpected link %+v", got)
+	}
+
+	links, _ := store.ListGitHubLinks(ctx)
+	if len(links) != 1 {
+		t.Errorf("expected 1 link, got %d", len(links))
+	}
+
+	if err := store.DeleteGitHubLink(ctx, project.ID); err != nil {
+		t.Fatalf("DeleteGitHubLink failed: %v", err)
+	}
+	if _, err := store.GetGitHubLink(ctx, project.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestListTasksDueBetween(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Test", Type: "project"}
+	store.CreateProject(ctx, project)
+	archived := &models.Project{Name: "Archived", Type: "project"}
+	store.CreateProject(ctx, archived)
+
+	date := func(day int) *time.Time {
+		d := time.Date(2025, time.March, day, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	store.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Before", Priority: "medium", Status: "todo", DueDate: date(2)})
+	store.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Low", Priority: "low", Status: "todo", DueDate: date(3)})
+	store.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "High", Priority: "high", Status: "todo", DueDate: date(3)})
+	store.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Done", Priority: "medium", Status: "done", DueDate: date(9)})
+	store.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "After", Priority: "medium", Status: "todo", DueDate: date(10)})
+	store.CreateTask(ctx, &models.Task{ProjectID: archived.ID, Description: "Archived", Priority: "medium", Status: "todo", DueDate: date(4)})
+	store.MarkProjectComplete(ctx, archived.ID)
+
+	tasks, err := store.ListTasksDueBetween(ctx, *date(3), *date(10))
+	if err != nil {
+		t.Fatalf("ListTasksDueBetween failed: %v", err)
+	}
+
+	got := make([]string, len(tasks))
+	for i, task := range tasks {
+		got[i] = task.Description
+	}
+	if strings.Join(got, ",") != "High,Low,Done" {
+		t.Errorf("expected High,Low,Done, got %v", got)
+	}
+	if tasks[0].ProjectName != "Test" {
+		t.Errorf("expected project name to be set, got %q", tasks[0].ProjectName)
+	}
+}
+
+func TestListOpenTasks_FiltersAndSorts(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	work := &models.Project{Name: "Work", Type: "project"}
+	store.CreateProject(ctx, work)
+	home := &models.Project{Name: "Home", Type: "project"}
+	store.CreateProject(ctx, home)
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	today := time.Now()
+	nextMonth := time.Now().AddDate(0, 1, 0)
+	store.CreateTask(ctx, &models.Task{ProjectID: work.ID, Description: "Report", Notes: "50% done", Priority: "low", Status: "todo", DueDate: &yesterday})
+	store.CreateTask(ctx, &models.Task{ProjectID: work.ID, Description: "Deploy", Priority: "high", Status: "in_progress", DueDate: &nextMonth})
+	store.CreateTask(ctx, &models.Task{ProjectID: home.ID, Description: "Laundry", Priority: "medium", Status: "todo", DueDate: &today})
+	store.CreateTask(ctx, &models.Task{ProjectID: home.ID, Description: "Paint fence", Priority: "high", Status: "todo"})
+	store.CreateTask(ctx, &models.Task{ProjectID: home.ID, Description: "Finished", Priority: "high", Status: "done"})
+
+	tests := []struct {
+		name   string
+		filter TaskFilter
+		want   string
+	}{
+		{"default sorts by due date", TaskFilter{}, "Report,Laundry,Deploy,Paint fence"},
+		{"by priority", TaskFilter{Sort: SortPriority}, "Deploy,Paint fence,Laundry,Report"},
+		{"by project", TaskFilter{Sort: SortProject}, "Report,Deploy,Laundry,Paint fence"},
+		{"project", TaskFilter{ProjectID: home.ID}, "Laundry,Paint fence"},
+		{"priority", TaskFilter{Priority: "high"}, "Deploy,Paint fence"},
+		{"status", TaskFilter{Status: "in_progress"}, "Deploy"},
+		{"overdue", TaskFilter{Due: DueOverdue}, "Report"},
+		{"today", TaskFilter{Due: DueToday}, "Laundry"},
+		{"this week", TaskFilter{Due: DueThisWeek}, "Laundry"},
+		{"no due date", TaskFilter{Due: DueNone}, "Paint fence"},
+		{"search description", TaskFilter{Search: "fence"}, "Paint fence"},
+		{"search is literal", TaskFilter{Search: "50%"}, "Report"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, err := store.ListOpenTasks(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("ListOpenTasks failed: %v", err)
+			}
+			got := make([]string, len(tasks))
+			for i, task := range tasks {
+				got[i] = task.Description
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, strings.Join(got, ","))
+			}
+		})
+	}
+}
+
+func TestListProjectTasks(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	work := &models.Project{Name: "Work", Type: "project"}
+	store.CreateProject(ctx, work)
+	home := &models.Project{Name: "Home", Type: "project"}
+	store.CreateProject(ctx, home)
+
+	lastWeek := time.Now().AddDate(0, 0, -7)
+	yesterday := time.Now().AddDate(0, 0, -1)
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	lastYear := time.Now().AddDate(-1, 0, 0)
+	store.CreateTask(ctx, &models.Task{ProjectID: work.ID, Description: "Report", Notes: "Draft first", Priority: "low", Status: "todo", DueDate: &yesterday, SortOrder: 1})
+	store.CreateTask(ctx, &models.Task{ProjectID: work.ID, Description: "Slides", Priority: "high", Status: "todo", DueDate: &tomorrow, SortOrder: 2})
+	store.CreateTask(ctx, &models.Task{ProjectID: work.ID, Description: "Budget", Priority: "high", Status: "todo", SortOrder: 3})
+	store.CreateTask(ctx, &models.Task{ProjectID: work.ID, Description: "Deploy", Notes: "After review", Priority: "high", Status: "in_progress", SortOrder: 4})
+	store.CreateTask(ctx, &models.Task{ProjectID: work.ID, Description: "Hire", Priority: "high", Status: "done", CompletedAt: &yesterday})
+	store.CreateTask(ctx, &models.Task{ProjectID: work.ID, Description: "Plan", Priority: "low", Status: "done", CompletedAt: &yesterday})
+	store.CreateTask(ctx, &models.Task{ProjectID: work.ID, Description: "Kickoff", Priority: "high", Status: "done", CompletedAt: &lastYear})
+	store.CreateTask(ctx, &models.Task{ProjectID: home.ID, Description: "Laundry", Priority: "high", Status: "todo"})
+
+	tests := []struct {
+		name   string
+		filter TaskFilter
+		want   string
+	}{
+		{"status", TaskFilter{Status: "todo"}, "Report,Slides,Budget"},
+		{"priority", TaskFilter{Status: "todo", Priority: "high"}, "Slides,Budget"},
+		{"overdue", TaskFilter{Status: "todo", Due: DueOverdue}, "Report"},
+		{"this week", TaskFilter{Status: "todo", Due: DueThisWeek}, "Slides"},
+		{"has notes", TaskFilter{HasNotes: true}, "Report,Deploy"},
+		{"recently done", TaskFilter{Status: "done", CompletedSince: &lastWeek}, "Hire,Plan"},
+		{"recently done by priority", TaskFilter{Status: "done", Priority: "high", CompletedSince: &lastWeek}, "Hire"},
+		{"sorted by priority", TaskFilter{Status: "todo", SortMode: models.SortPriority}, "Slides,Budget,Report"},
+		{"sorted by due date", TaskFilter{Status: "todo", SortMode: models.SortDueDate}, "Report,Slides,Budget"},
+		{"sorted by creation", TaskFilter{Status: "todo", SortMode: models.SortCreated}, "Report,Slides,Budget"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.ProjectID = work.ID
+			tasks, err := store.ListProjectTasks(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("ListProjectTasks failed: %v", err)
+			}
+			got := make([]string, len(tasks))
+			for i, task := range tasks {
+				got[i] = task.Description
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, strings.Join(got, ","))
+			}
+		})
+	}
+
+	if _, err := store.ListProjectTasks(ctx, TaskFilter{Status: "todo"}); err == nil {
+		t.Error("expected an error without a project")
+	}
+}
+
+func TestProjectReviews(t *testing.T) {
+	s := setupTestDB(t)
+	ctx := context.Background()
+
+	reviewed := &models.Project{Name: "Reviewed", Type: "project"}
+	never := &models.Project{Name: "Never", Type: "project"}
+	s.CreateProject(ctx, reviewed)
+	s.CreateProject(ctx, never)
+
+	first := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	second := time.Date(2025, 3, 8, 9, 0, 0, 0, time.UTC)
+	for _, at := range []time.Time{first, second} {
+		if err := s.RecordProjectReview(ctx, reviewed.ID, at); err != nil {
+			t.Fatalf("RecordProjectReview: %v", err)
+		}
+	}
+
+	reviews, err := s.ListLastProjectReviews(ctx)
+	if err != nil {
+		t.Fatalf("ListLastProjectReviews: %v", err)
+	}
+	if got, ok := reviews[reviewed.ID]; !ok || !got.Equal(second) {
+		t.Errorf("expected last review %v, got %v", second, got)
+	}
+	if _, ok := reviews[never.ID]; ok {
+		t.Errorf("did not expect a review for %q", never.Name)
+	}
+
+	if err := s.DeleteProject(ctx, reviewed.ID); err != nil {
+		t.Fatalf("DeleteProject: %v", err)
+	}
+	reviews, _ = s.ListLastProjectReviews(ctx)
+	if len(reviews) != 0 {
+		t.Errorf("expected review log to be removed with the project, got %v", reviews)
+	}
+}
+
+func TestListDailyCompletionCounts(t *testing.T) {
+	s := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "P", Type: "project"}
+	s.CreateProject(ctx, project)
+
+	for i, completed := range []string{"2025-06-01", "2025-06-01", "2025-06-03", ""} {
+		task := &models.Task{ProjectID: project.ID, Description: fmt.Sprintf("Task %d", i), Priority: "medium", Status: "todo"}
+		s.CreateTask(ctx, task)
+		if err := s.MoveTaskToStatus(ctx, task.ID, "done", i); err != nil {
+			t.Fatalf("MoveTaskToStatus: %v", err)
+		}
+		var value interface{}
+		if completed != "" {
+			value = completed
+		}
+		if _, err := s.db.ExecContext(ctx, `UPDATE tasks SET completed_at = ? WHERE id = ?`, value, task.ID); err != nil {
+			t.Fatalf("set completed_at: %v", err)
+		}
+	}
+	// Open tasks never count.
+	s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: "Open", Priority: "low", Status: "todo"})
+
+	counts, err := s.ListDailyCompletionCounts(ctx)
+	if err != nil {
+		t.Fatalf("ListDailyCompletionCounts: %v", err)
+	}
+	if len(counts) != 2 {
+		t.Fatalf("expected 2 days, got %+v", counts)
+	}
+	if counts[0].Day.Format("2006-01-02") != "2025-06-01" || counts[0].Count != 2 {
+		t.Errorf("unexpected first day %+v", counts[0])
+	}
+	if counts[1].Day.Format("2006-01-02") != "2025-06-03" || counts[1].Count != 1 {
+		t.Errorf("unexpected second day %+v", counts[1])
+	}
+}
+
+func TestSettings(t *testing.T) {
+	s := setupTestDB(t)
+	ctx := context.Background()
+
+	if _, err := s.GetSetting(ctx, "daily_goal"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	for _, value := range []string{"3", "5"} {
+		if err := s.SetSetting(ctx, "daily_goal", value); err != nil {
+			t.Fatalf("SetSetting: %v", err)
+		}
+	}
+
+	got, err := s.GetSetting(ctx, "daily_goal")
+	if err != nil {
+		t.Fatalf("GetSetting: %v", err)
+	}
+	if got != "5" {
+		t.Errorf("expected 5, got %q", got)
+	}
+}
+
+func TestGetSaveSettings(t *testing.T) {
+	s := setupTestDB(t)
+	ctx := context.Background()
+
+	got, err := s.GetSettings(ctx)
+	if err != nil {
+		t.Fatalf("GetSettings: %v", err)
+	}
+	if *got != models.DefaultSettings() {
+		t.Errorf("expected defaults, got %+v", got)
+	}
+
+	want := models.Settings{DefaultPriority: "high", UpcomingDays: 14, StaleDays: 30, DateFormat: "2006-01-02", WeekStart: time.Sunday, Theme: "dark", Palette: "forest", Language: "de"}
+	if err := s.SaveSettings(ctx, &want); err != nil {
+		t.Fatalf("SaveSettings: %v", err)
+	}
+	got, err = s.GetSettings(ctx)
+	if err != nil {
+		t.Fatalf("GetSettings: %v", err)
+	}
+	if *got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	// Values that no longer validate fall back to the default one by one.
+	s.SetSetting(ctx, "upcoming_days", "0")
+	s.SetSetting(ctx, "date_format", "Monday")
+	got, err = s.GetSettings(ctx)
+	if err != nil {
+		t.Fatalf("GetSettings: %v", err)
+	}
+	defaults := models.DefaultSettings()
+	if got.UpcomingDays != defaults.UpcomingDays || got.DateFormat != defaults.DateFormat {
+		t.Errorf("expected invalid values to fall back, got %+v", got)
+	}
+	if got.DefaultPriority != "high" || got.WeekStart != time.Sunday {
+		t.Errorf("expected valid values to be kept, got %+v", got)
+	}
+}
+
+func TestReportCounts(t *testing.T) {
+	s := setupTestDB(t)
+	ctx := context.Background()
+
+	home := &models.Project{Name: "Home", Type: "project"}
+	work := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, home)
+	s.CreateProject(ctx, work)
+
+	// created_at, completed_at ("" = still open)
+	seed := []struct {
+		project  *models.Project
+		priority string
+		created  string
+		done     string
+	}{
+		{work, "high", "2025-06-02 09:00:00", "2025-06-03"},   // Mon week of Jun 2
+		{work, "low", "2025-06-08 09:00:00", ""},              // Sun, same week
+		{home, "high", "2025-05-20 09:00:00", "2025-06-10"},   // created before range
+		{home, "medium", "2025-06-09 09:00:00", "2025-07-01"}, // completed after range
+	}
+	for i, row := range seed {
+		task := &models.Task{ProjectID: row.project.ID, Description: fmt.Sprintf("Task %d", i), Priority: row.priority, Status: "todo"}
+		if err := s.CreateTask(ctx, task); err != nil {
+			t.Fatalf("CreateTask: %v", err)
+		}
+		if row.done != "" {
+			if err := s.MoveTaskToStatus(ctx, task.ID, "done", i); err != nil {
+				t.Fatalf("MoveTaskToStatus: %v", err)
+			}
+			if _, err := s.db.ExecContext(ctx, `UPDATE tasks SET completed_at = ? WHERE id = ?`, row.done, task.ID); err != nil {
+				t.Fatalf("set completed_at: %v", err)
+			}
+		}
+		if _, err := s.db.ExecContext(ctx, `UPDATE tasks SET created_at = ? WHERE id = ?`, row.created, task.ID); err != nil {
+			t.Fatalf("set created_at: %v", err)
+		}
+	}
+
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		groupBy string
+		want    []models.ReportRow
+	}{
+		{GroupByProject, []models.ReportRow{{Group: "Home", Created: 1, Completed: 1}, {Group: "Work", Created: 2, Completed: 1}}},
+		{GroupByPriority, []models.ReportRow{{Group: "high", Completed: 2, Created: 1}, {Group: "medium", Created: 1}, {Group: "low", Created: 1}}},
+		{GroupByWeek, []models.ReportRow{{Group: "2025-06-02", Created: 2, Completed: 1}, {Group: "2025-06-09", Created: 1, Completed: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.groupBy, func(t *testing.T) {
+			got, err := s.ReportCounts(ctx, from, to, tt.groupBy, time.Monday)
+			if err != nil {
+				t.Fatalf("ReportCounts: %v", err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+
+	sundayWeeks, err := s.ReportCounts(ctx, from, to, GroupByWeek, time.Sunday)
+	if err != nil {
+		t.Fatalf("ReportCounts: %v", err)
+	}
+	want := []models.ReportRow{{Group: "2025-06-01", Created: 1, Completed: 1}, {Group: "2025-06-08", Created: 2, Completed: 1}}
+	if fmt.Sprint(sundayWeeks) != fmt.Sprint(want) {
+		t.Errorf("expected Sunday-based weeks %+v, got %+v", want, sundayWeeks)
+	}
+
+	if _, err := s.ReportCounts(ctx, from, to, "status", time.Monday); err == nil {
+		t.Error("expected an error for an unknown grouping")
+	}
+}
+
+func TestTaskRecurrence(t *testing.T) {
+	s := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "low", Status: "todo", Recurrence: "every day"}
+	if err := s.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	other := &models.Task{ProjectID: project.ID, Description: "Once", Priority: "low", Status: "todo"}
+	s.CreateTask(ctx, other)
+
+	got, _ := s.GetTask(ctx, task.ID)
+	if got.Recurrence != "every day" {
+		t.Errorf("expected every day, got %q", got.Recurrence)
+	}
+
+	got.Recurrence = "every monday"
+	if err := s.UpdateTask(ctx, got); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	rules, err := s.ListTaskRecurrences(ctx)
+	if err != nil {
+		t.Fatalf("ListTaskRecurrences: %v", err)
+	}
+	if len(rules) != 1 || rules[task.ID] != "every monday" {
+		t.Errorf("unexpected rules %v", rules)
+	}
+
+	if err := s.SetTaskRecurrence(ctx, task.ID, ""); err != nil {
+		t.Fatalf("SetTaskRecurrence: %v", err)
+	}
+	if got, _ := s.GetTask(ctx, task.ID); got.Recurrence != "" {
+		t.Errorf("expected rule cleared, got %q", got.Recurrence)
+	}
+}
+
+func TestCreateTasks(t *testing.T) {
+	s := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Trip", Type: "project"}
+	s.CreateProject(ctx, project)
+
+	tasks := []*models.Task{
+		{ProjectID: project.ID, Description: "Book flights", Priority: "high", Status: "todo", Tags: []string{"@Travel"}},
+		{ProjectID: project.ID, Description: "Pack", Priority: "low", Status: "todo", Recurrence: "every year"},
+	}
+	if err := s.CreateTasks(ctx, tasks); err != nil {
+		t.Fatalf("CreateTasks: %v", err)
+	}
+	if tasks[0].ID == 0 || tasks[1].SortOrder != tasks[0].SortOrder+1 {
+		t.Errorf("expected IDs and consecutive sort orders, got %+v %+v", tasks[0], tasks[1])
+	}
+	got, _ := s.GetTask(ctx, tasks[0].ID)
+	if len(got.Tags) != 1 || got.Tags[0] != "travel" {
+		t.Errorf("expected normalized tag, got %v", got.Tags)
+	}
+	if got, _ := s.GetTask(ctx, tasks[1].ID); got.Recurrence != "every year" {
+		t.Errorf("expected recurrence, got %q", got.Recurrence)
+	}
+
+	// A failing insert rolls back the whole batch.
+	err := s.CreateTasks(ctx, []*models.Task{
+		{ProjectID: project.ID, Description: "Renew passport", Priority: "medium", Status: "todo"},
+		{ProjectID: 9999, Description: "Orphan", Priority: "medium", Status: "todo"},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a missing project")
+	}
+	if all, _ := s.ListTasksByProject(ctx, project.ID, 0); len(all) != 2 {
+		t.Errorf("expected the batch to roll back, got %d tasks", len(all))
+	}
+}
+
+func TestJobStatuses(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	next := time.Date(2024, time.June, 20, 3, 0, 0, 0, time.UTC)
+	if err := store.SaveJobStatus(ctx, &models.JobStatus{Name: "backup", Schedule: "0 3 * * *", NextRunAt: next}); err != nil {
+		t.Fatalf("SaveJobStatus failed: %v", err)
+	}
+	lastRun := next.Add(-24 * time.Hour)
+	status := models.JobStatus{Name: "github", Schedule: "@every 5m0s", LastRunAt: &lastRun, LastDuration: 1500 * time.Millisecond, LastError: "rate limited", NextRunAt: lastRun.Add(5 * time.Minute)}
+	if err := store.SaveJobStatus(ctx, &status); err != nil {
+		t.Fatalf("SaveJobStatus failed: %v", err)
+	}
+	status.LastError = ""
+	if err := store.SaveJobStatus(ctx, &status); err != nil {
+		t.Fatalf("SaveJobStatus update failed: %v", err)
+	}
+
+	statuses, err := store.ListJobStatuses(ctx)
+	if err != nil {
+		t.Fatalf("ListJobStatuses failed: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 job statuses, got %d", len(statuses))
+	}
+	if statuses[0].Name != "backup" || statuses[0].LastRunAt != nil || !statuses[0].NextRunAt.Equal(next) {
+		t.Errorf("unexpected backup status: %+v", statuses[0])
+	}
+	got := statuses[1]
+	if got.LastRunAt == nil || !got.LastRunAt.Equal(lastRun) || got.LastDuration != status.LastDuration || got.LastError != "" {
+		t.Errorf("unexpected github status: %+v", got)
+	}
+
+	if err := store.PruneJobStatuses(ctx, []string{"github"}); err != nil {
+		t.Fatalf("PruneJobStatuses failed: %v", err)
+	}
+	statuses, _ = store.ListJobStatuses(ctx)
+	if len(statuses) != 1 || statuses[0].Name != "github" {
+		t.Errorf("expected only github to remain, got %+v", statuses)
+	}
+}
+
+func TestBackup(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+	store.CreateProject(ctx, &models.Project{Name: "Home", Type: "project"})
+
+	path := filepath.Join(t.TempDir(), "backup.db")
+	if err := store.Backup(ctx, path); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	backup, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("failed to open backup: %v", err)
+	}
+	defer backup.Close()
+	projects, err := backup.ListProjects(ctx)
+	if err != nil || len(projects) != 1 || projects[0].Name != "Home" {
+		t.Errorf("expected the backup to contain the project, got %v, %v", projects, err)
+	}
+
+	if err := store.Backup(ctx, path); err == nil {
+		t.Error("expected backing up over an existing file to fail")
+	}
+}
+
+func TestBackupAnonymized(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	store.CreateProject(ctx, project)
+	due := time.Date(2026, time.November, 2, 0, 0, 0, 0, time.UTC)
+	tasks := []*models.Task{
+		{ProjectID: project.ID, Description: "Call Dr. Smith", Notes: "+1 555 0100", Priority: "high", Status: "todo", DueDate: &due, Tags: []string{"health"}},
+		{ProjectID: project.ID, Description: "Book dentist", Priority: "low", Status: "todo", Tags: []string{"health"}},
+	}
+	store.CreateTasks(ctx, tasks)
+	tasks[1].Description = "Book dentist for Anna"
+	store.UpdateTask(ctx, tasks[1])
+	store.CreateTaskComment(ctx, &models.TaskComment{TaskID: tasks[0].ID, Body: "She moved offices"})
+	store.SetSetting(ctx, "capture_token", "secret")
+	store.SetSetting(ctx, "date_format", "iso")
+	original, _ := store.GetTask(ctx, tasks[1].ID)
+
+	rot13 := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z':
+				return 'a' + (r-'a'+13)%26
+			case r >= 'A' && r <= 'Z':
+				return 'A' + (r-'A'+13)%26
+			}
+			return r
+		}, s)
+	}
+	path := filepath.Join(t.TempDir(), "diagnostic.db")
+	if err := store.BackupAnonymized(ctx, path, rot13); err != nil {
+		t.Fatalf("BackupAnonymized failed: %v", err)
+	}
+
+	anon, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("failed to open the anon: %v", err)
+	}
+	defer anon.Close()
+
+	if p, _ := anon.GetProject(ctx, project.ID); p == nil || p.Name != "Ubzr" {
+		t.Errorf("expected the project name scrambled, got %+v", p)
+	}
+	call, _ := anon.GetTask(ctx, tasks[0].ID)
+	if call == nil || call.Description != "Pnyy Qe. Fzvgu" || call.Notes != "+1 555 0100" || call.DueDate == nil || !call.DueDate.Equal(due) {
+		t.Errorf("expected the texts scrambled and the due date kept, got %+v", call)
+	}
+	book, _ := anon.GetTask(ctx, tasks[1].ID)
+	if book == nil || book.Revision != original.Revision || !book.UpdatedAt.Equal(original.UpdatedAt) {
+		t.Errorf("expected scrambling not to count as an edit, got %+v, was %+v", book, original)
+	}
+	tags, _ := anon.ListTaskTags(ctx)
+	if tags[tasks[0].ID][0] != "urnygu" || tags[tasks[1].ID][0] != "urnygu" {
+		t.Errorf("expected equal tags to stay equal, got %v", tags)
+	}
+	history, _ := anon.ListTaskHistory(ctx, tasks[1].ID)
+	if len(history) != 1 || history[0].OldValue != "Obbx qragvfg" || history[0].NewValue != "Obbx qragvfg sbe Naan" {
+		t.Errorf("expected the edit history kept but scrambled, got %+v", history)
+	}
+	comments, _ := anon.ListTaskComments(ctx, tasks[0].ID)
+	if len(comments) != 1 || comments[0].Body != "Fur zbirq bssvprf" {
+		t.Errorf("expected the comment scrambled, got %+v", comments)
+	}
+	if token, _ := anon.GetSetting(ctx, "capture_token"); token != "" {
+		t.Errorf("expected the capture token left out, got %q", token)
+	}
+	if format, _ := anon.GetSetting(ctx, "date_format"); format != "iso" {
+		t.Errorf("expected other settings kept, got %q", format)
+	}
+
+	var triggers int
+	anon.DB().QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger'`).Scan(&triggers)
+	var want int
+	store.DB().QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger'`).Scan(&want)
+	if triggers != want {
+		t.Errorf("expected the %d triggers back in place, got %d", want, triggers)
+	}
+}
+
+func TestListMigrations(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+	if _, err := store.DB().Exec(`INSERT INTO schema_migrations (version, name) VALUES (9999, 'from_the_future')`); err != nil {
+		t.Fatal(err)
+	}
+
+	migrations, err := store.ListMigrations(ctx)
+	if err != nil {
+		t.Fatalf("ListMigrations failed: %v", err)
+	}
+	if len(migrations) < 2 || migrations[0].Version != 1 {
+		t.Fatalf("expected the migrations by version, got %+v", migrations)
+	}
+	for _, m := range migrations[:len(migrations)-1] {
+		if m.AppliedAt == nil || m.Unknown {
+			t.Errorf("expected %d_%s to be applied", m.Version, m.Name)
+		}
+	}
+	if last := migrations[len(migrations)-1]; last.Version != 9999 || !last.Unknown || last.AppliedAt == nil {
+		t.Errorf("expected the unknown migration last, got %+v", last)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	store.CreateProject(ctx, &models.Project{Name: "Home", Type: "project"})
+
+	if err := store.Checkpoint(ctx); err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	var logFrames int
+	store.DB().QueryRow(`PRAGMA wal_checkpoint`).Scan(new(int), &logFrames, new(int))
+	if logFrames != 0 {
+		t.Errorf("expected an empty log after the checkpoint, got %d frames", logFrames)
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+	now := time.Now()
+	locked := now.Add(time.Hour)
+	failures := []*models.AuthFailure{
+		{Scope: "capture", Client: "old", Failures: 2, LastFailureAt: now.Add(-48 * time.Hour)},
+		{Scope: "capture", Client: "recent", Failures: 2, LastFailureAt: now.Add(-time.Hour)},
+		{Scope: "capture", Client: "locked", Failures: 9, LastFailureAt: now.Add(-48 * time.Hour), LockedUntil: &locked},
+	}
+	for _, f := range failures {
+		if err := store.SaveAuthFailure(ctx, f); err != nil {
+			t.Fatalf("SaveAuthFailure failed: %v", err)
+		}
+	}
+
+	result, err := store.Cleanup(ctx, now)
+	if err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+	if result.ExpiredAuthFailures != 1 || result.SizeAfter <= 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	for _, client := range []string{"recent", "locked"} {
+		if f, _ := store.GetAuthFailure(ctx, "capture", client); f.Failures == 0 {
+			t.Errorf("expected the %s failures to be kept", client)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+	project := &models.Project{Name: "Home", Type: "project"}
+	if err := store.CreateProject(ctx, project); err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	task := &models.Task{ProjectID: project.ID, Description: "Paint the fence", Priority: "medium", Status: "todo", Tags: []string{"garden"}}
+	if err := store.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+
+	if err := store.Reset(ctx); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	projects, _ := store.ListProjects(ctx)
+	changes, _ := store.ListChanges(ctx, 0, 10)
+	if len(projects) != 0 || len(changes) != 0 {
+		t.Errorf("expected an empty database, got %d projects and %d changes", len(projects), len(changes))
+	}
+	migrations, err := store.ListMigrations(ctx)
+	if err != nil || len(migrations) == 0 || migrations[0].AppliedAt == nil {
+		t.Errorf("expected the migration history to be kept, got %v", err)
+	}
+
+	again := &models.Project{Name: "Home", Type: "project"}
+	if err := store.CreateProject(ctx, again); err != nil {
+		t.Fatalf("CreateProject after Reset failed: %v", err)
+	}
+	if again.ID == project.ID {
+		t.Errorf("expected a new ID after Reset, got %d again", again.ID)
+	}
+}
+
+func TestListTasksAfter(t *testing.T) {
+	s := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, project)
+	for i := 0; i < 5; i++ {
+		s.CreateTask(ctx, &models.Task{ProjectID: project.ID, Description: fmt.Sprintf("Task %d", i), Priority: "medium", Status: "todo"})
+	}
+
+	var seen []string
+	var after int64
+	for {
+		page, err := s.ListTasksAfter(ctx, after, 2)
+		if err != nil {
+			t.Fatalf("ListTasksAfter failed: %v", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		if len(page) > 2 {
+			t.Fatalf("expected at most 2 tasks a page, got %d", len(page))
+		}
+		for _, task := range page {
+			seen = append(seen, task.Description)
+		}
+		after = page[len(page)-1].ID
+	}
+	if strings.Join(seen, ",") != "Task 0,Task 1,Task 2,Task 3,Task 4" {
+		t.Errorf("expected every task once in ID order, got %v", seen)
+	}
+}
+
+func TestListChanges(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	store.CreateProject(ctx, project)
+	kept := &models.Task{ProjectID: project.ID, Description: "Kept", Priority: "medium", Status: "todo"}
+	store.CreateTask(ctx, kept)
+	gone := &models.Task{ProjectID: project.ID, Description: "Gone", Priority: "medium", Status: "todo"}
+	store.CreateTask(ctx, gone)
+
+	changes, err := store.ListChanges(ctx, 0, 100)
+	if err != nil {
+		t.Fatalf("ListChanges failed: %v", err)
+	}
+	if len(changes) != 3 || changes[0].Entity != models.ChangeProject || changes[2].Op != models.ChangeCreate {
+		t.Fatalf("expected three creations, got %+v", changes)
+	}
+	since := changes[2].Seq
+
+	if changes, _ := store.ListChanges(ctx, since, 100); len(changes) != 0 {
+		t.Fatalf("expected nothing new, got %+v", changes)
+	}
+
+	kept.Tags = []string{"errands"}
+	if err := store.UpdateTask(ctx, kept); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	store.ToggleTaskComplete(ctx, kept.ID)
+	store.DeleteTask(ctx, gone.ID)
+
+	changes, _ = store.ListChanges(ctx, since, 100)
+	got := make(map[int64]string)
+	for _, c := range changes {
+		got[c.EntityID] = c.Op
+	}
+	if len(changes) != 2 || got[kept.ID] != models.ChangeUpdate || got[gone.ID] != models.ChangeDelete {
+		t.Errorf("expected one change per task, got %+v", changes)
+	}
+	if changes, _ := store.ListChanges(ctx, since, 1); len(changes) != 1 {
+		t.Errorf("expected the limit to apply, got %d changes", len(changes))
+	}
+
+	// Deleting a project deletes its tasks.
+	since = changes[len(changes)-1].Seq
+	store.DeleteProject(ctx, project.ID)
+	changes, _ = store.ListChanges(ctx, since, 100)
+	if len(changes) != 2 || changes[0].Op != models.ChangeDelete || changes[1].Op != models.ChangeDelete {
+		t.Errorf("expected the project and its task to be deleted, got %+v", changes)
+	}
+}
+
+func TestRevisions(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	store.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "medium", Status: "todo"}
+	store.CreateTask(ctx, task)
+	if task.Revision != 1 || project.Revision != 1 {
+		t.Fatalf("expected new entities at revision 1, got task %d, project %d", task.Revision, project.Revision)
+	}
+
+	revision := func() int64 {
+		t.Helper()
+		got, err := store.GetTask(ctx, task.ID)
+		if err != nil {
+			t.Fatalf("GetTask failed: %v", err)
+		}
+		return got.Revision
+	}
+
+	// Saving it unchanged, reordering and escalation don't count.
+	store.UpdateTask(ctx, task)
+	store.ReorderTasks(ctx, project.ID, []int64{task.ID})
+	due := time.Now()
+	task.DueDate = &due
+	store.UpdateTask(ctx, task)
+	if got := revision(); got != 2 || task.Revision != 2 {
+		t.Fatalf("expected revision 2 after setting a due date, got %d (in memory %d)", got, task.Revision)
+	}
+	store.EscalatePriorities(ctx, 3, due)
+	if got := revision(); got != 2 {
+		t.Errorf("expected escalation to leave the revision, got %d", got)
+	}
+
+	store.ToggleTaskComplete(ctx, task.ID)
+	if got := revision(); got != 3 {
+		t.Errorf("expected completing to count, got %d", got)
+	}
+	task, _ = store.GetTask(ctx, task.ID)
+	task.Tags = []string{"garden"}
+	store.UpdateTask(ctx, task)
+	store.UpdateTask(ctx, task)
+	if got := revision(); got != 4 {
+		t.Errorf("expected one revision for the new tag, got %d", got)
+	}
+
+	store.SetProjectSortMode(ctx, project.ID, models.SortPriority)
+	got, _ := store.GetProject(ctx, project.ID)
+	if got.Revision != 1 {
+		t.Errorf("expected the sort mode to leave the revision, got %d", got.Revision)
+	}
+	got.Name = "House"
+	store.UpdateProject(ctx, got)
+	if got.Revision != 2 {
+		t.Errorf("expected a rename to count, got %d", got.Revision)
+	}
+}
+
+func TestSyncMutations(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	if applied, err := store.SyncMutationApplied(ctx, "m1"); err != nil || applied {
+		t.Fatalf("expected m1 to be unknown, got %v, %v", applied, err)
+	}
+	if err := store.RecordSyncMutation(ctx, "m1", now.Add(-31*24*time.Hour)); err != nil {
+		t.Fatalf("RecordSyncMutation failed: %v", err)
+	}
+	if applied, _ := store.SyncMutationApplied(ctx, "m1"); !applied {
+		t.Fatal("expected m1 to be recorded")
+	}
+	// Recording twice is harmless.
+	store.RecordSyncMutation(ctx, "m2", now)
+	if err := store.RecordSyncMutation(ctx, "m2", now); err != nil {
+		t.Fatalf("RecordSyncMutation failed on a repeat: %v", err)
+	}
+	if applied, _ := store.SyncMutationApplied(ctx, "m1"); applied {
+		t.Error("expected the month-old m1 to be forgotten")
+	}
+	if applied, _ := store.SyncMutationApplied(ctx, "m2"); !applied {
+		t.Error("expected m2 to be kept")
+	}
+}
+
+func TestEscalatePriorities(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	store.CreateProject(ctx, project)
+	today := time.Now()
+	day := func(n int) *time.Time {
+		d := dateOnlyUTC(today.AddDate(0, 0, n))
+		return &d
+	}
+	tasks := map[string]*models.Task{
+		"soon low":      {Priority: "low", Status: "todo", DueDate: day(2)},
+		"soon medium":   {Priority: "medium", Status: "todo", DueDate: day(3)},
+		"later low":     {Priority: "low", Status: "todo", DueDate: day(4)},
+		"overdue low":   {Priority: "low", Status: "in_progress", DueDate: day(-1)},
+		"done overdue":  {Priority: "low", Status: "done", DueDate: day(-1)},
+		"undated low":   {Priority: "low", Status: "todo"},
+		"today already": {Priority: "high", Status: "todo", DueDate: day(0)},
+	}
+	for name, task := range tasks {
+		task.ProjectID = project.ID
+		task.Description = name
+		store.CreateTask(ctx, task)
+	}
+
+	want := map[string]string{
+		"soon low":      "medium",
+		"soon medium":   "high",
+		"later low":     "",
+		"overdue low":   "high",
+		"done overdue":  "",
+		"undated low":   "",
+		"today already": "high",
+	}
+	if err := store.EscalatePriorities(ctx, 3, today); err != nil {
+		t.Fatalf("EscalatePriorities failed: %v", err)
+	}
+	for name, task := range tasks {
+		got, _ := store.GetTask(ctx, task.ID)
+		if got.EscalatedPriority != want[name] {
+			t.Errorf("%s: escalated to %q, want %q", name, got.EscalatedPriority, want[name])
+		}
+		if !got.UpdatedAt.Equal(task.UpdatedAt) {
+			t.Errorf("%s: expected escalation to leave updated_at alone", name)
+		}
+	}
+
+	open, _ := store.ListOpenTasks(ctx, TaskFilter{Due: DueOverdue})
+	if len(open) != 1 || open[0].EffectivePriority() != "high" || open[0].Urgency != 40 {
+		t.Errorf("expected the overdue task to be listed as high with urgency 40, got %+v", open)
+	}
+
+	if err := store.EscalatePriorities(ctx, 0, today); err != nil {
+		t.Fatalf("EscalatePriorities failed: %v", err)
+	}
+	for name, task := range tasks {
+		if got, _ := store.GetTask(ctx, task.ID); got.EscalatedPriority != "" {
+			t.Errorf("%s: expected escalation to be cleared, got %q", name, got.EscalatedPriority)
+		}
+	}
+}
+
+func dateOnlyUTC(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func TestAuthFailures(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	failure, err := store.GetAuthFailure(ctx, "capture_token", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("GetAuthFailure failed: %v", err)
+	}
+	if failure.Failures != 0 || failure.LockedUntil != nil {
+		t.Fatalf("expected an empty record, got %+v", failure)
+	}
+
+	now := time.Date(2024, time.June, 19, 12, 0, 0, 0, time.UTC)
+	until := now.Add(time.Minute)
+	failure.Failures = 7
+	failure.LastFailureAt = now
+	failure.LockedUntil = &until
+	if err := store.SaveAuthFailure(ctx, failure); err != nil {
+		t.Fatalf("SaveAuthFailure failed: %v", err)
+	}
+
+	got, _ := store.GetAuthFailure(ctx, "capture_token", "192.0.2.1")
+	if got.Failures != 7 || !got.LastFailureAt.Equal(now) || got.LockedUntil == nil || !got.LockedUntil.Equal(until) {
+		t.Errorf("unexpected record: %+v", got)
+	}
+	if other, _ := store.GetAuthFailure(ctx, "shortcuts_token", "192.0.2.1"); other.Failures != 0 {
+		t.Error("expected failures to be kept per scope")
+	}
+
+	if err := store.ClearAuthFailures(ctx, "capture_token", "192.0.2.1"); err != nil {
+		t.Fatalf("ClearAuthFailures failed: %v", err)
+	}
+	if got, _ := store.GetAuthFailure(ctx, "capture_token", "192.0.2.1"); got.Failures != 0 {
+		t.Errorf("expected failures to be cleared, got %d", got.Failures)
+	}
+}
+
+func TestTaskDetails(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	store.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Write report", Priority: "low", Status: "todo"}
+	store.CreateTask(ctx, task)
+
+	// Comments
+	for _, body := range []string{"First draft is up", "Reviewed"} {
+		if err := store.CreateTaskComment(ctx, &models.TaskComment{TaskID: task.ID, Body: body}); err != nil {
+			t.Fatalf("CreateTaskComment failed: %v", err)
+		}
+	}
+	comments, err := store.ListTaskComments(ctx, task.ID)
+	if err != nil || len(comments) != 2 || comments[0].Body != "First draft is up" {
+		t.Fatalf("expected both comments oldest first, got %+v (%v)", comments, err)
+	}
+	if err := store.DeleteTaskComment(ctx, comments[0].ID); err != nil {
+		t.Fatalf("DeleteTaskComment failed: %v", err)
+	}
+	if err := store.DeleteTaskComment(ctx, comments[0].ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	// Subtasks
+	for _, title := range []string{"Outline", "Charts"} {
+		if err := store.CreateSubtask(ctx, &models.Subtask{TaskID: task.ID, Title: title}); err != nil {
+			t.Fatalf("CreateSubtask failed: %v", err)
+		}
+	}
+	subtasks, err := store.ListSubtasks(ctx, task.ID)
+	if err != nil || len(subtasks) != 2 || subtasks[1].Title != "Charts" || subtasks[1].SortOrder != 2 {
+		t.Fatalf("expected subtasks in order, got %+v (%v)", subtasks, err)
+	}
+	toggled, err := store.ToggleSubtask(ctx, subtasks[0].ID)
+	if err != nil || !toggled.Done {
+		t.Fatalf("expected subtask ticked off, got %+v (%v)", toggled, err)
+	}
+	if _, err := store.ToggleSubtask(ctx, 9999); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound toggling a missing subtask, got %v", err)
+	}
+
+	// Attachments
+	attachment := &models.TaskAttachment{TaskID: task.ID, Name: "notes.txt", ContentType: "text/plain", Data: []byte("hello")}
+	if err := store.CreateTaskAttachment(ctx, attachment); err != nil {
+		t.Fatalf("CreateTaskAttachment failed: %v", err)
+	}
+	attachments, err := store.ListTaskAttachments(ctx, task.ID)
+	if err != nil || len(attachments) != 1 || attachments[0].Size != 5 || attachments[0].Data != nil {
+		t.Fatalf("expected the attachment listed without its data, got %+v (%v)", attachments, err)
+	}
+	got, err := store.GetTaskAttachment(ctx, attachment.ID)
+	if err != nil || string(got.Data) != "hello" {
+		t.Fatalf("expected the attachment's data, got %+v (%v)", got, err)
+	}
+
+	// History
+	due := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	task.Priority = "high"
+	task.DueDate = &due
+	if err := store.UpdateTask(ctx, task); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	if err := store.ReorderTasks(ctx, project.ID, []int64{task.ID}); err != nil {
+		t.Fatalf("ReorderTasks failed: %v", err)
+	}
+	history, err := store.ListTaskHistory(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("ListTaskHistory failed: %v", err)
+	}
+	var changes []string
+	for _, e := range history {
+		changes = append(changes, e.Field+":"+e.OldValue+"->"+e.NewValue)
+	}
+	if want := "priority:low->high,due_date:->2024-03-01"; strings.Join(changes, ",") != want {
+		t.Errorf("expected history %s, got %s", want, strings.Join(changes, ","))
+	}
+
+	// Everything goes with the task.
+	if err := store.DeleteTask(ctx, task.ID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if _, err := store.GetTaskAttachment(ctx, attachment.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the attachment deleted with its task, got %v", err)
+	}
+	if subtasks, _ := store.ListSubtasks(ctx, task.ID); len(subtasks) != 0 {
+		t.Errorf("expected subtasks deleted with their task, got %+v", subtasks)
+	}
+}
+
+func TestTaskCompletions(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	store.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "medium", Status: "todo"}
+	store.CreateTask(ctx, task)
+
+	// Done, reopened, then done again, by different code paths.
+	if err := store.ToggleTaskComplete(ctx, task.ID); err != nil {
+		t.Fatalf("ToggleTaskComplete failed: %v", err)
+	}
+	store.db.ExecContext(ctx, `UPDATE tasks SET completed_at = '2025-05-01' WHERE id = ?`, task.ID)
+	store.ToggleTaskComplete(ctx, task.ID)
+	if err := store.MoveTaskToStatus(ctx, task.ID, "done", 0); err != nil {
+		t.Fatalf("MoveTaskToStatus failed: %v", err)
+	}
+	// Moving it within Done on the same day keeps the completion as it is.
+	store.MoveTaskToStatus(ctx, task.ID, "done", 1)
+
+	completions, err := store.ListTaskCompletions(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("ListTaskCompletions failed: %v", err)
+	}
+	if len(completions) != 2 {
+		t.Fatalf("expected 2 completions, got %+v", completions)
+	}
+	today := time.Now().Format("2006-01-02")
+	if got := completions[0].CompletedAt.Format("2006-01-02"); got != today {
+		t.Errorf("expected the latest completion today, got %s", got)
+	}
+	if completions[0].CompletedAt.Format("15:04:05") == "00:00:00" && time.Now().Format("15:04") != "00:00" {
+		t.Errorf("expected the latest completion to record the time, got %v", completions[0].CompletedAt)
+	}
+	if got := completions[1].CompletedAt.Format("2006-01-02"); got != "2025-05-01" {
+		t.Errorf("expected the first completion kept after reopening, got %s", got)
+	}
+
+	counts, err := store.ListDailyCompletionCounts(ctx)
+	if err != nil || len(counts) != 2 {
+		t.Fatalf("expected both completions counted, got %+v (%v)", counts, err)
+	}
+
+	// A task imported already done keeps its day.
+	done := time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC)
+	imported := &models.Task{ProjectID: project.ID, Description: "Old", Priority: "low", Status: "done", CompletedAt: &done}
+	store.CreateTask(ctx, imported)
+	if completions, _ := store.ListTaskCompletions(ctx, imported.ID); len(completions) != 1 ||
+		completions[0].CompletedAt.Format("2006-01-02") != "2025-04-02" {
+		t.Errorf("expected the imported task's completion on 2025-04-02, got %+v", completions)
+	}
+
+	store.DeleteTask(ctx, task.ID)
+	if completions, _ := store.ListTaskCompletions(ctx, task.ID); len(completions) != 0 {
+		t.Errorf("expected completions deleted with their task, got %+v", completions)
+	}
+}
+
+func TestProjectLinks(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Website", Type: "project"}
+	store.CreateProject(ctx, project)
+
+	for _, l := range []models.ProjectLink{
+		{ProjectID: project.ID, Title: "Designs", URL: "https://figma.com/file/1"},
+		{ProjectID: project.ID, Title: "Analytics", URL: "https://analytics.example.com"},
+	} {
+		if err := store.CreateProjectLink(ctx, &l); err != nil {
+			t.Fatalf("CreateProjectLink failed: %v", err)
+		}
+	}
+	links, err := store.ListProjectLinks(ctx, project.ID)
+	if err != nil || len(links) != 2 || links[0].Title != "Designs" || links[1].SortOrder != 2 {
+		t.Fatalf("expected both links in order, got %+v (%v)", links, err)
+	}
+
+	link := links[1]
+	link.Title = "Dashboard"
+	if err := store.UpdateProjectLink(ctx, &link); err != nil {
+		t.Fatalf("UpdateProjectLink failed: %v", err)
+	}
+	got, err := store.GetProjectLink(ctx, link.ID)
+	if err != nil || got.Title != "Dashboard" || got.URL != link.URL {
+		t.Errorf("expected the link retitled, got %+v (%v)", got, err)
+	}
+
+	changes, _ := store.ListChanges(ctx, 0, 100)
+	last := changes[len(changes)-1]
+	if last.Entity != "project" || last.EntityID != project.ID || last.Op != "update" {
+		t.Errorf("expected a link edit to log a project update, got %+v", last)
+	}
+
+	if err := store.DeleteProjectLink(ctx, link.ID); err != nil {
+		t.Fatalf("DeleteProjectLink failed: %v", err)
+	}
+	if _, err := store.GetProjectLink(ctx, link.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if err := store.DeleteProjectLink(ctx, link.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	store.DeleteProject(ctx, project.ID)
+	if links, _ := store.ListProjectLinks(ctx, project.ID); len(links) != 0 {
+		t.Errorf("expected links deleted with their project, got %+v", links)
+	}
+}
+
+func TestProjectTaskCounts(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	today := time.Date(2024, time.June, 19, 0, 0, 0, 0, time.UTC)
+	day := func(offset int) *time.Time {
+		d := today.AddDate(0, 0, offset)
+		return &d
+	}
+
+	work := &models.Project{Name: "Work", Type: "project"}
+	store.CreateProject(ctx, work)
+	home := &models.Project{Name: "Home", Type: "project"}
+	store.CreateProject(ctx, home)
+	done := &models.Project{Name: "Old", Type: "project"}
+	store.CreateProject(ctx, done)
+
+	for _, task := range []*models.Task{
+		{ProjectID: work.ID, Description: "Undated", Priority: "low", Status: "todo"},
+		{ProjectID: work.ID, Description: "Late", Priority: "high", Status: "in_progress", DueDate: day(-2)},
+		{ProjectID: work.ID, Description: "Today", Priority: "high", Status: "todo", DueDate: day(0)},
+		{ProjectID: work.ID, Description: "Sunday", Priority: "high", Status: "todo", DueDate: day(6)},
+		{ProjectID: work.ID, Description: "Next week", Priority: "high", Status: "todo", DueDate: day(7)},
+		{ProjectID: work.ID, Description: "Shipped", Priority: "high", Status: "done", DueDate: day(-1)},
+		{ProjectID: home.ID, Description: "Finished", Priority: "low", Status: "done"},
+		{ProjectID: done.ID, Description: "Leftover", Priority: "low", Status: "todo", DueDate: day(-1)},
+	} {
+		store.CreateTask(ctx, task)
+	}
+	store.MarkProjectComplete(ctx, done.ID)
+
+	counts, err := store.ProjectTaskCounts(ctx, today)
+	if err != nil {
+		t.Fatalf("ProjectTaskCounts failed: %v", err)
+	}
+	if got, want := counts[work.ID], (models.ProjectCounts{Open: 5, Overdue: 1, DueThisWeek: 2, Done: 1}); got != want {
+		t.Errorf("expected %+v for Work, got %+v", want, got)
+	}
+	if got, want := counts[home.ID], (models.ProjectCounts{Done: 1}); got != want {
+		t.Errorf("expected %+v for a project with only done tasks, got %+v", want, got)
+	}
+	if _, ok := counts[done.ID]; ok {
+		t.Error("expected no counts for a completed project")
+	}
+}
+
+func TestHabits(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	floss := &models.Habit{Name: "Floss", Cadence: models.HabitDaily}
+	run := &models.Habit{Name: "Long run", Cadence: models.HabitWeekly}
+	for _, h := range []*models.Habit{floss, run} {
+		if err := store.CreateHabit(ctx, h); err != nil {
+			t.Fatalf("CreateHabit failed: %v", err)
+		}
+	}
+
+	today := time.Now()
+	yesterday := today.AddDate(0, 0, -1)
+	for _, day := range []time.Time{today, yesterday} {
+		if on, err := store.ToggleHabitCheckIn(ctx, floss.ID, day); err != nil || !on {
+			t.Fatalf("expected a check-in, got %v, %v", on, err)
+		}
+	}
+	if on, err := store.ToggleHabitCheckIn(ctx, run.ID, today); err != nil || !on {
+		t.Fatalf("expected a check-in, got %v, %v", on, err)
+	}
+	if on, err := store.ToggleHabitCheckIn(ctx, run.ID, today); err != nil || on {
+		t.Fatalf("expected toggling again to take the check-in back, got %v, %v", on, err)
+	}
+	if _, err := store.ToggleHabitCheckIn(ctx, 999, today); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	habits, err := store.ListHabits(ctx)
+	if err != nil {
+		t.Fatalf("ListHabits failed: %v", err)
+	}
+	if len(habits) != 2 || habits[0].Name != "Floss" || habits[1].Cadence != models.HabitWeekly {
+		t.Fatalf("expected Floss then Long run, got %+v", habits)
+	}
+	if len(habits[0].CheckIns) != 2 || !habits[0].CheckedIn(yesterday) || !habits[0].CheckedIn(today) {
+		t.Errorf("expected Floss checked in yesterday and today, got %v", habits[0].CheckIns)
+	}
+	if len(habits[1].CheckIns) != 0 {
+		t.Errorf("expected no check-ins for Long run, got %v", habits[1].CheckIns)
+	}
+
+	if err := store.DeleteHabit(ctx, floss.ID); err != nil {
+		t.Fatalf("DeleteHabit failed: %v", err)
+	}
+	var left int
+	store.db.QueryRow(`SELECT COUNT(*) FROM habit_checkins`).Scan(&left)
+	if left != 0 {
+		t.Errorf("expected check-ins to go with the habit, %d left", left)
+	}
+	if err := store.DeleteHabit(ctx, floss.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFocusSessions(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	store.CreateProject(ctx, project)
+	report := &models.Task{ProjectID: project.ID, Description: "Write report", Priority: "medium", Status: "todo"}
+	inbox := &models.Task{ProjectID: project.ID, Description: "Inbox zero", Priority: "medium", Status: "todo"}
+	store.CreateTask(ctx, report)
+	store.CreateTask(ctx, inbox)
+
+	if _, err := store.GetRunningFocusSession(ctx); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected no running session, got %v", err)
+	}
+	if _, err := store.StopFocusSession(ctx, time.Now()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound stopping nothing, got %v", err)
+	}
+	if _, err := store.StartFocusSession(ctx, 999, time.Now()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing task, got %v", err)
+	}
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if _, err := store.StartFocusSession(ctx, report.ID, start); err != nil {
+		t.Fatalf("StartFocusSession failed: %v", err)
+	}
+	// Starting another session stops the first.
+	if _, err := store.StartFocusSession(ctx, inbox.ID, start.Add(25*time.Minute)); err != nil {
+		t.Fatalf("StartFocusSession failed: %v", err)
+	}
+	running, err := store.GetRunningFocusSession(ctx)
+	if err != nil {
+		t.Fatalf("GetRunningFocusSession failed: %v", err)
+	}
+	if running.TaskID != inbox.ID || running.Task != "Inbox zero" {
+		t.Errorf("expected Inbox zero to be running, got %+v", running)
+	}
+
+	stopped, err := store.StopFocusSession(ctx, start.Add(35*time.Minute))
+	if err != nil {
+		t.Fatalf("StopFocusSession failed: %v", err)
+	}
+	if stopped.Running() || stopped.Duration(time.Now()) != 10*time.Minute {
+		t.Errorf("expected a 10-minute session, got %v", stopped.Duration(time.Now()))
+	}
+
+	sessions, err := store.ListFocusSessions(ctx, 0, start.Add(-time.Minute), time.Now())
+	if err != nil {
+		t.Fatalf("ListFocusSessions failed: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].TaskID != report.ID || sessions[0].Duration(time.Now()) != 25*time.Minute {
+		t.Fatalf("expected the report session of 25m first, got %+v", sessions)
+	}
+	if sessions, _ := store.ListFocusSessions(ctx, inbox.ID, start.Add(-time.Minute), time.Now()); len(sessions) != 1 {
+		t.Errorf("expected 1 session for Inbox zero, got %d", len(sessions))
+	}
+	if sessions, _ := store.ListFocusSessions(ctx, 0, start.Add(time.Hour), time.Now().Add(time.Hour)); len(sessions) != 0 {
+		t.Errorf("expected no sessions after the range, got %d", len(sessions))
+	}
+}
+
+func TestDayPlans(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	store.CreateProject(ctx, project)
+	report := &models.Task{ProjectID: project.ID, Description: "Write report", Priority: "medium", Status: "todo"}
+	inbox := &models.Task{ProjectID: project.ID, Description: "Inbox zero", Priority: "medium", Status: "todo"}
+	call := &models.Task{ProjectID: project.ID, Description: "Call bank", Priority: "medium", Status: "todo"}
+	for _, task := range []*models.Task{report, inbox, call} {
+		store.CreateTask(ctx, task)
+	}
+
+	yesterday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	today := yesterday.AddDate(0, 0, 1)
+
+	if err := store.AddToDayPlan(ctx, yesterday, report.ID, inbox.ID, call.ID); err != nil {
+		t.Fatalf("AddToDayPlan failed: %v", err)
+	}
+	// Adding a planned task again keeps its place.
+	if err := store.AddToDayPlan(ctx, yesterday, report.ID); err != nil {
+		t.Fatalf("AddToDayPlan failed: %v", err)
+	}
+	if err := store.AddToDayPlan(ctx, yesterday, 999); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing task, got %v", err)
+	}
+
+	plan, err := store.ListDayPlan(ctx, yesterday)
+	if err != nil {
+		t.Fatalf("ListDayPlan failed: %v", err)
+	}
+	if len(plan) != 3 || plan[0].ID != report.ID || plan[2].ID != call.ID || plan[0].ProjectName != "Work" {
+		t.Fatalf("expected report, inbox, call in order, got %+v", plan)
+	}
+	if plan, _ := store.ListDayPlan(ctx, today); len(plan) != 0 {
+		t.Errorf("expected an empty plan for today, got %d tasks", len(plan))
+	}
+
+	// Done tasks and tasks already planned for today don't carry over.
+	store.ToggleTaskComplete(ctx, report.ID)
+	store.AddToDayPlan(ctx, today, call.ID)
+	day, carry, err := store.ListDayPlanCarryover(ctx, today)
+	if err != nil {
+		t.Fatalf("ListDayPlanCarryover failed: %v", err)
+	}
+	if !day.Equal(yesterday) {
+		t.Errorf("expected the carryover from %v, got %v", yesterday, day)
+	}
+	if len(carry) != 1 || carry[0].ID != inbox.ID {
+		t.Errorf("expected only Inbox zero to carry over, got %+v", carry)
+	}
+	if day, carry, _ := store.ListDayPlanCarryover(ctx, yesterday); !day.IsZero() || len(carry) != 0 {
+		t.Errorf("expected nothing before the first plan, got %v %+v", day, carry)
+	}
+
+	if err := store.RemoveFromDayPlan(ctx, today, call.ID); err != nil {
+		t.Fatalf("RemoveFromDayPlan failed: %v", err)
+	}
+	if err := store.RemoveFromDayPlan(ctx, today, call.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSomedayTasks(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	store.CreateProject(ctx, project)
+	soon := time.Now().AddDate(0, 0, 1)
+	fence := &models.Task{ProjectID: project.ID, Description: "Paint fence", Priority: "high", Status: "todo", DueDate: &soon}
+	laundry := &models.Task{ProjectID: project.ID, Description: "Laundry", Priority: "medium", Status: "todo"}
+	store.CreateTask(ctx, fence)
+	store.CreateTask(ctx, laundry)
+
+	parked, err := store.SetTaskSomeday(ctx, fence.ID, true)
+	if err != nil {
+		t.Fatalf("SetTaskSomeday failed: %v", err)
+	}
+	if !parked.Someday || parked.Revision != fence.Revision+1 {
+		t.Errorf("expected a parked task with a new revision, got %+v", parked)
+	}
+	if _, err := store.SetTaskSomeday(ctx, 999, true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	open, _ := store.ListOpenTasks(ctx, TaskFilter{})
+	if len(open) != 1 || open[0].ID != laundry.ID {
+		t.Errorf("expected only Laundry to stay active, got %+v", open)
+	}
+	if upcoming, _ := store.ListUpcomingTasks(ctx, 7); len(upcoming) != 0 {
+		t.Errorf("expected someday tasks out of Upcoming, got %+v", upcoming)
+	}
+	counts, _ := store.ProjectTaskCounts(ctx, time.Now())
+	if c := counts[project.ID]; c.Open != 1 || c.DueThisWeek != 0 {
+		t.Errorf("expected someday tasks out of the counts, got %+v", c)
+	}
+	someday, _ := store.ListOpenTasks(ctx, TaskFilter{Someday: true})
+	if len(someday) != 1 || someday[0].ID != fence.ID || !someday[0].Someday {
+		t.Errorf("expected Paint fence in Someday, got %+v", someday)
+	}
+
+	if _, err := store.SetTaskSomeday(ctx, fence.ID, false); err != nil {
+		t.Fatalf("SetTaskSomeday failed: %v", err)
+	}
+	if upcoming, _ := store.ListUpcomingTasks(ctx, 7); len(upcoming) != 1 {
+		t.Errorf("expected the promoted task back in Upcoming, got %+v", upcoming)
+	}
+}
+
+func TestWaitingTasks(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	store.CreateProject(ctx, project)
+	quote := &models.Task{ProjectID: project.ID, Description: "Get a quote", Priority: "medium", Status: "todo", WaitingOn: "Acme"}
+	report := &models.Task{ProjectID: project.ID, Description: "Write report", Priority: "medium", Status: "todo"}
+	store.CreateTask(ctx, quote)
+	store.CreateTask(ctx, report)
+
+	got, err := store.GetTask(ctx, quote.ID)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if got.WaitingOn != "Acme" || got.WaitingSince == nil {
+		t.Fatalf("expected a task waiting on Acme since now, got %+v", got)
+	}
+	since := *got.WaitingSince
+
+	// Naming someone else keeps the wait's start; handing off a task starts one.
+	got.WaitingOn = "Acme sales"
+	if err := store.UpdateTask(ctx, got); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	if got, _ := store.GetTask(ctx, quote.ID); got.WaitingSince == nil || !got.WaitingSince.Equal(since) {
+		t.Errorf("expected the wait to still start at %v, got %v", since, got.WaitingSince)
+	}
+	report.WaitingOn = "Sam"
+	store.UpdateTask(ctx, report)
+	if got, _ := store.GetTask(ctx, report.ID); got.WaitingSince == nil {
+		t.Error("expected handing off a task to start the wait")
+	}
+
+	counts, _ := store.ProjectTaskCounts(ctx, time.Now())
+	if c := counts[project.ID]; c.Open != 0 {
+		t.Errorf("expected waiting tasks out of the open count, got %+v", c)
+	}
+	waiting, _ := store.ListOpenTasks(ctx, TaskFilter{Waiting: true})
+	if len(waiting) != 2 {
+		t.Errorf("expected 2 waiting tasks, got %d", len(waiting))
+	}
+
+	report.WaitingOn = ""
+	store.UpdateTask(ctx, report)
+	if got, _ := store.GetTask(ctx, report.ID); got.WaitingSince != nil {
+		t.Errorf("expected the wait to end, got %v", got.WaitingSince)
+	}
+	if counts, _ := store.ProjectTaskCounts(ctx, time.Now()); counts[project.ID].Open != 1 {
+		t.Errorf("expected the task back in the open count, got %+v", counts[project.ID])
+	}
+}
+
+func TestTaskLinks(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	store.CreateProject(ctx, project)
+	login := &models.Task{ProjectID: project.ID, Description: "Fix login", Priority: "high", Status: "todo"}
+	release := &models.Task{ProjectID: project.ID, Description: "Release 2.0", Priority: "medium", Status: "todo"}
+	dupe := &models.Task{ProjectID: project.ID, Description: "Login broken", Priority: "medium", Status: "todo"}
+	for _, task := range []*models.Task{login, release, dupe} {
+		store.CreateTask(ctx, task)
+	}
+
+	blocks := &models.TaskLink{TaskID: login.ID, RelatedTaskID: release.ID, Relation: models.RelationBlocks}
+	if err := store.CreateTaskLink(ctx, blocks); err != nil {
+		t.Fatalf("CreateTaskLink failed: %v", err)
+	}
+	if err := store.CreateTaskLink(ctx, &models.TaskLink{TaskID: dupe.ID, RelatedTaskID: login.ID, Relation: models.RelationRelated}); err != nil {
+		t.Fatalf("CreateTaskLink failed: %v", err)
+	}
+	// Linking again from the other end replaces the link.
+	if err := store.CreateTaskLink(ctx, &models.TaskLink{TaskID: login.ID, RelatedTaskID: dupe.ID, Relation: models.RelationDuplicate}); err != nil {
+		t.Fatalf("CreateTaskLink failed: %v", err)
+	}
+	if err := store.CreateTaskLink(ctx, &models.TaskLink{TaskID: login.ID, RelatedTaskID: 999, Relation: models.RelationRelated}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing task, got %v", err)
+	}
+
+	links, err := store.ListTaskLinks(ctx, login.ID)
+	if err != nil {
+		t.Fatalf("ListTaskLinks failed: %v", err)
+	}
+	if len(links) != 2 || links[0].Label() != "Blocks" || links[0].RelatedDescription != "Release 2.0" || links[1].Label() != "Duplicate of" {
+		t.Fatalf("expected Blocks Release 2.0 and Duplicate of Login broken, got %+v", links)
+	}
+	links, _ = store.ListTaskLinks(ctx, release.ID)
+	if len(links) != 1 || links[0].Label() != "Blocked by" || links[0].RelatedTaskID != login.ID || links[0].RelatedProject != "Work" {
+		t.Errorf("expected Release 2.0 to be blocked by Fix login, got %+v", links)
+	}
+
+	if err := store.DeleteTaskLink(ctx, blocks.ID); err != nil {
+		t.Fatalf("DeleteTaskLink failed: %v", err)
+	}
+	if err := store.DeleteTaskLink(ctx, blocks.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	store.DeleteTask(ctx, dupe.ID)
+	if links, _ := store.ListTaskLinks(ctx, login.ID); len(links) != 0 {
+		t.Errorf("expected links to go with their tasks, got %+v", links)
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	store.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "File taxes", Priority: "medium", Status: "todo"}
+	store.CreateTask(ctx, task)
+
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	first := &models.Notification{Kind: models.NotificationReminder, Message: "Overdue: File taxes", TaskID: &task.ID, ProjectID: &project.ID, CreatedAt: start}
+	second := &models.Notification{Kind: models.NotificationImport, Message: "Import finished", CreatedAt: start.Add(time.Hour)}
+	for _, n := range []*models.Notification{first, second} {
+		if err := store.CreateNotification(ctx, n); err != nil {
+			t.Fatalf("CreateNotification failed: %v", err)
+		}
+	}
+
+	list, err := store.ListNotifications(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListNotifications failed: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != second.ID || list[1].ID != first.ID {
+		t.Fatalf("expected newest first, got %+v", list)
+	}
+	if list[1].TaskID == nil || *list[1].TaskID != task.ID || list[0].TaskID != nil {
+		t.Errorf("expected only the reminder to point at the task, got %+v", list)
+	}
+	if unread, _ := store.CountUnreadNotifications(ctx); unread != 2 {
+		t.Errorf("expected 2 unread, got %d", unread)
+	}
+
+	readAt := start.Add(2 * time.Hour)
+	if err := store.MarkNotificationRead(ctx, first.ID, readAt); err != nil {
+		t.Fatalf("MarkNotificationRead failed: %v", err)
+	}
+	store.MarkNotificationRead(ctx, first.ID, readAt.Add(time.Hour))
+	if unread, _ := store.CountUnreadNotifications(ctx); unread != 1 {
+		t.Errorf("expected 1 unread, got %d", unread)
+	}
+	list, _ = store.ListNotifications(ctx, 10)
+	if list[1].ReadAt == nil || !list[1].ReadAt.Equal(readAt) {
+		t.Errorf("expected the first read time to be kept, got %v", list[1].ReadAt)
+	}
+	if err := store.MarkNotificationRead(ctx, 999, readAt); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := store.MarkAllNotificationsRead(ctx, readAt); err != nil {
+		t.Fatalf("MarkAllNotificationsRead failed: %v", err)
+	}
+	if unread, _ := store.CountUnreadNotifications(ctx); unread != 0 {
+		t.Errorf("expected none unread, got %d", unread)
+	}
+
+	// Purging the task keeps the notification, without its link.
+	if _, err := store.db.ExecContext(ctx, `DELETE FROM tasks WHERE id = ?`, task.ID); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	list, _ = store.ListNotifications(ctx, 1)
+	if len(list) != 1 {
+		t.Fatalf("expected a limit of 1 to list one notification, got %d", len(list))
+	}
+	list, _ = store.ListNotifications(ctx, 10)
+	if len(list) != 2 || list[1].TaskID != nil {
+		t.Errorf("expected the reminder to outlive its task, got %+v", list)
+	}
+}
+
+func TestListNotificationsPage(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	var ids []int64
+	for i, at := range []time.Time{start, start.Add(time.Hour), start.Add(time.Hour), start.Add(2 * time.Hour)} {
+		n := &models.Notification{Kind: models.NotificationImport, Message: fmt.Sprintf("Import %d", i), CreatedAt: at}
+		if err := store.CreateNotification(ctx, n); err != nil {
+			t.Fatalf("CreateNotification failed: %v", err)
+		}
+		ids = append(ids, n.ID)
+	}
+
+	var got []int64
+	var after *Keyset
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("expected to run out of pages, got %v", got)
+		}
+		list, next, err := store.ListNotificationsPage(ctx, after, 3)
+		if err != nil {
+			t.Fatalf("ListNotificationsPage failed: %v", err)
+		}
+		for _, n := range list {
+			got = append(got, n.ID)
+		}
+		if next == nil {
+			break
+		}
+		after = next
+	}
+	want := []int64{ids[3], ids[2], ids[1], ids[0]}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v, newest first across pages, got %v", want, got)
+	}
+
+	// A notification added after the first page was read doesn't shift the
+	// next one.
+	first, next, _ := store.ListNotificationsPage(ctx, nil, 2)
+	newer := &models.Notification{Kind: models.NotificationImport, Message: "Newer", CreatedAt: start.Add(3 * time.Hour)}
+	store.CreateNotification(ctx, newer)
+	rest, _, _ := store.ListNotificationsPage(ctx, next, 2)
+	if len(first) != 2 || len(rest) != 2 || rest[0].ID != ids[1] {
+		t.Errorf("expected the second page to start after the first, got %+v then %+v", first, rest)
+	}
+}
+
+func TestProjectNotificationPrefs(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Work", Type: "project"}
+	store.CreateProject(ctx, project)
+
+	prefs, err := store.GetProjectNotificationPrefs(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("GetProjectNotificationPrefs failed: %v", err)
+	}
+	if *prefs != models.DefaultProjectNotificationPrefs(project.ID) {
+		t.Errorf("expected every channel on before saving, got %+v", prefs)
+	}
+	if _, err := store.GetProjectNotificationPrefs(ctx, 999); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing project, got %v", err)
+	}
+
+	saved := models.ProjectNotificationPrefs{ProjectID: project.ID, Email: false, Slack: true, Inbox: true}
+	if err := store.SaveProjectNotificationPrefs(ctx, &saved); err != nil {
+		t.Fatalf("SaveProjectNotificationPrefs failed: %v", err)
+	}
+	saved.Muted = true
+	if err := store.SaveProjectNotificationPrefs(ctx, &saved); err != nil {
+		t.Fatalf("SaveProjectNotificationPrefs failed: %v", err)
+	}
+	if prefs, _ := store.GetProjectNotificationPrefs(ctx, project.ID); *prefs != saved {
+		t.Errorf("expected %+v, got %+v", saved, prefs)
+	}
+	if err := store.SaveProjectNotificationPrefs(ctx, &models.ProjectNotificationPrefs{ProjectID: 999}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound saving for a missing project, got %v", err)
+	}
+
+	all, err := store.ListNotificationPrefs(ctx)
+	if err != nil {
+		t.Fatalf("ListNotificationPrefs failed: %v", err)
+	}
+	if len(all) != 1 || all.Allows(project.ID, models.ChannelInbox) || !all.Allows(project.ID+1, models.ChannelEmail) {
+		t.Errorf("expected only the muted project to be held back, got %+v", all)
+	}
+}
+
+func TestListRecentlyChanged(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	store.CreateProject(ctx, project)
+	fence := &models.Task{ProjectID: project.ID, Description: "Paint fence", Priority: "medium", Status: "todo"}
+	store.CreateTask(ctx, fence)
+	gutters := &models.Task{ProjectID: project.ID, Description: "Clean gutters", Priority: "low", Status: "todo"}
+	store.CreateTask(ctx, gutters)
+	trash := &models.Task{ProjectID: project.ID, Description: "Old errand", Priority: "low", Status: "todo"}
+	store.CreateTask(ctx, trash)
+	if err := store.DeleteTask(ctx, trash.ID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+
+	due := time.Now().AddDate(0, 0, 3)
+	fence.Notes = "White, two coats"
+	fence.DueDate = &due
+	if err := store.UpdateTask(ctx, fence); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+
+	items, err := store.ListRecentlyChanged(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListRecentlyChanged failed: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("expected 2 tasks and the project without the deleted task, got %+v", items)
+	}
+	first := items[0]
+	if first.Kind != "task" || first.ID != fence.ID || first.ProjectName != "Home" {
+		t.Fatalf("expected the edited task first, got %+v", first)
+	}
+	if strings.Join(first.Changed, ",") != "notes,due date" {
+		t.Errorf("expected notes and due date changed, got %q", first.Changed)
+	}
+	if items[1].ID != gutters.ID || !items[1].New() {
+		t.Errorf("expected the untouched task next, as new, got %+v", items[1])
+	}
+	if items[2].Kind != "project" || items[2].Name != "Home" {
+		t.Errorf("expected the project last, got %+v", items[2])
+	}
+
+	project.Name = "House"
+	if err := store.UpdateProject(ctx, project); err != nil {
+		t.Fatalf("UpdateProject failed: %v", err)
+	}
+	items, err = store.ListRecentlyChanged(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListRecentlyChanged failed: %v", err)
+	}
+	if len(items) != 1 || items[0].Kind != "project" || strings.Join(items[0].Changed, ",") != "name" {
+		t.Errorf("expected only the renamed project, got %+v", items)
+	}
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	old := time.Now().AddDate(0, 0, -40)
+	delivered := &models.WebhookDelivery{Target: "slack:T1", Event: "overdue alert", URL: "https://hooks.example/1", Payload: `{}`, CreatedAt: old}
+	pending := &models.WebhookDelivery{Target: "slack:T1", Event: "overdue alert", URL: "https://hooks.example/1", Payload: `{}`, CreatedAt: old}
+	for _, d := range []*models.WebhookDelivery{delivered, pending} {
+		if err := store.CreateWebhookDelivery(ctx, d); err != nil {
+			t.Fatalf("CreateWebhookDelivery failed: %v", err)
+		}
+	}
+
+	next := time.Now().Add(time.Hour)
+	pending.NextAttemptAt = &next
+	if err := store.RecordWebhookAttempt(ctx, pending, &models.WebhookAttempt{At: time.Now(), StatusCode: 503, Latency: 1500 * time.Millisecond, Response: "busy"}); err != nil {
+		t.Fatalf("RecordWebhookAttempt failed: %v", err)
+	}
+	delivered.Status = models.WebhookDelivered
+	if err := store.RecordWebhookAttempt(ctx, delivered, &models.WebhookAttempt{At: time.Now(), StatusCode: 200}); err != nil {
+		t.Fatalf("RecordWebhookAttempt failed: %v", err)
+	}
+
+	got, err := store.GetWebhookDelivery(ctx, pending.ID)
+	if err != nil {
+		t.Fatalf("GetWebhookDelivery failed: %v", err)
+	}
+	if len(got.Attempts) != 1 || got.Attempts[0].Latency != 1500*time.Millisecond || got.Attempts[0].Response != "busy" {
+		t.Errorf("unexpected attempts %+v", got.Attempts)
+	}
+	if due, _ := store.ListDueWebhookDeliveries(ctx, time.Now()); len(due) != 0 {
+		t.Errorf("expected nothing due before the next attempt, got %+v", due)
+	}
+	if due, _ := store.ListDueWebhookDeliveries(ctx, next); len(due) != 1 || due[0].ID != pending.ID {
+		t.Errorf("expected the pending delivery due, got %+v", due)
+	}
+
+	// Pending deliveries are kept however old they are.
+	n, err := store.DeleteWebhookDeliveriesBefore(ctx, time.Now().AddDate(0, 0, -30))
+	if err != nil || n != 1 {
+		t.Fatalf("DeleteWebhookDeliveriesBefore = %d, %v; want 1", n, err)
+	}
+	if _, err := store.GetWebhookDelivery(ctx, delivered.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the delivered delivery gone, got %v", err)
+	}
+	if list, _ := store.ListWebhookDeliveries(ctx, 10); len(list) != 1 || list[0].ID != pending.ID {
+		t.Errorf("expected only the pending delivery left, got %+v", list)
+	}
+}
+
+func TestReportSchedules(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	rs := &models.ReportSchedule{Recipients: []string{"me@example.com", "boss@example.com"}, Schedule: "0 16 * * 5", Days: 7}
+	if err := store.CreateReportSchedule(ctx, rs); err != nil {
+		t.Fatalf("CreateReportSchedule failed: %v", err)
+	}
+
+	sent := time.Now()
+	rs.LastRunAt, rs.LastError = &sent, "slow relay"
+	if err := store.RecordReportRun(ctx, rs); err != nil {
+		t.Fatalf("RecordReportRun failed: %v", err)
+	}
+	got, err := store.GetReportSchedule(ctx, rs.ID)
+	if err != nil {
+		t.Fatalf("GetReportSchedule failed: %v", err)
+	}
+	if strings.Join(got.Recipients, ",") != "me@example.com,boss@example.com" || got.Days != 7 || got.LastRunAt == nil || got.LastError != "slow relay" {
+		t.Errorf("unexpected schedule %+v", got)
+	}
+
+	if err := store.DeleteReportSchedule(ctx, rs.ID); err != nil {
+		t.Fatalf("DeleteReportSchedule failed: %v", err)
+	}
+	if _, err := store.GetReportSchedule(ctx, rs.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after deleting, got %v", err)
+	}
+	if err := store.RecordReportRun(ctx, rs); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound recording a deleted schedule, got %v", err)
+	}
+}
+
+func TestSavedFilters(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	urgent := &models.SavedFilter{Name: "urgent", Query: "priority = high"}
+	if err := store.SaveFilter(ctx, urgent); err != nil {
+		t.Fatalf("SaveFilter failed: %v", err)
+	}
+	if err := store.SaveFilter(ctx, &models.SavedFilter{Name: "Errands", Query: "tag = errand"}); err != nil {
+		t.Fatalf("SaveFilter failed: %v", err)
+	}
+	again := &models.SavedFilter{Name: "urgent", Query: "priority = high AND due <= today"}
+	if err := store.SaveFilter(ctx, again); err != nil {
+		t.Fatalf("SaveFilter failed: %v", err)
+	}
+	if again.ID != urgent.ID {
+		t.Errorf("expected saving under the same name to replace filter %d, got %d", urgent.ID, again.ID)
+	}
+
+	filters, err := store.ListSavedFilters(ctx)
+	if err != nil {
+		t.Fatalf("ListSavedFilters failed: %v", err)
+	}
+	if len(filters) != 2 || filters[0].Name != "Errands" || filters[1].Query != "priority = high AND due <= today" {
+		t.Fatalf("unexpected filters %+v", filters)
+	}
+
+	if err := store.DeleteSavedFilter(ctx, urgent.ID); err != nil {
+		t.Fatalf("DeleteSavedFilter failed: %v", err)
+	}
+	if err := store.DeleteSavedFilter(ctx, urgent.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+	if filters, _ := store.ListSavedFilters(ctx); len(filters) != 1 {
+		t.Errorf("expected one filter left, got %+v", filters)
+	}
+}
+
+func TestUpdateAtRevision(t *testing.T) {
+	store := setupTestDB(t)
+	ctx := context.Background()
+
+	project := &models.Project{Name: "Home", Type: "project"}
+	store.CreateProject(ctx, project)
+	task := &models.Task{ProjectID: project.ID, Description: "Water plants", Priority: "medium", Status: "todo"}
+	store.CreateTask(ctx, task)
+
+	read := task.Revision
+	task.Description = "Water the ferns"
+	if err := store.UpdateTaskAtRevision(ctx, task, read); err != nil {
+		t.Fatalf("UpdateTaskAtRevision failed: %v", err)
+	}
+	task.Description = "Water the cactus"
+	if err := store.UpdateTaskAtRevision(ctx, task, read); !errors.Is(err, ErrStale) {
+		t.Errorf("expected ErrStale for an older revision, got %v", err)
+	}
+	if got, _ := store.GetTask(ctx, task.ID); got.Description != "Water the ferns" {
+		t.Errorf("expected the stale update to be refused, got %q", got.Description)
+	}
+
+	read = project.Revision
+	project.Name = "House"
+	if err := store.UpdateProjectAtRevision(ctx, project, read); err != nil {
+		t.Fatalf("UpdateProjectAtRevision failed: %v", err)
+	}
+	project.Name = "Flat"
+	if err := store.UpdateProjectAtRevision(ctx, project, read); !errors.Is(err, ErrStale) {
+		t.Errorf("expected ErrStale for an older revision, got %v", err)
+	}
+}
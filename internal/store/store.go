@@ -2,11 +2,82 @@ package store
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"mytasks/internal/models"
+	"mytasks/internal/taskql"
 )
 
+// ErrNotFound is returned (wrapped) when a requested record does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrStale is returned (wrapped) by the updates guarded by a revision when
+// the record has moved past it.
+var ErrStale = errors.New("changed since it was read")
+
+// ErrInvalidKeyset is returned (wrapped) when a Keyset does not fit the list
+// it is used with.
+var ErrInvalidKeyset = errors.New("invalid keyset")
+
+// Keyset marks where a page of a list ended: the sort key and ID of its last
+// row. The next page starts right after it, so rows added or removed in
+// between don't shift the pages the way offsets do. Key is the sort column's
+// raw text; a zero Keyset is not valid, use nil for the first page.
+type Keyset struct {
+	Key string
+	ID  int64
+}
+
+// Due date filters for TaskFilter.Due.
+const (
+	DueOverdue  = "overdue"
+	DueToday    = "today"
+	DueThisWeek = "week" // today and the six days after
+	DueNone     = "none"
+)
+
+// Sort orders for TaskFilter.Sort.
+const (
+	SortDueDate  = "due"
+	SortPriority = "priority"
+	SortProject  = "project"
+)
+
+// Report groupings for ReportCounts.
+const (
+	GroupByProject  = "project"
+	GroupByPriority = "priority"
+	GroupByWeek     = "week"
+)
+
+// TaskFilter narrows task list queries. Zero values match everything.
+type TaskFilter struct {
+	ProjectID int64
+	Priority  string
+	Status    string
+	// Search matches a substring of the description or notes.
+	Search string
+	Due    string
+	// HasNotes keeps only tasks with notes.
+	HasNotes bool
+	// Waiting keeps only tasks waiting on someone.
+	Waiting bool
+	// CompletedSince drops tasks completed before it. Tasks with no
+	// completion date, including legacy done ones, are kept.
+	CompletedSince *time.Time
+	// SortMode, one of models.SortModes, orders ListProjectTasks instead of
+	// the project's own sort mode.
+	SortMode string
+	// Sort is one of the Sort constants; it defaults to SortDueDate.
+	Sort string
+	// Someday lists only the tasks parked in Someday/Maybe, which are
+	// otherwise left out.
+	Someday bool
+	// Match keeps only the tasks a filter expression matches.
+	Match *taskql.Query
+}
+
 // Store defines the interface for data persistence operations.
 type Store interface {
 	// Project operations
@@ -15,31 +86,203 @@ type Store interface {
 	ListProjects(ctx context.Context) ([]models.Project, error)
 	ListActiveProjects(ctx context.Context) ([]models.Project, error)
 	ListCompletedProjects(ctx context.Context) ([]models.Project, error)
+	ListProjectsFiltered(ctx context.Context, completed bool, limit int) ([]models.Project, error)
 	UpdateProject(ctx context.Context, project *models.Project) error
+	UpdateProjectAtRevision(ctx context.Context, project *models.Project, revision int64) error
 	MarkProjectComplete(ctx context.Context, id int64) error
 	MarkProjectIncomplete(ctx context.Context, id int64) error
+	ResetChecklist(ctx context.Context, projectID int64, at time.Time) (int64, error)
 	DeleteProject(ctx context.Context, id int64) error
 	ReorderProjects(ctx context.Context, ids []int64) error
+	SetProjectSortMode(ctx context.Context, projectID int64, mode string) error
+	ProjectTaskCounts(ctx context.Context, today time.Time) (map[int64]models.ProjectCounts, error)
+
+	// Project links, shown in a project's Resources panel
+	CreateProjectLink(ctx context.Context, link *models.ProjectLink) error
+	GetProjectLink(ctx context.Context, id int64) (*models.ProjectLink, error)
+	ListProjectLinks(ctx context.Context, projectID int64) ([]models.ProjectLink, error)
+	UpdateProjectLink(ctx context.Context, link *models.ProjectLink) error
+	DeleteProjectLink(ctx context.Context, id int64) error
 
 	// Task operations
 	CreateTask(ctx context.Context, task *models.Task) error
+	CreateTasks(ctx context.Context, tasks []*models.Task) error
 	GetTask(ctx context.Context, id int64) (*models.Task, error)
-	ListTasks(ctx context.Context, completedSince *time.Time) ([]models.Task, error)
+	ListTasks(ctx context.Context, completedSince *time.Time, match *taskql.Query) ([]models.Task, error)
+	ListTasksAfter(ctx context.Context, afterID int64, limit int) ([]models.Task, error)
+	ListTasksPage(ctx context.Context, completedSince *time.Time, match *taskql.Query, after *Keyset, limit int) ([]models.Task, *Keyset, error)
 	ListTasksByProject(ctx context.Context, projectID int64, limit int) ([]models.Task, error)
 	ListTasksByProjectFiltered(ctx context.Context, projectID int64, completed bool, limit int) ([]models.Task, error)
-	ListTasksByProjectCompletedBetween(ctx context.Context, projectID int64, from, to *time.Time, limit int) ([]models.Task, error)
+	ListTasksByProjectCompletedBetween(ctx context.Context, projectID int64, from, to *time.Time, limit, offset int) ([]models.Task, error)
+	CountTasksByProjectCompletedBetween(ctx context.Context, projectID int64, from, to *time.Time) (int, error)
 	ListTasksByProjectAndStatus(ctx context.Context, projectID int64, status string) ([]models.Task, error)
 	ListRecentDoneTasks(ctx context.Context, projectID int64, since time.Time) ([]models.Task, error)
 	ListOldDoneTasks(ctx context.Context, projectID int64, before time.Time) ([]models.Task, error)
 	ListActiveProjectsWithOldDoneTasks(ctx context.Context, before time.Time) ([]models.Project, error)
 	ListUpcomingTasks(ctx context.Context, days int) ([]models.Task, error)
+	ListTasksDueBetween(ctx context.Context, start, end time.Time) ([]models.Task, error)
+	ListOpenTasks(ctx context.Context, filter TaskFilter) ([]models.Task, error)
+	ListProjectTasks(ctx context.Context, filter TaskFilter) ([]models.Task, error)
 	UpdateTask(ctx context.Context, task *models.Task) error
+	UpdateTaskAtRevision(ctx context.Context, task *models.Task, revision int64) error
+	SetTaskRecurrence(ctx context.Context, taskID int64, rule string) error
+	ListTaskRecurrences(ctx context.Context) (map[int64]string, error)
 	DeleteTask(ctx context.Context, id int64) error
+	SoftDeleteTask(ctx context.Context, id int64, token string, at time.Time) error
+	RestoreTask(ctx context.Context, token string, since time.Time) (int64, error)
+	PurgeDeletedTasks(ctx context.Context, before time.Time) (int64, error)
 	ToggleTaskComplete(ctx context.Context, id int64) error
+	SetTaskSomeday(ctx context.Context, id int64, someday bool) (*models.Task, error)
 	MoveTaskToStatus(ctx context.Context, taskID int64, newStatus string, newSortOrder int) error
 	ReorderTasks(ctx context.Context, projectID int64, ids []int64) error
 	ReorderTasksInStatus(ctx context.Context, projectID int64, status string, ids []int64) error
+	MoveTaskToProject(ctx context.Context, taskID, projectID int64) error
+	ListTaskTags(ctx context.Context) (map[int64][]string, error)
+	EscalatePriorities(ctx context.Context, days int, today time.Time) error
+
+	// Task page: comments, subtasks, attachments and edit history
+	CreateTaskComment(ctx context.Context, comment *models.TaskComment) error
+	ListTaskComments(ctx context.Context, taskID int64) ([]models.TaskComment, error)
+	DeleteTaskComment(ctx context.Context, id int64) error
+	CreateSubtask(ctx context.Context, subtask *models.Subtask) error
+	ListSubtasks(ctx context.Context, taskID int64) ([]models.Subtask, error)
+	ToggleSubtask(ctx context.Context, id int64) (*models.Subtask, error)
+	DeleteSubtask(ctx context.Context, id int64) error
+	CreateTaskAttachment(ctx context.Context, attachment *models.TaskAttachment) error
+	ListTaskAttachments(ctx context.Context, taskID int64) ([]models.TaskAttachment, error)
+	GetTaskAttachment(ctx context.Context, id int64) (*models.TaskAttachment, error)
+	DeleteTaskAttachment(ctx context.Context, id int64) error
+	ListTaskHistory(ctx context.Context, taskID int64) ([]models.TaskHistoryEntry, error)
+	ListTaskCompletions(ctx context.Context, taskID int64) ([]models.TaskCompletion, error)
+
+	// External references (import/sync)
+	GetTaskIDByExternalRef(ctx context.Context, source, externalID string) (int64, error)
+	GetTaskExternalRef(ctx context.Context, source string, taskID int64) (string, error)
+	SetTaskExternalRef(ctx context.Context, source, externalID string, taskID int64) error
+	ListTaskExternalRefs(ctx context.Context, source string) (map[int64]string, error)
+
+	// Slack integration
+	RegisterSlackWorkspace(ctx context.Context, teamID, teamName string) error
+	GetSlackWorkspace(ctx context.Context, teamID string) (*models.SlackWorkspace, error)
+	ListSlackWorkspaces(ctx context.Context) ([]models.SlackWorkspace, error)
+	UpdateSlackWorkspace(ctx context.Context, ws *models.SlackWorkspace) error
+
+	// Overdue notifications
+	ListOverdueTasksPendingNotification(ctx context.Context, channel string, today time.Time) ([]models.Task, error)
+	MarkOverdueNotified(ctx context.Context, taskID int64, channel string, dueDate time.Time) error
+
+	// Google Tasks sync
+	GetGoogleTasksAccount(ctx context.Context) (*models.GoogleTasksAccount, error)
+	SaveGoogleTasksAccount(ctx context.Context, account *models.GoogleTasksAccount) error
+	DeleteGoogleTasksAccount(ctx context.Context) error
+
+	// Google Calendar sync
+	GetGoogleCalendarAccount(ctx context.Context) (*models.GoogleCalendarAccount, error)
+	SaveGoogleCalendarAccount(ctx context.Context, account *models.GoogleCalendarAccount) error
+	DeleteGoogleCalendarAccount(ctx context.Context) error
+	ListCalendarEvents(ctx context.Context) ([]models.CalendarEvent, error)
+	SaveCalendarEvent(ctx context.Context, event *models.CalendarEvent) error
+	DeleteCalendarEvent(ctx context.Context, taskID int64) error
+
+	// GitHub issue sync
+	SaveGitHubLink(ctx context.Context, link *models.GitHubLink) error
+	GetGitHubLink(ctx context.Context, projectID int64) (*models.GitHubLink, error)
+	GetGitHubLinkByRepo(ctx context.Context, repo string) (*models.GitHubLink, error)
+	ListGitHubLinks(ctx context.Context) ([]models.GitHubLink, error)
+	DeleteGitHubLink(ctx context.Context, projectID int64) error
+
+	// Weekly review
+	RecordProjectReview(ctx context.Context, projectID int64, reviewedAt time.Time) error
+	ListLastProjectReviews(ctx context.Context) (map[int64]time.Time, error)
+
+	// Stats
+	ListDailyCompletionCounts(ctx context.Context) ([]models.DailyCount, error)
+	ReportCounts(ctx context.Context, from, to time.Time, groupBy string, weekStart time.Weekday) ([]models.ReportRow, error)
+
+	// Reports emailed on a schedule
+	CreateReportSchedule(ctx context.Context, rs *models.ReportSchedule) error
+	ListReportSchedules(ctx context.Context) ([]models.ReportSchedule, error)
+	GetReportSchedule(ctx context.Context, id int64) (*models.ReportSchedule, error)
+	RecordReportRun(ctx context.Context, rs *models.ReportSchedule) error
+	DeleteReportSchedule(ctx context.Context, id int64) error
+
+	// Filter expressions saved for the All Tasks list
+	SaveFilter(ctx context.Context, f *models.SavedFilter) error
+	ListSavedFilters(ctx context.Context) ([]models.SavedFilter, error)
+	DeleteSavedFilter(ctx context.Context, id int64) error
+
+	// Habits, checked in on the days they were done
+	CreateHabit(ctx context.Context, habit *models.Habit) error
+	ListHabits(ctx context.Context) ([]models.Habit, error)
+	DeleteHabit(ctx context.Context, id int64) error
+	ToggleHabitCheckIn(ctx context.Context, habitID int64, day time.Time) (bool, error)
+
+	// Task links, between related or duplicate tasks
+	CreateTaskLink(ctx context.Context, link *models.TaskLink) error
+	ListTaskLinks(ctx context.Context, taskID int64) ([]models.TaskLink, error)
+	DeleteTaskLink(ctx context.Context, id int64) error
+
+	// My Day: tasks planned for a day, apart from their due dates
+	AddToDayPlan(ctx context.Context, day time.Time, taskIDs ...int64) error
+	RemoveFromDayPlan(ctx context.Context, day time.Time, taskID int64) error
+	ListDayPlan(ctx context.Context, day time.Time) ([]models.Task, error)
+	ListDayPlanCarryover(ctx context.Context, day time.Time) (time.Time, []models.Task, error)
+
+	// Focus sessions, timing work on one task at a time
+	StartFocusSession(ctx context.Context, taskID int64, at time.Time) (*models.FocusSession, error)
+	StopFocusSession(ctx context.Context, at time.Time) (*models.FocusSession, error)
+	GetRunningFocusSession(ctx context.Context) (*models.FocusSession, error)
+	ListFocusSessions(ctx context.Context, taskID int64, from, to time.Time) ([]models.FocusSession, error)
+
+	// In-app notification inbox
+	CreateNotification(ctx context.Context, n *models.Notification) error
+	ListNotifications(ctx context.Context, limit int) ([]models.Notification, error)
+	ListNotificationsPage(ctx context.Context, after *Keyset, limit int) ([]models.Notification, *Keyset, error)
+	CountUnreadNotifications(ctx context.Context) (int, error)
+	MarkNotificationRead(ctx context.Context, id int64, at time.Time) error
+	MarkAllNotificationsRead(ctx context.Context, at time.Time) error
+	GetProjectNotificationPrefs(ctx context.Context, projectID int64) (*models.ProjectNotificationPrefs, error)
+	SaveProjectNotificationPrefs(ctx context.Context, prefs *models.ProjectNotificationPrefs) error
+	ListNotificationPrefs(ctx context.Context) (models.NotificationPrefs, error)
+
+	// Outgoing webhook deliveries
+	CreateWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, limit int) ([]models.WebhookDelivery, error)
+	ListDueWebhookDeliveries(ctx context.Context, now time.Time) ([]models.WebhookDelivery, error)
+	RecordWebhookAttempt(ctx context.Context, d *models.WebhookDelivery, attempt *models.WebhookAttempt) error
+	DeleteWebhookDeliveriesBefore(ctx context.Context, before time.Time) (int64, error)
+
+	// Recent view
+	ListRecentlyChanged(ctx context.Context, limit int) ([]models.RecentItem, error)
+
+	// Settings
+	GetSetting(ctx context.Context, key string) (string, error)
+	SetSetting(ctx context.Context, key, value string) error
+	GetSettings(ctx context.Context) (*models.Settings, error)
+	SaveSettings(ctx context.Context, settings *models.Settings) error
+
+	// Token authentication throttling
+	GetAuthFailure(ctx context.Context, scope, client string) (*models.AuthFailure, error)
+	SaveAuthFailure(ctx context.Context, failure *models.AuthFailure) error
+	ClearAuthFailures(ctx context.Context, scope, client string) error
+
+	// Offline sync
+	ListChanges(ctx context.Context, since int64, limit int) ([]models.Change, error)
+	SyncMutationApplied(ctx context.Context, id string) (bool, error)
+	RecordSyncMutation(ctx context.Context, id string, at time.Time) error
+
+	// Background jobs
+	SaveJobStatus(ctx context.Context, status *models.JobStatus) error
+	ListJobStatuses(ctx context.Context) ([]models.JobStatus, error)
+	PruneJobStatuses(ctx context.Context, keep []string) error
 
-	// Lifecycle
+	// Lifecycle and maintenance
+	Backup(ctx context.Context, path string) error
+	BackupAnonymized(ctx context.Context, path string, scramble func(string) string) error
+	ListMigrations(ctx context.Context) ([]models.Migration, error)
+	Checkpoint(ctx context.Context) error
+	Cleanup(ctx context.Context, now time.Time) (*models.CleanupResult, error)
+	Reset(ctx context.Context) error
 	Close() error
 }
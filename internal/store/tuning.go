@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Tuning sets SQLite pragmas on every connection. The zero value of a field
+// leaves SQLite's default, except that DefaultTuning waits 5s for locks.
+type Tuning struct {
+	// CacheSize is the page cache size: pages if positive, KiB if negative,
+	// as in PRAGMA cache_size. -64000 is about 64 MB.
+	CacheSize int
+	// Synchronous is "off", "normal", "full" or "extra". "normal" is safe
+	// with the write-ahead log and much faster on slow disks.
+	Synchronous string
+	// TempStore is "default", "file" or "memory", for temporary tables and
+	// indexes.
+	TempStore string
+	// MmapSize is the number of bytes of the database to memory-map.
+	MmapSize int64
+	// BusyTimeout is how long to wait for a lock held by another process,
+	// such as a backup tool, before failing.
+	BusyTimeout time.Duration
+}
+
+// DefaultTuning is the tuning NewSQLiteStore uses.
+func DefaultTuning() Tuning {
+	return Tuning{BusyTimeout: 5 * time.Second}
+}
+
+// Validate reports a setting SQLite would not accept.
+func (t Tuning) Validate() error {
+	if !oneOf(t.Synchronous, "", "off", "normal", "full", "extra") {
+		return fmt.Errorf("invalid synchronous %q, want off, normal, full or extra", t.Synchronous)
+	}
+	if !oneOf(t.TempStore, "", "default", "file", "memory") {
+		return fmt.Errorf("invalid temp_store %q, want default, file or memory", t.TempStore)
+	}
+	if t.MmapSize < 0 {
+		return fmt.Errorf("invalid mmap_size %d", t.MmapSize)
+	}
+	if t.BusyTimeout < 0 {
+		return fmt.Errorf("invalid busy_timeout %s", t.BusyTimeout)
+	}
+	return nil
+}
+
+// pragmas returns the statements applying t.
+func (t Tuning) pragmas() []string {
+	pragmas := []string{fmt.Sprintf("PRAGMA busy_timeout = %d", t.BusyTimeout.Milliseconds())}
+	if t.CacheSize != 0 {
+		pragmas = append(pragmas, fmt.Sprintf("PRAGMA cache_size = %d", t.CacheSize))
+	}
+	if t.Synchronous != "" {
+		pragmas = append(pragmas, "PRAGMA synchronous = "+strings.ToUpper(t.Synchronous))
+	}
+	if t.TempStore != "" {
+		pragmas = append(pragmas, "PRAGMA temp_store = "+strings.ToUpper(t.TempStore))
+	}
+	if t.MmapSize != 0 {
+		pragmas = append(pragmas, fmt.Sprintf("PRAGMA mmap_size = %d", t.MmapSize))
+	}
+	return pragmas
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// connector opens connections with the tuning pragmas applied, including
+// connections database/sql opens to replace broken ones.
+type connector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+}
+
+func newConnector(dsn string, t Tuning) *connector {
+	pragmas := t.pragmas()
+	return &connector{dsn: dsn, driver: &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			for _, pragma := range pragmas {
+				if _, err := conn.Exec(pragma, nil); err != nil {
+					return fmt.Errorf("%s: %w", pragma, err)
+				}
+			}
+			return nil
+		},
+	}}
+}
+
+func (c *connector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewTunedSQLiteStore_AppliesPragmas(t *testing.T) {
+	tuning := Tuning{CacheSize: -8000, Synchronous: "normal", TempStore: "memory", MmapSize: 1 << 20, BusyTimeout: 2 * time.Second}
+	store, err := NewTunedSQLiteStore(filepath.Join(t.TempDir(), "tuned.db"), tuning)
+	if err != nil {
+		t.Fatalf("NewTunedSQLiteStore failed: %v", err)
+	}
+	defer store.Close()
+
+	want := map[string]int64{
+		"cache_size":   -8000,
+		"synchronous":  1, // NORMAL
+		"temp_store":   2, // MEMORY
+		"mmap_size":    1 << 20,
+		"busy_timeout": 2000,
+	}
+	for pragma, value := range want {
+		var got int64
+		if err := store.DB().QueryRow("PRAGMA " + pragma).Scan(&got); err != nil {
+			t.Fatalf("PRAGMA %s: %v", pragma, err)
+		}
+		if got != value {
+			t.Errorf("PRAGMA %s = %d, want %d", pragma, got, value)
+		}
+	}
+}
+
+func TestTuningValidate(t *testing.T) {
+	for _, tuning := range []Tuning{
+		{Synchronous: "sometimes"},
+		{TempStore: "disk"},
+		{MmapSize: -1},
+		{BusyTimeout: -time.Second},
+	} {
+		if err := tuning.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", tuning)
+		}
+		if _, err := NewTunedSQLiteStore(":memory:", tuning); err == nil {
+			t.Errorf("expected NewTunedSQLiteStore to reject %+v", tuning)
+		}
+	}
+	if err := (Tuning{Synchronous: "FULL", TempStore: "file"}).Validate(); err != nil {
+		t.Errorf("expected upper-case values to be accepted: %v", err)
+	}
+}
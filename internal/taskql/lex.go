@@ -0,0 +1,103 @@
+package taskql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokKind
+	text string // a string's text is unquoted
+	pos  int
+}
+
+// lex splits src into tokens, ending with tokEOF.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: i})
+			i++
+		case c == '"' || c == '\'':
+			text, n, err := lexString(src[i:])
+			if err != nil {
+				return nil, &Error{Pos: i, Msg: err.Error()}
+			}
+			tokens = append(tokens, token{kind: tokString, text: text, pos: i})
+			i += n
+		case strings.ContainsRune("=!<>~", rune(c)):
+			n := 1
+			if i+1 < len(src) && src[i+1] == '=' && c != '=' && c != '~' {
+				n = 2
+			}
+			text := src[i : i+n]
+			if text == "!" {
+				return nil, &Error{Pos: i, Msg: `expected "!="`}
+			}
+			tokens = append(tokens, token{kind: tokOp, text: text, pos: i})
+			i += n
+		default:
+			start := i
+			for i < len(src) && isWordByte(src[i]) {
+				i++
+			}
+			if i == start {
+				return nil, &Error{Pos: i, Msg: fmt.Sprintf("unexpected %q", src[i:i+1])}
+			}
+			tokens = append(tokens, token{kind: tokWord, text: src[start:i], pos: start})
+		}
+	}
+	return append(tokens, token{kind: tokEOF, text: "end of filter", pos: len(src)}), nil
+}
+
+// lexString reads a string quoted with its first byte, where a backslash
+// escapes the next character, and returns its text and length in src.
+func lexString(src string) (string, int, error) {
+	quote := src[0]
+	var b strings.Builder
+	for i := 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			if i+1 < len(src) {
+				i++
+				b.WriteByte(src[i])
+			}
+		case quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(src[i])
+		}
+	}
+	return "", 0, errors.New("unterminated string")
+}
+
+// isWordByte reports whether c can be part of a bare word: letters, digits
+// and the punctuation of dates, times and tags. Bytes of multi-byte UTF-8
+// characters count as letters.
+func isWordByte(c byte) bool {
+	return c >= 0x80 || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)) || strings.IndexByte("_-.:/@#+", c) >= 0
+}
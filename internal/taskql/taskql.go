@@ -0,0 +1,407 @@
+// Package taskql parses the task filter language used by GET /api/tasks and
+// the All Tasks list, such as
+//
+//	completed=false AND priority IN (high,medium) AND due<=2025-07-01
+//
+// into a parameterized SQL condition. Conditions compare a field with a
+// value, and combine with AND, OR, NOT and parentheses; AND binds tighter
+// than OR. Values are bare words or quoted strings, so user input never
+// reaches the SQL text.
+package taskql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"mytasks/internal/nldate"
+)
+
+// Error is a mistake in a filter, at byte offset Pos.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos+1)
+}
+
+// Query is a parsed filter.
+type Query struct {
+	src   string
+	where string
+	args  []interface{}
+}
+
+// String returns the filter as written.
+func (q *Query) String() string {
+	return q.src
+}
+
+// SQL returns the filter as a condition on tasks aliased as t, with the
+// arguments for its placeholders.
+func (q *Query) SQL() (string, []interface{}) {
+	return q.where, append([]interface{}(nil), q.args...)
+}
+
+// Parse reads a filter. now is the day relative dates such as "today" or
+// "next friday" are counted from.
+func Parse(src string, now time.Time) (*Query, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, now: now}
+	if p.peek().kind == tokEOF {
+		return nil, &Error{Pos: 0, Msg: "empty filter"}
+	}
+	where, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+	return &Query{src: src, where: where, args: p.args}, nil
+}
+
+type parser struct {
+	tokens []token
+	next   int
+	args   []interface{}
+	now    time.Time
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	t := p.tokens[p.next]
+	if t.kind != tokEOF {
+		p.next++
+	}
+	return t
+}
+
+// keyword takes the next token if it is the keyword kw.
+func (p *parser) keyword(kw string) bool {
+	if t := p.peek(); t.kind == tokWord && strings.EqualFold(t.text, kw) {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(kind tokKind, what string) (token, error) {
+	t := p.take()
+	if t.kind != kind {
+		return t, &Error{Pos: t.pos, Msg: "expected " + what}
+	}
+	return t, nil
+}
+
+func (p *parser) or() (string, error) {
+	left, err := p.and()
+	if err != nil {
+		return "", err
+	}
+	for p.keyword("OR") {
+		right, err := p.and()
+		if err != nil {
+			return "", err
+		}
+		left = "(" + left + " OR " + right + ")"
+	}
+	return left, nil
+}
+
+func (p *parser) and() (string, error) {
+	left, err := p.unary()
+	if err != nil {
+		return "", err
+	}
+	for p.keyword("AND") {
+		right, err := p.unary()
+		if err != nil {
+			return "", err
+		}
+		left = "(" + left + " AND " + right + ")"
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (string, error) {
+	if p.keyword("NOT") {
+		cond, err := p.unary()
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + cond + ")", nil
+	}
+	if p.peek().kind == tokLParen {
+		p.take()
+		cond, err := p.or()
+		if err != nil {
+			return "", err
+		}
+		if _, err := p.expect(tokRParen, `")"`); err != nil {
+			return "", err
+		}
+		return cond, nil
+	}
+	return p.condition()
+}
+
+// condition reads field op value, or field [NOT] IN (values).
+func (p *parser) condition() (string, error) {
+	name, err := p.expect(tokWord, "a field")
+	if err != nil {
+		return "", err
+	}
+	f, ok := fields[strings.ToLower(name.text)]
+	if !ok {
+		return "", &Error{Pos: name.pos, Msg: fmt.Sprintf("unknown field %q", name.text)}
+	}
+
+	not := p.keyword("NOT")
+	if p.keyword("IN") {
+		values, err := p.list()
+		if err != nil {
+			return "", err
+		}
+		op := opIn
+		if not {
+			op = opNotIn
+		}
+		return p.compile(f, name, op, values)
+	}
+	if not {
+		return "", &Error{Pos: p.peek().pos, Msg: `expected "IN"`}
+	}
+
+	opTok, err := p.expect(tokOp, "an operator")
+	if err != nil {
+		return "", err
+	}
+	value, err := p.value()
+	if err != nil {
+		return "", err
+	}
+	return p.compile(f, name, op(opTok.text), []token{value})
+}
+
+func (p *parser) value() (token, error) {
+	t := p.take()
+	if t.kind != tokWord && t.kind != tokString {
+		return t, &Error{Pos: t.pos, Msg: "expected a value"}
+	}
+	return t, nil
+}
+
+// list reads a parenthesized, comma-separated list of values.
+func (p *parser) list() ([]token, error) {
+	if _, err := p.expect(tokLParen, `"("`); err != nil {
+		return nil, err
+	}
+	var values []token
+	for {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		if t := p.take(); t.kind == tokRParen {
+			return values, nil
+		} else if t.kind != tokComma {
+			return nil, &Error{Pos: t.pos, Msg: `expected "," or ")"`}
+		}
+	}
+}
+
+// Operators.
+type op string
+
+const (
+	opEq       op = "="
+	opNe       op = "!="
+	opLt       op = "<"
+	opLe       op = "<="
+	opGt       op = ">"
+	opGe       op = ">="
+	opContains op = "~"
+	opIn       op = "IN"
+	opNotIn    op = "NOT IN"
+)
+
+// compile turns one condition into SQL, adding its arguments.
+func (p *parser) compile(f field, name token, o op, values []token) (string, error) {
+	if !f.allows(o) {
+		return "", &Error{Pos: name.pos, Msg: fmt.Sprintf("%s can't be compared with %s", name.text, o)}
+	}
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		arg, err := f.value(v.text, p.now)
+		if err != nil {
+			return "", &Error{Pos: v.pos, Msg: err.Error()}
+		}
+		args[i] = arg
+	}
+
+	if f.kind == kindBool {
+		cond := f.column
+		if (o == opEq) != args[0].(bool) {
+			cond = "NOT " + cond
+		}
+		return cond, nil
+	}
+	if f.kind == kindDate && args[0] == nil {
+		switch o {
+		case opEq:
+			return f.column + " IS NULL", nil
+		case opNe:
+			return f.column + " IS NOT NULL", nil
+		}
+		return "", &Error{Pos: values[0].pos, Msg: fmt.Sprintf("none can't be compared with %s", o)}
+	}
+
+	column := f.column
+	if f.kind == kindDate {
+		column = "date(" + column + ")"
+	}
+	var cond string
+	switch o {
+	case opIn, opNotIn:
+		cond = column + " " + string(o) + " (" + strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ") + ")"
+	case opContains:
+		cond = column + " LIKE ? ESCAPE '\\'"
+		args[0] = "%" + escapeLike(args[0].(string)) + "%"
+	default:
+		cond = column + " " + string(o) + " ?"
+	}
+	if f.kind == kindText {
+		cond = strings.Replace(cond, column, column+" COLLATE NOCASE", 1)
+	}
+	if f.subquery != "" {
+		// Negations are applied outside the subquery, so tag != x means
+		// the task has no tag x rather than some tag other than x.
+		neg := o == opNe || o == opNotIn
+		switch o {
+		case opNe:
+			cond = strings.Replace(cond, " != ", " = ", 1)
+		case opNotIn:
+			cond = strings.Replace(cond, " NOT IN ", " IN ", 1)
+		}
+		cond = fmt.Sprintf(f.subquery, cond)
+		if neg {
+			cond = "NOT " + cond
+		}
+	}
+	p.args = append(p.args, args...)
+	return cond, nil
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+type kind int
+
+const (
+	kindBool kind = iota
+	kindEnum
+	kindText
+	kindInt
+	kindDate
+)
+
+// field is something a filter can compare.
+type field struct {
+	kind kind
+	// column is the SQL compared, on tasks aliased as t.
+	column string
+	// subquery, if set, wraps the condition on column, as a format with
+	// one %s.
+	subquery string
+	// enum lists the values of a kindEnum field.
+	enum []string
+}
+
+var fields = map[string]field{
+	"completed":    {kind: kindBool, column: "(t.status = 'done')"},
+	"someday":      {kind: kindBool, column: "(t.someday = 1)"},
+	"waiting":      {kind: kindBool, column: "(t.waiting_on != '')"},
+	"priority":     {kind: kindEnum, column: "t.priority", enum: []string{"high", "medium", "low"}},
+	"status":       {kind: kindEnum, column: "t.status", enum: []string{"todo", "in_progress", "done"}},
+	"description":  {kind: kindText, column: "t.description"},
+	"notes":        {kind: kindText, column: "t.notes"},
+	"waiting_on":   {kind: kindText, column: "t.waiting_on"},
+	"project":      {kind: kindText, column: "name", subquery: "t.project_id IN (SELECT id FROM projects WHERE %s)"},
+	"tag":          {kind: kindText, column: "tag", subquery: "EXISTS (SELECT 1 FROM task_tags WHERE task_id = t.id AND %s)"},
+	"id":           {kind: kindInt, column: "t.id"},
+	"project_id":   {kind: kindInt, column: "t.project_id"},
+	"due":          {kind: kindDate, column: "t.due_date"},
+	"completed_at": {kind: kindDate, column: "t.completed_at"},
+	"created":      {kind: kindDate, column: "t.created_at"},
+	"updated":      {kind: kindDate, column: "t.updated_at"},
+}
+
+// allows reports whether the field can be compared with o.
+func (f field) allows(o op) bool {
+	switch f.kind {
+	case kindBool:
+		return o == opEq || o == opNe
+	case kindEnum:
+		return o == opEq || o == opNe || o == opIn || o == opNotIn
+	case kindText:
+		return o == opEq || o == opNe || o == opIn || o == opNotIn || o == opContains
+	case kindDate:
+		return o != opIn && o != opNotIn && o != opContains
+	}
+	return o != opContains
+}
+
+// value reads a value for the field: a bool, a string, an int64, or a date
+// as YYYY-MM-DD, nil for "none".
+func (f field) value(s string, now time.Time) (interface{}, error) {
+	switch f.kind {
+	case kindBool:
+		switch strings.ToLower(s) {
+		case "true", "yes", "1":
+			return true, nil
+		case "false", "no", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("expected true or false, got %q", s)
+	case kindEnum:
+		for _, v := range f.enum {
+			if strings.EqualFold(s, v) {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("expected one of %s, got %q", strings.Join(f.enum, ", "), s)
+	case kindInt:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", s)
+		}
+		return n, nil
+	case kindDate:
+		if strings.EqualFold(s, "none") {
+			return nil, nil
+		}
+		if d, err := time.Parse("2006-01-02", s); err == nil {
+			return d.Format("2006-01-02"), nil
+		}
+		// Relative dates are read as a due date phrase, such as
+		// "today" or "next friday".
+		if r := nldate.Parse("due "+s, now); r.Due != nil && r.Recurrence == "" && r.Text == "due" {
+			return r.Due.Format("2006-01-02"), nil
+		}
+		return nil, fmt.Errorf("expected a date such as 2025-07-01 or today, got %q", s)
+	}
+	return s, nil
+}
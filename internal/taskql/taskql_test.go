@@ -0,0 +1,101 @@
+package taskql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// now is Wednesday 11 June 2025.
+var now = time.Date(2025, 6, 11, 15, 30, 0, 0, time.Local)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		src   string
+		where string
+		args  []interface{}
+	}{
+		{
+			"completed=false AND priority IN (high,medium) AND due<=2025-07-01",
+			"((NOT (t.status = 'done') AND t.priority IN (?, ?)) AND date(t.due_date) <= ?)",
+			[]interface{}{"high", "medium", "2025-07-01"},
+		},
+		{"priority = HIGH", "t.priority = ?", []interface{}{"high"}},
+		{"status not in (done)", "t.status NOT IN (?)", []interface{}{"done"}},
+		{
+			"priority=high OR priority=medium AND due=today",
+			"(t.priority = ? OR (t.priority = ? AND date(t.due_date) = ?))",
+			[]interface{}{"high", "medium", "2025-06-11"},
+		},
+		{
+			"(priority=high OR priority=medium) AND NOT someday=true",
+			"((t.priority = ? OR t.priority = ?) AND NOT ((t.someday = 1)))",
+			[]interface{}{"high", "medium"},
+		},
+		{`due < "next friday"`, "date(t.due_date) < ?", []interface{}{"2025-06-20"}},
+		{"due = none", "t.due_date IS NULL", nil},
+		{"due != none", "t.due_date IS NOT NULL", nil},
+		{`description ~ "50%_off"`, `t.description COLLATE NOCASE LIKE ? ESCAPE '\'`, []interface{}{`%50\%\_off%`}},
+		{`project = 'Home & garden'`, "t.project_id IN (SELECT id FROM projects WHERE name COLLATE NOCASE = ?)", []interface{}{"Home & garden"}},
+		{"tag != errands", "NOT EXISTS (SELECT 1 FROM task_tags WHERE task_id = t.id AND tag COLLATE NOCASE = ?)", []interface{}{"errands"}},
+		{"tag not in (a, b)", "NOT EXISTS (SELECT 1 FROM task_tags WHERE task_id = t.id AND tag COLLATE NOCASE IN (?, ?))", []interface{}{"a", "b"}},
+		{"id >= 10", "t.id >= ?", []interface{}{int64(10)}},
+		{"waiting = yes", "(t.waiting_on != '')", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			q, err := Parse(tt.src, now)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			where, args := q.SQL()
+			if where != tt.where {
+				t.Errorf("expected\n  %s\ngot\n  %s", tt.where, where)
+			}
+			if fmt.Sprint(args) != fmt.Sprint(tt.args) {
+				t.Errorf("expected args %v, got %v", tt.args, args)
+			}
+			if q.String() != tt.src {
+				t.Errorf("expected String() to give back %q, got %q", tt.src, q.String())
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		src string
+		msg string
+		pos int
+	}{
+		{"", "empty filter", 1},
+		{"prio = high", `unknown field "prio"`, 1},
+		{"priority = urgent", `expected one of high, medium, low, got "urgent"`, 12},
+		{"priority < high", "priority can't be compared with <", 1},
+		{"due <= someday", `expected a date such as 2025-07-01 or today, got "someday"`, 8},
+		{"due < none", "none can't be compared with <", 7},
+		{"completed = maybe", `expected true or false, got "maybe"`, 13},
+		{"priority IN (high", `expected "," or ")"`, 18},
+		{"(priority = high", `expected ")"`, 17},
+		{"priority = high high", `unexpected "high"`, 17},
+		{"priority high", "expected an operator", 10},
+		{`notes ~ "open`, "unterminated string", 9},
+		{"due ! none", `expected "!="`, 5},
+		{"priority NOT = high", `expected "IN"`, 14},
+		{"id = ten", `expected a number, got "ten"`, 6},
+		{"priority = high AND", "expected a field", 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := Parse(tt.src, now)
+			var perr *Error
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected an *Error, got %v", err)
+			}
+			if perr.Msg != tt.msg || perr.Pos+1 != tt.pos {
+				t.Errorf("expected %q at position %d, got %v", tt.msg, tt.pos, err)
+			}
+		})
+	}
+}
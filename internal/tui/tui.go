@@ -0,0 +1,318 @@
+// Package tui is a line-based terminal client that works on the store
+// directly: it lists projects, shows a project's open tasks, toggles them
+// and quick-adds new ones with the capture syntax of package quickadd.
+package tui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/quickadd"
+	"mytasks/internal/recurring"
+	"mytasks/internal/store"
+)
+
+const help = `Commands:
+  N          open project N, or toggle task N inside a project
+  x N        toggle task N
+  a TEXT     quick-add, e.g. "a #Household !high buy filters tomorrow"
+  d          show or hide completed tasks
+  b          back to the project list
+  r          refresh
+  q          quit`
+
+// UI is one terminal session. Its fields may be set before calling Run.
+type UI struct {
+	// Clear redraws the whole screen with ANSI escapes before each listing;
+	// leave it off when the output is not a terminal.
+	Clear bool
+	// Now is the clock used for due dates; it defaults to time.Now.
+	Now func() time.Time
+
+	store store.Store
+	in    *bufio.Scanner
+	out   io.Writer
+
+	projects   []models.Project
+	openCounts map[int64]int
+	project    *models.Project // nil on the project list
+	tasks      []models.Task
+	showDone   bool
+	status     string
+}
+
+// New returns a UI reading commands from in and writing to out.
+func New(s store.Store, in io.Reader, out io.Writer) *UI {
+	return &UI{Now: time.Now, store: s, in: bufio.NewScanner(in), out: out}
+}
+
+// Run shows the project list and handles commands until "q" or the end of
+// input.
+func (u *UI) Run(ctx context.Context) error {
+	if err := u.load(ctx); err != nil {
+		return err
+	}
+	for {
+		u.draw()
+		fmt.Fprint(u.out, "> ")
+		if !u.in.Scan() {
+			fmt.Fprintln(u.out)
+			return u.in.Err()
+		}
+		quit, err := u.exec(ctx, strings.TrimSpace(u.in.Text()))
+		if err != nil {
+			return err
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// exec runs one command. Mistakes in the command are reported in the status
+// line; only store failures are returned.
+func (u *UI) exec(ctx context.Context, line string) (quit bool, err error) {
+	cmd, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	u.status = ""
+
+	switch cmd {
+	case "":
+		return false, nil
+	case "q", "quit":
+		return true, nil
+	case "?", "h", "help":
+		u.status = help
+		return false, nil
+	case "r":
+		return false, u.load(ctx)
+	case "b":
+		u.project = nil
+		return false, u.load(ctx)
+	case "d":
+		if u.project == nil {
+			u.status = "Open a project first."
+			return false, nil
+		}
+		u.showDone = !u.showDone
+		return false, u.load(ctx)
+	case "a":
+		return false, u.add(ctx, arg)
+	case "x":
+		return false, u.toggle(ctx, arg)
+	}
+
+	if _, err := strconv.Atoi(cmd); err == nil && arg == "" {
+		if u.project != nil {
+			return false, u.toggle(ctx, cmd)
+		}
+		return false, u.open(ctx, cmd)
+	}
+	u.status = fmt.Sprintf("Unknown command %q; type ? for help.", line)
+	return false, nil
+}
+
+// load refreshes the project list and, inside a project, its tasks.
+func (u *UI) load(ctx context.Context) error {
+	projects, err := u.store.ListActiveProjects(ctx)
+	if err != nil {
+		return err
+	}
+	open, err := u.store.ListOpenTasks(ctx, store.TaskFilter{})
+	if err != nil {
+		return err
+	}
+	u.projects = projects
+	u.openCounts = make(map[int64]int)
+	for _, t := range open {
+		u.openCounts[t.ProjectID]++
+	}
+
+	if u.project == nil {
+		u.tasks = nil
+		return nil
+	}
+	tasks, err := u.store.ListTasksByProjectFiltered(ctx, u.project.ID, false, 0)
+	if err != nil {
+		return err
+	}
+	if u.showDone {
+		done, err := u.store.ListTasksByProjectFiltered(ctx, u.project.ID, true, 0)
+		if err != nil {
+			return err
+		}
+		tasks = append(tasks, done...)
+	}
+	u.tasks = tasks
+	return nil
+}
+
+func (u *UI) open(ctx context.Context, n string) error {
+	i, ok := pick(n, len(u.projects))
+	if !ok {
+		u.status = fmt.Sprintf("There is no project %s.", n)
+		return nil
+	}
+	project := u.projects[i]
+	u.project = &project
+	u.showDone = false
+	return u.load(ctx)
+}
+
+func (u *UI) toggle(ctx context.Context, n string) error {
+	if u.project == nil {
+		u.status = "Open a project first."
+		return nil
+	}
+	i, ok := pick(n, len(u.tasks))
+	if !ok {
+		u.status = fmt.Sprintf("There is no task %s.", n)
+		return nil
+	}
+	task := u.tasks[i]
+	if err := u.store.ToggleTaskComplete(ctx, task.ID); err != nil {
+		return err
+	}
+	if task.Completed {
+		u.status = fmt.Sprintf("Reopened %q.", task.Description)
+		return u.load(ctx)
+	}
+	u.status = fmt.Sprintf("Completed %q.", task.Description)
+	// The server's event bus isn't here to schedule the next occurrence.
+	next, err := recurring.NewSpawner(u.store, nil).Spawn(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	if next != nil && next.DueDate != nil {
+		u.status += " Next one due " + next.DueDate.Format("Mon Jan 2") + "."
+	}
+	return u.load(ctx)
+}
+
+// add creates a task from capture text. Without a "#project" marker it goes
+// to the open project, or to the Inbox from the project list.
+func (u *UI) add(ctx context.Context, text string) error {
+	entry := quickadd.Parse(text, u.Now())
+
+	project := u.project
+	switch {
+	case entry.Project != "":
+		if project = quickadd.MatchProject(entry.Project, u.projects); project == nil {
+			u.status = fmt.Sprintf("No active project named %q.", entry.Project)
+			return nil
+		}
+	case project == nil:
+		var err error
+		if project, err = u.inbox(ctx); err != nil {
+			return err
+		}
+	}
+
+	task := &models.Task{
+		ProjectID:   project.ID,
+		Description: entry.Description,
+		Priority:    entry.Priority,
+		Status:      "todo",
+		DueDate:     entry.Due,
+		Tags:        entry.Tags,
+		Recurrence:  entry.Recurrence,
+	}
+	if task.Priority == "" {
+		settings, err := u.store.GetSettings(ctx)
+		if err != nil {
+			return err
+		}
+		task.Priority = settings.DefaultPriority
+	}
+	if err := task.Validate(); err != nil {
+		u.status = "Not added: " + err.Error() + "."
+		return nil
+	}
+	if err := u.store.CreateTask(ctx, task); err != nil {
+		return err
+	}
+	u.status = fmt.Sprintf("Added %q to %s.", task.Description, project.Name)
+	return u.load(ctx)
+}
+
+// inbox finds the Inbox project, creating it like the web app does.
+func (u *UI) inbox(ctx context.Context) (*models.Project, error) {
+	for i := range u.projects {
+		if strings.EqualFold(u.projects[i].Name, "Inbox") {
+			return &u.projects[i], nil
+		}
+	}
+	project := &models.Project{Name: "Inbox", Type: "project"}
+	if err := u.store.CreateProject(ctx, project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+func (u *UI) draw() {
+	if u.Clear {
+		fmt.Fprint(u.out, "\x1b[H\x1b[2J")
+	} else {
+		fmt.Fprintln(u.out)
+	}
+
+	if u.project == nil {
+		fmt.Fprintln(u.out, "Projects")
+		if len(u.projects) == 0 {
+			fmt.Fprintln(u.out, "  No active projects. Add a task with \"a TEXT\" to start the Inbox.")
+		}
+		for i, p := range u.projects {
+			fmt.Fprintf(u.out, "%3d. %s (%d open)\n", i+1, p.Name, u.openCounts[p.ID])
+		}
+	} else {
+		fmt.Fprintln(u.out, u.project.Name)
+		if len(u.tasks) == 0 {
+			fmt.Fprintln(u.out, "  Nothing to do here.")
+		}
+		today := u.Now()
+		for i, t := range u.tasks {
+			fmt.Fprintf(u.out, "%3d. %s\n", i+1, taskLine(&t, today))
+		}
+	}
+
+	if u.status != "" {
+		fmt.Fprintln(u.out)
+		fmt.Fprintln(u.out, u.status)
+	}
+}
+
+// taskLine renders a task as "[ ] Buy filters  !high  due Fri Oct 16".
+func taskLine(t *models.Task, today time.Time) string {
+	var b strings.Builder
+	if t.Completed {
+		b.WriteString("[x] ")
+	} else {
+		b.WriteString("[ ] ")
+	}
+	b.WriteString(t.Description)
+	if p := t.EffectivePriority(); p != "" && p != "medium" {
+		b.WriteString("  !" + p)
+	}
+	if t.DueDate != nil {
+		b.WriteString("  due " + t.DueDate.Format("Mon Jan 2"))
+		if !t.Completed && t.DueDate.Format(time.DateOnly) < today.Format(time.DateOnly) {
+			b.WriteString(" (overdue)")
+		}
+	}
+	return b.String()
+}
+
+// pick turns a 1-based choice into an index below n.
+func pick(s string, n int) (int, bool) {
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 1 || i > n {
+		return 0, false
+	}
+	return i - 1, true
+}
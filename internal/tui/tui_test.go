@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+func setupStore(t *testing.T) *store.SQLiteStore {
+	t.Helper()
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func run(t *testing.T, s store.Store, input string) string {
+	t.Helper()
+	var out bytes.Buffer
+	u := New(s, strings.NewReader(input), &out)
+	u.Now = func() time.Time { return time.Date(2026, time.October, 16, 9, 0, 0, 0, time.Local) }
+	if err := u.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	return out.String()
+}
+
+func TestUI_ListsProjectsAndTogglesTasks(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	home := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, home)
+	work := &models.Project{Name: "Work", Type: "project"}
+	s.CreateProject(ctx, work)
+	filters := &models.Task{ProjectID: home.ID, Description: "Buy filters", Priority: "high", Status: "todo"}
+	s.CreateTask(ctx, filters)
+	s.CreateTask(ctx, &models.Task{ProjectID: home.ID, Description: "Fix tap", Priority: "medium", Status: "todo"})
+
+	out := run(t, s, "1\nx 1\nb\nq\n")
+
+	for _, want := range []string{"1. Home (2 open)", "2. Work (0 open)", "1. [ ] Buy filters  !high", `Completed "Buy filters".`, "1. Home (1 open)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	task, _ := s.GetTask(ctx, filters.ID)
+	if !task.Completed {
+		t.Error("expected the task to be completed")
+	}
+}
+
+func TestUI_CompletingARepeatingTaskSchedulesTheNext(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	home := &models.Project{Name: "Home", Type: "project"}
+	s.CreateProject(ctx, home)
+
+	out := run(t, s, "1\na water plants every friday\n1\nq\n")
+
+	if !strings.Contains(out, `Completed "water plants". Next one due Fri Oct 23.`) {
+		t.Errorf("expected the next occurrence to be reported:\n%s", out)
+	}
+	open, _ := s.ListTasksByProjectFiltered(ctx, home.ID, false, 0)
+	if len(open) != 1 || open[0].Description != "water plants" {
+		t.Errorf("expected the next occurrence to be open, got %+v", open)
+	}
+}
+
+func TestUI_QuickAdd(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	home := &models.Project{Name: "Home Office", Type: "project"}
+	s.CreateProject(ctx, home)
+
+	out := run(t, s, "a #home-office !low order desk tomorrow\na call mum\na #garden weed\n1\na tidy cables\nq\n")
+
+	if !strings.Contains(out, `Added "order desk" to Home Office.`) || !strings.Contains(out, `Added "call mum" to Inbox.`) {
+		t.Errorf("expected both tasks to be added:\n%s", out)
+	}
+	if !strings.Contains(out, `No active project named "garden".`) {
+		t.Errorf("expected an unknown project to be reported:\n%s", out)
+	}
+
+	tasks, _ := s.ListTasksByProjectFiltered(ctx, home.ID, false, 0)
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks in Home Office, got %d", len(tasks))
+	}
+	desk := tasks[0]
+	if desk.Description != "order desk" {
+		desk = tasks[1]
+	}
+	if desk.Priority != "low" || desk.DueDate == nil || desk.DueDate.Format(time.DateOnly) != "2026-10-17" {
+		t.Errorf("unexpected task: %+v", desk)
+	}
+	if !strings.Contains(out, "[ ] tidy cables") {
+		t.Errorf("expected a task added inside a project to go there:\n%s", out)
+	}
+}
+
+func TestUI_ReportsBadChoices(t *testing.T) {
+	s := setupStore(t)
+	out := run(t, s, "7\nx 1\nfrobnicate\n")
+
+	for _, want := range []string{"There is no project 7.", "Open a project first.", `Unknown command "frobnicate"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Package webhook sends JSON payloads to outgoing webhooks, such as Slack's,
+// recording every attempt with its status, latency and the start of the
+// response. Failed deliveries are retried with exponential backoff, and any
+// delivery can be sent again from the admin panel.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+const (
+	// MaxAttempts is how many times a delivery is tried before it is marked
+	// failed.
+	MaxAttempts = 6
+	// firstRetry is the wait before the second attempt; each later one waits
+	// twice as long as the one before, so the last retry comes about half an
+	// hour after the first attempt.
+	firstRetry = time.Minute
+	// keep is how long finished deliveries stay in the log.
+	keep = 30 * 24 * time.Hour
+	// maxResponse caps how much of a response is kept.
+	maxResponse = 512
+)
+
+// Sender delivers payloads and keeps their log.
+type Sender struct {
+	store  store.Store
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewSender creates a Sender. A nil client gets a bounded timeout.
+func NewSender(s store.Store, client *http.Client) *Sender {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Sender{store: s, client: client, now: time.Now}
+}
+
+// Send records a delivery of payload to url and makes its first attempt.
+// target and event describe it in the log. If the attempt fails, the
+// delivery is retried later by Run, and the error, for which
+// IsDeliveryError holds, is returned with it.
+func (s *Sender) Send(ctx context.Context, target, event, url string, payload []byte) (*models.WebhookDelivery, error) {
+	d := &models.WebhookDelivery{
+		Target:    target,
+		Event:     event,
+		URL:       url,
+		Payload:   string(payload),
+		Status:    models.WebhookPending,
+		CreatedAt: s.now(),
+	}
+	if err := s.store.CreateWebhookDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+	return d, s.attempt(ctx, d, true)
+}
+
+// Run retries the deliveries that are due, and forgets finished ones older
+// than 30 days. It is meant to be called every minute or so.
+func (s *Sender) Run(ctx context.Context) error {
+	now := s.now()
+	due, err := s.store.ListDueWebhookDeliveries(ctx, now)
+	if err != nil {
+		return err
+	}
+	for i := range due {
+		// A failed retry is in the log; the job itself only fails when
+		// the log can't be written.
+		if err := s.attempt(ctx, &due[i], true); err != nil && !IsDeliveryError(err) {
+			return err
+		}
+	}
+	_, err = s.store.DeleteWebhookDeliveriesBefore(ctx, now.Add(-keep))
+	return err
+}
+
+// Redeliver sends a delivery again now, whatever its status, for replaying
+// it from the admin panel. A failed redelivery is not retried.
+func (s *Sender) Redeliver(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	d, err := s.store.GetWebhookDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return d, s.attempt(ctx, d, false)
+}
+
+// deliveryError is an attempt that failed, as recorded in the log.
+type deliveryError struct {
+	attempt *models.WebhookAttempt
+}
+
+func (e *deliveryError) Error() string {
+	if e.attempt.Error != "" {
+		return "webhook request failed: " + e.attempt.Error
+	}
+	return fmt.Sprintf("webhook returned %d: %s", e.attempt.StatusCode, e.attempt.Response)
+}
+
+// IsDeliveryError reports whether err is from a failed attempt, which is in
+// the delivery log and will be retried, rather than from the log itself.
+func IsDeliveryError(err error) bool {
+	var de *deliveryError
+	return errors.As(err, &de)
+}
+
+// attempt posts d's payload and records the result. A failure schedules the
+// next attempt if retry is set and d has attempts left, and otherwise marks
+// d failed.
+func (s *Sender) attempt(ctx context.Context, d *models.WebhookDelivery, retry bool) error {
+	a := s.post(ctx, d)
+	d.NextAttemptAt = nil
+	switch {
+	case a.OK():
+		d.Status = models.WebhookDelivered
+	case retry && len(d.Attempts)+1 < MaxAttempts:
+		d.Status = models.WebhookPending
+		next := a.At.Add(firstRetry << len(d.Attempts))
+		d.NextAttemptAt = &next
+	default:
+		d.Status = models.WebhookFailed
+	}
+	if err := s.store.RecordWebhookAttempt(ctx, d, a); err != nil {
+		return err
+	}
+	if !a.OK() {
+		return &deliveryError{attempt: a}
+	}
+	return nil
+}
+
+func (s *Sender) post(ctx context.Context, d *models.WebhookDelivery) *models.WebhookAttempt {
+	a := &models.WebhookAttempt{At: s.now()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, strings.NewReader(d.Payload))
+	if err != nil {
+		a.Error = err.Error()
+		return a
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	a.Latency = time.Since(start)
+	if err != nil {
+		// The URL is left out: webhook URLs carry their credentials.
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			err = ue.Err
+		}
+		a.Error = err.Error()
+		return a
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	a.StatusCode = resp.StatusCode
+	a.Response = string(bytes.ToValidUTF8(bytes.TrimSpace(body), nil))
+	return a
+}
@@ -0,0 +1,204 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+func newTestStore(t *testing.T) store.Store {
+	t.Helper()
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// endpoint is a webhook that answers with the statuses in order, repeating
+// the last one.
+type endpoint struct {
+	statuses []int
+	bodies   []string
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	e.bodies = append(e.bodies, string(body))
+	status := e.statuses[0]
+	if len(e.statuses) > 1 {
+		e.statuses = e.statuses[1:]
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, http.StatusText(status))
+}
+
+func TestSender_Delivers(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	ep := &endpoint{statuses: []int{http.StatusOK}}
+	srv := httptest.NewServer(ep)
+	defer srv.Close()
+
+	d, err := NewSender(s, srv.Client()).Send(ctx, "slack:T1", "overdue alert", srv.URL, []byte(`{"text":"hi"}`))
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if len(ep.bodies) != 1 || ep.bodies[0] != `{"text":"hi"}` {
+		t.Errorf("endpoint got %q", ep.bodies)
+	}
+
+	got, err := s.GetWebhookDelivery(ctx, d.ID)
+	if err != nil {
+		t.Fatalf("GetWebhookDelivery failed: %v", err)
+	}
+	if got.Status != models.WebhookDelivered || got.NextAttemptAt != nil || len(got.Attempts) != 1 {
+		t.Fatalf("unexpected delivery %+v", got)
+	}
+	if a := got.Attempts[0]; a.StatusCode != http.StatusOK || a.Response != "OK" || a.Error != "" {
+		t.Errorf("unexpected attempt %+v", a)
+	}
+}
+
+func TestSender_RetriesWithBackoffThenFails(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	ep := &endpoint{statuses: []int{http.StatusInternalServerError}}
+	srv := httptest.NewServer(ep)
+	defer srv.Close()
+
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	sender := NewSender(s, srv.Client())
+	sender.now = func() time.Time { return now }
+
+	d, err := sender.Send(ctx, "slack:T1", "overdue alert", srv.URL, []byte(`{}`))
+	if !IsDeliveryError(err) || !strings.Contains(err.Error(), "500") {
+		t.Fatalf("Send returned %v, want the failed attempt", err)
+	}
+	if d.Status != models.WebhookPending || d.NextAttemptAt == nil || !d.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("after the first attempt: status %s, next attempt %v", d.Status, d.NextAttemptAt)
+	}
+
+	// Nothing is due before the next attempt time.
+	if err := sender.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(ep.bodies) != 1 {
+		t.Fatalf("retried early: %d attempts", len(ep.bodies))
+	}
+
+	for wait := time.Minute; ; wait *= 2 {
+		now = now.Add(wait)
+		if err := sender.Run(ctx); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if d, err = s.GetWebhookDelivery(ctx, d.ID); err != nil {
+			t.Fatalf("GetWebhookDelivery failed: %v", err)
+		}
+		if d.Status != models.WebhookPending {
+			break
+		}
+		if want := now.Add(2 * wait); !d.NextAttemptAt.Equal(want) {
+			t.Fatalf("after attempt %d: next attempt %v, want %v", len(d.Attempts), d.NextAttemptAt, want)
+		}
+	}
+	if d.Status != models.WebhookFailed || len(d.Attempts) != MaxAttempts {
+		t.Errorf("status %s after %d attempts, want failed after %d", d.Status, len(d.Attempts), MaxAttempts)
+	}
+
+	now = now.Add(24 * time.Hour)
+	if err := sender.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(ep.bodies) != MaxAttempts {
+		t.Errorf("failed delivery was retried: %d attempts", len(ep.bodies))
+	}
+}
+
+func TestSender_KeepsWebhookURLOutOfTheLog(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL + "/services/T1/SECRET"
+	srv.Close()
+
+	d, err := NewSender(s, nil).Send(ctx, "slack:T1", "overdue alert", url, []byte(`{}`))
+	if !IsDeliveryError(err) {
+		t.Fatalf("Send returned %v, want the failed attempt", err)
+	}
+	a := d.LastAttempt()
+	if a.Error == "" || a.StatusCode != 0 {
+		t.Fatalf("unexpected attempt %+v", a)
+	}
+	if strings.Contains(a.Error, "SECRET") || strings.Contains(err.Error(), "SECRET") {
+		t.Errorf("attempt error %q contains the webhook URL", a.Error)
+	}
+}
+
+func TestSender_Redeliver(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	ep := &endpoint{statuses: []int{http.StatusOK, http.StatusBadGateway}}
+	srv := httptest.NewServer(ep)
+	defer srv.Close()
+	sender := NewSender(s, srv.Client())
+
+	d, err := sender.Send(ctx, "slack:T1", "overdue alert", srv.URL, []byte(`{"text":"again"}`))
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	// A failed redelivery is final, even of a delivered payload.
+	d, err = sender.Redeliver(ctx, d.ID)
+	if !IsDeliveryError(err) {
+		t.Fatalf("Redeliver returned %v, want the failed attempt", err)
+	}
+	if d.Status != models.WebhookFailed || d.NextAttemptAt != nil || len(d.Attempts) != 2 {
+		t.Fatalf("unexpected delivery %+v", d)
+	}
+	if len(ep.bodies) != 2 || ep.bodies[1] != `{"text":"again"}` {
+		t.Errorf("endpoint got %q", ep.bodies)
+	}
+
+	if _, err := sender.Redeliver(ctx, d.ID+1); err == nil {
+		t.Error("expected an error redelivering an unknown delivery")
+	}
+}
+
+func TestSender_ForgetsOldDeliveries(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	ep := &endpoint{statuses: []int{http.StatusOK}}
+	srv := httptest.NewServer(ep)
+	defer srv.Close()
+
+	now := time.Now()
+	sender := NewSender(s, srv.Client())
+	sender.now = func() time.Time { return now.AddDate(0, 0, -31) }
+	if _, err := sender.Send(ctx, "slack:T1", "overdue alert", srv.URL, []byte(`{}`)); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	sender.now = func() time.Time { return now }
+	if _, err := sender.Send(ctx, "slack:T1", "overdue alert", srv.URL, []byte(`{}`)); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if err := sender.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	deliveries, err := s.ListWebhookDeliveries(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries failed: %v", err)
+	}
+	if len(deliveries) != 1 || !deliveries[0].CreatedAt.Equal(now) {
+		t.Errorf("expected only the recent delivery to remain, got %+v", deliveries)
+	}
+}
@@ -0,0 +1,55 @@
+package mytasks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// requestIDHeader carries the ID given to each request, which also appears in
+// its log lines and server error messages.
+const requestIDHeader = "X-Request-ID"
+
+// requestID gives each request a short random ID, set on the response before
+// any handler runs so later middleware and error responses can read it back.
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := make([]byte, 4)
+		rand.Read(buf)
+		w.Header().Set(requestIDHeader, hex.EncodeToString(buf))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestLogger logs one line per request with its ID, method, path, status,
+// size and duration. Server errors are logged at error level.
+func requestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+		defer func() {
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			level := slog.LevelInfo
+			if status >= 500 {
+				level = slog.LevelError
+			}
+			slog.LogAttrs(r.Context(), level, "request",
+				slog.String("request_id", w.Header().Get(requestIDHeader)),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("remote", r.RemoteAddr),
+			)
+		}()
+		next.ServeHTTP(ww, r)
+	})
+}
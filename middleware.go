@@ -0,0 +1,336 @@
+package mytasks
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// defaultCSP allows only same-origin scripts, styles, images and
+// connections (including the realtime WebSocket). The templates use inline
+// event handlers and htmx's hx-on attributes, which need 'unsafe-inline' and
+// 'unsafe-eval', and inline style attributes.
+const defaultCSP = "default-src 'self'; " +
+	"script-src 'self' 'unsafe-inline' 'unsafe-eval'; " +
+	"style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; " +
+	"connect-src 'self'; " +
+	"object-src 'none'; " +
+	"base-uri 'self'; " +
+	"form-action 'self'"
+
+// securityConfig holds the response security headers. Empty fields leave
+// their header out, so a reverse proxy can set it instead.
+type securityConfig struct {
+	// CSP is the Content-Security-Policy without frame-ancestors.
+	CSP string
+	// FrameAncestors lists who may embed the app in a frame, e.g. "'none'"
+	// or "'self' https://dashboard.example.com".
+	FrameAncestors string
+	ReferrerPolicy string
+}
+
+// securityHeaders sets Content-Security-Policy, X-Content-Type-Options,
+// Referrer-Policy and X-Frame-Options on every response.
+func securityHeaders(cfg securityConfig) func(http.Handler) http.Handler {
+	csp := cfg.CSP
+	if cfg.FrameAncestors != "" && !strings.Contains(csp, "frame-ancestors") {
+		if csp != "" {
+			csp += "; "
+		}
+		csp += "frame-ancestors " + cfg.FrameAncestors
+	}
+
+	// X-Frame-Options only covers older browsers, and can only say none or
+	// self.
+	var frameOptions string
+	switch cfg.FrameAncestors {
+	case "'none'":
+		frameOptions = "DENY"
+	case "'self'":
+		frameOptions = "SAMEORIGIN"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			if csp != "" {
+				h.Set("Content-Security-Policy", csp)
+			}
+			if frameOptions != "" {
+				h.Set("X-Frame-Options", frameOptions)
+			}
+			if cfg.ReferrerPolicy != "" {
+				h.Set("Referrer-Policy", cfg.ReferrerPolicy)
+			}
+			h.Set("X-Content-Type-Options", "nosniff")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// originList is a set of allowed origins such as "https://app.example.com".
+type originList []string
+
+// parseOrigins reads a list of origins, ignoring blanks and trailing
+// slashes.
+func parseOrigins(list []string) originList {
+	var origins originList
+	for _, origin := range list {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+func (l originList) allows(origin string) bool {
+	for _, allowed := range l {
+		if origin != "" && strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func isAPIPath(path string) bool {
+	return strings.HasPrefix(path, "/api/") || path == "/graphql"
+}
+
+// cors lets pages and browser extensions on the allowed origins call the
+// API. Preflight requests are answered here, before routing. Requests carry
+// no credentials, as the app has no sessions.
+func cors(origins originList) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(origins) == 0 || !isAPIPath(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			origin := r.Header.Get("Origin")
+			if !origins.allows(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Set("Access-Control-Expose-Headers", "ETag")
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+				h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
+				if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+					h.Set("Access-Control-Allow-Headers", headers)
+				}
+				h.Set("Access-Control-Max-Age", "600")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// proxyList is the set of reverse proxies whose X-Forwarded-* headers are
+// believed.
+type proxyList []netip.Prefix
+
+// parseProxies reads a list of IP addresses and CIDR ranges, e.g.
+// "127.0.0.1" and "10.0.0.0/8".
+func parseProxies(list []string) (proxyList, error) {
+	var proxies proxyList
+	for _, entry := range list {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy address %q", entry)
+		}
+		proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return proxies, nil
+}
+
+func (l proxyList) trusts(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range l {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedHeaders applies X-Forwarded-For and X-Forwarded-Host from trusted
+// proxies to the request's RemoteAddr and Host, so origin checks, logs and
+// throttling see the client's view. X-Forwarded-Proto is kept for handlers
+// building external URLs. Requests from anywhere else have these headers
+// removed, so nothing downstream can be fooled by them.
+func forwardedHeaders(proxies proxyList) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				peer = r.RemoteAddr
+			}
+
+			r = r.Clone(r.Context())
+			if !proxies.trusts(peer) {
+				r.Header.Del("X-Forwarded-For")
+				r.Header.Del("X-Forwarded-Host")
+				r.Header.Del("X-Forwarded-Proto")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if host := firstValue(r.Header.Get("X-Forwarded-Host")); host != "" {
+				r.Host = host
+			}
+			if client := forwardedClient(r.Header.Values("X-Forwarded-For"), proxies); client != "" {
+				r.RemoteAddr = client
+			}
+			if proto := firstValue(r.Header.Get("X-Forwarded-Proto")); proto != "" {
+				r.Header.Set("X-Forwarded-Proto", proto)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClient returns the address closest to the client in an
+// X-Forwarded-For chain that is not itself a trusted proxy.
+func forwardedClient(values []string, proxies proxyList) string {
+	var hops []string
+	for _, v := range values {
+		for _, hop := range strings.Split(v, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !proxies.trusts(hops[i]) || i == 0 {
+			return hops[i]
+		}
+	}
+	return ""
+}
+
+// firstValue returns the first entry of a comma-separated header value,
+// which proxies chaining onto each other produce.
+func firstValue(v string) string {
+	first, _, _ := strings.Cut(v, ",")
+	return strings.TrimSpace(first)
+}
+
+// csrfOriginCheck rejects writes from other sites. Origins allowed by the
+// CORS configuration may still write to the API, and pages served from any of
+// externalHosts count as this site.
+func csrfOriginCheck(apiOrigins originList, externalHosts []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			origin := r.Header.Get("Origin")
+			referer := r.Header.Get("Referer")
+			if isAPIPath(r.URL.Path) && apiOrigins.allows(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if origin == "" && referer == "" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+
+			if origin != "" {
+				u, err := url.Parse(origin)
+				if err != nil || !isOwnHost(u.Host, r.Host, externalHosts) {
+					http.Error(w, "forbidden", http.StatusForbidden)
+					return
+				}
+			}
+
+			if referer != "" {
+				u, err := url.Parse(referer)
+				if err != nil || !isOwnHost(u.Host, r.Host, externalHosts) {
+					http.Error(w, "forbidden", http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func sameHost(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
+
+// isOwnHost reports whether host is the one the request was made to or one
+// of the configured external hosts.
+func isOwnHost(host, requestHost string, externalHosts []string) bool {
+	if sameHost(host, requestHost) {
+		return true
+	}
+	for _, h := range externalHosts {
+		if sameHost(host, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenAuth accepts the token as "Authorization: Bearer <token>", a ?token=
+// query parameter, or the cookie set when the query parameter was used, so
+// links between pages under cookiePath keep working.
+func tokenAuth(token, cookieName, cookiePath string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			presented, fromQuery := r.URL.Query().Get("token"), true
+			if presented == "" {
+				fromQuery = false
+				if v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+					presented = v
+				} else if c, err := r.Cookie(cookieName); err == nil {
+					presented = c.Value
+				}
+			}
+			if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			if fromQuery {
+				http.SetCookie(w, &http.Cookie{
+					Name:     cookieName,
+					Value:    token,
+					Path:     cookiePath,
+					HttpOnly: true,
+					SameSite: http.SameSiteStrictMode,
+				})
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
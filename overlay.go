@@ -0,0 +1,55 @@
+package mytasks
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+)
+
+// overlayFS serves files from upper where they exist and from lower
+// otherwise. Directory listings include the files of both, so globbing sees
+// files that exist only in upper.
+type overlayFS struct {
+	upper, lower fs.FS
+}
+
+// Open prefers upper's file. Directories come from lower unless only upper
+// has them.
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.upper.Open(name)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		return o.lower.Open(name)
+	}
+	if info, err := f.Stat(); err == nil && !info.IsDir() {
+		return f, nil
+	}
+	if lf, err := o.lower.Open(name); err == nil {
+		f.Close()
+		return lf, nil
+	}
+	return f, nil
+}
+
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	upper, upperErr := fs.ReadDir(o.upper, name)
+	lower, lowerErr := fs.ReadDir(o.lower, name)
+	if upperErr != nil && lowerErr != nil {
+		return nil, lowerErr
+	}
+
+	seen := make(map[string]bool, len(upper))
+	entries := append([]fs.DirEntry(nil), upper...)
+	for _, e := range upper {
+		seen[e.Name()] = true
+	}
+	for _, e := range lower {
+		if !seen[e.Name()] {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
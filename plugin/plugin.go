@@ -0,0 +1,123 @@
+// Package plugin lets integrations extend mytasks without changing its
+// handlers. A plugin registers itself from an init function, and is compiled
+// in by importing its package for side effects, as database/sql drivers are:
+//
+//	import _ "example.com/mytasks-ntfy"
+//
+// The package lives outside internal/ so plugins can be separate Go modules.
+// Hooks therefore receive the plain Task and Project values defined here
+// rather than the server's own models.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Task describes a task passed to a hook.
+type Task struct {
+	ID          int64
+	ProjectID   int64
+	Description string
+	Notes       string
+	Priority    string // "high", "medium" or "low"
+	Status      string // "todo", "in_progress" or "done"
+	DueDate     *time.Time
+	Tags        []string
+}
+
+// Project describes a project passed to a hook.
+type Project struct {
+	ID   int64
+	Name string
+}
+
+// NavItem is a link added to the sidebar, below the built-in views. Path is
+// relative to the base path, e.g. "/plugins/ntfy/".
+type NavItem struct {
+	Label string
+	Path  string
+}
+
+// Plugin is a set of extension points. Every field but Name is optional.
+//
+// Hooks run on the goroutine of the request that caused them, after the
+// change is saved, and should hand off slow work. A panicking hook is logged
+// and does not fail the request.
+type Plugin struct {
+	// Name identifies the plugin in logs and in the URL of its Handler. It
+	// uses lower-case letters, digits and dashes.
+	Name string
+
+	OnTaskCreated      func(ctx context.Context, task Task)
+	OnTaskCompleted    func(ctx context.Context, task Task)
+	OnProjectCompleted func(ctx context.Context, project Project)
+
+	// NavItems are added to the sidebar on every page.
+	NavItems []NavItem
+
+	// TemplateFuncs are available to all templates, including overrides in
+	// TEMPLATES_OVERRIDE_DIR. A name already used by the server or another
+	// plugin stops the server at startup.
+	TemplateFuncs template.FuncMap
+
+	// Handler serves requests under /plugins/<Name>/, with that prefix
+	// removed. Writes get the same cross-site request checks as the app.
+	Handler http.Handler
+}
+
+var (
+	mu      sync.Mutex
+	plugins = make(map[string]Plugin)
+)
+
+// Register makes p available to the server. It panics if the name is
+// invalid or already registered, which surfaces mistakes when the binary
+// starts rather than in a request.
+func Register(p Plugin) {
+	if !validName(p.Name) {
+		panic(fmt.Sprintf("plugin: invalid name %q: use lower-case letters, digits and dashes", p.Name))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, dup := plugins[p.Name]; dup {
+		panic("plugin: Register called twice for " + p.Name)
+	}
+	plugins[p.Name] = p
+}
+
+// Registered returns the registered plugins sorted by name.
+func Registered() []Plugin {
+	mu.Lock()
+	defer mu.Unlock()
+	list := make([]Plugin, 0, len(plugins))
+	for _, p := range plugins {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func validName(name string) bool {
+	if name == "" || name[0] == '-' || name[len(name)-1] == '-' {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// unregisterAll is for tests.
+func unregisterAll() {
+	mu.Lock()
+	defer mu.Unlock()
+	plugins = make(map[string]Plugin)
+}
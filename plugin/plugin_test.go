@@ -0,0 +1,63 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestRegister_SortsByName(t *testing.T) {
+	t.Cleanup(unregisterAll)
+	Register(Plugin{Name: "ntfy"})
+	Register(Plugin{Name: "audit-log"})
+
+	got := Registered()
+	if len(got) != 2 || got[0].Name != "audit-log" || got[1].Name != "ntfy" {
+		t.Errorf("Registered() = %+v, want audit-log then ntfy", got)
+	}
+}
+
+func TestRegister_RejectsDuplicate(t *testing.T) {
+	t.Cleanup(unregisterAll)
+	Register(Plugin{Name: "ntfy"})
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic registering ntfy twice")
+		}
+	}()
+	Register(Plugin{Name: "ntfy"})
+}
+
+func TestRegister_RejectsInvalidName(t *testing.T) {
+	t.Cleanup(unregisterAll)
+	for _, name := range []string{"", "Ntfy", "my plugin", "a/b", "-x", "x-"} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected a panic for name %q", name)
+				}
+			}()
+			Register(Plugin{Name: name})
+		})
+	}
+}
+
+func Example() {
+	defer unregisterAll()
+
+	// In a plugin package's init function:
+	Register(Plugin{
+		Name: "audit-log",
+		OnTaskCreated: func(ctx context.Context, task Task) {
+			fmt.Printf("created %q in project %d\n", task.Description, task.ProjectID)
+		},
+		NavItems: []NavItem{{Label: "Audit log", Path: "/plugins/audit-log/"}},
+	})
+
+	// The server then calls the hooks as tasks change.
+	for _, p := range Registered() {
+		p.OnTaskCreated(context.Background(), Task{Description: "Buy milk", ProjectID: 3})
+	}
+	// Output: created "Buy milk" in project 3
+}
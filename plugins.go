@@ -0,0 +1,99 @@
+package mytasks
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"mytasks/internal/events"
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+	"mytasks/plugin"
+)
+
+// subscribePlugins calls the plugins' hooks from the event bus.
+func subscribePlugins(bus *events.Bus, s store.Store, plugins []plugin.Plugin) {
+	for _, p := range plugins {
+		p := p
+		if p.OnTaskCreated != nil {
+			bus.Subscribe(func(ctx context.Context, e events.Event) {
+				if e.Task != nil {
+					p.OnTaskCreated(ctx, pluginTask(e.Task))
+				}
+			}, events.TaskCreated)
+		}
+		if p.OnTaskCompleted != nil {
+			bus.Subscribe(func(ctx context.Context, e events.Event) {
+				if e.Task != nil {
+					p.OnTaskCompleted(ctx, pluginTask(e.Task))
+				}
+			}, events.TaskCompleted)
+		}
+		if p.OnProjectCompleted != nil {
+			bus.Subscribe(func(ctx context.Context, e events.Event) {
+				project := e.Project
+				if project == nil {
+					var err error
+					if project, err = s.GetProject(ctx, e.ProjectID); err != nil {
+						slog.Error("plugin: failed to load completed project", "plugin", p.Name, "project_id", e.ProjectID, "err", err)
+						return
+					}
+				}
+				p.OnProjectCompleted(ctx, plugin.Project{ID: project.ID, Name: project.Name})
+			}, events.ProjectCompleted)
+		}
+	}
+}
+
+func pluginTask(t *models.Task) plugin.Task {
+	return plugin.Task{
+		ID:          t.ID,
+		ProjectID:   t.ProjectID,
+		Description: t.Description,
+		Notes:       t.Notes,
+		Priority:    t.Priority,
+		Status:      t.Status,
+		DueDate:     t.DueDate,
+		Tags:        t.Tags,
+	}
+}
+
+// pluginFuncs gathers the plugins' template functions, plus pluginNav, which
+// lists their sidebar links.
+func pluginFuncs(plugins []plugin.Plugin) (template.FuncMap, error) {
+	var nav []plugin.NavItem
+	funcs := template.FuncMap{
+		"pluginNav": func() []plugin.NavItem { return nav },
+	}
+	owner := make(map[string]string)
+	for _, p := range plugins {
+		nav = append(nav, p.NavItems...)
+		for name, fn := range p.TemplateFuncs {
+			if _, reserved := funcs[name]; reserved && owner[name] == "" {
+				return nil, fmt.Errorf("plugin %s: template function %q is reserved", p.Name, name)
+			}
+			if other, dup := owner[name]; dup {
+				return nil, fmt.Errorf("plugin %s: template function %q is also defined by plugin %s", p.Name, name, other)
+			}
+			owner[name] = p.Name
+			funcs[name] = fn
+		}
+	}
+	return funcs, nil
+}
+
+// mountPlugins serves each plugin's Handler under /plugins/<name>/.
+func mountPlugins(r chi.Router, basePath string, plugins []plugin.Plugin) {
+	for _, p := range plugins {
+		if p.Handler == nil {
+			continue
+		}
+		prefix := "/plugins/" + p.Name
+		r.Handle(prefix+"/*", http.StripPrefix(prefix, p.Handler))
+		slog.Info("plugin: mounted", "plugin", p.Name, "path", basePath+prefix+"/")
+	}
+}
@@ -0,0 +1,840 @@
+// Package mytasks is the task manager as an http.Handler, so it can be
+// mounted inside another program as well as run on its own by cmd/mytasks:
+//
+//	s, err := mytasks.OpenStore("data/mytasks.db")
+//	...
+//	cfg := mytasks.DefaultConfig()
+//	cfg.BasePath = "/tasks"
+//	srv, err := mytasks.NewServer(cfg, s)
+//	...
+//	srv.Start(ctx)
+//	mux.Handle("/tasks/", srv)
+package mytasks
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc"
+
+	"mytasks/internal/accesslog"
+	"mytasks/internal/escalation"
+	"mytasks/internal/events"
+	"mytasks/internal/gcal"
+	"mytasks/internal/github"
+	"mytasks/internal/graphql"
+	"mytasks/internal/gtasks"
+	"mytasks/internal/handlers"
+	"mytasks/internal/housekeeping"
+	"mytasks/internal/i18n"
+	"mytasks/internal/inbox"
+	"mytasks/internal/mail"
+	"mytasks/internal/markdown"
+	"mytasks/internal/models"
+	"mytasks/internal/realtime"
+	"mytasks/internal/recurring"
+	"mytasks/internal/rpc"
+	"mytasks/internal/scheduler"
+	"mytasks/internal/seed"
+	"mytasks/internal/slack"
+	"mytasks/internal/store"
+	"mytasks/internal/webhook"
+	"mytasks/plugin"
+)
+
+//go:embed templates/*
+var templatesFS embed.FS
+
+//go:embed static/*
+var staticFS embed.FS
+
+// Store is the SQLite database behind a Server.
+type Store = store.SQLiteStore
+
+// StoreTuning sets SQLite pragmas such as cache_size and synchronous; see
+// store.Tuning.
+type StoreTuning = store.Tuning
+
+// DefaultStoreTuning is the tuning OpenStore uses: SQLite's defaults, with a
+// 5s busy timeout.
+func DefaultStoreTuning() StoreTuning {
+	return store.DefaultTuning()
+}
+
+// OpenStore opens the database at path, creating it and its directory if
+// needed, and brings its schema up to date.
+func OpenStore(path string) (*Store, error) {
+	return OpenTunedStore(path, DefaultStoreTuning())
+}
+
+// OpenTunedStore is OpenStore with SQLite tuned for the disk it is on.
+func OpenTunedStore(path string, tuning StoreTuning) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	return store.NewTunedSQLiteStore(path, tuning)
+}
+
+// Config holds a Server's settings. Each field corresponds to the setting of
+// the same name in cmd/mytasks; see README.md for details. Start from
+// DefaultConfig, as an empty header setting leaves the header out.
+type Config struct {
+	// BasePath is the path prefix the app is served under, e.g. "/tasks".
+	// The Server strips it from requests itself.
+	BasePath string
+	// DBPath is the database file, reported in /debug/diagnostics.
+	DBPath string
+
+	// DevMode reads templates and static files from the working directory,
+	// which must be a checkout of this repository.
+	DevMode              bool
+	TemplatesOverrideDir string
+	StaticOverrideDir    string
+
+	CSP                string
+	FrameAncestors     string
+	ReferrerPolicy     string
+	CORSAllowedOrigins []string
+	TrustedProxies     []string
+	ExternalHosts      []string
+	DebugToken         string
+	// AdminToken enables the admin panel at /admin for requests bearing it.
+	AdminToken string
+	// Maintenance starts the app in read-only maintenance mode, which the
+	// admin panel turns off.
+	Maintenance bool
+	// DemoMode runs a public demo: the store is emptied and seeded with the
+	// demo data at start and every DemoReset, each client may make
+	// DemoWriteLimit changes an hour, and features that store files or
+	// credentials are off. Only use it with a throwaway store.
+	DemoMode       bool
+	DemoReset      time.Duration
+	DemoWriteLimit int
+
+	// AccessLog, if set, receives a line per request in AccessLogFormat:
+	// "combined" like Apache and nginx, or "json".
+	AccessLog       io.Writer
+	AccessLogFormat string
+
+	SlackSigningSecret         string
+	InboundEmailSigningKey     string
+	InboundEmailAllowedSenders []string
+	GoogleClientID             string
+	GoogleClientSecret         string
+	GoogleRedirectURL          string
+	GoogleCalendarRedirectURL  string
+
+	SMTPHost          string
+	SMTPPort          string
+	SMTPUsername      string
+	SMTPPassword      string
+	SMTPFrom          string
+	OverdueAlertEmail []string
+	DigestEmail       []string
+
+	BackupDir       string
+	BackupKeep      int
+	AutoArchiveDays int
+	// JobSchedules overrides job schedules by name, e.g. "backup": "0 4 * * *".
+	JobSchedules map[string]string
+
+	// Workspaces are further databases, each served by its own copy of the
+	// app under BasePath/w/{slug}, with a switcher in the sidebar.
+	Workspaces []Workspace
+	// Tenancy, if set, hosts the Workspaces as tenants instead: without a
+	// switcher, and with TenancySubdomain at {slug}.TenantDomain rather
+	// than under /w/{slug}.
+	Tenancy      string
+	TenantDomain string
+}
+
+// DefaultConfig returns the settings cmd/mytasks uses when none are given.
+func DefaultConfig() Config {
+	return Config{
+		CSP:             defaultCSP,
+		FrameAncestors:  "'none'",
+		ReferrerPolicy:  "same-origin",
+		AccessLogFormat: "combined",
+		SMTPPort:        "587",
+		BackupKeep:      7,
+		DemoReset:       time.Hour,
+		DemoWriteLimit:  120,
+	}
+}
+
+// Server serves the app. Background jobs run once Start is called.
+type Server struct {
+	handler  http.Handler
+	basePath string
+	store    *Store
+	bus      *events.Bus
+	jobs     *scheduler.Scheduler
+	// workspaces serve Config.Workspaces, by slug.
+	workspaces map[string]*Server
+	// tenantDomain is set for TenancySubdomain.
+	tenantDomain string
+	maintenance  *atomic.Bool
+}
+
+// compressedTypes are the responses gzipped for clients that accept it:
+// chi's defaults plus CSV reports and the manifest.
+var compressedTypes = []string{
+	"text/html", "text/css", "text/plain", "text/javascript", "text/csv",
+	"application/javascript", "application/x-javascript", "application/json",
+	"application/manifest+json", "application/atom+xml", "application/rss+xml",
+	"image/svg+xml",
+}
+
+// NewServer builds the app on s, and on the store of each of
+// cfg.Workspaces. It fails if the configuration is inconsistent or the
+// templates do not parse.
+func NewServer(cfg Config, s *Store) (*Server, error) {
+	if err := validateWorkspaces(cfg.Workspaces); err != nil {
+		return nil, fmt.Errorf("WORKSPACES: %w", err)
+	}
+	if err := validateTenancy(cfg.Tenancy, cfg.TenantDomain); err != nil {
+		return nil, err
+	}
+	if cfg.DemoMode && len(cfg.Workspaces) > 0 {
+		return nil, errors.New("DEMO_MODE does not support WORKSPACES")
+	}
+	if cfg.DemoMode && (cfg.DemoReset <= 0 || cfg.DemoWriteLimit <= 0) {
+		return nil, errors.New("DEMO_MODE requires a positive DEMO_RESET and DEMO_WRITE_LIMIT")
+	}
+	basePath := normalizeBasePath(cfg.BasePath)
+	switcher := cfg.Workspaces
+	if cfg.Tenancy != "" {
+		switcher = nil
+	}
+	srv, err := newServer(cfg, s, workspaceSwitcher(basePath, switcher, ""))
+	if err != nil {
+		return nil, err
+	}
+	srv.tenantDomain = strings.ToLower(cfg.TenantDomain)
+	for _, ws := range cfg.Workspaces {
+		wsSrv, err := newServer(workspaceConfig(cfg, ws), ws.Store, workspaceSwitcher(basePath, switcher, ws.Slug))
+		if err != nil {
+			return nil, fmt.Errorf("workspace %s: %w", ws.Slug, err)
+		}
+		if srv.workspaces == nil {
+			srv.workspaces = make(map[string]*Server)
+		}
+		srv.workspaces[ws.Slug] = wsSrv
+	}
+	return srv, nil
+}
+
+// newServer builds the app of one workspace; workspaces lists them all for
+// the sidebar.
+func newServer(cfg Config, s *Store, workspaces func() []models.WorkspaceLink) (*Server, error) {
+	basePath := normalizeBasePath(cfg.BasePath)
+	smtpConfig := mail.Config{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+	}
+	inboundEmail := mail.InboundConfig{
+		SigningKey:     cfg.InboundEmailSigningKey,
+		AllowedSenders: cfg.InboundEmailAllowedSenders,
+	}
+	googleTasks := gtasks.OAuthConfig{
+		ClientID:     cfg.GoogleClientID,
+		ClientSecret: cfg.GoogleClientSecret,
+		RedirectURL:  cfg.GoogleRedirectURL,
+	}
+	googleCalendar := googleTasks
+	googleCalendar.Scope = gcal.Scope
+	googleCalendar.RedirectURL = cfg.GoogleCalendarRedirectURL
+	security := securityConfig{
+		CSP:            cfg.CSP,
+		FrameAncestors: cfg.FrameAncestors,
+		ReferrerPolicy: cfg.ReferrerPolicy,
+	}
+	corsOrigins := parseOrigins(cfg.CORSAllowedOrigins)
+	trustedProxies, err := parseProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+	if inboundEmail.Enabled() && !hasNonEmpty(inboundEmail.AllowedSenders) {
+		return nil, errors.New("INBOUND_EMAIL_SIGNING_KEY requires INBOUND_EMAIL_ALLOWED_SENDERS")
+	}
+	if len(cfg.OverdueAlertEmail) > 0 && !smtpConfig.Enabled() {
+		return nil, errors.New("OVERDUE_ALERT_EMAIL requires SMTP_HOST and SMTP_FROM")
+	}
+	if len(cfg.DigestEmail) > 0 && !smtpConfig.Enabled() {
+		return nil, errors.New("DIGEST_EMAIL requires SMTP_HOST and SMTP_FROM")
+	}
+	var accessLog func(http.Handler) http.Handler
+	if cfg.AccessLog != nil {
+		if accessLog, err = accesslog.Middleware(cfg.AccessLog, cfg.AccessLogFormat); err != nil {
+			return nil, fmt.Errorf("ACCESS_LOG_FORMAT: %w", err)
+		}
+	}
+
+	// In development, templates and static files come from the working
+	// directory so edits show up without rebuilding
+	templateFiles, _ := fs.Sub(templatesFS, "templates")
+	var staticFiles fs.FS = staticFS
+	if cfg.DevMode {
+		templateFiles, staticFiles = os.DirFS("templates"), os.DirFS(".")
+		slog.Warn("development mode: serving templates and static files from disk")
+	}
+
+	// Static files, with STATIC_OVERRIDE_DIR layered on top; palettes are
+	// the stylesheets in css/themes
+	staticSub, _ := fs.Sub(staticFiles, "static")
+	if cfg.StaticOverrideDir != "" {
+		if info, err := os.Stat(cfg.StaticOverrideDir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("STATIC_OVERRIDE_DIR %s is not a directory", cfg.StaticOverrideDir)
+		}
+		staticSub = overlayFS{upper: os.DirFS(cfg.StaticOverrideDir), lower: staticSub}
+	}
+	palettes, err := listPalettes(staticSub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list color palettes: %w", err)
+	}
+	// Files read from disk in development can change under the index, so
+	// they are linked by their plain names
+	var assets *assetIndex
+	if !cfg.DevMode {
+		if assets, err = newAssetIndex(staticSub); err != nil {
+			return nil, fmt.Errorf("failed to fingerprint static files: %w", err)
+		}
+	}
+
+	// Parse templates, letting files in TEMPLATES_OVERRIDE_DIR replace the
+	// built-in ones of the same name
+	templateDirs := []fs.FS{templateFiles}
+	if cfg.TemplatesOverrideDir != "" {
+		if info, err := os.Stat(cfg.TemplatesOverrideDir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("TEMPLATES_OVERRIDE_DIR %s is not a directory", cfg.TemplatesOverrideDir)
+		}
+		templateDirs = append(templateDirs, os.DirFS(cfg.TemplatesOverrideDir))
+	}
+	plugins := plugin.Registered()
+	templateFuncs, err := pluginFuncs(plugins)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plugins: %w", err)
+	}
+	for _, name := range []string{"workspaces", "maintenance", "demo"} {
+		if _, taken := templateFuncs[name]; taken {
+			return nil, fmt.Errorf("failed to load plugins: template function %q is reserved", name)
+		}
+	}
+	// The sidebar's workspace switcher, e.g. {{range workspaces}}.
+	templateFuncs["workspaces"] = workspaces
+	// Read-only maintenance mode, shared by the admin panel that turns it
+	// on and off, the banner, the handlers refusing changes and the jobs.
+	maintenance := new(atomic.Bool)
+	maintenance.Store(cfg.Maintenance)
+	templateFuncs["maintenance"] = maintenance.Load
+	// The demo banner, e.g. {{with demo}}, naming how often it resets.
+	templateFuncs["demo"] = func() time.Duration {
+		if !cfg.DemoMode {
+			return 0
+		}
+		return cfg.DemoReset
+	}
+	tmpl, err := parseTemplates(basePath, assets, templateFuncs, templateDirs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse templates: %w", err)
+	}
+
+	// Initialize handlers
+	// Side effects subscribe to domain events rather than being called from handlers
+	bus := events.NewBus()
+	hub := realtime.NewHub()
+	hub.SetOwnHost(func(host, requestHost string) bool {
+		return isOwnHost(host, requestHost, cfg.ExternalHosts)
+	})
+	bus.Subscribe(hub.HandleEvent)
+
+	h := handlers.New(s, tmpl)
+	h.SetHub(hub)
+	h.SetBasePath(basePath)
+	h.SetPalettes(palettes)
+	if cfg.DevMode {
+		h.SetTemplateReloader(newTemplateReloader(basePath, templateFuncs, templateDirs...).Load)
+	}
+	h.SetBus(bus)
+	h.SetMaintenance(maintenance)
+	if cfg.DemoMode {
+		h.SetDemo(handlers.DemoConfig{WriteLimit: cfg.DemoWriteLimit})
+	}
+	h.SetSlackSigningSecret(cfg.SlackSigningSecret)
+	h.SetInboundEmail(inboundEmail)
+	h.SetGoogleTasks(googleTasks)
+	h.SetGoogleCalendar(googleCalendar)
+	h.SetAdmin(handlers.AdminConfig{
+		DBPath:     cfg.DBPath,
+		BackupDir:  cfg.BackupDir,
+		BackupKeep: cfg.BackupKeep,
+		Workspaces: adminWorkspaces(cfg),
+		Tenants:    cfg.Tenancy != "",
+	})
+
+	githubSync := github.NewSyncer(s, bus)
+	bus.Subscribe(githubSync.HandleEvent, events.TaskCompleted, events.TaskReopened)
+	bus.Subscribe(recurring.NewSpawner(s, bus).HandleEvent, events.TaskCompleted)
+	escalator := escalation.NewEscalator(s)
+	bus.Subscribe(escalator.HandleEvent, events.TaskCreated, events.TaskUpdated, events.TaskReopened)
+	bus.Subscribe(inbox.NewRecorder(s).HandleEvent, inbox.Types...)
+	h.SetGitHub(githubSync)
+	webhooks := webhook.NewSender(s, nil)
+	h.SetWebhooks(webhooks)
+	var reports *mail.Reports
+	if smtpConfig.Enabled() {
+		reports = mail.NewReports(s, mail.NewSMTPMailer(smtpConfig))
+		h.SetReports(reports)
+	}
+	subscribePlugins(bus, s, plugins)
+
+	// Background jobs
+	jobs := scheduler.New()
+	jobs.SetStatusStore(s)
+	jobs.SetPaused(maintenance.Load)
+	if cfg.DemoMode {
+		jobs.Every("demo-reset", cfg.DemoReset, seed.NewDemoReset(s).Run)
+	}
+	jobs.Every("slack-overdue", 15*time.Minute, slack.NewNotifier(s, webhooks).Run)
+	jobs.Every("webhook-retry", time.Minute, webhooks.Run)
+	jobs.Every("github", 5*time.Minute, githubSync.Run)
+	jobs.Every("escalate", time.Hour, escalator.Run)
+	jobs.Every("purge-deleted", time.Minute, housekeeping.NewDeletedTaskPurger(s).Run)
+	jobs.Every("checklist-reset", 5*time.Minute, housekeeping.NewChecklistResetter(s, bus).Run)
+	jobs.Every("inbox-overdue", 5*time.Minute, inbox.NewOverdueReminder(s, bus).Run)
+	if len(cfg.OverdueAlertEmail) > 0 {
+		alerter := mail.NewOverdueAlerter(s, mail.NewSMTPMailer(smtpConfig), cfg.OverdueAlertEmail)
+		jobs.Every("email-overdue", 5*time.Minute, alerter.Run)
+	}
+	if googleTasks.Enabled() {
+		jobs.Every("google-tasks", 10*time.Minute, gtasks.NewSyncer(s, bus, googleTasks).Run)
+		jobs.Every("google-calendar", 10*time.Minute, gcal.NewSyncer(s, bus, googleCalendar).Run)
+	}
+	if reports != nil {
+		jobs.Every("email-reports", time.Minute, reports.Run)
+	}
+	if len(cfg.DigestEmail) > 0 {
+		digest := mail.NewDigest(s, mail.NewSMTPMailer(smtpConfig), cfg.DigestEmail)
+		jobs.Every("email-digest", 5*time.Minute, digest.Run)
+	}
+	if cfg.BackupDir != "" {
+		if err := jobs.Cron("backup", "0 3 * * *", housekeeping.NewBackup(s, cfg.BackupDir, cfg.BackupKeep).Run); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.AutoArchiveDays > 0 {
+		if err := jobs.Cron("auto-archive", "30 3 * * *", housekeeping.NewAutoArchiver(s, bus, cfg.AutoArchiveDays).Run); err != nil {
+			return nil, err
+		}
+	}
+	for name, spec := range cfg.JobSchedules {
+		if err := jobs.Override(name, spec); err != nil {
+			return nil, fmt.Errorf("JOB_SCHEDULES: %w", err)
+		}
+	}
+
+	// Create router
+	r := chi.NewRouter()
+	handle := h.Handle
+	r.NotFound(handle(h.NotFound))
+
+	// Middleware
+	r.Use(forwardedHeaders(trustedProxies))
+	r.Use(requestID)
+	r.Use(requestLogger)
+	if accessLog != nil {
+		r.Use(accessLog)
+	}
+	r.Use(middleware.Recoverer)
+	r.Use(middleware.Compress(5, compressedTypes...))
+	r.Use(securityHeaders(security))
+	r.Use(cors(corsOrigins))
+	r.Use(h.ReadOnly)
+	r.Use(h.DemoLimits)
+
+	// Integration webhooks authenticate with signatures rather than same-origin checks
+	r.Post("/integrations/slack/command", handle(h.NotInDemo(h.SlackCommand)))
+	r.Post("/integrations/email/inbound", handle(h.NotInDemo(h.InboundEmail)))
+	r.Post("/integrations/github/webhook", handle(h.NotInDemo(h.GitHubWebhook)))
+
+	// Profiling and diagnostics, only when a token is configured
+	if cfg.DebugToken != "" {
+		debugRoutes(r, cfg.DebugToken, basePath, s, cfg.DBPath)
+	}
+
+	// Capture authenticates with a token so bookmarklets work from any page
+	r.Get("/capture", handle(h.NotInDemo(h.NotInMaintenance(h.Capture))))
+	r.Post("/capture", handle(h.NotInDemo(h.Capture)))
+
+	// Apple Shortcuts API, also token-authenticated
+	r.Post("/shortcuts/tasks", handle(h.NotInDemo(h.ShortcutsAddTask)))
+	r.Get("/shortcuts/today", handle(h.ShortcutsToday))
+	r.Post("/shortcuts/complete", handle(h.NotInDemo(h.ShortcutsCompleteTask)))
+
+	r.Group(func(r chi.Router) {
+		r.Use(csrfOriginCheck(corsOrigins, cfg.ExternalHosts))
+
+		// Static files
+		if cfg.DevMode {
+			r.Handle("/static/*", noStore(http.StripPrefix("/static/", http.FileServer(http.FS(staticSub)))))
+		} else {
+			r.Handle("/static/*", http.StripPrefix("/static/", assets.handler(staticSub)))
+		}
+
+		// Installable app: the manifest, and the service worker served from
+		// the root so its scope covers every page
+		r.Get("/manifest.webmanifest", handle(h.Manifest))
+		r.Get("/sw.js", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "no-cache")
+			http.ServeFileFS(w, r, staticSub, "js/sw.js")
+		})
+
+		// Page routes
+		r.Get("/", handle(h.Home))
+		r.Get("/projects/completed", handle(h.CompletedProjects))
+		r.Get("/projects/{id}", handle(h.KanbanBoard))
+		r.Get("/tasks", handle(h.AllTasks))
+		r.Get("/tasks/{id}", handle(h.TaskDetail))
+		r.Post("/tasks/filters", handle(h.SaveFilter))
+		r.Post("/tasks/filters/{id}/delete", handle(h.DeleteSavedFilter))
+		r.Get("/attachments/{id}", handle(h.DownloadTaskAttachment))
+		r.Get("/today", handle(h.MyDay))
+		r.Get("/upcoming", handle(h.Upcoming))
+		r.Get("/someday", handle(h.Someday))
+		r.Get("/waiting", handle(h.Waiting))
+		r.Get("/recent", handle(h.Recent))
+		r.Get("/agenda", handle(h.Agenda))
+		r.Get("/review", handle(h.Review))
+		r.Get("/review/{id}", handle(h.ReviewProject))
+		r.Post("/review/{id}", handle(h.CompleteProjectReview))
+		r.Post("/review/tasks/{id}/snooze", handle(h.SnoozeReviewTask))
+		r.Post("/review/tasks/{id}/priority", handle(h.ReprioritizeReviewTask))
+		r.Get("/streak", handle(h.Streak))
+		r.Post("/streak/goal", handle(h.UpdateDailyGoal))
+		r.Get("/habits", handle(h.Habits))
+		r.Post("/habits", handle(h.CreateHabit))
+		r.Post("/habits/{id}/checkin", handle(h.CheckInHabit))
+		r.Delete("/habits/{id}", handle(h.DeleteHabit))
+		r.Get("/focus", handle(h.FocusTimer))
+		r.Get("/notifications", handle(h.NotificationBell))
+		r.Get("/archive", handle(h.Archive))
+		r.Get("/archive/projects", handle(h.ArchiveProjects))
+		r.Get("/archive/tasks", handle(h.CompletedTasks))
+		r.Get("/archive/tasks/{id}/more", handle(h.CompletedTasksMore))
+
+		// Import
+		r.Get("/import", handle(h.ImportPage))
+		r.Post("/import", handle(h.Import))
+		r.Post("/import/mstodo", handle(h.NotInDemo(h.ImportMicrosoftToDo)))
+		r.Get("/export/taskwarrior.json", handle(h.ExportTaskwarrior))
+		r.Get("/export/projects.zip", handle(h.ExportProjects))
+
+		// Settings
+		r.Get("/settings", handle(h.Settings))
+		r.Post("/settings", handle(h.UpdateSettings))
+		r.Post("/settings/theme", handle(h.ToggleTheme))
+		r.Get("/settings/reports", handle(h.ReportSettings))
+		r.Post("/settings/reports", handle(h.NotInDemo(h.CreateReportSchedule)))
+		r.Post("/settings/reports/{id}/send", handle(h.NotInDemo(h.SendReportNow)))
+		r.Post("/settings/reports/{id}/delete", handle(h.DeleteReportSchedule))
+		r.Get("/settings/jobs", handle(h.Jobs))
+		r.Get("/settings/slack", handle(h.SlackSettings))
+		r.Post("/settings/slack/{team_id}", handle(h.NotInDemo(h.UpdateSlackWorkspace)))
+		r.Get("/settings/google", handle(h.GoogleSettings))
+		r.Post("/settings/google", handle(h.NotInDemo(h.UpdateGoogleSettings)))
+		r.Post("/settings/google/disconnect", handle(h.DisconnectGoogle))
+		r.Get("/integrations/google/connect", handle(h.NotInDemo(h.ConnectGoogle)))
+		r.Get("/integrations/google/callback", handle(h.NotInMaintenance(h.GoogleCallback)))
+		r.Get("/settings/calendar", handle(h.CalendarSettings))
+		r.Post("/settings/calendar/disconnect", handle(h.DisconnectGoogleCalendar))
+		r.Get("/integrations/google/calendar/connect", handle(h.NotInDemo(h.ConnectGoogleCalendar)))
+		r.Get("/integrations/google/calendar/callback", handle(h.NotInMaintenance(h.GoogleCalendarCallback)))
+		r.Get("/settings/github", handle(h.GitHubSettings))
+		r.Post("/settings/github", handle(h.NotInDemo(h.SaveGitHubLink)))
+		r.Post("/settings/github/{project_id}/delete", handle(h.DeleteGitHubLink))
+		r.Get("/settings/capture", handle(h.CaptureSettings))
+		r.Post("/settings/capture/token", handle(h.NotInDemo(h.ResetCaptureToken)))
+		r.Post("/settings/capture/disable", handle(h.DisableCapture))
+		r.Get("/settings/shortcuts", handle(h.ShortcutsSettings))
+		r.Post("/settings/shortcuts/token", handle(h.NotInDemo(h.ResetShortcutsToken)))
+		r.Post("/settings/shortcuts/disable", handle(h.DisableShortcuts))
+
+		// Admin panel, only when a token is configured
+		if cfg.AdminToken != "" {
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(tokenAuth(cfg.AdminToken, "admin_token", basePath+"/admin"))
+				r.Get("/", handle(h.Admin))
+				r.Post("/backup", handle(h.AdminBackup))
+				r.Post("/checkpoint", handle(h.AdminCheckpoint))
+				r.Post("/cleanup", handle(h.AdminCleanup))
+				r.Post("/diagnostic", handle(h.AdminDiagnostic))
+				r.Post("/maintenance", handle(h.AdminMaintenance))
+				r.Post("/webhooks/{id}/redeliver", handle(h.AdminRedeliverWebhook))
+			})
+		}
+
+		// Realtime updates
+		r.Get("/ws", handle(h.Realtime))
+
+		// GraphQL, for dashboards that want several things in one request
+		r.Handle("/graphql", graphql.NewHandler(s, bus, maintenance.Load))
+
+		// Project API routes
+		r.Get("/api/projects/form", handle(h.GetProjectForm))
+		r.Get("/api/projects/{id}/form", handle(h.GetProjectForm))
+		r.Post("/api/projects", handle(h.CreateProject))
+		r.Get("/api/projects/{id}", handle(h.GetProject))
+		r.Put("/api/projects/{id}", handle(h.UpdateProject))
+		r.Get("/api/projects/{id}/header", handle(h.GetProjectHeader))
+		r.Get("/api/projects/{id}/header/form", handle(h.GetProjectHeaderForm))
+		r.Put("/api/projects/{id}/header", handle(h.UpdateProjectHeader))
+		r.Post("/api/projects/{id}/complete", handle(h.CompleteProject))
+		r.Post("/api/projects/{id}/reopen", handle(h.ReopenProject))
+		r.Post("/api/projects/{id}/sort", handle(h.SetProjectSortMode))
+		r.Delete("/api/projects/{id}", handle(h.DeleteProject))
+		r.Post("/api/projects/reorder", handle(h.ReorderProjects))
+		r.Get("/api/projects/{id}/notifications", handle(h.GetProjectNotificationPrefs))
+		r.Put("/api/projects/{id}/notifications", handle(h.UpdateProjectNotificationPrefs))
+		r.Get("/api/projects/{id}/links", handle(h.ListProjectLinks))
+		r.Post("/api/projects/{id}/links", handle(h.CreateProjectLink))
+		r.Put("/api/links/{id}", handle(h.UpdateProjectLink))
+		r.Delete("/api/links/{id}", handle(h.DeleteProjectLink))
+
+		// Task API routes
+		r.Get("/api/projects/{project_id}/tasks/form", handle(h.GetTaskForm))
+		r.Get("/api/tasks", handle(h.ListTasks))
+		r.Post("/api/tasks/quick", handle(h.QuickAdd))
+		r.Get("/api/quickfind", handle(h.QuickFind))
+		r.Get("/api/tasks/{id}/form", handle(h.GetTaskForm))
+		r.Post("/api/projects/{id}/tasks", handle(h.CreateTask))
+		r.Post("/api/projects/{id}/tasks/batch", handle(h.CreateTaskBatch))
+		r.Get("/api/tasks/{id}", handle(h.GetTask))
+		r.Put("/api/tasks/{id}", handle(h.UpdateTask))
+		r.Delete("/api/tasks/{id}", handle(h.DeleteTask))
+		r.Post("/api/tasks/undo", handle(h.UndoDeleteTask))
+		r.Post("/api/tasks/{id}/move", handle(h.MoveTask))
+		r.Post("/api/tasks/{id}/project", handle(h.MoveTaskToProject))
+		r.Post("/api/tasks/{id}/toggle", handle(h.ToggleTask))
+		r.Post("/api/tasks/{id}/comments", handle(h.CreateTaskComment))
+		r.Delete("/api/comments/{id}", handle(h.DeleteTaskComment))
+		r.Post("/api/tasks/{id}/subtasks", handle(h.CreateSubtask))
+		r.Post("/api/subtasks/{id}/toggle", handle(h.ToggleSubtask))
+		r.Delete("/api/subtasks/{id}", handle(h.DeleteSubtask))
+		r.Post("/api/tasks/{id}/attachments", handle(h.NotInDemo(h.CreateTaskAttachment)))
+		r.Post("/api/tasks/{id}/someday", handle(h.ParkTask))
+		r.Delete("/api/tasks/{id}/someday", handle(h.PromoteTask))
+		r.Delete("/api/tasks/{id}/waiting", handle(h.StopWaiting))
+		r.Get("/api/tasks/{id}/links", handle(h.ListTaskLinks))
+		r.Post("/api/tasks/{id}/links", handle(h.CreateTaskLink))
+		r.Delete("/api/task-links/{id}", handle(h.DeleteTaskLink))
+		r.Post("/api/tasks/{id}/focus", handle(h.StartFocus))
+		r.Post("/api/focus/stop", handle(h.StopFocus))
+		r.Get("/api/focus", handle(h.FocusTotals))
+		r.Get("/api/recent", handle(h.ListRecent))
+		r.Get("/api/notifications", handle(h.ListNotifications))
+		r.Post("/api/notifications/read-all", handle(h.MarkAllNotificationsRead))
+		r.Post("/api/notifications/{id}/read", handle(h.MarkNotificationRead))
+		r.Get("/api/my-day", handle(h.ListMyDay))
+		r.Post("/api/my-day/rollover", handle(h.RolloverMyDay))
+		r.Post("/api/my-day/rollover/dismiss", handle(h.DismissMyDayRollover))
+		r.Post("/api/my-day/{id}", handle(h.AddToMyDay))
+		r.Delete("/api/my-day/{id}", handle(h.RemoveFromMyDay))
+		r.Delete("/api/attachments/{id}", handle(h.DeleteTaskAttachment))
+		r.Post("/api/sync", handle(h.Sync))
+		r.Get("/api/changes", handle(h.ListChanges))
+		r.Post("/api/projects/{id}/tasks/reorder", handle(h.ReorderTasks))
+
+		// Reports
+		r.Get("/api/reports", handle(h.Reports))
+
+		// Demo data for trying things out in development
+		if cfg.DevMode {
+			r.Post("/api/admin/seed", handle(h.SeedDemo))
+		}
+
+		// Pages and APIs added by plugins
+		mountPlugins(r, basePath, plugins)
+	})
+
+	// Serve under BASE_PATH; routes above are relative to it
+	var handler http.Handler = r
+	if basePath != "" {
+		handler = http.StripPrefix(basePath, r)
+	}
+
+	return &Server{handler: handler, basePath: basePath, store: s, bus: bus, jobs: jobs, maintenance: maintenance}, nil
+}
+
+// ServeHTTP serves the app's pages, API and static files, passing requests
+// under /w/{slug}, or for {slug}.TenantDomain, to that workspace.
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route := srv.workspaceFor
+	if srv.tenantDomain != "" {
+		route = srv.tenantFor
+	}
+	ws, answered := route(w, r)
+	switch {
+	case answered:
+	case ws != nil:
+		ws.ServeHTTP(w, r)
+	default:
+		srv.handler.ServeHTTP(w, r)
+	}
+}
+
+// BasePath returns Config.BasePath in the form it is served under: "" or a
+// path such as "/tasks" without a trailing slash.
+func (srv *Server) BasePath() string {
+	return srv.basePath
+}
+
+// Start runs the background jobs, of every workspace, until ctx is
+// canceled.
+func (srv *Server) Start(ctx context.Context) {
+	srv.jobs.Start(ctx)
+	for _, ws := range srv.workspaces {
+		ws.Start(ctx)
+	}
+}
+
+// GRPCServer returns a gRPC server for the TaskService API, sharing the main
+// workspace's store and events so changes made over gRPC reach open browsers.
+func (srv *Server) GRPCServer() *grpc.Server {
+	return rpc.NewGRPCServer(srv.store, srv.bus, grpc.UnaryInterceptor(rpc.ReadOnly(srv.maintenance.Load)))
+}
+
+// parseTemplates parses *.html and partials/*.html from each directory in
+// turn. A file in a later directory replaces the template of the same name
+// from an earlier one, so dirs run from the built-in templates to overrides.
+// extra adds template functions, such as those from plugins, and may not
+// redefine the built-in ones.
+func parseTemplates(basePath string, assets *assetIndex, extra template.FuncMap, dirs ...fs.FS) (*template.Template, error) {
+	// Custom template functions
+	funcMap := template.FuncMap{
+		"add": func(a, b int) int { return a + b },
+		// App URLs start with the base path, e.g. href="{{base}}/settings".
+		"base": func() string { return basePath },
+		// Static files are linked by their fingerprinted URL, e.g.
+		// href="{{asset "css/styles.css"}}".
+		"asset": func(name string) string { return basePath + "/static/" + assets.path(name) },
+		// Dates follow the date format in PageData.Prefs, e.g. {{formatDate $.Prefs .DueDate}}.
+		"formatDate": models.Settings.FormatDate,
+		"shortDate":  models.Settings.FormatShortDate,
+		// Text is translated with PageData.Lang, e.g. {{t $.Lang "Upcoming"}}.
+		"t": (*i18n.Localizer).T,
+		// Notes and comments are written in Markdown, e.g. {{markdown .Notes}}.
+		"markdown": markdown.Render,
+		// The sidebar sums its projects' counts, e.g. {{totalCounts .ActiveProjects}}.
+		"totalCounts": models.TotalCounts,
+		// Focus time reads as "25m" or "1h 05m", e.g. {{duration .Total}}.
+		"duration": models.FormatDuration,
+		"dict": func(values ...interface{}) map[string]interface{} {
+			if len(values)%2 != 0 {
+				return nil
+			}
+			dict := make(map[string]interface{}, len(values)/2)
+			for i := 0; i < len(values); i += 2 {
+				key, ok := values[i].(string)
+				if !ok {
+					continue
+				}
+				dict[key] = values[i+1]
+			}
+			return dict
+		},
+	}
+	for name, fn := range extra {
+		if _, ok := funcMap[name]; ok {
+			return nil, fmt.Errorf("template function %q is already defined", name)
+		}
+		funcMap[name] = fn
+	}
+
+	tmpl := template.New("").Funcs(funcMap)
+
+	// Parse all templates
+	patterns := []string{
+		"*.html",
+		"partials/*.html",
+	}
+
+	for _, fsys := range dirs {
+		for _, pattern := range patterns {
+			matches, err := fs.Glob(fsys, pattern)
+			if err != nil {
+				return nil, fmt.Errorf("failed to glob pattern %s: %w", pattern, err)
+			}
+
+			for _, match := range matches {
+				content, err := fs.ReadFile(fsys, match)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read template %s: %w", match, err)
+				}
+
+				name := filepath.Base(match)
+				_, err = tmpl.New(name).Parse(string(content))
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse template %s: %w", match, err)
+				}
+			}
+		}
+	}
+
+	return tmpl, nil
+}
+
+// listPalettes returns the names of the stylesheets in css/themes that can be
+// chosen as color palettes.
+func listPalettes(static fs.FS) ([]string, error) {
+	matches, err := fs.Glob(static, "css/themes/*.css")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, match := range matches {
+		name := strings.TrimSuffix(path.Base(match), ".css")
+		if !models.ValidPaletteName(name) {
+			slog.Warn("ignoring color palette: use lower-case letters, digits and dashes", "file", match)
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// normalizeBasePath turns "mytasks/" or "/mytasks" into "/mytasks", and "/"
+// into "" for serving at the root.
+func normalizeBasePath(p string) string {
+	p = strings.Trim(strings.TrimSpace(p), "/")
+	if p == "" {
+		return ""
+	}
+	return "/" + p
+}
+
+func hasNonEmpty(values []string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return true
+		}
+	}
+	return false
+}
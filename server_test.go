@@ -0,0 +1,393 @@
+package mytasks
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"mytasks/internal/models"
+	"mytasks/internal/store"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := store.NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestNewServer_ServesUnderBasePath(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.BasePath = "tasks/"
+	srv, err := NewServer(cfg, newTestStore(t))
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	if srv.BasePath() != "/tasks" {
+		t.Errorf("BasePath() = %q, want /tasks", srv.BasePath())
+	}
+
+	for _, path := range []string{"/tasks/", "/tasks/upcoming", "/tasks/static/css/styles.css"} {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: status %d, want 200", path, rec.Code)
+		}
+	}
+}
+
+func TestNewServer_RejectsInconsistentConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DigestEmail = []string{"me@example.com"}
+	if _, err := NewServer(cfg, newTestStore(t)); err == nil {
+		t.Error("expected an error for DIGEST_EMAIL without SMTP_HOST")
+	}
+}
+
+func TestNewServer_WritesAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.BasePath = "/tasks"
+	cfg.AccessLog = &buf
+	srv, err := NewServer(cfg, newTestStore(t))
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks/upcoming", nil))
+	if !strings.Contains(buf.String(), `"GET /tasks/upcoming HTTP/1.1" 200 `) {
+		t.Errorf("expected a combined log line for the request, got %q", buf.String())
+	}
+
+	cfg.AccessLogFormat = "xml"
+	if _, err := NewServer(cfg, newTestStore(t)); err == nil {
+		t.Error("expected an error for an unknown access log format")
+	}
+}
+
+func TestNewServer_AdminNeedsToken(t *testing.T) {
+	cfg := DefaultConfig()
+	srv, err := NewServer(cfg, newTestStore(t))
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /admin without ADMIN_TOKEN: status %d, want 404", rec.Code)
+	}
+
+	cfg.AdminToken = "secret"
+	if srv, err = NewServer(cfg, newTestStore(t)); err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	for token, want := range map[string]int{"": http.StatusForbidden, "wrong": http.StatusForbidden, "secret": http.StatusOK} {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin?token="+token, nil))
+		if rec.Code != want {
+			t.Errorf("GET /admin?token=%s: status %d, want %d", token, rec.Code, want)
+		}
+	}
+}
+
+func TestNewServer_ServesServiceWorkerFromRoot(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.BasePath = "/tasks"
+	srv, err := NewServer(cfg, newTestStore(t))
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks/sw.js", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "self.addEventListener") {
+		t.Fatalf("GET /tasks/sw.js: status %d, body %.80q", rec.Code, rec.Body.String())
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("Cache-Control = %q, want no-cache", cc)
+	}
+}
+
+func TestNewServer_GzipsExports(t *testing.T) {
+	srv, err := NewServer(DefaultConfig(), newTestStore(t))
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	for _, path := range []string{"/api/reports?format=csv", "/export/taskwarrior.json"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Encoding") != "gzip" {
+			t.Errorf("GET %s: status %d, Content-Encoding %q", path, rec.Code, rec.Header().Get("Content-Encoding"))
+		}
+	}
+}
+
+func TestNewServer_FingerprintsStaticFiles(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.BasePath = "/tasks"
+	srv, err := NewServer(cfg, newTestStore(t))
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	page := get("/tasks/upcoming", "").Body.String()
+	css := regexp.MustCompile(`/tasks/static/css/styles\.[0-9a-f]{10}\.css`).FindString(page)
+	if css == "" {
+		t.Fatalf("expected the page to link a fingerprinted stylesheet:\n%.600s", page)
+	}
+	rec := get(css, "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "{") {
+		t.Fatalf("GET %s: status %d", css, rec.Code)
+	}
+	if cc := rec.Header().Get("Cache-Control"); !strings.Contains(cc, "immutable") {
+		t.Errorf("Cache-Control = %q, want immutable", cc)
+	}
+
+	// The plain name still works, revalidated by ETag.
+	rec = get("/tasks/static/css/styles.css", "")
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || rec.Header().Get("Cache-Control") != "no-cache" || etag == "" {
+		t.Fatalf("GET styles.css: status %d, Cache-Control %q, ETag %q", rec.Code, rec.Header().Get("Cache-Control"), etag)
+	}
+	if rec = get("/tasks/static/css/styles.css", etag); rec.Code != http.StatusNotModified {
+		t.Errorf("GET styles.css with its ETag: status %d, want 304", rec.Code)
+	}
+}
+
+func TestNewServer_ServesWorkspaces(t *testing.T) {
+	personal := newTestStore(t)
+	if err := personal.CreateProject(context.Background(), &models.Project{Name: "Garden", Type: "project"}); err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.Workspaces = []Workspace{{Slug: "personal", Name: "Personal", Store: personal}}
+	srv, err := NewServer(cfg, newTestStore(t))
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/w/personal/upcoming", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "Garden") {
+		t.Errorf("GET /w/personal/upcoming: status %d, want 200 listing the personal project", rec.Code)
+	}
+	if !strings.Contains(body, `href="/w/personal/" class="workspace-link active"`) {
+		t.Errorf("workspace page has no switcher marking Personal current")
+	}
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/upcoming", nil))
+	if strings.Contains(rec.Body.String(), "Garden") {
+		t.Errorf("main workspace lists the personal project")
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/w/personal", nil))
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/w/personal/" {
+		t.Errorf("GET /w/personal: status %d, Location %q", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestNewServer_RejectsBadWorkspaces(t *testing.T) {
+	for _, workspaces := range [][]Workspace{
+		{{Slug: "Work", Store: newTestStore(t)}},
+		{{Slug: "work", Store: newTestStore(t)}, {Slug: "work", Store: newTestStore(t)}},
+		{{Slug: "work"}},
+	} {
+		cfg := DefaultConfig()
+		cfg.Workspaces = workspaces
+		if _, err := NewServer(cfg, newTestStore(t)); err == nil {
+			t.Errorf("NewServer accepted workspaces %+v", workspaces)
+		}
+	}
+}
+
+func TestNewServer_ServesTenantsBySubdomain(t *testing.T) {
+	family := newTestStore(t)
+	if err := family.CreateProject(context.Background(), &models.Project{Name: "Chores", Type: "project"}); err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.Workspaces = []Workspace{{Slug: "family", Store: family}}
+	cfg.Tenancy = TenancySubdomain
+	cfg.TenantDomain = "tasks.example.org"
+	srv, err := NewServer(cfg, newTestStore(t))
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	get := func(host, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+	rec := get("Family.tasks.example.org:8080", "/upcoming")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Chores") {
+		t.Errorf("tenant host: status %d, want 200 listing the tenant's project", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "workspace-switcher") {
+		t.Errorf("tenant page links to other workspaces")
+	}
+	if rec := get("tasks.example.org", "/upcoming"); strings.Contains(rec.Body.String(), "Chores") {
+		t.Errorf("main host lists the tenant's project")
+	}
+	if rec := get("tasks.example.org", "/w/family/upcoming"); strings.Contains(rec.Body.String(), "Chores") {
+		t.Errorf("tenant reachable by path from the main host")
+	}
+	if rec := get("nobody.tasks.example.org", "/upcoming"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown tenant: status %d, want 404", rec.Code)
+	}
+}
+
+func TestWorkspaceConfig_TenantsDropOperatorNotifications(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SMTPHost, cfg.SMTPFrom, cfg.SMTPPassword = "smtp.example.org", "ops@example.org", "secret"
+	cfg.OverdueAlertEmail = []string{"ops@example.org"}
+	cfg.DigestEmail = []string{"ops@example.org"}
+	cfg.SlackSigningSecret = "slack"
+
+	ws := Workspace{Slug: "family"}
+	if got := workspaceConfig(cfg, ws); got.SMTPHost == "" || len(got.OverdueAlertEmail) == 0 {
+		t.Errorf("workspace lost the notification settings")
+	}
+	cfg.Tenancy = TenancyPath
+	got := workspaceConfig(cfg, ws)
+	if got.SMTPHost != "" || got.SMTPFrom != "" || got.SMTPPassword != "" {
+		t.Errorf("tenant keeps SMTP settings: %q %q", got.SMTPHost, got.SMTPFrom)
+	}
+	if len(got.OverdueAlertEmail) != 0 || len(got.DigestEmail) != 0 {
+		t.Errorf("tenant keeps alert recipients: %v %v", got.OverdueAlertEmail, got.DigestEmail)
+	}
+	if got.SlackSigningSecret != "" {
+		t.Errorf("tenant keeps the Slack signing secret")
+	}
+}
+
+func TestNewServer_RejectsBadTenancy(t *testing.T) {
+	for _, tenancy := range []string{"subdomain", "host"} {
+		cfg := DefaultConfig()
+		cfg.Tenancy = tenancy
+		if _, err := NewServer(cfg, newTestStore(t)); err == nil {
+			t.Errorf("NewServer accepted TENANCY=%s without TENANT_DOMAIN", tenancy)
+		}
+	}
+}
+
+func TestNewServer_StartsInMaintenance(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Maintenance = true
+	srv, err := NewServer(cfg, newTestStore(t))
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/upcoming", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `class="maintenance-banner"`) {
+		t.Errorf("GET /upcoming: status %d, want 200 with the maintenance banner", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/projects", strings.NewReader("name=Garden&type=project"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Origin", "http://example.com")
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("POST /api/projects: status %d, want 503", rec.Code)
+	}
+
+	// GraphQL queries are POSTed too; only its mutations are refused.
+	for body, want := range map[string]int{
+		`{"query": "{ projects { id } }"}`:                            http.StatusOK,
+		`{"query": "mutation { createProject(name: \"x\") { id } }"}`: http.StatusServiceUnavailable,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", "http://example.com")
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("POST /graphql %s: status %d, want %d", body, rec.Code, want)
+		}
+	}
+}
+
+func TestNewServer_RunsDemo(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.CreateProject(context.Background(), &models.Project{Name: "Private", Type: "project"}); err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.DemoMode = true
+	srv, err := NewServer(cfg, s)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv.Start(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	var projects []models.Project
+	for time.Now().Before(deadline) {
+		projects, _ = s.ListProjects(ctx)
+		if len(projects) > 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(projects) < 2 || projects[0].Name == "Private" {
+		t.Fatalf("expected the demo data in place of the store's, got %+v", projects)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/upcoming", nil))
+	if !strings.Contains(rec.Body.String(), `class="demo-banner"`) {
+		t.Errorf("expected the demo banner")
+	}
+	// Capture would add tasks past the write limit with GETs.
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/capture?title=Spam", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("GET /capture in the demo: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	// So would Shortcuts, with a token any visitor could make.
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/settings/shortcuts/token", nil)
+	req.Header.Set("Origin", "http://example.com")
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("POST /settings/shortcuts/token in the demo: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/shortcuts/tasks", strings.NewReader("title=Spam")))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("POST /shortcuts/tasks in the demo: status %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	cfg.Workspaces = []Workspace{{Slug: "personal", Store: newTestStore(t)}}
+	if _, err := NewServer(cfg, newTestStore(t)); err == nil {
+		t.Errorf("NewServer accepted DEMO_MODE with WORKSPACES")
+	}
+}
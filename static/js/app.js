@@ -1,13 +1,24 @@
+// The path prefix the app is served under, e.g. "/mytasks" behind a reverse
+// proxy, taken from this script's own URL.
+const basePath = document.currentScript
+    ? new URL(document.currentScript.src).pathname.replace(/\/static\/.*$/, '')
+    : '';
+
 // Initialize Kanban board and other sortables
 document.addEventListener('DOMContentLoaded', function() {
     initializeKanban();
     initializeSidebarSortable();
     initializeSidebarControls();
     initializeFormTriggers();
+    initializeRealtime();
+    initializeQuickFind();
 });
 
 // Re-initialize after htmx swaps
 document.addEventListener('htmx:afterSwap', function() {
+    // Column counts follow the cards shown, which may be filtered, so they
+    // are counted here rather than sent with the response.
+    updateKanbanCounts();
     initializeKanban();
     initializeSidebarControls();
     initializeFormTriggers();
@@ -156,6 +167,11 @@ function initializeKanban() {
 
     const projectId = kanbanPage.dataset.projectId;
 
+    // Cards can only be dragged on a whole column in manual order: a
+    // filtered board would renumber just the tasks shown, and a sorted one
+    // would jump back to its order on the next load.
+    if (!('canReorder' in kanbanPage.dataset)) return;
+
     document.querySelectorAll('.kanban-cards').forEach(function(column) {
         if (column._sortable) return;
 
@@ -165,7 +181,18 @@ function initializeKanban() {
             ghostClass: 'sortable-ghost',
             dragClass: 'sortable-drag',
             chosenClass: 'sortable-chosen',
+            onStart: function(evt) {
+                draggedTaskCard = evt.item;
+            },
             onEnd: function(evt) {
+                draggedTaskCard = null;
+                // Dropped on a project in the sidebar: the drop handler
+                // moved it there, so there is nothing to reorder here.
+                if (droppedOnProject) {
+                    droppedOnProject = false;
+                    return;
+                }
+
                 const taskId = parseInt(evt.item.dataset.id);
                 const newStatus = evt.to.dataset.status;
                 const oldStatus = evt.from.dataset.status;
@@ -175,26 +202,30 @@ function initializeKanban() {
                     const newIndex = Array.from(evt.to.querySelectorAll('.kanban-card'))
                         .findIndex(card => parseInt(card.dataset.id) === taskId);
 
-                    fetch('/api/tasks/' + taskId + '/move', {
+                    fetch(basePath + '/api/tasks/' + taskId + '/move', {
                         method: 'POST',
-                        headers: { 'Content-Type': 'application/json' },
+                        headers: { 'Content-Type': 'application/json', 'X-Client-ID': realtimeClientId },
                         body: JSON.stringify({
                             status: newStatus,
                             sort_order: newIndex + 1
                         })
-                    });
+                    }).then(refreshStreak);
 
                     // Keep inline edit form state in sync with card column immediately.
                     syncKanbanCardEditStatus(evt.item, newStatus);
                 }
 
+                // Reordering by hand switches the project back to manual sorting
+                const sortMode = document.querySelector('.sort-mode-select');
+                if (sortMode) sortMode.value = 'manual';
+
                 // Reorder within the target column
                 const ids = Array.from(evt.to.querySelectorAll('.kanban-card'))
                     .map(card => parseInt(card.dataset.id));
 
-                fetch('/api/projects/' + projectId + '/tasks/reorder?status=' + newStatus, {
+                fetch(basePath + '/api/projects/' + projectId + '/tasks/reorder?status=' + newStatus, {
                     method: 'POST',
-                    headers: { 'Content-Type': 'application/json' },
+                    headers: { 'Content-Type': 'application/json', 'X-Client-ID': realtimeClientId },
                     body: JSON.stringify({ ids: ids })
                 });
 
@@ -203,9 +234,9 @@ function initializeKanban() {
                     const sourceIds = Array.from(evt.from.querySelectorAll('.kanban-card'))
                         .map(card => parseInt(card.dataset.id));
 
-                    fetch('/api/projects/' + projectId + '/tasks/reorder?status=' + oldStatus, {
+                    fetch(basePath + '/api/projects/' + projectId + '/tasks/reorder?status=' + oldStatus, {
                         method: 'POST',
-                        headers: { 'Content-Type': 'application/json' },
+                        headers: { 'Content-Type': 'application/json', 'X-Client-ID': realtimeClientId },
                         body: JSON.stringify({ ids: sourceIds })
                     });
                 }
@@ -217,6 +248,48 @@ function initializeKanban() {
     });
 }
 
+// A kanban card can be dropped on another project in the sidebar to move
+// the task there. The response updates both projects' counts out of band.
+let draggedTaskCard = null;
+let droppedOnProject = false;
+
+function projectDropTarget(event) {
+    if (!draggedTaskCard || !(event.target instanceof Element)) return null;
+    const item = event.target.closest('#sidebar-projects .sidebar-item[data-project-id]');
+    const kanbanPage = document.querySelector('.kanban-page');
+    if (!item || (kanbanPage && item.dataset.projectId === kanbanPage.dataset.projectId)) return null;
+    return item;
+}
+
+document.addEventListener('dragover', function(event) {
+    const item = projectDropTarget(event);
+    if (!item) return;
+    event.preventDefault();
+    item.classList.add('drop-target');
+});
+
+document.addEventListener('dragleave', function(event) {
+    const item = projectDropTarget(event);
+    if (item && !item.contains(event.relatedTarget)) {
+        item.classList.remove('drop-target');
+    }
+});
+
+document.addEventListener('drop', function(event) {
+    const item = projectDropTarget(event);
+    if (!item) return;
+    event.preventDefault();
+    item.classList.remove('drop-target');
+    droppedOnProject = true;
+
+    const card = draggedTaskCard;
+    htmx.ajax('POST', basePath + '/api/tasks/' + card.dataset.id + '/project', {
+        target: card,
+        swap: 'delete',
+        values: { project_id: item.dataset.projectId }
+    }).then(updateKanbanCounts);
+}, true);
+
 function syncKanbanCardEditStatus(card, status) {
     const statusSelect = card.querySelector('.kanban-card-edit select[name="status"]');
     if (statusSelect) {
@@ -248,9 +321,9 @@ function initializeSidebarSortable() {
                     })
                     .filter(function(id) { return id > 0; });
 
-                fetch('/api/projects/reorder', {
+                fetch(basePath + '/api/projects/reorder', {
                     method: 'POST',
-                    headers: { 'Content-Type': 'application/json' },
+                    headers: { 'Content-Type': 'application/json', 'X-Client-ID': realtimeClientId },
                     body: JSON.stringify({ ids: ids })
                 });
             }
@@ -290,6 +363,15 @@ function showEditProjectForm() {
     }
 }
 
+function showBatchTaskForm() {
+    var form = document.getElementById('batch-task-form');
+    if (form) {
+        form.classList.remove('hidden');
+        var input = form.querySelector('textarea[name="text"]');
+        if (input) input.focus();
+    }
+}
+
 function showKanbanTaskForm(status) {
     // Hide all kanban forms first
     document.querySelectorAll('[id^="kanban-form-"]').forEach(function(f) {
@@ -325,6 +407,30 @@ function toggleKanbanCardEdit(taskId) {
     }
 }
 
+function toggleTaskPageEdit() {
+    var form = document.getElementById('task-page-edit');
+    if (!form) return;
+    form.classList.toggle('hidden');
+    if (!form.classList.contains('hidden')) {
+        form.querySelector('input[name="description"]').focus();
+    } else {
+        form.reset();
+    }
+}
+
+function toggleProjectLinkEdit(linkId) {
+    var item = document.getElementById('project-link-' + linkId);
+    if (!item) return;
+    item.querySelector('.project-link-view').classList.toggle('hidden');
+    var form = item.querySelector('.project-link-form');
+    form.classList.toggle('hidden');
+    if (form.classList.contains('hidden')) {
+        form.reset();
+    } else {
+        form.querySelector('input[name="title"]').focus();
+    }
+}
+
 function hideForm(button) {
     var formContainer = button.closest('.form-container');
     if (formContainer) {
@@ -337,8 +443,11 @@ function hideForm(button) {
 // Expose handlers for inline onclick attributes used by templates.
 window.showProjectForm = showProjectForm;
 window.showEditProjectForm = showEditProjectForm;
+window.showBatchTaskForm = showBatchTaskForm;
 window.showKanbanTaskForm = showKanbanTaskForm;
 window.toggleKanbanCardEdit = toggleKanbanCardEdit;
+window.toggleTaskPageEdit = toggleTaskPageEdit;
+window.toggleProjectLinkEdit = toggleProjectLinkEdit;
 window.hideForm = hideForm;
 
 // Handle HX-Redirect responses
@@ -348,3 +457,583 @@ document.addEventListener('htmx:beforeSwap', function(event) {
         window.location.href = redirect;
     }
 });
+
+// errorToast returns the error fragment the server sends htmx requests that
+// fail, or null if the response is something else.
+function errorToast(xhr) {
+    const template = document.createElement('template');
+    template.innerHTML = xhr.responseText || '';
+    return template.content.querySelector('.error-toast');
+}
+
+// Forms marked data-swap-errors render their own validation messages, so
+// swap in 400 responses instead of dropping them.
+document.addEventListener('htmx:beforeSwap', function(event) {
+    if (event.detail.xhr.status === 400 && event.detail.target.hasAttribute('data-swap-errors') &&
+        !errorToast(event.detail.xhr)) {
+        event.detail.shouldSwap = true;
+        event.detail.isError = false;
+    }
+});
+
+// Failed requests show the server's error fragment in the corner; server
+// errors name a request ID so they can be reported.
+document.addEventListener('htmx:responseError', function(event) {
+    const toast = errorToast(event.detail.xhr);
+    if (!toast) {
+        if (event.detail.xhr.status >= 500) {
+            alert(event.detail.xhr.responseText || 'internal server error');
+        }
+        return;
+    }
+    // Server errors stay until dismissed so the request ID can be copied.
+    showErrorToast(toast, event.detail.xhr.status < 500);
+});
+
+function showErrorToast(toast, autoDismiss) {
+    let container = document.querySelector('.error-toasts');
+    if (!container) {
+        container = document.createElement('div');
+        container.className = 'error-toasts';
+        document.body.appendChild(container);
+    }
+    container.appendChild(toast);
+    if (autoDismiss) {
+        setTimeout(function() { toast.remove(); }, 6000);
+    }
+}
+
+document.addEventListener('click', function(event) {
+    const button = event.target.closest('[data-action="dismiss-error"]');
+    if (button) button.closest('.error-toast').remove();
+});
+
+// Refresh the sidebar streak after task changes, local or remote.
+function refreshStreak() {
+    const streak = document.getElementById('sidebar-streak');
+    if (streak) htmx.trigger(streak, 'refresh');
+}
+
+document.addEventListener('htmx:afterRequest', function(event) {
+    let path = event.detail.pathInfo && event.detail.pathInfo.requestPath;
+    if (path && path.indexOf(basePath) === 0) path = path.slice(basePath.length);
+    if (event.detail.successful && path && path.indexOf('/api/tasks/') === 0) {
+        refreshStreak();
+    }
+    // Quick add may file the task on another board, or create the Inbox.
+    if (event.detail.successful && path === '/api/tasks/quick') {
+        refreshRegions(['#sidebar-projects', '.kanban-board', '.upcoming-list']);
+    }
+    if (event.detail.successful && path && /\/tasks\/batch$/.test(path)) {
+        refreshRegions(['.kanban-board']);
+    }
+    // An undone delete puts the task back wherever the page lists it.
+    if (event.detail.successful && path === '/api/tasks/undo') {
+        refreshRegions(['.kanban-board', '.upcoming-list', '.tasks-list']);
+    }
+    // Starting or stopping focus swaps the sidebar timer; the task page's
+    // Start/Stop button follows.
+    if (event.detail.successful && path && (path === '/api/focus/stop' || /^\/api\/tasks\/\d+\/focus$/.test(path))) {
+        refreshRegions(['.task-focus']);
+    }
+    // Parking a task, making it active again or no longer waiting on
+    // someone changes the open counts.
+    if (event.detail.successful && path && /^\/api\/tasks\/\d+\/(someday|waiting)$/.test(path)) {
+        refreshRegions(['#sidebar-projects', '#sidebar-upcoming-count']);
+    }
+    // Ticking off or unplanning a task updates the My Day list and count.
+    if (event.detail.successful && path && /^\/api\/(tasks\/\d+\/toggle|my-day\/\d+)$/.test(path)) {
+        refreshRegions(['.my-day-plan']);
+    }
+});
+
+// Focus timer: the server keeps the running session; the page only counts
+// up from its start, so every open tab and device shows the same time.
+function refreshFocus() {
+    const focus = document.getElementById('sidebar-focus');
+    if (focus) htmx.trigger(focus, 'refresh');
+}
+
+function formatFocusElapsed(ms) {
+    const total = Math.max(0, Math.floor(ms / 1000));
+    const hours = Math.floor(total / 3600);
+    const minutes = String(Math.floor(total / 60) % 60).padStart(2, '0');
+    const seconds = String(total % 60).padStart(2, '0');
+    return (hours ? hours + ':' + minutes : minutes) + ':' + seconds;
+}
+
+function tickFocusTimers() {
+    document.querySelectorAll('[data-focus-started]').forEach(function(timer) {
+        const elapsed = timer.querySelector('.focus-elapsed');
+        if (elapsed) elapsed.textContent = formatFocusElapsed(Date.now() - Date.parse(timer.dataset.focusStarted));
+    });
+}
+
+setInterval(tickFocusTimers, 1000);
+document.addEventListener('htmx:load', tickFocusTimers);
+
+// Undo toasts go away once the server would refuse the undo anyway.
+document.addEventListener('htmx:load', function(event) {
+    const elt = event.detail.elt;
+    const toasts = elt.matches('.undo-toast') ? [elt] : elt.querySelectorAll('.undo-toast');
+    toasts.forEach(function(toast) {
+        setTimeout(function() { toast.remove(); }, Number(toast.dataset.expires) * 1000);
+    });
+});
+
+// Theme toggle: send the theme on screen, which may come from the system
+// preference, so the server can flip it; apply the saved theme it returns.
+function currentTheme() {
+    const theme = document.documentElement.dataset.theme;
+    if (theme === 'light' || theme === 'dark') return theme;
+    return window.matchMedia('(prefers-color-scheme: dark)').matches ? 'dark' : 'light';
+}
+
+document.addEventListener('htmx:configRequest', function(event) {
+    if (event.detail.elt.hasAttribute('data-theme-toggle')) {
+        event.detail.parameters.current = currentTheme();
+    }
+});
+
+document.addEventListener('themeChanged', function(event) {
+    document.documentElement.dataset.theme = event.detail.value;
+});
+
+// Realtime collaboration: apply change events pushed by other clients.
+const realtimeClientId = Math.random().toString(36).slice(2) + Date.now().toString(36);
+const realtimeReconnectDelay = 3000;
+
+document.addEventListener('htmx:configRequest', function(event) {
+    event.detail.headers['X-Client-ID'] = realtimeClientId;
+});
+
+function initializeRealtime() {
+    if (!('WebSocket' in window)) return;
+
+    const kanbanPage = document.querySelector('.kanban-page');
+    const projectId = kanbanPage ? kanbanPage.dataset.projectId : '';
+    const scheme = window.location.protocol === 'https:' ? 'wss://' : 'ws://';
+    let url = scheme + window.location.host + basePath + '/ws';
+    if (projectId) url += '?project_id=' + encodeURIComponent(projectId);
+
+    const socket = new WebSocket(url);
+    socket.addEventListener('message', function(message) {
+        let event;
+        try {
+            event = JSON.parse(message.data);
+        } catch (_error) {
+            return;
+        }
+        if (event.origin === realtimeClientId) return;
+        applyRealtimeEvent(event);
+    });
+    socket.addEventListener('close', function() {
+        setTimeout(initializeRealtime, realtimeReconnectDelay);
+    });
+}
+
+function applyRealtimeEvent(event) {
+    if (event.entity === 'focus') {
+        refreshFocus();
+        refreshRegions(['.task-focus']);
+        return;
+    }
+    if (event.entity !== 'project') refreshStreak();
+
+    if (event.entity === 'project') {
+        const kanbanPage = document.querySelector('.kanban-page');
+        if (event.type === 'deleted' && kanbanPage && String(event.id) === kanbanPage.dataset.projectId) {
+            window.location.href = basePath + '/';
+            return;
+        }
+        refreshRegions(['#sidebar-projects', '.kanban-header', '.kanban-board']);
+        return;
+    }
+
+    if (event.type === 'deleted') {
+        const el = document.getElementById('task-' + event.id);
+        if (el) el.remove();
+        updateKanbanCounts();
+        refreshRegions(['#sidebar-projects', '#sidebar-upcoming-count', '.kanban-header', '.upcoming-list']);
+        return;
+    }
+
+    if (event.type === 'reordered' && event.status) {
+        const column = document.querySelector('.kanban-cards[data-status="' + event.status + '"]');
+        if (column && (event.ids || []).every(function(id) { return document.getElementById('task-' + id); })) {
+            event.ids.forEach(function(id) {
+                column.appendChild(document.getElementById('task-' + id));
+            });
+            updateKanbanCounts();
+            return;
+        }
+    }
+
+    refreshRegions(['#sidebar-projects', '#sidebar-upcoming-count', '.kanban-header', '.kanban-board', '.upcoming-list']);
+}
+
+// refreshRegions re-fetches the current page and swaps in the matching regions.
+function refreshRegions(selectors) {
+    const present = selectors.filter(function(sel) { return document.querySelector(sel); });
+    if (present.length === 0) return;
+
+    fetch(window.location.href, { headers: { 'X-Client-ID': realtimeClientId } })
+        .then(function(response) { return response.ok ? response.text() : null; })
+        .then(function(html) {
+            if (!html) return;
+            const doc = new DOMParser().parseFromString(html, 'text/html');
+            present.forEach(function(sel) {
+                const current = document.querySelector(sel);
+                const fresh = doc.querySelector(sel);
+                if (!current || !fresh) return;
+                current.replaceWith(fresh);
+                if (window.htmx) htmx.process(fresh);
+            });
+            initializeKanban();
+            initializeSidebarSortable();
+            initializeFormTriggers();
+        });
+}
+
+// Quick switcher: Ctrl+K (Cmd+K on macOS) opens a search box over every
+// project and open task, served by /api/quickfind.
+const quickFindDelay = 120;
+
+function initializeQuickFind() {
+    document.addEventListener('keydown', function(event) {
+        if ((event.ctrlKey || event.metaKey) && event.key.toLowerCase() === 'k') {
+            event.preventDefault();
+            openQuickFind();
+        }
+    });
+}
+
+function openQuickFind() {
+    let box = document.getElementById('quickfind');
+    if (!box) box = createQuickFind();
+    box.classList.remove('hidden');
+    const input = box.querySelector('input');
+    input.value = '';
+    input.focus();
+    loadQuickFind(box, '');
+}
+
+function closeQuickFind() {
+    const box = document.getElementById('quickfind');
+    if (box) box.classList.add('hidden');
+}
+
+function createQuickFind() {
+    const box = document.createElement('div');
+    box.id = 'quickfind';
+    box.className = 'quickfind hidden';
+    box.innerHTML = '<div class="quickfind-panel" role="dialog" aria-label="Jump to">' +
+        '<input type="text" class="quickfind-input" placeholder="Jump to a project or task…" autocomplete="off" aria-label="Jump to">' +
+        '<ul class="quickfind-results" role="listbox"></ul></div>';
+    document.body.appendChild(box);
+
+    const input = box.querySelector('input');
+    let timer = null;
+    input.addEventListener('input', function() {
+        clearTimeout(timer);
+        timer = setTimeout(function() { loadQuickFind(box, input.value); }, quickFindDelay);
+    });
+    input.addEventListener('keydown', function(event) {
+        if (event.key === 'Escape') {
+            closeQuickFind();
+        } else if (event.key === 'ArrowDown' || event.key === 'ArrowUp') {
+            event.preventDefault();
+            moveQuickFindSelection(box, event.key === 'ArrowDown' ? 1 : -1);
+        } else if (event.key === 'Enter') {
+            event.preventDefault();
+            const selected = box.querySelector('.quickfind-result.selected');
+            if (selected) window.location.href = selected.dataset.url;
+        }
+    });
+    box.addEventListener('click', function(event) {
+        const result = event.target.closest('.quickfind-result');
+        if (result) {
+            window.location.href = result.dataset.url;
+        } else if (event.target === box) {
+            closeQuickFind();
+        }
+    });
+    return box;
+}
+
+function loadQuickFind(box, query) {
+    fetch(basePath + '/api/quickfind?q=' + encodeURIComponent(query))
+        .then(function(response) { return response.ok ? response.json() : []; })
+        .then(function(results) {
+            if (box.querySelector('input').value !== query) return;
+            const list = box.querySelector('.quickfind-results');
+            list.innerHTML = '';
+            results.forEach(function(result, i) {
+                const item = document.createElement('li');
+                item.className = 'quickfind-result' + (i === 0 ? ' selected' : '');
+                item.setAttribute('role', 'option');
+                item.dataset.url = result.url;
+
+                const name = document.createElement('span');
+                name.className = 'quickfind-name';
+                name.textContent = result.name;
+                const meta = document.createElement('span');
+                meta.className = 'quickfind-meta';
+                meta.textContent = result.type === 'task' ? result.project : 'Project';
+
+                item.appendChild(name);
+                item.appendChild(meta);
+                list.appendChild(item);
+            });
+        });
+}
+
+function moveQuickFindSelection(box, step) {
+    const items = Array.from(box.querySelectorAll('.quickfind-result'));
+    if (items.length === 0) return;
+    let index = items.findIndex(function(item) { return item.classList.contains('selected'); });
+    if (index >= 0) items[index].classList.remove('selected');
+    index = (index + step + items.length) % items.length;
+    items[index].classList.add('selected');
+    items[index].scrollIntoView({ block: 'nearest' });
+}
+
+// Offline support: the service worker keeps the pages last seen so they open
+// without a connection. Task toggles and quick adds that can't reach the
+// server are queued here and replayed to /api/sync when it is back.
+// Workspaces under /w/{slug} share the origin, and so localStorage, so the
+// queue is kept per base path: each replays only to its own workspace.
+const syncQueueStorageKey = 'mytasks.sync.queue' + (basePath ? ':' + basePath : '');
+let syncInFlight = false;
+
+if ('serviceWorker' in navigator) {
+    window.addEventListener('load', function() {
+        navigator.serviceWorker.register(basePath + '/sw.js', { scope: basePath + '/' })
+            .catch(function() {});
+    });
+}
+
+document.addEventListener('DOMContentLoaded', function() {
+    applyQueuedMutations();
+    updateOfflineStatus();
+    flushSyncQueue();
+});
+window.addEventListener('online', function() {
+    updateOfflineStatus();
+    flushSyncQueue();
+});
+window.addEventListener('offline', updateOfflineStatus);
+
+function loadSyncQueue() {
+    try {
+        return JSON.parse(localStorage.getItem(syncQueueStorageKey)) || [];
+    } catch (_error) {
+        return [];
+    }
+}
+
+function saveSyncQueue(queue) {
+    localStorage.setItem(syncQueueStorageKey, JSON.stringify(queue));
+    updateOfflineStatus();
+}
+
+function queueMutation(mutation) {
+    mutation.id = window.crypto && crypto.randomUUID
+        ? crypto.randomUUID()
+        : Math.random().toString(36).slice(2) + Date.now().toString(36);
+    mutation.at = new Date().toISOString();
+    let queue = loadSyncQueue();
+    // Only the last completed state of a task matters.
+    if (mutation.type === 'complete') {
+        queue = queue.filter(function(m) {
+            return m.type !== 'complete' || m.task_id !== mutation.task_id;
+        });
+    }
+    queue.push(mutation);
+    saveSyncQueue(queue);
+}
+
+// A request that never reached the server is queued instead of lost.
+document.addEventListener('htmx:sendError', function(event) {
+    const elt = event.detail.elt;
+    let path = event.detail.pathInfo && event.detail.pathInfo.requestPath;
+    if (path && path.indexOf(basePath) === 0) path = path.slice(basePath.length);
+
+    const toggle = path && path.match(/^\/api\/tasks\/(\d+)\/toggle$/);
+    if (toggle) {
+        const taskId = Number(toggle[1]);
+        // Checkboxes have already flipped; the other toggles only complete.
+        const completed = elt.type === 'checkbox' ? elt.checked : true;
+        queueMutation({
+            type: 'complete',
+            task_id: taskId,
+            completed: completed,
+            base_revision: Number(elt.dataset.revision) || 0
+        });
+        markTaskPending(taskId, completed, elt);
+        return;
+    }
+
+    const edit = path && elt.tagName === 'FORM' && path.match(/^\/api\/tasks\/(\d+)$/);
+    if (edit) {
+        const fields = new FormData(elt);
+        queueMutation({
+            type: 'update',
+            task_id: Number(edit[1]),
+            base_revision: Number(elt.dataset.revision) || 0,
+            task: {
+                description: fields.get('description'),
+                notes: fields.get('notes') || '',
+                priority: fields.get('priority'),
+                status: fields.get('status'),
+                due_date: fields.get('due_date') || ''
+            }
+        });
+        const container = elt.closest('.form-container');
+        if (container) container.classList.add('hidden');
+        const item = document.getElementById('task-' + edit[1]);
+        if (item) item.classList.add('sync-pending');
+        return;
+    }
+
+    if (path === '/api/tasks/quick') {
+        const input = elt.querySelector('input[name="text"]');
+        if (!input || !input.value.trim()) return;
+        queueMutation({ type: 'add', text: input.value });
+        input.value = '';
+        let note = elt.querySelector('.quick-add-result');
+        if (!note) {
+            note = document.createElement('p');
+            note.className = 'quick-add-result';
+            elt.appendChild(note);
+        }
+        note.textContent = 'Saved offline; it will be added when you are back online.';
+    }
+});
+
+function markTaskPending(taskId, completed, elt) {
+    const checkbox = document.getElementById('checkbox-' + taskId);
+    if (checkbox) checkbox.checked = completed;
+    const item = document.getElementById('task-' + taskId) ||
+        (elt && elt.closest('.upcoming-task, .task-preview'));
+    if (!item) return;
+    item.classList.toggle('completed', completed);
+    item.classList.add('sync-pending');
+}
+
+// Pages may come from the service worker's cache, from before the changes
+// still queued; show those changes on them.
+function applyQueuedMutations() {
+    loadSyncQueue().forEach(function(m) {
+        if (m.type === 'complete') markTaskPending(m.task_id, m.completed, null);
+    });
+}
+
+function updateOfflineStatus() {
+    const pending = loadSyncQueue().length;
+    let banner = document.querySelector('.offline-banner');
+    if (navigator.onLine && pending === 0) {
+        if (banner) banner.remove();
+        return;
+    }
+    if (!banner) {
+        banner = document.createElement('div');
+        banner.className = 'offline-banner';
+        banner.setAttribute('role', 'status');
+        document.body.appendChild(banner);
+    }
+    const changes = pending === 1 ? '1 change' : pending + ' changes';
+    if (!navigator.onLine) {
+        banner.textContent = pending
+            ? 'Offline. ' + changes + ' will sync when you reconnect.'
+            : 'Offline. Changes will sync when you reconnect.';
+    } else {
+        banner.textContent = 'Syncing ' + changes + '…';
+    }
+}
+
+function flushSyncQueue() {
+    const queue = loadSyncQueue();
+    if (syncInFlight || queue.length === 0 || !navigator.onLine) return;
+
+    syncInFlight = true;
+    fetch(basePath + '/api/sync', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json', 'X-Client-ID': realtimeClientId },
+        body: JSON.stringify({ mutations: queue })
+    })
+        .then(function(response) { return response.ok ? response.json() : null; })
+        .then(function(body) {
+            if (!body) return;
+            const done = {};
+            const sent = {};
+            body.results.forEach(function(result) { done[result.id] = result; });
+            queue.forEach(function(m) { sent[m.id] = m; });
+            // Keep what was queued while the request was in flight.
+            saveSyncQueue(loadSyncQueue().filter(function(m) { return !done[m.id]; }));
+            body.results.forEach(function(result) {
+                if (result.status === 'rejected') showSyncRejection(result.error);
+                if (result.status === 'conflict') showSyncConflict(sent[result.id], result.conflict);
+            });
+            document.querySelectorAll('.sync-pending').forEach(function(el) {
+                el.classList.remove('sync-pending');
+            });
+            refreshStreak();
+            refreshRegions(['#sidebar-projects', '.kanban-board', '.upcoming-list', '.projects-grid']);
+        })
+        .catch(function() {})
+        .then(function() {
+            syncInFlight = false;
+            updateOfflineStatus();
+        });
+}
+
+// The task was changed elsewhere while this change waited. Nothing is
+// overwritten unless asked: "Keep mine" sends the change again on top of the
+// other one.
+function showSyncConflict(mutation, conflict) {
+    const toast = document.createElement('div');
+    toast.className = 'error-toast';
+    toast.setAttribute('role', 'alert');
+    const text = document.createElement('span');
+    text.textContent = '"' + conflict.server.description +
+        '" was changed elsewhere while you were offline, so your change was not saved.';
+    const keep = document.createElement('button');
+    keep.type = 'button';
+    keep.className = 'btn btn-sm btn-secondary';
+    keep.textContent = 'Keep mine';
+    keep.addEventListener('click', function() {
+        const retry = Object.assign({}, mutation, { base_revision: conflict.server.revision });
+        queueMutation(retry);
+        toast.remove();
+        flushSyncQueue();
+    });
+    const dismiss = document.createElement('button');
+    dismiss.type = 'button';
+    dismiss.className = 'error-toast-dismiss';
+    dismiss.dataset.action = 'dismiss-error';
+    dismiss.setAttribute('aria-label', 'Keep theirs');
+    dismiss.title = 'Keep theirs';
+    dismiss.innerHTML = '&times;';
+    toast.appendChild(text);
+    toast.appendChild(keep);
+    toast.appendChild(dismiss);
+    showErrorToast(toast, false);
+}
+
+function showSyncRejection(message) {
+    const toast = document.createElement('div');
+    toast.className = 'error-toast';
+    toast.setAttribute('role', 'alert');
+    const text = document.createElement('span');
+    text.textContent = 'A change made offline was not saved: ' + message;
+    const dismiss = document.createElement('button');
+    dismiss.type = 'button';
+    dismiss.className = 'error-toast-dismiss';
+    dismiss.dataset.action = 'dismiss-error';
+    dismiss.setAttribute('aria-label', 'Dismiss');
+    dismiss.innerHTML = '&times;';
+    toast.appendChild(text);
+    toast.appendChild(dismiss);
+    showErrorToast(toast, false);
+}
@@ -0,0 +1,130 @@
+// Service worker: keeps the pages and assets last seen so the app opens and
+// shows tasks without a connection. Changes made offline are queued by
+// app.js and replayed to /api/sync; this worker never caches or replays
+// anything but GET requests.
+
+const cacheName = 'mytasks-v2';
+
+// The path prefix the app is served under, from the scope it registered.
+const basePath = new URL(self.registration.scope).pathname.replace(/\/$/, '');
+
+// The home page redirects, so pages are only kept once opened.
+const shell = [
+    '/static/css/styles.css',
+    '/static/js/app.js',
+    '/static/js/vendor/htmx.min.js',
+    '/static/js/vendor/Sortable.min.js',
+    '/static/icons/icon.svg',
+    '/manifest.webmanifest',
+].map(function(path) { return basePath + path; });
+
+// Pages link static files by a name with a hash of their contents in it
+// (see assets.go); those never change, so the cached copy is always right.
+const fingerprinted = /\.[0-9a-f]{10}\.\w+$/;
+
+// Paths that only make sense live, and attachments, which may be large.
+const uncached = ['/ws', '/api/sync', '/admin', '/debug', '/attachments'];
+
+self.addEventListener('install', function(event) {
+    event.waitUntil(
+        caches.open(cacheName)
+            .then(function(cache) { return cache.addAll(shell); })
+            .then(function() { return self.skipWaiting(); })
+    );
+});
+
+self.addEventListener('activate', function(event) {
+    event.waitUntil(
+        caches.keys()
+            .then(function(names) {
+                return Promise.all(names
+                    .filter(function(name) { return name !== cacheName; })
+                    .map(function(name) { return caches.delete(name); }));
+            })
+            .then(function() { return self.clients.claim(); })
+    );
+});
+
+self.addEventListener('fetch', function(event) {
+    const request = event.request;
+    const url = new URL(request.url);
+    if (request.method !== 'GET' || url.origin !== self.location.origin) return;
+    // htmx fragments share URLs with the pages, so only whole pages are kept.
+    if (request.headers.get('HX-Request')) return;
+
+    let path = url.pathname;
+    if (path.indexOf(basePath) !== 0) return;
+    path = path.slice(basePath.length);
+    if (uncached.some(function(prefix) { return path.indexOf(prefix) === 0; })) return;
+
+    if (path.indexOf('/static/') === 0 && fingerprinted.test(path)) {
+        event.respondWith(cacheFirst(request));
+    } else if (path.indexOf('/static/') === 0) {
+        event.respondWith(staleWhileRevalidate(event, request));
+    } else {
+        event.respondWith(networkFirst(request));
+    }
+});
+
+// Pages and fragments come from the network when it answers, so they are
+// never stale online; the copy kept is shown offline.
+function networkFirst(request) {
+    return fetch(request)
+        .then(function(response) {
+            if (response.ok) {
+                const copy = response.clone();
+                caches.open(cacheName).then(function(cache) { cache.put(request, copy); });
+            }
+            return response;
+        })
+        .catch(function() {
+            return caches.match(request).then(function(cached) {
+                if (cached) return cached;
+                if (request.mode === 'navigate') return offlinePage();
+                return Response.error();
+            });
+        });
+}
+
+function cacheFirst(request) {
+    return caches.open(cacheName).then(function(cache) {
+        return cache.match(request).then(function(cached) {
+            if (cached) return cached;
+            return fetch(request).then(function(response) {
+                if (response.ok) cache.put(request, response.clone());
+                return response;
+            });
+        });
+    });
+}
+
+// Other assets are served from the cache at once and refreshed behind it.
+function staleWhileRevalidate(event, request) {
+    return caches.open(cacheName).then(function(cache) {
+        return cache.match(request).then(function(cached) {
+            const fresh = fetch(request).then(function(response) {
+                if (response.ok) cache.put(request, response.clone());
+                return response;
+            });
+            if (cached) {
+                event.waitUntil(fresh.catch(function() {}));
+                return cached;
+            }
+            return fresh;
+        });
+    });
+}
+
+function offlinePage() {
+    const html = '<!DOCTYPE html><html><head><meta charset="UTF-8">' +
+        '<meta name="viewport" content="width=device-width, initial-scale=1.0">' +
+        '<title>Offline - My Tasks</title>' +
+        '<link rel="stylesheet" href="' + basePath + '/static/css/styles.css"></head>' +
+        '<body><div class="empty-state-page"><h2>You are offline</h2>' +
+        '<p>This page has not been opened on this device yet. Pages you have opened before still work.</p>' +
+        '<a class="btn btn-primary" href="javascript:history.back()">Back</a></div></body></html>';
+    return new Response(html, {
+        status: 503,
+        headers: { 'Content-Type': 'text/html; charset=utf-8' }
+    });
+}
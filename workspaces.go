@@ -0,0 +1,179 @@
+package mytasks
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"mytasks/internal/handlers"
+	"mytasks/internal/models"
+)
+
+// Tenancy modes, for Config.Tenancy.
+const (
+	// TenancyPath serves tenants under /w/{slug}, as workspaces are.
+	TenancyPath = "path"
+	// TenancySubdomain serves tenants at {slug}.TenantDomain.
+	TenancySubdomain = "subdomain"
+)
+
+// Workspace is a database of its own, such as "personal" next to "work",
+// served by its own copy of the app under BasePath/w/{Slug}. The store given
+// to NewServer is the main workspace, at BasePath itself.
+type Workspace struct {
+	// Slug names the workspace in URLs: lower-case letters, digits and
+	// dashes.
+	Slug string
+	// Name is shown in the workspace switcher; it defaults to the slug.
+	Name string
+	// DBPath is the database file, reported in the admin panel.
+	DBPath string
+	Store  *Store
+}
+
+var workspaceSlug = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// validateWorkspaces checks the slugs are well formed and distinct.
+func validateWorkspaces(workspaces []Workspace) error {
+	seen := make(map[string]bool, len(workspaces))
+	for _, ws := range workspaces {
+		if !workspaceSlug.MatchString(ws.Slug) {
+			return fmt.Errorf("invalid workspace name %q: use lower-case letters, digits and dashes", ws.Slug)
+		}
+		if seen[ws.Slug] {
+			return fmt.Errorf("workspace %q is defined twice", ws.Slug)
+		}
+		if ws.Store == nil {
+			return fmt.Errorf("workspace %q has no store", ws.Slug)
+		}
+		seen[ws.Slug] = true
+	}
+	return nil
+}
+
+// validateTenancy checks the tenancy mode is known and has the domain it
+// needs.
+func validateTenancy(tenancy, domain string) error {
+	switch tenancy {
+	case "", TenancyPath:
+		return nil
+	case TenancySubdomain:
+		if domain == "" {
+			return errors.New("TENANCY=subdomain requires TENANT_DOMAIN")
+		}
+		return nil
+	}
+	return fmt.Errorf("TENANCY: unknown mode %q, want %s or %s", tenancy, TenancyPath, TenancySubdomain)
+}
+
+// workspacePath is where a workspace is served.
+func workspacePath(basePath, slug string) string {
+	return basePath + "/w/" + slug
+}
+
+// workspaceConfig derives a workspace's configuration from the main one.
+// Backups go to a directory of their own, and Google Tasks sync is left to
+// the main workspace, since its OAuth redirect URL names a single one.
+// Tenants don't get the operator's mail server, alert recipients or Slack
+// and inbound email secrets either: their alerts and reports would go out
+// as the operator, to the operator.
+func workspaceConfig(cfg Config, ws Workspace) Config {
+	if cfg.Tenancy != TenancySubdomain {
+		cfg.BasePath = workspacePath(normalizeBasePath(cfg.BasePath), ws.Slug)
+	}
+	if cfg.Tenancy != "" {
+		cfg.SMTPHost, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom = "", "", "", ""
+		cfg.OverdueAlertEmail, cfg.DigestEmail = nil, nil
+		cfg.SlackSigningSecret = ""
+		cfg.InboundEmailSigningKey, cfg.InboundEmailAllowedSenders = "", nil
+	}
+	cfg.DBPath = ws.DBPath
+	cfg.Workspaces = nil
+	cfg.Tenancy, cfg.TenantDomain = "", ""
+	if cfg.BackupDir != "" {
+		cfg.BackupDir = filepath.Join(cfg.BackupDir, ws.Slug)
+	}
+	cfg.GoogleClientID, cfg.GoogleClientSecret, cfg.GoogleRedirectURL = "", "", ""
+	cfg.GoogleCalendarRedirectURL = ""
+	return cfg
+}
+
+// workspaceSwitcher returns the template function listing the workspaces
+// for the sidebar, marking current, the slug of the workspace the page
+// belongs to ("" for the main one). It lists nothing when there is only the
+// main workspace.
+func workspaceSwitcher(basePath string, workspaces []Workspace, current string) func() []models.WorkspaceLink {
+	if len(workspaces) == 0 {
+		return func() []models.WorkspaceLink { return nil }
+	}
+	links := []models.WorkspaceLink{{URL: basePath + "/", Current: current == ""}}
+	for _, ws := range workspaces {
+		name := ws.Name
+		if name == "" {
+			name = ws.Slug
+		}
+		links = append(links, models.WorkspaceLink{
+			Name:    name,
+			URL:     workspacePath(basePath, ws.Slug) + "/",
+			Current: ws.Slug == current,
+		})
+	}
+	return func() []models.WorkspaceLink { return links }
+}
+
+// workspaceFor returns the workspace server for a request path under
+// /w/{slug}, or nil for the main workspace. A bare /w/{slug} redirects to
+// its trailing-slash form, and answered reports it did.
+func (srv *Server) workspaceFor(w http.ResponseWriter, r *http.Request) (ws *Server, answered bool) {
+	rest, ok := strings.CutPrefix(r.URL.Path, srv.basePath+"/w/")
+	if !ok {
+		return nil, false
+	}
+	slug, _, hasSlash := strings.Cut(rest, "/")
+	if ws = srv.workspaces[slug]; ws == nil {
+		return nil, false
+	}
+	if !hasSlash {
+		http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+		return nil, true
+	}
+	return ws, false
+}
+
+// tenantFor returns the tenant server for a request to {slug}.TenantDomain,
+// or nil for the main workspace, at TenantDomain itself or any other host.
+// A subdomain naming no tenant is answered with 404.
+func (srv *Server) tenantFor(w http.ResponseWriter, r *http.Request) (ws *Server, answered bool) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	slug, ok := strings.CutSuffix(strings.ToLower(host), "."+srv.tenantDomain)
+	if !ok {
+		return nil, false
+	}
+	if ws = srv.workspaces[slug]; ws == nil {
+		http.Error(w, "unknown tenant", http.StatusNotFound)
+		return nil, true
+	}
+	return ws, false
+}
+
+// adminWorkspaces lists cfg.Workspaces for the main workspace's admin panel,
+// each linking to its own.
+func adminWorkspaces(cfg Config) []handlers.AdminWorkspace {
+	var list []handlers.AdminWorkspace
+	for _, ws := range cfg.Workspaces {
+		url := workspacePath(normalizeBasePath(cfg.BasePath), ws.Slug) + "/admin"
+		if cfg.Tenancy == TenancySubdomain {
+			url = "//" + ws.Slug + "." + cfg.TenantDomain + normalizeBasePath(cfg.BasePath) + "/admin"
+		}
+		list = append(list, handlers.AdminWorkspace{Name: cmp.Or(ws.Name, ws.Slug), URL: url, DBPath: ws.DBPath, Store: ws.Store})
+	}
+	return list
+}